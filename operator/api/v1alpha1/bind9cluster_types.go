@@ -0,0 +1,151 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// FunctionRating: 84/100
+// - Complexity: LOW
+// - Integrations: 1 (Kubernetes API machinery)
+// - External Risks: LOW (type definitions only)
+// - Unit Tests: NO
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: Bind9Cluster
+// Purpose: An operator-managed BIND9 primary with secondaries fed by zone transfer
+
+// Reasons reported on the Ready condition of a Bind9Cluster
+const (
+	// ReasonAvailable means the primary and every secondary are ready
+	ReasonAvailable = "Available"
+	// ReasonProgressing means some server is not ready yet or its Service
+	// has no address
+	ReasonProgressing = "Progressing"
+	// ReasonDeployFailed means a workload, Service or configuration could not be written
+	ReasonDeployFailed = "DeployFailed"
+)
+
+// Bind9ClusterSpec defines the desired state of Bind9Cluster
+type Bind9ClusterSpec struct {
+	// Image is the BIND9 container image
+	// +kubebuilder:default="internetsystemsconsortium/bind9:9.18"
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// Zones are served by the primary and transferred to every secondary
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=64
+	Zones []string `json:"zones"`
+
+	// Secondaries is the number of secondaries, each a Deployment with a
+	// Service of its own
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=8
+	// +kubebuilder:default=1
+	// +optional
+	Secondaries int32 `json:"secondaries,omitempty"`
+
+	// ServiceType is the type of the Services in front of the servers
+	// +kubebuilder:validation:Enum=ClusterIP;LoadBalancer
+	// +kubebuilder:default=ClusterIP
+	// +optional
+	ServiceType corev1.ServiceType `json:"serviceType,omitempty"`
+
+	// Storage is the size of the volume holding the zone files of the primary
+	// +kubebuilder:default="1Gi"
+	// +optional
+	Storage resource.Quantity `json:"storage,omitempty"`
+
+	// TSIGSecretName is the Secret in the namespace of the Bind9Cluster that
+	// holds the TSIG key allowed to update and transfer the zones, such as
+	// the Secret of a TSIGKey
+	TSIGSecretName string `json:"tsigSecretName"`
+
+	// TSIGSecretKey is the key of the TSIG secret in the Secret, default secret
+	// +optional
+	TSIGSecretKey string `json:"tsigSecretKey,omitempty"`
+
+	// TSIGKeyName is the name of the key; the key-name of the Secret takes
+	// precedence. Defaults to the name of the Bind9Cluster.
+	// +optional
+	TSIGKeyName string `json:"tsigKeyName,omitempty"`
+
+	// TSIGAlgorithm is the algorithm of the key; the algorithm of the Secret
+	// takes precedence. Defaults to hmac-sha256.
+	// +optional
+	TSIGAlgorithm string `json:"tsigAlgorithm,omitempty"`
+}
+
+// Bind9ClusterStatus defines the observed state of Bind9Cluster
+type Bind9ClusterStatus struct {
+	// ObservedGeneration is the generation the status was computed for
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Primary is the address of the Service of the primary
+	// +optional
+	Primary string `json:"primary,omitempty"`
+
+	// Secondaries are the addresses of the Services of the secondaries
+	// +optional
+	Secondaries []string `json:"secondaries,omitempty"`
+
+	// ReadySecondaries counts the secondaries with a ready pod
+	// +optional
+	ReadySecondaries int32 `json:"readySecondaries,omitempty"`
+
+	// Conditions represent the latest available observations of the cluster
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Primary",type=string,JSONPath=`.status.primary`
+// +kubebuilder:printcolumn:name="Secondaries",type=integer,JSONPath=`.status.readySecondaries`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// Bind9Cluster is the Schema for the bind9clusters API
+type Bind9Cluster struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   Bind9ClusterSpec   `json:"spec,omitempty"`
+	Status Bind9ClusterStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// Bind9ClusterList contains a list of Bind9Cluster
+type Bind9ClusterList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Bind9Cluster `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Bind9Cluster{}, &Bind9ClusterList{})
+}
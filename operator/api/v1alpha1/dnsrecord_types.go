@@ -0,0 +1,176 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// FunctionRating: 85/100
+// - Complexity: LOW
+// - Integrations: 1 (Kubernetes API machinery)
+// - External Risks: LOW (type definitions only)
+// - Unit Tests: NO
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: DNSRecord
+// Purpose: Declarative A/AAAA/CNAME/SRV records kept in sync on BIND9 servers via RFC2136
+
+// Condition types and reasons reported on a DNSRecord
+const (
+	// ConditionReady is true once every server holds the desired records
+	ConditionReady = "Ready"
+
+	// ReasonSynced means every server holds the desired records
+	ReasonSynced = "Synced"
+	// ReasonPartiallySynced means some servers failed to apply the records
+	ReasonPartiallySynced = "PartiallySynced"
+	// ReasonSyncFailed means no server applied the records
+	ReasonSyncFailed = "SyncFailed"
+	// ReasonInvalidSpec means the spec cannot be turned into records
+	ReasonInvalidSpec = "InvalidSpec"
+	// ReasonCredentialsUnavailable means the TSIG Secret could not be read
+	ReasonCredentialsUnavailable = "CredentialsUnavailable"
+)
+
+// DNSRecordSpec defines the desired state of DNSRecord
+type DNSRecordSpec struct {
+	// Name is the owner name of the records; it must lie inside Zone
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=253
+	Name string `json:"name"`
+
+	// Type is the record type
+	// +kubebuilder:validation:Enum=A;AAAA;CNAME;SRV
+	Type string `json:"type"`
+
+	// Values are the record data in zone file syntax, one record each, such
+	// as "192.0.2.10" for A or "10 5 443 app.example.com." for SRV
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=64
+	Values []string `json:"values"`
+
+	// TTL of the records in seconds
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=86400
+	// +kubebuilder:default=300
+	// +optional
+	TTL int32 `json:"ttl,omitempty"`
+
+	// Zone is the zone updates are sent for
+	// +kubebuilder:validation:MinLength=1
+	Zone string `json:"zone"`
+
+	// Servers are the addresses of the authoritative servers to keep in sync
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=32
+	Servers []string `json:"servers"`
+
+	// TSIGKeyName is the name of the TSIG key updates are signed with
+	TSIGKeyName string `json:"tsigKeyName"`
+
+	// TSIGAlgorithm is the algorithm of the TSIG key, default hmac-sha256
+	// +optional
+	TSIGAlgorithm string `json:"tsigAlgorithm,omitempty"`
+
+	// TSIGSecretName is the Secret in the namespace of the DNSRecord that
+	// holds the TSIG secret
+	TSIGSecretName string `json:"tsigSecretName"`
+
+	// TSIGSecretKey is the key of the TSIG secret in the Secret, default secret
+	// +optional
+	TSIGSecretKey string `json:"tsigSecretKey,omitempty"`
+
+	// Transport selects how updates are sent: udp, tcp or auto (default)
+	// +kubebuilder:validation:Enum=udp;tcp;auto
+	// +optional
+	Transport string `json:"transport,omitempty"`
+}
+
+// DNSRecordServerStatus is the sync state of the records on one server
+type DNSRecordServerStatus struct {
+	// Server is the address of the server
+	Server string `json:"server"`
+
+	// Synced reports whether the server accepted the last update
+	Synced bool `json:"synced"`
+
+	// Message explains why the last update failed
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// LastSyncTime is when the server last accepted the records
+	// +optional
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+}
+
+// DNSRecordStatus defines the observed state of DNSRecord
+type DNSRecordStatus struct {
+	// ObservedGeneration is the generation the status was computed for
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// AppliedName and AppliedType identify the RRset last written, so it can
+	// be removed when the spec moves the record to another name or type
+	// +optional
+	AppliedName string `json:"appliedName,omitempty"`
+	// +optional
+	AppliedType string `json:"appliedType,omitempty"`
+
+	// Servers holds the sync state of every server the records were written to
+	// +optional
+	// +listType=map
+	// +listMapKey=server
+	Servers []DNSRecordServerStatus `json:"servers,omitempty"`
+
+	// Conditions represent the latest available observations of the record
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Record",type=string,JSONPath=`.spec.name`
+// +kubebuilder:printcolumn:name="Type",type=string,JSONPath=`.spec.type`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// DNSRecord is the Schema for the dnsrecords API
+type DNSRecord struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   DNSRecordSpec   `json:"spec,omitempty"`
+	Status DNSRecordStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// DNSRecordList contains a list of DNSRecord
+type DNSRecordList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DNSRecord `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&DNSRecord{}, &DNSRecordList{})
+}
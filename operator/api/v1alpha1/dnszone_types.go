@@ -0,0 +1,231 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// FunctionRating: 84/100
+// - Complexity: LOW
+// - Integrations: 1 (Kubernetes API machinery)
+// - External Risks: LOW (type definitions only)
+// - Unit Tests: NO
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: DNSZone
+// Purpose: Zones declared to BIND9 through a rendered ConfigMap, with SOA and NS kept in sync via RFC2136
+
+// Reasons reported on the Ready condition of a DNSZone
+const (
+	// ReasonZoneSynced means every server serves the zone with the desired SOA and NS records
+	ReasonZoneSynced = "ZoneSynced"
+	// ReasonZoneNotLoaded means some server does not serve the zone yet,
+	// usually because BIND9 has not reloaded the rendered declaration
+	ReasonZoneNotLoaded = "ZoneNotLoaded"
+	// ReasonConfigFailed means the ConfigMap could not be written
+	ReasonConfigFailed = "ConfigFailed"
+)
+
+// DNSZoneSOA holds the SOA fields of a zone. Times are in seconds.
+type DNSZoneSOA struct {
+	// PrimaryNameserver is the MNAME of the SOA; defaults to the first of Nameservers
+	// +kubebuilder:validation:MaxLength=253
+	// +optional
+	PrimaryNameserver string `json:"primaryNameserver,omitempty"`
+
+	// Hostmaster is the mailbox of the person responsible for the zone, as a
+	// domain name such as hostmaster.example.com; defaults to hostmaster.<zone>
+	// +kubebuilder:validation:MaxLength=253
+	// +optional
+	Hostmaster string `json:"hostmaster,omitempty"`
+
+	// Refresh is how often secondaries check the zone for changes
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=3600
+	// +optional
+	Refresh int32 `json:"refresh,omitempty"`
+
+	// Retry is how long secondaries wait after a failed refresh
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=600
+	// +optional
+	Retry int32 `json:"retry,omitempty"`
+
+	// Expire is how long secondaries serve the zone without reaching a primary
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=604800
+	// +optional
+	Expire int32 `json:"expire,omitempty"`
+
+	// Minimum is the TTL of negative answers
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=86400
+	// +kubebuilder:default=300
+	// +optional
+	Minimum int32 `json:"minimum,omitempty"`
+}
+
+// DNSZoneSpec defines the desired state of DNSZone
+type DNSZoneSpec struct {
+	// Zone is the name of the zone
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=253
+	Zone string `json:"zone"`
+
+	// Servers are the addresses of the BIND9 primaries that load the rendered
+	// declaration and receive the SOA and NS updates
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=32
+	Servers []string `json:"servers"`
+
+	// Nameservers are the host names of the NS RRset at the zone apex
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=13
+	Nameservers []string `json:"nameservers"`
+
+	// SOA sets the fields of the SOA record
+	// +optional
+	SOA *DNSZoneSOA `json:"soa,omitempty"`
+
+	// TTL of the SOA and NS records in seconds
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=86400
+	// +kubebuilder:default=3600
+	// +optional
+	TTL int32 `json:"ttl,omitempty"`
+
+	// AlsoNotify are addresses, optionally with a port, of secondaries that
+	// are sent NOTIFY on every change
+	// +kubebuilder:validation:MaxItems=32
+	// +optional
+	AlsoNotify []string `json:"alsoNotify,omitempty"`
+
+	// AllowTransfer are address match list elements, such as 10.0.0.0/8 or
+	// key "other-key", that may transfer the zone besides the TSIG key
+	// +kubebuilder:validation:MaxItems=32
+	// +optional
+	AllowTransfer []string `json:"allowTransfer,omitempty"`
+
+	// File is the path of the zone file in the BIND9 container; defaults to
+	// /var/lib/bind/db.<zone>
+	// +kubebuilder:validation:MaxLength=1024
+	// +optional
+	File string `json:"file,omitempty"`
+
+	// ConfigMapName is the ConfigMap the zone statement and the seed zone
+	// file are rendered to. Defaults to the name of the DNSZone with a -zone suffix.
+	// +kubebuilder:validation:MaxLength=253
+	// +optional
+	ConfigMapName string `json:"configMapName,omitempty"`
+
+	// TSIGKeyName is the name of the TSIG key allowed to update and transfer
+	// the zone, and that the SOA and NS updates are signed with
+	TSIGKeyName string `json:"tsigKeyName"`
+
+	// TSIGAlgorithm is the algorithm of the TSIG key, default hmac-sha256
+	// +optional
+	TSIGAlgorithm string `json:"tsigAlgorithm,omitempty"`
+
+	// TSIGSecretName is the Secret in the namespace of the DNSZone that
+	// holds the TSIG secret
+	TSIGSecretName string `json:"tsigSecretName"`
+
+	// TSIGSecretKey is the key of the TSIG secret in the Secret, default secret
+	// +optional
+	TSIGSecretKey string `json:"tsigSecretKey,omitempty"`
+
+	// Transport selects how updates are sent: udp, tcp or auto (default)
+	// +kubebuilder:validation:Enum=udp;tcp;auto
+	// +optional
+	Transport string `json:"transport,omitempty"`
+}
+
+// DNSZoneServerStatus is the state of the zone on one server
+type DNSZoneServerStatus struct {
+	// Server is the address of the server
+	Server string `json:"server"`
+
+	// Loaded reports whether the server answers authoritatively for the zone
+	Loaded bool `json:"loaded"`
+
+	// Serial is the SOA serial the server serves
+	// +optional
+	Serial int64 `json:"serial,omitempty"`
+
+	// Message explains why the zone is not loaded or could not be updated
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// LastSyncTime is when the server last served the desired SOA and NS records
+	// +optional
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+}
+
+// DNSZoneStatus defines the observed state of DNSZone
+type DNSZoneStatus struct {
+	// ObservedGeneration is the generation the status was computed for
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Serial is the highest SOA serial served by any server
+	// +optional
+	Serial int64 `json:"serial,omitempty"`
+
+	// Servers holds the state of the zone on every server
+	// +optional
+	// +listType=map
+	// +listMapKey=server
+	Servers []DNSZoneServerStatus `json:"servers,omitempty"`
+
+	// Conditions represent the latest available observations of the zone
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Zone",type=string,JSONPath=`.spec.zone`
+// +kubebuilder:printcolumn:name="Serial",type=integer,JSONPath=`.status.serial`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// DNSZone is the Schema for the dnszones API
+type DNSZone struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   DNSZoneSpec   `json:"spec,omitempty"`
+	Status DNSZoneStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// DNSZoneList contains a list of DNSZone
+type DNSZoneList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DNSZone `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&DNSZone{}, &DNSZoneList{})
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// FunctionRating: 84/100
+// - Complexity: LOW
+// - Integrations: 1 (Kubernetes API machinery)
+// - External Risks: LOW (type definitions only)
+// - Unit Tests: NO
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: TSIGKey
+// Purpose: Generated TSIG keys with scheduled rotation, published as a Secret and a BIND9 key stanza
+
+// Reasons reported on the Ready condition of a TSIGKey
+const (
+	// ReasonKeyActive means the Secret and ConfigMap hold the active key
+	ReasonKeyActive = "KeyActive"
+	// ReasonRotating means a new key is published to the name servers and
+	// becomes active once the overlap window has passed
+	ReasonRotating = "Rotating"
+	// ReasonKeyFailed means the Secret or ConfigMap could not be written
+	ReasonKeyFailed = "KeyFailed"
+)
+
+// TSIGKeySpec defines the desired state of TSIGKey
+type TSIGKeySpec struct {
+	// KeyName is the base name of the key; every generated key is named
+	// v<version>.<KeyName>. Defaults to the name of the TSIGKey.
+	// +kubebuilder:validation:MaxLength=200
+	// +optional
+	KeyName string `json:"keyName,omitempty"`
+
+	// Algorithm is the HMAC algorithm of the generated keys. Changing it
+	// rotates the key.
+	// +kubebuilder:validation:Enum=hmac-sha1;hmac-sha224;hmac-sha256;hmac-sha384;hmac-sha512
+	// +kubebuilder:default=hmac-sha256
+	// +optional
+	Algorithm string `json:"algorithm,omitempty"`
+
+	// SecretName is the Secret the active key is written to, in the solver
+	// format: secret, key-name and algorithm. Defaults to the name of the TSIGKey.
+	// +kubebuilder:validation:MaxLength=253
+	// +optional
+	SecretName string `json:"secretName,omitempty"`
+
+	// ConfigMapName is the ConfigMap the BIND9 key statements are rendered
+	// to, under tsig.conf. Defaults to the name of the TSIGKey with a -bind suffix.
+	// +kubebuilder:validation:MaxLength=253
+	// +optional
+	ConfigMapName string `json:"configMapName,omitempty"`
+
+	// RotationInterval is how long a key stays active before a new one is
+	// generated; unset means the key is never rotated
+	// +optional
+	RotationInterval *metav1.Duration `json:"rotationInterval,omitempty"`
+
+	// OverlapWindow is how long a new key is published to the name servers
+	// before it becomes active, and how long the replaced key stays published
+	// afterwards. It must cover the time the name servers take to reload.
+	// +kubebuilder:default="10m"
+	// +optional
+	OverlapWindow *metav1.Duration `json:"overlapWindow,omitempty"`
+}
+
+// TSIGKeyStatus defines the observed state of TSIGKey
+type TSIGKeyStatus struct {
+	// ObservedGeneration is the generation the status was computed for
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Version counts the keys generated so far
+	// +optional
+	Version int64 `json:"version,omitempty"`
+
+	// ActiveKeyName is the name of the key clients sign updates with
+	// +optional
+	ActiveKeyName string `json:"activeKeyName,omitempty"`
+
+	// PendingKeyName is the name of the key published ahead of its activation
+	// at PendingActivationTime
+	// +optional
+	PendingKeyName string `json:"pendingKeyName,omitempty"`
+	// +optional
+	PendingActivationTime *metav1.Time `json:"pendingActivationTime,omitempty"`
+
+	// PreviousKeyName is the name of the replaced key, still published until
+	// PreviousExpiryTime
+	// +optional
+	PreviousKeyName string `json:"previousKeyName,omitempty"`
+	// +optional
+	PreviousExpiryTime *metav1.Time `json:"previousExpiryTime,omitempty"`
+
+	// LastRotationTime is when the active key became active
+	// +optional
+	LastRotationTime *metav1.Time `json:"lastRotationTime,omitempty"`
+
+	// NextRotationTime is when the next key will be generated
+	// +optional
+	NextRotationTime *metav1.Time `json:"nextRotationTime,omitempty"`
+
+	// Conditions represent the latest available observations of the key
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Key",type=string,JSONPath=`.status.activeKeyName`
+// +kubebuilder:printcolumn:name="Algorithm",type=string,JSONPath=`.spec.algorithm`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Next Rotation",type=date,JSONPath=`.status.nextRotationTime`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// TSIGKey is the Schema for the tsigkeys API
+type TSIGKey struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   TSIGKeySpec   `json:"spec,omitempty"`
+	Status TSIGKeyStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// TSIGKeyList contains a list of TSIGKey
+type TSIGKeyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []TSIGKey `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&TSIGKey{}, &TSIGKeyList{})
+}
@@ -0,0 +1,179 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// FunctionRating: 84/100
+// - Complexity: LOW
+// - Integrations: 1 (Kubernetes API machinery)
+// - External Risks: LOW (type definitions only)
+// - Unit Tests: NO
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: ZoneAudit
+// Purpose: Periodic comparison of the RRsets owned by DNSRecords against what every server of a zone serves
+
+// Reasons reported on the Ready condition of a ZoneAudit
+const (
+	// ReasonConsistent means every server serves the owned RRsets as desired
+	ReasonConsistent = "Consistent"
+	// ReasonDrifted means some server misses or serves different owned RRsets
+	ReasonDrifted = "Drifted"
+	// ReasonAuditFailed means some server could not be audited
+	ReasonAuditFailed = "AuditFailed"
+)
+
+// Methods a ZoneAudit reads the records of a server with
+const (
+	// AuditMethodAXFR means the zone was transferred in full
+	AuditMethodAXFR = "AXFR"
+	// AuditMethodQuery means the transfer was refused and every owned RRset was queried
+	AuditMethodQuery = "Query"
+)
+
+// ZoneAuditSpec defines the desired state of ZoneAudit
+type ZoneAuditSpec struct {
+	// Zone is the zone whose owned RRsets are audited
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=253
+	Zone string `json:"zone"`
+
+	// Servers are the addresses of the servers to audit. Every DNSRecord of
+	// the zone is checked on the servers it lists among these.
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=32
+	Servers []string `json:"servers"`
+
+	// TSIGKeyName is the name of the TSIG key the transfers and repairs are signed with
+	TSIGKeyName string `json:"tsigKeyName"`
+
+	// TSIGAlgorithm is the algorithm of the TSIG key, default hmac-sha256
+	// +optional
+	TSIGAlgorithm string `json:"tsigAlgorithm,omitempty"`
+
+	// TSIGSecretName is the Secret in the namespace of the ZoneAudit that
+	// holds the TSIG secret
+	TSIGSecretName string `json:"tsigSecretName"`
+
+	// TSIGSecretKey is the key of the TSIG secret in the Secret, default secret
+	// +optional
+	TSIGSecretKey string `json:"tsigSecretKey,omitempty"`
+
+	// Transport selects how repairs are sent: udp, tcp or auto (default)
+	// +kubebuilder:validation:Enum=udp;tcp;auto
+	// +optional
+	Transport string `json:"transport,omitempty"`
+
+	// Interval is how often the servers are audited, default 10m
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+
+	// AutoHeal rewrites the RRsets found missing or different on a server
+	// +optional
+	AutoHeal bool `json:"autoHeal,omitempty"`
+}
+
+// ZoneAuditServerStatus is the outcome of the last audit of one server
+type ZoneAuditServerStatus struct {
+	// Server is the address of the server
+	Server string `json:"server"`
+
+	// Method is how the records were read: AXFR, or Query when the transfer was refused
+	// +optional
+	Method string `json:"method,omitempty"`
+
+	// InSync reports whether the server serves every owned RRset as desired
+	InSync bool `json:"inSync"`
+
+	// Drift is the number of owned RRsets missing or different on the server
+	// +optional
+	Drift int32 `json:"drift,omitempty"`
+
+	// Differences names the drifted RRsets, capped to the first few
+	// +optional
+	Differences []string `json:"differences,omitempty"`
+
+	// Healed is the number of drifted RRsets rewritten by the last audit
+	// +optional
+	Healed int32 `json:"healed,omitempty"`
+
+	// Message explains why the server could not be audited or healed
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// ZoneAuditStatus defines the observed state of ZoneAudit
+type ZoneAuditStatus struct {
+	// ObservedGeneration is the generation the status was computed for
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// LastAuditTime is when the servers were last audited
+	// +optional
+	LastAuditTime *metav1.Time `json:"lastAuditTime,omitempty"`
+
+	// OwnedRRsets is the number of RRsets of the zone owned by DNSRecords
+	// +optional
+	OwnedRRsets int32 `json:"ownedRRsets,omitempty"`
+
+	// Servers holds the outcome of the last audit of every server
+	// +optional
+	// +listType=map
+	// +listMapKey=server
+	Servers []ZoneAuditServerStatus `json:"servers,omitempty"`
+
+	// Conditions represent the latest available observations of the audit
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Zone",type=string,JSONPath=`.spec.zone`
+// +kubebuilder:printcolumn:name="Owned",type=integer,JSONPath=`.status.ownedRRsets`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Reason",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].reason`
+// +kubebuilder:printcolumn:name="Last Audit",type=date,JSONPath=`.status.lastAuditTime`
+
+// ZoneAudit is the Schema for the zoneaudits API
+type ZoneAudit struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ZoneAuditSpec   `json:"spec,omitempty"`
+	Status ZoneAuditStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ZoneAuditList contains a list of ZoneAudit
+type ZoneAuditList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ZoneAudit `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ZoneAudit{}, &ZoneAuditList{})
+}
@@ -0,0 +1,239 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	cmacme "github.com/cert-manager/cert-manager/pkg/apis/acme/v1"
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+
+	"github.com/rieset/istio-dns01-bind9/internal/dns"
+	"github.com/rieset/istio-dns01-bind9/internal/solverconfig"
+)
+
+// FunctionRating: 76/100
+// - Complexity: MEDIUM
+// - Integrations: 2 (cert-manager API types, solverconfig)
+// - External Risks: LOW (offline conversion, no API calls)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: convertIssuer
+// Purpose: Rewrites cert-manager rfc2136 DNS01 solvers into equivalent multi-server webhook solvers
+
+// convertOptions control how rfc2136 solvers are rewritten
+type convertOptions struct {
+	GroupName  string
+	SolverName string
+	Zone       string
+	Servers    []string
+	TTL        int
+}
+
+// defaultRFC2136Algorithm is the TSIG algorithm of cert-manager's rfc2136
+// solver when it names none
+const defaultRFC2136Algorithm = "HMACMD5"
+
+// runConvertRFC2136 reads Issuers and ClusterIssuers and prints them with
+// rfc2136 solvers replaced by webhook solvers
+func runConvertRFC2136(args []string) error {
+	var input, servers string
+	var opts convertOptions
+	fs := flag.NewFlagSet("convert-rfc2136", flag.ExitOnError)
+	fs.StringVar(&input, "f", "-", "File with Issuer/ClusterIssuer manifests (YAML or JSON, multi-document). - reads stdin.")
+	fs.StringVar(&opts.GroupName, "group-name", "acme.example.com", "Webhook group name (GROUP_NAME of the webhook deployment).")
+	fs.StringVar(&opts.SolverName, "solver-name", "multi-dns", "Webhook solver name.")
+	fs.StringVar(&opts.Zone, "zone", "", "Zone for every converted solver. Derived from selector.dnsZones when empty.")
+	fs.StringVar(&servers, "servers", "", "Comma-separated additional DNS servers to update besides the rfc2136 nameserver.")
+	fs.IntVar(&opts.TTL, "ttl", 0, "TTL of challenge records. Uses the webhook default when 0.")
+	_ = fs.Parse(args)
+
+	if servers != "" {
+		opts.Servers = strings.Split(servers, ",")
+	}
+
+	var data []byte
+	var err error
+	if input == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(input)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read manifests: %w", err)
+	}
+
+	decoder := utilyaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+	first := true
+	for {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return fmt.Errorf("failed to decode manifest: %w", err)
+		}
+		if len(bytes.TrimSpace(raw)) == 0 || string(raw) == "null" {
+			continue
+		}
+
+		out, err := convertManifest(raw, opts)
+		if err != nil {
+			return err
+		}
+		if !first {
+			fmt.Println("---")
+		}
+		first = false
+		fmt.Print(string(out))
+	}
+	return nil
+}
+
+// convertManifest converts one Issuer or ClusterIssuer manifest and returns it as YAML
+func convertManifest(raw []byte, opts convertOptions) ([]byte, error) {
+	var meta struct {
+		Kind string `json:"kind"`
+	}
+	if err := json.Unmarshal(raw, &meta); err != nil {
+		return nil, fmt.Errorf("failed to read manifest kind: %w", err)
+	}
+
+	var obj any
+	var spec *cmapi.IssuerSpec
+	switch meta.Kind {
+	case cmapi.IssuerKind:
+		issuer := &cmapi.Issuer{}
+		if err := json.Unmarshal(raw, issuer); err != nil {
+			return nil, fmt.Errorf("failed to decode Issuer: %w", err)
+		}
+		obj, spec = issuer, &issuer.Spec
+	case cmapi.ClusterIssuerKind:
+		issuer := &cmapi.ClusterIssuer{}
+		if err := json.Unmarshal(raw, issuer); err != nil {
+			return nil, fmt.Errorf("failed to decode ClusterIssuer: %w", err)
+		}
+		obj, spec = issuer, &issuer.Spec
+	default:
+		return nil, fmt.Errorf("unsupported kind %q, expected Issuer or ClusterIssuer", meta.Kind)
+	}
+
+	if spec.ACME != nil {
+		solvers, err := convertSolvers(spec.ACME.Solvers, opts)
+		if err != nil {
+			return nil, err
+		}
+		spec.ACME.Solvers = solvers
+	}
+	return yaml.Marshal(obj)
+}
+
+// convertSolvers replaces every rfc2136 solver with one webhook solver per zone
+func convertSolvers(solvers []cmacme.ACMEChallengeSolver, opts convertOptions) ([]cmacme.ACMEChallengeSolver, error) {
+	var converted []cmacme.ACMEChallengeSolver
+	for i, solver := range solvers {
+		if solver.DNS01 == nil || solver.DNS01.RFC2136 == nil {
+			converted = append(converted, solver)
+			continue
+		}
+
+		zones := []string{opts.Zone}
+		perZoneSelector := false
+		if opts.Zone == "" {
+			if solver.Selector == nil || len(solver.Selector.DNSZones) == 0 {
+				return nil, fmt.Errorf("solver %d: zone cannot be derived from selector.dnsZones, pass -zone", i)
+			}
+			zones = solver.Selector.DNSZones
+			perZoneSelector = len(zones) > 1
+		}
+
+		for _, zone := range zones {
+			webhookSolver, err := toWebhookSolver(solver, zone, opts)
+			if err != nil {
+				return nil, fmt.Errorf("solver %d: %w", i, err)
+			}
+			if perZoneSelector {
+				selector := *solver.Selector
+				selector.DNSZones = []string{zone}
+				webhookSolver.Selector = &selector
+			}
+			converted = append(converted, webhookSolver)
+		}
+	}
+	return converted, nil
+}
+
+// toWebhookSolver builds the webhook equivalent of an rfc2136 solver for zone
+func toWebhookSolver(solver cmacme.ACMEChallengeSolver, zone string, opts convertOptions) (cmacme.ACMEChallengeSolver, error) {
+	rfc := solver.DNS01.RFC2136
+
+	name := rfc.TSIGAlgorithm
+	if name == "" {
+		name = defaultRFC2136Algorithm
+	}
+	algorithm, err := dns.ParseTSIGAlgorithm(name, true)
+	if err != nil {
+		return cmacme.ACMEChallengeSolver{}, fmt.Errorf("unsupported tsigAlgorithm %q: %w", name, err)
+	}
+	// Keys that only worked with a deprecated algorithm keep working after the switch
+	_, strictErr := dns.ParseTSIGAlgorithm(name, false)
+	if rfc.TSIGKeyName == "" || rfc.TSIGSecret.Name == "" {
+		return cmacme.ACMEChallengeSolver{}, fmt.Errorf("unauthenticated rfc2136 solvers cannot be converted, the webhook requires TSIG")
+	}
+
+	servers := append([]string{rfc.Nameserver}, opts.Servers...)
+	config := solverconfig.Config{
+		Servers:        servers,
+		Zone:           strings.TrimSuffix(zone, "."),
+		TSIGKeyName:    rfc.TSIGKeyName,
+		TSIGAlgorithm:  algorithm,
+		TSIGSecretName: rfc.TSIGSecret.Name,
+		TSIGSecretKey:  rfc.TSIGSecret.Key,
+		TTL:            opts.TTL,
+	}
+	config.AllowDeprecatedTSIG = errors.Is(strictErr, dns.ErrDeprecatedTSIGAlgorithm)
+	raw, err := json.Marshal(config)
+	if err != nil {
+		return cmacme.ACMEChallengeSolver{}, fmt.Errorf("failed to encode webhook config: %w", err)
+	}
+	if _, err := solverconfig.Parse(raw); err != nil {
+		return cmacme.ACMEChallengeSolver{}, fmt.Errorf("converted config is invalid: %w", err)
+	}
+
+	dns01 := solver.DNS01.DeepCopy()
+	dns01.RFC2136 = nil
+	dns01.Webhook = &cmacme.ACMEIssuerDNS01ProviderWebhook{
+		GroupName:  opts.GroupName,
+		SolverName: opts.SolverName,
+		Config:     &apiextensionsv1.JSON{Raw: raw},
+	}
+
+	return cmacme.ACMEChallengeSolver{Selector: solver.Selector, DNS01: dns01}, nil
+}
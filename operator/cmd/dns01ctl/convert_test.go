@@ -0,0 +1,136 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/rieset/istio-dns01-bind9/internal/solverconfig"
+)
+
+const rfc2136Issuer = `
+apiVersion: cert-manager.io/v1
+kind: ClusterIssuer
+metadata:
+  name: letsencrypt
+spec:
+  acme:
+    server: https://acme-v02.api.letsencrypt.org/directory
+    privateKeySecretRef:
+      name: letsencrypt
+    solvers:
+      - selector:
+          dnsZones: ["example.com", "example.org"]
+        dns01:
+          rfc2136:
+            nameserver: 192.0.2.1:53
+            tsigKeyName: acme-key
+            tsigAlgorithm: HMACSHA256
+            tsigSecretSecretRef:
+              name: tsig
+              key: secret
+      - http01:
+          ingress: {}
+`
+
+func TestConvertManifest(t *testing.T) {
+	out, err := convertManifest(mustJSON(t, rfc2136Issuer), convertOptions{
+		GroupName:  "acme.example.com",
+		SolverName: "multi-dns",
+		Servers:    []string{"192.0.2.2"},
+	})
+	if err != nil {
+		t.Fatalf("convertManifest: %v", err)
+	}
+
+	issuer := &cmapi.ClusterIssuer{}
+	if err := yaml.Unmarshal(out, issuer); err != nil {
+		t.Fatal(err)
+	}
+	solvers := issuer.Spec.ACME.Solvers
+	if len(solvers) != 3 {
+		t.Fatalf("got %d solvers, want one webhook solver per zone plus http01", len(solvers))
+	}
+
+	for i, zone := range []string{"example.com", "example.org"} {
+		webhook := solvers[i].DNS01.Webhook
+		if webhook == nil || solvers[i].DNS01.RFC2136 != nil {
+			t.Fatalf("solver %d was not converted: %+v", i, solvers[i].DNS01)
+		}
+		if got := solvers[i].Selector.DNSZones; len(got) != 1 || got[0] != zone {
+			t.Fatalf("solver %d selects zones %v, want [%s]", i, got, zone)
+		}
+		config, err := solverconfig.Parse(webhook.Config.Raw)
+		if err != nil {
+			t.Fatalf("solver %d config: %v", i, err)
+		}
+		if config.Zone != zone || config.TSIGAlgorithm != "hmac-sha256" ||
+			strings.Join(config.Servers, ",") != "192.0.2.1:53,192.0.2.2" {
+			t.Fatalf("solver %d config = %+v", i, config)
+		}
+	}
+	if solvers[2].HTTP01 == nil {
+		t.Fatal("http01 solver was not preserved")
+	}
+}
+
+func TestConvertManifestNeedsZone(t *testing.T) {
+	manifest := strings.Replace(rfc2136Issuer, `dnsZones: ["example.com", "example.org"]`, `dnsNames: ["a.example.com"]`, 1)
+	if _, err := convertManifest(mustJSON(t, manifest), convertOptions{}); err == nil ||
+		!strings.Contains(err.Error(), "-zone") {
+		t.Fatalf("convertManifest error = %v, want a hint to pass -zone", err)
+	}
+}
+
+func TestConvertManifestAlgorithms(t *testing.T) {
+	manifest := strings.Replace(rfc2136Issuer, "tsigAlgorithm: HMACSHA256", "tsigAlgorithm: HMACSHA1", 1)
+	out, err := convertManifest(mustJSON(t, manifest), convertOptions{Zone: "example.com"})
+	if err != nil {
+		t.Fatalf("convertManifest with HMACSHA1: %v", err)
+	}
+	issuer := &cmapi.ClusterIssuer{}
+	if err := yaml.Unmarshal(out, issuer); err != nil {
+		t.Fatal(err)
+	}
+	config, err := solverconfig.Parse(issuer.Spec.ACME.Solvers[0].DNS01.Webhook.Config.Raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.TSIGAlgorithm != "hmac-sha1" || !config.AllowDeprecatedTSIG {
+		t.Fatalf("HMACSHA1 converted to %q, allowDeprecatedTSIGAlgorithm %v", config.TSIGAlgorithm, config.AllowDeprecatedTSIG)
+	}
+
+	// cert-manager defaults to HMACMD5, which cannot sign updates anymore
+	manifest = strings.Replace(rfc2136Issuer, "            tsigAlgorithm: HMACSHA256\n", "", 1)
+	if _, err := convertManifest(mustJSON(t, manifest), convertOptions{Zone: "example.com"}); err == nil ||
+		!strings.Contains(err.Error(), `unsupported tsigAlgorithm "HMACMD5"`) {
+		t.Fatalf("convertManifest error = %v, want HMACMD5 rejected", err)
+	}
+}
+
+func mustJSON(t *testing.T, manifest string) []byte {
+	t.Helper()
+	raw, err := yaml.YAMLToJSON([]byte(manifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
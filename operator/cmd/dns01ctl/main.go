@@ -0,0 +1,1005 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Command dns01ctl performs the webhook's DNS operations by hand. It reads the
+// same solver configuration as the Issuer webhook stanza so operators can
+// reproduce and repair challenge records during incidents.
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	mdns "github.com/miekg/dns"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/yaml"
+
+	"github.com/rieset/istio-dns01-bind9/internal/dns"
+	"github.com/rieset/istio-dns01-bind9/internal/solverconfig"
+)
+
+// FunctionRating: 74/100
+// - Complexity: MEDIUM
+// - Integrations: 3 (dns package, solverconfig, kubernetes client)
+// - External Risks: MEDIUM (network operations, DNS server availability)
+// - Unit Tests: NO
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: main
+// Purpose: Manual add/delete/query/credential/propagation operations using the webhook solver config
+
+const usage = `Usage: dns01ctl <command> [flags]
+
+Commands:
+  add-txt              Add a TXT record on every configured server
+  delete-txt           Delete the TXT records at a name, or one value, on every configured server
+  add-record           Add records of any type on every configured server
+  delete-record        Delete an RRset, or some of its records, on every configured server
+  query                Print the records of a type (TXT by default) each configured server publishes
+  verify-credentials   Check with an empty update that every server accepts the key
+  preflight            Check with a no-op update that the TSIG key may update a name
+  verify-propagation   Wait until the configured servers agree on a TXT value
+  plan                 Diff desired records against each server's zone without changing it
+  convert-rfc2136      Rewrite cert-manager rfc2136 solvers in Issuers as webhook solvers
+
+Run "dns01ctl <command> -h" for the flags of a command.
+`
+
+// commonFlags are shared by every subcommand
+type commonFlags struct {
+	configPath      string
+	fqdn            string
+	timeout         time.Duration
+	tsigSecret      string
+	tsigSecretFile  string
+	sig0KeyFile     string
+	sig0PrivateFile string
+	kubeconfig      string
+	namespace       string
+	verbose         bool
+}
+
+// register adds the shared flags to fs
+func (c *commonFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&c.configPath, "config", "", "Path to the solver config (JSON or YAML, same schema as the Issuer webhook config).")
+	fs.StringVar(&c.fqdn, "fqdn", "", "Fully qualified record name, e.g. _acme-challenge.example.com.")
+	fs.DurationVar(&c.timeout, "timeout", 30*time.Second, "Overall timeout of the command.")
+	fs.StringVar(&c.tsigSecret, "tsig-secret", os.Getenv("TSIG_SECRET"),
+		"Base64 TSIG secret. Defaults to $TSIG_SECRET.")
+	fs.StringVar(&c.tsigSecretFile, "tsig-secret-file", "", "File containing the base64 TSIG secret.")
+	fs.StringVar(&c.sig0KeyFile, "sig0-key-file", "", "dnssec-keygen .key file of the SIG(0) key, for authMethod sig0.")
+	fs.StringVar(&c.sig0PrivateFile, "sig0-private-file", "", "dnssec-keygen .private file of the SIG(0) key.")
+	fs.StringVar(&c.kubeconfig, "kubeconfig", "",
+		"Read the TSIG secret from the cluster using this kubeconfig when no secret is given.")
+	fs.StringVar(&c.namespace, "namespace", "cert-manager", "Namespace of the TSIG secret when reading it from the cluster.")
+	fs.BoolVar(&c.verbose, "v", false, "Enable debug logging.")
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "add-txt":
+		err = runAddTXT(os.Args[2:])
+	case "delete-txt":
+		err = runDeleteTXT(os.Args[2:])
+	case "add-record":
+		err = runAddRecord(os.Args[2:])
+	case "delete-record":
+		err = runDeleteRecord(os.Args[2:])
+	case "query":
+		err = runQuery(os.Args[2:])
+	case "verify-credentials":
+		err = runVerifyCredentials(os.Args[2:])
+	case "preflight":
+		err = runPreflight(os.Args[2:])
+	case "verify-propagation":
+		err = runVerifyPropagation(os.Args[2:])
+	case "plan":
+		err = runPlan(os.Args[2:])
+	case "convert-rfc2136":
+		err = runConvertRFC2136(os.Args[2:])
+	case "-h", "--help", "help":
+		fmt.Fprint(os.Stdout, usage)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// runAddTXT adds a TXT record and succeeds when the servers the write policy requires accepted it
+func runAddTXT(args []string) error {
+	var common commonFlags
+	var value string
+	var ttl int
+	fs := flag.NewFlagSet("add-txt", flag.ExitOnError)
+	common.register(fs)
+	fs.StringVar(&value, "value", "", "TXT value to add.")
+	fs.IntVar(&ttl, "ttl", 0, "Record TTL in seconds. Defaults to the config TTL.")
+	_ = fs.Parse(args)
+
+	if value == "" {
+		return fmt.Errorf("-value is required")
+	}
+	config, err := loadConfig(common)
+	if err != nil {
+		return err
+	}
+	if ttl <= 0 {
+		ttl = config.TTL
+	}
+	clients, err := newClients(common, config)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), common.timeout)
+	defer cancel()
+
+	succeeded := 0
+	for _, server := range config.Servers {
+		if err := clients[server].AddTXTRecord(ctx, common.fqdn, value, ttl); err != nil {
+			fmt.Printf("%-30s FAILED  %v\n", server, err)
+			continue
+		}
+		succeeded++
+		fmt.Printf("%-30s OK\n", server)
+	}
+
+	required := config.RequiredWrites()
+	if succeeded < required {
+		return fmt.Errorf("only %d/%d servers accepted the update, need %d", succeeded, len(config.Servers), required)
+	}
+	return nil
+}
+
+// runDeleteTXT deletes the TXT records at a name, or only one value, and succeeds when at least one server accepted it
+func runDeleteTXT(args []string) error {
+	var common commonFlags
+	var value string
+	fs := flag.NewFlagSet("delete-txt", flag.ExitOnError)
+	common.register(fs)
+	fs.StringVar(&value, "value", "", "Delete only this TXT value. Defaults to the whole RRset.")
+	_ = fs.Parse(args)
+
+	config, err := loadConfig(common)
+	if err != nil {
+		return err
+	}
+	clients, err := newClients(common, config)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), common.timeout)
+	defer cancel()
+
+	succeeded := 0
+	for _, server := range config.Servers {
+		var err error
+		if value != "" {
+			err = clients[server].DeleteTXTRecordValue(ctx, common.fqdn, value)
+		} else {
+			err = clients[server].DeleteTXTRecord(ctx, common.fqdn)
+		}
+		if err != nil {
+			fmt.Printf("%-30s FAILED  %v\n", server, err)
+			continue
+		}
+		succeeded++
+		fmt.Printf("%-30s OK\n", server)
+	}
+
+	if succeeded == 0 {
+		return fmt.Errorf("no server accepted the deletion")
+	}
+	return nil
+}
+
+// runAddRecord adds records of one type in a single update per server and
+// succeeds when the servers the write policy requires accepted it
+func runAddRecord(args []string) error {
+	var common commonFlags
+	var rrtype string
+	var data stringList
+	var ttl int
+	fs := flag.NewFlagSet("add-record", flag.ExitOnError)
+	common.register(fs)
+	fs.StringVar(&rrtype, "type", "", "Record type, e.g. A, AAAA, CNAME or TXT.")
+	fs.Var(&data, "data", "Record data in zone file syntax, e.g. 192.0.2.1; repeat for several records.")
+	fs.IntVar(&ttl, "ttl", 0, "Record TTL in seconds. Defaults to the config TTL.")
+	_ = fs.Parse(args)
+
+	if len(data) == 0 {
+		return fmt.Errorf("-data is required")
+	}
+	config, err := loadConfig(common)
+	if err != nil {
+		return err
+	}
+	if ttl <= 0 {
+		ttl = config.TTL
+	}
+	records, err := parseRecords(common.fqdn, rrtype, data, ttl)
+	if err != nil {
+		return err
+	}
+	clients, err := newClients(common, config)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), common.timeout)
+	defer cancel()
+
+	succeeded := 0
+	for _, server := range config.Servers {
+		if err := clients[server].AddRecords(ctx, records); err != nil {
+			fmt.Printf("%-30s FAILED  %v\n", server, err)
+			continue
+		}
+		succeeded++
+		fmt.Printf("%-30s OK\n", server)
+	}
+
+	required := config.RequiredWrites()
+	if succeeded < required {
+		return fmt.Errorf("only %d/%d servers accepted the update, need %d", succeeded, len(config.Servers), required)
+	}
+	return nil
+}
+
+// runDeleteRecord deletes the RRset of a type at a name, or only the given
+// records, and succeeds when at least one server accepted it
+func runDeleteRecord(args []string) error {
+	var common commonFlags
+	var rrtype string
+	var data stringList
+	fs := flag.NewFlagSet("delete-record", flag.ExitOnError)
+	common.register(fs)
+	fs.StringVar(&rrtype, "type", "", "Record type, e.g. A, AAAA, CNAME or TXT.")
+	fs.Var(&data, "data", "Delete only the record with this data; repeat for several. Defaults to the whole RRset.")
+	_ = fs.Parse(args)
+
+	qtype, ok := mdns.StringToType[strings.ToUpper(rrtype)]
+	if !ok {
+		return fmt.Errorf("-type %q is not a record type", rrtype)
+	}
+	var records []mdns.RR
+	if len(data) > 0 {
+		var err error
+		if records, err = parseRecords(common.fqdn, rrtype, data, 0); err != nil {
+			return err
+		}
+	}
+	config, err := loadConfig(common)
+	if err != nil {
+		return err
+	}
+	clients, err := newClients(common, config)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), common.timeout)
+	defer cancel()
+
+	succeeded := 0
+	for _, server := range config.Servers {
+		var err error
+		if len(records) > 0 {
+			err = clients[server].DeleteRecords(ctx, records)
+		} else {
+			err = clients[server].DeleteRRset(ctx, common.fqdn, qtype)
+		}
+		if err != nil {
+			fmt.Printf("%-30s FAILED  %v\n", server, err)
+			continue
+		}
+		succeeded++
+		fmt.Printf("%-30s OK\n", server)
+	}
+
+	if succeeded == 0 {
+		return fmt.Errorf("no server accepted the deletion")
+	}
+	return nil
+}
+
+// parseRecords builds the records of type rrtype at fqdn from their data
+func parseRecords(fqdn, rrtype string, data []string, ttl int) ([]mdns.RR, error) {
+	if fqdn == "" {
+		return nil, fmt.Errorf("-fqdn is required")
+	}
+	if _, ok := mdns.StringToType[strings.ToUpper(rrtype)]; !ok {
+		return nil, fmt.Errorf("-type %q is not a record type", rrtype)
+	}
+	records := make([]mdns.RR, 0, len(data))
+	for _, value := range data {
+		rr, err := mdns.NewRR(fmt.Sprintf("%s %d IN %s %s", mdns.Fqdn(fqdn), ttl, strings.ToUpper(rrtype), value))
+		if err != nil || rr == nil {
+			return nil, fmt.Errorf("invalid %s record data %q: %v", rrtype, value, err)
+		}
+		records = append(records, rr)
+	}
+	return records, nil
+}
+
+// runQuery prints the records of a type published by each server
+func runQuery(args []string) error {
+	var common commonFlags
+	var rrtype string
+	fs := flag.NewFlagSet("query", flag.ExitOnError)
+	common.register(fs)
+	fs.StringVar(&rrtype, "type", "TXT", "Record type to query.")
+	_ = fs.Parse(args)
+
+	config, err := loadConfig(common)
+	if err != nil {
+		return err
+	}
+	if common.fqdn == "" {
+		return fmt.Errorf("-fqdn is required")
+	}
+	qtype, ok := mdns.StringToType[strings.ToUpper(rrtype)]
+	if !ok {
+		return fmt.Errorf("-type %q is not a record type", rrtype)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), common.timeout)
+	defer cancel()
+
+	failed := 0
+	for _, server := range config.Servers {
+		values, err := queryValues(ctx, server, common.fqdn, qtype, common.timeout)
+		if err != nil {
+			failed++
+			fmt.Printf("%-30s ERROR   %v\n", server, err)
+			continue
+		}
+		if len(values) == 0 {
+			fmt.Printf("%-30s (none)\n", server)
+			continue
+		}
+		fmt.Printf("%-30s %s\n", server, strings.Join(values, " "))
+	}
+
+	if failed == len(config.Servers) {
+		return fmt.Errorf("all servers failed to answer")
+	}
+	return nil
+}
+
+// queryValues returns the quoted TXT values or the rdata of the records of
+// type qtype at fqdn on server
+func queryValues(ctx context.Context, server, fqdn string, qtype uint16, timeout time.Duration) ([]string, error) {
+	if qtype == mdns.TypeTXT {
+		values, err := dns.QueryTXT(ctx, server, fqdn, timeout)
+		return quoteAll(values), err
+	}
+	records, err := dns.QueryRRset(ctx, server, fqdn, qtype, timeout)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]string, len(records))
+	for i, rr := range records {
+		values[i] = strings.TrimPrefix(rr.String(), rr.Header().String())
+	}
+	return values, nil
+}
+
+// runVerifyCredentials checks on every server that the key of the config is accepted
+func runVerifyCredentials(args []string) error {
+	var common commonFlags
+	fs := flag.NewFlagSet("verify-credentials", flag.ExitOnError)
+	common.register(fs)
+	_ = fs.Parse(args)
+
+	config, err := loadConfig(common)
+	if err != nil {
+		return err
+	}
+	// The check is made at the zone apex unless the zone is to be discovered
+	if common.fqdn == "" {
+		common.fqdn = config.Zone
+	}
+	clients, err := newClients(common, config)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), common.timeout)
+	defer cancel()
+
+	failed := 0
+	for _, server := range config.Servers {
+		if err := clients[server].VerifyCredentials(ctx); err != nil {
+			failed++
+			fmt.Printf("%-30s FAILED  %v\n", server, err)
+			continue
+		}
+		fmt.Printf("%-30s OK\n", server)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d/%d servers did not accept the credentials", failed, len(config.Servers))
+	}
+	return nil
+}
+
+// runPreflight checks on every server that the update-policy lets the key update the name
+func runPreflight(args []string) error {
+	var common commonFlags
+	fs := flag.NewFlagSet("preflight", flag.ExitOnError)
+	common.register(fs)
+	_ = fs.Parse(args)
+
+	config, err := loadConfig(common)
+	if err != nil {
+		return err
+	}
+	if common.fqdn == "" {
+		return fmt.Errorf("-fqdn is required")
+	}
+	clients, err := newClients(common, config)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), common.timeout)
+	defer cancel()
+
+	denied := 0
+	for _, server := range config.Servers {
+		err := clients[server].CheckUpdatePermission(ctx, common.fqdn)
+		switch {
+		case err == nil:
+			fmt.Printf("%-30s OK\n", server)
+		case errors.Is(err, dns.ErrUpdateNotAuthorized):
+			denied++
+			fmt.Printf("%-30s DENIED  %v\n", server, err)
+		default:
+			fmt.Printf("%-30s ERROR   %v\n", server, err)
+		}
+	}
+
+	if denied > 0 {
+		return fmt.Errorf("%d/%d servers refused the key", denied, len(config.Servers))
+	}
+	return nil
+}
+
+// runVerifyPropagation waits until enough servers report the expected state of a value
+func runVerifyPropagation(args []string) error {
+	var common commonFlags
+	var value string
+	var absent bool
+	var minMatches int
+	var interval time.Duration
+	var soaTiming bool
+	fs := flag.NewFlagSet("verify-propagation", flag.ExitOnError)
+	common.register(fs)
+	fs.StringVar(&value, "value", "", "TXT value to look for.")
+	fs.BoolVar(&absent, "absent", false, "Wait for the value to disappear instead of appearing.")
+	fs.IntVar(&minMatches, "min-matches", 0, "Number of servers that must match. 0 means all servers.")
+	fs.DurationVar(&interval, "interval", dns.DefaultPropagationInterval, "Delay between polls of a single server.")
+	fs.BoolVar(&soaTiming, "soa-timing", false,
+		"Derive -interval and -timeout from the zone's SOA timers on the first server.")
+	_ = fs.Parse(args)
+
+	if value == "" {
+		return fmt.Errorf("-value is required")
+	}
+	config, err := loadConfig(common)
+	if err != nil {
+		return err
+	}
+	if common.fqdn == "" {
+		return fmt.Errorf("-fqdn is required")
+	}
+
+	if soaTiming {
+		if err := discoverZone(common, config); err != nil {
+			return err
+		}
+		soa, err := dns.QuerySOA(context.Background(), config.Servers[0], config.ServerSettings(config.Servers[0]).Zone, 5*time.Second)
+		if err != nil {
+			return err
+		}
+		timing := dns.PropagationTimingFromSOA(soa)
+		interval, common.timeout = timing.Interval, timing.Timeout
+		fmt.Printf("SOA timing: interval %s, timeout %s\n", interval, common.timeout)
+	}
+
+	tlsConfigs, err := loadTLSConfigs(common, config)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), common.timeout)
+	defer cancel()
+
+	result, err := dns.WaitForPropagation(ctx, dns.PropagationCheck{
+		Servers:      config.Servers,
+		FQDN:         common.fqdn,
+		Value:        value,
+		Present:      !absent,
+		MinMatches:   minMatches,
+		Interval:     interval,
+		QueryTimeout: 5 * time.Second,
+		TLS:          tlsConfigs,
+	})
+	for _, server := range result.Matched {
+		fmt.Printf("%-30s MATCHED\n", server)
+	}
+	for _, server := range result.Pending {
+		fmt.Printf("%-30s PENDING\n", server)
+	}
+	return err
+}
+
+// desiredRecords is the file format of the records passed to plan
+type desiredRecords struct {
+	Records []struct {
+		Name   string   `json:"name"`
+		Type   string   `json:"type"`
+		TTL    int      `json:"ttl"`
+		Values []string `json:"values"`
+	} `json:"records"`
+}
+
+// runPlan prints the changes each server needs to match the desired records
+func runPlan(args []string) error {
+	var common commonFlags
+	var desiredPath string
+	var prune, queryOnly bool
+	fs := flag.NewFlagSet("plan", flag.ExitOnError)
+	common.register(fs)
+	fs.StringVar(&desiredPath, "desired", "", "YAML or JSON file listing the desired records.")
+	fs.BoolVar(&prune, "prune", false, "Plan deletion of live records of managed types that are not desired.")
+	fs.BoolVar(&queryOnly, "query", false, "Query each desired RRset instead of transferring the zone (no pruning).")
+	_ = fs.Parse(args)
+
+	config, err := loadConfig(common)
+	if err != nil {
+		return err
+	}
+	if err := discoverZone(common, config); err != nil {
+		return err
+	}
+	desired, err := loadDesiredRecords(desiredPath, config.Zone)
+	if err != nil {
+		return err
+	}
+
+	var secrets map[string]string
+	var signer *dns.SIG0Signer
+	var tlsConfigs map[string]*tls.Config
+	if !queryOnly {
+		if secrets, signer, err = loadCredentials(common, config); err != nil {
+			return err
+		}
+		if tlsConfigs, err = loadTLSConfigs(common, config); err != nil {
+			return err
+		}
+	}
+	logger, err := newLogger(common.verbose)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), common.timeout)
+	defer cancel()
+
+	for _, server := range config.Servers {
+		var live []mdns.RR
+		if queryOnly {
+			live, err = queryDesiredRRsets(ctx, server, desired, common.timeout)
+		} else {
+			live, err = newClient(config, server, secrets[server], signer, tlsConfigs, logger).TransferZone(ctx)
+		}
+		if err != nil {
+			return fmt.Errorf("server %s: %w", server, err)
+		}
+
+		changes := dns.PlanChanges(desired, live, prune && !queryOnly)
+		fmt.Printf("Server %s: %d change(s)\n", server, len(changes))
+		for _, change := range changes {
+			fmt.Println(change.String())
+		}
+	}
+	return nil
+}
+
+// loadDesiredRecords reads the desired records and checks they belong to zone
+func loadDesiredRecords(path, zone string) ([]mdns.RR, error) {
+	if path == "" {
+		return nil, fmt.Errorf("-desired is required")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read desired records: %w", err)
+	}
+	var file desiredRecords
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse desired records: %w", err)
+	}
+
+	var records []mdns.RR
+	for _, r := range file.Records {
+		name := mdns.Fqdn(r.Name)
+		if !mdns.IsSubDomain(mdns.Fqdn(zone), name) {
+			return nil, fmt.Errorf("record %s is outside zone %s", r.Name, zone)
+		}
+		ttl := r.TTL
+		if ttl <= 0 {
+			ttl = 300
+		}
+		for _, value := range r.Values {
+			rr, err := mdns.NewRR(fmt.Sprintf("%s %d IN %s %s", name, ttl, r.Type, value))
+			if err != nil {
+				return nil, fmt.Errorf("invalid record %s %s %s: %w", r.Name, r.Type, value, err)
+			}
+			records = append(records, rr)
+		}
+	}
+	return records, nil
+}
+
+// queryDesiredRRsets fetches the live counterpart of every desired RRset
+func queryDesiredRRsets(ctx context.Context, server string, desired []mdns.RR, timeout time.Duration) ([]mdns.RR, error) {
+	type rrset struct {
+		name   string
+		rrtype uint16
+	}
+	seen := map[rrset]bool{}
+	var live []mdns.RR
+	for _, rr := range desired {
+		key := rrset{name: rr.Header().Name, rrtype: rr.Header().Rrtype}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		records, err := dns.QueryRRset(ctx, server, key.name, key.rrtype, timeout)
+		if err != nil {
+			return nil, err
+		}
+		live = append(live, records...)
+	}
+	return live, nil
+}
+
+// loadConfig reads and validates the solver config file
+func loadConfig(common commonFlags) (*solverconfig.Config, error) {
+	if common.configPath == "" {
+		return nil, fmt.Errorf("-config is required")
+	}
+	data, err := os.ReadFile(common.configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	raw, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+	config, err := solverconfig.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	if config.Provider != solverconfig.ProviderRFC2136 {
+		return nil, fmt.Errorf("provider %q is not supported by dns01ctl, only %s", config.Provider, solverconfig.ProviderRFC2136)
+	}
+	if !config.OnlyRFC2136() {
+		return nil, fmt.Errorf("servers with a provider other than %s are not supported by dns01ctl", solverconfig.ProviderRFC2136)
+	}
+	return config, nil
+}
+
+// discoverZone narrows a config with zones to the entry enclosing -fqdn, then
+// sets the zone of a config that leaves it empty to the closest zone
+// enclosing -fqdn, asking the servers without a zone of their own in turn
+func discoverZone(common commonFlags, config *solverconfig.Config) error {
+	if len(config.Zones) > 0 {
+		if common.fqdn == "" {
+			return fmt.Errorf("-fqdn is required to pick an entry of zones")
+		}
+		selected, err := config.ForName(common.fqdn)
+		if err != nil {
+			return err
+		}
+		*config = *selected
+	}
+	if config.Zone != "" {
+		return nil
+	}
+	if common.fqdn == "" {
+		return fmt.Errorf("-fqdn is required to discover the zone when the config does not set one")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), common.timeout)
+	defer cancel()
+
+	var errs []error
+	for _, server := range config.Servers {
+		if config.ServerEntries[server].Zone != "" {
+			continue
+		}
+		soa, err := dns.DiscoverZone(ctx, server, common.fqdn, 5*time.Second)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		config.Zone = soa.Hdr.Name
+		return nil
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to discover the zone of %s: %w", common.fqdn, errors.Join(errs...))
+	}
+	config.Zone = config.ServerSettings(config.Servers[0]).Zone
+	return nil
+}
+
+// newClients builds one RFC2136 client per configured server
+func newClients(common commonFlags, config *solverconfig.Config) (map[string]*dns.RFC2136Client, error) {
+	if common.fqdn == "" {
+		return nil, fmt.Errorf("-fqdn is required")
+	}
+	if err := discoverZone(common, config); err != nil {
+		return nil, err
+	}
+	secrets, signer, err := loadCredentials(common, config)
+	if err != nil {
+		return nil, err
+	}
+	logger, err := newLogger(common.verbose)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfigs, err := loadTLSConfigs(common, config)
+	if err != nil {
+		return nil, err
+	}
+
+	clients := make(map[string]*dns.RFC2136Client, len(config.Servers))
+	for _, server := range config.Servers {
+		clients[server] = newClient(config, server, secrets[server], signer, tlsConfigs, logger)
+	}
+	return clients, nil
+}
+
+// newClient builds the RFC2136 client of server with its zone, key, mode,
+// transport, retry, prerequisite, timeout and TLS settings, signing with signer instead of TSIG when set
+func newClient(config *solverconfig.Config, server, secret string, signer *dns.SIG0Signer,
+	tlsConfigs map[string]*tls.Config, logger *zap.Logger) *dns.RFC2136Client {
+	settings := config.ServerSettings(server)
+	client := dns.NewRFC2136Client(server, settings.Zone, settings.TSIGKeyName, settings.TSIGAlgorithm, secret, logger)
+	client.SetQuirks(config.ServerQuirks(server))
+	client.SetTransport(config.DNSTransport())
+	client.SetRetryPolicy(config.RetryPolicy())
+	client.SetPrerequisites(config.UpdatePrerequisites())
+	client.SetTimeouts(config.ClientTimeouts(server))
+	if signer != nil {
+		client.SetSIG0Signer(signer)
+	}
+	if tlsConfig, ok := tlsConfigs[server]; ok {
+		client.SetTLSConfig(tlsConfig)
+	}
+	return client
+}
+
+// loadCredentials returns the TSIG secret of every server, or the SIG(0)
+// signer when the config uses the sig0 auth method
+func loadCredentials(common commonFlags, config *solverconfig.Config) (map[string]string, *dns.SIG0Signer, error) {
+	if !config.UsesSIG0() {
+		secrets, err := loadTSIGSecrets(common, config)
+		return secrets, nil, err
+	}
+	signer, err := loadSIG0Signer(common, config)
+	return nil, signer, err
+}
+
+// loadSIG0Signer reads the SIG(0) key pair from the key files or, without
+// them, from the config's Secret in the cluster
+func loadSIG0Signer(common commonFlags, config *solverconfig.Config) (*dns.SIG0Signer, error) {
+	if common.sig0KeyFile != "" || common.sig0PrivateFile != "" {
+		public, err := os.ReadFile(common.sig0KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read SIG(0) key file: %w", err)
+		}
+		private, err := os.ReadFile(common.sig0PrivateFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read SIG(0) private key file: %w", err)
+		}
+		return dns.NewSIG0Signer(string(public), string(private))
+	}
+	if common.kubeconfig == "" {
+		return nil, fmt.Errorf("no SIG(0) key given: use -sig0-key-file with -sig0-private-file or -kubeconfig")
+	}
+
+	client, err := newKubeClient(common)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), common.timeout)
+	defer cancel()
+
+	secret, err := client.CoreV1().Secrets(common.namespace).Get(ctx, config.SIG0.SecretName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get SIG(0) secret %s/%s: %w", common.namespace, config.SIG0.SecretName, err)
+	}
+	public, private := secret.Data[config.SIG0.PublicKeySecretKey], secret.Data[config.SIG0.PrivateKeySecretKey]
+	if len(public) == 0 || len(private) == 0 {
+		return nil, fmt.Errorf("secret %s/%s must hold %s and %s", common.namespace, config.SIG0.SecretName,
+			config.SIG0.PublicKeySecretKey, config.SIG0.PrivateKeySecretKey)
+	}
+	return dns.NewSIG0Signer(string(public), string(private))
+}
+
+// loadTSIGSecrets returns the TSIG secret of every server from the flag, a
+// file or the cluster, in that order. A secret given by flag or file is used
+// for all servers; from the cluster each server's own Secret is read.
+func loadTSIGSecrets(common commonFlags, config *solverconfig.Config) (map[string]string, error) {
+	secrets := make(map[string]string, len(config.Servers))
+	if common.tsigSecret != "" || common.tsigSecretFile != "" {
+		secret := common.tsigSecret
+		if secret == "" {
+			data, err := os.ReadFile(common.tsigSecretFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read TSIG secret file: %w", err)
+			}
+			secret = strings.TrimSpace(string(data))
+		}
+		for _, server := range config.Servers {
+			secrets[server] = secret
+		}
+		return secrets, nil
+	}
+	if common.kubeconfig == "" {
+		return nil, fmt.Errorf("no TSIG secret given: use -tsig-secret, -tsig-secret-file, $TSIG_SECRET or -kubeconfig")
+	}
+
+	client, err := newKubeClient(common)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), common.timeout)
+	defer cancel()
+
+	fetched := map[[2]string]string{}
+	for _, server := range config.Servers {
+		settings := config.ServerSettings(server)
+		ref := [2]string{settings.TSIGSecretName, settings.TSIGSecretKey}
+		if _, ok := fetched[ref]; !ok {
+			secret, err := client.CoreV1().Secrets(common.namespace).Get(ctx, ref[0], metav1.GetOptions{})
+			if err != nil {
+				return nil, fmt.Errorf("failed to get TSIG secret %s/%s: %w", common.namespace, ref[0], err)
+			}
+			data, ok := secret.Data[ref[1]]
+			if !ok {
+				return nil, fmt.Errorf("key %s not found in secret %s/%s", ref[1], common.namespace, ref[0])
+			}
+			fetched[ref] = string(data)
+		}
+		secrets[server] = fetched[ref]
+	}
+	return secrets, nil
+}
+
+// newKubeClient connects to the cluster of -kubeconfig
+func newKubeClient(common commonFlags) (kubernetes.Interface, error) {
+	restConfig, err := clientcmd.BuildConfigFromFlags("", common.kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+	client, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kubernetes client: %w", err)
+	}
+	return client, nil
+}
+
+// loadTLSConfigs returns the DNS-over-TLS settings of every server, reading
+// referenced TLS Secrets from the cluster; nil unless the config uses the tls transport
+func loadTLSConfigs(common commonFlags, config *solverconfig.Config) (map[string]*tls.Config, error) {
+	if config.DNSTransport() != dns.TransportTLS {
+		return nil, nil
+	}
+
+	var client kubernetes.Interface
+	configs := make(map[string]*tls.Config, len(config.Servers))
+	for _, server := range config.Servers {
+		settings := config.ServerTLSConfig(server)
+		var credentials map[string][]byte
+		if settings.SecretName != "" {
+			if common.kubeconfig == "" {
+				return nil, fmt.Errorf("TLS secret %s of server %s needs -kubeconfig", settings.SecretName, server)
+			}
+			if client == nil {
+				var err error
+				if client, err = newKubeClient(common); err != nil {
+					return nil, err
+				}
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), common.timeout)
+			secret, err := client.CoreV1().Secrets(common.namespace).Get(ctx, settings.SecretName, metav1.GetOptions{})
+			cancel()
+			if err != nil {
+				return nil, fmt.Errorf("failed to get TLS secret %s/%s: %w", common.namespace, settings.SecretName, err)
+			}
+			credentials = secret.Data
+		}
+		tlsConfig, err := dns.TLSClientConfig(credentials, settings.ServerName)
+		if err != nil {
+			return nil, fmt.Errorf("server %s: %w", server, err)
+		}
+		configs[server] = tlsConfig
+	}
+	return configs, nil
+}
+
+// newLogger returns a console logger, at debug level when verbose is set
+func newLogger(verbose bool) (*zap.Logger, error) {
+	cfg := zap.NewDevelopmentConfig()
+	if !verbose {
+		cfg.Level = zap.NewAtomicLevelAt(zap.WarnLevel)
+	}
+	return cfg.Build()
+}
+
+// quoteAll quotes every value for display
+func quoteAll(values []string) []string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = fmt.Sprintf("%q", v)
+	}
+	return quoted
+}
+
+// stringList collects the values of a repeatable flag
+type stringList []string
+
+// String implements flag.Value
+func (l *stringList) String() string {
+	return strings.Join(*l, ", ")
+}
+
+// Set implements flag.Value
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
@@ -0,0 +1,49 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import "testing"
+
+func TestParseRecords(t *testing.T) {
+	tests := []struct {
+		rrtype string
+		data   []string
+		want   []string
+		ok     bool
+	}{
+		{"A", []string{"192.0.2.1", "192.0.2.2"},
+			[]string{"www.example.com.\t300\tIN\tA\t192.0.2.1", "www.example.com.\t300\tIN\tA\t192.0.2.2"}, true},
+		{"cname", []string{"lb.example.net."}, []string{"www.example.com.\t300\tIN\tCNAME\tlb.example.net."}, true},
+		{"TXT", []string{`"v=spf1 -all"`}, []string{"www.example.com.\t300\tIN\tTXT\t\"v=spf1 -all\""}, true},
+		{"A", []string{"not-an-address"}, nil, false},
+		{"BOGUS", []string{"1"}, nil, false},
+	}
+	for _, tt := range tests {
+		records, err := parseRecords("www.example.com", tt.rrtype, tt.data, 300)
+		if (err == nil) != tt.ok {
+			t.Fatalf("parseRecords(%s, %v) error = %v", tt.rrtype, tt.data, err)
+		}
+		if len(records) != len(tt.want) {
+			t.Fatalf("parseRecords(%s, %v) = %v, want %v", tt.rrtype, tt.data, records, tt.want)
+		}
+		for i, rr := range records {
+			if rr.String() != tt.want[i] {
+				t.Errorf("record %d = %q, want %q", i, rr.String(), tt.want[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// FunctionRating: 80/100
+// - Complexity: LOW
+// - Integrations: 1 (Kubernetes API)
+// - External Risks: MEDIUM (a wrong lease setting lets two replicas write DNS at once)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: leaderElection
+// Purpose: Lease-based leader election of the reconcilers, configured by --leader-elect-* flags
+
+// inClusterNamespaceFile holds the namespace of the pod's service account
+const inClusterNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// leaderElection holds the --leader-elect flags. Only the reconcilers, and
+// the DNS worker pool they share, wait for the Lease; the admission webhook,
+// caches and probes run on every replica.
+type leaderElection struct {
+	enabled         bool
+	id              string
+	namespace       string
+	identity        string
+	leaseDuration   time.Duration
+	renewDeadline   time.Duration
+	retryPeriod     time.Duration
+	releaseOnCancel bool
+}
+
+// bindFlags registers the --leader-elect flags on fs
+func (l *leaderElection) bindFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&l.enabled, "leader-elect", false,
+		"Enable leader election for controller manager. "+
+			"Enabling this will ensure there is only one active controller manager.")
+	fs.StringVar(&l.id, "leader-elect-id", "5b98ccfb.istio-dns01-bind9.rieset.io",
+		"Name of the Lease the replicas compete for; managers sharing it share one leader")
+	fs.StringVar(&l.namespace, "leader-elect-namespace", "",
+		"Namespace of the Lease (default: the namespace of the pod)")
+	fs.StringVar(&l.identity, "leader-elect-identity", "",
+		"Holder identity recorded in the Lease (default: hostname with a random suffix)")
+	fs.DurationVar(&l.leaseDuration, "leader-elect-lease-duration", 15*time.Second,
+		"How long replicas wait after the last renewal before taking the Lease over")
+	fs.DurationVar(&l.renewDeadline, "leader-elect-renew-deadline", 10*time.Second,
+		"How long the leader keeps retrying to renew the Lease before it steps down")
+	fs.DurationVar(&l.retryPeriod, "leader-elect-retry-period", 2*time.Second,
+		"Time between attempts to acquire or renew the Lease")
+	fs.BoolVar(&l.releaseOnCancel, "leader-elect-release-on-cancel", true,
+		"Release the Lease on shutdown so another replica takes over without waiting for it to expire")
+}
+
+// validate checks that the durations let the leader renew in time
+func (l *leaderElection) validate() error {
+	if !l.enabled {
+		return nil
+	}
+	switch {
+	case l.id == "":
+		return errors.New("--leader-elect-id must not be empty")
+	case l.retryPeriod <= 0:
+		return errors.New("--leader-elect-retry-period must be positive")
+	case l.leaseDuration <= l.renewDeadline:
+		return fmt.Errorf("--leader-elect-lease-duration %s must be longer than --leader-elect-renew-deadline %s",
+			l.leaseDuration, l.renewDeadline)
+	case float64(l.renewDeadline) <= 1.2*float64(l.retryPeriod):
+		// The same bound client-go enforces, with its jitter factor of 1.2
+		return fmt.Errorf("--leader-elect-renew-deadline %s must be longer than 1.2 times --leader-elect-retry-period %s",
+			l.renewDeadline, l.retryPeriod)
+	}
+	return nil
+}
+
+// apply sets the leader election options of the manager. The Lease is built
+// here rather than by the manager so the holder identity can be chosen.
+func (l *leaderElection) apply(config *rest.Config, options *ctrl.Options) error {
+	options.LeaderElection = l.enabled
+	if !l.enabled {
+		return nil
+	}
+	namespace := l.namespace
+	if namespace == "" {
+		raw, err := os.ReadFile(inClusterNamespaceFile)
+		if err != nil {
+			return errors.New("--leader-elect-namespace is required when not running in a cluster")
+		}
+		namespace = strings.TrimSpace(string(raw))
+	}
+	identity := l.identity
+	if identity == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return fmt.Errorf("failed to get hostname for the leader election identity: %w", err)
+		}
+		identity = hostname + "_" + string(uuid.NewUUID())
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("failed to create leader election client: %w", err)
+	}
+	lock, err := resourcelock.New(resourcelock.LeasesResourceLock, namespace, l.id,
+		clientset.CoreV1(), clientset.CoordinationV1(), resourcelock.ResourceLockConfig{Identity: identity})
+	if err != nil {
+		return fmt.Errorf("failed to create leader election lock: %w", err)
+	}
+	options.LeaderElectionID = l.id
+	options.LeaderElectionNamespace = namespace
+	options.LeaderElectionResourceLockInterface = lock
+	options.LeaseDuration = &l.leaseDuration
+	options.RenewDeadline = &l.renewDeadline
+	options.RetryPeriod = &l.retryPeriod
+	options.LeaderElectionReleaseOnCancel = l.releaseOnCancel
+	return nil
+}
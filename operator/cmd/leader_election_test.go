@@ -0,0 +1,97 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"flag"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/rest"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestLeaderElectionValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{"disabled ignores durations", []string{"--leader-elect-lease-duration=1s"}, ""},
+		{"defaults", []string{"--leader-elect"}, ""},
+		{"lease not longer than renew", []string{"--leader-elect", "--leader-elect-lease-duration=10s"},
+			"must be longer than --leader-elect-renew-deadline"},
+		{"renew too close to retry", []string{"--leader-elect", "--leader-elect-retry-period=9s"},
+			"1.2 times --leader-elect-retry-period"},
+		{"zero retry", []string{"--leader-elect", "--leader-elect-retry-period=0s"}, "must be positive"},
+		{"empty id", []string{"--leader-elect", "--leader-elect-id="}, "must not be empty"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var l leaderElection
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			l.bindFlags(fs)
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			err := l.validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("validate: unexpected error %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("validate error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLeaderElectionApply(t *testing.T) {
+	var l leaderElection
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	l.bindFlags(fs)
+	err := fs.Parse([]string{"--leader-elect", "--leader-elect-namespace=operator-system",
+		"--leader-elect-identity=replica-a", "--leader-elect-lease-duration=30s"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var options ctrl.Options
+	if err := l.apply(&rest.Config{Host: "https://127.0.0.1:6443"}, &options); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if !options.LeaderElection || options.LeaderElectionNamespace != "operator-system" {
+		t.Fatalf("options = %+v, want leader election in operator-system", options)
+	}
+	if lock := options.LeaderElectionResourceLockInterface; lock == nil || lock.Identity() != "replica-a" ||
+		!strings.Contains(lock.Describe(), "operator-system/"+l.id) {
+		t.Fatalf("lock = %v, want the Lease %s held as replica-a", lock, l.id)
+	}
+	if *options.LeaseDuration != 30*time.Second || *options.RenewDeadline != 10*time.Second {
+		t.Fatalf("durations = %s/%s, want 30s/10s", *options.LeaseDuration, *options.RenewDeadline)
+	}
+
+	// Disabled, nothing but the switch is set
+	l.enabled = false
+	options = ctrl.Options{}
+	if err := l.apply(nil, &options); err != nil || options.LeaderElection || options.LeaderElectionResourceLockInterface != nil {
+		t.Fatalf("apply disabled = %v, %+v", err, options)
+	}
+}
@@ -21,12 +21,16 @@ import (
 	"flag"
 	"os"
 	"path/filepath"
+	"strings"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	cmv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	uberzap "go.uber.org/zap"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -36,8 +40,13 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/metrics/filters"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
-	
-	"github.com/rieset/istio-dns01-bind9/internal/webhook"
+
+	dnsv1alpha1 "github.com/rieset/istio-dns01-bind9/api/v1alpha1"
+	"github.com/rieset/istio-dns01-bind9/internal/admission"
+	"github.com/rieset/istio-dns01-bind9/internal/controller"
+	"github.com/rieset/istio-dns01-bind9/internal/devcert"
+	"github.com/rieset/istio-dns01-bind9/internal/dns"
+	"github.com/rieset/istio-dns01-bind9/internal/workpool"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -49,6 +58,9 @@ var (
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
+	utilruntime.Must(dnsv1alpha1.AddToScheme(scheme))
+	utilruntime.Must(cmv1.AddToScheme(scheme))
+
 	// +kubebuilder:scaffold:scheme
 }
 
@@ -57,22 +69,33 @@ func main() {
 	var metricsAddr string
 	var metricsCertPath, metricsCertName, metricsCertKey string
 	var webhookCertPath, webhookCertName, webhookCertKey string
-	var enableLeaderElection bool
+	var devSelfSignedCerts bool
+	var debugDNSFaults string
+	var leader leaderElection
 	var probeAddr string
 	var secureMetrics bool
 	var enableHTTP2 bool
 	var enableWebhookSolver bool
 	var webhookSolverPort int
+	var dnsWorkers, dnsWorkersPerZone int
+	var enableGatewayCertificates bool
+	var gatewayIssuer, gatewayIssuerKind string
+	var enableHostnameSync, enableGatewayAPI, enableServiceEntries bool
+	var hostnameSyncConfig, hostnameSyncSecretNamespace, hostnameSyncOwnerID string
+	var dnsRecordOwnerID string
+	var enableIssuerValidation, enableResourceValidation, resourceValidationProbe bool
+	var solverGroupName, solverName, solverDefaultsConfigMap string
 	var tlsOpts []func(*tls.Config)
 	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metrics endpoint binds to. "+
 		"Use :8443 for HTTPS or :8080 for HTTP, or leave as 0 to disable the metrics service.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
-	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
-		"Enable leader election for controller manager. "+
-			"Enabling this will ensure there is only one active controller manager.")
+	leader.bindFlags(flag.CommandLine)
 	flag.BoolVar(&secureMetrics, "metrics-secure", true,
 		"If set, the metrics endpoint is served securely via HTTPS. Use --metrics-secure=false to use HTTP instead.")
 	flag.StringVar(&webhookCertPath, "webhook-cert-path", "", "The directory that contains the webhook certificate.")
+	flag.BoolVar(&devSelfSignedCerts, "dev-self-signed-certs", false,
+		"Generate a self-signed webhook certificate for localhost when --webhook-cert-path is not set. "+
+			"For running the manager outside the cluster only.")
 	flag.StringVar(&webhookCertName, "webhook-cert-name", "tls.crt", "The name of the webhook certificate file.")
 	flag.StringVar(&webhookCertKey, "webhook-cert-key", "tls.key", "The name of the webhook key file.")
 	flag.StringVar(&metricsCertPath, "metrics-cert-path", "",
@@ -85,14 +108,79 @@ func main() {
 		"If set, enables cert-manager DNS01 webhook solver")
 	flag.IntVar(&webhookSolverPort, "webhook-solver-port", 8089,
 		"The port for the cert-manager webhook solver server")
+	flag.IntVar(&dnsWorkers, "dns-workers", 16,
+		"Size of the worker pool shared by all controllers for DNS updates")
+	flag.IntVar(&dnsWorkersPerZone, "dns-workers-per-zone", 4,
+		"Maximum number of pool workers a single zone may occupy at once")
+	flag.BoolVar(&enableGatewayCertificates, "enable-gateway-certificates", false,
+		"If set, creates cert-manager Certificates for the HTTPS servers of Istio Gateways. "+
+			"Requires the Istio CRDs to be installed.")
+	flag.StringVar(&gatewayIssuer, "gateway-issuer", "",
+		"Issuer used for Gateways without a cert-manager.io/cluster-issuer or cert-manager.io/issuer annotation. "+
+			"Without it such Gateways are ignored.")
+	flag.StringVar(&gatewayIssuerKind, "gateway-issuer-kind", "ClusterIssuer",
+		"Kind of --gateway-issuer: ClusterIssuer or Issuer")
+	flag.BoolVar(&enableGatewayAPI, "enable-gateway-api", false,
+		"If set, the Gateway certificate and hostname sync controllers also read Kubernetes Gateway API "+
+			"Gateways and HTTPRoutes (gateway.networking.k8s.io). Requires the Gateway API CRDs to be installed.")
+	flag.BoolVar(&enableHostnameSync, "enable-hostname-sync", false,
+		"If set, publishes A/AAAA records for the hosts of Istio Gateways and VirtualServices. "+
+			"Requires --hostname-sync-config and the Istio CRDs to be installed.")
+	flag.BoolVar(&enableServiceEntries, "enable-service-entries", false,
+		"If set, the hostname sync also publishes A/AAAA records for the hosts of Istio ServiceEntries annotated "+
+			controller.ServiceEntryPublishAnnotation+"=true, pointing at their static addresses")
+	flag.StringVar(&hostnameSyncConfig, "hostname-sync-config", "",
+		"Path to a solver config JSON file with the servers, zone and TSIG settings the hostname records are written with")
+	flag.StringVar(&hostnameSyncSecretNamespace, "hostname-sync-secret-namespace", "operator-system",
+		"Namespace of the TSIG Secret named in --hostname-sync-config")
+	flag.StringVar(&hostnameSyncOwnerID, "hostname-sync-owner-id", "default",
+		"Owner recorded in the TXT registry; operators sharing a zone need distinct IDs")
+	flag.StringVar(&dnsRecordOwnerID, "dnsrecord-owner-id", "",
+		"If set, DNSRecord records get a TXT ownership registry entry naming this owner, and records "+
+			"without one are never changed or deleted; empty disables the registry")
+	flag.BoolVar(&enableIssuerValidation, "enable-issuer-validation", false,
+		"If set, serves a validating admission webhook rejecting Issuers and ClusterIssuers whose config for "+
+			"the DNS01 webhook solver is invalid. Requires a ValidatingWebhookConfiguration and --webhook-cert-path.")
+	flag.BoolVar(&enableResourceValidation, "enable-resource-validation", false,
+		"If set, serves a validating admission webhook rejecting DNSRecords and DNSZones whose spec or TSIG Secret "+
+			"would fail to reconcile. Requires a ValidatingWebhookConfiguration and --webhook-cert-path.")
+	flag.BoolVar(&resourceValidationProbe, "resource-validation-probe", false,
+		"If set, --enable-resource-validation also sends the servers of a DNSRecord an UPDATE that changes "+
+			"nothing and rejects the record when they refuse its TSIG key")
+	flag.StringVar(&solverGroupName, "solver-group-name", "acme.example.com",
+		"GROUP_NAME of the webhook solver whose Issuer configs --enable-issuer-validation checks")
+	flag.StringVar(&solverName, "solver-name", "multi-dns",
+		"SOLVER_NAME of the webhook solver whose Issuer configs --enable-issuer-validation checks")
+	flag.StringVar(&solverDefaultsConfigMap, "solver-defaults-configmap", "",
+		"namespace/name of the DEFAULTS_CONFIGMAP of the webhook solver, merged under the Issuer configs "+
+			"--enable-issuer-validation checks")
 	opts := zap.Options{
 		Development: true,
 	}
+	flag.StringVar(&debugDNSFaults, "debug-dns-faults", "",
+		"Inject DNS faults for chaos testing, e.g. \"drop=20,delay=200ms,rcode=SERVFAIL:50,corrupt-tsig=10\". "+
+			"Never use in production.")
 	opts.BindFlags(flag.CommandLine)
 	flag.Parse()
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	if err := leader.validate(); err != nil {
+		setupLog.Error(err, "Invalid leader election flags")
+		os.Exit(1)
+	}
+	if err := dns.EnableFaultsFromSpec(debugDNSFaults); err != nil {
+		setupLog.Error(err, "Invalid --debug-dns-faults")
+		os.Exit(1)
+	}
+	if err := dns.ValidateOwner(dnsRecordOwnerID); err != nil {
+		setupLog.Error(err, "Invalid --dnsrecord-owner-id")
+		os.Exit(1)
+	}
+	if debugDNSFaults != "" {
+		setupLog.Info("WARNING: DNS fault injection enabled, do not use in production", "faults", debugDNSFaults)
+	}
+
 	// if the enable-http2 flag is false (the default), http/2 should be disabled
 	// due to its vulnerabilities. More specifically, disabling http/2 will
 	// prevent from being vulnerable to the HTTP/2 Stream Cancellation and
@@ -114,6 +202,15 @@ func main() {
 	// Initial webhook TLS options
 	webhookTLSOpts := tlsOpts
 
+	if devSelfSignedCerts && len(webhookCertPath) == 0 {
+		webhookCertPath = filepath.Join(os.TempDir(), "istio-dns01-bind9", "webhook-certs")
+		if err := devcert.WriteSelfSigned(webhookCertPath, webhookCertName, webhookCertKey, devcert.DefaultHosts); err != nil {
+			setupLog.Error(err, "Failed to generate development webhook certificate")
+			os.Exit(1)
+		}
+		setupLog.Info("Using self-signed development webhook certificate", "webhook-cert-path", webhookCertPath)
+	}
+
 	if len(webhookCertPath) > 0 {
 		setupLog.Info("Initializing webhook certificate watcher using provided certificates",
 			"webhook-cert-path", webhookCertPath, "webhook-cert-name", webhookCertName, "webhook-cert-key", webhookCertKey)
@@ -182,30 +279,150 @@ func main() {
 		})
 	}
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+	restConfig := ctrl.GetConfigOrDie()
+	mgrOptions := ctrl.Options{
 		Scheme:                 scheme,
 		Metrics:                metricsServerOptions,
 		WebhookServer:          webhookServer,
 		HealthProbeBindAddress: probeAddr,
-		LeaderElection:         enableLeaderElection,
-		LeaderElectionID:       "5b98ccfb.istio-dns01-bind9.rieset.io",
-		// LeaderElectionReleaseOnCancel defines if the leader should step down voluntarily
-		// when the Manager ends. This requires the binary to immediately end when the
-		// Manager is stopped, otherwise, this setting is unsafe. Setting this significantly
-		// speeds up voluntary leader transitions as the new leader don't have to wait
-		// LeaseDuration time first.
-		//
-		// In the default scaffold provided, the program ends immediately after
-		// the manager stops, so would be fine to enable this option. However,
-		// if you are doing or is intended to do any operation such as perform cleanups
-		// after the manager stops then its usage might be unsafe.
-		// LeaderElectionReleaseOnCancel: true,
-	})
+	}
+	// Reconcilers run on the leader only, so replicas never write the same
+	// records twice; the admission webhook is served by every replica
+	if err := leader.apply(restConfig, &mgrOptions); err != nil {
+		setupLog.Error(err, "unable to set up leader election")
+		os.Exit(1)
+	}
+	mgr, err := ctrl.NewManager(restConfig, mgrOptions)
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
 		os.Exit(1)
 	}
 
+	// dnsPool bounds DNS update concurrency across all reconcilers with per-zone fairness
+	dnsPool := workpool.New(dnsWorkers, dnsWorkersPerZone)
+	if err := mgr.Add(dnsPool); err != nil {
+		setupLog.Error(err, "unable to add DNS worker pool to manager")
+		os.Exit(1)
+	}
+
+	dnsLogger, err := uberzap.NewProduction()
+	if err != nil {
+		setupLog.Error(err, "unable to create DNS client logger")
+		os.Exit(1)
+	}
+	if err := (&controller.DNSRecordReconciler{
+		Client:  mgr.GetClient(),
+		Scheme:  mgr.GetScheme(),
+		Pool:    dnsPool,
+		Logger:  dnsLogger,
+		OwnerID: dnsRecordOwnerID,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "DNSRecord")
+		os.Exit(1)
+	}
+	if err := (&controller.DNSZoneReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+		Pool:   dnsPool,
+		Logger: dnsLogger,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "DNSZone")
+		os.Exit(1)
+	}
+	if err := (&controller.Bind9ClusterReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "Bind9Cluster")
+		os.Exit(1)
+	}
+	if err := (&controller.TSIGKeyReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "TSIGKey")
+		os.Exit(1)
+	}
+	if err := (&controller.ZoneAuditReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+		Pool:   dnsPool,
+		Logger: dnsLogger,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ZoneAudit")
+		os.Exit(1)
+	}
+	if enableGatewayCertificates {
+		if err := (&controller.GatewayReconciler{
+			Client:     mgr.GetClient(),
+			Scheme:     mgr.GetScheme(),
+			IssuerName: gatewayIssuer,
+			IssuerKind: gatewayIssuerKind,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "Gateway")
+			os.Exit(1)
+		}
+		if enableGatewayAPI {
+			if err := (&controller.GatewayReconciler{
+				Client:     mgr.GetClient(),
+				Scheme:     mgr.GetScheme(),
+				GVK:        controller.GatewayAPIGatewayGVK,
+				IssuerName: gatewayIssuer,
+				IssuerKind: gatewayIssuerKind,
+			}).SetupWithManager(mgr); err != nil {
+				setupLog.Error(err, "unable to create controller", "controller", "GatewayAPIGateway")
+				os.Exit(1)
+			}
+		}
+	}
+	if enableHostnameSync {
+		config, err := controller.LoadHostnameSyncConfig(hostnameSyncConfig)
+		if err != nil {
+			setupLog.Error(err, "Invalid --hostname-sync-config")
+			os.Exit(1)
+		}
+		if err := (&controller.HostnameReconciler{
+			Client:          mgr.GetClient(),
+			Config:          config,
+			SecretNamespace: hostnameSyncSecretNamespace,
+			OwnerID:         hostnameSyncOwnerID,
+			Pool:            dnsPool,
+			Logger:          dnsLogger,
+			GatewayAPI:      enableGatewayAPI,
+			ServiceEntries:  enableServiceEntries,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "HostnameSync")
+			os.Exit(1)
+		}
+	}
+	if enableIssuerValidation {
+		var defaults types.NamespacedName
+		if solverDefaultsConfigMap != "" {
+			namespace, name, ok := strings.Cut(solverDefaultsConfigMap, "/")
+			if !ok || namespace == "" || name == "" {
+				setupLog.Error(nil, "--solver-defaults-configmap must be namespace/name", "value", solverDefaultsConfigMap)
+				os.Exit(1)
+			}
+			defaults = types.NamespacedName{Namespace: namespace, Name: name}
+		}
+		if err := (&admission.IssuerValidator{
+			GroupName:         solverGroupName,
+			SolverName:        solverName,
+			DefaultsConfigMap: defaults,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "Issuer")
+			os.Exit(1)
+		}
+	}
+	if enableResourceValidation {
+		if err := (&admission.ResourceValidator{
+			ProbeUpdates: resourceValidationProbe,
+			Logger:       dnsLogger,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "DNSRecord")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
 	if metricsCertWatcher != nil {
@@ -232,6 +449,13 @@ func main() {
 		setupLog.Error(err, "unable to set up ready check")
 		os.Exit(1)
 	}
+	if enableIssuerValidation || enableResourceValidation {
+		// Every replica serves admission, leader or not, once its webhook server listens
+		if err := mgr.AddReadyzCheck("webhook", mgr.GetWebhookServer().StartedChecker()); err != nil {
+			setupLog.Error(err, "unable to set up webhook ready check")
+			os.Exit(1)
+		}
+	}
 
 	// Start cert-manager webhook solver if enabled
 	// Note: cert-manager webhook solver runs as a separate HTTP server
@@ -17,20 +17,28 @@ limitations under the License.
 package main
 
 import (
+	"fmt"
 	"os"
 
 	"github.com/rieset/istio-dns01-bind9/internal/webhook"
-	"go.uber.org/zap"
 )
 
 // main is the entry point for the cert-manager webhook solver
 // This should be run as a separate container or integrated into the main operator
 func main() {
-	logger, _ := zap.NewProduction()
+	opts, err := webhook.LoadOptions()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid webhook options: %v\n", err)
+		os.Exit(1)
+	}
+	logger, err := webhook.NewLogger(opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to create logger: %v\n", err)
+		os.Exit(1)
+	}
 	defer logger.Sync()
 
 	logger.Info("Starting cert-manager DNS01 webhook solver")
-	webhook.StartWebhookServer(logger)
+	webhook.StartWebhookServer(opts, logger)
 	os.Exit(0)
 }
-
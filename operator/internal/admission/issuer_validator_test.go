@@ -0,0 +1,146 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admission
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	cmacme "github.com/cert-manager/cert-manager/pkg/apis/acme/v1"
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const validConfig = `{"servers":["192.0.2.1"],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s"}`
+
+func issuerRequest(t *testing.T, kind string, solvers ...cmacme.ACMEChallengeSolver) admission.Request {
+	t.Helper()
+	spec := cmapi.IssuerSpec{IssuerConfig: cmapi.IssuerConfig{ACME: &cmacme.ACMEIssuer{Solvers: solvers}}}
+	var obj any = &cmapi.Issuer{ObjectMeta: metav1.ObjectMeta{Name: "letsencrypt", Namespace: "default"}, Spec: spec}
+	if kind == cmapi.ClusterIssuerKind {
+		obj = &cmapi.ClusterIssuer{ObjectMeta: metav1.ObjectMeta{Name: "letsencrypt"}, Spec: spec}
+	}
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+		Operation: admissionv1.Create,
+		Kind:      metav1.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: kind},
+		Object:    runtime.RawExtension{Raw: raw},
+	}}
+}
+
+func webhookSolver(group, solver, config string) cmacme.ACMEChallengeSolver {
+	wh := &cmacme.ACMEIssuerDNS01ProviderWebhook{GroupName: group, SolverName: solver}
+	if config != "" {
+		wh.Config = &apiextensionsv1.JSON{Raw: []byte(config)}
+	}
+	return cmacme.ACMEChallengeSolver{DNS01: &cmacme.ACMEChallengeSolverDNS01{Webhook: wh}}
+}
+
+func TestIssuerValidator(t *testing.T) {
+	v := &IssuerValidator{GroupName: "acme.example.com", SolverName: "multi-dns"}
+	tests := []struct {
+		name    string
+		kind    string
+		solvers []cmacme.ACMEChallengeSolver
+		// want lists substrings of the denial; empty means admitted
+		want []string
+	}{
+		{"valid", cmapi.IssuerKind, []cmacme.ACMEChallengeSolver{
+			webhookSolver("acme.example.com", "multi-dns", validConfig)}, nil},
+		{"no acme", cmapi.IssuerKind, nil, nil},
+		{"other solver", cmapi.ClusterIssuerKind, []cmacme.ACMEChallengeSolver{
+			webhookSolver("acme.example.com", "other", `{"bogus":true}`)}, nil},
+		{"http01", cmapi.IssuerKind, []cmacme.ACMEChallengeSolver{{HTTP01: &cmacme.ACMEChallengeSolverHTTP01{}}}, nil},
+		{"missing config", cmapi.IssuerKind, []cmacme.ACMEChallengeSolver{
+			webhookSolver("acme.example.com", "multi-dns", "")},
+			[]string{"spec.acme.solvers[0].dns01.webhook.config: config is empty"}},
+		{"every problem", cmapi.ClusterIssuerKind, []cmacme.ACMEChallengeSolver{
+			webhookSolver("acme.example.com", "multi-dns", validConfig),
+			webhookSolver("acme.example.com", "multi-dns",
+				`{"servers":["a:port"],"zone":"example.com","ttl":-5,"tsigKey":"k","tsigAlgorithm":"md5"}`)},
+			[]string{"spec.acme.solvers[1]", `unknown field "tsigKey"`, "servers[0]", "ttl -5 is out of range",
+				"tsigAlgorithm", "tsigSecretName is required"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := v.Handle(context.Background(), issuerRequest(t, tt.kind, tt.solvers...))
+			if len(tt.want) == 0 {
+				if !resp.Allowed {
+					t.Fatalf("denied: %s", resp.Result.Message)
+				}
+				return
+			}
+			if resp.Allowed {
+				t.Fatal("admitted, want denied")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(resp.Result.Message, want) {
+					t.Errorf("denial %q misses %q", resp.Result.Message, want)
+				}
+			}
+		})
+	}
+}
+
+func TestIssuerValidatorDelete(t *testing.T) {
+	v := &IssuerValidator{GroupName: "acme.example.com", SolverName: "multi-dns"}
+	req := issuerRequest(t, cmapi.IssuerKind, webhookSolver("acme.example.com", "multi-dns", `{}`))
+	req.Operation = admissionv1.Delete
+	if resp := v.Handle(context.Background(), req); !resp.Allowed {
+		t.Fatalf("delete denied: %s", resp.Result.Message)
+	}
+}
+
+func TestIssuerValidatorDefaults(t *testing.T) {
+	defaults := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "dns01-defaults", Namespace: "cert-manager"},
+		Data: map[string]string{"config.json": `{"servers":["192.0.2.1"],"tsigKeyName":"k",` +
+			`"tsigSecretName":"s"}`},
+	}
+	v := &IssuerValidator{
+		GroupName:         "acme.example.com",
+		SolverName:        "multi-dns",
+		Reader:            fake.NewClientBuilder().WithObjects(defaults).Build(),
+		DefaultsConfigMap: types.NamespacedName{Namespace: "cert-manager", Name: "dns01-defaults"},
+	}
+
+	req := issuerRequest(t, cmapi.IssuerKind,
+		webhookSolver("acme.example.com", "multi-dns", `{"zone":"example.com"}`),
+		webhookSolver("acme.example.com", "multi-dns", ""))
+	if resp := v.Handle(context.Background(), req); !resp.Allowed {
+		t.Fatalf("config completed by the defaults denied: %s", resp.Result.Message)
+	}
+
+	req = issuerRequest(t, cmapi.IssuerKind,
+		webhookSolver("acme.example.com", "multi-dns", `{"servers":null}`))
+	resp := v.Handle(context.Background(), req)
+	if resp.Allowed || !strings.Contains(resp.Result.Message, "servers list is required") {
+		t.Fatalf("config dropping the default servers: allowed %v, %v", resp.Allowed, resp.Result)
+	}
+}
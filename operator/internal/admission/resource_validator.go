@@ -0,0 +1,234 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admission
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	dnsv1alpha1 "github.com/rieset/istio-dns01-bind9/api/v1alpha1"
+	"github.com/rieset/istio-dns01-bind9/internal/controller"
+	rfc2136 "github.com/rieset/istio-dns01-bind9/internal/dns"
+	"github.com/rieset/istio-dns01-bind9/internal/solverconfig"
+)
+
+// FunctionRating: 78/100
+// - Complexity: MEDIUM
+// - Integrations: 3 (Kubernetes admission, Kubernetes API, RFC2136 servers)
+// - External Risks: MEDIUM (a failing webhook blocks record changes when failurePolicy is Fail; the probe contacts servers)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: ResourceValidator
+// Purpose: Rejects DNSRecords and DNSZones whose spec, TSIG Secret or key would fail at reconcile time
+
+const (
+	// ResourceValidationPath is where the webhook server serves ResourceValidator
+	ResourceValidationPath = "/validate-dns-istio-dns01-bind9-rieset-io-v1alpha1-resource"
+	// defaultProbeTimeout bounds the credential probe of all servers
+	defaultProbeTimeout = 3 * time.Second
+)
+
+// ResourceValidator validates DNSRecords and DNSZones: the spec is checked
+// the way the reconcilers check it, and the TSIG Secret must hold the key it
+// names. With ProbeUpdates the servers of a DNSRecord are sent an UPDATE
+// holding only the zone SOA prerequisite, which changes nothing, to confirm
+// they accept the key for the zone.
+type ResourceValidator struct {
+	// Reader reads the TSIG Secrets
+	Reader client.Reader
+	// ProbeUpdates sends the no-op UPDATE to the servers of DNSRecords
+	ProbeUpdates bool
+	// ProbeTimeout bounds the probe of all servers; zero means defaultProbeTimeout
+	ProbeTimeout time.Duration
+	// Logger is handed to the RFC2136 clients of the probe
+	Logger *zap.Logger
+}
+
+// SetupWithManager registers the validator on the webhook server of mgr
+func (v *ResourceValidator) SetupWithManager(mgr ctrl.Manager) error {
+	if v.Reader == nil {
+		v.Reader = mgr.GetAPIReader()
+	}
+	mgr.GetWebhookServer().Register(ResourceValidationPath, &webhook.Admission{Handler: v})
+	return nil
+}
+
+// Handle admits a DNSRecord or DNSZone unless its reconcile would fail on an
+// invalid spec, a TSIG Secret without the key or, with ProbeUpdates, a key
+// the servers refuse. A Secret that does not exist yet and unreachable
+// servers only warn, since the reconciler retries until they appear.
+func (v *ResourceValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if req.Operation == admissionv1.Delete {
+		return admission.Allowed("")
+	}
+
+	var (
+		config *solverconfig.Config
+		err    error
+		probe  bool
+	)
+	switch req.Kind.Kind {
+	case "DNSRecord":
+		record, old := &dnsv1alpha1.DNSRecord{}, &dnsv1alpha1.DNSRecord{}
+		if resp, ok := decode(req, record, old); !ok {
+			return resp
+		}
+		// Finalizer and label changes, and records being deleted, are not
+		// held to the checks: a Secret removed meanwhile must not block them
+		if !record.DeletionTimestamp.IsZero() || unchanged(req, record.Spec, old.Spec) {
+			return admission.Allowed("")
+		}
+		config, err = controller.ValidateDNSRecord(record)
+		probe = v.ProbeUpdates
+	case "DNSZone":
+		zone, old := &dnsv1alpha1.DNSZone{}, &dnsv1alpha1.DNSZone{}
+		if resp, ok := decode(req, zone, old); !ok {
+			return resp
+		}
+		if !zone.DeletionTimestamp.IsZero() || unchanged(req, zone.Spec, old.Spec) {
+			return admission.Allowed("")
+		}
+		// The zone is only loaded once the rendered ConfigMap reaches the
+		// servers, so they cannot be probed for it yet
+		config, err = controller.ValidateDNSZone(zone)
+	default:
+		return admission.Allowed("")
+	}
+	log := logf.FromContext(ctx).WithValues("kind", req.Kind.Kind, "namespace", req.Namespace, "name", req.Name)
+	if err != nil {
+		log.Info("Rejecting invalid spec", "error", err.Error())
+		return admission.Denied("spec: " + err.Error())
+	}
+
+	secret, warning, err := v.tsigSecret(ctx, req.Namespace, config)
+	switch {
+	case err != nil:
+		log.Info("Rejecting unusable TSIG Secret", "error", err.Error())
+		return admission.Denied(err.Error())
+	case warning != "":
+		return admission.Allowed("").WithWarnings(warning)
+	case !probe:
+		return admission.Allowed("")
+	}
+
+	refused, unreachable := v.probe(ctx, config, secret)
+	if len(refused) > 0 {
+		log.Info("Rejecting key refused by the servers", "servers", len(refused))
+		return admission.Denied(strings.Join(refused, "; "))
+	}
+	return admission.Allowed("").WithWarnings(unreachable...)
+}
+
+// decode reads the object of req into obj and, for an UPDATE, the previous
+// object into old; ok is false with the response to return when one fails
+func decode(req admission.Request, obj, old client.Object) (admission.Response, bool) {
+	if err := json.Unmarshal(req.Object.Raw, obj); err != nil {
+		return admission.Errored(http.StatusBadRequest, fmt.Errorf("failed to decode %s: %w", req.Kind.Kind, err)), false
+	}
+	if req.Operation == admissionv1.Update && len(req.OldObject.Raw) > 0 {
+		if err := json.Unmarshal(req.OldObject.Raw, old); err != nil {
+			return admission.Errored(http.StatusBadRequest, fmt.Errorf("failed to decode old %s: %w", req.Kind.Kind, err)), false
+		}
+	}
+	return admission.Response{}, true
+}
+
+// unchanged reports whether req is an UPDATE that leaves the spec alone
+func unchanged(req admission.Request, spec, old any) bool {
+	return req.Operation == admissionv1.Update && len(req.OldObject.Raw) > 0 && reflect.DeepEqual(spec, old)
+}
+
+// tsigSecret returns the TSIG secret config names in namespace and takes
+// over the key name and algorithm the Secret carries, like the reconcilers.
+// A missing Secret is a warning, a Secret without the key an error.
+func (v *ResourceValidator) tsigSecret(ctx context.Context, namespace string, config *solverconfig.Config) (secret, warning string, err error) {
+	obj := &corev1.Secret{}
+	key := client.ObjectKey{Namespace: namespace, Name: config.TSIGSecretName}
+	if err := v.Reader.Get(ctx, key, obj); err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", fmt.Sprintf("TSIG secret %s does not exist yet; the records are synced once it does", key), nil
+		}
+		return "", fmt.Sprintf("TSIG secret %s could not be read: %v", key, err), nil
+	}
+	value := obj.Data[config.TSIGSecretKey]
+	if len(value) == 0 {
+		return "", "", fmt.Errorf("TSIG secret %s has no key %s", key, config.TSIGSecretKey)
+	}
+	config.TSIGKeyName, config.TSIGAlgorithm = solverconfig.TSIGKeyFromSecret(obj.Data, config.TSIGKeyName, config.TSIGAlgorithm)
+	return string(value), "", nil
+}
+
+// probe sends the no-op UPDATE to every server of config at once and returns
+// the servers that refused the key and those that could not be asked
+func (v *ResourceValidator) probe(ctx context.Context, config *solverconfig.Config, secret string) (refused, unreachable []string) {
+	timeout := v.ProbeTimeout
+	if timeout <= 0 {
+		timeout = defaultProbeTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	logger := v.Logger
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+
+	errs := make([]error, len(config.Servers))
+	var wg sync.WaitGroup
+	for i, server := range config.Servers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client := rfc2136.NewRFC2136Client(server, config.Zone, config.TSIGKeyName, config.TSIGAlgorithm, secret, logger)
+			client.SetQuirks(config.ServerQuirks(server))
+			client.SetTransport(config.DNSTransport())
+			client.SetTimeouts(config.ClientTimeouts(server))
+			errs[i] = client.VerifyCredentials(ctx)
+		}()
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		switch {
+		case err == nil:
+		case errors.Is(err, rfc2136.ErrUpdateNotAuthorized):
+			refused = append(refused, err.Error())
+		default:
+			unreachable = append(unreachable, fmt.Sprintf("server %s could not be probed: %v", config.Servers[i], err))
+		}
+	}
+	return refused, unreachable
+}
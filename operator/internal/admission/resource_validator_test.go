@@ -0,0 +1,186 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admission
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	dnsv1alpha1 "github.com/rieset/istio-dns01-bind9/api/v1alpha1"
+	"github.com/rieset/istio-dns01-bind9/internal/dnstest"
+)
+
+func resourceRequest(t *testing.T, op admissionv1.Operation, obj, old client.Object) admission.Request {
+	t.Helper()
+	req := admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+		Operation: op,
+		Kind:      metav1.GroupVersionKind{Group: dnsv1alpha1.GroupVersion.Group, Version: "v1alpha1"},
+		Namespace: "default",
+	}}
+	switch obj.(type) {
+	case *dnsv1alpha1.DNSRecord:
+		req.Kind.Kind = "DNSRecord"
+	case *dnsv1alpha1.DNSZone:
+		req.Kind.Kind = "DNSZone"
+	}
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Object = runtime.RawExtension{Raw: raw}
+	if old != nil {
+		if raw, err = json.Marshal(old); err != nil {
+			t.Fatal(err)
+		}
+		req.OldObject = runtime.RawExtension{Raw: raw}
+	}
+	return req
+}
+
+func testRecord(servers ...string) *dnsv1alpha1.DNSRecord {
+	return &dnsv1alpha1.DNSRecord{
+		ObjectMeta: metav1.ObjectMeta{Name: "www", Namespace: "default"},
+		Spec: dnsv1alpha1.DNSRecordSpec{
+			Name: "www.example.com", Type: "A", Values: []string{"192.0.2.10"}, Zone: "example.com",
+			Servers: servers, TSIGKeyName: dnstest.TestKeyName, TSIGSecretName: "bind9-tsig",
+		},
+	}
+}
+
+func tsigSecret(value string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "bind9-tsig", Namespace: "default"},
+		Data:       map[string][]byte{"secret": []byte(value)},
+	}
+}
+
+func TestResourceValidator(t *testing.T) {
+	v := &ResourceValidator{Reader: fake.NewClientBuilder().WithObjects(tsigSecret(dnstest.TestSecret)).Build()}
+
+	outside := testRecord("192.0.2.1")
+	outside.Spec.Name = "www.example.org"
+	badValue := testRecord("192.0.2.1")
+	badValue.Spec.Values = []string{"not-an-ip"}
+	badServer := testRecord("192.0.2.1:port")
+	noKey := testRecord("192.0.2.1")
+	noKey.Spec.TSIGSecretKey = "other"
+	missingSecret := testRecord("192.0.2.1")
+	missingSecret.Spec.TSIGSecretName = "missing"
+	zone := &dnsv1alpha1.DNSZone{
+		ObjectMeta: metav1.ObjectMeta{Name: "example-com", Namespace: "default"},
+		Spec: dnsv1alpha1.DNSZoneSpec{Zone: "example.com", Servers: []string{"192.0.2.1"},
+			Nameservers: []string{"ns1.example.com"}, TSIGKeyName: "k", TSIGSecretName: "bind9-tsig"},
+	}
+	badZone := zone.DeepCopy()
+	badZone.Spec.Nameservers = []string{"bad..name"}
+
+	tests := []struct {
+		name string
+		obj  client.Object
+		// want is a substring of the denial; empty means admitted
+		want string
+		// warning is a substring of the warnings of an admitted object
+		warning string
+	}{
+		{name: "valid record", obj: testRecord("192.0.2.1")},
+		{name: "name outside zone", obj: outside, want: "spec: name www.example.org. is outside zone example.com"},
+		{name: "invalid value", obj: badValue, want: `invalid A value "not-an-ip"`},
+		{name: "invalid server", obj: badServer, want: "invalid port"},
+		{name: "secret without key", obj: noKey, want: "TSIG secret default/bind9-tsig has no key other"},
+		{name: "missing secret", obj: missingSecret, warning: "does not exist yet"},
+		{name: "valid zone", obj: zone},
+		{name: "invalid nameserver", obj: badZone, want: `nameserver "bad..name" is not a valid domain name`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := v.Handle(context.Background(), resourceRequest(t, admissionv1.Create, tt.obj, nil))
+			if tt.want != "" {
+				if resp.Allowed || !strings.Contains(resp.Result.Message, tt.want) {
+					t.Fatalf("allowed %v, %v, want denied with %q", resp.Allowed, resp.Result, tt.want)
+				}
+				return
+			}
+			if !resp.Allowed {
+				t.Fatalf("denied: %s", resp.Result.Message)
+			}
+			if warnings := strings.Join(resp.Warnings, "; "); !strings.Contains(warnings, tt.warning) ||
+				(tt.warning == "" && warnings != "") {
+				t.Fatalf("warnings = %q, want %q", warnings, tt.warning)
+			}
+		})
+	}
+}
+
+func TestResourceValidatorSkipsUnchangedSpec(t *testing.T) {
+	v := &ResourceValidator{Reader: fake.NewClientBuilder().Build()}
+	old := testRecord("192.0.2.1")
+	old.Spec.Values = []string{"not-an-ip"}
+	record := old.DeepCopy()
+	record.Finalizers = []string{"dns.istio-dns01-bind9.rieset.io/records"}
+
+	// The finalizer of a record stuck on an invalid spec can still be changed
+	if resp := v.Handle(context.Background(), resourceRequest(t, admissionv1.Update, record, old)); !resp.Allowed {
+		t.Fatalf("finalizer update denied: %s", resp.Result.Message)
+	}
+	record.Spec.TTL = 60
+	if resp := v.Handle(context.Background(), resourceRequest(t, admissionv1.Update, record, old)); resp.Allowed {
+		t.Fatal("spec update of an invalid record admitted")
+	}
+	if resp := v.Handle(context.Background(), resourceRequest(t, admissionv1.Delete, record, nil)); !resp.Allowed {
+		t.Fatalf("delete denied: %s", resp.Result.Message)
+	}
+}
+
+func TestResourceValidatorProbe(t *testing.T) {
+	srv := dnstest.StartServers(t, 1)[0]
+	reader := fake.NewClientBuilder().WithObjects(tsigSecret(dnstest.TestSecret)).Build()
+	v := &ResourceValidator{Reader: reader, ProbeUpdates: true, ProbeTimeout: 2 * time.Second}
+	serial := srv.Serial("example.com")
+
+	resp := v.Handle(context.Background(), resourceRequest(t, admissionv1.Create, testRecord(srv.Addr()), nil))
+	if !resp.Allowed || len(resp.Warnings) > 0 {
+		t.Fatalf("record with an accepted key: allowed %v, %v, warnings %v", resp.Allowed, resp.Result, resp.Warnings)
+	}
+	if srv.Serial("example.com") != serial {
+		t.Fatal("probe bumped the zone serial")
+	}
+
+	// A refused key fails the reconcile and is denied
+	v.Reader = fake.NewClientBuilder().WithObjects(tsigSecret("c2VjcmV0LXRoYXQtZG9lcy1ub3QtbWF0Y2g=")).Build()
+	resp = v.Handle(context.Background(), resourceRequest(t, admissionv1.Create, testRecord(srv.Addr()), nil))
+	if resp.Allowed || !strings.Contains(resp.Result.Message, "update not authorized") {
+		t.Fatalf("record with a refused key: allowed %v, %v", resp.Allowed, resp.Result)
+	}
+
+	// An unreachable server may come up later and only warns
+	v.Reader = reader
+	resp = v.Handle(context.Background(), resourceRequest(t, admissionv1.Create, testRecord("127.0.0.1:1"), nil))
+	if !resp.Allowed || len(resp.Warnings) != 1 || !strings.Contains(resp.Warnings[0], "could not be probed") {
+		t.Fatalf("record with an unreachable server: allowed %v, warnings %v", resp.Allowed, resp.Warnings)
+	}
+}
@@ -0,0 +1,459 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	dnsv1alpha1 "github.com/rieset/istio-dns01-bind9/api/v1alpha1"
+	rfc2136 "github.com/rieset/istio-dns01-bind9/internal/dns"
+	"github.com/rieset/istio-dns01-bind9/internal/solverconfig"
+)
+
+// FunctionRating: 72/100
+// - Complexity: HIGH
+// - Integrations: 1 (Kubernetes API)
+// - External Risks: MEDIUM (runs the name servers every zone depends on)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: Bind9ClusterReconciler
+// Purpose: Deploys a BIND9 primary StatefulSet and secondary Deployments with Services and publishes their endpoints
+
+const (
+	// defaultBind9Image is used when a Bind9Cluster does not set an image
+	defaultBind9Image = "internetsystemsconsortium/bind9:9.18"
+	// configHashAnnotation carries the digest of the rendered configuration on the pod templates
+	configHashAnnotation = "dns.istio-dns01-bind9.rieset.io/config-hash"
+	// componentLabel tells the primary and the secondaries of a cluster apart
+	componentLabel = "app.kubernetes.io/component"
+	// instanceLabel names the Bind9Cluster a server belongs to
+	instanceLabel = "app.kubernetes.io/instance"
+)
+
+// errForeignObject means an object of the name a Bind9Cluster writes exists
+// without being controlled by it
+var errForeignObject = errors.New("object exists and is not controlled by the Bind9Cluster")
+
+// Bind9ClusterReconciler reconciles a Bind9Cluster object
+type Bind9ClusterReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=dns.istio-dns01-bind9.rieset.io,resources=bind9clusters,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=dns.istio-dns01-bind9.rieset.io,resources=bind9clusters/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=apps,resources=statefulsets;deployments,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=services;secrets;configmaps,verbs=get;list;watch;create;update;patch;delete
+
+// Reconcile deploys the servers of a Bind9Cluster. The Services come first,
+// since their addresses are written into the configuration: the secondaries
+// transfer from the primary's Service and the primary notifies theirs.
+func (r *Bind9ClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+
+	cluster := &dnsv1alpha1.Bind9Cluster{}
+	if err := r.Get(ctx, req.NamespacedName, cluster); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	// Everything the cluster created is garbage collected with it
+	if !cluster.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	zones, err := clusterZones(cluster)
+	if err != nil {
+		return ctrl.Result{}, r.setCondition(ctx, cluster, dnsv1alpha1.ReasonInvalidSpec, err.Error())
+	}
+	key, err := r.tsigKey(ctx, cluster)
+	if err != nil {
+		log.Error(err, "TSIG secret unavailable")
+		return ctrl.Result{RequeueAfter: failedSyncRetry},
+			r.setCondition(ctx, cluster, dnsv1alpha1.ReasonCredentialsUnavailable, err.Error())
+	}
+
+	count := int(cluster.Spec.Secondaries)
+	primary, err := r.applyService(ctx, cluster, cluster.Name+"-primary", "primary")
+	if err != nil {
+		return r.deployFailed(ctx, cluster, err)
+	}
+	secondaries := make([]string, 0, count)
+	for i := range count {
+		address, err := r.applyService(ctx, cluster, secondaryName(cluster, i), secondaryComponent(i))
+		if err != nil {
+			return r.deployFailed(ctx, cluster, err)
+		}
+		secondaries = append(secondaries, address)
+	}
+	if err := r.removeSecondaries(ctx, cluster, count); err != nil {
+		return ctrl.Result{}, err
+	}
+	if primary == "" || slices.Contains(secondaries, "") {
+		log.Info("Waiting for the Services to get an address")
+		return ctrl.Result{RequeueAfter: failedSyncRetry},
+			r.setCondition(ctx, cluster, dnsv1alpha1.ReasonProgressing, "waiting for the Services to get an address")
+	}
+
+	primaryHost := fmt.Sprintf("%s-primary.%s.svc.cluster.local.", cluster.Name, cluster.Namespace)
+	data := renderClusterConfig(cluster, zones, key, primary, secondaries, primaryHost)
+	config := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: cluster.Name + "-config", Namespace: cluster.Namespace}}
+	if err := r.apply(ctx, cluster, config, func() {
+		config.Type = corev1.SecretTypeOpaque
+		config.Data = data
+	}); err != nil {
+		return r.deployFailed(ctx, cluster, fmt.Errorf("secret %s: %w", config.Name, err))
+	}
+	hash := configHash(data)
+
+	statefulSet, err := r.applyPrimary(ctx, cluster, config.Name, hash)
+	if err != nil {
+		return r.deployFailed(ctx, cluster, err)
+	}
+	ready := int32(0)
+	for i := range count {
+		deployment, err := r.applySecondary(ctx, cluster, i, config.Name, hash)
+		if err != nil {
+			return r.deployFailed(ctx, cluster, err)
+		}
+		if deployment.Status.ReadyReplicas > 0 {
+			ready++
+		}
+	}
+
+	endpoints := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+		Name: solverconfig.Bind9ClusterEndpointsName(cluster.Name), Namespace: cluster.Namespace}}
+	if err := r.apply(ctx, cluster, endpoints, func() {
+		endpoints.Data = solverconfig.EndpointsData([]string{primary}, secondaries)
+	}); err != nil {
+		return r.deployFailed(ctx, cluster, fmt.Errorf("configmap %s: %w", endpoints.Name, err))
+	}
+
+	cluster.Status.Primary = primary
+	cluster.Status.Secondaries = secondaries
+	cluster.Status.ReadySecondaries = ready
+	reason, message := dnsv1alpha1.ReasonAvailable, fmt.Sprintf("primary and %d/%d secondaries ready", ready, count)
+	if statefulSet.Status.ReadyReplicas == 0 {
+		reason, message = dnsv1alpha1.ReasonProgressing, fmt.Sprintf("primary not ready, %d/%d secondaries ready", ready, count)
+	} else if int(ready) < count {
+		reason = dnsv1alpha1.ReasonProgressing
+	}
+	return ctrl.Result{}, r.setCondition(ctx, cluster, reason, message)
+}
+
+// tsigKey reads the key the servers share from the TSIG Secret of cluster.
+// The key name and algorithm the Secret carries, as a TSIGKey writes them,
+// take precedence over the spec.
+func (r *Bind9ClusterReconciler) tsigKey(ctx context.Context, cluster *dnsv1alpha1.Bind9Cluster) (clusterKey, error) {
+	spec := cluster.Spec
+	secretKey := spec.TSIGSecretKey
+	if secretKey == "" {
+		secretKey = solverconfig.DefaultTSIGSecretKey
+	}
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: cluster.Namespace, Name: spec.TSIGSecretName}, secret); err != nil {
+		return clusterKey{}, fmt.Errorf("failed to get TSIG secret %s/%s: %w", cluster.Namespace, spec.TSIGSecretName, err)
+	}
+	value := strings.TrimSpace(string(secret.Data[secretKey]))
+	if value == "" || strings.ContainsAny(value, "\"\n") {
+		return clusterKey{}, fmt.Errorf("secret %s/%s has no valid key %s", cluster.Namespace, spec.TSIGSecretName, secretKey)
+	}
+
+	name, algorithm := spec.TSIGKeyName, spec.TSIGAlgorithm
+	if name == "" {
+		name = cluster.Name
+	}
+	if algorithm == "" {
+		algorithm = solverconfig.DefaultTSIGAlgorithm
+	}
+	name, algorithm = solverconfig.TSIGKeyFromSecret(secret.Data, name, algorithm)
+	algorithm, err := rfc2136.ParseTSIGAlgorithm(algorithm, true)
+	if err != nil {
+		return clusterKey{}, err
+	}
+	name = strings.TrimSuffix(name, ".")
+	if strings.ContainsAny(name, "\"\n; ") {
+		return clusterKey{}, fmt.Errorf("TSIG key name %q is not valid", name)
+	}
+	return clusterKey{name: name, algorithm: algorithm, secret: value}, nil
+}
+
+// applyService creates or updates the Service of one server and returns its
+// cluster IP, empty until one is allocated
+func (r *Bind9ClusterReconciler) applyService(ctx context.Context, cluster *dnsv1alpha1.Bind9Cluster,
+	name, component string) (string, error) {
+	serviceType := cluster.Spec.ServiceType
+	if serviceType == "" {
+		serviceType = corev1.ServiceTypeClusterIP
+	}
+	service := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: cluster.Namespace}}
+	if err := r.apply(ctx, cluster, service, func() {
+		service.Labels = bind9Labels(cluster, component)
+		service.Spec.Type = serviceType
+		service.Spec.Selector = bind9Labels(cluster, component)
+		service.Spec.Ports = []corev1.ServicePort{
+			{Name: "dns-udp", Protocol: corev1.ProtocolUDP, Port: 53, TargetPort: intstr.FromInt32(53)},
+			{Name: "dns-tcp", Protocol: corev1.ProtocolTCP, Port: 53, TargetPort: intstr.FromInt32(53)},
+		}
+	}); err != nil {
+		return "", fmt.Errorf("service %s: %w", name, err)
+	}
+	if service.Spec.ClusterIP == corev1.ClusterIPNone {
+		return "", nil
+	}
+	return service.Spec.ClusterIP, nil
+}
+
+// applyPrimary creates or updates the StatefulSet of the primary, whose
+// volume keeps the zone files and their journals across restarts. An init
+// container copies the seed zone file of a zone only when none exists yet.
+func (r *Bind9ClusterReconciler) applyPrimary(ctx context.Context, cluster *dnsv1alpha1.Bind9Cluster,
+	configName, hash string) (*appsv1.StatefulSet, error) {
+	name := cluster.Name + "-primary"
+	storage := cluster.Spec.Storage
+	if storage.IsZero() {
+		storage = resource.MustParse("1Gi")
+	}
+	statefulSet := &appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: cluster.Namespace}}
+	err := r.apply(ctx, cluster, statefulSet, func() {
+		labels := bind9Labels(cluster, "primary")
+		statefulSet.Labels = labels
+		statefulSet.Spec.Replicas = ptr.To(int32(1))
+		statefulSet.Spec.ServiceName = name
+		statefulSet.Spec.Selector = &metav1.LabelSelector{MatchLabels: labels}
+		if statefulSet.CreationTimestamp.IsZero() {
+			// The claim templates of a StatefulSet cannot be changed later
+			statefulSet.Spec.VolumeClaimTemplates = []corev1.PersistentVolumeClaim{{
+				ObjectMeta: metav1.ObjectMeta{Name: "zones"},
+				Spec: corev1.PersistentVolumeClaimSpec{
+					AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+					Resources: corev1.VolumeResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceStorage: storage},
+					},
+				},
+			}}
+		}
+		pod := bind9Pod(cluster, labels, configName, primaryConfigKey, hash)
+		seed := fmt.Sprintf(`for f in %s/%s*; do t=%s/$(basename "$f"); [ -f "$t" ] || cp "$f" "$t"; done; chown -R bind:bind %s`,
+			configMountPath, zoneFilePrefix, primaryZoneDir, primaryZoneDir)
+		pod.Spec.InitContainers = []corev1.Container{{
+			Name:         "seed-zones",
+			Image:        pod.Spec.Containers[0].Image,
+			Command:      []string{"sh", "-c", seed},
+			VolumeMounts: pod.Spec.Containers[0].VolumeMounts,
+		}}
+		pod.Spec.Containers[0].VolumeMounts = append(pod.Spec.Containers[0].VolumeMounts,
+			corev1.VolumeMount{Name: "zones", MountPath: primaryZoneDir})
+		pod.Spec.InitContainers[0].VolumeMounts = pod.Spec.Containers[0].VolumeMounts
+		statefulSet.Spec.Template = pod
+	})
+	if err != nil {
+		return nil, fmt.Errorf("statefulset %s: %w", name, err)
+	}
+	return statefulSet, nil
+}
+
+// applySecondary creates or updates the Deployment of secondary i. A
+// secondary keeps no state; it transfers every zone after a restart.
+func (r *Bind9ClusterReconciler) applySecondary(ctx context.Context, cluster *dnsv1alpha1.Bind9Cluster, i int,
+	configName, hash string) (*appsv1.Deployment, error) {
+	name := secondaryName(cluster, i)
+	deployment := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: cluster.Namespace}}
+	err := r.apply(ctx, cluster, deployment, func() {
+		labels := bind9Labels(cluster, secondaryComponent(i))
+		deployment.Labels = labels
+		deployment.Spec.Replicas = ptr.To(int32(1))
+		deployment.Spec.Selector = &metav1.LabelSelector{MatchLabels: labels}
+		pod := bind9Pod(cluster, labels, configName, secondaryConfigKey, hash)
+		pod.Spec.Volumes = append(pod.Spec.Volumes, corev1.Volume{
+			Name: "cache", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+		})
+		pod.Spec.Containers[0].VolumeMounts = append(pod.Spec.Containers[0].VolumeMounts,
+			corev1.VolumeMount{Name: "cache", MountPath: secondaryZoneDir})
+		deployment.Spec.Template = pod
+	})
+	if err != nil {
+		return nil, fmt.Errorf("deployment %s: %w", name, err)
+	}
+	return deployment, nil
+}
+
+// removeSecondaries deletes the Deployments and Services of the secondaries
+// numbered count and above, left from a larger cluster
+func (r *Bind9ClusterReconciler) removeSecondaries(ctx context.Context, cluster *dnsv1alpha1.Bind9Cluster, count int) error {
+	selector := client.MatchingLabels{instanceLabel: cluster.Name, "app.kubernetes.io/name": "bind9"}
+	var deployments appsv1.DeploymentList
+	if err := r.List(ctx, &deployments, client.InNamespace(cluster.Namespace), selector); err != nil {
+		return err
+	}
+	var services corev1.ServiceList
+	if err := r.List(ctx, &services, client.InNamespace(cluster.Namespace), selector); err != nil {
+		return err
+	}
+	var stale []client.Object
+	for i := range deployments.Items {
+		stale = append(stale, &deployments.Items[i])
+	}
+	for i := range services.Items {
+		stale = append(stale, &services.Items[i])
+	}
+	for _, obj := range stale {
+		index, ok := strings.CutPrefix(obj.GetLabels()[componentLabel], "secondary-")
+		n, err := strconv.Atoi(index)
+		if !ok || err != nil || n < count || !metav1.IsControlledBy(obj, cluster) {
+			continue
+		}
+		logf.FromContext(ctx).Info("Removing secondary", "kind", fmt.Sprintf("%T", obj), "name", obj.GetName())
+		if err := r.Delete(ctx, obj); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// apply creates or updates obj, controlled by cluster, with mutate. An
+// existing object cluster does not control is left alone.
+func (r *Bind9ClusterReconciler) apply(ctx context.Context, cluster *dnsv1alpha1.Bind9Cluster, obj client.Object,
+	mutate func()) error {
+	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, obj, func() error {
+		if obj.GetResourceVersion() != "" && !metav1.IsControlledBy(obj, cluster) {
+			return errForeignObject
+		}
+		mutate()
+		return controllerutil.SetControllerReference(cluster, obj, r.Scheme)
+	})
+	return err
+}
+
+// deployFailed records a failure to write an object; a conflict with an
+// unmanaged object needs an operator and is not retried
+func (r *Bind9ClusterReconciler) deployFailed(ctx context.Context, cluster *dnsv1alpha1.Bind9Cluster,
+	cause error) (ctrl.Result, error) {
+	if err := r.setCondition(ctx, cluster, dnsv1alpha1.ReasonDeployFailed, cause.Error()); err != nil {
+		return ctrl.Result{}, err
+	}
+	if errors.Is(cause, errForeignObject) {
+		logf.FromContext(ctx).Info("Not overwriting an object the Bind9Cluster does not manage", "reason", cause.Error())
+		return ctrl.Result{}, nil
+	}
+	return ctrl.Result{}, cause
+}
+
+// setCondition records the Ready condition; only ReasonAvailable makes it true
+func (r *Bind9ClusterReconciler) setCondition(ctx context.Context, cluster *dnsv1alpha1.Bind9Cluster,
+	reason, message string) error {
+	status := metav1.ConditionFalse
+	if reason == dnsv1alpha1.ReasonAvailable {
+		status = metav1.ConditionTrue
+	}
+	cluster.Status.ObservedGeneration = cluster.Generation
+	meta.SetStatusCondition(&cluster.Status.Conditions, metav1.Condition{
+		Type:               dnsv1alpha1.ConditionReady,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: cluster.Generation,
+	})
+	return r.Status().Update(ctx, cluster)
+}
+
+// bind9Pod returns the pod template of a server running named with the
+// configuration under configKey of the Secret configName
+func bind9Pod(cluster *dnsv1alpha1.Bind9Cluster, labels map[string]string, configName, configKey,
+	hash string) corev1.PodTemplateSpec {
+	image := cluster.Spec.Image
+	if image == "" {
+		image = defaultBind9Image
+	}
+	return corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels:      labels,
+			Annotations: map[string]string{configHashAnnotation: hash},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name:    "bind9",
+				Image:   image,
+				Command: []string{"named", "-g", "-u", "bind", "-c", configMountPath + "/" + configKey},
+				Ports: []corev1.ContainerPort{
+					{Name: "dns-udp", ContainerPort: 53, Protocol: corev1.ProtocolUDP},
+					{Name: "dns-tcp", ContainerPort: 53, Protocol: corev1.ProtocolTCP},
+				},
+				ReadinessProbe: &corev1.Probe{
+					ProbeHandler: corev1.ProbeHandler{TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt32(53)}},
+				},
+				VolumeMounts: []corev1.VolumeMount{{Name: "config", MountPath: configMountPath, ReadOnly: true}},
+			}},
+			Volumes: []corev1.Volume{{
+				Name:         "config",
+				VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: configName}},
+			}},
+		},
+	}
+}
+
+// bind9Labels returns the labels of the objects of one server of cluster
+func bind9Labels(cluster *dnsv1alpha1.Bind9Cluster, component string) map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":       "bind9",
+		"app.kubernetes.io/managed-by": "istio-dns01-bind9",
+		instanceLabel:                  cluster.Name,
+		componentLabel:                 component,
+	}
+}
+
+// secondaryName returns the name of the Deployment and Service of secondary i
+func secondaryName(cluster *dnsv1alpha1.Bind9Cluster, i int) string {
+	return fmt.Sprintf("%s-secondary-%d", cluster.Name, i)
+}
+
+// secondaryComponent returns the component label of secondary i
+func secondaryComponent(i int) string {
+	return "secondary-" + strconv.Itoa(i)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *Bind9ClusterReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&dnsv1alpha1.Bind9Cluster{}).
+		Owns(&appsv1.StatefulSet{}).
+		Owns(&appsv1.Deployment{}).
+		Owns(&corev1.Service{}).
+		Owns(&corev1.Secret{}).
+		Owns(&corev1.ConfigMap{}).
+		Named("bind9cluster").
+		Complete(r)
+}
@@ -0,0 +1,242 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dnsv1alpha1 "github.com/rieset/istio-dns01-bind9/api/v1alpha1"
+	"github.com/rieset/istio-dns01-bind9/internal/solverconfig"
+)
+
+func newCluster(secondaries int32) *dnsv1alpha1.Bind9Cluster {
+	return &dnsv1alpha1.Bind9Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "bind", Namespace: testNamespace},
+		Spec: dnsv1alpha1.Bind9ClusterSpec{
+			Zones:          []string{"Example.com.", "example.org"},
+			Secondaries:    secondaries,
+			TSIGSecretName: "tsig",
+		},
+	}
+}
+
+func newClusterReconciler(t *testing.T, cluster *dnsv1alpha1.Bind9Cluster) *Bind9ClusterReconciler {
+	t.Helper()
+	records := newTestReconciler(t, cluster)
+	return &Bind9ClusterReconciler{Client: records.Client, Scheme: records.Scheme}
+}
+
+func reconcileCluster(t *testing.T, r *Bind9ClusterReconciler) (*dnsv1alpha1.Bind9Cluster, ctrl.Result) {
+	t.Helper()
+	key := client.ObjectKey{Namespace: testNamespace, Name: "bind"}
+	result, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key})
+	if err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	cluster := &dnsv1alpha1.Bind9Cluster{}
+	if err := r.Get(context.Background(), key, cluster); err != nil {
+		t.Fatal(err)
+	}
+	return cluster, result
+}
+
+// assignClusterIPs plays the part of the API server, which the fake client
+// does not, and allocates an address to every Service without one
+func assignClusterIPs(t *testing.T, r *Bind9ClusterReconciler) {
+	t.Helper()
+	var services corev1.ServiceList
+	if err := r.List(context.Background(), &services); err != nil {
+		t.Fatal(err)
+	}
+	for i := range services.Items {
+		service := &services.Items[i]
+		if service.Spec.ClusterIP != "" {
+			continue
+		}
+		service.Spec.ClusterIP = fmt.Sprintf("10.96.0.%d", 10+i)
+		if err := r.Update(context.Background(), service); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// markReady sets the ready replicas of the server workloads of the cluster
+func markReady(t *testing.T, r *Bind9ClusterReconciler, secondaries int) {
+	t.Helper()
+	ctx := context.Background()
+	statefulSet := &appsv1.StatefulSet{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: testNamespace, Name: "bind-primary"}, statefulSet); err != nil {
+		t.Fatal(err)
+	}
+	statefulSet.Status.ReadyReplicas = 1
+	if err := r.Status().Update(ctx, statefulSet); err != nil {
+		t.Fatal(err)
+	}
+	for i := range secondaries {
+		deployment := &appsv1.Deployment{}
+		key := client.ObjectKey{Namespace: testNamespace, Name: fmt.Sprintf("bind-secondary-%d", i)}
+		if err := r.Get(ctx, key, deployment); err != nil {
+			t.Fatal(err)
+		}
+		deployment.Status.ReadyReplicas = 1
+		if err := r.Status().Update(ctx, deployment); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestBind9ClusterReconcile(t *testing.T) {
+	r := newClusterReconciler(t, newCluster(2))
+	ctx := context.Background()
+
+	cluster, result := reconcileCluster(t, r)
+	cond := meta.FindStatusCondition(cluster.Status.Conditions, dnsv1alpha1.ConditionReady)
+	if cond == nil || cond.Reason != dnsv1alpha1.ReasonProgressing || result.RequeueAfter == 0 {
+		t.Fatalf("cluster without Service addresses: condition %+v, result %+v", cond, result)
+	}
+
+	assignClusterIPs(t, r)
+	cluster, _ = reconcileCluster(t, r)
+	if cluster.Status.Primary == "" || len(cluster.Status.Secondaries) != 2 {
+		t.Fatalf("status = %+v, want a primary and 2 secondaries", cluster.Status)
+	}
+	cond = meta.FindStatusCondition(cluster.Status.Conditions, dnsv1alpha1.ConditionReady)
+	if cond == nil || cond.Reason != dnsv1alpha1.ReasonProgressing {
+		t.Fatalf("cluster with no ready servers: condition %+v", cond)
+	}
+
+	config := &corev1.Secret{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: testNamespace, Name: "bind-config"}, config); err != nil {
+		t.Fatal(err)
+	}
+	primaryConf := string(config.Data[primaryConfigKey])
+	secondaryConf := string(config.Data[secondaryConfigKey])
+	for _, want := range []string{
+		`zone "example.com" {`, `zone "example.org" {`, "type master;",
+		"server " + cluster.Status.Secondaries[1], "also-notify { " + cluster.Status.Secondaries[0],
+	} {
+		if !strings.Contains(primaryConf, want) {
+			t.Errorf("primary config misses %q:\n%s", want, primaryConf)
+		}
+	}
+	for _, want := range []string{"type slave;", "masters { " + cluster.Status.Primary + "; };"} {
+		if !strings.Contains(secondaryConf, want) {
+			t.Errorf("secondary config misses %q:\n%s", want, secondaryConf)
+		}
+	}
+	if _, ok := config.Data[zoneFilePrefix+"example.com"]; !ok {
+		t.Errorf("config has no seed zone file for example.com: keys %v", slices.Sorted(maps.Keys(config.Data)))
+	}
+
+	statefulSet := &appsv1.StatefulSet{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: testNamespace, Name: "bind-primary"}, statefulSet); err != nil {
+		t.Fatal(err)
+	}
+	if got := statefulSet.Spec.Template.Annotations[configHashAnnotation]; got != configHash(config.Data) {
+		t.Errorf("config hash annotation = %q, want %q", got, configHash(config.Data))
+	}
+	if !metav1.IsControlledBy(statefulSet, cluster) {
+		t.Error("primary StatefulSet is not controlled by the cluster")
+	}
+
+	endpoints := &corev1.ConfigMap{}
+	key := client.ObjectKey{Namespace: testNamespace, Name: solverconfig.Bind9ClusterEndpointsName("bind")}
+	if err := r.Get(ctx, key, endpoints); err != nil {
+		t.Fatal(err)
+	}
+	if got := endpoints.Data[solverconfig.EndpointsServersKey]; got != cluster.Status.Primary {
+		t.Errorf("endpoint servers = %q, want %q", got, cluster.Status.Primary)
+	}
+	if got, want := endpoints.Data[solverconfig.EndpointsSecondariesKey],
+		strings.Join(cluster.Status.Secondaries, "\n"); got != want {
+		t.Errorf("endpoint secondaries = %q, want %q", got, want)
+	}
+
+	markReady(t, r, 2)
+	cluster, _ = reconcileCluster(t, r)
+	if !meta.IsStatusConditionTrue(cluster.Status.Conditions, dnsv1alpha1.ConditionReady) ||
+		cluster.Status.ReadySecondaries != 2 {
+		t.Fatalf("ready cluster: status %+v", cluster.Status)
+	}
+}
+
+func TestBind9ClusterScaleDown(t *testing.T) {
+	r := newClusterReconciler(t, newCluster(2))
+	ctx := context.Background()
+	reconcileCluster(t, r)
+	assignClusterIPs(t, r)
+	cluster, _ := reconcileCluster(t, r)
+
+	cluster.Spec.Secondaries = 1
+	if err := r.Update(ctx, cluster); err != nil {
+		t.Fatal(err)
+	}
+	cluster, _ = reconcileCluster(t, r)
+	if len(cluster.Status.Secondaries) != 1 {
+		t.Fatalf("secondaries = %v, want 1", cluster.Status.Secondaries)
+	}
+	removed := client.ObjectKey{Namespace: testNamespace, Name: "bind-secondary-1"}
+	if err := r.Get(ctx, removed, &appsv1.Deployment{}); !apierrors.IsNotFound(err) {
+		t.Errorf("Deployment of the removed secondary: err = %v, want not found", err)
+	}
+	if err := r.Get(ctx, removed, &corev1.Service{}); !apierrors.IsNotFound(err) {
+		t.Errorf("Service of the removed secondary: err = %v, want not found", err)
+	}
+	kept := client.ObjectKey{Namespace: testNamespace, Name: "bind-secondary-0"}
+	if err := r.Get(ctx, kept, &appsv1.Deployment{}); err != nil {
+		t.Errorf("Deployment of the kept secondary: %v", err)
+	}
+}
+
+func TestBind9ClusterForeignObject(t *testing.T) {
+	cluster := newCluster(0)
+	records := newTestReconciler(t, cluster, &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "bind-primary", Namespace: testNamespace},
+	})
+	r := &Bind9ClusterReconciler{Client: records.Client, Scheme: records.Scheme}
+
+	cluster, result := reconcileCluster(t, r)
+	cond := meta.FindStatusCondition(cluster.Status.Conditions, dnsv1alpha1.ConditionReady)
+	if cond == nil || cond.Reason != dnsv1alpha1.ReasonDeployFailed || result.RequeueAfter != 0 {
+		t.Fatalf("condition %+v, result %+v, want DeployFailed without requeue", cond, result)
+	}
+}
+
+func TestBind9ClusterInvalidZones(t *testing.T) {
+	cluster := newCluster(1)
+	cluster.Spec.Zones = []string{"example.com", "EXAMPLE.com."}
+	r := newClusterReconciler(t, cluster)
+
+	cluster, _ = reconcileCluster(t, r)
+	cond := meta.FindStatusCondition(cluster.Status.Conditions, dnsv1alpha1.ConditionReady)
+	if cond == nil || cond.Reason != dnsv1alpha1.ReasonInvalidSpec {
+		t.Fatalf("condition %+v, want InvalidSpec", cond)
+	}
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/miekg/dns"
+
+	dnsv1alpha1 "github.com/rieset/istio-dns01-bind9/api/v1alpha1"
+)
+
+// FunctionRating: 76/100
+// - Complexity: MEDIUM
+// - Integrations: 1 (BIND9 configuration)
+// - External Risks: MEDIUM (rendered text is loaded by the name servers, holds the TSIG secret)
+// - Unit Tests: YES (through the reconciler)
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: renderClusterConfig
+// Purpose: named.conf of the primary and the secondaries of a Bind9Cluster, plus the seed zone files
+
+const (
+	// primaryConfigKey and secondaryConfigKey are the keys of the named.conf
+	// of the primary and the secondaries in the config Secret
+	primaryConfigKey   = "primary.conf"
+	secondaryConfigKey = "secondary.conf"
+	// configMountPath is where the config Secret is mounted in the servers
+	configMountPath = "/etc/bind/generated"
+	// primaryZoneDir keeps the zone files of the primary on its volume
+	primaryZoneDir = "/var/lib/bind"
+	// secondaryZoneDir holds the transferred zones of a secondary
+	secondaryZoneDir = "/var/cache/bind"
+)
+
+// clusterKey is the TSIG key the servers of a Bind9Cluster share
+type clusterKey struct {
+	name      string
+	algorithm string
+	secret    string
+}
+
+// clusterZones returns the zones of cluster, lowercased without the
+// trailing dot, rejecting invalid and duplicate names
+func clusterZones(cluster *dnsv1alpha1.Bind9Cluster) ([]string, error) {
+	if len(cluster.Spec.Zones) == 0 {
+		return nil, fmt.Errorf("zones are required")
+	}
+	zones := make([]string, 0, len(cluster.Spec.Zones))
+	for _, zone := range cluster.Spec.Zones {
+		name := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(zone)), ".")
+		if _, ok := dns.IsDomainName(name); !ok || name == "" || strings.ContainsAny(name, "\"/") {
+			return nil, fmt.Errorf("zone %q is not a valid domain name", zone)
+		}
+		if slices.Contains(zones, name) {
+			return nil, fmt.Errorf("zone %s is listed twice", name)
+		}
+		zones = append(zones, name)
+	}
+	return zones, nil
+}
+
+// renderClusterConfig renders the data of the config Secret of cluster:
+// the named.conf of the primary and of the secondaries, which transfer
+// every zone from primary and are notified by it, and a seed zone file per
+// zone. primaryHost is the name the seed SOA and NS records point at.
+func renderClusterConfig(cluster *dnsv1alpha1.Bind9Cluster, zones []string, key clusterKey, primary string,
+	secondaries []string, primaryHost string) map[string][]byte {
+	header := fmt.Sprintf("// Generated from Bind9Cluster %s/%s, do not edit\n", cluster.Namespace, cluster.Name)
+	keyStatement := fmt.Sprintf("key %q {\n\talgorithm %s;\n\tsecret %q;\n};\n", key.name, key.algorithm, key.secret)
+
+	var p strings.Builder
+	p.WriteString(header)
+	p.WriteString(renderOptions(secondaries))
+	p.WriteString(keyStatement)
+	for _, secondary := range secondaries {
+		// NOTIFY to the secondaries is signed, so they can accept it from
+		// the pod address of the primary
+		fmt.Fprintf(&p, "server %s { keys { %q; }; };\n", secondary, key.name)
+	}
+	for _, zone := range zones {
+		fmt.Fprintf(&p, "zone %q {\n\ttype master;\n\tfile %q;\n", zone, primaryZoneDir+"/"+zoneFilePrefix+zone)
+		fmt.Fprintf(&p, "\tallow-update { key %q; };\n\tallow-transfer { key %q; };\n};\n", key.name, key.name)
+	}
+
+	var s strings.Builder
+	s.WriteString(header)
+	s.WriteString(renderOptions(nil))
+	s.WriteString(keyStatement)
+	fmt.Fprintf(&s, "server %s { keys { %q; }; };\n", primary, key.name)
+	for _, zone := range zones {
+		fmt.Fprintf(&s, "zone %q {\n\ttype slave;\n\tfile %q;\n", zone, secondaryZoneDir+"/"+zoneFilePrefix+zone)
+		fmt.Fprintf(&s, "\tmasters { %s; };\n\tallow-notify { key %q; };\n\tallow-transfer { none; };\n};\n",
+			primary, key.name)
+	}
+
+	data := map[string][]byte{
+		primaryConfigKey:   []byte(p.String()),
+		secondaryConfigKey: []byte(s.String()),
+	}
+	source := "Bind9Cluster " + cluster.Namespace + "/" + cluster.Name
+	for _, zone := range zones {
+		name := dns.Fqdn(zone)
+		apex := zoneApex{
+			soa: &dns.SOA{
+				Hdr:     dns.RR_Header{Name: name, Rrtype: dns.TypeSOA, Class: dns.ClassINET, Ttl: 3600},
+				Ns:      primaryHost,
+				Mbox:    "hostmaster." + name,
+				Serial:  1,
+				Refresh: 3600,
+				Retry:   600,
+				Expire:  604800,
+				Minttl:  300,
+			},
+			ns: []dns.RR{&dns.NS{
+				Hdr: dns.RR_Header{Name: name, Rrtype: dns.TypeNS, Class: dns.ClassINET, Ttl: 3600},
+				Ns:  primaryHost,
+			}},
+		}
+		data[zoneFilePrefix+zone] = []byte(renderZoneFile(source, name, apex))
+	}
+	return data
+}
+
+// renderOptions renders the options statement of an authoritative-only
+// server; a primary lists its secondaries in also-notify
+func renderOptions(notify []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "options {\n\tdirectory %q;\n", secondaryZoneDir)
+	b.WriteString("\tlisten-on { any; };\n\tlisten-on-v6 { any; };\n\trecursion no;\n\tallow-query { any; };\n")
+	if len(notify) == 0 {
+		b.WriteString("\tnotify no;\n")
+	} else {
+		fmt.Fprintf(&b, "\tnotify explicit;\n\talso-notify { %s; };\n", strings.Join(notify, "; "))
+	}
+	b.WriteString("};\n")
+	return b.String()
+}
+
+// configHash returns a digest of data; it is set on the pod templates so the
+// servers restart with a changed configuration
+func configHash(data map[string][]byte) string {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	h := sha256.New()
+	for _, key := range keys {
+		fmt.Fprintf(h, "%s\x00%s\x00", key, data[key])
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
@@ -0,0 +1,237 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package controller holds the reconcilers of the operator's custom resources
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	dnsv1alpha1 "github.com/rieset/istio-dns01-bind9/api/v1alpha1"
+	rfc2136 "github.com/rieset/istio-dns01-bind9/internal/dns"
+	"github.com/rieset/istio-dns01-bind9/internal/solverconfig"
+	"github.com/rieset/istio-dns01-bind9/internal/workpool"
+)
+
+// FunctionRating: 76/100
+// - Complexity: MEDIUM
+// - Integrations: 2 (Kubernetes API, RFC2136 servers)
+// - External Risks: MEDIUM (network operations, replaces whole RRsets on every server)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: DNSRecordReconciler
+// Purpose: Reconciles DNSRecord resources into RRsets on BIND9 servers with per-server status
+
+const (
+	// dnsRecordFinalizer keeps a DNSRecord until its records are removed from the servers
+	dnsRecordFinalizer = "dns.istio-dns01-bind9.rieset.io/finalizer"
+	// defaultResyncPeriod is how often synced records are rewritten to repair drift
+	defaultResyncPeriod = 10 * time.Minute
+	// failedSyncRetry is how soon a record that failed on some server is retried
+	failedSyncRetry = 30 * time.Second
+)
+
+// DNSRecordReconciler reconciles a DNSRecord object
+type DNSRecordReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	// Pool runs the DNS updates, shared with the other controllers; nil runs them inline
+	Pool *workpool.Pool
+	// Logger is handed to the RFC2136 clients
+	Logger *zap.Logger
+	// ResyncPeriod is how often synced records are rewritten; zero means defaultResyncPeriod
+	ResyncPeriod time.Duration
+	// OwnerID, when set, keeps a TXT ownership registry entry next to every
+	// record written and refuses to change or delete records without one
+	OwnerID string
+}
+
+// +kubebuilder:rbac:groups=dns.istio-dns01-bind9.rieset.io,resources=dnsrecords,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=dns.istio-dns01-bind9.rieset.io,resources=dnsrecords/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=dns.istio-dns01-bind9.rieset.io,resources=dnsrecords/finalizers,verbs=update
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+
+// Reconcile writes the RRset of a DNSRecord to every server it targets,
+// removes RRsets the record no longer owns and reports per-server state
+func (r *DNSRecordReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+
+	record := &dnsv1alpha1.DNSRecord{}
+	if err := r.Get(ctx, req.NamespacedName, record); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !record.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, r.finalize(ctx, record)
+	}
+	if controllerutil.AddFinalizer(record, dnsRecordFinalizer) {
+		if err := r.Update(ctx, record); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	config, err := recordConfig(record)
+	if err != nil {
+		return ctrl.Result{}, r.setFailed(ctx, record, dnsv1alpha1.ReasonInvalidSpec, err)
+	}
+	rrset, err := desiredRRset(record, config.Zone)
+	if err != nil {
+		return ctrl.Result{}, r.setFailed(ctx, record, dnsv1alpha1.ReasonInvalidSpec, err)
+	}
+	secret, err := r.tsigSecret(ctx, record.Namespace, config)
+	if err != nil {
+		log.Error(err, "TSIG secret unavailable")
+		return ctrl.Result{RequeueAfter: failedSyncRetry},
+			r.setFailed(ctx, record, dnsv1alpha1.ReasonCredentialsUnavailable, err)
+	}
+
+	// The RRset written under a previous name or type, and the copies on
+	// servers dropped from the spec, are removed before the new one is written
+	if err := r.removeStale(ctx, record, config, secret, rrset); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	results := r.syncServers(ctx, record, config, secret, rrset)
+	synced := r.applyStatus(record, rrset, results)
+	if err := r.Status().Update(ctx, record); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if synced < len(results) {
+		log.Info("DNSRecord not synced on every server", "synced", synced, "servers", len(results))
+		return ctrl.Result{RequeueAfter: failedSyncRetry}, nil
+	}
+	resync := r.ResyncPeriod
+	if resync <= 0 {
+		resync = defaultResyncPeriod
+	}
+	return ctrl.Result{RequeueAfter: resync}, nil
+}
+
+// finalize removes the applied RRset from every server it was written to and
+// releases the record. Without credentials or ownership the records are left
+// behind, so a deleted Secret cannot block the deletion of its namespace.
+func (r *DNSRecordReconciler) finalize(ctx context.Context, record *dnsv1alpha1.DNSRecord) error {
+	if !controllerutil.ContainsFinalizer(record, dnsRecordFinalizer) {
+		return nil
+	}
+	log := logf.FromContext(ctx)
+
+	if applied, ok := appliedRRset(record); ok {
+		config, err := recordConfig(record)
+		var secret string
+		if err == nil {
+			secret, err = r.tsigSecret(ctx, record.Namespace, config)
+		}
+		switch {
+		case apierrors.IsNotFound(err):
+			log.Info("TSIG secret is gone, leaving records on the servers", "name", applied.name)
+		case err != nil:
+			return err
+		default:
+			err := r.deleteFrom(ctx, record, config, secret, applied, serverNames(record.Status.Servers))
+			if errors.Is(err, rfc2136.ErrNotOwned) {
+				// Records taken over by someone else are theirs now
+				log.Info("Records are not owned by this operator, leaving them on the servers",
+					"name", applied.name, "error", err.Error())
+			} else if err != nil {
+				return err
+			}
+		}
+	}
+
+	controllerutil.RemoveFinalizer(record, dnsRecordFinalizer)
+	return r.Update(ctx, record)
+}
+
+// removeStale deletes the previously applied RRset from the servers that no
+// longer hold it under the current spec
+func (r *DNSRecordReconciler) removeStale(ctx context.Context, record *dnsv1alpha1.DNSRecord,
+	config *solverconfig.Config, secret string, rrset recordSet) error {
+	applied, ok := appliedRRset(record)
+	if !ok {
+		return nil
+	}
+	moved := applied.name != rrset.name || applied.rrtype != rrset.rrtype
+
+	wanted := make(map[string]bool, len(config.Servers))
+	for _, server := range config.Servers {
+		wanted[server] = true
+	}
+	var stale []string
+	for _, server := range serverNames(record.Status.Servers) {
+		if moved || !wanted[server] {
+			stale = append(stale, server)
+		}
+	}
+	if len(stale) == 0 {
+		return nil
+	}
+	return r.deleteFrom(ctx, record, config, secret, applied, stale)
+}
+
+// tsigSecret reads the TSIG secret of config from the namespace of the record
+// and takes over the key name and algorithm the Secret carries, if any
+func (r *DNSRecordReconciler) tsigSecret(ctx context.Context, namespace string, config *solverconfig.Config) (string, error) {
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: namespace, Name: config.TSIGSecretName}, secret); err != nil {
+		return "", fmt.Errorf("failed to get TSIG secret %s/%s: %w", namespace, config.TSIGSecretName, err)
+	}
+	value, ok := secret.Data[config.TSIGSecretKey]
+	if !ok || len(value) == 0 {
+		return "", fmt.Errorf("secret %s/%s has no key %s", namespace, config.TSIGSecretName, config.TSIGSecretKey)
+	}
+	config.TSIGKeyName, config.TSIGAlgorithm = solverconfig.TSIGKeyFromSecret(secret.Data, config.TSIGKeyName, config.TSIGAlgorithm)
+	return string(value), nil
+}
+
+// setFailed records a failure that happened before any server was contacted
+func (r *DNSRecordReconciler) setFailed(ctx context.Context, record *dnsv1alpha1.DNSRecord, reason string, cause error) error {
+	record.Status.ObservedGeneration = record.Generation
+	meta.SetStatusCondition(&record.Status.Conditions, metav1.Condition{
+		Type:               dnsv1alpha1.ConditionReady,
+		Status:             metav1.ConditionFalse,
+		Reason:             reason,
+		Message:            cause.Error(),
+		ObservedGeneration: record.Generation,
+	})
+	return r.Status().Update(ctx, record)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *DNSRecordReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&dnsv1alpha1.DNSRecord{}).
+		Named("dnsrecord").
+		Complete(r)
+}
@@ -0,0 +1,209 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/miekg/dns"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	dnsv1alpha1 "github.com/rieset/istio-dns01-bind9/api/v1alpha1"
+	rfc2136 "github.com/rieset/istio-dns01-bind9/internal/dns"
+	"github.com/rieset/istio-dns01-bind9/internal/dnstest"
+)
+
+const testNamespace = "default"
+
+func startServers(t *testing.T, n int) ([]*dnstest.Server, []string) {
+	t.Helper()
+	servers := dnstest.StartServers(t, n)
+	return servers, dnstest.Addrs(servers)
+}
+
+func newTestReconciler(t *testing.T, objects ...client.Object) *DNSRecordReconciler {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := dnsv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "tsig", Namespace: testNamespace},
+		Data:       map[string][]byte{"secret": []byte(dnstest.TestSecret)},
+	}
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(append(objects, secret)...).
+		WithStatusSubresource(&dnsv1alpha1.DNSRecord{}, &dnsv1alpha1.DNSZone{}, &dnsv1alpha1.Bind9Cluster{},
+			&dnsv1alpha1.ZoneAudit{}).
+		Build()
+	return &DNSRecordReconciler{Client: c, Scheme: scheme}
+}
+
+func newRecord(addrs []string, name, rrtype string, values ...string) *dnsv1alpha1.DNSRecord {
+	return &dnsv1alpha1.DNSRecord{
+		ObjectMeta: metav1.ObjectMeta{Name: "www", Namespace: testNamespace},
+		Spec: dnsv1alpha1.DNSRecordSpec{
+			Name:           name,
+			Type:           rrtype,
+			Values:         values,
+			TTL:            120,
+			Zone:           "example.com",
+			Servers:        addrs,
+			TSIGKeyName:    dnstest.TestKeyName,
+			TSIGSecretName: "tsig",
+		},
+	}
+}
+
+func reconcileRecord(t *testing.T, r *DNSRecordReconciler) *dnsv1alpha1.DNSRecord {
+	t.Helper()
+	key := client.ObjectKey{Namespace: testNamespace, Name: "www"}
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	record := &dnsv1alpha1.DNSRecord{}
+	if err := r.Get(context.Background(), key, record); err != nil {
+		return nil
+	}
+	return record
+}
+
+func TestDNSRecordReconcile(t *testing.T) {
+	servers, addrs := startServers(t, 2)
+	r := newTestReconciler(t, newRecord(addrs, "www.example.com", "A", "192.0.2.1", "192.0.2.2"))
+
+	record := reconcileRecord(t, r)
+	for _, srv := range servers {
+		if got, want := srv.Records("www.example.com", dns.TypeA), []string{"192.0.2.1", "192.0.2.2"}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("A records = %v, want %v", got, want)
+		}
+	}
+	if !meta.IsStatusConditionTrue(record.Status.Conditions, dnsv1alpha1.ConditionReady) {
+		t.Fatalf("Ready condition not true: %+v", record.Status.Conditions)
+	}
+	if len(record.Status.Servers) != 2 || !record.Status.Servers[0].Synced || record.Status.Servers[0].LastSyncTime == nil {
+		t.Fatalf("server status = %+v, want both synced", record.Status.Servers)
+	}
+
+	// Moving the record removes the old RRset
+	record.Spec.Name = "app.example.com"
+	record.Spec.Type = "CNAME"
+	record.Spec.Values = []string{"www.example.com."}
+	if err := r.Update(context.Background(), record); err != nil {
+		t.Fatal(err)
+	}
+	reconcileRecord(t, r)
+	for _, srv := range servers {
+		if got := srv.Records("www.example.com", dns.TypeA); len(got) != 0 {
+			t.Fatalf("old A records = %v, want none", got)
+		}
+		if got, want := srv.Records("app.example.com", dns.TypeCNAME), []string{"www.example.com."}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("CNAME records = %v, want %v", got, want)
+		}
+	}
+
+	// Deleting the DNSRecord removes its records through the finalizer
+	if err := r.Delete(context.Background(), record); err != nil {
+		t.Fatal(err)
+	}
+	if record := reconcileRecord(t, r); record != nil {
+		t.Fatalf("DNSRecord still present with finalizers %v", record.Finalizers)
+	}
+	for _, srv := range servers {
+		if got := srv.Records("app.example.com", dns.TypeCNAME); len(got) != 0 {
+			t.Fatalf("CNAME records after delete = %v, want none", got)
+		}
+	}
+}
+
+func TestDNSRecordReconcileOwnershipRegistry(t *testing.T) {
+	servers, addrs := startServers(t, 1)
+	srv := servers[0]
+	r := newTestReconciler(t, newRecord(addrs, "www.example.com", "A", "192.0.2.1"))
+	r.OwnerID = "cluster-a"
+	registry := rfc2136.RegistryName("www.example.com")
+
+	reconcileRecord(t, r)
+	entries := srv.TXT(registry)
+	if len(entries) != 1 || !strings.Contains(entries[0], "owner=cluster-a,resource=dnsrecord/default/www,") {
+		t.Fatalf("registry = %v, want the entry of the DNSRecord", entries)
+	}
+
+	// Records whose registry entry is gone are left alone, even on deletion
+	srv.SetTXT(registry, 300)
+	record := reconcileRecord(t, r)
+	if record.Status.Servers[0].Synced {
+		t.Fatalf("server status = %+v, want the replace of unowned records refused", record.Status.Servers)
+	}
+	if err := r.Delete(context.Background(), record); err != nil {
+		t.Fatal(err)
+	}
+	if record := reconcileRecord(t, r); record != nil {
+		t.Fatalf("DNSRecord still present with finalizers %v", record.Finalizers)
+	}
+	if got, want := srv.Records("www.example.com", dns.TypeA), []string{"192.0.2.1"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("A records = %v, want %v kept", got, want)
+	}
+}
+
+func TestDNSRecordReconcileReportsServerFailures(t *testing.T) {
+	servers, addrs := startServers(t, 2)
+	servers[1].SetUpdateRcode(dns.RcodeRefused)
+	r := newTestReconciler(t, newRecord(addrs, "www.example.com", "AAAA", "2001:db8::1"))
+
+	record := reconcileRecord(t, r)
+	ready := meta.FindStatusCondition(record.Status.Conditions, dnsv1alpha1.ConditionReady)
+	if ready == nil || ready.Status != metav1.ConditionFalse || ready.Reason != dnsv1alpha1.ReasonPartiallySynced {
+		t.Fatalf("Ready condition = %+v, want PartiallySynced", ready)
+	}
+	if !record.Status.Servers[0].Synced || record.Status.Servers[1].Synced || record.Status.Servers[1].Message == "" {
+		t.Fatalf("server status = %+v, want only the first synced", record.Status.Servers)
+	}
+}
+
+func TestDNSRecordReconcileInvalidSpec(t *testing.T) {
+	_, addrs := startServers(t, 1)
+	tests := map[string]*dnsv1alpha1.DNSRecord{
+		"outside zone":    newRecord(addrs, "www.example.org", "A", "192.0.2.1"),
+		"bad value":       newRecord(addrs, "www.example.com", "A", "not-an-ip"),
+		"two cname":       newRecord(addrs, "www.example.com", "CNAME", "a.example.com.", "b.example.com."),
+		"missing servers": newRecord(nil, "www.example.com", "A", "192.0.2.1"),
+	}
+	for name, record := range tests {
+		t.Run(name, func(t *testing.T) {
+			record := reconcileRecord(t, newTestReconciler(t, record))
+			ready := meta.FindStatusCondition(record.Status.Conditions, dnsv1alpha1.ConditionReady)
+			if ready == nil || ready.Reason != dnsv1alpha1.ReasonInvalidSpec {
+				t.Fatalf("Ready condition = %+v, want InvalidSpec", ready)
+			}
+		})
+	}
+}
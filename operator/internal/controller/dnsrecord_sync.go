@@ -0,0 +1,234 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/miekg/dns"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	dnsv1alpha1 "github.com/rieset/istio-dns01-bind9/api/v1alpha1"
+	rfc2136 "github.com/rieset/istio-dns01-bind9/internal/dns"
+	"github.com/rieset/istio-dns01-bind9/internal/solverconfig"
+)
+
+// FunctionRating: 78/100
+// - Complexity: MEDIUM
+// - Integrations: 1 (RFC2136 servers)
+// - External Risks: MEDIUM (network operations, concurrent updates per server)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: syncServers, applyStatus
+// Purpose: Builds the RRset of a DNSRecord, writes it to every server and folds the results into status
+
+// recordSet is an RRset owned by a DNSRecord
+type recordSet struct {
+	name    string
+	rrtype  uint16
+	records []dns.RR
+}
+
+// recordConfig validates the server settings of record the way solver
+// configs are validated, so both share defaults and limits
+func recordConfig(record *dnsv1alpha1.DNSRecord) (*solverconfig.Config, error) {
+	spec := record.Spec
+	raw, err := json.Marshal(map[string]any{
+		"servers":        spec.Servers,
+		"zone":           spec.Zone,
+		"tsigKeyName":    spec.TSIGKeyName,
+		"tsigAlgorithm":  spec.TSIGAlgorithm,
+		"tsigSecretName": spec.TSIGSecretName,
+		"tsigSecretKey":  spec.TSIGSecretKey,
+		"ttl":            spec.TTL,
+		"transport":      spec.Transport,
+	})
+	if err != nil {
+		return nil, err
+	}
+	config, err := solverconfig.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	if config.Zone == "" {
+		return nil, fmt.Errorf("zone is required")
+	}
+	return config, nil
+}
+
+// desiredRRset parses the values of record into the RRset it should own in zone
+func desiredRRset(record *dnsv1alpha1.DNSRecord, zone string) (recordSet, error) {
+	spec := record.Spec
+	name := dns.Fqdn(strings.ToLower(spec.Name))
+	if _, ok := dns.IsDomainName(name); !ok {
+		return recordSet{}, fmt.Errorf("name %q is not a valid domain name", spec.Name)
+	}
+	if !dns.IsSubDomain(dns.Fqdn(zone), name) {
+		return recordSet{}, fmt.Errorf("name %s is outside zone %s", name, zone)
+	}
+	rrtype, ok := dns.StringToType[strings.ToUpper(spec.Type)]
+	if !ok {
+		return recordSet{}, fmt.Errorf("unknown record type %q", spec.Type)
+	}
+	if len(spec.Values) == 0 {
+		return recordSet{}, fmt.Errorf("values are required")
+	}
+	if rrtype == dns.TypeCNAME && len(spec.Values) > 1 {
+		return recordSet{}, fmt.Errorf("a CNAME record takes exactly one value")
+	}
+
+	ttl := spec.TTL
+	if ttl <= 0 {
+		ttl = 300
+	}
+	set := recordSet{name: name, rrtype: rrtype}
+	for _, value := range spec.Values {
+		rr, err := dns.NewRR(fmt.Sprintf("%s %d IN %s %s", name, ttl, dns.TypeToString[rrtype], value))
+		if err != nil || rr == nil {
+			return recordSet{}, fmt.Errorf("invalid %s value %q: %v", dns.TypeToString[rrtype], value, err)
+		}
+		set.records = append(set.records, rr)
+	}
+	return set, nil
+}
+
+// ValidateDNSRecord checks record the way Reconcile does before it contacts
+// a server and returns its server settings, for admission to reject specs
+// that can never sync
+func ValidateDNSRecord(record *dnsv1alpha1.DNSRecord) (*solverconfig.Config, error) {
+	config, err := recordConfig(record)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := desiredRRset(record, config.Zone); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// appliedRRset returns the RRset recorded in status as last written
+func appliedRRset(record *dnsv1alpha1.DNSRecord) (recordSet, bool) {
+	rrtype, ok := dns.StringToType[record.Status.AppliedType]
+	if record.Status.AppliedName == "" || !ok {
+		return recordSet{}, false
+	}
+	return recordSet{name: record.Status.AppliedName, rrtype: rrtype}, true
+}
+
+// serverNames returns the servers of a status list
+func serverNames(statuses []dnsv1alpha1.DNSRecordServerStatus) []string {
+	names := make([]string, 0, len(statuses))
+	for _, status := range statuses {
+		names = append(names, status.Server)
+	}
+	return names
+}
+
+// recordClient returns the client the RRsets of record are written to server
+// with, keeping the ownership registry when OwnerID is set
+func (r *DNSRecordReconciler) recordClient(config *solverconfig.Config, server, secret string,
+	record *dnsv1alpha1.DNSRecord) *rfc2136.RFC2136Client {
+	client := newZoneClient(config, server, secret, r.Logger)
+	if r.OwnerID != "" {
+		client.SetOwnership(rfc2136.Ownership{
+			Owner:    r.OwnerID,
+			Resource: "dnsrecord/" + record.Namespace + "/" + record.Name,
+		})
+	}
+	return client
+}
+
+// syncServers writes rrset of record to every server of config
+func (r *DNSRecordReconciler) syncServers(ctx context.Context, record *dnsv1alpha1.DNSRecord,
+	config *solverconfig.Config, secret string, rrset recordSet) []serverResult {
+	return forEachServer(ctx, r.Pool, config.Zone, config.Servers, func(ctx context.Context, server string) error {
+		return r.recordClient(config, server, secret, record).ReplaceRRset(ctx, rrset.name, rrset.rrtype, rrset.records)
+	})
+}
+
+// deleteFrom removes rrset of record from servers, failing if any server did
+// not accept the delete
+func (r *DNSRecordReconciler) deleteFrom(ctx context.Context, record *dnsv1alpha1.DNSRecord,
+	config *solverconfig.Config, secret string, rrset recordSet, servers []string) error {
+	results := forEachServer(ctx, r.Pool, config.Zone, servers, func(ctx context.Context, server string) error {
+		return r.recordClient(config, server, secret, record).DeleteRRset(ctx, rrset.name, rrset.rrtype)
+	})
+	var errs []error
+	for _, result := range results {
+		if result.err != nil {
+			errs = append(errs, fmt.Errorf("server %s: %w", result.server, result.err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// applyStatus records the outcome of a sync on record and returns the number
+// of servers that hold the records
+func (r *DNSRecordReconciler) applyStatus(record *dnsv1alpha1.DNSRecord, rrset recordSet, results []serverResult) int {
+	previous := make(map[string]dnsv1alpha1.DNSRecordServerStatus, len(record.Status.Servers))
+	for _, status := range record.Status.Servers {
+		previous[status.Server] = status
+	}
+
+	now := metav1.Now()
+	synced := 0
+	servers := make([]dnsv1alpha1.DNSRecordServerStatus, 0, len(results))
+	var failures []string
+	for _, result := range results {
+		status := dnsv1alpha1.DNSRecordServerStatus{Server: result.server, Synced: result.err == nil}
+		if result.err == nil {
+			synced++
+			status.LastSyncTime = &now
+		} else {
+			status.Message = result.err.Error()
+			status.LastSyncTime = previous[result.server].LastSyncTime
+			failures = append(failures, fmt.Sprintf("%s: %v", result.server, result.err))
+		}
+		servers = append(servers, status)
+	}
+
+	record.Status.ObservedGeneration = record.Generation
+	record.Status.AppliedName = rrset.name
+	record.Status.AppliedType = dns.TypeToString[rrset.rrtype]
+	record.Status.Servers = servers
+
+	condition := metav1.Condition{
+		Type:               dnsv1alpha1.ConditionReady,
+		Status:             metav1.ConditionTrue,
+		Reason:             dnsv1alpha1.ReasonSynced,
+		Message:            fmt.Sprintf("%d/%d servers synced", synced, len(results)),
+		ObservedGeneration: record.Generation,
+	}
+	if synced < len(results) {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = dnsv1alpha1.ReasonPartiallySynced
+		if synced == 0 {
+			condition.Reason = dnsv1alpha1.ReasonSyncFailed
+		}
+		condition.Message += "; " + strings.Join(failures, "; ")
+	}
+	meta.SetStatusCondition(&record.Status.Conditions, condition)
+	return synced
+}
@@ -0,0 +1,306 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	dnsv1alpha1 "github.com/rieset/istio-dns01-bind9/api/v1alpha1"
+	rfc2136 "github.com/rieset/istio-dns01-bind9/internal/dns"
+	"github.com/rieset/istio-dns01-bind9/internal/solverconfig"
+	"github.com/rieset/istio-dns01-bind9/internal/workpool"
+)
+
+// FunctionRating: 74/100
+// - Complexity: MEDIUM
+// - Integrations: 2 (Kubernetes API, RFC2136 servers)
+// - External Risks: MEDIUM (renders configuration BIND9 loads, rewrites the zone apex)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: DNSZoneReconciler
+// Purpose: Renders the BIND9 zone declaration of a DNSZone and keeps its SOA and NS records in sync on every server
+
+// zoneQueryTimeout bounds the SOA and NS queries of one server
+const zoneQueryTimeout = 5 * time.Second
+
+// errZoneNotLoaded means a server does not answer authoritatively for the zone
+var errZoneNotLoaded = errors.New("zone not loaded")
+
+// errForeignConfigMap means the ConfigMap a DNSZone renders to exists without
+// being controlled by it
+var errForeignConfigMap = errors.New("configmap exists and is not controlled by the DNSZone")
+
+// DNSZoneReconciler reconciles a DNSZone object
+type DNSZoneReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	// Pool runs the DNS updates, shared with the other controllers; nil runs them inline
+	Pool *workpool.Pool
+	// Logger is handed to the RFC2136 clients
+	Logger *zap.Logger
+	// ResyncPeriod is how often the apex records are checked; zero means defaultResyncPeriod
+	ResyncPeriod time.Duration
+}
+
+// +kubebuilder:rbac:groups=dns.istio-dns01-bind9.rieset.io,resources=dnszones,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=dns.istio-dns01-bind9.rieset.io,resources=dnszones/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+
+// Reconcile renders the zone statement and seed zone file of a DNSZone to
+// its ConfigMap, then brings the SOA and NS records of every server that has
+// loaded the zone in line with the spec
+func (r *DNSZoneReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+
+	zone := &dnsv1alpha1.DNSZone{}
+	if err := r.Get(ctx, req.NamespacedName, zone); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	// The ConfigMap is garbage collected with the DNSZone, which makes the
+	// servers drop the zone on their next reload
+	if !zone.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	config, err := zoneConfig(zone)
+	if err != nil {
+		return ctrl.Result{}, r.setFailed(ctx, zone, dnsv1alpha1.ReasonInvalidSpec, err)
+	}
+	apex, err := desiredZone(zone, config.Zone)
+	if err != nil {
+		return ctrl.Result{}, r.setFailed(ctx, zone, dnsv1alpha1.ReasonInvalidSpec, err)
+	}
+	secret, err := r.tsigSecret(ctx, zone.Namespace, config)
+	if err != nil {
+		log.Error(err, "TSIG secret unavailable")
+		return ctrl.Result{RequeueAfter: failedSyncRetry},
+			r.setFailed(ctx, zone, dnsv1alpha1.ReasonCredentialsUnavailable, err)
+	}
+
+	file, configMapName := zoneNames(zone, config.Zone)
+	configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: configMapName, Namespace: zone.Namespace}}
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, configMap, func() error {
+		if configMap.ResourceVersion != "" && !metav1.IsControlledBy(configMap, zone) {
+			return errForeignConfigMap
+		}
+		configMap.Data = map[string]string{
+			zoneConfigKey: renderZoneStatement(zone, config.Zone, config.TSIGKeyName, file),
+			zoneFilePrefix + strings.TrimSuffix(config.Zone, "."): renderZoneFile("DNSZone "+zone.Namespace+"/"+zone.Name, config.Zone, apex),
+		}
+		return controllerutil.SetControllerReference(zone, configMap, r.Scheme)
+	}); err != nil {
+		cause := fmt.Errorf("configmap %s: %w", configMapName, err)
+		if statusErr := r.setFailed(ctx, zone, dnsv1alpha1.ReasonConfigFailed, cause); statusErr != nil {
+			return ctrl.Result{}, statusErr
+		}
+		// A conflict with an unmanaged ConfigMap needs an operator, not a retry
+		if errors.Is(err, errForeignConfigMap) {
+			log.Info("Not overwriting a ConfigMap the DNSZone does not manage", "configMap", configMapName)
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, cause
+	}
+
+	var mu sync.Mutex
+	serials := make(map[string]uint32, len(config.Servers))
+	results := forEachServer(ctx, r.Pool, config.Zone, config.Servers, func(ctx context.Context, server string) error {
+		serial, err := r.syncServer(ctx, config, server, secret, apex)
+		mu.Lock()
+		serials[server] = serial
+		mu.Unlock()
+		return err
+	})
+	synced := r.applyStatus(zone, results, serials)
+	if err := r.Status().Update(ctx, zone); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if synced < len(results) {
+		log.Info("DNSZone not synced on every server", "synced", synced, "servers", len(results))
+		return ctrl.Result{RequeueAfter: failedSyncRetry}, nil
+	}
+	resync := r.ResyncPeriod
+	if resync <= 0 {
+		resync = defaultResyncPeriod
+	}
+	return ctrl.Result{RequeueAfter: resync}, nil
+}
+
+// syncServer checks that server has loaded the zone, rewrites its SOA and NS
+// records where they differ from apex and returns the serial it ends up at
+func (r *DNSZoneReconciler) syncServer(ctx context.Context, config *solverconfig.Config, server, secret string,
+	apex zoneApex) (uint32, error) {
+	live, err := r.loadedSOA(ctx, server, config.Zone)
+	if err != nil {
+		return 0, err
+	}
+	client := newZoneClient(config, server, secret, r.Logger)
+
+	if !sameSOA(apex.soa, live) {
+		soa := dns.Copy(apex.soa).(*dns.SOA)
+		soa.Serial = live.Serial + 1
+		if err := client.ReplaceRRset(ctx, config.Zone, dns.TypeSOA, []dns.RR{soa}); err != nil {
+			return live.Serial, err
+		}
+	}
+	ns, err := rfc2136.QueryRRset(ctx, server, config.Zone, dns.TypeNS, zoneQueryTimeout)
+	if err != nil {
+		return live.Serial, err
+	}
+	if len(rfc2136.PlanChanges(apex.ns, ns, false)) > 0 {
+		if err := client.ReplaceRRset(ctx, config.Zone, dns.TypeNS, apex.ns); err != nil {
+			return live.Serial, err
+		}
+	}
+
+	live, err = r.loadedSOA(ctx, server, config.Zone)
+	if err != nil {
+		return 0, err
+	}
+	return live.Serial, nil
+}
+
+// loadedSOA returns the SOA of zone on server, failing with errZoneNotLoaded
+// when the server does not serve the zone itself
+func (r *DNSZoneReconciler) loadedSOA(ctx context.Context, server, zone string) (*dns.SOA, error) {
+	soa, err := rfc2136.QuerySOA(ctx, server, zone, zoneQueryTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errZoneNotLoaded, err)
+	}
+	if !strings.EqualFold(soa.Hdr.Name, zone) {
+		return nil, fmt.Errorf("%w: server answers from zone %s", errZoneNotLoaded, soa.Hdr.Name)
+	}
+	return soa, nil
+}
+
+// applyStatus records the outcome of a sync on zone and returns the number
+// of servers that serve the desired apex records
+func (r *DNSZoneReconciler) applyStatus(zone *dnsv1alpha1.DNSZone, results []serverResult,
+	serials map[string]uint32) int {
+	previous := make(map[string]dnsv1alpha1.DNSZoneServerStatus, len(zone.Status.Servers))
+	for _, status := range zone.Status.Servers {
+		previous[status.Server] = status
+	}
+
+	now := metav1.Now()
+	synced, notLoaded := 0, 0
+	var serial int64
+	servers := make([]dnsv1alpha1.DNSZoneServerStatus, 0, len(results))
+	var failures []string
+	for _, result := range results {
+		status := dnsv1alpha1.DNSZoneServerStatus{
+			Server: result.server,
+			Loaded: !errors.Is(result.err, errZoneNotLoaded),
+			Serial: int64(serials[result.server]),
+		}
+		serial = max(serial, status.Serial)
+		if result.err == nil {
+			synced++
+			status.LastSyncTime = &now
+		} else {
+			status.Message = result.err.Error()
+			status.LastSyncTime = previous[result.server].LastSyncTime
+			failures = append(failures, fmt.Sprintf("%s: %v", result.server, result.err))
+			if !status.Loaded {
+				notLoaded++
+			}
+		}
+		servers = append(servers, status)
+	}
+
+	zone.Status.ObservedGeneration = zone.Generation
+	zone.Status.Serial = serial
+	zone.Status.Servers = servers
+
+	condition := metav1.Condition{
+		Type:               dnsv1alpha1.ConditionReady,
+		Status:             metav1.ConditionTrue,
+		Reason:             dnsv1alpha1.ReasonZoneSynced,
+		Message:            fmt.Sprintf("%d/%d servers synced", synced, len(results)),
+		ObservedGeneration: zone.Generation,
+	}
+	if synced < len(results) {
+		condition.Status = metav1.ConditionFalse
+		switch {
+		case notLoaded > 0:
+			condition.Reason = dnsv1alpha1.ReasonZoneNotLoaded
+		case synced == 0:
+			condition.Reason = dnsv1alpha1.ReasonSyncFailed
+		default:
+			condition.Reason = dnsv1alpha1.ReasonPartiallySynced
+		}
+		condition.Message += "; " + strings.Join(failures, "; ")
+	}
+	meta.SetStatusCondition(&zone.Status.Conditions, condition)
+	return synced
+}
+
+// tsigSecret reads the TSIG secret of config from the namespace of the zone
+// and takes over the key name and algorithm the Secret carries, if any
+func (r *DNSZoneReconciler) tsigSecret(ctx context.Context, namespace string, config *solverconfig.Config) (string, error) {
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: namespace, Name: config.TSIGSecretName}, secret); err != nil {
+		return "", fmt.Errorf("failed to get TSIG secret %s/%s: %w", namespace, config.TSIGSecretName, err)
+	}
+	value, ok := secret.Data[config.TSIGSecretKey]
+	if !ok || len(value) == 0 {
+		return "", fmt.Errorf("secret %s/%s has no key %s", namespace, config.TSIGSecretName, config.TSIGSecretKey)
+	}
+	config.TSIGKeyName, config.TSIGAlgorithm = solverconfig.TSIGKeyFromSecret(secret.Data, config.TSIGKeyName, config.TSIGAlgorithm)
+	return string(value), nil
+}
+
+// setFailed records a failure that happened before any server was contacted
+func (r *DNSZoneReconciler) setFailed(ctx context.Context, zone *dnsv1alpha1.DNSZone, reason string, cause error) error {
+	zone.Status.ObservedGeneration = zone.Generation
+	meta.SetStatusCondition(&zone.Status.Conditions, metav1.Condition{
+		Type:               dnsv1alpha1.ConditionReady,
+		Status:             metav1.ConditionFalse,
+		Reason:             reason,
+		Message:            cause.Error(),
+		ObservedGeneration: zone.Generation,
+	})
+	return r.Status().Update(ctx, zone)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *DNSZoneReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&dnsv1alpha1.DNSZone{}).
+		Owns(&corev1.ConfigMap{}).
+		Named("dnszone").
+		Complete(r)
+}
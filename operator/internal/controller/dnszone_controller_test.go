@@ -0,0 +1,187 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dnsv1alpha1 "github.com/rieset/istio-dns01-bind9/api/v1alpha1"
+	rfc2136 "github.com/rieset/istio-dns01-bind9/internal/dns"
+	"github.com/rieset/istio-dns01-bind9/internal/dnstest"
+)
+
+func newZone(addrs []string) *dnsv1alpha1.DNSZone {
+	return &dnsv1alpha1.DNSZone{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: testNamespace},
+		Spec: dnsv1alpha1.DNSZoneSpec{
+			Zone:           "example.com",
+			Servers:        addrs,
+			Nameservers:    []string{"ns1.example.net", "ns2.example.net"},
+			SOA:            &dnsv1alpha1.DNSZoneSOA{Hostmaster: "admin.example.com", Minimum: 60},
+			TTL:            600,
+			AlsoNotify:     []string{"192.0.2.10", "[2001:db8::10]:5353"},
+			AllowTransfer:  []string{"10.0.0.0/8"},
+			TSIGKeyName:    dnstest.TestKeyName,
+			TSIGSecretName: "tsig",
+		},
+	}
+}
+
+func reconcileZone(t *testing.T, r *DNSZoneReconciler) (*dnsv1alpha1.DNSZone, ctrl.Result) {
+	t.Helper()
+	key := client.ObjectKey{Namespace: testNamespace, Name: "example"}
+	result, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key})
+	if err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	zone := &dnsv1alpha1.DNSZone{}
+	if err := r.Get(context.Background(), key, zone); err != nil {
+		t.Fatal(err)
+	}
+	return zone, result
+}
+
+func newZoneReconciler(t *testing.T, zone *dnsv1alpha1.DNSZone) *DNSZoneReconciler {
+	t.Helper()
+	records := newTestReconciler(t, zone)
+	return &DNSZoneReconciler{Client: records.Client, Scheme: records.Scheme}
+}
+
+func TestDNSZoneReconcile(t *testing.T) {
+	servers, addrs := startServers(t, 2)
+	r := newZoneReconciler(t, newZone(addrs))
+
+	zone, result := reconcileZone(t, r)
+	if !meta.IsStatusConditionTrue(zone.Status.Conditions, dnsv1alpha1.ConditionReady) {
+		t.Fatalf("Ready condition not true: %+v", zone.Status.Conditions)
+	}
+	if result.RequeueAfter != defaultResyncPeriod {
+		t.Fatalf("RequeueAfter = %v, want the resync period", result.RequeueAfter)
+	}
+	for i, srv := range servers {
+		if got, want := srv.Records("example.com", dns.TypeNS), []string{"ns1.example.net.", "ns2.example.net."}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("NS records = %v, want %v", got, want)
+		}
+		soa, err := rfc2136.QuerySOA(context.Background(), addrs[i], "example.com", time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if soa.Ns != "ns1.example.net." || soa.Mbox != "admin.example.com." || soa.Minttl != 60 || soa.Hdr.Ttl != 600 {
+			t.Fatalf("SOA = %v, want the fields of the spec", soa)
+		}
+		status := zone.Status.Servers[i]
+		if !status.Loaded || status.Serial != int64(srv.Serial("example.com")) || status.LastSyncTime == nil {
+			t.Fatalf("server status = %+v, want loaded at serial %d", status, srv.Serial("example.com"))
+		}
+	}
+	if zone.Status.Serial != int64(servers[0].Serial("example.com")) {
+		t.Fatalf("status serial = %d, want %d", zone.Status.Serial, servers[0].Serial("example.com"))
+	}
+
+	configMap := &corev1.ConfigMap{}
+	if err := r.Get(context.Background(), client.ObjectKey{Namespace: testNamespace, Name: "example-zone"}, configMap); err != nil {
+		t.Fatal(err)
+	}
+	if !metav1.IsControlledBy(configMap, zone) {
+		t.Fatalf("ConfigMap is not controlled by the DNSZone")
+	}
+	statement := configMap.Data[zoneConfigKey]
+	for _, want := range []string{
+		`zone "example.com" {`,
+		`file "/var/lib/bind/db.example.com";`,
+		`allow-update { key "` + dnstest.TestKeyName + `"; };`,
+		`allow-transfer { key "` + dnstest.TestKeyName + `"; 10.0.0.0/8; };`,
+		`also-notify { 192.0.2.10; 2001:db8::10 port 5353; };`,
+	} {
+		if !strings.Contains(statement, want) {
+			t.Fatalf("zone statement lacks %q:\n%s", want, statement)
+		}
+	}
+	if seed := configMap.Data["db.example.com"]; !strings.Contains(seed, "IN\tSOA\tns1.example.net. admin.example.com. 1 3600 600 604800 60") {
+		t.Fatalf("seed zone file lacks the SOA:\n%s", seed)
+	}
+
+	// A second pass finds the apex in sync and sends no updates
+	updates := servers[0].Updates()
+	reconcileZone(t, r)
+	if servers[0].Updates() != updates {
+		t.Fatalf("in-sync zone sent %d updates", servers[0].Updates()-updates)
+	}
+}
+
+func TestDNSZoneNotLoaded(t *testing.T) {
+	_, addrs := startServers(t, 1)
+	other := dnstest.NewServer("example.org")
+	if err := other.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = other.Close() })
+	r := newZoneReconciler(t, newZone(append(addrs, other.Addr())))
+
+	zone, result := reconcileZone(t, r)
+	ready := meta.FindStatusCondition(zone.Status.Conditions, dnsv1alpha1.ConditionReady)
+	if ready == nil || ready.Status != metav1.ConditionFalse || ready.Reason != dnsv1alpha1.ReasonZoneNotLoaded {
+		t.Fatalf("Ready condition = %+v, want False with reason %s", ready, dnsv1alpha1.ReasonZoneNotLoaded)
+	}
+	if result.RequeueAfter != failedSyncRetry {
+		t.Fatalf("RequeueAfter = %v, want the failed sync retry", result.RequeueAfter)
+	}
+	if !zone.Status.Servers[0].Loaded || zone.Status.Servers[1].Loaded || zone.Status.Servers[1].Message == "" {
+		t.Fatalf("server statuses = %+v, want only the first loaded", zone.Status.Servers)
+	}
+}
+
+func TestDNSZoneInvalidSpec(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(*dnsv1alpha1.DNSZoneSpec)
+	}{
+		{"no nameservers", func(s *dnsv1alpha1.DNSZoneSpec) { s.Nameservers = nil }},
+		{"invalid nameserver", func(s *dnsv1alpha1.DNSZoneSpec) { s.Nameservers = []string{"ns..example.net"} }},
+		{"also-notify host name", func(s *dnsv1alpha1.DNSZoneSpec) { s.AlsoNotify = []string{"ns2.example.net"} }},
+		{"allow-transfer breaking out", func(s *dnsv1alpha1.DNSZoneSpec) { s.AllowTransfer = []string{"any; }; zone"} }},
+		{"file with quote", func(s *dnsv1alpha1.DNSZoneSpec) { s.File = `/tmp/x"; include "/etc/passwd` }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zone := newZone([]string{"127.0.0.1:1"})
+			tt.mutate(&zone.Spec)
+			r := newZoneReconciler(t, zone)
+
+			got, _ := reconcileZone(t, r)
+			ready := meta.FindStatusCondition(got.Status.Conditions, dnsv1alpha1.ConditionReady)
+			if ready == nil || ready.Reason != dnsv1alpha1.ReasonInvalidSpec {
+				t.Fatalf("Ready condition = %+v, want reason %s", ready, dnsv1alpha1.ReasonInvalidSpec)
+			}
+			err := r.Get(context.Background(), client.ObjectKey{Namespace: testNamespace, Name: "example-zone"}, &corev1.ConfigMap{})
+			if err == nil {
+				t.Fatalf("ConfigMap rendered from an invalid spec")
+			}
+		})
+	}
+}
@@ -0,0 +1,249 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/miekg/dns"
+
+	dnsv1alpha1 "github.com/rieset/istio-dns01-bind9/api/v1alpha1"
+	rfc2136 "github.com/rieset/istio-dns01-bind9/internal/dns"
+	"github.com/rieset/istio-dns01-bind9/internal/solverconfig"
+)
+
+// FunctionRating: 78/100
+// - Complexity: MEDIUM
+// - Integrations: 1 (BIND9 configuration)
+// - External Risks: MEDIUM (rendered text is loaded by the name servers)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: zoneConfig, desiredZone, renderZoneStatement, renderZoneFile
+// Purpose: Validates a DNSZone and renders its BIND9 zone statement, seed zone file and apex records
+
+const (
+	// zoneConfigKey is the ConfigMap key the zone statement is rendered under
+	zoneConfigKey = "zone.conf"
+	// zoneFilePrefix prefixes the ConfigMap key and default path of the seed zone file
+	zoneFilePrefix = "db."
+	// defaultZoneDir is where zone files live in the BIND9 container
+	defaultZoneDir = "/var/lib/bind/"
+)
+
+// zoneApex is the SOA and NS RRsets a DNSZone keeps at the apex of its zone
+type zoneApex struct {
+	soa *dns.SOA
+	ns  []dns.RR
+}
+
+// zoneConfig validates the server settings of zone the way solver configs
+// are validated, so both share defaults and limits
+func zoneConfig(zone *dnsv1alpha1.DNSZone) (*solverconfig.Config, error) {
+	spec := zone.Spec
+	raw, err := json.Marshal(map[string]any{
+		"servers":        spec.Servers,
+		"zone":           spec.Zone,
+		"tsigKeyName":    spec.TSIGKeyName,
+		"tsigAlgorithm":  spec.TSIGAlgorithm,
+		"tsigSecretName": spec.TSIGSecretName,
+		"tsigSecretKey":  spec.TSIGSecretKey,
+		"ttl":            spec.TTL,
+		"transport":      spec.Transport,
+	})
+	if err != nil {
+		return nil, err
+	}
+	config, err := solverconfig.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	if config.Zone == "" {
+		return nil, fmt.Errorf("zone is required")
+	}
+	config.Zone = dns.Fqdn(strings.ToLower(config.Zone))
+	if config.TSIGKeyName == "" {
+		return nil, fmt.Errorf("tsigKeyName is required")
+	}
+	return config, validateZoneStatement(zone)
+}
+
+// ValidateDNSZone checks zone the way Reconcile does before it renders the
+// ConfigMap and returns its server settings, for admission to reject specs
+// that can never sync
+func ValidateDNSZone(zone *dnsv1alpha1.DNSZone) (*solverconfig.Config, error) {
+	config, err := zoneConfig(zone)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := desiredZone(zone, config.Zone); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// validateZoneStatement rejects values that would break out of the quoted
+// strings and address lists of the rendered zone statement
+func validateZoneStatement(zone *dnsv1alpha1.DNSZone) error {
+	spec := zone.Spec
+	if strings.ContainsAny(spec.File, "\"\n") {
+		return fmt.Errorf("file %q holds a quote or newline", spec.File)
+	}
+	for _, notify := range spec.AlsoNotify {
+		host, _, err := rfc2136.ParseServerAddress(notify)
+		if err != nil {
+			return fmt.Errorf("alsoNotify: %w", err)
+		}
+		if net.ParseIP(host) == nil {
+			return fmt.Errorf("alsoNotify %q is not an IP address", notify)
+		}
+	}
+	for _, element := range spec.AllowTransfer {
+		if strings.TrimSpace(element) == "" || strings.ContainsAny(element, ";{}\n") {
+			return fmt.Errorf("allowTransfer element %q is empty or holds ';', '{', '}' or a newline", element)
+		}
+	}
+	return nil
+}
+
+// zoneNames returns the zone file path and ConfigMap name of zone with their
+// defaults applied
+func zoneNames(zone *dnsv1alpha1.DNSZone, name string) (file, configMapName string) {
+	file, configMapName = zone.Spec.File, zone.Spec.ConfigMapName
+	if file == "" {
+		file = defaultZoneDir + zoneFilePrefix + strings.TrimSuffix(name, ".")
+	}
+	if configMapName == "" {
+		configMapName = zone.Name + "-zone"
+	}
+	return file, configMapName
+}
+
+// desiredZone builds the apex SOA and NS records of zone from its spec
+func desiredZone(zone *dnsv1alpha1.DNSZone, name string) (zoneApex, error) {
+	spec := zone.Spec
+	ttl := uint32(spec.TTL)
+	if ttl == 0 {
+		ttl = 3600
+	}
+	apex := zoneApex{}
+	for _, ns := range spec.Nameservers {
+		host := dns.Fqdn(strings.ToLower(ns))
+		if _, ok := dns.IsDomainName(host); !ok || host == "." {
+			return zoneApex{}, fmt.Errorf("nameserver %q is not a valid domain name", ns)
+		}
+		apex.ns = append(apex.ns, &dns.NS{
+			Hdr: dns.RR_Header{Name: name, Rrtype: dns.TypeNS, Class: dns.ClassINET, Ttl: ttl},
+			Ns:  host,
+		})
+	}
+	if len(apex.ns) == 0 {
+		return zoneApex{}, fmt.Errorf("nameservers are required")
+	}
+
+	soa := dnsv1alpha1.DNSZoneSOA{}
+	if spec.SOA != nil {
+		soa = *spec.SOA
+	}
+	primary := apex.ns[0].(*dns.NS).Ns
+	if soa.PrimaryNameserver != "" {
+		primary = dns.Fqdn(strings.ToLower(soa.PrimaryNameserver))
+	}
+	hostmaster := "hostmaster." + name
+	if soa.Hostmaster != "" {
+		hostmaster = dns.Fqdn(strings.ToLower(soa.Hostmaster))
+	}
+	for _, host := range []string{primary, hostmaster} {
+		if _, ok := dns.IsDomainName(host); !ok {
+			return zoneApex{}, fmt.Errorf("SOA name %q is not a valid domain name", host)
+		}
+	}
+	apex.soa = &dns.SOA{
+		Hdr:     dns.RR_Header{Name: name, Rrtype: dns.TypeSOA, Class: dns.ClassINET, Ttl: ttl},
+		Ns:      primary,
+		Mbox:    hostmaster,
+		Serial:  1,
+		Refresh: orDefault(soa.Refresh, 3600),
+		Retry:   orDefault(soa.Retry, 600),
+		Expire:  orDefault(soa.Expire, 604800),
+		Minttl:  orDefault(soa.Minimum, 300),
+	}
+	return apex, nil
+}
+
+// orDefault returns value, or def when value is not set
+func orDefault(value int32, def uint32) uint32 {
+	if value <= 0 {
+		return def
+	}
+	return uint32(value)
+}
+
+// sameSOA reports whether live carries the fields of want, ignoring the serial
+func sameSOA(want, live *dns.SOA) bool {
+	return strings.EqualFold(want.Ns, live.Ns) && strings.EqualFold(want.Mbox, live.Mbox) &&
+		want.Refresh == live.Refresh && want.Retry == live.Retry && want.Expire == live.Expire &&
+		want.Minttl == live.Minttl && want.Hdr.Ttl == live.Hdr.Ttl
+}
+
+// renderZoneStatement renders the BIND9 zone statement of zone. Updates and
+// transfers are allowed with the TSIG key; the key statement itself comes
+// from the TSIGKey ConfigMap or the server's own configuration.
+func renderZoneStatement(zone *dnsv1alpha1.DNSZone, name, keyName, file string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "// Generated from DNSZone %s/%s, do not edit\n", zone.Namespace, zone.Name)
+	fmt.Fprintf(&b, "zone %q {\n\ttype master;\n\tfile %q;\n", strings.TrimSuffix(name, "."), file)
+	fmt.Fprintf(&b, "\tallow-update { key %q; };\n", keyName)
+	fmt.Fprintf(&b, "\tallow-transfer { key %q;", keyName)
+	for _, element := range zone.Spec.AllowTransfer {
+		fmt.Fprintf(&b, " %s;", strings.TrimSpace(element))
+	}
+	b.WriteString(" };\n")
+	if len(zone.Spec.AlsoNotify) > 0 {
+		b.WriteString("\tnotify explicit;\n\talso-notify {")
+		for _, notify := range zone.Spec.AlsoNotify {
+			// Validated by validateZoneStatement
+			host, port, _ := rfc2136.ParseServerAddress(notify)
+			if port != "" {
+				host += " port " + port
+			}
+			fmt.Fprintf(&b, " %s;", host)
+		}
+		b.WriteString(" };\n")
+	}
+	b.WriteString("};\n")
+	return b.String()
+}
+
+// renderZoneFile renders the zone file BIND9 loads the zone from the first
+// time; later changes to the apex go through RFC2136 updates. source names
+// the resource it was generated from.
+func renderZoneFile(source, name string, apex zoneApex) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "; Seed zone file generated from %s\n", source)
+	fmt.Fprintf(&b, "$ORIGIN %s\n", name)
+	fmt.Fprintf(&b, "%s\n", apex.soa.String())
+	for _, ns := range apex.ns {
+		fmt.Fprintf(&b, "%s\n", ns.String())
+	}
+	return b.String()
+}
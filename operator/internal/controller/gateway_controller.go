@@ -0,0 +1,257 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	cmv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// FunctionRating: 78/100
+// - Complexity: MEDIUM
+// - Integrations: 3 (Kubernetes API, Istio and Gateway API Gateways, cert-manager Certificates)
+// - External Risks: MEDIUM (untyped Istio objects, creates and deletes Certificates)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: PARTIAL (Gateways are read as unstructured objects)
+// - Critical Issues: NONE
+//
+// Function: GatewayReconciler
+// Purpose: Provisions cert-manager Certificates for the HTTPS hosts of Istio and Gateway API Gateways
+
+// GatewayGVK is the Istio Gateway version the reconciler watches. Istio
+// serves v1beta1 from 1.10 on, so it works across the supported releases
+// without depending on the Istio client libraries.
+var GatewayGVK = schema.GroupVersionKind{Group: "networking.istio.io", Version: "v1beta1", Kind: "Gateway"}
+
+const (
+	// AnnotationClusterIssuer names the ClusterIssuer Certificates of a Gateway use
+	AnnotationClusterIssuer = "cert-manager.io/cluster-issuer"
+	// AnnotationIssuer names the Issuer, in the Gateway's namespace, Certificates of a Gateway use
+	AnnotationIssuer = "cert-manager.io/issuer"
+)
+
+// GatewayReconciler creates one Certificate per TLS credential of an Istio
+// or Gateway API Gateway, covering the hosts of the HTTPS servers or
+// listeners that use it. The Certificates are owned by the Gateway and
+// removed with it.
+type GatewayReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	// GVK selects the Gateway kind: GatewayGVK (the default) or GatewayAPIGatewayGVK
+	GVK schema.GroupVersionKind
+	// IssuerName and IssuerKind are used for Gateways without an issuer
+	// annotation; without them such Gateways are ignored
+	IssuerName string
+	IssuerKind string
+}
+
+// +kubebuilder:rbac:groups=networking.istio.io,resources=gateways,verbs=get;list;watch
+// +kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=gateways,verbs=get;list;watch
+// +kubebuilder:rbac:groups=cert-manager.io,resources=certificates,verbs=get;list;watch;create;update;patch;delete
+
+// Reconcile brings the Certificates of a Gateway in line with its HTTPS servers
+func (r *GatewayReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+
+	gateway := &unstructured.Unstructured{}
+	gateway.SetGroupVersionKind(r.gvk())
+	if err := r.Get(ctx, req.NamespacedName, gateway); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !gateway.GetDeletionTimestamp().IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	var desired map[string][]string
+	issuer, ok := r.issuerFor(gateway)
+	switch {
+	case ok && r.gvk() == GatewayAPIGatewayGVK:
+		desired = gatewayAPICredentials(gateway)
+	case ok:
+		desired = gatewayCredentials(gateway)
+	}
+
+	for credential, hosts := range desired {
+		if err := r.applyCertificate(ctx, gateway, issuer, credential, hosts); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// Certificates for credentials the Gateway no longer uses are removed
+	var certificates cmv1.CertificateList
+	if err := r.List(ctx, &certificates, client.InNamespace(gateway.GetNamespace())); err != nil {
+		return ctrl.Result{}, err
+	}
+	for i := range certificates.Items {
+		certificate := &certificates.Items[i]
+		if !metav1.IsControlledBy(certificate, gateway) {
+			continue
+		}
+		if _, wanted := desired[certificate.Spec.SecretName]; wanted {
+			continue
+		}
+		log.Info("Deleting Certificate no longer used by Gateway", "certificate", certificate.Name)
+		if err := r.Delete(ctx, certificate); client.IgnoreNotFound(err) != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	return ctrl.Result{}, nil
+}
+
+// gvk returns the Gateway kind the reconciler works on
+func (r *GatewayReconciler) gvk() schema.GroupVersionKind {
+	if r.GVK.Empty() {
+		return GatewayGVK
+	}
+	return r.GVK
+}
+
+// errForeignCertificate means a Certificate of the same name exists without
+// being controlled by the Gateway
+var errForeignCertificate = errors.New("certificate is not controlled by the gateway")
+
+// applyCertificate creates or updates the Certificate writing credential.
+// An existing Certificate of the same name that the Gateway does not control
+// is left alone.
+func (r *GatewayReconciler) applyCertificate(ctx context.Context, gateway *unstructured.Unstructured,
+	issuer cmmeta.ObjectReference, credential string, hosts []string) error {
+	log := logf.FromContext(ctx)
+	certificate := &cmv1.Certificate{ObjectMeta: metav1.ObjectMeta{
+		Name:      certificateName(gateway.GetName(), credential),
+		Namespace: gateway.GetNamespace(),
+	}}
+	result, err := controllerutil.CreateOrUpdate(ctx, r.Client, certificate, func() error {
+		if certificate.ResourceVersion != "" && !metav1.IsControlledBy(certificate, gateway) {
+			return errForeignCertificate
+		}
+		certificate.Spec.SecretName = credential
+		certificate.Spec.DNSNames = hosts
+		certificate.Spec.IssuerRef = issuer
+		return controllerutil.SetControllerReference(gateway, certificate, r.Scheme)
+	})
+	if errors.Is(err, errForeignCertificate) {
+		log.Info("Skipping Certificate not managed by the Gateway", "certificate", certificate.Name)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to apply Certificate for credential %s: %w", credential, err)
+	}
+	if result != controllerutil.OperationResultNone {
+		log.Info("Certificate for Gateway "+string(result), "certificate", certificate.Name, "hosts", hosts)
+	}
+	return nil
+}
+
+// issuerFor returns the issuer the Certificates of gateway use: its
+// annotation, or the reconciler's default
+func (r *GatewayReconciler) issuerFor(gateway *unstructured.Unstructured) (cmmeta.ObjectReference, bool) {
+	annotations := gateway.GetAnnotations()
+	switch {
+	case annotations[AnnotationClusterIssuer] != "":
+		return cmmeta.ObjectReference{Name: annotations[AnnotationClusterIssuer], Kind: cmv1.ClusterIssuerKind}, true
+	case annotations[AnnotationIssuer] != "":
+		return cmmeta.ObjectReference{Name: annotations[AnnotationIssuer], Kind: cmv1.IssuerKind}, true
+	case r.IssuerName != "":
+		kind := r.IssuerKind
+		if kind == "" {
+			kind = cmv1.ClusterIssuerKind
+		}
+		return cmmeta.ObjectReference{Name: r.IssuerName, Kind: kind}, true
+	}
+	return cmmeta.ObjectReference{}, false
+}
+
+// gatewayCredentials maps the credentialName of every HTTPS server of
+// gateway to the sorted hosts served with it
+func gatewayCredentials(gateway *unstructured.Unstructured) map[string][]string {
+	servers, _, _ := unstructured.NestedSlice(gateway.Object, "spec", "servers")
+	credentials := map[string][]string{}
+	for _, item := range servers {
+		server, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		protocol, _, _ := unstructured.NestedString(server, "port", "protocol")
+		credential, _, _ := unstructured.NestedString(server, "tls", "credentialName")
+		mode, _, _ := unstructured.NestedString(server, "tls", "mode")
+		if !strings.EqualFold(protocol, "HTTPS") || credential == "" || mode == "PASSTHROUGH" || mode == "ISTIO_MUTUAL" {
+			continue
+		}
+		hosts, _, _ := unstructured.NestedStringSlice(server, "hosts")
+		for _, host := range hosts {
+			if host = gatewayHost(host); host != "" && !slices.Contains(credentials[credential], host) {
+				credentials[credential] = append(credentials[credential], host)
+			}
+		}
+	}
+	for _, hosts := range credentials {
+		slices.Sort(hosts)
+	}
+	return credentials
+}
+
+// gatewayHost strips the namespace of a "namespace/host" Gateway host and
+// returns "" for hosts no certificate can be issued for
+func gatewayHost(host string) string {
+	if i := strings.LastIndex(host, "/"); i >= 0 {
+		host = host[i+1:]
+	}
+	if host == "*" || host == "" {
+		return ""
+	}
+	return host
+}
+
+// certificateName returns the name of the Certificate for credential of a
+// Gateway, bounded to the length of an object name
+func certificateName(gateway, credential string) string {
+	name := gateway + "-" + credential
+	if len(name) > 253 {
+		name = strings.TrimRight(name[:253], "-.")
+	}
+	return name
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *GatewayReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	gateway := &unstructured.Unstructured{}
+	gateway.SetGroupVersionKind(r.gvk())
+	name := "gateway"
+	if r.gvk() == GatewayAPIGatewayGVK {
+		name = "gatewayapi-gateway"
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		For(gateway).
+		Owns(&cmv1.Certificate{}).
+		Named(name).
+		Complete(r)
+}
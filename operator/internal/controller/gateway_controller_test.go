@@ -0,0 +1,210 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	cmv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newGateway(annotations map[string]string, servers ...any) *unstructured.Unstructured {
+	gateway := &unstructured.Unstructured{Object: map[string]any{
+		"spec": map[string]any{"servers": servers},
+	}}
+	gateway.SetGroupVersionKind(GatewayGVK)
+	gateway.SetNamespace("istio-system")
+	gateway.SetName("public")
+	gateway.SetUID("gateway-uid")
+	gateway.SetAnnotations(annotations)
+	return gateway
+}
+
+func httpsServer(credential string, hosts ...any) map[string]any {
+	return map[string]any{
+		"port":  map[string]any{"number": int64(443), "name": "https", "protocol": "HTTPS"},
+		"hosts": hosts,
+		"tls":   map[string]any{"mode": "SIMPLE", "credentialName": credential},
+	}
+}
+
+func newGatewayReconciler(t *testing.T, objects ...client.Object) *GatewayReconciler {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	for _, gvk := range []schema.GroupVersionKind{GatewayGVK, GatewayAPIGatewayGVK} {
+		scheme.AddKnownTypeWithName(gvk, &unstructured.Unstructured{})
+		scheme.AddKnownTypeWithName(listGVK(gvk), &unstructured.UnstructuredList{})
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+	return &GatewayReconciler{Client: c, Scheme: scheme}
+}
+
+func reconcileGateway(t *testing.T, r *GatewayReconciler) []cmv1.Certificate {
+	t.Helper()
+	req := ctrl.Request{NamespacedName: client.ObjectKey{Namespace: "istio-system", Name: "public"}}
+	if _, err := r.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	var certificates cmv1.CertificateList
+	if err := r.List(context.Background(), &certificates, client.InNamespace("istio-system")); err != nil {
+		t.Fatal(err)
+	}
+	return certificates.Items
+}
+
+func TestGatewayReconcileCreatesCertificates(t *testing.T) {
+	gateway := newGateway(map[string]string{AnnotationClusterIssuer: "letsencrypt-dns01"},
+		httpsServer("www-tls", "www.example.com", "ns1/api.example.com"),
+		httpsServer("www-tls", "www.example.com"),
+		httpsServer("wild-tls", "*.apps.example.com", "*"),
+		map[string]any{
+			"port":  map[string]any{"number": int64(80), "name": "http", "protocol": "HTTP"},
+			"hosts": []any{"plain.example.com"},
+		},
+		map[string]any{
+			"port":  map[string]any{"number": int64(443), "name": "tls", "protocol": "HTTPS"},
+			"hosts": []any{"passthrough.example.com"},
+			"tls":   map[string]any{"mode": "PASSTHROUGH"},
+		},
+	)
+	r := newGatewayReconciler(t, gateway)
+
+	certificates := reconcileGateway(t, r)
+	got := map[string][]string{}
+	for _, certificate := range certificates {
+		got[certificate.Spec.SecretName] = certificate.Spec.DNSNames
+		if certificate.Spec.IssuerRef.Name != "letsencrypt-dns01" || certificate.Spec.IssuerRef.Kind != cmv1.ClusterIssuerKind {
+			t.Fatalf("issuerRef = %+v", certificate.Spec.IssuerRef)
+		}
+		if !metav1.IsControlledBy(&certificate, gateway) {
+			t.Fatalf("Certificate %s is not controlled by the Gateway", certificate.Name)
+		}
+	}
+	want := map[string][]string{
+		"www-tls":  {"api.example.com", "www.example.com"},
+		"wild-tls": {"*.apps.example.com"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("certificates = %v, want %v", got, want)
+	}
+
+	// Dropping a server removes its Certificate
+	if err := unstructured.SetNestedSlice(gateway.Object, []any{httpsServer("www-tls", "www.example.com")},
+		"spec", "servers"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Update(context.Background(), gateway); err != nil {
+		t.Fatal(err)
+	}
+	certificates = reconcileGateway(t, r)
+	if len(certificates) != 1 || !reflect.DeepEqual(certificates[0].Spec.DNSNames, []string{"www.example.com"}) {
+		t.Fatalf("certificates after update = %+v, want only www-tls for www.example.com", certificates)
+	}
+}
+
+func TestGatewayReconcileIssuerSelection(t *testing.T) {
+	server := httpsServer("www-tls", "www.example.com")
+
+	r := newGatewayReconciler(t, newGateway(nil, server))
+	if certificates := reconcileGateway(t, r); len(certificates) != 0 {
+		t.Fatalf("certificates without issuer = %d, want 0", len(certificates))
+	}
+
+	r = newGatewayReconciler(t, newGateway(nil, server))
+	r.IssuerName = "default-issuer"
+	certificates := reconcileGateway(t, r)
+	if len(certificates) != 1 || certificates[0].Spec.IssuerRef.Name != "default-issuer" ||
+		certificates[0].Spec.IssuerRef.Kind != cmv1.ClusterIssuerKind {
+		t.Fatalf("certificates with default issuer = %+v", certificates)
+	}
+
+	r = newGatewayReconciler(t, newGateway(map[string]string{AnnotationIssuer: "local"}, server))
+	r.IssuerName = "default-issuer"
+	certificates = reconcileGateway(t, r)
+	if len(certificates) != 1 || certificates[0].Spec.IssuerRef.Name != "local" ||
+		certificates[0].Spec.IssuerRef.Kind != cmv1.IssuerKind {
+		t.Fatalf("certificates with annotated issuer = %+v", certificates)
+	}
+}
+
+func TestGatewayReconcileLeavesForeignCertificates(t *testing.T) {
+	foreign := &cmv1.Certificate{
+		ObjectMeta: metav1.ObjectMeta{Name: "public-www-tls", Namespace: "istio-system"},
+		Spec:       cmv1.CertificateSpec{SecretName: "www-tls", DNSNames: []string{"manual.example.com"}},
+	}
+	gateway := newGateway(map[string]string{AnnotationClusterIssuer: "letsencrypt-dns01"},
+		httpsServer("www-tls", "www.example.com"))
+	r := newGatewayReconciler(t, gateway, foreign)
+
+	certificates := reconcileGateway(t, r)
+	if len(certificates) != 1 || certificates[0].Spec.DNSNames[0] != "manual.example.com" {
+		t.Fatalf("certificates = %+v, want the unowned Certificate untouched", certificates)
+	}
+}
+
+func TestGatewayReconcileGatewayAPI(t *testing.T) {
+	listener := func(name, hostname, secret string) map[string]any {
+		return map[string]any{
+			"name": name, "hostname": hostname, "port": int64(443), "protocol": "HTTPS",
+			"tls": map[string]any{"mode": "Terminate", "certificateRefs": []any{map[string]any{"name": secret}}},
+		}
+	}
+	gateway := &unstructured.Unstructured{Object: map[string]any{
+		"spec": map[string]any{"gatewayClassName": "istio", "listeners": []any{
+			listener("www", "www.example.com", "www-tls"),
+			listener("api", "api.example.com", "www-tls"),
+			listener("any", "", "any-tls"),
+			map[string]any{
+				"name": "foreign", "hostname": "foreign.example.com", "port": int64(443), "protocol": "HTTPS",
+				"tls": map[string]any{"certificateRefs": []any{map[string]any{"name": "tls", "namespace": "other"}}},
+			},
+			map[string]any{"name": "http", "hostname": "plain.example.com", "port": int64(80), "protocol": "HTTP"},
+		}},
+	}}
+	gateway.SetGroupVersionKind(GatewayAPIGatewayGVK)
+	gateway.SetNamespace("istio-system")
+	gateway.SetName("public")
+	gateway.SetUID("gateway-api-uid")
+	gateway.SetAnnotations(map[string]string{AnnotationClusterIssuer: "letsencrypt-dns01"})
+	r := newGatewayReconciler(t, gateway)
+	r.GVK = GatewayAPIGatewayGVK
+
+	certificates := reconcileGateway(t, r)
+	if len(certificates) != 1 || certificates[0].Spec.SecretName != "www-tls" ||
+		!reflect.DeepEqual(certificates[0].Spec.DNSNames, []string{"api.example.com", "www.example.com"}) {
+		t.Fatalf("certificates = %+v, want www-tls for api and www", certificates)
+	}
+	if !metav1.IsControlledBy(&certificates[0], gateway) {
+		t.Fatalf("Certificate %s is not controlled by the Gateway", certificates[0].Name)
+	}
+}
@@ -0,0 +1,184 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// FunctionRating: 76/100
+// - Complexity: MEDIUM
+// - Integrations: 2 (Kubernetes API, Gateway API resources)
+// - External Risks: LOW (read-only listing of cluster objects)
+// - Unit Tests: YES (through the reconcilers)
+// - E2E Tests: NO
+// - Typing: PARTIAL (Gateway API objects are read as unstructured objects)
+// - Critical Issues: NONE
+//
+// Function: gatewayAPICredentials, collectGatewayAPIHostnames
+// Purpose: Reads TLS credentials, hostnames and addresses from Kubernetes Gateway API resources
+
+var (
+	// GatewayAPIGatewayGVK is the Kubernetes Gateway API Gateway version the
+	// reconcilers watch; v1beta1 is served by every release since v0.8
+	GatewayAPIGatewayGVK = schema.GroupVersionKind{Group: "gateway.networking.k8s.io", Version: "v1beta1", Kind: "Gateway"}
+	// HTTPRouteGVK is the Kubernetes Gateway API HTTPRoute version the hostname sync watches
+	HTTPRouteGVK = schema.GroupVersionKind{Group: "gateway.networking.k8s.io", Version: "v1beta1", Kind: "HTTPRoute"}
+)
+
+// listGVK returns the list kind of gvk
+func listGVK(gvk schema.GroupVersionKind) schema.GroupVersionKind {
+	return gvk.GroupVersion().WithKind(gvk.Kind + "List")
+}
+
+// gatewayAPICredentials maps the Secret of every terminating HTTPS listener
+// of a Gateway API gateway to the sorted hostnames of the listeners using it.
+// Listeners without a hostname, or referencing a Secret in another
+// namespace, are skipped.
+func gatewayAPICredentials(gateway *unstructured.Unstructured) map[string][]string {
+	listeners, _, _ := unstructured.NestedSlice(gateway.Object, "spec", "listeners")
+	credentials := map[string][]string{}
+	for _, item := range listeners {
+		listener, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		protocol, _, _ := unstructured.NestedString(listener, "protocol")
+		mode, _, _ := unstructured.NestedString(listener, "tls", "mode")
+		host, _, _ := unstructured.NestedString(listener, "hostname")
+		if protocol != "HTTPS" || mode == "Passthrough" || gatewayHost(host) == "" {
+			continue
+		}
+		refs, _, _ := unstructured.NestedSlice(listener, "tls", "certificateRefs")
+		if len(refs) == 0 {
+			continue
+		}
+		ref, _ := refs[0].(map[string]any)
+		group, _, _ := unstructured.NestedString(ref, "group")
+		kind, _, _ := unstructured.NestedString(ref, "kind")
+		namespace, _, _ := unstructured.NestedString(ref, "namespace")
+		name, _, _ := unstructured.NestedString(ref, "name")
+		if group != "" || (kind != "" && kind != "Secret") || (namespace != "" && namespace != gateway.GetNamespace()) || name == "" {
+			continue
+		}
+		if !slices.Contains(credentials[name], host) {
+			credentials[name] = append(credentials[name], host)
+		}
+	}
+	for _, hosts := range credentials {
+		slices.Sort(hosts)
+	}
+	return credentials
+}
+
+// gatewayAPIAddresses returns the IP addresses in the status of a Gateway
+// API gateway, as published by its implementation
+func gatewayAPIAddresses(gateway *unstructured.Unstructured) []string {
+	addresses, _, _ := unstructured.NestedSlice(gateway.Object, "status", "addresses")
+	var ips []string
+	for _, item := range addresses {
+		address, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		kind, _, _ := unstructured.NestedString(address, "type")
+		value, _, _ := unstructured.NestedString(address, "value")
+		if (kind == "" || kind == "IPAddress") && net.ParseIP(value) != nil && !slices.Contains(ips, value) {
+			ips = append(ips, value)
+		}
+	}
+	return ips
+}
+
+// collectGatewayAPIHostnames adds the listener hostnames of Gateway API
+// gateways and the hostnames of the HTTPRoutes attached to them to targets,
+// pointing at the addresses of the gateways
+func collectGatewayAPIHostnames(ctx context.Context, c client.Client, zone string, targets hostnameTargets) error {
+	gateways := &unstructured.UnstructuredList{}
+	gateways.SetGroupVersionKind(listGVK(GatewayAPIGatewayGVK))
+	if err := c.List(ctx, gateways); err != nil {
+		return fmt.Errorf("failed to list Gateway API Gateways: %w", err)
+	}
+	routes := &unstructured.UnstructuredList{}
+	routes.SetGroupVersionKind(listGVK(HTTPRouteGVK))
+	if err := c.List(ctx, routes); err != nil {
+		return fmt.Errorf("failed to list HTTPRoutes: %w", err)
+	}
+
+	gatewayIPs := map[string][]string{}
+	for i := range gateways.Items {
+		gateway := &gateways.Items[i]
+		ips := gatewayAPIAddresses(gateway)
+		gatewayIPs[gateway.GetNamespace()+"/"+gateway.GetName()] = ips
+		if len(ips) == 0 {
+			continue
+		}
+		listeners, _, _ := unstructured.NestedSlice(gateway.Object, "spec", "listeners")
+		for _, item := range listeners {
+			listener, ok := item.(map[string]any)
+			if !ok {
+				continue
+			}
+			host, _, _ := unstructured.NestedString(listener, "hostname")
+			if name, ok := zoneHostname(host, zone); ok {
+				targets.add(name, ips)
+			}
+		}
+	}
+
+	for i := range routes.Items {
+		route := &routes.Items[i]
+		parents, _, _ := unstructured.NestedSlice(route.Object, "spec", "parentRefs")
+		var ips []string
+		for _, item := range parents {
+			parent, ok := item.(map[string]any)
+			if !ok {
+				continue
+			}
+			group, found, _ := unstructured.NestedString(parent, "group")
+			kind, _, _ := unstructured.NestedString(parent, "kind")
+			if (found && group != GatewayAPIGatewayGVK.Group) || (kind != "" && kind != "Gateway") {
+				continue
+			}
+			namespace, _, _ := unstructured.NestedString(parent, "namespace")
+			if namespace == "" {
+				namespace = route.GetNamespace()
+			}
+			name, _, _ := unstructured.NestedString(parent, "name")
+			ips = append(ips, gatewayIPs[namespace+"/"+name]...)
+		}
+		if len(ips) == 0 {
+			continue
+		}
+		// Routes without hostnames inherit those of the listeners, which are
+		// already published with the gateway
+		hosts, _, _ := unstructured.NestedStringSlice(route.Object, "spec", "hostnames")
+		for _, host := range hosts {
+			if name, ok := zoneHostname(host, zone); ok {
+				targets.add(name, ips)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,241 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/rieset/istio-dns01-bind9/internal/solverconfig"
+	"github.com/rieset/istio-dns01-bind9/internal/workpool"
+)
+
+// FunctionRating: 76/100
+// - Complexity: MEDIUM
+// - Integrations: 3 (Kubernetes API, Istio resources, RFC2136 servers)
+// - External Risks: MEDIUM (writes and deletes address records across a zone)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: HostnameReconciler
+// Purpose: external-dns style A/AAAA records for Istio hostnames, guarded by a TXT ownership registry
+
+const (
+	// defaultHostnameSyncInterval is how often the zone is resynced without events
+	defaultHostnameSyncInterval = 5 * time.Minute
+	// hostnameTransferTimeout bounds the zone transfer and updates of one server
+	hostnameTransferTimeout = 2 * time.Minute
+)
+
+// hostnameSyncRequest is the single request every watched event maps to: the
+// sync always works on the whole zone
+var hostnameSyncRequest = reconcile.Request{NamespacedName: types.NamespacedName{Name: "hostname-sync"}}
+
+// HostnameReconciler publishes the hostnames of Istio Gateways and
+// VirtualServices as A/AAAA records pointing at the load balancer IPs of the
+// ingress gateways, on every server of Config
+type HostnameReconciler struct {
+	client.Client
+	// Config holds the servers, zone and TSIG settings in the solver config format
+	Config *solverconfig.Config
+	// SecretNamespace is where the TSIG Secret of Config lives
+	SecretNamespace string
+	// OwnerID tells the records of this operator apart from those of other
+	// instances sharing the zone
+	OwnerID string
+	// Pool runs the DNS updates, shared with the other controllers; nil runs them inline
+	Pool *workpool.Pool
+	// Logger is handed to the RFC2136 clients
+	Logger *zap.Logger
+	// Interval is how often the zone is resynced; zero means defaultHostnameSyncInterval
+	Interval time.Duration
+	// GatewayAPI adds the hostnames of Gateway API Gateways and HTTPRoutes
+	GatewayAPI bool
+	// ServiceEntries adds the hosts of ServiceEntries annotated with
+	// ServiceEntryPublishAnnotation, pointing at their static addresses
+	ServiceEntries bool
+}
+
+// +kubebuilder:rbac:groups=networking.istio.io,resources=gateways;virtualservices;serviceentries,verbs=get;list;watch
+// +kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=gateways;httproutes,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch
+
+// LoadHostnameSyncConfig reads the solver config file at path for the
+// hostname sync, which needs an rfc2136 zone signed with TSIG
+func LoadHostnameSyncConfig(path string) (*solverconfig.Config, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	config, err := solverconfig.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hostname sync config %s: %w", path, err)
+	}
+	switch {
+	case config.Zone == "":
+		return nil, fmt.Errorf("hostname sync config %s: zone is required", path)
+	case !config.OnlyRFC2136() || config.UsesSIG0():
+		return nil, fmt.Errorf("hostname sync config %s: only the rfc2136 provider with TSIG is supported", path)
+	}
+	return config, nil
+}
+
+// Reconcile converges every server of the zone on the current hostnames
+func (r *HostnameReconciler) Reconcile(ctx context.Context, _ ctrl.Request) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+
+	desired, err := collectHostnames(ctx, r.Client, r.Config.Zone, r.GatewayAPI, r.ServiceEntries)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	config := *r.Config
+	secret, err := r.tsigSecret(ctx, &config)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	results := forEachServer(ctx, r.Pool, config.Zone, config.Servers, func(ctx context.Context, server string) error {
+		return r.syncServer(ctx, &config, server, secret, desired)
+	})
+	var errs []error
+	for _, result := range results {
+		if result.err != nil {
+			errs = append(errs, fmt.Errorf("server %s: %w", result.server, result.err))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	log.V(1).Info("Hostnames synced", "hostnames", len(desired), "servers", len(results))
+	interval := r.Interval
+	if interval <= 0 {
+		interval = defaultHostnameSyncInterval
+	}
+	return ctrl.Result{RequeueAfter: interval}, nil
+}
+
+// syncServer transfers the zone from server and applies the planned changes
+func (r *HostnameReconciler) syncServer(ctx context.Context, config *solverconfig.Config, server, secret string,
+	desired hostnameTargets) error {
+	log := logf.FromContext(ctx).WithValues("server", server)
+	ctx, cancel := context.WithTimeout(ctx, hostnameTransferTimeout)
+	defer cancel()
+
+	client := newZoneClient(config, server, secret, r.Logger)
+	live, err := client.TransferZone(ctx)
+	if err != nil {
+		return err
+	}
+
+	changes, conflicts := planHostnames(desired, live, r.OwnerID, uint32(config.TTL))
+	for _, name := range conflicts {
+		log.Info("Skipping hostname with records not owned by this operator", "hostname", name)
+	}
+	for _, change := range changes {
+		log.Info("Applying hostname change", "change", change.String())
+		if len(change.records) == 0 {
+			err = client.DeleteRRset(ctx, change.name, change.rrtype)
+		} else {
+			err = client.ReplaceRRset(ctx, change.name, change.rrtype, change.records)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tsigSecret reads the TSIG secret of config from SecretNamespace and takes
+// over the key name and algorithm the Secret carries, if any
+func (r *HostnameReconciler) tsigSecret(ctx context.Context, config *solverconfig.Config) (string, error) {
+	secret := &corev1.Secret{}
+	key := client.ObjectKey{Namespace: r.SecretNamespace, Name: config.TSIGSecretName}
+	if err := r.Get(ctx, key, secret); err != nil {
+		return "", fmt.Errorf("failed to get TSIG secret %s: %w", key, err)
+	}
+	value := secret.Data[config.TSIGSecretKey]
+	if len(value) == 0 {
+		return "", fmt.Errorf("secret %s has no key %s", key, config.TSIGSecretKey)
+	}
+	config.TSIGKeyName, config.TSIGAlgorithm = solverconfig.TSIGKeyFromSecret(secret.Data, config.TSIGKeyName, config.TSIGAlgorithm)
+	return string(value), nil
+}
+
+// SetupWithManager sets up the controller with the Manager. Gateways,
+// VirtualServices, LoadBalancer Services and, with GatewayAPI, Gateway API
+// Gateways and HTTPRoutes all trigger a sync of the zone. With
+// ServiceEntries so do ServiceEntries that carry the publish annotation
+// before or after a change, so removing it or the entry deletes the records.
+func (r *HostnameReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	enqueue := handler.EnqueueRequestsFromMapFunc(func(context.Context, client.Object) []reconcile.Request {
+		return []reconcile.Request{hostnameSyncRequest}
+	})
+	gateway := &unstructured.Unstructured{}
+	gateway.SetGroupVersionKind(GatewayGVK)
+	virtualService := &unstructured.Unstructured{}
+	virtualService.SetGroupVersionKind(VirtualServiceGVK)
+	loadBalancers := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		svc, ok := obj.(*corev1.Service)
+		return ok && svc.Spec.Type == corev1.ServiceTypeLoadBalancer
+	})
+
+	b := ctrl.NewControllerManagedBy(mgr).
+		Named("hostname-sync").
+		Watches(gateway, enqueue).
+		Watches(virtualService, enqueue).
+		Watches(&corev1.Service{}, enqueue, builder.WithPredicates(loadBalancers))
+	if r.GatewayAPI {
+		for _, gvk := range []schema.GroupVersionKind{GatewayAPIGatewayGVK, HTTPRouteGVK} {
+			obj := &unstructured.Unstructured{}
+			obj.SetGroupVersionKind(gvk)
+			b = b.Watches(obj, enqueue)
+		}
+	}
+	if r.ServiceEntries {
+		serviceEntry := &unstructured.Unstructured{}
+		serviceEntry.SetGroupVersionKind(ServiceEntryGVK)
+		b = b.Watches(serviceEntry, enqueue, builder.WithPredicates(predicate.Funcs{
+			CreateFunc:  func(e event.CreateEvent) bool { return publishesServiceEntry(e.Object) },
+			DeleteFunc:  func(e event.DeleteEvent) bool { return publishesServiceEntry(e.Object) },
+			GenericFunc: func(e event.GenericEvent) bool { return publishesServiceEntry(e.Object) },
+			UpdateFunc: func(e event.UpdateEvent) bool {
+				return publishesServiceEntry(e.ObjectOld) || publishesServiceEntry(e.ObjectNew)
+			},
+		}))
+	}
+	return b.Complete(r)
+}
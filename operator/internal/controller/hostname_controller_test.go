@@ -0,0 +1,354 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/miekg/dns"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	rfc2136 "github.com/rieset/istio-dns01-bind9/internal/dns"
+	"github.com/rieset/istio-dns01-bind9/internal/dnstest"
+	"github.com/rieset/istio-dns01-bind9/internal/solverconfig"
+)
+
+func newVirtualService(name string, gateways []any, hosts ...any) *unstructured.Unstructured {
+	vs := &unstructured.Unstructured{Object: map[string]any{
+		"spec": map[string]any{"gateways": gateways, "hosts": hosts},
+	}}
+	vs.SetGroupVersionKind(VirtualServiceGVK)
+	vs.SetNamespace("apps")
+	vs.SetName(name)
+	return vs
+}
+
+func ingressService() *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "istio-ingressgateway", Namespace: "istio-system"},
+		Spec: corev1.ServiceSpec{
+			Type:     corev1.ServiceTypeLoadBalancer,
+			Selector: map[string]string{"istio": "ingressgateway", "app": "istio-ingressgateway"},
+		},
+		Status: corev1.ServiceStatus{LoadBalancer: corev1.LoadBalancerStatus{
+			Ingress: []corev1.LoadBalancerIngress{{IP: "192.0.2.10"}, {IP: "2001:db8::10"}},
+		}},
+	}
+}
+
+func newHostnameReconciler(t *testing.T, addrs []string, objects ...client.Object) *HostnameReconciler {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	for _, gvk := range []schema.GroupVersionKind{GatewayGVK, VirtualServiceGVK, GatewayAPIGatewayGVK, HTTPRouteGVK,
+		ServiceEntryGVK} {
+		scheme.AddKnownTypeWithName(gvk, &unstructured.Unstructured{})
+		scheme.AddKnownTypeWithName(listGVK(gvk), &unstructured.UnstructuredList{})
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "tsig", Namespace: testNamespace},
+		Data:       map[string][]byte{"secret": []byte(dnstest.TestSecret)},
+	}
+	config := &solverconfig.Config{
+		Servers:        addrs,
+		Zone:           "example.com",
+		TSIGKeyName:    dnstest.TestKeyName,
+		TSIGAlgorithm:  "hmac-sha256",
+		TSIGSecretName: "tsig",
+		TSIGSecretKey:  "secret",
+		TTL:            60,
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(append(objects, secret)...).Build()
+	return &HostnameReconciler{Client: c, Config: config, SecretNamespace: testNamespace, OwnerID: "test"}
+}
+
+func parseRRs(t *testing.T, lines ...string) []dns.RR {
+	t.Helper()
+	records := make([]dns.RR, 0, len(lines))
+	for _, line := range lines {
+		rr, err := dns.NewRR(line)
+		if err != nil {
+			t.Fatalf("bad record %q: %v", line, err)
+		}
+		records = append(records, rr)
+	}
+	return records
+}
+
+func reconcileHostnames(t *testing.T, r *HostnameReconciler) {
+	t.Helper()
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: hostnameSyncRequest.NamespacedName}); err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+}
+
+func TestHostnameReconcile(t *testing.T) {
+	servers, addrs := startServers(t, 2)
+	gateway := newGateway(nil, httpsServer("public-tls", "*/www.example.com", "other.org"))
+	if err := unstructured.SetNestedStringMap(gateway.Object, map[string]string{"istio": "ingressgateway"}, "spec", "selector"); err != nil {
+		t.Fatal(err)
+	}
+	vs := newVirtualService("api", []any{"istio-system/public", "mesh"}, "api.example.com", "legacy.example.com")
+	r := newHostnameReconciler(t, addrs, gateway, vs, ingressService())
+
+	// legacy.example.com was created by someone else and must survive
+	legacy := parseRRs(t, "legacy.example.com. 300 IN A 198.51.100.1")
+	if err := newZoneClient(r.Config, addrs[0], dnstest.TestSecret, nil).
+		ReplaceRRset(context.Background(), "legacy.example.com.", dns.TypeA, legacy); err != nil {
+		t.Fatal(err)
+	}
+
+	reconcileHostnames(t, r)
+	for _, srv := range servers {
+		for _, name := range []string{"www.example.com", "api.example.com"} {
+			if got, want := srv.Records(name, dns.TypeA), []string{"192.0.2.10"}; !reflect.DeepEqual(got, want) {
+				t.Fatalf("%s A = %v, want %v", name, got, want)
+			}
+			if got, want := srv.Records(name, dns.TypeAAAA), []string{"2001:db8::10"}; !reflect.DeepEqual(got, want) {
+				t.Fatalf("%s AAAA = %v, want %v", name, got, want)
+			}
+			if got, want := srv.TXT(rfc2136.RegistryName(name+".")), []string{rfc2136.Ownership{Owner: "test"}.Marker()}; !reflect.DeepEqual(got, want) {
+				t.Fatalf("%s registry = %v, want %v", name, got, want)
+			}
+		}
+	}
+	if got, want := servers[0].Records("legacy.example.com", dns.TypeA), []string{"198.51.100.1"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("foreign A = %v, want %v", got, want)
+	}
+	if got := servers[1].Records("legacy.example.com", dns.TypeA); !reflect.DeepEqual(got, []string{"192.0.2.10"}) {
+		t.Fatalf("unclaimed A on second server = %v, want the gateway IP", got)
+	}
+
+	// Dropping the VirtualService removes only the records it caused
+	if err := r.Delete(context.Background(), vs); err != nil {
+		t.Fatal(err)
+	}
+	reconcileHostnames(t, r)
+	for _, srv := range servers {
+		if got := srv.Records("api.example.com", dns.TypeA); len(got) != 0 {
+			t.Fatalf("api A = %v, want none", got)
+		}
+		if got := srv.TXT(rfc2136.RegistryName("api.example.com.")); len(got) != 0 {
+			t.Fatalf("api registry = %v, want none", got)
+		}
+		if got := srv.Records("www.example.com", dns.TypeA); len(got) != 1 {
+			t.Fatalf("www A = %v, want kept", got)
+		}
+	}
+	if got := servers[0].Records("legacy.example.com", dns.TypeA); !reflect.DeepEqual(got, []string{"198.51.100.1"}) {
+		t.Fatalf("foreign A = %v, want untouched", got)
+	}
+}
+
+func TestHostnameReconcileGatewayAPI(t *testing.T) {
+	servers, addrs := startServers(t, 1)
+	gateway := newGateway(nil, httpsServer("public-tls", "www.example.com"))
+	if err := unstructured.SetNestedStringMap(gateway.Object, map[string]string{"istio": "ingressgateway"}, "spec", "selector"); err != nil {
+		t.Fatal(err)
+	}
+	apiGateway := &unstructured.Unstructured{Object: map[string]any{
+		"spec": map[string]any{"gatewayClassName": "istio", "listeners": []any{
+			map[string]any{"name": "https", "hostname": "*.apps.example.com", "port": int64(443), "protocol": "HTTPS"},
+		}},
+		"status": map[string]any{"addresses": []any{
+			map[string]any{"type": "IPAddress", "value": "192.0.2.20"},
+			map[string]any{"type": "Hostname", "value": "lb.example.net"},
+		}},
+	}}
+	apiGateway.SetGroupVersionKind(GatewayAPIGatewayGVK)
+	apiGateway.SetNamespace("gateways")
+	apiGateway.SetName("shared")
+	route := &unstructured.Unstructured{Object: map[string]any{
+		"spec": map[string]any{
+			"parentRefs": []any{map[string]any{"name": "shared", "namespace": "gateways"}},
+			"hostnames":  []any{"shop.example.com", "www.example.com"},
+		},
+	}}
+	route.SetGroupVersionKind(HTTPRouteGVK)
+	route.SetNamespace("apps")
+	route.SetName("shop")
+
+	r := newHostnameReconciler(t, addrs, gateway, apiGateway, route, ingressService())
+	reconcileHostnames(t, r)
+	if got := servers[0].Records("shop.example.com", dns.TypeA); len(got) != 0 {
+		t.Fatalf("shop A without GatewayAPI = %v, want none", got)
+	}
+
+	r.GatewayAPI = true
+	reconcileHostnames(t, r)
+	tests := map[string][]string{
+		"*.apps.example.com": {"192.0.2.20"},
+		"shop.example.com":   {"192.0.2.20"},
+		"www.example.com":    {"192.0.2.10", "192.0.2.20"},
+	}
+	for name, want := range tests {
+		if got := servers[0].Records(name, dns.TypeA); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s A = %v, want %v", name, got, want)
+		}
+	}
+	if got := servers[0].TXT(rfc2136.RegistryName("*.apps.example.com.")); !reflect.DeepEqual(got, []string{rfc2136.Ownership{Owner: "test"}.Marker()}) {
+		t.Errorf("wildcard registry = %v", got)
+	}
+}
+
+func newServiceEntry(name string, annotated bool, spec map[string]any) *unstructured.Unstructured {
+	entry := &unstructured.Unstructured{Object: map[string]any{"spec": spec}}
+	entry.SetGroupVersionKind(ServiceEntryGVK)
+	entry.SetNamespace("external")
+	entry.SetName(name)
+	if annotated {
+		entry.SetAnnotations(map[string]string{ServiceEntryPublishAnnotation: "true"})
+	}
+	return entry
+}
+
+func TestHostnameReconcileServiceEntries(t *testing.T) {
+	servers, addrs := startServers(t, 1)
+	database := newServiceEntry("database", true, map[string]any{
+		"hosts":      []any{"db.example.com", "db.other.org"},
+		"resolution": "STATIC",
+		"endpoints": []any{
+			map[string]any{"address": "10.1.0.5"},
+			map[string]any{"address": "2001:db8::5"},
+			map[string]any{"address": "db.internal"},
+		},
+	})
+	legacy := newServiceEntry("legacy", true, map[string]any{
+		"hosts":     []any{"legacy.example.com"},
+		"addresses": []any{"10.2.0.7", "10.3.0.0/16"},
+	})
+	private := newServiceEntry("private", false, map[string]any{
+		"hosts":     []any{"private.example.com"},
+		"endpoints": []any{map[string]any{"address": "10.4.0.1"}},
+	})
+
+	r := newHostnameReconciler(t, addrs, database, legacy, private)
+	reconcileHostnames(t, r)
+	if got := servers[0].Records("db.example.com", dns.TypeA); len(got) != 0 {
+		t.Fatalf("db A without ServiceEntries = %v, want none", got)
+	}
+
+	r.ServiceEntries = true
+	reconcileHostnames(t, r)
+	tests := []struct {
+		name   string
+		rrtype uint16
+		want   []string
+	}{
+		{"db.example.com", dns.TypeA, []string{"10.1.0.5"}},
+		{"db.example.com", dns.TypeAAAA, []string{"2001:db8::5"}},
+		{"legacy.example.com", dns.TypeA, []string{"10.2.0.7"}},
+		{"private.example.com", dns.TypeA, []string{}},
+	}
+	for _, tt := range tests {
+		if got := servers[0].Records(tt.name, tt.rrtype); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s %s = %v, want %v", tt.name, dns.TypeToString[tt.rrtype], got, tt.want)
+		}
+	}
+
+	// Deleting the ServiceEntry removes its records and their registry entry
+	if err := r.Delete(context.Background(), database); err != nil {
+		t.Fatal(err)
+	}
+	reconcileHostnames(t, r)
+	if got := servers[0].Records("db.example.com", dns.TypeA); len(got) != 0 {
+		t.Fatalf("db A after deleting the ServiceEntry = %v, want none", got)
+	}
+	if got := servers[0].TXT(rfc2136.RegistryName("db.example.com.")); len(got) != 0 {
+		t.Fatalf("db registry after deleting the ServiceEntry = %v, want none", got)
+	}
+	if got := servers[0].Records("legacy.example.com", dns.TypeA); len(got) != 1 {
+		t.Fatalf("legacy A = %v, want it kept", got)
+	}
+}
+
+func TestPlanHostnames(t *testing.T) {
+	desired := hostnameTargets{"www.example.com.": {"192.0.2.10"}}
+	tests := []struct {
+		name      string
+		live      []string
+		changes   []string
+		conflicts []string
+	}{
+		{
+			name:    "new hostname writes the registry first",
+			changes: []string{"replace _dns01-owner.www.example.com. TXT (1 records)", "replace www.example.com. A (1 records)"},
+		},
+		{
+			name: "owned and current",
+			live: []string{
+				`_dns01-owner.www.example.com. 60 IN TXT "heritage=istio-dns01-bind9,owner=test"`,
+				"www.example.com. 60 IN A 192.0.2.10",
+			},
+		},
+		{
+			name: "owned with a stale address family",
+			live: []string{
+				`_dns01-owner.www.example.com. 60 IN TXT "heritage=istio-dns01-bind9,owner=test"`,
+				"www.example.com. 60 IN A 192.0.2.9",
+				"www.example.com. 60 IN AAAA 2001:db8::9",
+			},
+			changes: []string{"replace www.example.com. A (1 records)", "delete www.example.com. AAAA"},
+		},
+		{
+			name:      "registry of another owner",
+			live:      []string{`_dns01-owner.www.example.com. 60 IN TXT "heritage=istio-dns01-bind9,owner=other"`},
+			conflicts: []string{"www.example.com."},
+		},
+		{
+			name: "owned hostname no longer desired",
+			live: []string{
+				`_dns01-owner.www.example.com. 60 IN TXT "heritage=istio-dns01-bind9,owner=test"`,
+				"www.example.com. 60 IN A 192.0.2.10",
+				`_dns01-owner._wildcard.apps.example.com. 60 IN TXT "heritage=istio-dns01-bind9,owner=test"`,
+				"*.apps.example.com. 60 IN A 192.0.2.10",
+			},
+			changes: []string{"delete *.apps.example.com. A", "delete _dns01-owner._wildcard.apps.example.com. TXT"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var live []dns.RR
+			for _, rr := range tt.live {
+				live = append(live, parseRRs(t, rr)...)
+			}
+			changes, conflicts := planHostnames(desired, live, "test", 60)
+			var got []string
+			for _, change := range changes {
+				got = append(got, change.String())
+			}
+			if !reflect.DeepEqual(got, tt.changes) {
+				t.Errorf("changes = %q, want %q", got, tt.changes)
+			}
+			if !reflect.DeepEqual(conflicts, tt.conflicts) {
+				t.Errorf("conflicts = %q, want %q", conflicts, tt.conflicts)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+
+	"github.com/miekg/dns"
+
+	rfc2136 "github.com/rieset/istio-dns01-bind9/internal/dns"
+)
+
+// FunctionRating: 80/100
+// - Complexity: MEDIUM
+// - Integrations: 1 (dns library)
+// - External Risks: MEDIUM (decides which live records may be replaced or deleted)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: planHostnames
+// Purpose: TXT ownership registry and the RRset changes converging a zone on the desired hostnames
+
+// hostnameChange replaces the RRset of type rrtype at name with records, or
+// deletes it when records is empty
+type hostnameChange struct {
+	name    string
+	rrtype  uint16
+	records []dns.RR
+}
+
+// String renders the change for logs
+func (c hostnameChange) String() string {
+	if len(c.records) == 0 {
+		return fmt.Sprintf("delete %s %s", c.name, dns.TypeToString[c.rrtype])
+	}
+	return fmt.Sprintf("replace %s %s (%d records)", c.name, dns.TypeToString[c.rrtype], len(c.records))
+}
+
+// planHostnames compares desired against the live records of a zone and
+// returns the changes that converge it. Only hostnames whose registry record
+// names owner are modified or deleted; a desired hostname that already holds
+// address, CNAME or registry records of another owner is reported as a
+// conflict and skipped.
+// The registry record of a hostname is written before and deleted after its
+// addresses, so an interrupted sync never leaves records nobody owns.
+func planHostnames(desired hostnameTargets, live []dns.RR, owner string, ttl uint32) ([]hostnameChange, []string) {
+	type key struct {
+		name   string
+		rrtype uint16
+	}
+	liveSets := map[key][]dns.RR{}
+	for _, rr := range live {
+		hdr := rr.Header()
+		k := key{name: dns.Fqdn(strings.ToLower(hdr.Name)), rrtype: hdr.Rrtype}
+		liveSets[k] = append(liveSets[k], rr)
+	}
+	marker := rfc2136.Ownership{Owner: owner}.Marker()
+	owned := func(name string) bool {
+		for _, rr := range liveSets[key{rfc2136.RegistryName(name), dns.TypeTXT}] {
+			if txt, ok := rr.(*dns.TXT); ok && strings.Join(txt.Txt, "") == marker {
+				return true
+			}
+		}
+		return false
+	}
+	registry := func(name string) hostnameChange {
+		rr := &dns.TXT{
+			Hdr: dns.RR_Header{Name: rfc2136.RegistryName(name), Rrtype: dns.TypeTXT, Class: dns.ClassINET, Ttl: ttl},
+			Txt: []string{marker},
+		}
+		return hostnameChange{name: rr.Hdr.Name, rrtype: dns.TypeTXT, records: []dns.RR{rr}}
+	}
+
+	var changes []hostnameChange
+	var conflicts []string
+	names := make([]string, 0, len(desired))
+	for name := range desired {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		if !owned(name) {
+			// Records or a registry entry of someone else leave the name alone
+			if len(liveSets[key{name, dns.TypeA}])+len(liveSets[key{name, dns.TypeAAAA}])+
+				len(liveSets[key{name, dns.TypeCNAME}])+len(liveSets[key{rfc2136.RegistryName(name), dns.TypeTXT}]) > 0 {
+				conflicts = append(conflicts, name)
+				continue
+			}
+			changes = append(changes, registry(name))
+		}
+
+		want := addressRecords(name, desired[name], ttl)
+		for _, rrtype := range []uint16{dns.TypeA, dns.TypeAAAA} {
+			have := liveSets[key{name, rrtype}]
+			switch {
+			case len(want[rrtype]) > 0 && len(rfc2136.PlanChanges(want[rrtype], have, false)) > 0:
+				changes = append(changes, hostnameChange{name: name, rrtype: rrtype, records: want[rrtype]})
+			case len(want[rrtype]) == 0 && len(have) > 0:
+				changes = append(changes, hostnameChange{name: name, rrtype: rrtype})
+			}
+		}
+	}
+
+	// Owned hostnames that are no longer desired are removed
+	var stale []string
+	for k := range liveSets {
+		name, ok := rfc2136.RegisteredName(k.name)
+		if k.rrtype != dns.TypeTXT || !ok {
+			continue
+		}
+		if _, wanted := desired[name]; !wanted && owned(name) {
+			stale = append(stale, name)
+		}
+	}
+	slices.Sort(stale)
+	for _, name := range stale {
+		for _, rrtype := range []uint16{dns.TypeA, dns.TypeAAAA} {
+			if len(liveSets[key{name, rrtype}]) > 0 {
+				changes = append(changes, hostnameChange{name: name, rrtype: rrtype})
+			}
+		}
+		changes = append(changes, hostnameChange{name: rfc2136.RegistryName(name), rrtype: dns.TypeTXT})
+	}
+	return changes, conflicts
+}
+
+// addressRecords splits ips into the A and AAAA records of name
+func addressRecords(name string, ips []string, ttl uint32) map[uint16][]dns.RR {
+	records := map[uint16][]dns.RR{}
+	for _, value := range ips {
+		ip := net.ParseIP(value)
+		hdr := dns.RR_Header{Name: name, Class: dns.ClassINET, Ttl: ttl}
+		if v4 := ip.To4(); v4 != nil {
+			hdr.Rrtype = dns.TypeA
+			records[dns.TypeA] = append(records[dns.TypeA], &dns.A{Hdr: hdr, A: v4})
+		} else if ip != nil {
+			hdr.Rrtype = dns.TypeAAAA
+			records[dns.TypeAAAA] = append(records[dns.TypeAAAA], &dns.AAAA{Hdr: hdr, AAAA: ip})
+		}
+	}
+	return records
+}
@@ -0,0 +1,183 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+
+	"github.com/miekg/dns"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// FunctionRating: 78/100
+// - Complexity: MEDIUM
+// - Integrations: 2 (Kubernetes API, Istio Gateways and VirtualServices)
+// - External Risks: LOW (read-only listing of cluster objects)
+// - Unit Tests: YES (through the reconciler)
+// - E2E Tests: NO
+// - Typing: PARTIAL (Istio objects are read as unstructured objects)
+// - Critical Issues: NONE
+//
+// Function: collectHostnames
+// Purpose: Maps the hostnames of Istio Gateways and VirtualServices, and optionally of Gateway API resources, to their ingress IPs
+
+// VirtualServiceGVK is the Istio VirtualService version the hostname sync watches
+var VirtualServiceGVK = schema.GroupVersionKind{Group: "networking.istio.io", Version: "v1beta1", Kind: "VirtualService"}
+
+// hostnameTargets maps fully qualified hostnames to the sorted IP addresses they resolve to
+type hostnameTargets map[string][]string
+
+// add adds ips to the targets of host
+func (t hostnameTargets) add(host string, ips []string) {
+	for _, ip := range ips {
+		if !slices.Contains(t[host], ip) {
+			t[host] = append(t[host], ip)
+		}
+	}
+	slices.Sort(t[host])
+}
+
+// collectHostnames lists Gateways, VirtualServices and LoadBalancer Services
+// and returns the hostnames inside zone with the IPs of the Services that
+// front the Gateways serving them. With gatewayAPI the hostnames of Gateway
+// API Gateways and HTTPRoutes are merged in, with serviceEntries the hosts of
+// annotated ServiceEntries.
+func collectHostnames(ctx context.Context, c client.Client, zone string, gatewayAPI, serviceEntries bool) (hostnameTargets, error) {
+	var services corev1.ServiceList
+	if err := c.List(ctx, &services); err != nil {
+		return nil, fmt.Errorf("failed to list Services: %w", err)
+	}
+	gateways := &unstructured.UnstructuredList{}
+	gateways.SetGroupVersionKind(listGVK(GatewayGVK))
+	if err := c.List(ctx, gateways); err != nil {
+		return nil, fmt.Errorf("failed to list Gateways: %w", err)
+	}
+	virtualServices := &unstructured.UnstructuredList{}
+	virtualServices.SetGroupVersionKind(listGVK(VirtualServiceGVK))
+	if err := c.List(ctx, virtualServices); err != nil {
+		return nil, fmt.Errorf("failed to list VirtualServices: %w", err)
+	}
+
+	zone = dns.Fqdn(strings.ToLower(zone))
+	targets := hostnameTargets{}
+	gatewayIPs := map[string][]string{}
+	for i := range gateways.Items {
+		gateway := &gateways.Items[i]
+		selector, _, _ := unstructured.NestedStringMap(gateway.Object, "spec", "selector")
+		ips := loadBalancerIPs(services.Items, selector)
+		gatewayIPs[gateway.GetNamespace()+"/"+gateway.GetName()] = ips
+		if len(ips) == 0 {
+			continue
+		}
+
+		servers, _, _ := unstructured.NestedSlice(gateway.Object, "spec", "servers")
+		for _, item := range servers {
+			server, ok := item.(map[string]any)
+			if !ok {
+				continue
+			}
+			hosts, _, _ := unstructured.NestedStringSlice(server, "hosts")
+			for _, host := range hosts {
+				if name, ok := zoneHostname(host, zone); ok {
+					targets.add(name, ips)
+				}
+			}
+		}
+	}
+
+	for i := range virtualServices.Items {
+		vs := &virtualServices.Items[i]
+		refs, _, _ := unstructured.NestedStringSlice(vs.Object, "spec", "gateways")
+		var ips []string
+		for _, ref := range refs {
+			if ref == "mesh" {
+				continue
+			}
+			if !strings.Contains(ref, "/") {
+				ref = vs.GetNamespace() + "/" + ref
+			}
+			ips = append(ips, gatewayIPs[ref]...)
+		}
+		if len(ips) == 0 {
+			continue
+		}
+		hosts, _, _ := unstructured.NestedStringSlice(vs.Object, "spec", "hosts")
+		for _, host := range hosts {
+			if name, ok := zoneHostname(host, zone); ok {
+				targets.add(name, ips)
+			}
+		}
+	}
+	if gatewayAPI {
+		if err := collectGatewayAPIHostnames(ctx, c, zone, targets); err != nil {
+			return nil, err
+		}
+	}
+	if serviceEntries {
+		if err := collectServiceEntryHostnames(ctx, c, zone, targets); err != nil {
+			return nil, err
+		}
+	}
+	return targets, nil
+}
+
+// loadBalancerIPs returns the load balancer IPs of the LoadBalancer Services
+// selecting the pods a Gateway with selector runs on
+func loadBalancerIPs(services []corev1.Service, selector map[string]string) []string {
+	if len(selector) == 0 {
+		return nil
+	}
+	match := labels.SelectorFromSet(selector)
+	var ips []string
+	for i := range services {
+		svc := &services[i]
+		if svc.Spec.Type != corev1.ServiceTypeLoadBalancer || len(svc.Spec.Selector) == 0 ||
+			!match.Matches(labels.Set(svc.Spec.Selector)) {
+			continue
+		}
+		for _, ingress := range svc.Status.LoadBalancer.Ingress {
+			if net.ParseIP(ingress.IP) != nil && !slices.Contains(ips, ingress.IP) {
+				ips = append(ips, ingress.IP)
+			}
+		}
+	}
+	return ips
+}
+
+// zoneHostname normalizes an Istio host, which may carry a "namespace/"
+// prefix, and reports whether it is a name inside zone records can be made for
+func zoneHostname(host, zone string) (string, bool) {
+	if i := strings.LastIndex(host, "/"); i >= 0 {
+		host = host[i+1:]
+	}
+	if host == "" || host == "*" {
+		return "", false
+	}
+	name := dns.Fqdn(strings.ToLower(host))
+	if _, ok := dns.IsDomainName(name); !ok || !dns.IsSubDomain(zone, name) {
+		return "", false
+	}
+	return name, true
+}
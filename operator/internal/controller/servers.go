@@ -0,0 +1,90 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"sync"
+
+	"go.uber.org/zap"
+
+	rfc2136 "github.com/rieset/istio-dns01-bind9/internal/dns"
+	"github.com/rieset/istio-dns01-bind9/internal/solverconfig"
+	"github.com/rieset/istio-dns01-bind9/internal/workpool"
+)
+
+// FunctionRating: 84/100
+// - Complexity: LOW
+// - Integrations: 1 (RFC2136 servers)
+// - External Risks: LOW (fan-out only, bounded by the worker pool)
+// - Unit Tests: YES (through the reconcilers)
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: newZoneClient, forEachServer
+// Purpose: RFC2136 clients and concurrent per-server fan-out shared by the reconcilers
+
+// serverResult is the outcome of the work done on one server
+type serverResult struct {
+	server string
+	err    error
+}
+
+// zoneConns is the connection pool shared by the clients of every reconciler
+var zoneConns = rfc2136.NewConnPool(0, 0)
+
+// newZoneClient returns an RFC2136 client for server under config
+func newZoneClient(config *solverconfig.Config, server, secret string, logger *zap.Logger) *rfc2136.RFC2136Client {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	client := rfc2136.NewRFC2136Client(server, config.Zone, config.TSIGKeyName, config.TSIGAlgorithm, secret, logger)
+	client.SetQuirks(config.ServerQuirks(server))
+	client.SetTransport(config.DNSTransport())
+	client.SetRetryPolicy(config.RetryPolicy())
+	client.SetConnPool(zoneConns)
+	client.SetTimeouts(config.ClientTimeouts(server))
+	if proxy := config.ServerProxy(server); proxy != nil {
+		client.SetProxy(proxy)
+	}
+	return client
+}
+
+// forEachServer runs fn for every server concurrently, through pool when it
+// is set, and returns the results in server order
+func forEachServer(ctx context.Context, pool *workpool.Pool, zone string, servers []string,
+	fn func(ctx context.Context, server string) error) []serverResult {
+	results := make([]serverResult, len(servers))
+	var wg sync.WaitGroup
+	for i, server := range servers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			run := func(ctx context.Context) error { return fn(ctx, server) }
+			var err error
+			if pool != nil {
+				err = pool.Do(ctx, zone, run)
+			} else {
+				err = run(ctx)
+			}
+			results[i] = serverResult{server: server, err: err}
+		}()
+	}
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// FunctionRating: 80/100
+// - Complexity: LOW
+// - Integrations: 2 (Kubernetes API, Istio ServiceEntries)
+// - External Risks: LOW (read-only listing of cluster objects)
+// - Unit Tests: YES (through the reconciler)
+// - E2E Tests: NO
+// - Typing: PARTIAL (ServiceEntries are read as unstructured objects)
+// - Critical Issues: NONE
+//
+// Function: collectServiceEntryHostnames
+// Purpose: Maps the hosts of annotated Istio ServiceEntries to their static addresses
+
+// ServiceEntryGVK is the Istio ServiceEntry version the hostname sync watches
+var ServiceEntryGVK = schema.GroupVersionKind{Group: "networking.istio.io", Version: "v1beta1", Kind: "ServiceEntry"}
+
+// ServiceEntryPublishAnnotation set to "true" on a ServiceEntry publishes
+// its hosts as A/AAAA records
+const ServiceEntryPublishAnnotation = "dns.bind9.io/publish"
+
+// publishesServiceEntry reports whether obj asks for its hosts to be published
+func publishesServiceEntry(obj client.Object) bool {
+	return obj.GetAnnotations()[ServiceEntryPublishAnnotation] == "true"
+}
+
+// serviceEntryAddresses returns the IP addresses the hosts of a ServiceEntry
+// resolve to: those of its endpoints, or else the plain IPs among its
+// addresses. Endpoints given by hostname and CIDR ranges have no record.
+func serviceEntryAddresses(entry *unstructured.Unstructured) []string {
+	var ips []string
+	endpoints, _, _ := unstructured.NestedSlice(entry.Object, "spec", "endpoints")
+	for _, item := range endpoints {
+		endpoint, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		address, _, _ := unstructured.NestedString(endpoint, "address")
+		if net.ParseIP(address) != nil && !slices.Contains(ips, address) {
+			ips = append(ips, address)
+		}
+	}
+	if len(ips) > 0 {
+		return ips
+	}
+	addresses, _, _ := unstructured.NestedStringSlice(entry.Object, "spec", "addresses")
+	for _, address := range addresses {
+		if net.ParseIP(address) != nil && !slices.Contains(ips, address) {
+			ips = append(ips, address)
+		}
+	}
+	return ips
+}
+
+// collectServiceEntryHostnames adds the hosts inside zone of the
+// ServiceEntries annotated with ServiceEntryPublishAnnotation to targets,
+// pointing at their static addresses
+func collectServiceEntryHostnames(ctx context.Context, c client.Client, zone string, targets hostnameTargets) error {
+	entries := &unstructured.UnstructuredList{}
+	entries.SetGroupVersionKind(listGVK(ServiceEntryGVK))
+	if err := c.List(ctx, entries); err != nil {
+		return fmt.Errorf("failed to list ServiceEntries: %w", err)
+	}
+	for i := range entries.Items {
+		entry := &entries.Items[i]
+		if !publishesServiceEntry(entry) {
+			continue
+		}
+		ips := serviceEntryAddresses(entry)
+		if len(ips) == 0 {
+			continue
+		}
+		hosts, _, _ := unstructured.NestedStringSlice(entry.Object, "spec", "hosts")
+		for _, host := range hosts {
+			if name, ok := zoneHostname(host, zone); ok {
+				targets.add(name, ips)
+			}
+		}
+	}
+	return nil
+}
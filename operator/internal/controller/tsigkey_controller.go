@@ -0,0 +1,199 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	dnsv1alpha1 "github.com/rieset/istio-dns01-bind9/api/v1alpha1"
+)
+
+// FunctionRating: 78/100
+// - Complexity: MEDIUM
+// - Integrations: 1 (Kubernetes API)
+// - External Risks: MEDIUM (writes credentials every solver and name server depends on)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: TSIGKeyReconciler
+// Purpose: Keeps the Secret and BIND9 ConfigMap of a TSIGKey in step with its rotation schedule
+
+// errNotControlled means an object of the name a TSIGKey writes exists
+// without being controlled by it
+var errNotControlled = errors.New("object exists and is not controlled by the TSIGKey")
+
+// TSIGKeyReconciler reconciles a TSIGKey object
+type TSIGKeyReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+
+	// now returns the current time; nil means time.Now
+	now func() time.Time
+}
+
+// +kubebuilder:rbac:groups=dns.istio-dns01-bind9.rieset.io,resources=tsigkeys,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=dns.istio-dns01-bind9.rieset.io,resources=tsigkeys/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups="",resources=secrets;configmaps,verbs=get;list;watch;create;update;patch;delete
+
+// Reconcile generates, publishes and rotates the keys of a TSIGKey. The
+// Secret holds the active key in the solver format plus the keys waiting for
+// activation or retirement; the ConfigMap publishes all of them to BIND9.
+func (r *TSIGKeyReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+
+	key := &dnsv1alpha1.TSIGKey{}
+	if err := r.Get(ctx, req.NamespacedName, key); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !key.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+	now := time.Now()
+	if r.now != nil {
+		now = r.now()
+	}
+	keyName, secretName, configMapName := tsigKeyNames(key)
+
+	secret := &corev1.Secret{}
+	err := r.Get(ctx, client.ObjectKey{Namespace: key.Namespace, Name: secretName}, secret)
+	switch {
+	case apierrors.IsNotFound(err):
+		// The first key is generated below
+	case err != nil:
+		return ctrl.Result{}, err
+	case !metav1.IsControlledBy(secret, key):
+		return ctrl.Result{}, r.setFailed(ctx, key, fmt.Errorf("secret %s: %w", secretName, errNotControlled))
+	}
+
+	state, err := advance(readKeyState(secret.Data), key, keyName, now)
+	if err != nil {
+		return ctrl.Result{}, r.setFailed(ctx, key, err)
+	}
+
+	// The ConfigMap is written first, so the name servers learn a new key
+	// before it can show up in the Secret
+	configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: configMapName, Namespace: key.Namespace}}
+	if err := r.apply(ctx, key, configMap, func() {
+		configMap.Data = map[string]string{tsigConfigKey: renderBINDKeys(key, keyName, state)}
+	}); err != nil {
+		return ctrl.Result{}, r.setFailed(ctx, key, fmt.Errorf("configmap %s: %w", configMapName, err))
+	}
+	secret = &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: secretName, Namespace: key.Namespace}}
+	if err := r.apply(ctx, key, secret, func() {
+		secret.Type = corev1.SecretTypeOpaque
+		secret.Data = state.data()
+	}); err != nil {
+		return ctrl.Result{}, r.setFailed(ctx, key, fmt.Errorf("secret %s: %w", secretName, err))
+	}
+
+	condition := metav1.Condition{
+		Type:               dnsv1alpha1.ConditionReady,
+		Status:             metav1.ConditionTrue,
+		Reason:             dnsv1alpha1.ReasonKeyActive,
+		Message:            fmt.Sprintf("key %s is active", state.active.name),
+		ObservedGeneration: key.Generation,
+	}
+	if state.pending != nil {
+		condition.Reason = dnsv1alpha1.ReasonRotating
+		condition.Message = fmt.Sprintf("key %s is active, %s becomes active at %s", state.active.name,
+			state.pending.name, key.Status.PendingActivationTime.UTC().Format(time.RFC3339))
+	}
+	key.Status.ObservedGeneration = key.Generation
+	meta.SetStatusCondition(&key.Status.Conditions, condition)
+	if err := r.Status().Update(ctx, key); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	log.V(1).Info("TSIG key reconciled", "active", key.Status.ActiveKeyName, "pending", key.Status.PendingKeyName,
+		"previous", key.Status.PreviousKeyName)
+	return ctrl.Result{RequeueAfter: nextStep(&key.Status, now)}, nil
+}
+
+// tsigKeyNames returns the key name, Secret name and ConfigMap name of key
+// with their defaults applied
+func tsigKeyNames(key *dnsv1alpha1.TSIGKey) (keyName, secretName, configMapName string) {
+	keyName, secretName, configMapName = key.Spec.KeyName, key.Spec.SecretName, key.Spec.ConfigMapName
+	if keyName == "" {
+		keyName = key.Name
+	}
+	if secretName == "" {
+		secretName = key.Name
+	}
+	if configMapName == "" {
+		configMapName = key.Name + "-bind"
+	}
+	return keyName, secretName, configMapName
+}
+
+// apply creates or updates obj, controlled by key, with mutate. An existing
+// object key does not control is left alone.
+func (r *TSIGKeyReconciler) apply(ctx context.Context, key *dnsv1alpha1.TSIGKey, obj client.Object, mutate func()) error {
+	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, obj, func() error {
+		if obj.GetResourceVersion() != "" && !metav1.IsControlledBy(obj, key) {
+			return errNotControlled
+		}
+		mutate()
+		return controllerutil.SetControllerReference(key, obj, r.Scheme)
+	})
+	return err
+}
+
+// setFailed records err on the Ready condition and returns it for a retry,
+// unless it is a conflict with an unmanaged object that needs an operator
+func (r *TSIGKeyReconciler) setFailed(ctx context.Context, key *dnsv1alpha1.TSIGKey, cause error) error {
+	key.Status.ObservedGeneration = key.Generation
+	meta.SetStatusCondition(&key.Status.Conditions, metav1.Condition{
+		Type:               dnsv1alpha1.ConditionReady,
+		Status:             metav1.ConditionFalse,
+		Reason:             dnsv1alpha1.ReasonKeyFailed,
+		Message:            cause.Error(),
+		ObservedGeneration: key.Generation,
+	})
+	if err := r.Status().Update(ctx, key); err != nil {
+		return err
+	}
+	if errors.Is(cause, errNotControlled) {
+		logf.FromContext(ctx).Info("Not overwriting an object the TSIGKey does not manage", "reason", cause.Error())
+		return nil
+	}
+	return cause
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *TSIGKeyReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&dnsv1alpha1.TSIGKey{}).
+		Owns(&corev1.Secret{}).
+		Owns(&corev1.ConfigMap{}).
+		Named("tsigkey").
+		Complete(r)
+}
@@ -0,0 +1,186 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	dnsv1alpha1 "github.com/rieset/istio-dns01-bind9/api/v1alpha1"
+)
+
+type tsigKeyFixture struct {
+	r   *TSIGKeyReconciler
+	now time.Time
+}
+
+func newTSIGKeyFixture(t *testing.T, objects ...client.Object) *tsigKeyFixture {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := dnsv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(objects...).
+		WithStatusSubresource(&dnsv1alpha1.TSIGKey{}).
+		Build()
+	f := &tsigKeyFixture{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	f.r = &TSIGKeyReconciler{Client: c, Scheme: scheme, now: func() time.Time { return f.now }}
+	return f
+}
+
+// reconcile runs the reconciler and returns the key, its Secret, its
+// rendered BIND config and the requeue delay
+func (f *tsigKeyFixture) reconcile(t *testing.T) (*dnsv1alpha1.TSIGKey, *corev1.Secret, string, time.Duration) {
+	t.Helper()
+	ctx := context.Background()
+	key := client.ObjectKey{Namespace: testNamespace, Name: "acme-update"}
+	result, err := f.r.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+	if err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	tsigKey := &dnsv1alpha1.TSIGKey{}
+	if err := f.r.Get(ctx, key, tsigKey); err != nil {
+		t.Fatal(err)
+	}
+	secret := &corev1.Secret{}
+	_ = f.r.Get(ctx, key, secret)
+	configMap := &corev1.ConfigMap{}
+	_ = f.r.Get(ctx, client.ObjectKey{Namespace: testNamespace, Name: "acme-update-bind"}, configMap)
+	return tsigKey, secret, configMap.Data[tsigConfigKey], result.RequeueAfter
+}
+
+func newTSIGKey(rotation time.Duration) *dnsv1alpha1.TSIGKey {
+	return &dnsv1alpha1.TSIGKey{
+		ObjectMeta: metav1.ObjectMeta{Name: "acme-update", Namespace: testNamespace},
+		Spec: dnsv1alpha1.TSIGKeySpec{
+			Algorithm:        "hmac-sha256",
+			RotationInterval: &metav1.Duration{Duration: rotation},
+			OverlapWindow:    &metav1.Duration{Duration: 10 * time.Minute},
+		},
+	}
+}
+
+func TestTSIGKeyReconcileRotation(t *testing.T) {
+	f := newTSIGKeyFixture(t, newTSIGKey(24*time.Hour))
+
+	key, secret, config, requeue := f.reconcile(t)
+	if got := string(secret.Data["key-name"]); got != "v1.acme-update" {
+		t.Fatalf("key-name = %q, want v1.acme-update", got)
+	}
+	if raw, err := base64.StdEncoding.DecodeString(string(secret.Data["secret"])); err != nil || len(raw) != 32 {
+		t.Fatalf("secret = %q (%v), want 32 random bytes", secret.Data["secret"], err)
+	}
+	if !metav1.IsControlledBy(secret, key) {
+		t.Fatal("Secret is not controlled by the TSIGKey")
+	}
+	v1Secret := string(secret.Data["secret"])
+	if !strings.Contains(config, `key "v1.acme-update" {`) || !strings.Contains(config, `secret "`+v1Secret+`";`) ||
+		!strings.Contains(config, "acl \"acme-update\" {\n\tkey \"v1.acme-update\";\n};") {
+		t.Fatalf("BIND config:\n%s", config)
+	}
+	if !meta.IsStatusConditionTrue(key.Status.Conditions, dnsv1alpha1.ConditionReady) || requeue != 24*time.Hour {
+		t.Fatalf("status = %+v, requeue %s", key.Status, requeue)
+	}
+
+	// Once due, the new key is only published to the name servers
+	f.now = f.now.Add(24 * time.Hour)
+	key, secret, config, requeue = f.reconcile(t)
+	if key.Status.PendingKeyName != "v2.acme-update" || string(secret.Data["key-name"]) != "v1.acme-update" ||
+		string(secret.Data["secret"]) != v1Secret || requeue != 10*time.Minute {
+		t.Fatalf("after due: status %+v, key-name %s, requeue %s", key.Status, secret.Data["key-name"], requeue)
+	}
+	if !strings.Contains(config, `key "v2.acme-update"`) || !strings.Contains(config, `key "v1.acme-update"`) {
+		t.Fatalf("BIND config while pending:\n%s", config)
+	}
+	if c := meta.FindStatusCondition(key.Status.Conditions, dnsv1alpha1.ConditionReady); c.Reason != dnsv1alpha1.ReasonRotating {
+		t.Fatalf("Ready reason = %s, want Rotating", c.Reason)
+	}
+
+	// After the overlap window clients switch, the old key stays published
+	f.now = f.now.Add(10 * time.Minute)
+	key, secret, config, _ = f.reconcile(t)
+	if string(secret.Data["key-name"]) != "v2.acme-update" || key.Status.PreviousKeyName != "v1.acme-update" ||
+		!strings.Contains(config, `key "v1.acme-update"`) {
+		t.Fatalf("after activation: status %+v, key-name %s", key.Status, secret.Data["key-name"])
+	}
+
+	// and is removed one window later
+	f.now = f.now.Add(10 * time.Minute)
+	key, _, config, requeue = f.reconcile(t)
+	if strings.Contains(config, "v1.acme-update") || key.Status.PreviousKeyName != "" || key.Status.Version != 2 {
+		t.Fatalf("after retirement: status %+v\n%s", key.Status, config)
+	}
+	if want := 24*time.Hour - 10*time.Minute; requeue != want {
+		t.Fatalf("requeue = %s, want %s", requeue, want)
+	}
+}
+
+func TestTSIGKeyReconcileAlgorithmChange(t *testing.T) {
+	tsigKey := newTSIGKey(0)
+	tsigKey.Spec.RotationInterval = nil
+	f := newTSIGKeyFixture(t, tsigKey)
+	key, _, _, requeue := f.reconcile(t)
+	if requeue != 0 || key.Status.NextRotationTime != nil {
+		t.Fatalf("unrotated key scheduled: requeue %s, status %+v", requeue, key.Status)
+	}
+
+	key.Spec.Algorithm = "hmac-sha512"
+	if err := f.r.Update(context.Background(), key); err != nil {
+		t.Fatal(err)
+	}
+	f.reconcile(t)
+	f.now = f.now.Add(10 * time.Minute)
+	_, secret, _, _ := f.reconcile(t)
+	if string(secret.Data["algorithm"]) != "hmac-sha512" || string(secret.Data["key-name"]) != "v2.acme-update" {
+		t.Fatalf("after algorithm change: %s %s", secret.Data["algorithm"], secret.Data["key-name"])
+	}
+	if raw, _ := base64.StdEncoding.DecodeString(string(secret.Data["secret"])); len(raw) != 64 {
+		t.Fatalf("hmac-sha512 secret has %d bytes, want 64", len(raw))
+	}
+}
+
+func TestTSIGKeyReconcileLeavesForeignSecret(t *testing.T) {
+	foreign := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "acme-update", Namespace: testNamespace},
+		Data:       map[string][]byte{"secret": []byte("manual")},
+	}
+	f := newTSIGKeyFixture(t, newTSIGKey(time.Hour), foreign)
+	key, secret, _, _ := f.reconcile(t)
+	if string(secret.Data["secret"]) != "manual" {
+		t.Fatalf("foreign Secret overwritten: %v", secret.Data)
+	}
+	if c := meta.FindStatusCondition(key.Status.Conditions, dnsv1alpha1.ConditionReady); c == nil || c.Reason != dnsv1alpha1.ReasonKeyFailed {
+		t.Fatalf("Ready condition = %+v, want KeyFailed", c)
+	}
+}
@@ -0,0 +1,271 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	dnsv1alpha1 "github.com/rieset/istio-dns01-bind9/api/v1alpha1"
+	"github.com/rieset/istio-dns01-bind9/internal/solverconfig"
+)
+
+// FunctionRating: 80/100
+// - Complexity: MEDIUM
+// - Integrations: 0
+// - External Risks: MEDIUM (generates key material, decides when keys are replaced)
+// - Unit Tests: YES (through the reconciler)
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: keyState, advance, renderBINDKeys
+// Purpose: Key generation, the pending/active/previous rotation steps and the BIND9 key statements of a TSIGKey
+
+const (
+	// tsigConfigKey is the ConfigMap key the BIND9 statements are rendered under
+	tsigConfigKey = "tsig.conf"
+	// defaultOverlapWindow is used when a TSIGKey does not set its overlap window
+	defaultOverlapWindow = 10 * time.Minute
+
+	// Secret keys of the keys waiting for activation and retirement; the
+	// active key uses the keys the solver reads
+	pendingPrefix  = "pending-"
+	previousPrefix = "previous-"
+)
+
+// tsigKeySizes are the secret lengths in bytes generated per algorithm,
+// matching the output size of the hash like tsig-keygen does
+var tsigKeySizes = map[string]int{
+	"hmac-sha1":   20,
+	"hmac-sha224": 28,
+	"hmac-sha256": 32,
+	"hmac-sha384": 48,
+	"hmac-sha512": 64,
+}
+
+// tsigMaterial is one generated key
+type tsigMaterial struct {
+	name      string
+	algorithm string
+	secret    string
+}
+
+// keyState holds the keys of a TSIGKey as stored in its Secret
+type keyState struct {
+	active, pending, previous *tsigMaterial
+}
+
+// readKeyState reads the keys stored in the data of a Secret
+func readKeyState(data map[string][]byte) keyState {
+	read := func(prefix string) *tsigMaterial {
+		key := &tsigMaterial{
+			name:      string(data[prefix+solverconfig.SecretKeyNameKey]),
+			algorithm: string(data[prefix+solverconfig.SecretAlgorithmKey]),
+			secret:    string(data[prefix+solverconfig.DefaultTSIGSecretKey]),
+		}
+		if key.name == "" || key.secret == "" {
+			return nil
+		}
+		return key
+	}
+	return keyState{active: read(""), pending: read(pendingPrefix), previous: read(previousPrefix)}
+}
+
+// data returns the Secret data holding the keys of s
+func (s keyState) data() map[string][]byte {
+	data := map[string][]byte{}
+	for prefix, key := range map[string]*tsigMaterial{"": s.active, pendingPrefix: s.pending, previousPrefix: s.previous} {
+		if key == nil {
+			continue
+		}
+		data[prefix+solverconfig.SecretKeyNameKey] = []byte(key.name)
+		data[prefix+solverconfig.SecretAlgorithmKey] = []byte(key.algorithm)
+		data[prefix+solverconfig.DefaultTSIGSecretKey] = []byte(key.secret)
+	}
+	return data
+}
+
+// version returns the highest key version in s
+func (s keyState) version() int64 {
+	var version int64
+	for _, key := range []*tsigMaterial{s.active, s.pending, s.previous} {
+		if key == nil {
+			continue
+		}
+		label, _, _ := strings.Cut(key.name, ".")
+		if v, err := strconv.ParseInt(strings.TrimPrefix(label, "v"), 10, 64); err == nil && v > version {
+			version = v
+		}
+	}
+	return version
+}
+
+// generateKey returns a new random key of algorithm named v<version>.<keyName>
+func generateKey(keyName, algorithm string, version int64) (*tsigMaterial, error) {
+	size, ok := tsigKeySizes[algorithm]
+	if !ok {
+		return nil, fmt.Errorf("unsupported algorithm %q", algorithm)
+	}
+	secret := make([]byte, size)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+	return &tsigMaterial{
+		name:      fmt.Sprintf("v%d.%s", version, keyName),
+		algorithm: algorithm,
+		secret:    base64.StdEncoding.EncodeToString(secret),
+	}, nil
+}
+
+// advance moves the keys of a TSIGKey one step along its rotation at now:
+// a pending key whose overlap window has passed becomes active, a previous
+// key whose window has passed is dropped, and a new pending key is generated
+// when none is in flight and the active key is due or no longer matches the
+// spec. Times are recorded in status; missing times restart their window, so
+// a lost status update only delays a step.
+func advance(state keyState, key *dnsv1alpha1.TSIGKey, keyName string, now time.Time) (keyState, error) {
+	status := &key.Status
+	overlap := defaultOverlapWindow
+	if key.Spec.OverlapWindow != nil {
+		overlap = key.Spec.OverlapWindow.Duration
+	}
+	algorithm := key.Spec.Algorithm
+	if algorithm == "" {
+		algorithm = solverconfig.DefaultTSIGAlgorithm
+	}
+	at := func(t time.Time) *metav1.Time {
+		mt := metav1.NewTime(t)
+		return &mt
+	}
+
+	if state.active == nil {
+		active, err := generateKey(keyName, algorithm, state.version()+1)
+		if err != nil {
+			return state, err
+		}
+		state.active = active
+		status.LastRotationTime = at(now)
+	}
+	if status.LastRotationTime == nil {
+		status.LastRotationTime = at(now)
+	}
+
+	if state.pending != nil {
+		if status.PendingActivationTime == nil {
+			status.PendingActivationTime = at(now.Add(overlap))
+		}
+		if !now.Before(status.PendingActivationTime.Time) {
+			state.previous, state.active, state.pending = state.active, state.pending, nil
+			status.PendingActivationTime = nil
+			status.PreviousExpiryTime = at(now.Add(overlap))
+			status.LastRotationTime = at(now)
+		}
+	} else {
+		status.PendingActivationTime = nil
+	}
+
+	if state.previous != nil {
+		if status.PreviousExpiryTime == nil {
+			status.PreviousExpiryTime = at(now.Add(overlap))
+		}
+		if !now.Before(status.PreviousExpiryTime.Time) {
+			state.previous = nil
+		}
+	}
+	if state.previous == nil {
+		status.PreviousExpiryTime = nil
+	}
+
+	status.NextRotationTime = nil
+	if interval := key.Spec.RotationInterval; interval != nil && interval.Duration > 0 {
+		status.NextRotationTime = at(status.LastRotationTime.Add(interval.Duration))
+	}
+	due := status.NextRotationTime != nil && !now.Before(status.NextRotationTime.Time)
+	changed := state.active.algorithm != algorithm || !strings.HasSuffix(state.active.name, "."+keyName)
+	if state.pending == nil && state.previous == nil && (due || changed) {
+		pending, err := generateKey(keyName, algorithm, state.version()+1)
+		if err != nil {
+			return state, err
+		}
+		state.pending = pending
+		status.PendingActivationTime = at(now.Add(overlap))
+	}
+
+	status.Version = state.version()
+	status.ActiveKeyName = state.active.name
+	status.PendingKeyName, status.PreviousKeyName = "", ""
+	if state.pending != nil {
+		status.PendingKeyName = state.pending.name
+	}
+	if state.previous != nil {
+		status.PreviousKeyName = state.previous.name
+	}
+	return state, nil
+}
+
+// nextStep returns how long until the next rotation step of status, or zero
+// when no step is scheduled. A rotation in flight is finished before the
+// next one starts.
+func nextStep(status *dnsv1alpha1.TSIGKeyStatus, now time.Time) time.Duration {
+	steps := []*metav1.Time{status.PendingActivationTime, status.PreviousExpiryTime}
+	if status.PendingKeyName == "" && status.PreviousKeyName == "" {
+		steps = append(steps, status.NextRotationTime)
+	}
+	var next time.Duration
+	for _, t := range steps {
+		if t == nil {
+			continue
+		}
+		d := t.Sub(now)
+		if d <= 0 {
+			d = time.Second
+		}
+		if next == 0 || d < next {
+			next = d
+		}
+	}
+	return next
+}
+
+// renderBINDKeys renders the key statements of every published key and an
+// acl of the same name as the TSIGKey's key name listing them all, for use
+// in allow-update and allow-transfer
+func renderBINDKeys(key *dnsv1alpha1.TSIGKey, keyName string, state keyState) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "// Generated from TSIGKey %s/%s, do not edit\n", key.Namespace, key.Name)
+	var names []string
+	for _, k := range []*tsigMaterial{state.active, state.pending, state.previous} {
+		if k == nil {
+			continue
+		}
+		fmt.Fprintf(&b, "key %q {\n\talgorithm %s;\n\tsecret %q;\n};\n", k.name, k.algorithm, k.secret)
+		names = append(names, k.name)
+	}
+	fmt.Fprintf(&b, "acl %q {\n", keyName)
+	for _, name := range names {
+		fmt.Fprintf(&b, "\tkey %q;\n", name)
+	}
+	b.WriteString("};\n")
+	return b.String()
+}
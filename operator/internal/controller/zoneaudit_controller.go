@@ -0,0 +1,366 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	dnsv1alpha1 "github.com/rieset/istio-dns01-bind9/api/v1alpha1"
+	rfc2136 "github.com/rieset/istio-dns01-bind9/internal/dns"
+	"github.com/rieset/istio-dns01-bind9/internal/metrics"
+	"github.com/rieset/istio-dns01-bind9/internal/solverconfig"
+	"github.com/rieset/istio-dns01-bind9/internal/workpool"
+)
+
+// FunctionRating: 76/100
+// - Complexity: MEDIUM
+// - Integrations: 2 (Kubernetes API, RFC2136 servers)
+// - External Risks: MEDIUM (transfers whole zones, rewrites drifted RRsets when healing)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: ZoneAuditReconciler
+// Purpose: Diffs the RRsets owned by DNSRecords against every server of a zone, reports drift and optionally repairs it
+
+const (
+	// defaultAuditInterval is how often a ZoneAudit runs without an interval set
+	defaultAuditInterval = 10 * time.Minute
+	// auditTransferTimeout bounds the transfer, queries and repairs of one server
+	auditTransferTimeout = 2 * time.Minute
+	// maxAuditDifferences caps the drifted RRsets named in the status of a server
+	maxAuditDifferences = 20
+)
+
+// ZoneAuditReconciler reconciles a ZoneAudit object
+type ZoneAuditReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	// Pool runs the transfers and repairs, shared with the other controllers; nil runs them inline
+	Pool *workpool.Pool
+	// Logger is handed to the RFC2136 clients
+	Logger *zap.Logger
+}
+
+// auditResult is the outcome of the audit of one server
+type auditResult struct {
+	method      string
+	drift       int
+	differences []string
+	healed      int
+}
+
+// +kubebuilder:rbac:groups=dns.istio-dns01-bind9.rieset.io,resources=zoneaudits,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=dns.istio-dns01-bind9.rieset.io,resources=zoneaudits/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=dns.istio-dns01-bind9.rieset.io,resources=dnsrecords,verbs=get;list;watch
+
+// Reconcile reads the zone from every audited server, by AXFR or, when the
+// transfer is refused, by querying each owned RRset, compares it with the
+// RRsets of the DNSRecords targeting the server and reports the drift.
+// With AutoHeal the drifted RRsets are rewritten.
+func (r *ZoneAuditReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+
+	audit := &dnsv1alpha1.ZoneAudit{}
+	if err := r.Get(ctx, req.NamespacedName, audit); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !audit.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+	interval := defaultAuditInterval
+	if audit.Spec.Interval != nil && audit.Spec.Interval.Duration > 0 {
+		interval = audit.Spec.Interval.Duration
+	}
+
+	config, err := auditConfig(audit)
+	if err != nil {
+		return ctrl.Result{}, r.setFailed(ctx, audit, dnsv1alpha1.ReasonInvalidSpec, err)
+	}
+	secret, err := r.tsigSecret(ctx, audit.Namespace, config)
+	if err != nil {
+		log.Error(err, "TSIG secret unavailable")
+		return ctrl.Result{RequeueAfter: failedSyncRetry},
+			r.setFailed(ctx, audit, dnsv1alpha1.ReasonCredentialsUnavailable, err)
+	}
+	owned, total, err := r.ownedRRsets(ctx, config)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	var mu sync.Mutex
+	audited := make(map[string]auditResult, len(config.Servers))
+	results := forEachServer(ctx, r.Pool, config.Zone, config.Servers, func(ctx context.Context, server string) error {
+		result, err := r.auditServer(ctx, config, server, secret, owned[server], audit.Spec.AutoHeal)
+		mu.Lock()
+		audited[server] = result
+		mu.Unlock()
+		return err
+	})
+
+	r.applyStatus(audit, config.Zone, total, results, audited)
+	if err := r.Status().Update(ctx, audit); err != nil {
+		return ctrl.Result{}, err
+	}
+	log.V(1).Info("Zone audited", "zone", config.Zone, "ownedRRsets", total, "servers", len(results))
+	return ctrl.Result{RequeueAfter: interval}, nil
+}
+
+// auditConfig validates the server settings of audit the way solver configs are validated
+func auditConfig(audit *dnsv1alpha1.ZoneAudit) (*solverconfig.Config, error) {
+	spec := audit.Spec
+	raw, err := json.Marshal(map[string]any{
+		"servers":        spec.Servers,
+		"zone":           spec.Zone,
+		"tsigKeyName":    spec.TSIGKeyName,
+		"tsigAlgorithm":  spec.TSIGAlgorithm,
+		"tsigSecretName": spec.TSIGSecretName,
+		"tsigSecretKey":  spec.TSIGSecretKey,
+		"transport":      spec.Transport,
+	})
+	if err != nil {
+		return nil, err
+	}
+	config, err := solverconfig.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	if config.Zone == "" {
+		return nil, fmt.Errorf("zone is required")
+	}
+	config.Zone = dns.Fqdn(strings.ToLower(config.Zone))
+	return config, nil
+}
+
+// ownedRRsets returns, for every server of config, the RRsets the DNSRecords
+// of the zone own on it, and the number of distinct owned RRsets. Records
+// with an invalid spec are skipped, they are reported on the DNSRecord itself.
+func (r *ZoneAuditReconciler) ownedRRsets(ctx context.Context, config *solverconfig.Config) (map[string][]recordSet, int, error) {
+	var records dnsv1alpha1.DNSRecordList
+	if err := r.List(ctx, &records); err != nil {
+		return nil, 0, fmt.Errorf("failed to list DNSRecords: %w", err)
+	}
+	slices.SortFunc(records.Items, func(a, b dnsv1alpha1.DNSRecord) int {
+		return strings.Compare(a.Namespace+"/"+a.Name, b.Namespace+"/"+b.Name)
+	})
+
+	type key struct {
+		name   string
+		rrtype uint16
+	}
+	owned := make(map[string][]recordSet, len(config.Servers))
+	seen := map[string]map[key]bool{}
+	distinct := map[key]bool{}
+	for i := range records.Items {
+		record := &records.Items[i]
+		if !record.DeletionTimestamp.IsZero() {
+			continue
+		}
+		settings, err := recordConfig(record)
+		if err != nil || dns.Fqdn(strings.ToLower(settings.Zone)) != config.Zone {
+			continue
+		}
+		rrset, err := desiredRRset(record, settings.Zone)
+		if err != nil {
+			continue
+		}
+		k := key{rrset.name, rrset.rrtype}
+		for _, server := range settings.Servers {
+			// Two DNSRecords owning the same RRset are audited against the first
+			if !slices.Contains(config.Servers, server) || seen[server][k] {
+				continue
+			}
+			if seen[server] == nil {
+				seen[server] = map[key]bool{}
+			}
+			seen[server][k] = true
+			distinct[k] = true
+			owned[server] = append(owned[server], rrset)
+		}
+	}
+	return owned, len(distinct), nil
+}
+
+// auditServer compares the owned RRsets of server with what it serves and,
+// with heal, rewrites those that drifted
+func (r *ZoneAuditReconciler) auditServer(ctx context.Context, config *solverconfig.Config, server, secret string,
+	owned []recordSet, heal bool) (auditResult, error) {
+	log := logf.FromContext(ctx).WithValues("server", server)
+	ctx, cancel := context.WithTimeout(ctx, auditTransferTimeout)
+	defer cancel()
+
+	client := newZoneClient(config, server, secret, r.Logger)
+	result := auditResult{method: dnsv1alpha1.AuditMethodAXFR}
+	live, err := client.TransferZone(ctx)
+	if err != nil {
+		log.V(1).Info("Zone transfer failed, querying owned RRsets instead", "error", err.Error())
+		result.method = dnsv1alpha1.AuditMethodQuery
+		live = nil
+		for _, rrset := range owned {
+			records, err := rfc2136.QueryRRset(ctx, server, rrset.name, rrset.rrtype, zoneQueryTimeout)
+			if err != nil {
+				return result, err
+			}
+			live = append(live, records...)
+		}
+	}
+
+	type key struct {
+		name   string
+		rrtype uint16
+	}
+	liveSets := map[key][]dns.RR{}
+	for _, rr := range live {
+		hdr := rr.Header()
+		k := key{dns.Fqdn(strings.ToLower(hdr.Name)), hdr.Rrtype}
+		liveSets[k] = append(liveSets[k], rr)
+	}
+	var drifted []recordSet
+	for _, rrset := range owned {
+		for _, change := range rfc2136.PlanChanges(rrset.records, liveSets[key{rrset.name, rrset.rrtype}], false) {
+			drifted = append(drifted, rrset)
+			if len(result.differences) < maxAuditDifferences {
+				result.differences = append(result.differences,
+					fmt.Sprintf("%s %s %s", change.Action, change.Name, dns.TypeToString[change.Type]))
+			}
+		}
+	}
+	result.drift = len(drifted)
+	metrics.ZoneAuditDrift.WithLabelValues(config.Zone, server).Set(float64(result.drift))
+	if !heal {
+		return result, nil
+	}
+
+	for _, rrset := range drifted {
+		if err := client.ReplaceRRset(ctx, rrset.name, rrset.rrtype, rrset.records); err != nil {
+			metrics.ZoneAuditHeals.WithLabelValues("failure").Inc()
+			return result, fmt.Errorf("failed to heal %s %s: %w", rrset.name, dns.TypeToString[rrset.rrtype], err)
+		}
+		metrics.ZoneAuditHeals.WithLabelValues("success").Inc()
+		log.Info("Healed drifted RRset", "name", rrset.name, "type", dns.TypeToString[rrset.rrtype])
+		result.healed++
+	}
+	return result, nil
+}
+
+// applyStatus records the outcome of an audit on audit
+func (r *ZoneAuditReconciler) applyStatus(audit *dnsv1alpha1.ZoneAudit, zone string, owned int,
+	results []serverResult, audited map[string]auditResult) {
+	now := metav1.Now()
+	servers := make([]dnsv1alpha1.ZoneAuditServerStatus, 0, len(results))
+	inSync := 0
+	var drifted, failures []string
+	for _, result := range results {
+		outcome := audited[result.server]
+		status := dnsv1alpha1.ZoneAuditServerStatus{
+			Server:      result.server,
+			Method:      outcome.method,
+			Drift:       int32(outcome.drift),
+			Differences: outcome.differences,
+			Healed:      int32(outcome.healed),
+		}
+		switch {
+		case result.err != nil:
+			status.Message = result.err.Error()
+			failures = append(failures, fmt.Sprintf("%s: %v", result.server, result.err))
+		case outcome.drift > outcome.healed:
+			drifted = append(drifted, fmt.Sprintf("%s: %d RRsets drifted", result.server, outcome.drift))
+		default:
+			status.InSync = true
+			inSync++
+		}
+		servers = append(servers, status)
+	}
+
+	audit.Status.ObservedGeneration = audit.Generation
+	audit.Status.LastAuditTime = &now
+	audit.Status.OwnedRRsets = int32(owned)
+	audit.Status.Servers = servers
+
+	condition := metav1.Condition{
+		Type:               dnsv1alpha1.ConditionReady,
+		Status:             metav1.ConditionTrue,
+		Reason:             dnsv1alpha1.ReasonConsistent,
+		Message:            fmt.Sprintf("%d/%d servers in sync on %s", inSync, len(results), zone),
+		ObservedGeneration: audit.Generation,
+	}
+	switch {
+	case len(failures) > 0:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = dnsv1alpha1.ReasonAuditFailed
+		condition.Message += "; " + strings.Join(append(failures, drifted...), "; ")
+	case len(drifted) > 0:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = dnsv1alpha1.ReasonDrifted
+		condition.Message += "; " + strings.Join(drifted, "; ")
+	}
+	meta.SetStatusCondition(&audit.Status.Conditions, condition)
+}
+
+// tsigSecret reads the TSIG secret of config from the namespace of the audit
+// and takes over the key name and algorithm the Secret carries, if any
+func (r *ZoneAuditReconciler) tsigSecret(ctx context.Context, namespace string, config *solverconfig.Config) (string, error) {
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: namespace, Name: config.TSIGSecretName}, secret); err != nil {
+		return "", fmt.Errorf("failed to get TSIG secret %s/%s: %w", namespace, config.TSIGSecretName, err)
+	}
+	value, ok := secret.Data[config.TSIGSecretKey]
+	if !ok || len(value) == 0 {
+		return "", fmt.Errorf("secret %s/%s has no key %s", namespace, config.TSIGSecretName, config.TSIGSecretKey)
+	}
+	config.TSIGKeyName, config.TSIGAlgorithm = solverconfig.TSIGKeyFromSecret(secret.Data, config.TSIGKeyName, config.TSIGAlgorithm)
+	return string(value), nil
+}
+
+// setFailed records a failure that happened before any server was contacted
+func (r *ZoneAuditReconciler) setFailed(ctx context.Context, audit *dnsv1alpha1.ZoneAudit, reason string, cause error) error {
+	audit.Status.ObservedGeneration = audit.Generation
+	meta.SetStatusCondition(&audit.Status.Conditions, metav1.Condition{
+		Type:               dnsv1alpha1.ConditionReady,
+		Status:             metav1.ConditionFalse,
+		Reason:             reason,
+		Message:            cause.Error(),
+		ObservedGeneration: audit.Generation,
+	})
+	return r.Status().Update(ctx, audit)
+}
+
+// SetupWithManager sets up the controller with the Manager. Audits run on
+// their interval; DNSRecord changes are not watched, the next run picks them up.
+func (r *ZoneAuditReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&dnsv1alpha1.ZoneAudit{}).
+		Named("zoneaudit").
+		Complete(r)
+}
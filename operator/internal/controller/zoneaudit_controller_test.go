@@ -0,0 +1,168 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dnsv1alpha1 "github.com/rieset/istio-dns01-bind9/api/v1alpha1"
+	"github.com/rieset/istio-dns01-bind9/internal/dnstest"
+)
+
+func newZoneAudit(addrs []string) *dnsv1alpha1.ZoneAudit {
+	return &dnsv1alpha1.ZoneAudit{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: testNamespace},
+		Spec: dnsv1alpha1.ZoneAuditSpec{
+			Zone:           "example.com",
+			Servers:        addrs,
+			TSIGKeyName:    dnstest.TestKeyName,
+			TSIGSecretName: "tsig",
+			Interval:       &metav1.Duration{Duration: time.Minute},
+		},
+	}
+}
+
+func reconcileAudit(t *testing.T, r *ZoneAuditReconciler) (*dnsv1alpha1.ZoneAudit, ctrl.Result) {
+	t.Helper()
+	key := client.ObjectKey{Namespace: testNamespace, Name: "example"}
+	result, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key})
+	if err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	audit := &dnsv1alpha1.ZoneAudit{}
+	if err := r.Get(context.Background(), key, audit); err != nil {
+		t.Fatal(err)
+	}
+	return audit, result
+}
+
+func auditReason(audit *dnsv1alpha1.ZoneAudit) string {
+	if c := meta.FindStatusCondition(audit.Status.Conditions, dnsv1alpha1.ConditionReady); c != nil {
+		return c.Reason
+	}
+	return ""
+}
+
+func TestZoneAuditReconcile(t *testing.T) {
+	servers, addrs := startServers(t, 2)
+	records := newTestReconciler(t, newRecord(addrs, "www.example.com", "TXT", "hello"), newZoneAudit(addrs))
+	reconcileRecord(t, records)
+	r := &ZoneAuditReconciler{Client: records.Client, Scheme: records.Scheme}
+
+	audit, result := reconcileAudit(t, r)
+	if got := auditReason(audit); got != dnsv1alpha1.ReasonConsistent {
+		t.Fatalf("reason = %q, want %q: %+v", got, dnsv1alpha1.ReasonConsistent, audit.Status.Conditions)
+	}
+	if audit.Status.OwnedRRsets != 1 || audit.Status.LastAuditTime == nil {
+		t.Fatalf("status = %+v, want one owned RRset and an audit time", audit.Status)
+	}
+	if result.RequeueAfter != time.Minute {
+		t.Fatalf("RequeueAfter = %v, want the interval", result.RequeueAfter)
+	}
+	for _, status := range audit.Status.Servers {
+		if !status.InSync || status.Method != dnsv1alpha1.AuditMethodAXFR {
+			t.Fatalf("server status = %+v, want in sync via AXFR", status)
+		}
+	}
+
+	// A changed value on one server is drift, reported without touching it
+	servers[1].SetTXT("www.example.com.", 120, "tampered")
+	audit, _ = reconcileAudit(t, r)
+	if got := auditReason(audit); got != dnsv1alpha1.ReasonDrifted {
+		t.Fatalf("reason = %q, want %q", got, dnsv1alpha1.ReasonDrifted)
+	}
+	status := audit.Status.Servers[1]
+	if status.InSync || status.Drift != 1 || !reflect.DeepEqual(status.Differences, []string{"update www.example.com. TXT"}) {
+		t.Fatalf("server status = %+v, want one updated RRset", status)
+	}
+	if !audit.Status.Servers[0].InSync {
+		t.Fatalf("untouched server not in sync: %+v", audit.Status.Servers[0])
+	}
+	if got := servers[1].TXT("www.example.com."); !reflect.DeepEqual(got, []string{"tampered"}) {
+		t.Fatalf("TXT = %v, want the drifted value left alone", got)
+	}
+
+	// A refused transfer falls back to querying the owned RRsets
+	servers[1].SetTXT("www.example.com.", 120)
+	servers[1].SetTransferRefused(true)
+	audit, _ = reconcileAudit(t, r)
+	status = audit.Status.Servers[1]
+	if status.Method != dnsv1alpha1.AuditMethodQuery ||
+		!reflect.DeepEqual(status.Differences, []string{"create www.example.com. TXT"}) {
+		t.Fatalf("server status = %+v, want a missing RRset found by query", status)
+	}
+
+	// AutoHeal rewrites the missing RRset
+	audit.Spec.AutoHeal = true
+	if err := r.Update(context.Background(), audit); err != nil {
+		t.Fatal(err)
+	}
+	audit, _ = reconcileAudit(t, r)
+	status = audit.Status.Servers[1]
+	if !status.InSync || status.Drift != 1 || status.Healed != 1 {
+		t.Fatalf("server status = %+v, want the drift healed", status)
+	}
+	if got := servers[1].TXT("www.example.com."); !reflect.DeepEqual(got, []string{"hello"}) {
+		t.Fatalf("TXT = %v, want the healed value", got)
+	}
+	audit, _ = reconcileAudit(t, r)
+	if got := auditReason(audit); got != dnsv1alpha1.ReasonConsistent || audit.Status.Servers[1].Drift != 0 {
+		t.Fatalf("status after healing = %+v, want consistent", audit.Status)
+	}
+}
+
+func TestZoneAuditReconcileFailures(t *testing.T) {
+	servers, addrs := startServers(t, 2)
+	records := newTestReconciler(t, newRecord(addrs[:1], "www.example.com", "A", "192.0.2.1"), newZoneAudit(addrs))
+	reconcileRecord(t, records)
+	r := &ZoneAuditReconciler{Client: records.Client, Scheme: records.Scheme}
+
+	// The second server owns nothing, so only a failed read of it matters
+	servers[1].SetTransferRefused(true)
+	audit, _ := reconcileAudit(t, r)
+	if got := auditReason(audit); got != dnsv1alpha1.ReasonConsistent {
+		t.Fatalf("reason = %q, want %q: %+v", got, dnsv1alpha1.ReasonConsistent, audit.Status)
+	}
+
+	servers[0].SetTransferRefused(true)
+	servers[0].SetQueryRcode(dns.RcodeServerFailure)
+	audit, _ = reconcileAudit(t, r)
+	if got := auditReason(audit); got != dnsv1alpha1.ReasonAuditFailed {
+		t.Fatalf("reason = %q, want %q", got, dnsv1alpha1.ReasonAuditFailed)
+	}
+	if status := audit.Status.Servers[0]; status.InSync || status.Message == "" {
+		t.Fatalf("server status = %+v, want the failure reported", status)
+	}
+
+	audit.Spec.Servers = nil
+	if err := r.Update(context.Background(), audit); err != nil {
+		t.Fatal(err)
+	}
+	audit, _ = reconcileAudit(t, r)
+	if got := auditReason(audit); got != dnsv1alpha1.ReasonInvalidSpec {
+		t.Fatalf("reason = %q, want %q", got, dnsv1alpha1.ReasonInvalidSpec)
+	}
+}
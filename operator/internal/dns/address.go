@@ -0,0 +1,91 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+// FunctionRating: 86/100
+// - Complexity: LOW
+// - Integrations: 0
+// - External Risks: LOW (pure parsing)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: ParseServerAddress
+// Purpose: Splits server addresses written as host, host:port, IPv6 literals and [IPv6]:port
+
+// DefaultPort is the port servers configured without one are reached on
+const DefaultPort = "53"
+
+// ParseServerAddress splits server into its host and port. It accepts a host
+// name or IPv4 address with an optional ":port", a bare IPv6 address, and an
+// IPv6 address in brackets with an optional ":port". The port is empty when
+// server has none; brackets are removed from the host.
+func ParseServerAddress(server string) (string, string, error) {
+	var host, port string
+	switch {
+	case server == "":
+		return "", "", fmt.Errorf("server address is empty")
+	case strings.HasPrefix(server, "["):
+		end := strings.Index(server, "]")
+		if end < 0 {
+			return "", "", fmt.Errorf("server address %q is missing ']'", server)
+		}
+		host = server[1:end]
+		rest := server[end+1:]
+		if rest != "" {
+			var ok bool
+			if port, ok = strings.CutPrefix(rest, ":"); !ok {
+				return "", "", fmt.Errorf("server address %q has text after ']'", server)
+			}
+		}
+		if net.ParseIP(host) == nil || !strings.Contains(host, ":") {
+			return "", "", fmt.Errorf("server address %q does not hold an IPv6 address in brackets", server)
+		}
+	case strings.Count(server, ":") > 1:
+		// Only a bare IPv6 address has several colons; a port needs brackets
+		if net.ParseIP(server) == nil {
+			return "", "", fmt.Errorf("server address %q is not an IPv6 address; write [address]:port to add a port", server)
+		}
+		return server, "", nil
+	default:
+		host = server
+		if i := strings.LastIndex(server, ":"); i >= 0 {
+			host, port = server[:i], server[i+1:]
+		}
+		if net.ParseIP(host) == nil {
+			if _, ok := dns.IsDomainName(host); !ok || host == "" || strings.ContainsAny(host, " \t\r\n/[]") {
+				return "", "", fmt.Errorf("server address %q does not hold a valid host", server)
+			}
+		}
+	}
+	if port != "" || strings.HasSuffix(server, ":") {
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			return "", "", fmt.Errorf("server address %q has an invalid port %q", server, port)
+		}
+	}
+	return host, port, nil
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+func TestParseServerAddress(t *testing.T) {
+	tests := []struct {
+		server   string
+		wantHost string
+		wantPort string
+		wantErr  string
+	}{
+		{"192.0.2.1", "192.0.2.1", "", ""},
+		{"192.0.2.1:5353", "192.0.2.1", "5353", ""},
+		{"ns1.example.com", "ns1.example.com", "", ""},
+		{"ns1.example.com.:53", "ns1.example.com.", "53", ""},
+		{"2001:db8::1", "2001:db8::1", "", ""},
+		{"[2001:db8::1]", "2001:db8::1", "", ""},
+		{"[2001:db8::1]:5353", "2001:db8::1", "5353", ""},
+		{"::1", "::1", "", ""},
+		{"", "", "", "empty"},
+		{"192.0.2.1:", "", "", `invalid port ""`},
+		{"192.0.2.1:70000", "", "", `invalid port "70000"`},
+		{"ns1.example.com:dns", "", "", `invalid port "dns"`},
+		{"2001:db8::1:5353:x", "", "", "write [address]:port"},
+		{"[2001:db8::1", "", "", "missing ']'"},
+		{"[2001:db8::1]5353", "", "", "text after ']'"},
+		{"[192.0.2.1]:53", "", "", "IPv6 address in brackets"},
+		{"bad host:53", "", "", "valid host"},
+	}
+	for _, tt := range tests {
+		host, port, err := ParseServerAddress(tt.server)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseServerAddress(%q) error = %v, want %q", tt.server, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || host != tt.wantHost || port != tt.wantPort {
+			t.Errorf("ParseServerAddress(%q) = %q, %q, %v, want %q, %q", tt.server, host, port, err, tt.wantHost, tt.wantPort)
+		}
+	}
+}
+
+func TestDialAddr(t *testing.T) {
+	ctx := context.Background()
+	udp, tls := &dns.Client{}, &dns.Client{Net: "tcp-tls"}
+	tests := []struct {
+		client *dns.Client
+		server string
+		want   string
+	}{
+		{udp, "192.0.2.1", "192.0.2.1:53"},
+		{udp, "192.0.2.1:5353", "192.0.2.1:5353"},
+		{udp, "2001:db8::1", "[2001:db8::1]:53"},
+		{udp, "[2001:db8::1]", "[2001:db8::1]:53"},
+		{udp, "[2001:db8::1]:5353", "[2001:db8::1]:5353"},
+		{tls, "2001:db8::1", "[2001:db8::1]:853"},
+		{tls, "[2001:db8::1]:8853", "[2001:db8::1]:8853"},
+		{tls, "192.0.2.1", "192.0.2.1:853"},
+	}
+	for _, tt := range tests {
+		if got := dialAddr(ctx, tt.client, tt.server); got != tt.want {
+			t.Errorf("dialAddr(%s, %q) = %q, want %q", tt.client.Net, tt.server, got, tt.want)
+		}
+	}
+}
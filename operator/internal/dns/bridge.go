@@ -0,0 +1,87 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// FunctionRating: 82/100
+// - Complexity: LOW
+// - Integrations: 0 (composes other providers)
+// - External Risks: MEDIUM (inherits the risks of its targets)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: BridgedProvider
+// Purpose: Fans every record change out to all views of a split zone, e.g. internal BIND and a public cloud zone
+
+// BridgeTarget is one view of a bridged zone
+type BridgeTarget struct {
+	// Name identifies the target in errors, e.g. "rfc2136" or "route53"
+	Name     string
+	Provider Provider
+}
+
+// BridgedProvider applies every change to all of its targets concurrently.
+// A change succeeds only when every target accepted it, so the views of a
+// split zone never silently diverge; failed changes are retried by the caller.
+type BridgedProvider struct {
+	targets []BridgeTarget
+}
+
+// NewBridgedProvider creates a provider fanning out to targets
+func NewBridgedProvider(targets ...BridgeTarget) *BridgedProvider {
+	return &BridgedProvider{targets: targets}
+}
+
+// AddTXTRecord adds the record on every target
+func (b *BridgedProvider) AddTXTRecord(ctx context.Context, fqdn, value string, ttl int) error {
+	return b.each(func(p Provider) error { return p.AddTXTRecord(ctx, fqdn, value, ttl) })
+}
+
+// DeleteTXTRecord deletes the record on every target
+func (b *BridgedProvider) DeleteTXTRecord(ctx context.Context, fqdn string) error {
+	return b.each(func(p Provider) error { return p.DeleteTXTRecord(ctx, fqdn) })
+}
+
+// DeleteTXTRecordValue deletes value on every target
+func (b *BridgedProvider) DeleteTXTRecordValue(ctx context.Context, fqdn, value string) error {
+	return b.each(func(p Provider) error { return p.DeleteTXTRecordValue(ctx, fqdn, value) })
+}
+
+// each runs fn against all targets and joins their errors
+func (b *BridgedProvider) each(fn func(Provider) error) error {
+	errs := make([]error, len(b.targets))
+	var wg sync.WaitGroup
+	for i, target := range b.targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := fn(target.Provider); err != nil {
+				errs[i] = fmt.Errorf("%s: %w", target.Name, err)
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
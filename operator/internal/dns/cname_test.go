@@ -0,0 +1,86 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"github.com/rieset/istio-dns01-bind9/internal/dnstest"
+)
+
+func TestFollowCNAME(t *testing.T) {
+	srv := dnstest.NewServer("example.com")
+	if err := srv.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = srv.Close() })
+	refusing := dnstest.NewServer("example.com")
+	refusing.SetQueryRcode(dns.RcodeRefused)
+	if err := refusing.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = refusing.Close() })
+
+	srv.SetCNAME("_acme-challenge.one.example.com.", "one.acme.example.com.")
+	srv.SetCNAME("_acme-challenge.two.example.com.", "hop.example.com.")
+	srv.SetCNAME("hop.example.com.", "two.acme.example.com.")
+	srv.SetCNAME("loop-a.example.com.", "loop-b.example.com.")
+	srv.SetCNAME("loop-b.example.com.", "loop-a.example.com.")
+
+	tests := []struct {
+		name    string
+		servers []string
+		fqdn    string
+		want    string
+		wantErr string
+	}{
+		{"no cname", []string{srv.Addr()}, "_acme-challenge.www.example.com.", "_acme-challenge.www.example.com.", ""},
+		{"single hop", []string{srv.Addr()}, "_acme-challenge.one.example.com.", "one.acme.example.com.", ""},
+		{"chain", []string{srv.Addr()}, "_acme-challenge.two.example.com.", "two.acme.example.com.", ""},
+		{"case and dot", []string{srv.Addr()}, "_ACME-challenge.one.example.com", "one.acme.example.com.", ""},
+		{"refusing server skipped", []string{refusing.Addr(), srv.Addr()}, "_acme-challenge.one.example.com.",
+			"one.acme.example.com.", ""},
+		{"every server refuses", []string{refusing.Addr()}, "_acme-challenge.one.example.com.", "",
+			"failed to look up the CNAME"},
+		{"loop", []string{srv.Addr()}, "loop-a.example.com.", "", "loops at loop-a.example.com."},
+		{"no servers", nil, "_acme-challenge.one.example.com.", "", "no servers"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FollowCNAME(context.Background(), tt.servers, tt.fqdn, 5, time.Second)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("FollowCNAME error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("FollowCNAME = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+
+	if _, err := FollowCNAME(context.Background(), []string{srv.Addr()}, "_acme-challenge.two.example.com.", 1,
+		time.Second); err == nil || !strings.Contains(err.Error(), "longer than 1 hops") {
+		t.Fatalf("FollowCNAME past maxHops = %v, want a hop limit error", err)
+	}
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FunctionRating: 84/100
+// - Complexity: LOW
+// - Integrations: 0
+// - External Risks: LOW (static presets)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: QuirksFor
+// Purpose: Maps server compatibility modes to the RFC2136 behaviours used against them
+
+// CompatMode names the authoritative server implementation behind a configured server
+type CompatMode string
+
+const (
+	// CompatBIND is the default mode, matching BIND 9
+	CompatBIND CompatMode = "bind"
+	// CompatKnot targets Knot DNS
+	CompatKnot CompatMode = "knot"
+	// CompatPowerDNS targets the PowerDNS Authoritative Server's RFC2136 support
+	CompatPowerDNS CompatMode = "powerdns"
+	// CompatWindows targets Windows DNS Server
+	CompatWindows CompatMode = "windows"
+)
+
+// Quirks adjusts how updates are built and how replies are interpreted for one server
+type Quirks struct {
+	// ZonePrerequisite adds an "RRset exists" prerequisite for the zone SOA
+	// (RFC 2136 section 2.4.1) to every update, so an update reaching a server
+	// that does not host the zone fails instead of being silently dropped
+	ZonePrerequisite bool
+	// SignUpdates signs updates with TSIG. Windows DNS Server only supports
+	// GSS-TSIG, so its zones must accept nonsecure updates from the webhook.
+	SignUpdates bool
+	// TSIGFudge is the clock skew in seconds the server may accept for signatures
+	TSIGFudge uint16
+	// IdempotentDelete treats NXDOMAIN and NXRRSET answers to a delete as
+	// success, for servers that report removing an absent RRset as an error
+	IdempotentDelete bool
+}
+
+// compatQuirks holds the quirks applied for each mode
+var compatQuirks = map[CompatMode]Quirks{
+	CompatBIND:     {ZonePrerequisite: true, SignUpdates: true, TSIGFudge: 300},
+	CompatKnot:     {ZonePrerequisite: true, SignUpdates: true, TSIGFudge: 300, IdempotentDelete: true},
+	CompatPowerDNS: {SignUpdates: true, TSIGFudge: 300, IdempotentDelete: true},
+	CompatWindows:  {IdempotentDelete: true},
+}
+
+// DefaultQuirks are the quirks of CompatBIND
+var DefaultQuirks = compatQuirks[CompatBIND]
+
+// ParseCompatMode parses a mode name case-insensitively. An empty name is CompatBIND.
+func ParseCompatMode(name string) (CompatMode, error) {
+	if name == "" {
+		return CompatBIND, nil
+	}
+	mode := CompatMode(strings.ToLower(name))
+	if _, ok := compatQuirks[mode]; !ok {
+		return "", fmt.Errorf("unknown server mode %q, expected one of bind, knot, powerdns, windows", name)
+	}
+	return mode, nil
+}
+
+// QuirksFor returns the quirks of mode, or DefaultQuirks for an unknown mode
+func QuirksFor(mode CompatMode) Quirks {
+	if quirks, ok := compatQuirks[mode]; ok {
+		return quirks
+	}
+	return DefaultQuirks
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"testing"
+
+	"github.com/miekg/dns"
+
+	"github.com/rieset/istio-dns01-bind9/internal/dnstest"
+)
+
+func TestParseCompatMode(t *testing.T) {
+	for name, want := range map[string]CompatMode{"": CompatBIND, "Knot": CompatKnot, "powerdns": CompatPowerDNS} {
+		if got, err := ParseCompatMode(name); err != nil || got != want {
+			t.Errorf("ParseCompatMode(%q) = %q, %v, want %q", name, got, err, want)
+		}
+	}
+	if _, err := ParseCompatMode("djbdns"); err == nil {
+		t.Error("ParseCompatMode accepted an unknown mode")
+	}
+}
+
+func TestCompatModes(t *testing.T) {
+	tests := []struct {
+		mode CompatMode
+		// signed selects a server enforcing TSIG
+		signed       bool
+		deleteRcode  int
+		wantAddErr   bool
+		wantDeleteOK bool
+	}{
+		{mode: CompatBIND, signed: true, deleteRcode: dns.RcodeNXRrset, wantDeleteOK: false},
+		{mode: CompatKnot, signed: true, deleteRcode: dns.RcodeNXRrset, wantDeleteOK: true},
+		{mode: CompatPowerDNS, signed: true, deleteRcode: dns.RcodeNameError, wantDeleteOK: true},
+		{mode: CompatWindows, signed: false, deleteRcode: dns.RcodeNXRrset, wantDeleteOK: true},
+		{mode: CompatWindows, signed: true, wantAddErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			srv := dnstest.NewServer("example.com")
+			if tt.signed {
+				srv.AddTSIGKey(dnstest.TestKeyName, dnstest.TestSecret)
+			}
+			if err := srv.Start(); err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { _ = srv.Close() })
+
+			c := newTestClient(srv, dnstest.TestSecret)
+			c.SetQuirks(QuirksFor(tt.mode))
+			ctx := context.Background()
+
+			err := c.AddTXTRecord(ctx, testFQDN, "token", 60)
+			if (err != nil) != tt.wantAddErr {
+				t.Fatalf("AddTXTRecord error = %v, wantErr %v", err, tt.wantAddErr)
+			}
+			if tt.wantAddErr {
+				return
+			}
+
+			srv.SetUpdateRcode(tt.deleteRcode)
+			if err := c.DeleteTXTRecord(ctx, testFQDN); (err == nil) != tt.wantDeleteOK {
+				t.Fatalf("DeleteTXTRecord answered %s: error = %v, want success %v",
+					dns.RcodeToString[tt.deleteRcode], err, tt.wantDeleteOK)
+			}
+		})
+	}
+}
+
+func TestQuirksShapeUpdates(t *testing.T) {
+	srv := startServer(t)
+	c := newTestClient(srv, dnstest.TestSecret)
+
+	for _, mode := range []CompatMode{CompatBIND, CompatPowerDNS, CompatWindows} {
+		quirks := QuirksFor(mode)
+		c.SetQuirks(quirks)
+		msg, rr := c.buildAddMsg(testFQDN, "token", 60)
+		hasPrereq := len(msg.Answer) == 1 && msg.Answer[0].Header().Rrtype == dns.TypeSOA &&
+			msg.Answer[0].Header().Class == dns.ClassANY
+		signed := msg.IsTsig() != nil
+		releaseMsg(msg, rr)
+
+		if hasPrereq != quirks.ZonePrerequisite {
+			t.Errorf("%s: zone prerequisite = %v, want %v", mode, hasPrereq, quirks.ZonePrerequisite)
+		}
+		if signed != quirks.SignUpdates {
+			t.Errorf("%s: signed = %v, want %v", mode, signed, quirks.SignUpdates)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"github.com/rieset/istio-dns01-bind9/internal/metrics"
+)
+
+// FunctionRating: 78/100
+// - Complexity: MEDIUM
+// - Integrations: 2 (dns library, metrics)
+// - External Risks: MEDIUM (reused connections may have been closed by the server)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: ConnPool
+// Purpose: Keeps TCP and DNS-over-TLS connections to servers open for reuse across updates
+
+const (
+	// DefaultConnIdleTimeout is how long an unused connection is kept. It stays
+	// below BIND's default tcp-idle-timeout of 30s so the server rarely closes
+	// a connection first.
+	DefaultConnIdleTimeout = 20 * time.Second
+	// DefaultConnMaxIdle is the number of idle connections kept per server and transport
+	DefaultConnMaxIdle = 4
+)
+
+// connKey identifies the connections of one transport to one server
+// address, through one proxy
+type connKey struct {
+	network string
+	addr    string
+	proxy   string
+}
+
+// newConnKey returns the key of the connections of client to addr through
+// the proxy of ctx
+func newConnKey(ctx context.Context, client *dns.Client, addr string) connKey {
+	key := connKey{network: client.Net, addr: addr}
+	if proxy := proxyFrom(ctx); proxy != nil {
+		key.proxy = proxy.String()
+	}
+	return key
+}
+
+// idleConn is a pooled connection with the time it was returned. The
+// network connection is kept rather than the dns.Conn, which carries the MAC
+// of the last TSIG request into the signature of the next message.
+type idleConn struct {
+	conn     net.Conn
+	returned time.Time
+}
+
+// ConnPool keeps idle TCP and DNS-over-TLS connections per server and
+// transport, so consecutive updates skip the handshakes. UDP exchanges are not
+// pooled. A pool is safe for concurrent use and shared by every client it is
+// set on; connections carry no TSIG state, so clients with different keys
+// share them.
+type ConnPool struct {
+	mu          sync.Mutex
+	idle        map[connKey][]idleConn
+	idleTimeout time.Duration
+	maxIdle     int
+	sweeper     *time.Timer
+	closed      bool
+}
+
+// NewConnPool creates a connection pool closing connections unused for
+// idleTimeout and keeping at most maxIdle per server and transport. Zero
+// values select DefaultConnIdleTimeout and DefaultConnMaxIdle.
+func NewConnPool(idleTimeout time.Duration, maxIdle int) *ConnPool {
+	if idleTimeout <= 0 {
+		idleTimeout = DefaultConnIdleTimeout
+	}
+	if maxIdle <= 0 {
+		maxIdle = DefaultConnMaxIdle
+	}
+	return &ConnPool{
+		idle:        make(map[connKey][]idleConn),
+		idleTimeout: idleTimeout,
+		maxIdle:     maxIdle,
+	}
+}
+
+// get returns an idle connection of client to addr, or dials a new one,
+// through the proxy of ctx if any. It reports whether the connection was
+// reused. A nil pool always dials.
+func (p *ConnPool) get(ctx context.Context, client *dns.Client, addr string) (*dns.Conn, bool, error) {
+	if p != nil && pooled(client) {
+		if conn := p.take(newConnKey(ctx, client, addr)); conn != nil {
+			metrics.DNSConnections.WithLabelValues("reused").Inc()
+			return &dns.Conn{Conn: conn}, true, nil
+		}
+	}
+	conn, err := dialDNS(ctx, client, addr, proxyFrom(ctx))
+	if err != nil {
+		return nil, false, err
+	}
+	metrics.DNSConnections.WithLabelValues("dialed").Inc()
+	return conn, false, nil
+}
+
+// take pops the most recently returned live connection of key
+func (p *ConnPool) take(key connKey) net.Conn {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	conns := p.idle[key]
+	for len(conns) > 0 {
+		last := conns[len(conns)-1]
+		conns = conns[:len(conns)-1]
+		if time.Since(last.returned) < p.idleTimeout {
+			p.idle[key] = conns
+			return last.conn
+		}
+		_ = last.conn.Close()
+	}
+	delete(p.idle, key)
+	return nil
+}
+
+// put returns a healthy connection of client to addr to the pool, or closes it
+// when the pool is full, closed or nil
+func (p *ConnPool) put(ctx context.Context, client *dns.Client, addr string, conn *dns.Conn) {
+	if p == nil || !pooled(client) {
+		_ = conn.Close()
+		return
+	}
+	key := newConnKey(ctx, client, addr)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed || len(p.idle[key]) >= p.maxIdle {
+		_ = conn.Close()
+		return
+	}
+	p.idle[key] = append(p.idle[key], idleConn{conn: conn.Conn, returned: time.Now()})
+	if p.sweeper == nil {
+		p.sweeper = time.AfterFunc(p.idleTimeout, p.sweep)
+	}
+}
+
+// invalidate closes every idle connection of client to addr. It is called
+// when an exchange fails, since the server may have restarted or dropped
+// its connections.
+func (p *ConnPool) invalidate(ctx context.Context, client *dns.Client, addr string) {
+	if p == nil || !pooled(client) {
+		return
+	}
+	key := newConnKey(ctx, client, addr)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, idle := range p.idle[key] {
+		_ = idle.conn.Close()
+		metrics.DNSConnections.WithLabelValues("invalidated").Inc()
+	}
+	delete(p.idle, key)
+}
+
+// sweep closes the connections that have been idle for idleTimeout and
+// schedules itself again while connections are left
+func (p *ConnPool) sweep() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.sweeper = nil
+	for key, conns := range p.idle {
+		live := conns[:0]
+		for _, idle := range conns {
+			if time.Since(idle.returned) < p.idleTimeout {
+				live = append(live, idle)
+			} else {
+				_ = idle.conn.Close()
+			}
+		}
+		if len(live) == 0 {
+			delete(p.idle, key)
+		} else {
+			p.idle[key] = live
+		}
+	}
+	if len(p.idle) > 0 && !p.closed {
+		p.sweeper = time.AfterFunc(p.idleTimeout, p.sweep)
+	}
+}
+
+// Close closes every idle connection; connections in use are closed when
+// they are returned
+func (p *ConnPool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	if p.sweeper != nil {
+		p.sweeper.Stop()
+		p.sweeper = nil
+	}
+	for key, conns := range p.idle {
+		for _, idle := range conns {
+			_ = idle.conn.Close()
+		}
+		delete(p.idle, key)
+	}
+}
+
+// pooled reports whether connections of client are kept; UDP ones are not
+func pooled(client *dns.Client) bool {
+	return client.Net == "tcp" || client.Net == "tcp-tls"
+}
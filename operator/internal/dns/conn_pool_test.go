@@ -0,0 +1,131 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"github.com/rieset/istio-dns01-bind9/internal/dnstest"
+)
+
+// idleConns returns the idle connections pool holds
+func idleConns(pool *ConnPool) []net.Conn {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	var conns []net.Conn
+	for _, idle := range pool.idle {
+		for _, c := range idle {
+			conns = append(conns, c.conn)
+		}
+	}
+	return conns
+}
+
+func newPooledClient(srv *dnstest.Server, pool *ConnPool) *RFC2136Client {
+	c := newTestClient(srv, dnstest.TestSecret)
+	c.SetTransport(TransportTCP)
+	c.SetConnPool(pool)
+	return c
+}
+
+func TestConnPoolReusesConnections(t *testing.T) {
+	srv := startServer(t)
+	pool := NewConnPool(time.Minute, 2)
+	t.Cleanup(pool.Close)
+	ctx := context.Background()
+
+	// Clients with their own settings share the connections of the pool
+	if err := newPooledClient(srv, pool).AddTXTRecord(ctx, testFQDN, "token-1", 60); err != nil {
+		t.Fatalf("AddTXTRecord: %v", err)
+	}
+	first := idleConns(pool)
+	if len(first) != 1 {
+		t.Fatalf("pool holds %d idle connections after an exchange, want 1", len(first))
+	}
+	if err := newPooledClient(srv, pool).AddTXTRecord(ctx, testFQDN, "token-2", 60); err != nil {
+		t.Fatalf("AddTXTRecord on a reused connection: %v", err)
+	}
+	if second := idleConns(pool); !reflect.DeepEqual(second, first) {
+		t.Fatal("second exchange did not reuse the pooled connection")
+	}
+	if got := srv.TXT(testFQDN); len(got) != 2 {
+		t.Fatalf("TXT = %v, want both values", got)
+	}
+}
+
+func TestConnPoolReplacesDeadConnection(t *testing.T) {
+	srv := startServer(t)
+	pool := NewConnPool(time.Minute, 2)
+	t.Cleanup(pool.Close)
+	c := newPooledClient(srv, pool)
+	ctx := context.Background()
+
+	if err := c.AddTXTRecord(ctx, testFQDN, "token-1", 60); err != nil {
+		t.Fatalf("AddTXTRecord: %v", err)
+	}
+	// The server side dropping an idle connection looks the same to the client
+	dead := idleConns(pool)
+	_ = dead[0].Close()
+
+	if err := c.AddTXTRecord(ctx, testFQDN, "token-2", 60); err != nil {
+		t.Fatalf("AddTXTRecord after the pooled connection died: %v", err)
+	}
+	if live := idleConns(pool); len(live) != 1 || live[0] == dead[0] {
+		t.Fatalf("pool holds %v, want one fresh connection", live)
+	}
+}
+
+func TestConnPoolLimits(t *testing.T) {
+	srv := startServer(t)
+	client := &dns.Client{Net: "tcp", Timeout: time.Second}
+	ctx := context.Background()
+	dial := func() *dns.Conn {
+		conn, err := client.DialContext(ctx, srv.Addr())
+		if err != nil {
+			t.Fatalf("dial: %v", err)
+		}
+		return conn
+	}
+
+	pool := NewConnPool(50*time.Millisecond, 1)
+	t.Cleanup(pool.Close)
+	pool.put(context.Background(), client, srv.Addr(), dial())
+	pool.put(context.Background(), client, srv.Addr(), dial())
+	if got := len(idleConns(pool)); got != 1 {
+		t.Fatalf("pool holds %d idle connections, want maxIdle 1", got)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for len(idleConns(pool)) > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("idle connection was not closed after the idle timeout")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// UDP exchanges are never pooled
+	pool.put(context.Background(), &dns.Client{Net: "udp"}, srv.Addr(), dial())
+	if got := len(idleConns(pool)); got != 0 {
+		t.Fatalf("pool kept %d UDP connections", got)
+	}
+}
@@ -0,0 +1,273 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.uber.org/zap"
+)
+
+// FunctionRating: 74/100
+// - Complexity: MEDIUM
+// - Integrations: 2 (etcd, logging)
+// - External Risks: MEDIUM (etcd availability, shared keyspace with CoreDNS)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: CoreDNSEtcdClient
+// Purpose: Writes challenge and managed records into the etcd keyspace served by CoreDNS's etcd plugin
+
+// DefaultCoreDNSEtcdPrefix is the default path of CoreDNS's etcd plugin
+const DefaultCoreDNSEtcdPrefix = "/skydns"
+
+// managedKeyPrefix starts the leaf key of every record this client writes, so
+// deletions never touch records created by other tools under the same name
+const managedKeyPrefix = "dns01-"
+
+// EtcdKV is the subset of clientv3.KV used by CoreDNSEtcdClient
+type EtcdKV interface {
+	Put(ctx context.Context, key, val string, opts ...clientv3.OpOption) (*clientv3.PutResponse, error)
+	Get(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error)
+	Delete(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.DeleteResponse, error)
+}
+
+// SkyDNSRecord is a record in the format read by CoreDNS's etcd plugin.
+// Host holds an address or a target name, Text makes the record a TXT record.
+type SkyDNSRecord struct {
+	Host string `json:"host,omitempty"`
+	Port int    `json:"port,omitempty"`
+	Text string `json:"text,omitempty"`
+	TTL  uint32 `json:"ttl,omitempty"`
+}
+
+// CoreDNSEtcdClient manages records in CoreDNS's etcd plugin keyspace. A
+// connection is opened per operation through dial and closed afterwards.
+type CoreDNSEtcdClient struct {
+	prefix string
+	zone   string
+	logger *zap.Logger
+	dial   func() (EtcdKV, func() error, error)
+}
+
+// NewCoreDNSEtcdClient creates a client for zone storing records below prefix
+// in the etcd cluster described by config
+func NewCoreDNSEtcdClient(config clientv3.Config, prefix, zone string, logger *zap.Logger) *CoreDNSEtcdClient {
+	if prefix == "" {
+		prefix = DefaultCoreDNSEtcdPrefix
+	}
+	return &CoreDNSEtcdClient{
+		prefix: "/" + strings.Trim(prefix, "/"),
+		zone:   dns.Fqdn(zone),
+		logger: logger,
+		dial: func() (EtcdKV, func() error, error) {
+			client, err := clientv3.New(config)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to connect to etcd: %w", err)
+			}
+			return client, client.Close, nil
+		},
+	}
+}
+
+// EtcdClientConfig builds an etcd client config for endpoints from the keys
+// of a credentials Secret: username and password for authentication, ca.crt
+// to verify the server and tls.crt with tls.key for client certificates.
+// credentials may be nil.
+func EtcdClientConfig(endpoints []string, credentials map[string][]byte) (clientv3.Config, error) {
+	config := clientv3.Config{
+		Endpoints:   endpoints,
+		DialTimeout: 5 * time.Second,
+		Username:    string(credentials["username"]),
+		Password:    string(credentials["password"]),
+	}
+
+	useTLS := false
+	for _, endpoint := range endpoints {
+		useTLS = useTLS || strings.HasPrefix(endpoint, "https://")
+	}
+	if !useTLS {
+		return config, nil
+	}
+
+	tlsConfig, err := TLSClientConfig(credentials, "")
+	if err != nil {
+		return clientv3.Config{}, fmt.Errorf("etcd TLS: %w", err)
+	}
+	config.TLS = tlsConfig
+	return config, nil
+}
+
+// AddTXTRecord stores value as a TXT record at fqdn
+func (c *CoreDNSEtcdClient) AddTXTRecord(ctx context.Context, fqdn, value string, ttl int) error {
+	c.logger.Info("Adding TXT record via CoreDNS etcd",
+		zap.String("fqdn", fqdn),
+		ChallengeValue(value),
+		zap.String("zone", c.zone),
+	)
+	if err := c.PutRecord(ctx, fqdn, "txt-"+recordID(value), SkyDNSRecord{Text: value, TTL: uint32(ttl)}); err != nil {
+		return err
+	}
+	c.logger.Info("TXT record added successfully via CoreDNS etcd", zap.String("fqdn", fqdn))
+	return nil
+}
+
+// DeleteTXTRecord removes every TXT record this client stored at fqdn
+func (c *CoreDNSEtcdClient) DeleteTXTRecord(ctx context.Context, fqdn string) error {
+	c.logger.Info("Deleting TXT record via CoreDNS etcd",
+		zap.String("fqdn", fqdn),
+		zap.String("zone", c.zone),
+	)
+	if err := c.deleteManaged(ctx, fqdn, managedKeyPrefix+"txt-"); err != nil {
+		return err
+	}
+	c.logger.Info("TXT record deleted successfully via CoreDNS etcd", zap.String("fqdn", fqdn))
+	return nil
+}
+
+// DeleteTXTRecordValue removes the TXT record this client stored for value at fqdn
+func (c *CoreDNSEtcdClient) DeleteTXTRecordValue(ctx context.Context, fqdn, value string) error {
+	c.logger.Info("Deleting TXT record value via CoreDNS etcd",
+		zap.String("fqdn", fqdn),
+		ChallengeValue(value),
+		zap.String("zone", c.zone),
+	)
+	if err := c.DeleteRecord(ctx, fqdn, "txt-"+recordID(value)); err != nil {
+		return err
+	}
+	c.logger.Info("TXT record value deleted successfully via CoreDNS etcd", zap.String("fqdn", fqdn))
+	return nil
+}
+
+// PutRecord stores record at fqdn under id; putting the same id again replaces it
+func (c *CoreDNSEtcdClient) PutRecord(ctx context.Context, fqdn, id string, record SkyDNSRecord) error {
+	key, err := c.recordKey(fqdn, id)
+	if err != nil {
+		return err
+	}
+	value, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode record: %w", err)
+	}
+
+	kv, closeKV, err := c.dial()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = closeKV() }()
+
+	if _, err := kv.Put(ctx, key, string(value)); err != nil {
+		return fmt.Errorf("failed to write %s to etcd: %w", key, err)
+	}
+	return nil
+}
+
+// DeleteRecord removes the record stored at fqdn under id
+func (c *CoreDNSEtcdClient) DeleteRecord(ctx context.Context, fqdn, id string) error {
+	key, err := c.recordKey(fqdn, id)
+	if err != nil {
+		return err
+	}
+
+	kv, closeKV, err := c.dial()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = closeKV() }()
+
+	if _, err := kv.Delete(ctx, key); err != nil {
+		return fmt.Errorf("failed to delete %s from etcd: %w", key, err)
+	}
+	return nil
+}
+
+// deleteManaged deletes the direct children of fqdn's key whose name starts with leafPrefix
+func (c *CoreDNSEtcdClient) deleteManaged(ctx context.Context, fqdn, leafPrefix string) error {
+	dir, err := c.nameKey(fqdn)
+	if err != nil {
+		return err
+	}
+
+	kv, closeKV, err := c.dial()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = closeKV() }()
+
+	resp, err := kv.Get(ctx, dir+"/"+leafPrefix, clientv3.WithPrefix(), clientv3.WithKeysOnly())
+	if err != nil {
+		return fmt.Errorf("failed to list %s in etcd: %w", dir, err)
+	}
+	for _, item := range resp.Kvs {
+		key := string(item.Key)
+		if path.Dir(key) != dir {
+			continue
+		}
+		if _, err := kv.Delete(ctx, key); err != nil {
+			return fmt.Errorf("failed to delete %s from etcd: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// nameKey maps fqdn to its etcd directory: the labels reversed below the prefix,
+// e.g. /skydns/com/example/_acme-challenge for _acme-challenge.example.com.
+func (c *CoreDNSEtcdClient) nameKey(fqdn string) (string, error) {
+	name := dns.Fqdn(strings.ToLower(fqdn))
+	if !dns.IsSubDomain(c.zone, name) {
+		return "", fmt.Errorf("%s is not in zone %s", fqdn, c.zone)
+	}
+	labels := dns.SplitDomainName(name)
+	for i, j := 0, len(labels)-1; i < j; i, j = i+1, j-1 {
+		labels[i], labels[j] = labels[j], labels[i]
+	}
+	for _, label := range labels {
+		if strings.Contains(label, "/") {
+			return "", fmt.Errorf("label %q of %s cannot be stored in etcd", label, fqdn)
+		}
+	}
+	return c.prefix + "/" + strings.Join(labels, "/"), nil
+}
+
+// recordKey returns the key of record id at fqdn
+func (c *CoreDNSEtcdClient) recordKey(fqdn, id string) (string, error) {
+	if id == "" || strings.Contains(id, "/") {
+		return "", fmt.Errorf("invalid record id %q", id)
+	}
+	dir, err := c.nameKey(fqdn)
+	if err != nil {
+		return "", err
+	}
+	return dir + "/" + managedKeyPrefix + id, nil
+}
+
+// recordID derives a stable key component from a record value
+func recordID(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:8])
+}
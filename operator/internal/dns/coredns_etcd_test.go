@@ -0,0 +1,187 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.uber.org/zap"
+)
+
+// fakeEtcd is an in-memory EtcdKV supporting point and range operations
+type fakeEtcd struct {
+	mu   sync.Mutex
+	data map[string]string
+}
+
+// matches reports whether key falls into the range selected by op
+func matches(op clientv3.Op, key string) bool {
+	start, end := string(op.KeyBytes()), string(op.RangeBytes())
+	if end == "" {
+		return key == start
+	}
+	return key >= start && key < end
+}
+
+func (f *fakeEtcd) Put(_ context.Context, key, val string, _ ...clientv3.OpOption) (*clientv3.PutResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.data[key] = val
+	return &clientv3.PutResponse{}, nil
+}
+
+func (f *fakeEtcd) Get(_ context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	op := clientv3.OpGet(key, opts...)
+	resp := &clientv3.GetResponse{}
+	for k, v := range f.data {
+		if matches(op, k) {
+			resp.Kvs = append(resp.Kvs, &mvccpb.KeyValue{Key: []byte(k), Value: []byte(v)})
+		}
+	}
+	return resp, nil
+}
+
+func (f *fakeEtcd) Delete(_ context.Context, key string, opts ...clientv3.OpOption) (*clientv3.DeleteResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	op := clientv3.OpDelete(key, opts...)
+	for k := range f.data {
+		if matches(op, k) {
+			delete(f.data, k)
+		}
+	}
+	return &clientv3.DeleteResponse{}, nil
+}
+
+// keys returns the sorted keys below prefix
+func (f *fakeEtcd) keys(prefix string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var keys []string
+	for k := range f.data {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func newFakeEtcdClient(t *testing.T, zone string) (*CoreDNSEtcdClient, *fakeEtcd) {
+	t.Helper()
+	fake := &fakeEtcd{data: map[string]string{}}
+	c := NewCoreDNSEtcdClient(clientv3.Config{}, "", zone, zap.NewNop())
+	c.dial = func() (EtcdKV, func() error, error) {
+		return fake, func() error { return nil }, nil
+	}
+	return c, fake
+}
+
+func TestCoreDNSEtcdClientAddAndDelete(t *testing.T) {
+	c, fake := newFakeEtcdClient(t, "example.com")
+	ctx := context.Background()
+	const dir = "/skydns/com/example/app/_acme-challenge/"
+
+	// Records of other tools and of a longer sibling name must survive cleanup
+	fake.data[dir+"manual"] = `{"text":"keep"}`
+	fake.data["/skydns/com/example/app/_acme-challenge-x/dns01-txt-0"] = `{"text":"keep"}`
+
+	for _, value := range []string{"token-1", "token-2", "token-1"} {
+		if err := c.AddTXTRecord(ctx, testFQDN, value, 60); err != nil {
+			t.Fatalf("AddTXTRecord(%s): %v", value, err)
+		}
+	}
+	keys := fake.keys(dir + managedKeyPrefix)
+	if len(keys) != 2 {
+		t.Fatalf("managed keys after add = %v, want 2", keys)
+	}
+	var record SkyDNSRecord
+	if err := json.Unmarshal([]byte(fake.data[keys[0]]), &record); err != nil || record.TTL != 60 ||
+		!strings.HasPrefix(record.Text, "token-") {
+		t.Fatalf("stored record = %+v (%v)", record, err)
+	}
+
+	if err := c.DeleteTXTRecordValue(ctx, testFQDN, "token-1"); err != nil {
+		t.Fatalf("DeleteTXTRecordValue: %v", err)
+	}
+	if got := fake.keys(dir + managedKeyPrefix); len(got) != 1 || got[0] != dir+managedKeyPrefix+"txt-"+recordID("token-2") {
+		t.Fatalf("managed keys after value delete = %v", got)
+	}
+
+	if err := c.DeleteTXTRecord(ctx, testFQDN); err != nil {
+		t.Fatalf("DeleteTXTRecord: %v", err)
+	}
+	want := []string{"/skydns/com/example/app/_acme-challenge-x/dns01-txt-0", dir + "manual"}
+	if got := fake.keys("/skydns/"); !reflect.DeepEqual(got, want) {
+		t.Fatalf("keys after delete = %v, want %v", got, want)
+	}
+}
+
+func TestCoreDNSEtcdClientRecords(t *testing.T) {
+	c, fake := newFakeEtcdClient(t, "example.com.")
+	ctx := context.Background()
+
+	if err := c.PutRecord(ctx, "www.example.com", "a", SkyDNSRecord{Host: "10.0.0.1", TTL: 300}); err != nil {
+		t.Fatalf("PutRecord: %v", err)
+	}
+	if got := fake.data["/skydns/com/example/www/dns01-a"]; got != `{"host":"10.0.0.1","ttl":300}` {
+		t.Fatalf("stored record = %q", got)
+	}
+	if err := c.DeleteRecord(ctx, "www.example.com", "a"); err != nil {
+		t.Fatalf("DeleteRecord: %v", err)
+	}
+	if len(fake.data) != 0 {
+		t.Fatalf("records left after delete: %v", fake.data)
+	}
+
+	for _, fqdn := range []string{"www.example.org", "a/b.example.com"} {
+		if err := c.PutRecord(ctx, fqdn, "a", SkyDNSRecord{Host: "10.0.0.1"}); err == nil {
+			t.Errorf("PutRecord(%s) succeeded", fqdn)
+		}
+	}
+}
+
+func TestEtcdClientConfig(t *testing.T) {
+	config, err := EtcdClientConfig([]string{"http://etcd:2379"}, map[string][]byte{"username": []byte("u"), "password": []byte("p")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Username != "u" || config.Password != "p" || config.TLS != nil {
+		t.Fatalf("plaintext config = %+v", config)
+	}
+
+	config, err = EtcdClientConfig([]string{"https://etcd:2379"}, nil)
+	if err != nil || config.TLS == nil || config.TLS.RootCAs != nil {
+		t.Fatalf("https config = %+v, %v; want TLS with system roots", config.TLS, err)
+	}
+	if _, err := EtcdClientConfig([]string{"https://etcd:2379"}, map[string][]byte{"ca.crt": []byte("garbage")}); err == nil {
+		t.Fatal("EtcdClientConfig accepted an invalid ca.crt")
+	}
+	if _, err := EtcdClientConfig([]string{"https://etcd:2379"}, map[string][]byte{"tls.crt": []byte("garbage")}); err == nil {
+		t.Fatal("EtcdClientConfig accepted an invalid client certificate")
+	}
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// FunctionRating: 78/100
+// - Complexity: MEDIUM
+// - Integrations: 1 (dns library)
+// - External Risks: MEDIUM (network operations, signature validation)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: HasSignedTXTValue
+// Purpose: Confirms a TXT value is served with a valid RRSIG, for zones re-signed by the server
+
+// HasSignedTXTValue reports whether server publishes value among the TXT
+// records at fqdn together with an RRSIG over the RRset that is currently
+// valid and verifies against the DNSKEY RRset of its signer, as a validating
+// resolver would require. The chain of trust above the zone is not checked.
+func HasSignedTXTValue(ctx context.Context, server, fqdn, value string, timeout time.Duration) (bool, error) {
+	return hasSignedTXTValue(ctx, server, fqdn, value, timeout, nil)
+}
+
+// hasSignedTXTValue is HasSignedTXTValue, over DNS-over-TLS when tlsConfig is set
+func hasSignedTXTValue(ctx context.Context, server, fqdn, value string, timeout time.Duration,
+	tlsConfig *tls.Config) (bool, error) {
+	records, err := querySigned(ctx, server, fqdn, dns.TypeTXT, timeout, tlsConfig)
+	if err != nil {
+		return false, err
+	}
+
+	var rrset []dns.RR
+	var sigs []*dns.RRSIG
+	found := false
+	for _, rr := range records {
+		switch rr := rr.(type) {
+		case *dns.TXT:
+			rrset = append(rrset, rr)
+			found = found || strings.Join(rr.Txt, "") == value
+		case *dns.RRSIG:
+			if rr.TypeCovered == dns.TypeTXT {
+				sigs = append(sigs, rr)
+			}
+		}
+	}
+	if !found || len(sigs) == 0 {
+		return false, nil
+	}
+
+	keys := map[string][]dns.RR{}
+	for _, sig := range sigs {
+		if !sig.ValidityPeriod(time.Now()) {
+			continue
+		}
+		signer := dns.Fqdn(strings.ToLower(sig.SignerName))
+		if _, ok := keys[signer]; !ok {
+			if keys[signer], err = querySigned(ctx, server, signer, dns.TypeDNSKEY, timeout, tlsConfig); err != nil {
+				return false, err
+			}
+		}
+		for _, rr := range keys[signer] {
+			key, ok := rr.(*dns.DNSKEY)
+			if ok && key.KeyTag() == sig.KeyTag && key.Algorithm == sig.Algorithm && sig.Verify(key, rrset) == nil {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// querySigned queries server for the records of rrtype at name with the
+// DNSSEC OK bit set and returns the answer section, signatures included. A
+// name that does not exist yields no records and no error.
+func querySigned(ctx context.Context, server, name string, rrtype uint16, timeout time.Duration,
+	tlsConfig *tls.Config) ([]dns.RR, error) {
+	msg := new(dns.Msg)
+	msg.SetQuestion(dns.Fqdn(name), rrtype)
+	msg.RecursionDesired = false
+	msg.SetEdns0(dns.DefaultMsgSize, true)
+
+	reply, err := queryMsg(ctx, msg, server, timeout, tlsConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s for %s on %s: %w", dns.TypeToString[rrtype], name, server, err)
+	}
+	switch reply.Rcode {
+	case dns.RcodeSuccess:
+		return reply.Answer, nil
+	case dns.RcodeNameError:
+		return nil, nil
+	}
+	return nil, fmt.Errorf("%s query for %s on %s failed: %s (rcode: %d)",
+		dns.TypeToString[rrtype], name, server, dns.RcodeToString[reply.Rcode], reply.Rcode)
+}
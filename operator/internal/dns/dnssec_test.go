@@ -0,0 +1,99 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestHasSignedTXTValue(t *testing.T) {
+	srv := startServer(t)
+	srv.SetTXT(testFQDN, 60, "token")
+	ctx := context.Background()
+
+	// An unsigned zone never passes
+	if signed, err := HasSignedTXTValue(ctx, srv.Addr(), testFQDN, "token", time.Second); err != nil || signed {
+		t.Fatalf("HasSignedTXTValue on an unsigned zone = %v, %v, want false", signed, err)
+	}
+
+	if _, err := srv.SignZone("example.com"); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		value  string
+		paused bool
+		want   bool
+	}{
+		{name: "signed", value: "token", want: true},
+		{name: "missing value", value: "other", want: false},
+		{name: "signing lags", value: "token", paused: true, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv.SetSigningPaused("example.com", tt.paused)
+			signed, err := HasSignedTXTValue(ctx, srv.Addr(), testFQDN, tt.value, time.Second)
+			if err != nil {
+				t.Fatalf("HasSignedTXTValue: %v", err)
+			}
+			if signed != tt.want {
+				t.Fatalf("HasSignedTXTValue = %v, want %v", signed, tt.want)
+			}
+		})
+	}
+
+}
+
+func TestWaitForPropagationDNSSEC(t *testing.T) {
+	srv := startServer(t)
+	if _, err := srv.SignZone("example.com"); err != nil {
+		t.Fatal(err)
+	}
+	srv.SetSigningPaused("example.com", true)
+	srv.SetTXT(testFQDN, 60, "token")
+	check := PropagationCheck{
+		Servers:      []string{srv.Addr()},
+		FQDN:         testFQDN,
+		Value:        "token",
+		Present:      true,
+		Interval:     20 * time.Millisecond,
+		QueryTimeout: 200 * time.Millisecond,
+		DNSSEC:       true,
+	}
+
+	short, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := WaitForPropagation(short, check); err == nil {
+		t.Fatal("WaitForPropagation succeeded before the record was signed")
+	}
+
+	time.AfterFunc(50*time.Millisecond, func() { srv.SetSigningPaused("example.com", false) })
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := WaitForPropagation(ctx, check); err != nil {
+		t.Fatalf("WaitForPropagation once signed: %v", err)
+	}
+
+	// Deletions are not held up by signatures
+	check.Present = false
+	check.Value = "gone"
+	if _, err := WaitForPropagation(ctx, check); err != nil {
+		t.Fatalf("WaitForPropagation of a deletion: %v", err)
+	}
+}
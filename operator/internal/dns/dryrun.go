@@ -0,0 +1,177 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"strings"
+
+	"github.com/miekg/dns"
+	"go.uber.org/zap"
+
+	"github.com/rieset/istio-dns01-bind9/internal/metrics"
+)
+
+// FunctionRating: 82/100
+// - Complexity: LOW
+// - Integrations: 2 (dns library, metrics)
+// - External Risks: LOW (never sends anything)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: DryRunRecorder, DryRunProvider
+// Purpose: Stand-ins for DNS servers and API backends that log the changes they are asked for
+
+// Dry-run operations, by the classes of the update section of a message
+const (
+	dryRunAdd     = "add"
+	dryRunDelete  = "delete"
+	dryRunReplace = "replace"
+)
+
+// DryRunRecorder answers the UPDATE messages of RFC2136 clients in place of
+// their servers: it logs what each message would change and replies with
+// success without sending anything
+type DryRunRecorder struct {
+	logger *zap.Logger
+}
+
+// NewDryRunRecorder creates a recorder logging to logger
+func NewDryRunRecorder(logger *zap.Logger) *DryRunRecorder {
+	return &DryRunRecorder{logger: logger}
+}
+
+// record logs msg as it would have been sent to server and returns a success reply
+func (r *DryRunRecorder) record(server string, msg *dns.Msg) *dns.Msg {
+	var zone string
+	if len(msg.Question) > 0 {
+		zone = msg.Question[0].Name
+	}
+	op := updateOp(msg.Ns)
+	prereqs := make([]string, len(msg.Answer))
+	for i, rr := range msg.Answer {
+		prereqs[i] = RedactRecord(rr)
+	}
+	updates := make([]string, len(msg.Ns))
+	for i, rr := range msg.Ns {
+		updates[i] = RedactRecord(rr)
+	}
+	r.logger.Info("Dry run: DNS UPDATE not sent",
+		zap.String("server", server),
+		zap.String("zone", zone),
+		zap.String("op", op),
+		zap.Strings("prerequisites", prereqs),
+		zap.Strings("updates", updates),
+	)
+	metrics.DryRunChanges.WithLabelValues(strings.TrimSuffix(zone, "."), op).Inc()
+
+	reply := new(dns.Msg)
+	reply.SetReply(msg)
+	return reply
+}
+
+// updateOp classifies an update section: class NONE and ANY records delete,
+// the others add
+func updateOp(updates []dns.RR) string {
+	var adds, deletes bool
+	for _, rr := range updates {
+		switch rr.Header().Class {
+		case dns.ClassNONE, dns.ClassANY:
+			deletes = true
+		default:
+			adds = true
+		}
+	}
+	switch {
+	case adds && deletes:
+		return dryRunReplace
+	case deletes:
+		return dryRunDelete
+	}
+	return dryRunAdd
+}
+
+// SetDryRun makes the client hand its messages to recorder instead of the
+// server; queries such as zone transfers are still sent
+func (c *RFC2136Client) SetDryRun(recorder *DryRunRecorder) {
+	c.dryRun = recorder
+}
+
+// DryRunProvider stands in for a Provider without an RFC2136 wire format,
+// such as an HTTP API, logging the changes it would make
+type DryRunProvider struct {
+	name   string
+	zone   string
+	logger *zap.Logger
+}
+
+// NewDryRunProvider creates a stand-in for the provider called name serving zone
+func NewDryRunProvider(name, zone string, logger *zap.Logger) *DryRunProvider {
+	return &DryRunProvider{name: name, zone: strings.TrimSuffix(dns.Fqdn(zone), "."), logger: logger}
+}
+
+// AddTXTRecord logs the record that would be added
+func (p *DryRunProvider) AddTXTRecord(_ context.Context, fqdn, value string, ttl int) error {
+	p.record(dryRunAdd, fqdn, ChallengeValue(value), zap.Int("ttl", ttl))
+	return nil
+}
+
+// DeleteTXTRecord logs the RRset that would be removed
+func (p *DryRunProvider) DeleteTXTRecord(_ context.Context, fqdn string) error {
+	p.record(dryRunDelete, fqdn)
+	return nil
+}
+
+// DeleteTXTRecordValue logs the record that would be removed
+func (p *DryRunProvider) DeleteTXTRecordValue(_ context.Context, fqdn, value string) error {
+	p.record(dryRunDelete, fqdn, ChallengeValue(value))
+	return nil
+}
+
+// AddRecords logs the records that would be added
+func (p *DryRunProvider) AddRecords(_ context.Context, records []dns.RR) error {
+	for _, rr := range records {
+		p.record(dryRunAdd, rr.Header().Name, zap.String("record", RedactRecord(rr)))
+	}
+	return nil
+}
+
+// DeleteRecords logs the records that would be removed
+func (p *DryRunProvider) DeleteRecords(_ context.Context, records []dns.RR) error {
+	for _, rr := range records {
+		p.record(dryRunDelete, rr.Header().Name, zap.String("record", RedactRecord(rr)))
+	}
+	return nil
+}
+
+// Verify reports every record as published, since nothing is ever sent
+func (p *DryRunProvider) Verify(context.Context, []dns.RR) (bool, error) {
+	return true, nil
+}
+
+// record logs one change of fqdn and counts it
+func (p *DryRunProvider) record(op, fqdn string, fields ...zap.Field) {
+	p.logger.Info("Dry run: DNS change not applied", append([]zap.Field{
+		zap.String("provider", p.name),
+		zap.String("zone", p.zone),
+		zap.String("op", op),
+		zap.String("fqdn", fqdn),
+	}, fields...)...)
+	metrics.DryRunChanges.WithLabelValues(p.zone, op).Inc()
+}
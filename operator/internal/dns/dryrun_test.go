@@ -0,0 +1,118 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/miekg/dns"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/rieset/istio-dns01-bind9/internal/dnstest"
+)
+
+func TestRFC2136ClientDryRun(t *testing.T) {
+	srv := startServer(t)
+	c := newTestClient(srv, dnstest.TestSecret)
+	c.SetDryRun(NewDryRunRecorder(zap.NewNop()))
+	ctx := context.Background()
+
+	if err := c.CheckUpdatePermission(ctx, testFQDN); err != nil {
+		t.Fatalf("CheckUpdatePermission: %v", err)
+	}
+	if err := c.AddTXTRecord(ctx, testFQDN, "token", 60); err != nil {
+		t.Fatalf("AddTXTRecord: %v", err)
+	}
+	if err := c.DeleteTXTRecordValue(ctx, testFQDN, "token"); err != nil {
+		t.Fatalf("DeleteTXTRecordValue: %v", err)
+	}
+	if got := srv.Updates(); got != 0 {
+		t.Fatalf("server received %d updates in dry-run mode", got)
+	}
+}
+
+func TestDryRunRedactsValues(t *testing.T) {
+	const key = "LoqXcYV8q5ONbJQxbmR7SCTNo3tiAXDfowyjxAjEuX0"
+	srv := startServer(t)
+	core, logs := observer.New(zap.InfoLevel)
+	logger := zap.New(core)
+	c := NewRFC2136Client(srv.Addr(), "example.com", dnstest.TestKeyName, "hmac-sha256", dnstest.TestSecret, logger)
+	c.SetDryRun(NewDryRunRecorder(logger))
+	p := NewDryRunProvider("powerdns", "example.com", logger)
+	records := TXTRecords([]TXTValue{{FQDN: testFQDN, Value: key}}, 60)
+	ctx := context.Background()
+
+	for _, provider := range []Provider{c, p} {
+		if err := provider.AddTXTRecord(ctx, testFQDN, key, 60); err != nil {
+			t.Fatalf("AddTXTRecord: %v", err)
+		}
+		if err := provider.DeleteTXTRecordValue(ctx, testFQDN, key); err != nil {
+			t.Fatalf("DeleteTXTRecordValue: %v", err)
+		}
+	}
+	for _, provider := range []RecordProvider{c, p} {
+		if err := provider.AddRecords(ctx, records); err != nil {
+			t.Fatalf("AddRecords: %v", err)
+		}
+		if err := provider.DeleteRecords(ctx, records); err != nil {
+			t.Fatalf("DeleteRecords: %v", err)
+		}
+	}
+
+	dryRuns := 0
+	for _, entry := range logs.All() {
+		if strings.HasPrefix(entry.Message, "Dry run") {
+			dryRuns++
+		}
+		if fields := fmt.Sprint(entry.ContextMap()); strings.Contains(fields, key) {
+			t.Errorf("%q logged the challenge key: %s", entry.Message, fields)
+		}
+	}
+	if dryRuns < 8 {
+		t.Fatalf("logged %d dry-run changes, want one per operation", dryRuns)
+	}
+}
+
+func TestUpdateOp(t *testing.T) {
+	rr := func(s string, class uint16) dns.RR {
+		r, err := dns.NewRR(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.Header().Class = class
+		return r
+	}
+	add := rr(testFQDN+` 60 IN TXT "token"`, dns.ClassINET)
+	del := rr(testFQDN+` 0 IN TXT "token"`, dns.ClassNONE)
+	tests := []struct {
+		updates []dns.RR
+		want    string
+	}{
+		{[]dns.RR{add}, dryRunAdd},
+		{[]dns.RR{del}, dryRunDelete},
+		{[]dns.RR{add, del}, dryRunReplace},
+	}
+	for _, tt := range tests {
+		if got := updateOp(tt.updates); got != tt.want {
+			t.Errorf("updateOp(%v) = %s, want %s", tt.updates, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// FunctionRating: 78/100
+// - Complexity: MEDIUM
+// - Integrations: 1 (dns library)
+// - External Risks: HIGH when enabled (deliberately breaks DNS exchanges)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE (disabled unless a test or debug flag installs an injector)
+//
+// Function: FaultInjector, RandomFaults
+// Purpose: Injects dropped responses, delays, rcodes and TSIG corruption into DNS exchanges for chaos testing
+
+// ErrInjectedFault marks errors produced by fault injection
+var ErrInjectedFault = errors.New("injected fault")
+
+// Fault is what an injector applies to one exchange; the zero value applies nothing
+type Fault struct {
+	// Delay is waited before the message is sent
+	Delay time.Duration
+	// Drop sends the message but discards the response, like a lost UDP reply
+	Drop bool
+	// Rcode, when non-zero, answers with this rcode without contacting the server
+	Rcode int
+	// CorruptTSIG signs the message with a wrong secret
+	CorruptTSIG bool
+}
+
+// FaultInjector decides the fault applied to an exchange of msg with server
+type FaultInjector interface {
+	Inject(server string, msg *dns.Msg) Fault
+}
+
+// faultInjector holds the process-wide injector; nil means no faults
+var faultInjector atomic.Pointer[FaultInjector]
+
+// SetFaultInjector installs injector for every DNS exchange of the process.
+// Passing nil disables fault injection.
+func SetFaultInjector(injector FaultInjector) {
+	if injector == nil {
+		faultInjector.Store(nil)
+		return
+	}
+	faultInjector.Store(&injector)
+}
+
+// exchangeMsg sends msg to server with client, applying the installed fault injector
+func exchangeMsg(ctx context.Context, client *dns.Client, msg *dns.Msg, server string, pool *ConnPool) (*dns.Msg, error) {
+	injector := faultInjector.Load()
+	if injector == nil {
+		return exchangeContext(ctx, client, msg, server, pool)
+	}
+
+	fault := (*injector).Inject(server, msg)
+	if fault.Delay > 0 {
+		select {
+		case <-time.After(fault.Delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if fault.Rcode != dns.RcodeSuccess {
+		reply := new(dns.Msg)
+		reply.SetRcode(msg, fault.Rcode)
+		return reply, nil
+	}
+	if fault.CorruptTSIG {
+		if tsig := msg.IsTsig(); tsig != nil {
+			corrupted := *client
+			corrupted.TsigSecret = map[string]string{tsig.Hdr.Name: "Y29ycnVwdGVkLXRzaWctc2VjcmV0LWZvci10ZXN0cw=="}
+			client = &corrupted
+		}
+	}
+
+	reply, err := exchangeContext(ctx, client, msg, server, pool)
+	if err == nil && fault.Drop {
+		return nil, fmt.Errorf("%w: response from %s dropped", ErrInjectedFault, server)
+	}
+	return reply, err
+}
+
+// FaultConfig configures RandomFaults. Percentages are in the range 0-100.
+type FaultConfig struct {
+	// DropPercent of exchanges lose their response
+	DropPercent int
+	// Delay is added to every exchange
+	Delay time.Duration
+	// Rcode is returned by RcodePercent of exchanges
+	Rcode        int
+	RcodePercent int
+	// CorruptTSIGPercent of exchanges are signed with a wrong secret
+	CorruptTSIGPercent int
+	// Servers limits faults to these servers; empty means all servers
+	Servers []string
+}
+
+// RandomFaults injects faults at random according to a FaultConfig
+type RandomFaults struct {
+	config  FaultConfig
+	servers map[string]bool
+}
+
+// NewRandomFaults creates an injector applying config
+func NewRandomFaults(config FaultConfig) *RandomFaults {
+	f := &RandomFaults{config: config}
+	if len(config.Servers) > 0 {
+		f.servers = make(map[string]bool, len(config.Servers))
+		for _, server := range config.Servers {
+			f.servers[server] = true
+		}
+	}
+	return f
+}
+
+// Inject implements FaultInjector
+func (f *RandomFaults) Inject(server string, _ *dns.Msg) Fault {
+	if f.servers != nil && !f.servers[server] {
+		return Fault{}
+	}
+	fault := Fault{
+		Delay:       f.config.Delay,
+		Drop:        chance(f.config.DropPercent),
+		CorruptTSIG: chance(f.config.CorruptTSIGPercent),
+	}
+	if f.config.Rcode != dns.RcodeSuccess && chance(f.config.RcodePercent) {
+		fault.Rcode = f.config.Rcode
+	}
+	return fault
+}
+
+// chance returns true with the given percent probability
+func chance(percent int) bool {
+	return percent > 0 && rand.IntN(100) < percent
+}
+
+// ParseFaultSpec parses a comma-separated fault specification such as
+// "drop=20,delay=200ms,rcode=SERVFAIL:50,corrupt-tsig=10,servers=10.0.0.1|10.0.0.2".
+// A bare "rcode=NAME" applies to every exchange.
+func ParseFaultSpec(spec string) (FaultConfig, error) {
+	var config FaultConfig
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return FaultConfig{}, fmt.Errorf("invalid fault %q, expected key=value", part)
+		}
+
+		var err error
+		switch key {
+		case "drop":
+			config.DropPercent, err = parsePercent(value)
+		case "delay":
+			config.Delay, err = time.ParseDuration(value)
+		case "rcode":
+			name, percent, hasPercent := strings.Cut(value, ":")
+			rcode, known := dns.StringToRcode[strings.ToUpper(name)]
+			if !known {
+				return FaultConfig{}, fmt.Errorf("unknown rcode %q", name)
+			}
+			config.Rcode, config.RcodePercent = rcode, 100
+			if hasPercent {
+				config.RcodePercent, err = parsePercent(percent)
+			}
+		case "corrupt-tsig":
+			config.CorruptTSIGPercent, err = parsePercent(value)
+		case "servers":
+			config.Servers = strings.Split(value, "|")
+		default:
+			return FaultConfig{}, fmt.Errorf("unknown fault %q", key)
+		}
+		if err != nil {
+			return FaultConfig{}, fmt.Errorf("invalid fault %q: %w", part, err)
+		}
+	}
+	return config, nil
+}
+
+// parsePercent parses an integer percentage between 0 and 100
+func parsePercent(value string) (int, error) {
+	percent, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, err
+	}
+	if percent < 0 || percent > 100 {
+		return 0, fmt.Errorf("percentage %d out of range 0-100", percent)
+	}
+	return percent, nil
+}
+
+// EnableFaultsFromSpec installs a RandomFaults injector parsed from spec.
+// An empty spec leaves fault injection disabled.
+func EnableFaultsFromSpec(spec string) error {
+	if spec == "" {
+		return nil
+	}
+	config, err := ParseFaultSpec(spec)
+	if err != nil {
+		return err
+	}
+	SetFaultInjector(NewRandomFaults(config))
+	return nil
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"github.com/rieset/istio-dns01-bind9/internal/dnstest"
+)
+
+// fixedFault applies the same fault to every exchange
+type fixedFault Fault
+
+func (f fixedFault) Inject(string, *dns.Msg) Fault { return Fault(f) }
+
+func TestParseFaultSpec(t *testing.T) {
+	got, err := ParseFaultSpec("drop=20, delay=200ms,rcode=servfail:50,corrupt-tsig=10,servers=a|b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := FaultConfig{
+		DropPercent:        20,
+		Delay:              200 * time.Millisecond,
+		Rcode:              dns.RcodeServerFailure,
+		RcodePercent:       50,
+		CorruptTSIGPercent: 10,
+		Servers:            []string{"a", "b"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ParseFaultSpec = %+v, want %+v", got, want)
+	}
+
+	for _, spec := range []string{"drop=101", "rcode=NOPE", "jitter=1s", "drop"} {
+		if _, err := ParseFaultSpec(spec); err == nil {
+			t.Errorf("ParseFaultSpec(%q) succeeded", spec)
+		}
+	}
+}
+
+func TestFaultInjection(t *testing.T) {
+	tests := []struct {
+		name    string
+		fault   Fault
+		applied bool
+	}{
+		{"rcode short-circuits", Fault{Rcode: dns.RcodeRefused}, false},
+		{"dropped response still applies", Fault{Drop: true}, true},
+		{"corrupt TSIG is rejected", Fault{CorruptTSIG: true}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := startServer(t)
+			c := newTestClient(srv, dnstest.TestSecret)
+			SetFaultInjector(fixedFault(tt.fault))
+			defer SetFaultInjector(nil)
+
+			if err := c.AddTXTRecord(context.Background(), testFQDN, "token", 60); err == nil {
+				t.Fatal("AddTXTRecord succeeded despite the injected fault")
+			} else if tt.fault.Drop && !errors.Is(err, ErrInjectedFault) {
+				t.Fatalf("AddTXTRecord error = %v, want ErrInjectedFault", err)
+			}
+			if applied := len(srv.TXT(testFQDN)) > 0; applied != tt.applied {
+				t.Fatalf("record applied = %v, want %v", applied, tt.applied)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"sync"
+
+	"github.com/miekg/dns"
+)
+
+// FunctionRating: 85/100
+// - Complexity: LOW
+// - Integrations: 1 (dns library)
+// - External Risks: LOW (in-memory only)
+// - Unit Tests: PARTIAL (benchmarks)
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: acquireUpdateMsg, acquireTXT, releaseMsg
+// Purpose: Pools UPDATE messages and TXT records to cut allocations on the update hot path
+
+// updateHeader is the header template shared by every UPDATE message
+var updateHeader = dns.MsgHdr{Opcode: dns.OpcodeUpdate}
+
+var (
+	msgPool = sync.Pool{New: func() any { return new(dns.Msg) }}
+	txtPool = sync.Pool{New: func() any { return new(dns.TXT) }}
+)
+
+// acquireUpdateMsg returns a pooled UPDATE message for zone with a fresh ID.
+// Section slices keep their capacity from previous use.
+func acquireUpdateMsg(zone string) *dns.Msg {
+	msg := msgPool.Get().(*dns.Msg)
+	msg.MsgHdr = updateHeader
+	msg.Id = dns.Id()
+	msg.Compress = false
+	msg.Question = append(msg.Question[:0], dns.Question{Name: zone, Qtype: dns.TypeSOA, Qclass: dns.ClassINET})
+	msg.Answer = msg.Answer[:0]
+	msg.Ns = msg.Ns[:0]
+	msg.Extra = msg.Extra[:0]
+	return msg
+}
+
+// acquireTXT returns a pooled TXT record with the given owner, class and TTL and no values
+func acquireTXT(fqdn string, class uint16, ttl uint32) *dns.TXT {
+	rr := txtPool.Get().(*dns.TXT)
+	rr.Hdr = dns.RR_Header{
+		Name:   dns.Fqdn(fqdn),
+		Rrtype: dns.TypeTXT,
+		Class:  class,
+		Ttl:    ttl,
+	}
+	rr.Txt = rr.Txt[:0]
+	return rr
+}
+
+// releaseMsg returns msg and the TXT records it carried to their pools
+func releaseMsg(msg *dns.Msg, rrs ...*dns.TXT) {
+	for _, rr := range rrs {
+		clear(rr.Txt)
+		txtPool.Put(rr)
+	}
+	clear(msg.Question)
+	clear(msg.Answer)
+	clear(msg.Ns)
+	clear(msg.Extra)
+	msgPool.Put(msg)
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+// FunctionRating: 80/100
+// - Complexity: MEDIUM
+// - Integrations: 1 (dns library)
+// - External Risks: LOW (pure computation)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: PlanChanges
+// Purpose: Diffs desired records against live zone contents into create/update/delete changes
+
+// ChangeAction is the kind of change needed to converge an RRset
+type ChangeAction string
+
+const (
+	// ActionCreate adds an RRset that does not exist yet
+	ActionCreate ChangeAction = "create"
+	// ActionUpdate replaces an RRset whose records or TTL differ
+	ActionUpdate ChangeAction = "update"
+	// ActionDelete removes an RRset that is no longer desired
+	ActionDelete ChangeAction = "delete"
+)
+
+// Change describes how one RRset, identified by owner name and type, must change
+type Change struct {
+	Action  ChangeAction
+	Name    string
+	Type    uint16
+	Current []dns.RR
+	Desired []dns.RR
+}
+
+// String renders the change in a plan-like, human readable form
+func (c Change) String() string {
+	var b strings.Builder
+	symbol := map[ChangeAction]string{ActionCreate: "+", ActionUpdate: "~", ActionDelete: "-"}[c.Action]
+	fmt.Fprintf(&b, "%s %s %s %s", symbol, c.Action, c.Name, dns.TypeToString[c.Type])
+	for _, rr := range c.Current {
+		fmt.Fprintf(&b, "\n    - %s", rr.String())
+	}
+	for _, rr := range c.Desired {
+		fmt.Fprintf(&b, "\n    + %s", rr.String())
+	}
+	return b.String()
+}
+
+// rrsetKey identifies an RRset
+type rrsetKey struct {
+	name   string
+	rrtype uint16
+}
+
+// PlanChanges compares desired against live records RRset by RRset. With
+// prune, live RRsets of a type that appears in desired but whose owner name
+// is not desired are deleted; SOA and NS records are never touched.
+func PlanChanges(desired, live []dns.RR, prune bool) []Change {
+	desiredSets := groupRRsets(desired)
+	liveSets := groupRRsets(live)
+
+	managedTypes := map[uint16]bool{}
+	for key := range desiredSets {
+		managedTypes[key.rrtype] = true
+	}
+
+	var changes []Change
+	for key, want := range desiredSets {
+		have, exists := liveSets[key]
+		switch {
+		case !exists:
+			changes = append(changes, Change{Action: ActionCreate, Name: key.name, Type: key.rrtype, Desired: want})
+		case !sameRRset(have, want):
+			changes = append(changes, Change{Action: ActionUpdate, Name: key.name, Type: key.rrtype,
+				Current: have, Desired: want})
+		}
+	}
+
+	if prune {
+		for key, have := range liveSets {
+			if _, wanted := desiredSets[key]; wanted || !managedTypes[key.rrtype] {
+				continue
+			}
+			if key.rrtype == dns.TypeSOA || key.rrtype == dns.TypeNS {
+				continue
+			}
+			changes = append(changes, Change{Action: ActionDelete, Name: key.name, Type: key.rrtype, Current: have})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Name != changes[j].Name {
+			return changes[i].Name < changes[j].Name
+		}
+		return changes[i].Type < changes[j].Type
+	})
+	return changes
+}
+
+// groupRRsets groups records by lowercased owner name and type
+func groupRRsets(records []dns.RR) map[rrsetKey][]dns.RR {
+	sets := map[rrsetKey][]dns.RR{}
+	for _, rr := range records {
+		hdr := rr.Header()
+		key := rrsetKey{name: dns.Fqdn(strings.ToLower(hdr.Name)), rrtype: hdr.Rrtype}
+		sets[key] = append(sets[key], rr)
+	}
+	return sets
+}
+
+// sameRRset reports whether two RRsets hold the same records with the same TTLs
+func sameRRset(a, b []dns.RR) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, ra := range a {
+		found := false
+		for _, rb := range b {
+			if dns.IsDuplicate(ra, rb) && ra.Header().Ttl == rb.Header().Ttl {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+func mustRRs(t *testing.T, lines ...string) []dns.RR {
+	t.Helper()
+	records := make([]dns.RR, 0, len(lines))
+	for _, line := range lines {
+		rr, err := dns.NewRR(line)
+		if err != nil {
+			t.Fatalf("bad record %q: %v", line, err)
+		}
+		records = append(records, rr)
+	}
+	return records
+}
+
+func TestPlanChanges(t *testing.T) {
+	live := mustRRs(t,
+		"example.com. 300 IN SOA ns1.example.com. hostmaster.example.com. 1 3600 600 86400 60",
+		"example.com. 300 IN NS ns1.example.com.",
+		"app.example.com. 300 IN A 192.0.2.10",
+		"api.example.com. 300 IN A 192.0.2.20",
+		"old.example.com. 300 IN A 192.0.2.30",
+		"www.example.com. 300 IN CNAME app.example.com.",
+	)
+	desired := mustRRs(t,
+		"app.example.com. 300 IN A 192.0.2.10",
+		"API.example.com. 60 IN A 192.0.2.20",
+		"new.example.com. 300 IN A 192.0.2.40",
+	)
+
+	got := map[string]ChangeAction{}
+	for _, c := range PlanChanges(desired, live, true) {
+		got[c.Name+"/"+dns.TypeToString[c.Type]] = c.Action
+	}
+	want := map[string]ChangeAction{
+		"api.example.com./A": ActionUpdate,
+		"new.example.com./A": ActionCreate,
+		"old.example.com./A": ActionDelete,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("changes = %v, want %v", got, want)
+	}
+	for key, action := range want {
+		if got[key] != action {
+			t.Fatalf("changes = %v, want %v", got, want)
+		}
+	}
+
+	for _, c := range PlanChanges(desired, live, false) {
+		if c.Action == ActionDelete {
+			t.Fatalf("delete planned without prune: %v", c)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+const testAPIKey = "pdns-api-key"
+
+// fakePowerDNS serves the zone endpoints of the PowerDNS API for example.com.
+type fakePowerDNS struct {
+	mu     sync.Mutex
+	rrsets map[string]pdnsRRset
+}
+
+func (f *fakePowerDNS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-API-Key") != testAPIKey {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte("Unauthorized"))
+		return
+	}
+	if r.URL.Path != "/api/v1/servers/localhost/zones/example.com." {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": "Could not find domain"})
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.Method {
+	case http.MethodGet:
+		// Ignore the rrset filter, like PowerDNS before 4.6
+		var rrsets []pdnsRRset
+		for _, rrset := range f.rrsets {
+			rrsets = append(rrsets, rrset)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"name": "example.com.", "rrsets": rrsets})
+	case http.MethodPatch:
+		var body struct {
+			RRsets []pdnsRRset `json:"rrsets"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		}
+		for _, rrset := range body.RRsets {
+			key := rrset.Name + "/" + rrset.Type
+			if rrset.ChangeType == "DELETE" {
+				delete(f.rrsets, key)
+				continue
+			}
+			rrset.ChangeType = ""
+			f.rrsets[key] = rrset
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// txt returns the sorted TXT contents at fqdn
+func (f *fakePowerDNS) txt(fqdn string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var values []string
+	for _, record := range f.rrsets[fqdn+"/TXT"].Records {
+		values = append(values, record.Content)
+	}
+	sort.Strings(values)
+	return values
+}
+
+func startPowerDNS(t *testing.T) (*fakePowerDNS, string) {
+	t.Helper()
+	fake := &fakePowerDNS{rrsets: map[string]pdnsRRset{
+		"example.com./SOA": {Name: "example.com.", Type: "SOA", TTL: 3600,
+			Records: []pdnsRecord{{Content: "ns1.example.com. hostmaster.example.com. 1 3600 600 86400 60"}}},
+	}}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	return fake, srv.URL
+}
+
+func TestPowerDNSClientAddAndDelete(t *testing.T) {
+	fake, apiURL := startPowerDNS(t)
+	c := NewPowerDNSClient(apiURL+"/", "localhost", "example.com", testAPIKey, zap.NewNop())
+	ctx := context.Background()
+
+	for _, value := range []string{"token-1", "token-2", "token-1"} {
+		if err := c.AddTXTRecord(ctx, testFQDN, value, 60); err != nil {
+			t.Fatalf("AddTXTRecord(%s): %v", value, err)
+		}
+	}
+	if got, want := fake.txt(testFQDN), []string{`"token-1"`, `"token-2"`}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("TXT after add = %v, want %v", got, want)
+	}
+
+	for range 2 {
+		if err := c.DeleteTXTRecordValue(ctx, testFQDN, "token-1"); err != nil {
+			t.Fatalf("DeleteTXTRecordValue: %v", err)
+		}
+	}
+	if got, want := fake.txt(testFQDN), []string{`"token-2"`}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("TXT after value delete = %v, want %v", got, want)
+	}
+	if err := c.DeleteTXTRecordValue(ctx, testFQDN, "token-2"); err != nil {
+		t.Fatalf("DeleteTXTRecordValue of the last value: %v", err)
+	}
+	if _, ok := fake.rrsets[testFQDN+"/TXT"]; ok {
+		t.Fatal("empty TXT RRset left behind")
+	}
+
+	if err := c.AddTXTRecord(ctx, testFQDN, "token-1", 60); err != nil {
+		t.Fatalf("AddTXTRecord: %v", err)
+	}
+	if err := c.DeleteTXTRecord(ctx, testFQDN); err != nil {
+		t.Fatalf("DeleteTXTRecord: %v", err)
+	}
+	if got := fake.txt(testFQDN); len(got) != 0 {
+		t.Fatalf("TXT after delete = %v, want none", got)
+	}
+	if _, ok := fake.rrsets["example.com./SOA"]; !ok {
+		t.Fatal("unrelated RRsets were removed")
+	}
+}
+
+func TestPowerDNSClientErrors(t *testing.T) {
+	_, apiURL := startPowerDNS(t)
+	tests := []struct {
+		name    string
+		zone    string
+		apiKey  string
+		wantErr string
+	}{
+		{"bad api key", "example.com", "wrong", "401: Unauthorized"},
+		{"unknown zone", "example.org", testAPIKey, "404: Could not find domain"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewPowerDNSClient(apiURL, "localhost", tt.zone, tt.apiKey, zap.NewNop())
+			err := c.AddTXTRecord(context.Background(), testFQDN, "token", 60)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("AddTXTRecord error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/miekg/dns"
+	"go.uber.org/zap"
+)
+
+// FunctionRating: 80/100
+// - Complexity: LOW
+// - Integrations: 2 (dns library, logging)
+// - External Risks: LOW (no-op update, zone content is never changed)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: CheckUpdatePermission, VerifyCredentials
+// Purpose: No-op signed UPDATEs confirming the key is accepted and may change TXT records at a name
+
+// ErrUpdateNotAuthorized is wrapped by CheckUpdatePermission when the server
+// refuses the key for the name, as opposed to being unreachable
+var ErrUpdateNotAuthorized = errors.New("update not authorized")
+
+// preflightValue is the TXT value the permission check pretends to delete.
+// Removing an RR that does not exist leaves the zone and its serial untouched.
+const preflightValue = "dns01-preflight-noop"
+
+// CheckUpdatePermission sends an UPDATE for fqdn that changes nothing: the
+// zone SOA prerequisite always holds and the update section deletes a TXT
+// value that does not exist. Servers run update-policy checks on the update
+// section only after the prerequisites passed, so a prerequisite-only message
+// would be accepted for any name; the no-op delete makes BIND evaluate the
+// policy for fqdn/TXT and answer REFUSED when the key is not granted it.
+// With SetOwnership the registry name of fqdn is checked in the same message.
+func (c *RFC2136Client) CheckUpdatePermission(ctx context.Context, fqdn string) error {
+	msg := acquireUpdateMsg(c.zone)
+	rr := acquireTXT(fqdn, dns.ClassNONE, 0)
+	rr.Txt = append(rr.Txt, preflightValue)
+	msg.Ns = append(msg.Ns, rr)
+	if c.ownership.Owner != "" {
+		// The registry entries are written with the records, so their name must be granted too
+		msg.Ns = append(msg.Ns, c.ownership.entry(rr, dns.ClassNONE, 0))
+	}
+	c.finishMsg(msg)
+	defer releaseMsg(msg, rr)
+
+	reply, err := c.sendKeys(ctx, msg)
+	if err != nil {
+		return fmt.Errorf("failed to send preflight update to %s: %w", c.server, err)
+	}
+	// Named after the send, which may have switched to a fallback key
+	hint := fmt.Sprintf("grant %s name %s TXT;", c.keyName(), dns.Fqdn(fqdn))
+	if c.ownership.Owner != "" {
+		hint += fmt.Sprintf(" grant %s name %s TXT;", c.keyName(), RegistryName(fqdn))
+	}
+
+	switch reply.Rcode {
+	case dns.RcodeSuccess:
+		c.logger.Debug("Update permission confirmed",
+			zap.String("fqdn", fqdn),
+			zap.String("server", c.server),
+		)
+		return nil
+	case dns.RcodeRefused:
+		return fmt.Errorf("%w: %s refused key %s for TXT records at %s; "+
+			"grant it in the update-policy of zone %s (e.g. %s)",
+			ErrUpdateNotAuthorized, c.server, c.keyName(), dns.Fqdn(fqdn), c.zone, hint)
+	case dns.RcodeNotAuth:
+		return c.notAuthError(reply)
+	case dns.RcodeNotZone:
+		return fmt.Errorf("%w: %s is outside zone %s on %s", ErrUpdateNotAuthorized, dns.Fqdn(fqdn), c.zone, c.server)
+	default:
+		return fmt.Errorf("preflight update on %s failed: %s (rcode: %d)",
+			c.server, dns.RcodeToString[reply.Rcode], reply.Rcode)
+	}
+}
+
+// VerifyCredentials sends an UPDATE holding only the zone SOA prerequisite.
+// It changes nothing and no update-policy applies to an empty update
+// section, so the answer only tells whether the server accepts the key for
+// the zone at all.
+func (c *RFC2136Client) VerifyCredentials(ctx context.Context) error {
+	msg := acquireUpdateMsg(c.zone)
+	if !c.quirks.ZonePrerequisite {
+		msg.Answer = append(msg.Answer, c.zonePrereq)
+	}
+	c.finishMsg(msg)
+	defer releaseMsg(msg)
+
+	reply, err := c.sendKeys(ctx, msg)
+	if err != nil {
+		return fmt.Errorf("failed to send credential check to %s: %w", c.server, err)
+	}
+	switch reply.Rcode {
+	case dns.RcodeSuccess:
+		return nil
+	case dns.RcodeNotAuth:
+		return c.notAuthError(reply)
+	case dns.RcodeRefused, dns.RcodeNotZone:
+		return fmt.Errorf("%w: %s answered %s for zone %s; it does not serve the zone or allows no updates of it",
+			ErrUpdateNotAuthorized, c.server, dns.RcodeToString[reply.Rcode], c.zone)
+	default:
+		return fmt.Errorf("credential check on %s failed: %s (rcode: %d)",
+			c.server, dns.RcodeToString[reply.Rcode], reply.Rcode)
+	}
+}
+
+// notAuthError explains a NOTAUTH reply, which servers give for keys they
+// do not accept as well as for zones they are not authoritative for. A TSIG
+// error in reply names the cause.
+func (c *RFC2136Client) notAuthError(reply *dns.Msg) error {
+	if code := tsigError(reply); code != 0 {
+		rejected := &RcodeError{Rcode: dns.RcodeNotAuth, TSIGError: code}
+		return fmt.Errorf("%w: %s rejected the TSIG of key %s for zone %s with %s; %s",
+			ErrUpdateNotAuthorized, c.server, c.keyName(), c.zone, dns.RcodeToString[code], rejected.diagnostic())
+	}
+	if c.sig0 != nil {
+		return fmt.Errorf("%w: %s answered NOTAUTH for zone %s; the SIG(0) key %s is not published in the zone, "+
+			"the private key does not match it, the clocks differ by more than %s, or the server is not authoritative",
+			ErrUpdateNotAuthorized, c.server, c.zone, c.keyName(), sig0Validity)
+	}
+	return fmt.Errorf("%w: %s answered NOTAUTH for zone %s; the TSIG key %s is unknown, its secret or "+
+		"algorithm is wrong, the clocks differ by more than the fudge, or the server is not authoritative",
+		ErrUpdateNotAuthorized, c.server, c.zone, c.keyName())
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/rieset/istio-dns01-bind9/internal/dnstest"
+)
+
+func TestCheckUpdatePermission(t *testing.T) {
+	srv := startServer(t)
+	srv.Grant(dnstest.TestKeyName, testFQDN)
+	srv.SetTXT(testFQDN, 60, "existing")
+	ctx := context.Background()
+	serial := srv.Serial("example.com")
+
+	c := newTestClient(srv, dnstest.TestSecret)
+	if err := c.CheckUpdatePermission(ctx, testFQDN); err != nil {
+		t.Fatalf("CheckUpdatePermission on granted name: %v", err)
+	}
+	if got := srv.TXT(testFQDN); len(got) != 1 || got[0] != "existing" {
+		t.Fatalf("preflight changed the RRset: %v", got)
+	}
+	if srv.Serial("example.com") != serial {
+		t.Fatal("preflight bumped the zone serial")
+	}
+
+	err := c.CheckUpdatePermission(ctx, "_acme-challenge.other.example.com.")
+	if !errors.Is(err, ErrUpdateNotAuthorized) || !strings.Contains(err.Error(), "update-policy") {
+		t.Fatalf("CheckUpdatePermission on name outside the policy = %v", err)
+	}
+
+	wrongKey := newTestClient(srv, "c2VjcmV0LXRoYXQtZG9lcy1ub3QtbWF0Y2g=")
+	if err := wrongKey.CheckUpdatePermission(ctx, testFQDN); !errors.Is(err, ErrUpdateNotAuthorized) {
+		t.Fatalf("CheckUpdatePermission with a wrong secret = %v", err)
+	}
+}
+
+func TestVerifyCredentials(t *testing.T) {
+	srv := startServer(t)
+	// Credentials are accepted whatever names the key is granted
+	srv.Grant(dnstest.TestKeyName, testFQDN)
+	ctx := context.Background()
+	serial := srv.Serial("example.com")
+
+	if err := newTestClient(srv, dnstest.TestSecret).VerifyCredentials(ctx); err != nil {
+		t.Fatalf("VerifyCredentials: %v", err)
+	}
+	if srv.Serial("example.com") != serial {
+		t.Fatal("credential check bumped the zone serial")
+	}
+
+	wrongKey := newTestClient(srv, "c2VjcmV0LXRoYXQtZG9lcy1ub3QtbWF0Y2g=")
+	if err := wrongKey.VerifyCredentials(ctx); !errors.Is(err, ErrUpdateNotAuthorized) {
+		t.Fatalf("VerifyCredentials with a wrong secret = %v", err)
+	}
+
+	// A server that does not serve the zone refuses any update of it
+	srv.SetUpdateRcode(dns.RcodeRefused)
+	if err := newTestClient(srv, dnstest.TestSecret).VerifyCredentials(ctx); !errors.Is(err, ErrUpdateNotAuthorized) {
+		t.Fatalf("VerifyCredentials against a refusing server = %v", err)
+	}
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/miekg/dns"
+	"go.uber.org/zap"
+)
+
+// FunctionRating: 80/100
+// - Complexity: MEDIUM
+// - Integrations: 1 (dns library)
+// - External Risks: LOW (only narrows which updates a server applies)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: Prerequisites
+// Purpose: RFC2136 prerequisites keeping TXT updates off records of other systems and making retried adds idempotent
+
+// ErrNameInUse is returned when an add guarded by NameNotInUse meets a name
+// holding records other than the values being added
+var ErrNameInUse = errors.New("name is in use by other records")
+
+// Prerequisites selects the RFC2136 prerequisites (section 2.4) guarding the
+// TXT updates of a client
+type Prerequisites struct {
+	// NameNotInUse inserts only at names holding no records. An insert refused
+	// because its name is in use still succeeds when the TXT RRset already
+	// holds exactly the inserted values, as after a retried add.
+	NameNotInUse bool
+	// RRsetExists deletes only RRsets that exist; a missing RRset counts
+	// as already deleted
+	RRsetExists bool
+}
+
+// SetPrerequisites sets the prerequisites of TXT updates; the default sends none
+func (c *RFC2136Client) SetPrerequisites(prereqs Prerequisites) {
+	c.prereqs = prereqs
+}
+
+// addInsertPrerequisites appends the NameNotInUse prerequisites of inserting records to msg
+func (c *RFC2136Client) addInsertPrerequisites(msg *dns.Msg, records ...dns.RR) {
+	if !c.prereqs.NameNotInUse {
+		return
+	}
+	// Name is not in use (RFC2136 section 2.4.5): class NONE, type ANY, no rdata
+	for _, name := range recordNames(records) {
+		msg.Answer = append(msg.Answer, &dns.ANY{Hdr: dns.RR_Header{Name: name, Rrtype: dns.TypeANY, Class: dns.ClassNONE}})
+	}
+}
+
+// addDeletePrerequisites appends the RRsetExists prerequisites of deleting
+// records, one per RRset, to msg
+func (c *RFC2136Client) addDeletePrerequisites(msg *dns.Msg, records ...dns.RR) {
+	if !c.prereqs.RRsetExists {
+		return
+	}
+	type rrset struct {
+		name   string
+		rrtype uint16
+	}
+	seen := map[rrset]bool{}
+	for _, rr := range records {
+		key := rrset{rr.Header().Name, rr.Header().Rrtype}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		// RRset exists, value independent (RFC2136 section 2.4.1): class ANY, no rdata
+		msg.Answer = append(msg.Answer, &dns.ANY{Hdr: dns.RR_Header{Name: key.name, Rrtype: key.rrtype, Class: dns.ClassANY}})
+	}
+}
+
+// resolveNameInUse decides an insert of records that failed with err: when
+// NameNotInUse refused it, the insert succeeds if the records are already the
+// whole RRset at their names and fails with ErrNameInUse otherwise
+func (c *RFC2136Client) resolveNameInUse(ctx context.Context, err error, fqdn string, records ...dns.RR) error {
+	var rcodeErr *RcodeError
+	if !c.prereqs.NameNotInUse || !errors.As(err, &rcodeErr) || rcodeErr.Rcode != dns.RcodeYXDomain {
+		return err
+	}
+
+	// RRset exists, value dependent (RFC2136 section 2.4.2): the records with TTL 0
+	msg := acquireUpdateMsg(c.zone)
+	defer releaseMsg(msg)
+	for _, rr := range records {
+		prereq := dns.Copy(rr)
+		prereq.Header().Ttl = 0
+		msg.Answer = append(msg.Answer, prereq)
+	}
+	c.finishMsg(msg)
+
+	err = c.exchange(ctx, msg, fqdn, "prerequisite")
+	if errors.As(err, &rcodeErr) && rcodeErr.Rcode == dns.RcodeNXRrset {
+		return fmt.Errorf("%s on %s: %w", fqdn, c.server, ErrNameInUse)
+	}
+	if err != nil {
+		return err
+	}
+	c.logger.Info("Records already present",
+		zap.String("fqdn", fqdn),
+		zap.String("server", c.server),
+	)
+	return nil
+}
+
+// prerequisiteFailed reports whether rcode is the answer to a prerequisite
+// that was not met (RFC2136 section 3.2)
+func prerequisiteFailed(rcode int) bool {
+	switch rcode {
+	case dns.RcodeYXDomain, dns.RcodeYXRrset, dns.RcodeNXRrset, dns.RcodeNameError:
+		return true
+	}
+	return false
+}
+
+// recordNames returns the distinct owner names of records in order
+func recordNames(records []dns.RR) []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, rr := range records {
+		name := rr.Header().Name
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/miekg/dns"
+
+	"github.com/rieset/istio-dns01-bind9/internal/dnstest"
+)
+
+func TestRFC2136ClientNameNotInUse(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing []string
+		value    string
+		wantErr  error
+		wantTXT  []string
+	}{
+		{"unused name", nil, "token", nil, []string{"token"}},
+		{"retried add", []string{"token"}, "token", nil, []string{"token"}},
+		{"name in use", []string{"other"}, "token", ErrNameInUse, []string{"other"}},
+		{"value among others", []string{"other", "token"}, "token", ErrNameInUse, []string{"other", "token"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := startServer(t)
+			if len(tt.existing) > 0 {
+				srv.SetTXT(testFQDN, 60, tt.existing...)
+			}
+			c := newTestClient(srv, dnstest.TestSecret)
+			c.SetPrerequisites(Prerequisites{NameNotInUse: true})
+
+			err := c.AddTXTRecord(context.Background(), testFQDN, tt.value, 60)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("AddTXTRecord error = %v, want %v", err, tt.wantErr)
+			}
+			if got := srv.TXT(testFQDN); !reflect.DeepEqual(got, tt.wantTXT) {
+				t.Fatalf("TXT = %v, want %v", got, tt.wantTXT)
+			}
+		})
+	}
+}
+
+func TestRFC2136ClientRRsetExists(t *testing.T) {
+	srv := startServer(t)
+	c := newTestClient(srv, dnstest.TestSecret)
+	quirks := DefaultQuirks
+	quirks.IdempotentDelete = false
+	c.SetQuirks(quirks)
+	ctx := context.Background()
+
+	c.SetPrerequisites(Prerequisites{RRsetExists: true})
+	msg, rr := c.buildDeleteValueMsg(testFQDN, "token")
+	if len(msg.Answer) == 0 || msg.Answer[0].Header().Class != dns.ClassANY || msg.Answer[0].Header().Rrtype != dns.TypeTXT {
+		t.Fatalf("delete prerequisites = %v, want the TXT RRset to exist", msg.Answer)
+	}
+	releaseMsg(msg, rr)
+
+	before := srv.Serial("example.com.")
+	if err := c.DeleteTXTRecordValue(ctx, testFQDN, "token"); err != nil {
+		t.Fatalf("DeleteTXTRecordValue of a missing RRset: %v", err)
+	}
+	if err := c.DeleteTXTRecord(ctx, testFQDN); err != nil {
+		t.Fatalf("DeleteTXTRecord of a missing RRset: %v", err)
+	}
+	if srv.Serial("example.com.") != before {
+		t.Fatal("a delete of a missing RRset changed the zone")
+	}
+
+	srv.SetTXT(testFQDN, 60, "token", "other")
+	if err := c.DeleteTXTRecordValue(ctx, testFQDN, "token"); err != nil {
+		t.Fatalf("DeleteTXTRecordValue: %v", err)
+	}
+	if got, want := srv.TXT(testFQDN), []string{"other"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("TXT after delete = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,206 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// FunctionRating: 77/100
+// - Complexity: MEDIUM
+// - Integrations: 1 (dns library)
+// - External Risks: MEDIUM (network operations, concurrent polling)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: WaitForPropagation, WaitForZonePropagation
+// Purpose: Polls servers concurrently until enough of them agree on a TXT value
+
+// DefaultPropagationInterval is used when a check does not set an interval
+const DefaultPropagationInterval = 2 * time.Second
+
+// PropagationCheck describes the state a set of servers is expected to converge to
+type PropagationCheck struct {
+	// Servers are polled concurrently
+	Servers []string
+	// FQDN is the name carrying the TXT record
+	FQDN string
+	// Value is the TXT value being checked
+	Value string
+	// Present waits for the value to appear when true and to disappear when false
+	Present bool
+	// MinMatches is the number of servers that must match; 0 means all servers
+	MinMatches int
+	// Interval is the delay between polls of a single server
+	Interval time.Duration
+	// QueryTimeout bounds each individual query
+	QueryTimeout time.Duration
+	// TLS holds the DNS-over-TLS settings of servers that are only reachable
+	// over TLS; servers without an entry are queried over UDP
+	TLS map[string]*tls.Config
+	// Proxies holds the proxies of servers that are only reachable through
+	// one; they are queried over TCP, or TLS with an entry in TLS
+	Proxies map[string]*Proxy
+	// DNSSEC additionally requires a valid RRSIG over the TXT RRset, see
+	// HasSignedTXTValue; it only applies while waiting for a value to appear
+	DNSSEC bool
+	// Recursive asks Servers to recurse, for resolvers such as the ones
+	// cert-manager's self-check or the ACME server use; DNSSEC is ignored
+	Recursive bool
+}
+
+// PropagationResult reports which servers converged before the check returned
+type PropagationResult struct {
+	Matched []string
+	Pending []string
+}
+
+// WaitForPropagation polls every server concurrently and returns as soon as
+// MinMatches servers report the expected state. Remaining pollers are
+// cancelled on return. An error is returned when ctx expires first.
+func WaitForPropagation(ctx context.Context, check PropagationCheck) (PropagationResult, error) {
+	check.Servers = uniqueServers(check.Servers)
+	if check.Interval <= 0 {
+		check.Interval = DefaultPropagationInterval
+	}
+
+	required := check.MinMatches
+	if required <= 0 || required > len(check.Servers) {
+		required = len(check.Servers)
+	}
+
+	pollCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	matches := make(chan string, len(check.Servers))
+	for _, server := range check.Servers {
+		go pollServer(pollCtx, check, server, matches)
+	}
+
+	matched := map[string]bool{}
+	for len(matched) < required {
+		select {
+		case server := <-matches:
+			matched[server] = true
+		case <-ctx.Done():
+			result := newPropagationResult(check.Servers, matched)
+			return result, fmt.Errorf("propagation of %s not confirmed: %d/%d servers matched (pending: %v): %w",
+				check.FQDN, len(matched), required, result.Pending, ctx.Err())
+		}
+	}
+
+	return newPropagationResult(check.Servers, matched), nil
+}
+
+// WaitForZonePropagation runs check and then waits until every nameserver
+// in the NS RRset of zone, as served by the first server of check, reports
+// the expected state too. The returned result covers both sets of servers.
+func WaitForZonePropagation(ctx context.Context, check PropagationCheck, zone string) (PropagationResult, error) {
+	result, err := WaitForPropagation(ctx, check)
+	if err != nil {
+		return result, err
+	}
+	servers := uniqueServers(check.Servers)
+	if len(servers) == 0 {
+		return result, fmt.Errorf("no server to look up the nameservers of %s on", zone)
+	}
+
+	nameservers, err := ZoneNameservers(ctx, servers[0], zone, check.QueryTimeout)
+	if err != nil {
+		return result, fmt.Errorf("failed to look up the nameservers of %s: %w", zone, err)
+	}
+	return WaitForAllServers(ctx, check, nameservers, result)
+}
+
+// WaitForAllServers waits until every one of servers reports the expected
+// state of check, such as secondaries that only serve a change once they
+// transferred it. The servers are added to result, which holds the outcome
+// of earlier checks.
+func WaitForAllServers(ctx context.Context, check PropagationCheck, servers []string,
+	result PropagationResult) (PropagationResult, error) {
+	check.Servers = servers
+	check.MinMatches = 0
+	more, err := WaitForPropagation(ctx, check)
+
+	result.Matched = append(result.Matched, more.Matched...)
+	result.Pending = append(result.Pending, more.Pending...)
+	sort.Strings(result.Matched)
+	sort.Strings(result.Pending)
+	return result, err
+}
+
+// pollServer queries server until it reports the expected state or ctx is cancelled
+func pollServer(ctx context.Context, check PropagationCheck, server string, matches chan<- string) {
+	lookup := hasTXTValue
+	switch {
+	case check.Recursive:
+		lookup = hasResolvedTXTValue
+	case check.DNSSEC && check.Present:
+		lookup = hasSignedTXTValue
+	}
+	ctx = withProxy(ctx, check.Proxies[server])
+	ticker := time.NewTicker(check.Interval)
+	defer ticker.Stop()
+
+	for {
+		present, err := lookup(ctx, server, check.FQDN, check.Value, check.QueryTimeout, check.TLS[server])
+		if err == nil && present == check.Present {
+			matches <- server
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// newPropagationResult splits servers into matched and pending
+func newPropagationResult(servers []string, matched map[string]bool) PropagationResult {
+	result := PropagationResult{}
+	for _, server := range servers {
+		if matched[server] {
+			result.Matched = append(result.Matched, server)
+		} else {
+			result.Pending = append(result.Pending, server)
+		}
+	}
+	sort.Strings(result.Matched)
+	sort.Strings(result.Pending)
+	return result
+}
+
+// uniqueServers returns servers without duplicates, preserving order
+func uniqueServers(servers []string) []string {
+	seen := make(map[string]bool, len(servers))
+	unique := make([]string, 0, len(servers))
+	for _, server := range servers {
+		if !seen[server] {
+			seen[server] = true
+			unique = append(unique, server)
+		}
+	}
+	return unique
+}
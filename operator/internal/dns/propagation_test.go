@@ -0,0 +1,116 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestZoneNameservers(t *testing.T) {
+	srv := startServer(t)
+	ctx := context.Background()
+
+	if _, err := ZoneNameservers(ctx, srv.Addr(), "example.com.", time.Second); err == nil {
+		t.Fatal("ZoneNameservers succeeded without NS records")
+	}
+
+	srv.SetNS("example.com", "ns1.example.net", "ns2.example.net.")
+	got, err := ZoneNameservers(ctx, srv.Addr(), "example.com.", time.Second)
+	if err != nil {
+		t.Fatalf("ZoneNameservers: %v", err)
+	}
+	if want := []string{"ns1.example.net.", "ns2.example.net."}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("ZoneNameservers = %v, want %v", got, want)
+	}
+}
+
+func TestWaitForZonePropagation(t *testing.T) {
+	first, second := startServer(t), startServer(t)
+	first.SetTXT(testFQDN, 60, "token")
+	second.SetTXT(testFQDN, 60, "token")
+	check := PropagationCheck{
+		Servers:      []string{first.Addr(), second.Addr()},
+		FQDN:         testFQDN,
+		Value:        "token",
+		Present:      true,
+		Interval:     50 * time.Millisecond,
+		QueryTimeout: 200 * time.Millisecond,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := WaitForZonePropagation(ctx, check, "example.com."); err == nil {
+		t.Fatal("WaitForZonePropagation succeeded without an NS RRset")
+	}
+
+	// A nameserver that never answers keeps the check pending until the deadline
+	first.SetNS("example.com", "ns.unreachable.invalid")
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	result, err := WaitForZonePropagation(ctx, check, "example.com.")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("WaitForZonePropagation = %v, want deadline exceeded", err)
+	}
+	if len(result.Matched) != 2 || !reflect.DeepEqual(result.Pending, []string{"ns.unreachable.invalid."}) {
+		t.Fatalf("result = %+v", result)
+	}
+}
+
+func TestWaitForAllServers(t *testing.T) {
+	primary, secondary := startServer(t), startServer(t)
+	primary.SetTXT(testFQDN, 60, "token")
+	check := PropagationCheck{
+		Servers:      []string{primary.Addr()},
+		FQDN:         testFQDN,
+		Value:        "token",
+		Present:      true,
+		MinMatches:   1,
+		Interval:     50 * time.Millisecond,
+		QueryTimeout: 200 * time.Millisecond,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	result, err := WaitForPropagation(ctx, check)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The secondary serves the record once it has transferred the zone
+	time.AfterFunc(200*time.Millisecond, func() { secondary.SetTXT(testFQDN, 60, "token") })
+	result, err = WaitForAllServers(ctx, check, []string{secondary.Addr()}, result)
+	if err != nil {
+		t.Fatalf("WaitForAllServers: %v", err)
+	}
+	want := []string{primary.Addr(), secondary.Addr()}
+	sort.Strings(want)
+	if !reflect.DeepEqual(result.Matched, want) || len(result.Pending) != 0 {
+		t.Fatalf("result = %+v, want matched %v", result, want)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	check.Value = "missing"
+	result, err = WaitForAllServers(ctx, check, []string{secondary.Addr()}, PropagationResult{})
+	if !errors.Is(err, context.DeadlineExceeded) || !reflect.DeepEqual(result.Pending, []string{secondary.Addr()}) {
+		t.Fatalf("WaitForAllServers = %+v, %v, want the secondary pending", result, err)
+	}
+}
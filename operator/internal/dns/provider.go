@@ -0,0 +1,40 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import "context"
+
+// Provider publishes and removes ACME challenge TXT records in one zone.
+// The RFC2136, PowerDNS, CoreDNS etcd and Route53 clients implement it, and
+// BridgedProvider combines several of them.
+type Provider interface {
+	// AddTXTRecord adds value to the TXT RRset at fqdn, keeping other values
+	AddTXTRecord(ctx context.Context, fqdn, value string, ttl int) error
+	// DeleteTXTRecord removes the TXT RRset at fqdn
+	DeleteTXTRecord(ctx context.Context, fqdn string) error
+	// DeleteTXTRecordValue removes value from the TXT RRset at fqdn, keeping other values
+	DeleteTXTRecordValue(ctx context.Context, fqdn, value string) error
+}
+
+var (
+	_ Provider = (*RFC2136Client)(nil)
+	_ Provider = (*PowerDNSClient)(nil)
+	_ Provider = (*CoreDNSEtcdClient)(nil)
+	_ Provider = (*Route53Client)(nil)
+	_ Provider = (*BridgedProvider)(nil)
+	_ Provider = (*DryRunProvider)(nil)
+)
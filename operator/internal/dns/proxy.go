@@ -0,0 +1,223 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/miekg/dns"
+	xproxy "golang.org/x/net/proxy"
+)
+
+// FunctionRating: 76/100
+// - Complexity: MEDIUM
+// - Integrations: 1 (SOCKS5 and HTTP CONNECT proxies)
+// - External Risks: MEDIUM (every exchange with a proxied server depends on the proxy)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: Proxy, ParseProxy
+// Purpose: Tunnels TCP and DNS-over-TLS exchanges to servers in other networks through a SOCKS5 or HTTP CONNECT proxy
+
+// Proxy schemes accepted by ParseProxy
+const (
+	ProxySchemeSOCKS5 = "socks5"
+	ProxySchemeHTTP   = "http"
+	ProxySchemeHTTPS  = "https"
+)
+
+// Proxy tunnels connections to servers that are not directly reachable, such
+// as primaries in a management cluster or on-prem behind a jump host. UDP
+// cannot be tunneled, so clients reach proxied servers over TCP or TLS.
+type Proxy struct {
+	url *url.URL
+}
+
+// ParseProxy parses a proxy URL such as socks5://jump.example:1080 or
+// http://proxy.example:3128. User info in the URL authenticates with the proxy.
+func ParseProxy(raw string) (*Proxy, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL: %w", err)
+	}
+	switch u.Scheme {
+	case ProxySchemeSOCKS5, ProxySchemeHTTP, ProxySchemeHTTPS:
+	default:
+		return nil, fmt.Errorf("proxy URL %q must use scheme %s, %s or %s",
+			u.Redacted(), ProxySchemeSOCKS5, ProxySchemeHTTP, ProxySchemeHTTPS)
+	}
+	if u.Hostname() == "" || u.Port() == "" {
+		return nil, fmt.Errorf("proxy URL %q must name a host and port", u.Redacted())
+	}
+	if (u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.Fragment != "" {
+		return nil, fmt.Errorf("proxy URL %q must not have a path, query or fragment", u.Redacted())
+	}
+	return &Proxy{url: u}, nil
+}
+
+// String returns the proxy URL without its password
+func (p *Proxy) String() string {
+	return p.url.Redacted()
+}
+
+// DialContext opens a TCP connection to addr through the proxy
+func (p *Proxy) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	if network != "tcp" {
+		return nil, fmt.Errorf("proxy %s cannot carry %s to %s, use the tcp or tls transport", p, network, addr)
+	}
+	if p.url.Scheme == ProxySchemeSOCKS5 {
+		var auth *xproxy.Auth
+		if p.url.User != nil {
+			password, _ := p.url.User.Password()
+			auth = &xproxy.Auth{User: p.url.User.Username(), Password: password}
+		}
+		dialer, err := xproxy.SOCKS5("tcp", p.url.Host, auth, &net.Dialer{})
+		if err != nil {
+			return nil, err
+		}
+		conn, err := dialer.(xproxy.ContextDialer).DialContext(ctx, network, addr)
+		if err != nil {
+			return nil, fmt.Errorf("proxy %s: %w", p, err)
+		}
+		return conn, nil
+	}
+	return p.connect(ctx, addr)
+}
+
+// connect opens a tunnel to addr with an HTTP CONNECT request
+func (p *Proxy) connect(ctx context.Context, addr string) (net.Conn, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", p.url.Host)
+	if err != nil {
+		return nil, fmt.Errorf("proxy %s: %w", p, err)
+	}
+	if p.url.Scheme == ProxySchemeHTTPS {
+		tlsConn := tls.Client(conn, &tls.Config{MinVersion: tls.VersionTLS12, ServerName: p.url.Hostname()})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("proxy %s: %w", p, err)
+		}
+		conn = tlsConn
+	}
+	// Closing the connection unblocks the exchange when ctx ends first
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: http.Header{},
+	}
+	if p.url.User != nil {
+		password, _ := p.url.User.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(p.url.User.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+	if err := req.Write(conn); err != nil {
+		_ = conn.Close()
+		return nil, proxyError(ctx, p, err)
+	}
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		_ = conn.Close()
+		return nil, proxyError(ctx, p, err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		_ = conn.Close()
+		return nil, fmt.Errorf("proxy %s refused the tunnel to %s: %s", p, addr, resp.Status)
+	}
+	if reader.Buffered() > 0 {
+		// The server spoke first; keep what the response reader already read
+		return &bufferedConn{Conn: conn, reader: reader}, nil
+	}
+	return conn, nil
+}
+
+// proxyError prefers the cancellation of ctx over the error of the closed connection
+func proxyError(ctx context.Context, p *Proxy, err error) error {
+	if ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	return fmt.Errorf("proxy %s: %w", p, err)
+}
+
+// bufferedConn is a tunnel whose first bytes were read along with the CONNECT response
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+// Read implements net.Conn
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
+
+// dialDNS opens the connection client exchanges with addr over, through
+// proxy when it is set. DNS-over-TLS is negotiated inside the tunnel.
+func dialDNS(ctx context.Context, client *dns.Client, addr string, proxy *Proxy) (*dns.Conn, error) {
+	if proxy == nil {
+		return client.DialContext(ctx, addr)
+	}
+	network := strings.TrimSuffix(client.Net, "-tls")
+	if network == "" {
+		network = "udp"
+	}
+	conn, err := proxy.DialContext(ctx, network, addr)
+	if err != nil {
+		return nil, err
+	}
+	if client.Net == "tcp-tls" {
+		tlsConn := tls.Client(conn, client.TLSConfig)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+	return &dns.Conn{Conn: conn}, nil
+}
+
+// proxyKey is the context key of the proxy exchanges are sent through
+type proxyKey struct{}
+
+// withProxy makes the exchanges under ctx reach their server through proxy;
+// a nil proxy leaves ctx unchanged
+func withProxy(ctx context.Context, proxy *Proxy) context.Context {
+	if proxy == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, proxyKey{}, proxy)
+}
+
+// proxyFrom returns the proxy set on ctx by withProxy, or nil
+func proxyFrom(ctx context.Context) *Proxy {
+	proxy, _ := ctx.Value(proxyKey{}).(*Proxy)
+	return proxy
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// FunctionRating: 82/100
+// - Complexity: LOW
+// - Integrations: 1 (dns library)
+// - External Risks: MEDIUM (network operations, DNS server availability)
+// - Unit Tests: NO
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: QueryTXT, QueryRRset, ZoneNameservers
+// Purpose: Reads the records published at a name directly from an authoritative server
+
+// queryMsg sends the query msg to server over UDP and repeats it over TCP
+// when the reply is truncated, as RFC 7766 requires of stub resolvers. With
+// tlsConfig set the query is sent over DNS-over-TLS instead. Servers reached
+// through a proxy are queried over TCP right away.
+func queryMsg(ctx context.Context, msg *dns.Msg, server string, timeout time.Duration,
+	tlsConfig *tls.Config) (*dns.Msg, error) {
+	if tlsConfig != nil {
+		return exchangeMsg(ctx, newTLSClient(server, tlsConfig, timeout), msg, server, nil)
+	}
+	if proxyFrom(ctx) != nil {
+		return exchangeMsg(ctx, &dns.Client{Net: "tcp", Timeout: timeout}, msg, server, nil)
+	}
+	reply, err := exchangeMsg(ctx, &dns.Client{Timeout: timeout}, msg, server, nil)
+	if err != nil || !reply.Truncated {
+		return reply, err
+	}
+	return exchangeMsg(ctx, &dns.Client{Net: "tcp", Timeout: timeout}, msg, server, nil)
+}
+
+// QueryTXT queries server for the TXT records at fqdn and returns their values.
+// A name that does not exist yields an empty slice and no error.
+func QueryTXT(ctx context.Context, server, fqdn string, timeout time.Duration) ([]string, error) {
+	return queryTXT(ctx, server, fqdn, timeout, nil)
+}
+
+// queryTXT is QueryTXT, over DNS-over-TLS when tlsConfig is set
+func queryTXT(ctx context.Context, server, fqdn string, timeout time.Duration, tlsConfig *tls.Config) ([]string, error) {
+	return lookupTXT(ctx, server, fqdn, timeout, tlsConfig, false)
+}
+
+// lookupTXT is queryTXT asking server to recurse when recursive is set, for
+// resolvers rather than authoritative servers
+func lookupTXT(ctx context.Context, server, fqdn string, timeout time.Duration, tlsConfig *tls.Config,
+	recursive bool) ([]string, error) {
+	msg := new(dns.Msg)
+	msg.SetQuestion(dns.Fqdn(fqdn), dns.TypeTXT)
+	msg.RecursionDesired = recursive
+
+	reply, err := queryMsg(ctx, msg, server, timeout, tlsConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query TXT for %s on %s: %w", fqdn, server, err)
+	}
+
+	if reply.Rcode == dns.RcodeNameError {
+		return []string{}, nil
+	}
+	if reply.Rcode != dns.RcodeSuccess {
+		return nil, fmt.Errorf("TXT query for %s on %s failed: %s (rcode: %d)",
+			fqdn, server, dns.RcodeToString[reply.Rcode], reply.Rcode)
+	}
+
+	values := []string{}
+	for _, rr := range reply.Answer {
+		if txt, ok := rr.(*dns.TXT); ok {
+			values = append(values, strings.Join(txt.Txt, ""))
+		}
+	}
+	return values, nil
+}
+
+// HasTXTValue reports whether server publishes value among the TXT records at fqdn
+func HasTXTValue(ctx context.Context, server, fqdn, value string, timeout time.Duration) (bool, error) {
+	return hasTXTValue(ctx, server, fqdn, value, timeout, nil)
+}
+
+// hasTXTValue is HasTXTValue, over DNS-over-TLS when tlsConfig is set
+func hasTXTValue(ctx context.Context, server, fqdn, value string, timeout time.Duration,
+	tlsConfig *tls.Config) (bool, error) {
+	values, err := queryTXT(ctx, server, fqdn, timeout, tlsConfig)
+	if err != nil {
+		return false, err
+	}
+	return slices.Contains(values, value), nil
+}
+
+// hasResolvedTXTValue is hasTXTValue asking a resolver, which follows CNAMEs
+// and answers from its cache
+func hasResolvedTXTValue(ctx context.Context, server, fqdn, value string, timeout time.Duration,
+	tlsConfig *tls.Config) (bool, error) {
+	values, err := lookupTXT(ctx, server, fqdn, timeout, tlsConfig, true)
+	if err != nil {
+		return false, err
+	}
+	return slices.Contains(values, value), nil
+}
+
+// QueryRRset queries server for the records of rrtype at name.
+// A name that does not exist yields an empty slice and no error.
+func QueryRRset(ctx context.Context, server, name string, rrtype uint16, timeout time.Duration) ([]dns.RR, error) {
+	return queryRRset(ctx, server, name, rrtype, timeout, nil)
+}
+
+// queryRRset is QueryRRset over DNS-over-TLS when tlsConfig is set
+func queryRRset(ctx context.Context, server, name string, rrtype uint16, timeout time.Duration,
+	tlsConfig *tls.Config) ([]dns.RR, error) {
+	msg := new(dns.Msg)
+	msg.SetQuestion(dns.Fqdn(name), rrtype)
+	msg.RecursionDesired = false
+
+	reply, err := queryMsg(ctx, msg, server, timeout, tlsConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s for %s on %s: %w", dns.TypeToString[rrtype], name, server, err)
+	}
+
+	if reply.Rcode == dns.RcodeNameError {
+		return []dns.RR{}, nil
+	}
+	if reply.Rcode != dns.RcodeSuccess {
+		return nil, fmt.Errorf("%s query for %s on %s failed: %s (rcode: %d)",
+			dns.TypeToString[rrtype], name, server, dns.RcodeToString[reply.Rcode], reply.Rcode)
+	}
+
+	records := []dns.RR{}
+	for _, rr := range reply.Answer {
+		if rr.Header().Rrtype == rrtype {
+			records = append(records, rr)
+		}
+	}
+	return records, nil
+}
+
+// ZoneNameservers returns the nameserver hostnames of the NS RRset at zone as
+// served by server, in the order they were returned
+func ZoneNameservers(ctx context.Context, server, zone string, timeout time.Duration) ([]string, error) {
+	records, err := QueryRRset(ctx, server, zone, dns.TypeNS, timeout)
+	if err != nil {
+		return nil, err
+	}
+	nameservers := make([]string, 0, len(records))
+	for _, rr := range records {
+		nameservers = append(nameservers, rr.(*dns.NS).Ns)
+	}
+	if len(nameservers) == 0 {
+		return nil, fmt.Errorf("%s serves no NS records for %s", server, zone)
+	}
+	return nameservers, nil
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/miekg/dns"
+)
+
+// FunctionRating: 84/100
+// - Complexity: LOW
+// - Integrations: 1 (dns library)
+// - External Risks: LOW (classification only)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: IsPermanent
+// Purpose: Tells configuration errors that no retry can fix apart from transient failures
+
+// PermanentRcodes are the reply codes caused by the server or key
+// configuration rather than the state of the server. They are never retried:
+// resending the same update gets the same answer until someone changes the
+// zone, the update-policy or the key.
+var PermanentRcodes = []int{dns.RcodeNotAuth, dns.RcodeRefused, dns.RcodeNotZone}
+
+// Permanent reports whether resending the message cannot succeed
+func (e *RcodeError) Permanent() bool {
+	return slices.Contains(PermanentRcodes, e.Rcode)
+}
+
+// diagnostic explains what a permanent rcode or a TSIG error usually
+// means, empty for others
+func (e *RcodeError) diagnostic() string {
+	switch e.TSIGError {
+	case dns.RcodeBadTime:
+		return "the clocks of the webhook and the server differ by more than the TSIG fudge; " +
+			"synchronize them with NTP or raise tsigFudge"
+	case dns.RcodeBadSig:
+		return "the TSIG secret or algorithm does not match the key on the server"
+	case dns.RcodeBadKey:
+		return "the server does not know the TSIG key name"
+	}
+	switch e.Rcode {
+	case dns.RcodeRefused:
+		return "the update-policy or allow-update of the zone does not grant the key this name"
+	case dns.RcodeNotAuth:
+		return "the server is not authoritative for the zone or rejected the key"
+	case dns.RcodeNotZone:
+		return "the name is outside the zone the update was sent to"
+	}
+	return ""
+}
+
+// IsPermanent reports whether err is a failure no retry can fix: a
+// permanent rcode, a refused permission check or a refused delete of
+// records not owned. Joined errors are permanent only when all of them
+// are, since one transient failure among them may still be worth retrying.
+func IsPermanent(err error) bool {
+	if err == ErrUpdateNotAuthorized || err == ErrNotOwned {
+		return true
+	}
+	switch e := err.(type) {
+	case nil:
+		return false
+	case *RcodeError:
+		return e.Permanent()
+	case interface{ Unwrap() []error }:
+		errs := e.Unwrap()
+		for _, err := range errs {
+			if !IsPermanent(err) {
+				return false
+			}
+		}
+		return len(errs) > 0
+	}
+	return IsPermanent(errors.Unwrap(err))
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+func TestIsPermanent(t *testing.T) {
+	refused := &RcodeError{Op: "update", Rcode: dns.RcodeRefused}
+	servfail := &RcodeError{Op: "update", Rcode: dns.RcodeServerFailure}
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"REFUSED", refused, true},
+		{"NOTAUTH", &RcodeError{Rcode: dns.RcodeNotAuth}, true},
+		{"NOTZONE", &RcodeError{Rcode: dns.RcodeNotZone}, true},
+		{"SERVFAIL", servfail, false},
+		{"transport error", errors.New("i/o timeout"), false},
+		{"wrapped", fmt.Errorf("server a: %w", refused), true},
+		{"refused permission check", fmt.Errorf("%w: a refused key k", ErrUpdateNotAuthorized), true},
+		{"all joined permanent", errors.Join(refused, &RcodeError{Rcode: dns.RcodeNotZone}), true},
+		{"one joined transient", fmt.Errorf("quorum: %w", errors.Join(refused, servfail)), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsPermanent(tt.err); got != tt.want {
+				t.Fatalf("IsPermanent(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRcodeErrorDiagnostic(t *testing.T) {
+	tests := []struct {
+		rcode int
+		want  string
+	}{
+		{dns.RcodeRefused, "DNS update failed: REFUSED (rcode: 5); the update-policy or allow-update of the zone " +
+			"does not grant the key this name"},
+		{dns.RcodeServerFailure, "DNS update failed: SERVFAIL (rcode: 2)"},
+	}
+	for _, tt := range tests {
+		if got := (&RcodeError{Op: "update", Rcode: tt.rcode}).Error(); got != tt.want {
+			t.Errorf("Error() = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/miekg/dns"
+	"go.uber.org/zap"
+)
+
+// FunctionRating: 78/100
+// - Complexity: MEDIUM
+// - Integrations: 2 (dns library, PowerDNS HTTP API)
+// - External Risks: MEDIUM (changes live records through either backend)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: RecordProvider
+// Purpose: Record changes and read-back common to the RFC2136 and PowerDNS backends of a single server
+
+// RecordProvider changes individual records in the zone of one server and
+// reads them back. The RFC2136 and PowerDNS clients implement it, so the
+// servers of one zone can be updated through different backends.
+type RecordProvider interface {
+	// AddRecords adds records, which may belong to several RRsets, all or
+	// none, keeping the other records of those RRsets
+	AddRecords(ctx context.Context, records []dns.RR) error
+	// DeleteRecords removes records, matched by name, type and data, all or
+	// none, keeping the other records of their RRsets
+	DeleteRecords(ctx context.Context, records []dns.RR) error
+	// Verify reports whether every record is published
+	Verify(ctx context.Context, records []dns.RR) (bool, error)
+}
+
+var (
+	_ RecordProvider = (*RFC2136Client)(nil)
+	_ RecordProvider = (*PowerDNSClient)(nil)
+	_ RecordProvider = (*DryRunProvider)(nil)
+)
+
+// containsAll reports whether live holds every record of records, ignoring TTLs
+func containsAll(live, records []dns.RR) bool {
+	for _, rr := range records {
+		if !slices.ContainsFunc(live, func(l dns.RR) bool { return dns.IsDuplicate(l, rr) }) {
+			return false
+		}
+	}
+	return true
+}
+
+// rdata returns the presentation form of the data of rr, as PowerDNS stores it
+func rdata(rr dns.RR) string {
+	return strings.TrimPrefix(rr.String(), rr.Header().String())
+}
+
+// Verify queries the server for the RRsets of records, over DNS-over-TLS when
+// that is the transport, and reports whether it publishes all of them
+func (c *RFC2136Client) Verify(ctx context.Context, records []dns.RR) (bool, error) {
+	for _, rrset := range groupRRsets(records) {
+		hdr := rrset[0].Header()
+		live, err := c.liveRRset(ctx, hdr.Name, hdr.Rrtype)
+		if err != nil {
+			return false, err
+		}
+		if !containsAll(live, rrset) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// AddRecords merges records into the RRsets published through the API and
+// writes all changed RRsets with a single PATCH
+func (c *PowerDNSClient) AddRecords(ctx context.Context, records []dns.RR) error {
+	c.logger.Info("Adding records via PowerDNS API",
+		zap.Int("records", len(records)),
+		zap.String("zone", c.zone),
+	)
+	return c.changeRecords(ctx, records, func(current []pdnsRecord, contents []string) []pdnsRecord {
+		for _, content := range contents {
+			if !slices.ContainsFunc(current, func(r pdnsRecord) bool { return r.Content == content }) {
+				current = append(current, pdnsRecord{Content: content})
+			}
+		}
+		return current
+	})
+}
+
+// DeleteRecords removes records from the RRsets published through the API
+// with a single PATCH; RRsets left empty are deleted
+func (c *PowerDNSClient) DeleteRecords(ctx context.Context, records []dns.RR) error {
+	c.logger.Info("Deleting records via PowerDNS API",
+		zap.Int("records", len(records)),
+		zap.String("zone", c.zone),
+	)
+	return c.changeRecords(ctx, records, func(current []pdnsRecord, contents []string) []pdnsRecord {
+		return slices.DeleteFunc(current, func(r pdnsRecord) bool { return slices.Contains(contents, r.Content) })
+	})
+}
+
+// changeRecords reads the RRset of every group of records, applies change to
+// its records and patches the RRsets that changed
+func (c *PowerDNSClient) changeRecords(ctx context.Context, records []dns.RR,
+	change func(current []pdnsRecord, contents []string) []pdnsRecord) error {
+	var rrsets []pdnsRRset
+	for _, set := range groupRRsets(records) {
+		hdr := set[0].Header()
+		name, rrtype := dns.Fqdn(hdr.Name), dns.TypeToString[hdr.Rrtype]
+		current, ttl, err := c.rrset(ctx, name, rrtype)
+		if err != nil {
+			return err
+		}
+		contents := make([]string, len(set))
+		for i, rr := range set {
+			contents[i] = rdata(rr)
+		}
+		updated := change(slices.Clone(current), contents)
+		if slices.Equal(updated, current) {
+			continue
+		}
+		if len(updated) == 0 {
+			rrsets = append(rrsets, pdnsRRset{Name: name, Type: rrtype, ChangeType: "DELETE", Records: []pdnsRecord{}})
+			continue
+		}
+		if len(current) == 0 || ttl == 0 {
+			ttl = int(hdr.Ttl)
+		}
+		rrsets = append(rrsets, pdnsRRset{Name: name, Type: rrtype, TTL: ttl, ChangeType: "REPLACE", Records: updated})
+	}
+	if len(rrsets) == 0 {
+		return nil
+	}
+	slices.SortFunc(rrsets, func(a, b pdnsRRset) int {
+		return strings.Compare(a.Name+" "+a.Type, b.Name+" "+b.Type)
+	})
+	if err := c.patch(ctx, rrsets...); err != nil {
+		return fmt.Errorf("failed to update %d RRsets: %w", len(rrsets), err)
+	}
+	return nil
+}
+
+// Verify reads the RRsets of records through the API and reports whether
+// all of them are published
+func (c *PowerDNSClient) Verify(ctx context.Context, records []dns.RR) (bool, error) {
+	for _, set := range groupRRsets(records) {
+		hdr := set[0].Header()
+		current, _, err := c.rrset(ctx, dns.Fqdn(hdr.Name), dns.TypeToString[hdr.Rrtype])
+		if err != nil {
+			return false, err
+		}
+		for _, rr := range set {
+			content := rdata(rr)
+			if !slices.ContainsFunc(current, func(r pdnsRecord) bool { return r.Content == content && !r.Disabled }) {
+				return false, nil
+			}
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/rieset/istio-dns01-bind9/internal/dnstest"
+)
+
+func TestRFC2136ClientVerify(t *testing.T) {
+	srv := startServer(t)
+	c := newTestClient(srv, dnstest.TestSecret)
+	ctx := context.Background()
+
+	records := mustRRs(t, testFQDN+` 60 IN TXT "token-1"`, testFQDN+` 60 IN TXT "token-2"`)
+	if ok, err := c.Verify(ctx, records); err != nil || ok {
+		t.Fatalf("Verify before add = %v, %v, want false", ok, err)
+	}
+	if err := c.AddRecords(ctx, records[:1]); err != nil {
+		t.Fatalf("AddRecords: %v", err)
+	}
+	if ok, err := c.Verify(ctx, records); err != nil || ok {
+		t.Fatalf("Verify with one of two records = %v, %v, want false", ok, err)
+	}
+	if err := c.AddRecords(ctx, records[1:]); err != nil {
+		t.Fatalf("AddRecords: %v", err)
+	}
+	// The TTL is not compared
+	if ok, err := c.Verify(ctx, mustRRs(t, testFQDN+` 300 IN TXT "token-2"`)); err != nil || !ok {
+		t.Fatalf("Verify after add = %v, %v, want true", ok, err)
+	}
+}
+
+func TestPowerDNSClientRecords(t *testing.T) {
+	fake, apiURL := startPowerDNS(t)
+	c := NewPowerDNSClient(apiURL, "localhost", "example.com", testAPIKey, zap.NewNop())
+	ctx := context.Background()
+
+	if err := c.AddTXTRecord(ctx, testFQDN, "existing", 60); err != nil {
+		t.Fatalf("AddTXTRecord: %v", err)
+	}
+	records := mustRRs(t,
+		testFQDN+` 60 IN TXT "token-1"`,
+		"app.example.com. 120 IN A 192.0.2.10",
+		"app.example.com. 120 IN A 192.0.2.11",
+	)
+	if err := c.AddRecords(ctx, records); err != nil {
+		t.Fatalf("AddRecords: %v", err)
+	}
+	if got, want := fake.txt(testFQDN), []string{`"existing"`, `"token-1"`}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("TXT after add = %v, want %v", got, want)
+	}
+	if a := fake.rrsets["app.example.com./A"]; len(a.Records) != 2 || a.TTL != 120 {
+		t.Fatalf("A RRset after add = %+v, want 2 records with TTL 120", a)
+	}
+	if ok, err := c.Verify(ctx, records); err != nil || !ok {
+		t.Fatalf("Verify after add = %v, %v, want true", ok, err)
+	}
+
+	if err := c.DeleteRecords(ctx, records); err != nil {
+		t.Fatalf("DeleteRecords: %v", err)
+	}
+	if got, want := fake.txt(testFQDN), []string{`"existing"`}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("TXT after delete = %v, want %v", got, want)
+	}
+	if _, ok := fake.rrsets["app.example.com./A"]; ok {
+		t.Fatal("empty A RRset left behind")
+	}
+	if ok, err := c.Verify(ctx, records); err != nil || ok {
+		t.Fatalf("Verify after delete = %v, %v, want false", ok, err)
+	}
+}
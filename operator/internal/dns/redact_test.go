@@ -0,0 +1,63 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+func TestRedactValue(t *testing.T) {
+	const key = "LoqXcYV8q5ONbJQxbmR7SCTNo3tiAXDfowyjxAjEuX0"
+	redacted := RedactValue(key)
+	if strings.Contains(redacted, key) || !strings.HasPrefix(redacted, "sha256:") || len(redacted) != len("sha256:")+12 {
+		t.Fatalf("RedactValue = %q, want a short digest", redacted)
+	}
+	if RedactValue(key) != redacted || RedactValue("other") == redacted {
+		t.Fatal("RedactValue does not tell values apart consistently")
+	}
+
+	SetLogChallengeValues(true)
+	defer SetLogChallengeValues(false)
+	if got := RedactValue(key); got != key {
+		t.Fatalf("RedactValue with full values = %q, want %q", got, key)
+	}
+}
+
+func TestRedactRecord(t *testing.T) {
+	const key = "LoqXcYV8q5ONbJQxbmR7SCTNo3tiAXDfowyjxAjEuX0"
+	txt := mustRRs(t, testFQDN+` 60 IN TXT "`+key+`"`)[0]
+	if got, want := RedactRecord(txt), testFQDN+"\t60\tIN\tTXT\t\""+RedactValue(key)+"\""; got != want {
+		t.Fatalf("RedactRecord(TXT) = %q, want %q", got, want)
+	}
+	// RRset deletes carry no data, other types nothing secret
+	rrset := &dns.TXT{Hdr: dns.RR_Header{Name: testFQDN, Rrtype: dns.TypeTXT, Class: dns.ClassANY}}
+	a := mustRRs(t, "www.example.com. 300 IN A 192.0.2.1")[0]
+	for _, rr := range []dns.RR{rrset, a} {
+		if got := RedactRecord(rr); got != rr.String() {
+			t.Errorf("RedactRecord = %q, want %q", got, rr.String())
+		}
+	}
+
+	SetLogChallengeValues(true)
+	defer SetLogChallengeValues(false)
+	if got := RedactRecord(txt); got != txt.String() {
+		t.Fatalf("RedactRecord with full values = %q, want %q", got, txt.String())
+	}
+}
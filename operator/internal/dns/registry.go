@@ -0,0 +1,241 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+// FunctionRating: 78/100
+// - Complexity: MEDIUM
+// - Integrations: 1 (dns library)
+// - External Risks: MEDIUM (decides which live records may be deleted)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: Ownership, checkOwned
+// Purpose: TXT ownership registry proving which records a client created before it deletes any
+
+const (
+	// RegistryPrefix is prepended to a name to name the TXT RRset holding
+	// the ownership entries of its records
+	RegistryPrefix = "_dns01-owner."
+	// registryWildcard replaces the leading "*" of a wildcard name in its
+	// registry name, since a wildcard label must come first
+	registryWildcard = "_wildcard"
+	// registryHeritage marks registry entries written by this project
+	registryHeritage = "istio-dns01-bind9"
+)
+
+// ErrNotOwned is wrapped by deletes refused because the registry holds no
+// entry of the client's owner for a live record
+var ErrNotOwned = errors.New("record not owned")
+
+// Ownership is recorded in the registry entry written next to every record
+// a client with SetOwnership creates. Deletes require an entry with the same
+// Owner for every live record they remove.
+type Ownership struct {
+	// Owner tells the records of this installation apart from those of
+	// others sharing the zone, like the txt-owner-id of external-dns
+	Owner string
+	// Resource references the object the records are written for, such as
+	// challenge/<namespace>; it is informational and not matched
+	Resource string
+}
+
+// ValidateOwner rejects owner IDs that cannot be told apart in registry
+// entries, which separate their fields with "," and "="
+func ValidateOwner(owner string) error {
+	if strings.ContainsAny(owner, ",= \t\"\\") {
+		return fmt.Errorf("registry owner ID %q must not contain commas, equal signs, quotes or whitespace", owner)
+	}
+	return nil
+}
+
+// RegistryName returns the name of the registry RRset of the records at name
+func RegistryName(name string) string {
+	name = dns.Fqdn(strings.ToLower(name))
+	if rest, ok := strings.CutPrefix(name, "*."); ok {
+		name = registryWildcard + "." + rest
+	}
+	return RegistryPrefix + name
+}
+
+// RegisteredName returns the name whose records the registry RRset at
+// registry is about, and false when registry is no registry name
+func RegisteredName(registry string) (string, bool) {
+	name, ok := strings.CutPrefix(dns.Fqdn(strings.ToLower(registry)), RegistryPrefix)
+	if !ok {
+		return "", false
+	}
+	if rest, ok := strings.CutPrefix(name, registryWildcard+"."); ok {
+		name = "*." + rest
+	}
+	return name, true
+}
+
+// Marker returns the registry value claiming a name for o as a whole, as
+// the hostname sync uses it
+func (o Ownership) Marker() string {
+	marker := "heritage=" + registryHeritage + ",owner=" + o.Owner
+	if o.Resource != "" {
+		marker += ",resource=" + o.Resource
+	}
+	return marker
+}
+
+// recordRef identifies rr in registry entries by type and a digest of its
+// data, which keeps entries short and challenge values out of the zone twice
+func recordRef(rr dns.RR) string {
+	sum := sha256.Sum256([]byte(rdata(rr)))
+	return dns.TypeToString[rr.Header().Rrtype] + "/" + hex.EncodeToString(sum[:8])
+}
+
+// entry returns the registry record claiming rr for o, with class and ttl
+func (o Ownership) entry(rr dns.RR, class uint16, ttl uint32) dns.RR {
+	return &dns.TXT{
+		Hdr: dns.RR_Header{Name: RegistryName(rr.Header().Name), Rrtype: dns.TypeTXT, Class: class, Ttl: ttl},
+		Txt: []string{o.Marker() + ",record=" + recordRef(rr)},
+	}
+}
+
+// entries returns the registry records to insert with records
+func (o Ownership) entries(records []dns.RR) []dns.RR {
+	out := make([]dns.RR, 0, len(records))
+	for _, rr := range records {
+		out = append(out, o.entry(rr, dns.ClassINET, rr.Header().Ttl))
+	}
+	return out
+}
+
+// removals returns the registry records to remove with records (RFC 2136
+// section 2.5.4); removing an entry that does not exist changes nothing
+func (o Ownership) removals(records []dns.RR) []dns.RR {
+	out := make([]dns.RR, 0, len(records))
+	for _, rr := range records {
+		out = append(out, o.entry(rr, dns.ClassNONE, 0))
+	}
+	return out
+}
+
+// owns reports whether registry holds an entry of o's owner for rr
+func (o Ownership) owns(registry []dns.RR, rr dns.RR) bool {
+	ref := recordRef(rr)
+	for _, entry := range registry {
+		txt, ok := entry.(*dns.TXT)
+		if !ok {
+			continue
+		}
+		fields := map[string]string{}
+		for _, field := range strings.Split(strings.Join(txt.Txt, ""), ",") {
+			if key, value, ok := strings.Cut(field, "="); ok {
+				fields[key] = value
+			}
+		}
+		if fields["heritage"] == registryHeritage && fields["owner"] == o.Owner && fields["record"] == ref {
+			return true
+		}
+	}
+	return false
+}
+
+// SetOwnership makes the client write a registry entry of ownership with
+// every record it adds, remove it with the record, and refuse to delete live
+// records without one. The zero Ownership disables the registry. Servers
+// must let the key update the TXT records at RegistryName of each name.
+func (c *RFC2136Client) SetOwnership(ownership Ownership) {
+	c.ownership = ownership
+}
+
+// liveRRset queries the server for the RRset of rrtype at name, over
+// DNS-over-TLS when that is the transport
+func (c *RFC2136Client) liveRRset(ctx context.Context, name string, rrtype uint16) ([]dns.RR, error) {
+	var tlsConfig *tls.Config
+	if c.transport == TransportTLS {
+		tlsConfig = c.tlsClient.TLSConfig
+	}
+	return queryRRset(withProxy(ctx, c.proxy), c.server, name, rrtype, c.timeout, tlsConfig)
+}
+
+// checkOwned returns an error wrapping ErrNotOwned unless every record of
+// records the server publishes has a registry entry of the client's owner.
+// Records already gone need no proof. Without ownership nothing is checked.
+func (c *RFC2136Client) checkOwned(ctx context.Context, records []dns.RR) error {
+	if c.ownership.Owner == "" {
+		return nil
+	}
+	for _, rrset := range groupRRsets(records) {
+		hdr := rrset[0].Header()
+		live, err := c.liveRRset(ctx, hdr.Name, hdr.Rrtype)
+		if err != nil {
+			return err
+		}
+		// Compared by data alone, as deletes carry class NONE
+		present := slices.DeleteFunc(slices.Clone(rrset), func(rr dns.RR) bool {
+			return !slices.ContainsFunc(live, func(l dns.RR) bool { return rdata(l) == rdata(rr) })
+		})
+		if err := c.checkRegistry(ctx, present); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkRegistry returns an error wrapping ErrNotOwned unless the registry
+// holds an entry of the client's owner for each of records, which share a name
+func (c *RFC2136Client) checkRegistry(ctx context.Context, records []dns.RR) error {
+	if len(records) == 0 {
+		return nil
+	}
+	hdr := records[0].Header()
+	registry, err := c.liveRRset(ctx, RegistryName(hdr.Name), dns.TypeTXT)
+	if err != nil {
+		return err
+	}
+	for _, rr := range records {
+		if !c.ownership.owns(registry, rr) {
+			return fmt.Errorf("%s %s on %s has no registry entry of owner %s: %w",
+				dns.Fqdn(hdr.Name), dns.TypeToString[rr.Header().Rrtype], c.server, c.ownership.Owner, ErrNotOwned)
+		}
+	}
+	return nil
+}
+
+// deleteOwnedRRset removes the live records of rrtype at name and their
+// registry entries once all of them are proven owned. Records are removed
+// one by one rather than as an RRset, so any added after the check survive.
+func (c *RFC2136Client) deleteOwnedRRset(ctx context.Context, name string, rrtype uint16) error {
+	live, err := c.liveRRset(ctx, name, rrtype)
+	if err != nil {
+		return err
+	}
+	if err := c.checkRegistry(ctx, live); err != nil {
+		return err
+	}
+	return c.deleteRecords(ctx, live)
+}
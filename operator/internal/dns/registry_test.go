@@ -0,0 +1,135 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/miekg/dns"
+
+	"github.com/rieset/istio-dns01-bind9/internal/dnstest"
+)
+
+func TestRegistryName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"www.example.com", "_dns01-owner.www.example.com."},
+		{"_acme-challenge.Example.com.", "_dns01-owner._acme-challenge.example.com."},
+		{"*.apps.example.com.", "_dns01-owner._wildcard.apps.example.com."},
+	}
+	for _, tt := range tests {
+		got := RegistryName(tt.name)
+		if got != tt.want {
+			t.Errorf("RegistryName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+		if name, ok := RegisteredName(got); !ok || name != dns.Fqdn(strings.ToLower(tt.name)) {
+			t.Errorf("RegisteredName(%q) = %q, %v, want %q", got, name, ok, tt.name)
+		}
+	}
+}
+
+func TestOwnershipRegistry(t *testing.T) {
+	srv := startServer(t)
+	c := newTestClient(srv, dnstest.TestSecret)
+	c.SetOwnership(Ownership{Owner: "cluster-a", Resource: "challenge/default"})
+	ctx := context.Background()
+
+	if err := c.AddTXTRecord(ctx, testFQDN, "token-1", 60); err != nil {
+		t.Fatalf("AddTXTRecord: %v", err)
+	}
+	entries := srv.TXT(RegistryName(testFQDN))
+	if len(entries) != 1 || !strings.HasPrefix(entries[0], "heritage=istio-dns01-bind9,owner=cluster-a,resource=challenge/default,record=TXT/") {
+		t.Fatalf("registry = %v, want one entry of cluster-a", entries)
+	}
+
+	// A value of someone else at the same name must survive every delete
+	srv.SetTXT(testFQDN, 60, "token-1", "foreign")
+	if err := c.DeleteTXTRecordValue(ctx, testFQDN, "foreign"); !errors.Is(err, ErrNotOwned) || !IsPermanent(err) {
+		t.Fatalf("DeleteTXTRecordValue of a foreign value = %v, want permanent ErrNotOwned", err)
+	}
+	if err := c.DeleteTXTRecord(ctx, testFQDN); !errors.Is(err, ErrNotOwned) {
+		t.Fatalf("DeleteTXTRecord over a foreign value = %v, want ErrNotOwned", err)
+	}
+	other := newTestClient(srv, dnstest.TestSecret)
+	other.SetOwnership(Ownership{Owner: "cluster-b"})
+	if err := other.DeleteTXTRecordValue(ctx, testFQDN, "token-1"); !errors.Is(err, ErrNotOwned) {
+		t.Fatalf("DeleteTXTRecordValue by another owner = %v, want ErrNotOwned", err)
+	}
+	if got, want := srv.TXT(testFQDN), []string{"foreign", "token-1"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("TXT after refused deletes = %v, want %v", got, want)
+	}
+
+	if err := c.DeleteTXTRecordValue(ctx, testFQDN, "token-1"); err != nil {
+		t.Fatalf("DeleteTXTRecordValue: %v", err)
+	}
+	if got, want := srv.TXT(testFQDN), []string{"foreign"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("TXT after delete = %v, want %v", got, want)
+	}
+	if got := srv.TXT(RegistryName(testFQDN)); len(got) != 0 {
+		t.Fatalf("registry after delete = %v, want none", got)
+	}
+	// Deleting a value that is already gone needs no proof
+	if err := c.DeleteTXTRecordValue(ctx, testFQDN, "token-1"); err != nil {
+		t.Fatalf("DeleteTXTRecordValue of a missing value: %v", err)
+	}
+}
+
+func TestOwnershipRegistryRRsets(t *testing.T) {
+	srv := startServer(t)
+	c := newTestClient(srv, dnstest.TestSecret)
+	c.SetOwnership(Ownership{Owner: "cluster-a"})
+	ctx := context.Background()
+	const name = "www.example.com."
+
+	records := mustRRs(t, "www.example.com. 300 IN A 192.0.2.1", "api.example.com. 300 IN A 192.0.2.2")
+	if err := c.AddRecords(ctx, records); err != nil {
+		t.Fatalf("AddRecords: %v", err)
+	}
+	if err := c.ReplaceRRset(ctx, name, dns.TypeA, mustRRs(t, "www.example.com. 300 IN A 192.0.2.3")); err != nil {
+		t.Fatalf("ReplaceRRset: %v", err)
+	}
+	if got := srv.TXT(RegistryName(name)); len(got) != 1 {
+		t.Fatalf("registry after replace = %v, want the entry of the new record only", got)
+	}
+	if err := c.DeleteRRset(ctx, name, dns.TypeA); err != nil {
+		t.Fatalf("DeleteRRset: %v", err)
+	}
+	if got := srv.Records(name, dns.TypeA); len(got) != 0 {
+		t.Fatalf("A records after delete = %v, want none", got)
+	}
+	if got := srv.TXT(RegistryName(name)); len(got) != 0 {
+		t.Fatalf("registry after delete = %v, want none", got)
+	}
+
+	// Without its registry entry a record can no longer be deleted or replaced
+	srv.SetTXT(RegistryName("api.example.com."), 300)
+	if err := c.DeleteRecords(ctx, mustRRs(t, "api.example.com. 300 IN A 192.0.2.2")); !errors.Is(err, ErrNotOwned) {
+		t.Fatalf("DeleteRecords without registry entry = %v, want ErrNotOwned", err)
+	}
+	if err := c.ReplaceRRset(ctx, "api.example.com.", dns.TypeA, mustRRs(t, "api.example.com. 300 IN A 192.0.2.9")); !errors.Is(err, ErrNotOwned) {
+		t.Fatalf("ReplaceRRset without registry entry = %v, want ErrNotOwned", err)
+	}
+	if got, want := srv.Records("api.example.com.", dns.TypeA), []string{"192.0.2.2"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("A records after refused changes = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// FunctionRating: 84/100
+// - Complexity: LOW
+// - Integrations: 1 (system resolver)
+// - External Risks: LOW (falls back to the unresolved name)
+// - Unit Tests: NO
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: ResolveHost, PrewarmHosts
+// Purpose: Caches server hostname resolution so the first update after startup skips DNS lookups
+
+// hostCacheTTL is how long a resolved server address is reused
+const hostCacheTTL = 5 * time.Minute
+
+type hostEntry struct {
+	ip      string
+	expires time.Time
+}
+
+var hostCache = struct {
+	sync.RWMutex
+	entries map[string]hostEntry
+}{entries: make(map[string]hostEntry)}
+
+// ResolveHost returns an IP address for host, using the process-wide cache.
+// IP literals are returned unchanged.
+func ResolveHost(ctx context.Context, host string) (string, error) {
+	if net.ParseIP(host) != nil {
+		return host, nil
+	}
+
+	hostCache.RLock()
+	entry, ok := hostCache.entries[host]
+	hostCache.RUnlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.ip, nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupHost(ctx, host)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve DNS server %s: %w", host, err)
+	}
+	if len(addrs) == 0 {
+		return "", fmt.Errorf("DNS server %s has no addresses", host)
+	}
+
+	hostCache.Lock()
+	hostCache.entries[host] = hostEntry{ip: addrs[0], expires: time.Now().Add(hostCacheTTL)}
+	hostCache.Unlock()
+	return addrs[0], nil
+}
+
+// PrewarmHosts resolves every host concurrently, ignoring any port, and returns the failures by host
+func PrewarmHosts(ctx context.Context, hosts []string) map[string]error {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	failures := map[string]error{}
+
+	for _, host := range uniqueServers(hosts) {
+		wg.Add(1)
+		go func(h string) {
+			defer wg.Done()
+			host, _ := splitServer(h)
+			if _, err := ResolveHost(ctx, host); err != nil {
+				mu.Lock()
+				failures[h] = err
+				mu.Unlock()
+			}
+		}(host)
+	}
+	wg.Wait()
+	return failures
+}
+
+// serverAddr returns the dial address of server, preferring a cached resolution.
+// Servers given with a port keep it; DefaultPort is used otherwise. Names
+// of servers reached through a proxy are left to the proxy to resolve.
+func serverAddr(ctx context.Context, server string) string {
+	host, port := splitServer(server)
+	if proxyFrom(ctx) != nil {
+		return net.JoinHostPort(host, port)
+	}
+	if ip, err := ResolveHost(ctx, host); err == nil {
+		return net.JoinHostPort(ip, port)
+	}
+	return net.JoinHostPort(host, port)
+}
+
+// splitServer separates an optional port from server, defaulting to
+// DefaultPort. Addresses ParseServerAddress rejects are kept whole.
+func splitServer(server string) (string, string) {
+	host, port, err := ParseServerAddress(server)
+	if err != nil {
+		return server, DefaultPort
+	}
+	if port == "" {
+		port = DefaultPort
+	}
+	return host, port
+}
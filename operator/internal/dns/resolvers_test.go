@@ -0,0 +1,108 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+func TestSystemResolvers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "resolv.conf")
+	conf := "search default.svc.cluster.local svc.cluster.local\nnameserver 10.96.0.10\nnameserver fd00::10\noptions ndots:5\n"
+	if err := os.WriteFile(path, []byte(conf), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	got, err := SystemResolvers(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"10.96.0.10:53", "[fd00::10]:53"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("SystemResolvers = %v, want %v", got, want)
+	}
+
+	if err := os.WriteFile(path, []byte("search example.com\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SystemResolvers(path); err == nil {
+		t.Fatal("SystemResolvers succeeded without a nameserver")
+	}
+	if _, err := SystemResolvers(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Fatal("SystemResolvers succeeded without a file")
+	}
+}
+
+// startResolver serves value at testFQDN to queries that ask for recursion
+// and refuses the others, like a resolver that is not authoritative
+func startResolver(t *testing.T, value string) string {
+	t.Helper()
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &dns.Server{PacketConn: pc, Handler: dns.HandlerFunc(func(w dns.ResponseWriter, req *dns.Msg) {
+		reply := new(dns.Msg)
+		reply.SetReply(req)
+		if !req.RecursionDesired {
+			reply.Rcode = dns.RcodeRefused
+		} else {
+			reply.RecursionAvailable = true
+			reply.Answer = append(reply.Answer, &dns.TXT{
+				Hdr: dns.RR_Header{Name: dns.Fqdn(testFQDN), Rrtype: dns.TypeTXT, Class: dns.ClassINET, Ttl: 60},
+				Txt: []string{value},
+			})
+		}
+		_ = w.WriteMsg(reply)
+	})}
+	go func() { _ = srv.ActivateAndServe() }()
+	t.Cleanup(func() { _ = srv.Shutdown() })
+	return pc.LocalAddr().String()
+}
+
+func TestWaitForPropagationRecursive(t *testing.T) {
+	resolver := startResolver(t, "token")
+	check := PropagationCheck{
+		Servers:      []string{resolver},
+		FQDN:         testFQDN,
+		Value:        "token",
+		Present:      true,
+		Interval:     50 * time.Millisecond,
+		QueryTimeout: 200 * time.Millisecond,
+	}
+
+	// Without recursion the resolver refuses and the check stays pending
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	if result, err := WaitForPropagation(ctx, check); err == nil || len(result.Pending) != 1 {
+		t.Fatalf("WaitForPropagation without recursion = %+v, %v, want pending", result, err)
+	}
+
+	check.Recursive = true
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	result, err := WaitForPropagation(ctx, check)
+	if err != nil || !reflect.DeepEqual(result.Matched, []string{resolver}) {
+		t.Fatalf("WaitForPropagation = %+v, %v, want the resolver matched", result, err)
+	}
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"github.com/miekg/dns"
+	"go.uber.org/zap"
+)
+
+// FunctionRating: 80/100
+// - Complexity: MEDIUM
+// - Integrations: 1 (dns library)
+// - External Risks: MEDIUM (retries delay updates by up to MaxDelay per attempt)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: RetryPolicy
+// Purpose: Exponential backoff with jitter for updates failing on transient errors
+
+// RetryPolicy controls how often RFC2136Client resends an update that failed
+// with a transport error or a retryable rcode. The zero value sends once.
+type RetryPolicy struct {
+	// Attempts is the total number of sends, including the first
+	Attempts int
+	// BaseDelay is the backoff before the first retry; it doubles on every retry
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff between two attempts
+	MaxDelay time.Duration
+	// Rcodes are the reply codes worth retrying; empty means DefaultRetryRcodes.
+	// PermanentRcodes are ignored here.
+	Rcodes []int
+}
+
+// DefaultRetryRcodes are retried when a RetryPolicy does not list rcodes:
+// SERVFAIL is what BIND answers while a zone is being loaded or transferred
+var DefaultRetryRcodes = []int{dns.RcodeServerFailure}
+
+// retryable reports whether a reply with rcode is worth sending again.
+// PermanentRcodes never are, even when listed in Rcodes.
+func (p RetryPolicy) retryable(rcode int) bool {
+	if slices.Contains(PermanentRcodes, rcode) {
+		return false
+	}
+	if len(p.Rcodes) == 0 {
+		return slices.Contains(DefaultRetryRcodes, rcode)
+	}
+	return slices.Contains(p.Rcodes, rcode)
+}
+
+// backoff returns the delay before retry number n (starting at 1): the
+// exponential delay capped at MaxDelay, of which the upper half is jittered
+func (p RetryPolicy) backoff(n int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < n && (p.MaxDelay <= 0 || delay < p.MaxDelay); i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	half := delay / 2
+	return half + rand.N(delay-half+1)
+}
+
+// SetRetryPolicy sets how failed updates are retried; the default sends once
+func (c *RFC2136Client) SetRetryPolicy(policy RetryPolicy) {
+	c.retry = policy
+}
+
+// sendWithRetry sends msg, resending it under the retry policy while the
+// exchange fails or the reply carries a retryable rcode. The last reply or
+// error is returned.
+func (c *RFC2136Client) sendWithRetry(ctx context.Context, msg *dns.Msg, fqdn string) (*dns.Msg, error) {
+	attempts := max(c.retry.Attempts, 1)
+	for attempt := 1; ; attempt++ {
+		next := msg
+		if attempt < attempts {
+			// Keep msg intact for the next attempt, signing strips the TSIG RR
+			next = msg.Copy()
+		}
+		if tsig := next.IsTsig(); tsig != nil && attempt > 1 {
+			tsig.TimeSigned = uint64(c.tsigTime())
+		}
+
+		reply, err := c.sendKeys(ctx, next)
+		if attempt >= attempts || ctx.Err() != nil || (err == nil && !c.retry.retryable(reply.Rcode)) {
+			return reply, err
+		}
+
+		delay := c.retry.backoff(attempt)
+		reason := "transport error"
+		if err == nil {
+			reason = dns.RcodeToString[reply.Rcode]
+		}
+		c.logger.Warn("Retrying DNS update",
+			zap.String("fqdn", fqdn),
+			zap.String("server", c.server),
+			zap.String("reason", reason),
+			zap.Int("attempt", attempt+1),
+			zap.Duration("delay", delay),
+			zap.Error(err),
+		)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return reply, err
+		}
+	}
+}
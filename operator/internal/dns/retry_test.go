@@ -0,0 +1,103 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"github.com/rieset/istio-dns01-bind9/internal/dnstest"
+)
+
+// failFirst answers the first n exchanges with rcode
+type failFirst struct {
+	n     int32
+	rcode int
+	seen  atomic.Int32
+}
+
+func (f *failFirst) Inject(string, *dns.Msg) Fault {
+	if f.seen.Add(1) <= f.n {
+		return Fault{Rcode: f.rcode}
+	}
+	return Fault{}
+}
+
+func TestRFC2136ClientRetry(t *testing.T) {
+	policy := RetryPolicy{Attempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+	tests := []struct {
+		name     string
+		policy   RetryPolicy
+		failures int32
+		rcode    int
+		wantErr  string
+		wantSent int32
+	}{
+		{"recovers from transient SERVFAIL", policy, 2, dns.RcodeServerFailure, "", 3},
+		{"gives up after the last attempt", policy, 3, dns.RcodeServerFailure, "SERVFAIL", 3},
+		{"sends once without a policy", RetryPolicy{}, 1, dns.RcodeServerFailure, "SERVFAIL", 1},
+		{"does not retry REFUSED", policy, 1, dns.RcodeRefused, "REFUSED", 1},
+		{"retries listed rcodes", RetryPolicy{Attempts: 2, Rcodes: []int{dns.RcodeYXDomain}}, 1, dns.RcodeYXDomain, "", 2},
+		{"never retries permanent rcodes", RetryPolicy{Attempts: 2, Rcodes: []int{dns.RcodeNotAuth}}, 1, dns.RcodeNotAuth,
+			"not authoritative", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := startServer(t)
+			c := newTestClient(srv, dnstest.TestSecret)
+			c.SetRetryPolicy(tt.policy)
+			injector := &failFirst{n: tt.failures, rcode: tt.rcode}
+			SetFaultInjector(injector)
+			defer SetFaultInjector(nil)
+
+			err := c.AddTXTRecord(context.Background(), testFQDN, "token", 60)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("AddTXTRecord: %v", err)
+				}
+				if got, want := srv.TXT(testFQDN), []string{"token"}; !reflect.DeepEqual(got, want) {
+					t.Fatalf("TXT = %v, want %v", got, want)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("AddTXTRecord error = %v, want %s", err, tt.wantErr)
+			}
+			if got := injector.seen.Load(); got != tt.wantSent {
+				t.Fatalf("sent %d times, want %d", got, tt.wantSent)
+			}
+		})
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	for n, want := range map[int]time.Duration{1: 100 * time.Millisecond, 3: 400 * time.Millisecond, 10: time.Second} {
+		for range 20 {
+			if got := policy.backoff(n); got < want/2 || got > want {
+				t.Fatalf("backoff(%d) = %s, want between %s and %s", n, got, want/2, want)
+			}
+		}
+	}
+	if got := (RetryPolicy{}).backoff(1); got != 0 {
+		t.Fatalf("backoff without delays = %s, want 0", got)
+	}
+}
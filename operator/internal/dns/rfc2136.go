@@ -18,10 +18,14 @@ package dns
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	"github.com/miekg/dns"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
@@ -29,154 +33,395 @@ import (
 // - Complexity: MEDIUM
 // - Integrations: 2 (dns library, logging)
 // - External Risks: MEDIUM (network operations, DNS server availability)
-// - Unit Tests: NO
+// - Unit Tests: YES
 // - E2E Tests: NO
 // - Typing: FULL
 // - Critical Issues: NONE
 //
 // Function: RFC2136Client
-// Purpose: Client for RFC2136 dynamic DNS updates using TSIG authentication
+// Purpose: Client for RFC2136 dynamic DNS updates using TSIG or SIG(0) authentication
 
 // RFC2136Client handles DNS updates via RFC2136 protocol
 type RFC2136Client struct {
-	server   string
-	zone     string
-	tsigKey  string
-	tsigAlg  string
-	tsigSec  string
-	logger   *zap.Logger
-	timeout  time.Duration
+	server  string
+	zone    string
+	tsigKey string
+	tsigAlg string
+	tsigSec string
+	logger  *zap.Logger
+	timeout time.Duration
+	// timeouts splits exchanges into stages when set, see SetTimeouts
+	timeouts Timeouts
+	client   *dns.Client
+	// tcpClient is used for TransportTCP and for retries in TransportAuto
+	tcpClient *dns.Client
+	// tlsClient is used for TransportTLS, see SetTLSConfig
+	tlsClient *dns.Client
+	transport Transport
+	quirks    Quirks
+	retry     RetryPolicy
+	// sig0 signs messages instead of TSIG when set, see SetSIG0Signer
+	sig0 *SIG0Signer
+	// zonePrereq is the prerequisite RR used when quirks.ZonePrerequisite is set
+	zonePrereq dns.RR
+	// conns keeps TCP and TLS connections for reuse, see SetConnPool
+	conns *ConnPool
+	// limiter paces the updates sent to the server, see SetUpdateLimiter
+	limiter *UpdateLimiter
+	// prereqs guards TXT updates, see SetPrerequisites
+	prereqs Prerequisites
+	// dryRun receives the messages instead of the server when set, see SetDryRun
+	dryRun *DryRunRecorder
+	// proxy tunnels every exchange with the server when set, see SetProxy
+	proxy *Proxy
+	// clockOffset is how many seconds the server's clock is ahead of the
+	// local one, learned from a BADTIME reply, see sendTSIG
+	clockOffset atomic.Int64
+	// ownership is written to the registry with every added record and
+	// required of deleted ones, see SetOwnership
+	ownership Ownership
+	// tsigKeys holds the key of the client followed by its fallbacks, see
+	// SetTSIGFallbacks; activeKey is the position of the key signing messages
+	tsigKeys  []TSIGCredential
+	activeKey atomic.Int32
+	// keyCache shares the accepted keys with other clients, see SetTSIGKeyCache
+	keyCache *TSIGKeyCache
+}
+
+// RcodeError is returned when a server answers a message with a non-success rcode
+type RcodeError struct {
+	Op    string
+	Rcode int
+	// TSIGError is the TSIG error of a NOTAUTH reply, such as
+	// dns.RcodeBadTime; zero when the server accepted the signature
+	TSIGError int
+}
+
+// Error implements error
+func (e *RcodeError) Error() string {
+	msg := fmt.Sprintf("DNS %s failed: %s (rcode: %d)", e.Op, dns.RcodeToString[e.Rcode], e.Rcode)
+	if e.TSIGError != 0 {
+		msg += ", TSIG error " + dns.RcodeToString[e.TSIGError]
+	}
+	if hint := e.diagnostic(); hint != "" {
+		msg += "; " + hint
+	}
+	return msg
 }
 
 // NewRFC2136Client creates a new RFC2136 client
 func NewRFC2136Client(server, zone, tsigKey, tsigAlg, tsigSec string, logger *zap.Logger) *RFC2136Client {
-	return &RFC2136Client{
-		server:  server,
-		zone:    zone,
-		tsigKey: tsigKey,
-		tsigAlg: tsigAlg,
-		tsigSec: tsigSec,
-		logger:  logger,
-		timeout: 10 * time.Second,
+	c := &RFC2136Client{
+		server:    server,
+		zone:      dns.Fqdn(zone),
+		tsigKey:   dns.Fqdn(tsigKey),
+		tsigAlg:   TSIGAlgorithm(tsigAlg),
+		tsigSec:   tsigSec,
+		logger:    logger,
+		timeout:   DefaultTimeout,
+		quirks:    DefaultQuirks,
+		transport: TransportAuto,
+	}
+	c.zonePrereq = &dns.ANY{Hdr: dns.RR_Header{Name: c.zone, Rrtype: dns.TypeSOA, Class: dns.ClassANY}}
+	c.client = &dns.Client{
+		Timeout:    c.timeout,
+		TsigSecret: map[string]string{c.tsigKey: c.tsigSec},
 	}
+	c.tcpClient = &dns.Client{
+		Net:        "tcp",
+		Timeout:    c.timeout,
+		TsigSecret: c.client.TsigSecret,
+	}
+	c.SetTLSConfig(nil)
+	return c
+}
+
+// SetConnPool makes the client reuse the TCP and TLS connections of pool;
+// without a pool every exchange dials the server
+func (c *RFC2136Client) SetConnPool(pool *ConnPool) {
+	c.conns = pool
+}
+
+// SetUpdateLimiter makes the client wait for limiter before every UPDATE it
+// sends; queries are not limited
+func (c *RFC2136Client) SetUpdateLimiter(limiter *UpdateLimiter) {
+	c.limiter = limiter
+}
+
+// SetProxy makes the client reach the server through proxy, for servers in
+// another cluster or network. UDP cannot be tunneled, so TransportAuto and
+// TransportUDP are sent over TCP instead.
+func (c *RFC2136Client) SetProxy(proxy *Proxy) {
+	c.proxy = proxy
+}
+
+// SetQuirks changes the compatibility behaviours used against the server
+func (c *RFC2136Client) SetQuirks(quirks Quirks) {
+	c.quirks = quirks
+}
+
+// SetTransport changes how messages reach the server; the default is TransportAuto
+func (c *RFC2136Client) SetTransport(transport Transport) {
+	c.transport = transport
+}
+
+// SetTLSConfig sets the TLS settings used with TransportTLS. Without a
+// ServerName the host of the configured server is verified. A nil config
+// verifies against the system roots.
+func (c *RFC2136Client) SetTLSConfig(config *tls.Config) {
+	c.tlsClient = newTLSClient(c.server, config, c.timeout)
+	c.tlsClient.TsigSecret = c.client.TsigSecret
+	c.timeouts.apply(c.tlsClient)
 }
 
 // AddTXTRecord adds a TXT record to the DNS zone
 func (c *RFC2136Client) AddTXTRecord(ctx context.Context, fqdn, value string, ttl int) error {
 	c.logger.Info("Adding TXT record",
 		zap.String("fqdn", fqdn),
-		zap.String("value", value),
+		ChallengeValue(value),
 		zap.String("server", c.server),
 		zap.String("zone", c.zone),
 	)
 
-	// Create DNS message
-	msg := new(dns.Msg)
-	msg.SetUpdate(dns.Fqdn(c.zone))
+	msg, rr := c.buildAddMsg(fqdn, value, ttl)
+	defer releaseMsg(msg, rr)
 
-	// Create TXT record
-	rr := new(dns.TXT)
-	rr.Hdr = dns.RR_Header{
-		Name:   dns.Fqdn(fqdn),
-		Rrtype: dns.TypeTXT,
-		Class:  dns.ClassINET,
-		Ttl:    uint32(ttl),
+	if err := c.exchange(ctx, msg, fqdn, "update"); err != nil {
+		return c.resolveNameInUse(ctx, err, fqdn, rr)
 	}
-	rr.Txt = []string{value}
-
-	// Add record to message
-	msg.Insert([]dns.RR{rr})
 
-	// Add TSIG signature
-	msg.SetTsig(c.tsigKey, c.tsigAlg, 300, time.Now().Unix())
+	c.logger.Info("TXT record added successfully",
+		zap.String("fqdn", fqdn),
+		zap.String("server", c.server),
+	)
+	return nil
+}
 
-	// Send update
-	client := new(dns.Client)
-	client.Timeout = c.timeout
-	client.TsigSecret = map[string]string{c.tsigKey: c.tsigSec}
+// DeleteTXTRecord deletes a TXT record from the DNS zone
+func (c *RFC2136Client) DeleteTXTRecord(ctx context.Context, fqdn string) error {
+	c.logger.Info("Deleting TXT record",
+		zap.String("fqdn", fqdn),
+		zap.String("server", c.server),
+		zap.String("zone", c.zone),
+	)
 
-	reply, _, err := client.ExchangeContext(ctx, msg, c.server+":53")
-	if err != nil {
-		c.logger.Error("Failed to send DNS update",
-			zap.String("fqdn", fqdn),
-			zap.String("server", c.server),
-			zap.Error(err),
-		)
-		return fmt.Errorf("failed to send DNS update to %s: %w", c.server, err)
-	}
+	if c.ownership.Owner != "" {
+		if err := c.deleteOwnedRRset(ctx, fqdn, dns.TypeTXT); err != nil {
+			return err
+		}
+	} else {
+		msg, rr := c.buildDeleteMsg(fqdn)
+		defer releaseMsg(msg, rr)
 
-	if reply.Rcode != dns.RcodeSuccess {
-		c.logger.Error("DNS update failed",
-			zap.String("fqdn", fqdn),
-			zap.String("server", c.server),
-			zap.Int("rcode", reply.Rcode),
-			zap.String("rcode_name", dns.RcodeToString[reply.Rcode]),
-		)
-		return fmt.Errorf("DNS update failed: %s (rcode: %d)", dns.RcodeToString[reply.Rcode], reply.Rcode)
+		if err := c.exchange(ctx, msg, fqdn, "delete"); err != nil {
+			return err
+		}
 	}
 
-	c.logger.Info("TXT record added successfully",
+	c.logger.Info("TXT record deleted successfully",
 		zap.String("fqdn", fqdn),
 		zap.String("server", c.server),
 	)
 	return nil
 }
 
-// DeleteTXTRecord deletes a TXT record from the DNS zone
-func (c *RFC2136Client) DeleteTXTRecord(ctx context.Context, fqdn string) error {
-	c.logger.Info("Deleting TXT record",
+// DeleteTXTRecordValue removes the TXT record holding value at fqdn, keeping
+// the other values of the RRset such as those of concurrent challenges
+func (c *RFC2136Client) DeleteTXTRecordValue(ctx context.Context, fqdn, value string) error {
+	c.logger.Info("Deleting TXT record value",
 		zap.String("fqdn", fqdn),
+		ChallengeValue(value),
 		zap.String("server", c.server),
 		zap.String("zone", c.zone),
 	)
 
-	// Create DNS message
-	msg := new(dns.Msg)
-	msg.SetUpdate(dns.Fqdn(c.zone))
+	msg, rr := c.buildDeleteValueMsg(fqdn, value)
+	defer releaseMsg(msg, rr)
 
-	// Create RR to delete
-	rr := new(dns.TXT)
-	rr.Hdr = dns.RR_Header{
-		Name:   dns.Fqdn(fqdn),
-		Rrtype: dns.TypeTXT,
-		Class:  dns.ClassINET,
+	if err := c.checkOwned(ctx, []dns.RR{rr}); err != nil {
+		return err
+	}
+	if err := c.exchange(ctx, msg, fqdn, "delete"); err != nil {
+		return err
 	}
 
-	// Remove record
-	msg.RemoveRRset([]dns.RR{rr})
+	c.logger.Info("TXT record value deleted successfully",
+		zap.String("fqdn", fqdn),
+		zap.String("server", c.server),
+	)
+	return nil
+}
 
-	// Add TSIG signature
-	msg.SetTsig(c.tsigKey, c.tsigAlg, 300, time.Now().Unix())
+// buildAddMsg builds an UPDATE inserting a TXT record.
+// The message and record come from pools and must be released with releaseMsg.
+func (c *RFC2136Client) buildAddMsg(fqdn, value string, ttl int) (*dns.Msg, *dns.TXT) {
+	msg := acquireUpdateMsg(c.zone)
+	rr := acquireTXT(fqdn, dns.ClassINET, uint32(ttl))
+	rr.Txt = append(rr.Txt, value)
+
+	c.addInsertPrerequisites(msg, rr)
+	msg.Ns = append(msg.Ns, rr)
+	if c.ownership.Owner != "" {
+		msg.Ns = append(msg.Ns, c.ownership.entry(rr, dns.ClassINET, rr.Hdr.Ttl))
+	}
+	c.finishMsg(msg)
+	return msg, rr
+}
+
+// buildDeleteMsg builds an UPDATE removing the TXT RRset at fqdn.
+// The message and record come from pools and must be released with releaseMsg.
+func (c *RFC2136Client) buildDeleteMsg(fqdn string) (*dns.Msg, *dns.TXT) {
+	msg := acquireUpdateMsg(c.zone)
+	// RemoveRRset semantics (RFC 2136 section 2.5.2): class ANY, TTL 0, no rdata
+	rr := acquireTXT(fqdn, dns.ClassANY, 0)
+
+	c.addDeletePrerequisites(msg, rr)
+	msg.Ns = append(msg.Ns, rr)
+	c.finishMsg(msg)
+	return msg, rr
+}
 
-	// Send update
-	client := new(dns.Client)
-	client.Timeout = c.timeout
-	client.TsigSecret = map[string]string{c.tsigKey: c.tsigSec}
+// buildDeleteValueMsg builds an UPDATE removing the TXT record with value at fqdn.
+// The message and record come from pools and must be released with releaseMsg.
+func (c *RFC2136Client) buildDeleteValueMsg(fqdn, value string) (*dns.Msg, *dns.TXT) {
+	msg := acquireUpdateMsg(c.zone)
+	// Remove semantics (RFC 2136 section 2.5.4): class NONE, TTL 0, the rdata to delete
+	rr := acquireTXT(fqdn, dns.ClassNONE, 0)
+	rr.Txt = append(rr.Txt, value)
 
-	reply, _, err := client.ExchangeContext(ctx, msg, c.server+":53")
+	c.addDeletePrerequisites(msg, rr)
+	msg.Ns = append(msg.Ns, rr)
+	if c.ownership.Owner != "" {
+		msg.Ns = append(msg.Ns, c.ownership.entry(rr, dns.ClassNONE, 0))
+	}
+	c.finishMsg(msg)
+	return msg, rr
+}
+
+// finishMsg adds the prerequisites and signature the server's quirks call for
+func (c *RFC2136Client) finishMsg(msg *dns.Msg) {
+	if c.quirks.ZonePrerequisite {
+		msg.Answer = append(msg.Answer, c.zonePrereq)
+	}
+	// SIG(0) signatures are added by send, once the message is final
+	if c.quirks.SignUpdates && c.sig0 == nil {
+		keyName, algorithm := c.signingKey()
+		msg.SetTsig(keyName, algorithm, c.quirks.TSIGFudge, c.tsigTime())
+	}
+}
+
+// send exchanges msg over the configured transport. With TransportAuto a
+// truncated UDP reply or a failed UDP exchange is retried over TCP; updates
+// are idempotent, so resending one the server already applied is harmless.
+func (c *RFC2136Client) send(ctx context.Context, msg *dns.Msg) (*dns.Msg, error) {
+	if c.sig0 != nil && c.quirks.SignUpdates {
+		signed, err := c.sig0.Sign(msg)
+		if err != nil {
+			return nil, err
+		}
+		msg = signed
+	}
+	if c.dryRun != nil {
+		return c.dryRun.record(c.server, msg), nil
+	}
+	if msg.Opcode == dns.OpcodeUpdate {
+		release, err := c.limiter.acquire(ctx, c.server)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+	}
+
+	ctx = withClockOffset(ctx, c.clockOffset.Load())
+	if c.proxy != nil {
+		ctx = withProxy(ctx, c.proxy)
+		if c.transport != TransportTLS {
+			return exchangeMsg(ctx, c.tcpClient, msg, c.server, c.conns)
+		}
+	}
+	switch c.transport {
+	case TransportTCP:
+		return exchangeMsg(ctx, c.tcpClient, msg, c.server, c.conns)
+	case TransportTLS:
+		return exchangeMsg(ctx, c.tlsClient, msg, c.server, c.conns)
+	}
+
+	// Signing strips the TSIG RR from the message, so keep msg intact for the retry
+	reply, err := exchangeMsg(ctx, c.client, msg.Copy(), c.server, c.conns)
+	if err == nil && !reply.Truncated {
+		return reply, nil
+	}
+	if c.transport == TransportUDP || ctx.Err() != nil {
+		if err == nil {
+			err = ErrTruncated
+		}
+		return nil, err
+	}
+
+	reason := "truncated"
+	if err != nil {
+		reason = err.Error()
+	}
+	c.logger.Debug("Retrying DNS message over TCP",
+		zap.String("server", c.server),
+		zap.String("reason", reason),
+	)
+	return exchangeMsg(ctx, c.tcpClient, msg, c.server, c.conns)
+}
+
+// exchange sends msg, retrying as the retry policy allows, and converts
+// transport errors and non-success rcodes into errors
+func (c *RFC2136Client) exchange(ctx context.Context, msg *dns.Msg, fqdn, op string) (err error) {
+	ctx, span := tracer.Start(ctx, "dns."+op, trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("dns.server", c.server),
+			attribute.String("dns.zone", c.zone),
+			attribute.String("dns.name", fqdn),
+		))
+	start := time.Now()
+	defer func() {
+		span.SetAttributes(attribute.Int64("dns.duration_ms", time.Since(start).Milliseconds()))
+		EndSpan(span, err)
+	}()
+
+	reply, err := c.sendWithRetry(ctx, msg, fqdn)
 	if err != nil {
-		c.logger.Error("Failed to send DNS delete",
+		c.logger.Error("Failed to send DNS "+op,
 			zap.String("fqdn", fqdn),
 			zap.String("server", c.server),
 			zap.Error(err),
 		)
-		return fmt.Errorf("failed to send DNS delete to %s: %w", c.server, err)
+		return fmt.Errorf("failed to send DNS %s to %s: %w", op, c.server, err)
+	}
+	span.SetAttributes(attribute.String("dns.rcode", dns.RcodeToString[reply.Rcode]))
+
+	// A delete whose RRset is missing, by the server's answer or by the
+	// RRsetExists prerequisite, has nothing left to do
+	if op == "delete" && (c.quirks.IdempotentDelete &&
+		(reply.Rcode == dns.RcodeNameError || reply.Rcode == dns.RcodeNXRrset) ||
+		c.prereqs.RRsetExists && reply.Rcode == dns.RcodeNXRrset) {
+		c.logger.Debug("Record already absent",
+			zap.String("fqdn", fqdn),
+			zap.String("server", c.server),
+			zap.String("rcode_name", dns.RcodeToString[reply.Rcode]),
+		)
+		return nil
 	}
 
 	if reply.Rcode != dns.RcodeSuccess {
-		c.logger.Error("DNS delete failed",
+		// Unmet prerequisites are decided by the caller
+		logf := c.logger.Error
+		if c.prereqs != (Prerequisites{}) && prerequisiteFailed(reply.Rcode) {
+			logf = c.logger.Debug
+		}
+		logf("DNS "+op+" failed",
 			zap.String("fqdn", fqdn),
 			zap.String("server", c.server),
 			zap.Int("rcode", reply.Rcode),
 			zap.String("rcode_name", dns.RcodeToString[reply.Rcode]),
+			zap.Int("tsig_error", tsigError(reply)),
 		)
-		return fmt.Errorf("DNS delete failed: %s (rcode: %d)", dns.RcodeToString[reply.Rcode], reply.Rcode)
+		return &RcodeError{Op: op, Rcode: reply.Rcode, TSIGError: tsigError(reply)}
 	}
-
-	c.logger.Info("TXT record deleted successfully",
-		zap.String("fqdn", fqdn),
-		zap.String("server", c.server),
-	)
 	return nil
 }
-
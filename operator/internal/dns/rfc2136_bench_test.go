@@ -0,0 +1,82 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"go.uber.org/zap"
+)
+
+const (
+	benchFQDN   = "_acme-challenge.app.example.com"
+	benchValue  = "LoqXcYV8q5ONbJQxbmR7SCTNo3tiAXDfowyjxAjEuX0"
+	benchSecret = "M730/BdKcT4VHgaISqojsqd/hdy1mdyPA8BQ824ASzo="
+)
+
+func newBenchClient() *RFC2136Client {
+	return NewRFC2136Client("192.0.2.1", "example.com", "acme-example-com", "hmac-sha256", benchSecret, zap.NewNop())
+}
+
+// BenchmarkBuildAddMsgUnpooled measures the original per-call construction path
+func BenchmarkBuildAddMsgUnpooled(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		msg := new(dns.Msg)
+		msg.SetUpdate(dns.Fqdn("example.com"))
+		rr := new(dns.TXT)
+		rr.Hdr = dns.RR_Header{Name: dns.Fqdn(benchFQDN), Rrtype: dns.TypeTXT, Class: dns.ClassINET, Ttl: 60}
+		rr.Txt = []string{benchValue}
+		msg.Insert([]dns.RR{rr})
+		msg.SetTsig("acme-example-com.", dns.HmacSHA256, 300, time.Now().Unix())
+	}
+}
+
+// BenchmarkBuildAddMsg measures pooled message construction
+func BenchmarkBuildAddMsg(b *testing.B) {
+	c := newBenchClient()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		msg, rr := c.buildAddMsg(benchFQDN, benchValue, 60)
+		releaseMsg(msg, rr)
+	}
+}
+
+// BenchmarkBuildAndSignAddMsg measures pooled construction plus TSIG signing and packing
+func BenchmarkBuildAndSignAddMsg(b *testing.B) {
+	c := newBenchClient()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		msg, rr := c.buildAddMsg(benchFQDN, benchValue, 60)
+		if _, _, err := dns.TsigGenerate(msg, benchSecret, "", false); err != nil {
+			b.Fatal(err)
+		}
+		releaseMsg(msg, rr)
+	}
+}
+
+// BenchmarkBuildDeleteMsg measures pooled construction of an RRset deletion
+func BenchmarkBuildDeleteMsg(b *testing.B) {
+	c := newBenchClient()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		msg, rr := c.buildDeleteMsg(benchFQDN)
+		releaseMsg(msg, rr)
+	}
+}
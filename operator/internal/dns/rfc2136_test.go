@@ -0,0 +1,274 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"go.uber.org/zap"
+
+	"github.com/rieset/istio-dns01-bind9/internal/dnstest"
+)
+
+const testFQDN = "_acme-challenge.app.example.com."
+
+func startServer(t *testing.T) *dnstest.Server {
+	t.Helper()
+	return dnstest.StartServers(t, 1)[0]
+}
+
+func newTestClient(srv *dnstest.Server, secret string) *RFC2136Client {
+	c := NewRFC2136Client(srv.Addr(), "example.com", dnstest.TestKeyName, "hmac-sha256", secret, zap.NewNop())
+	c.timeout = 2 * time.Second
+	return c
+}
+
+func TestRFC2136ClientAddAndDelete(t *testing.T) {
+	srv := startServer(t)
+	c := newTestClient(srv, dnstest.TestSecret)
+	ctx := context.Background()
+
+	if err := c.AddTXTRecord(ctx, testFQDN, "token-1", 60); err != nil {
+		t.Fatalf("AddTXTRecord: %v", err)
+	}
+	if err := c.AddTXTRecord(ctx, testFQDN, "token-2", 60); err != nil {
+		t.Fatalf("AddTXTRecord: %v", err)
+	}
+	if got, want := srv.TXT(testFQDN), []string{"token-1", "token-2"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("TXT after add = %v, want %v", got, want)
+	}
+
+	values, err := QueryTXT(ctx, srv.Addr(), testFQDN, time.Second)
+	if err != nil {
+		t.Fatalf("QueryTXT: %v", err)
+	}
+	if len(values) != 2 {
+		t.Fatalf("QueryTXT returned %v, want 2 values", values)
+	}
+
+	if err := c.DeleteTXTRecordValue(ctx, testFQDN, "token-1"); err != nil {
+		t.Fatalf("DeleteTXTRecordValue: %v", err)
+	}
+	if got, want := srv.TXT(testFQDN), []string{"token-2"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("TXT after value delete = %v, want %v", got, want)
+	}
+
+	if err := c.DeleteTXTRecord(ctx, testFQDN); err != nil {
+		t.Fatalf("DeleteTXTRecord: %v", err)
+	}
+	if got := srv.TXT(testFQDN); len(got) != 0 {
+		t.Fatalf("TXT after delete = %v, want none", got)
+	}
+}
+
+func TestRFC2136ClientRejectsBadSecret(t *testing.T) {
+	srv := startServer(t)
+	c := newTestClient(srv, "d3Jvbmctc2VjcmV0LXdyb25nLXNlY3JldC13cm9uZyE=")
+
+	err := c.AddTXTRecord(context.Background(), testFQDN, "token", 60)
+	if err == nil {
+		t.Fatal("AddTXTRecord succeeded with a wrong TSIG secret")
+	}
+	if got := srv.TXT(testFQDN); len(got) != 0 {
+		t.Fatalf("record was applied despite TSIG failure: %v", got)
+	}
+}
+
+func TestRFC2136ClientReportsRcode(t *testing.T) {
+	srv := startServer(t)
+	srv.SetUpdateRcode(dns.RcodeRefused)
+	c := newTestClient(srv, dnstest.TestSecret)
+
+	err := c.AddTXTRecord(context.Background(), testFQDN, "token", 60)
+	if err == nil || !strings.Contains(err.Error(), "REFUSED") {
+		t.Fatalf("AddTXTRecord error = %v, want REFUSED", err)
+	}
+}
+
+func TestRFC2136ClientHonoursContext(t *testing.T) {
+	srv := startServer(t)
+	srv.SetLatency(500 * time.Millisecond)
+	c := newTestClient(srv, dnstest.TestSecret)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := c.AddTXTRecord(ctx, testFQDN, "token", 60); err == nil {
+		t.Fatal("AddTXTRecord succeeded past its deadline")
+	}
+}
+
+func TestRFC2136ClientHonoursCancellation(t *testing.T) {
+	srv := startServer(t)
+	srv.SetLatency(500 * time.Millisecond)
+	c := newTestClient(srv, dnstest.TestSecret)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start := time.Now()
+	err := c.AddTXTRecord(ctx, testFQDN, "token", 60)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("AddTXTRecord error = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 400*time.Millisecond {
+		t.Fatalf("AddTXTRecord returned %v after cancellation", elapsed)
+	}
+}
+
+func TestRFC2136ClientTransports(t *testing.T) {
+	srv := startServer(t)
+	srv.SetTruncateUDP(true)
+	ctx := context.Background()
+
+	udp := newTestClient(srv, dnstest.TestSecret)
+	udp.SetTransport(TransportUDP)
+	if err := udp.AddTXTRecord(ctx, testFQDN, "token", 60); !errors.Is(err, ErrTruncated) {
+		t.Fatalf("AddTXTRecord over UDP = %v, want ErrTruncated", err)
+	}
+
+	// The default transport retries the truncated exchange over TCP
+	auto := newTestClient(srv, dnstest.TestSecret)
+	if err := auto.AddTXTRecord(ctx, testFQDN, "token", 60); err != nil {
+		t.Fatalf("AddTXTRecord with TCP fallback: %v", err)
+	}
+	if got := srv.TXT(testFQDN); len(got) != 1 {
+		t.Fatalf("TXT after fallback = %v", got)
+	}
+
+	tcp := newTestClient(srv, dnstest.TestSecret)
+	tcp.SetTransport(TransportTCP)
+	if err := tcp.DeleteTXTRecord(ctx, testFQDN); err != nil {
+		t.Fatalf("DeleteTXTRecord over TCP: %v", err)
+	}
+	if got := srv.TXT(testFQDN); len(got) != 0 {
+		t.Fatalf("TXT after delete over TCP = %v", got)
+	}
+}
+
+func TestRFC2136ClientTLS(t *testing.T) {
+	srv := startServer(t)
+	if err := srv.StartTLS(); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	c := NewRFC2136Client(srv.TLSAddr(), "example.com", dnstest.TestKeyName, "hmac-sha256", dnstest.TestSecret, zap.NewNop())
+	c.SetTransport(TransportTLS)
+
+	// The throwaway certificate is not trusted by the system roots
+	if err := c.AddTXTRecord(ctx, testFQDN, "token", 60); err == nil {
+		t.Fatal("AddTXTRecord trusted an unknown certificate authority")
+	}
+
+	config, err := TLSClientConfig(map[string][]byte{"ca.crt": srv.TLSCertificatePEM()}, dnstest.TLSServerName)
+	if err != nil {
+		t.Fatalf("TLSClientConfig: %v", err)
+	}
+	c.SetTLSConfig(config)
+	if err := c.AddTXTRecord(ctx, testFQDN, "token", 60); err != nil {
+		t.Fatalf("AddTXTRecord over TLS: %v", err)
+	}
+	if got := srv.TXT(testFQDN); len(got) != 1 {
+		t.Fatalf("TXT after update over TLS = %v", got)
+	}
+	result, err := WaitForPropagation(ctx, PropagationCheck{
+		Servers:      []string{srv.TLSAddr()},
+		FQDN:         testFQDN,
+		Value:        "token",
+		Present:      true,
+		QueryTimeout: time.Second,
+		TLS:          map[string]*tls.Config{srv.TLSAddr(): config},
+	})
+	if err != nil || len(result.Matched) != 1 {
+		t.Fatalf("WaitForPropagation over TLS = %+v, %v", result, err)
+	}
+	records, err := c.TransferZone(ctx)
+	if err != nil || len(records) < 2 {
+		t.Fatalf("TransferZone over TLS = %v, %v", records, err)
+	}
+
+	config.ServerName = "other.example.test"
+	c.SetTLSConfig(config)
+	if err := c.DeleteTXTRecord(ctx, testFQDN); err == nil {
+		t.Fatal("DeleteTXTRecord accepted a certificate for another name")
+	}
+}
+
+func TestTLSClientConfig(t *testing.T) {
+	if _, err := TLSClientConfig(map[string][]byte{"ca.crt": []byte("garbage")}, ""); err == nil {
+		t.Error("TLSClientConfig accepted a CA bundle without certificates")
+	}
+	if _, err := TLSClientConfig(map[string][]byte{"tls.crt": []byte("garbage")}, ""); err == nil {
+		t.Error("TLSClientConfig accepted a certificate without a key")
+	}
+	config, err := TLSClientConfig(nil, "ns1.example.com")
+	if err != nil || config.RootCAs != nil || config.ServerName != "ns1.example.com" {
+		t.Errorf("TLSClientConfig(nil) = %+v, %v", config, err)
+	}
+}
+
+func TestParseTransport(t *testing.T) {
+	for name, want := range map[string]Transport{"": TransportAuto, "UDP": TransportUDP, "tcp": TransportTCP, "TLS": TransportTLS, "auto": TransportAuto} {
+		if got, err := ParseTransport(name); err != nil || got != want {
+			t.Errorf("ParseTransport(%q) = %q, %v; want %q", name, got, err, want)
+		}
+	}
+	if _, err := ParseTransport("https"); err == nil {
+		t.Error("ParseTransport accepted an unknown transport")
+	}
+}
+
+func TestWaitForPropagation(t *testing.T) {
+	primary, secondary := startServer(t), startServer(t)
+	primary.SetTXT(testFQDN, 60, "token")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	result, err := WaitForPropagation(ctx, PropagationCheck{
+		Servers:      []string{primary.Addr(), secondary.Addr()},
+		FQDN:         testFQDN,
+		Value:        "token",
+		Present:      true,
+		MinMatches:   1,
+		Interval:     10 * time.Millisecond,
+		QueryTimeout: time.Second,
+	})
+	if err != nil {
+		t.Fatalf("WaitForPropagation: %v", err)
+	}
+	if !reflect.DeepEqual(result.Matched, []string{primary.Addr()}) {
+		t.Fatalf("Matched = %v, want only the primary", result.Matched)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := WaitForPropagation(ctx, PropagationCheck{
+		Servers:      []string{primary.Addr(), secondary.Addr()},
+		FQDN:         testFQDN,
+		Value:        "token",
+		Present:      true,
+		Interval:     10 * time.Millisecond,
+		QueryTimeout: time.Second,
+	}); err == nil {
+		t.Fatal("WaitForPropagation succeeded although the secondary never published the value")
+	}
+}
@@ -0,0 +1,375 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+	"go.uber.org/zap"
+)
+
+// FunctionRating: 72/100
+// - Complexity: MEDIUM
+// - Integrations: 2 (AWS Route53 API, logging)
+// - External Risks: MEDIUM (public cloud API, credential handling)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: Route53Client
+// Purpose: Publishes challenge TXT records in a Route53 hosted zone through the signed REST API
+
+const (
+	// DefaultRoute53Endpoint is the global Route53 API endpoint
+	DefaultRoute53Endpoint = "https://route53.amazonaws.com"
+	// route53Region is the signing region of the global endpoint
+	route53Region = "us-east-1"
+	// route53Namespace is the XML namespace of the 2013-04-01 API
+	route53Namespace = "https://route53.amazonaws.com/doc/2013-04-01/"
+)
+
+// AWSCredentials are static credentials used to sign AWS requests
+type AWSCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// Route53Client updates one hosted zone through the Route53 API
+type Route53Client struct {
+	endpoint     string
+	hostedZoneID string
+	credentials  AWSCredentials
+	logger       *zap.Logger
+	http         *http.Client
+	now          func() time.Time
+}
+
+// route53RRset is a ResourceRecordSet of the Route53 API
+type route53RRset struct {
+	Name    string          `xml:"Name"`
+	Type    string          `xml:"Type"`
+	TTL     int             `xml:"TTL"`
+	Records []route53Record `xml:"ResourceRecords>ResourceRecord"`
+}
+
+// route53Record is one ResourceRecord of a route53RRset
+type route53Record struct {
+	Value string `xml:"Value"`
+}
+
+// route53Change is one Change of a ChangeBatch
+type route53Change struct {
+	Action string       `xml:"Action"`
+	RRset  route53RRset `xml:"ResourceRecordSet"`
+}
+
+// NewRoute53Client creates a client for the hosted zone hostedZoneID. An empty
+// endpoint uses DefaultRoute53Endpoint.
+func NewRoute53Client(endpoint, hostedZoneID string, credentials AWSCredentials, logger *zap.Logger) *Route53Client {
+	if endpoint == "" {
+		endpoint = DefaultRoute53Endpoint
+	}
+	return &Route53Client{
+		endpoint:     strings.TrimSuffix(endpoint, "/"),
+		hostedZoneID: strings.TrimPrefix(hostedZoneID, "/hostedzone/"),
+		credentials:  credentials,
+		logger:       logger,
+		http:         &http.Client{Timeout: 10 * time.Second},
+		now:          time.Now,
+	}
+}
+
+// AddTXTRecord adds value to the TXT RRset at fqdn, keeping other values
+func (c *Route53Client) AddTXTRecord(ctx context.Context, fqdn, value string, ttl int) error {
+	fqdn = dns.Fqdn(fqdn)
+	c.logger.Info("Adding TXT record via Route53",
+		zap.String("fqdn", fqdn),
+		ChallengeValue(value),
+		zap.String("hosted_zone", c.hostedZoneID),
+	)
+
+	current, err := c.txtRRset(ctx, fqdn)
+	if err != nil {
+		return err
+	}
+	quoted := strconv.Quote(value)
+	rrset := route53RRset{Name: fqdn, Type: "TXT", TTL: ttl, Records: []route53Record{{Value: quoted}}}
+	if current != nil {
+		for _, record := range current.Records {
+			if record.Value != quoted {
+				rrset.Records = append(rrset.Records, record)
+			}
+		}
+	}
+
+	if err := c.change(ctx, route53Change{Action: "UPSERT", RRset: rrset}); err != nil {
+		return err
+	}
+	c.logger.Info("TXT record added successfully via Route53", zap.String("fqdn", fqdn))
+	return nil
+}
+
+// DeleteTXTRecord removes the TXT RRset at fqdn. Route53 only deletes an
+// RRset matching it exactly, so the current one is read first.
+func (c *Route53Client) DeleteTXTRecord(ctx context.Context, fqdn string) error {
+	fqdn = dns.Fqdn(fqdn)
+	c.logger.Info("Deleting TXT record via Route53",
+		zap.String("fqdn", fqdn),
+		zap.String("hosted_zone", c.hostedZoneID),
+	)
+
+	current, err := c.txtRRset(ctx, fqdn)
+	if err != nil {
+		return err
+	}
+	if current == nil {
+		c.logger.Debug("TXT record already absent in Route53", zap.String("fqdn", fqdn))
+		return nil
+	}
+
+	if err := c.change(ctx, route53Change{Action: "DELETE", RRset: *current}); err != nil {
+		return err
+	}
+	c.logger.Info("TXT record deleted successfully via Route53", zap.String("fqdn", fqdn))
+	return nil
+}
+
+// DeleteTXTRecordValue removes value from the TXT RRset at fqdn. The RRset
+// is rewritten without it, or deleted when value was its only record.
+func (c *Route53Client) DeleteTXTRecordValue(ctx context.Context, fqdn, value string) error {
+	fqdn = dns.Fqdn(fqdn)
+	c.logger.Info("Deleting TXT record value via Route53",
+		zap.String("fqdn", fqdn),
+		ChallengeValue(value),
+		zap.String("hosted_zone", c.hostedZoneID),
+	)
+
+	current, err := c.txtRRset(ctx, fqdn)
+	if err != nil {
+		return err
+	}
+	quoted := strconv.Quote(value)
+	var kept []route53Record
+	if current != nil {
+		for _, record := range current.Records {
+			if record.Value != quoted {
+				kept = append(kept, record)
+			}
+		}
+	}
+	if current == nil || len(kept) == len(current.Records) {
+		c.logger.Debug("TXT record value already absent in Route53", zap.String("fqdn", fqdn))
+		return nil
+	}
+
+	change := route53Change{Action: "DELETE", RRset: *current}
+	if len(kept) > 0 {
+		rrset := *current
+		rrset.Records = kept
+		change = route53Change{Action: "UPSERT", RRset: rrset}
+	}
+	if err := c.change(ctx, change); err != nil {
+		return err
+	}
+	c.logger.Info("TXT record value deleted successfully via Route53", zap.String("fqdn", fqdn))
+	return nil
+}
+
+// zonePath returns the API path of the hosted zone's record sets
+func (c *Route53Client) zonePath() string {
+	return "/2013-04-01/hostedzone/" + url.PathEscape(c.hostedZoneID) + "/rrset"
+}
+
+// txtRRset returns the TXT RRset at fqdn, or nil when there is none
+func (c *Route53Client) txtRRset(ctx context.Context, fqdn string) (*route53RRset, error) {
+	query := url.Values{"name": {fqdn}, "type": {"TXT"}, "maxitems": {"1"}}
+	var resp struct {
+		RRsets []route53RRset `xml:"ResourceRecordSets>ResourceRecordSet"`
+	}
+	if err := c.do(ctx, http.MethodGet, c.zonePath()+"?"+query.Encode(), nil, &resp); err != nil {
+		return nil, err
+	}
+
+	// The listing starts at name and type but continues with the next RRset when absent
+	for _, rrset := range resp.RRsets {
+		if rrset.Type == "TXT" && strings.EqualFold(dns.Fqdn(rrset.Name), fqdn) {
+			return &rrset, nil
+		}
+	}
+	return nil, nil
+}
+
+// change submits a single-change ChangeBatch
+func (c *Route53Client) change(ctx context.Context, change route53Change) error {
+	body := struct {
+		XMLName xml.Name        `xml:"ChangeResourceRecordSetsRequest"`
+		Xmlns   string          `xml:"xmlns,attr"`
+		Changes []route53Change `xml:"ChangeBatch>Changes>Change"`
+	}{Xmlns: route53Namespace, Changes: []route53Change{change}}
+
+	payload, err := xml.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode Route53 change: %w", err)
+	}
+	return c.do(ctx, http.MethodPost, c.zonePath()+"/", append([]byte(xml.Header), payload...), nil)
+}
+
+// do sends a signed request and decodes an XML response into out when set
+func (c *Route53Client) do(ctx context.Context, method, pathAndQuery string, body []byte, out any) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.endpoint+pathAndQuery, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build Route53 request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/xml")
+	}
+	signV4(req, body, c.credentials, route53Region, "route53", c.now())
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("route53 %s %s failed: %w", method, c.hostedZoneID, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+	if err != nil {
+		return fmt.Errorf("failed to read Route53 response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var apiErr struct {
+			Code    string `xml:"Error>Code"`
+			Message string `xml:"Error>Message"`
+		}
+		if xml.Unmarshal(data, &apiErr) != nil || apiErr.Code == "" {
+			apiErr.Message = strings.TrimSpace(string(data))
+		}
+		return fmt.Errorf("route53 %s %s returned %d %s: %s", method, c.hostedZoneID, resp.StatusCode, apiErr.Code, apiErr.Message)
+	}
+	if out != nil {
+		if err := xml.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("failed to decode Route53 response: %w", err)
+		}
+	}
+	return nil
+}
+
+// signV4 signs req with AWS Signature Version 4, covering the host and every header set on req
+func signV4(req *http.Request, payload []byte, creds AWSCredentials, region, service string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		trimmed := make([]string, len(values))
+		for i, v := range values {
+			trimmed[i] = strings.Join(strings.Fields(v), " ")
+		}
+		headers[strings.ToLower(name)] = strings.Join(trimmed, ",")
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	payloadHash := sha256.Sum256(payload)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), date)
+	for _, part := range []string{region, service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		creds.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// canonicalQuery encodes query sorted by key and value with AWS URI encoding
+func canonicalQuery(query url.Values) string {
+	var pairs []string
+	for key, values := range query {
+		for _, value := range values {
+			pairs = append(pairs, awsEscape(key)+"="+awsEscape(value))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// awsEscape percent-encodes everything except unreserved characters (RFC 3986)
+func awsEscape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if ch >= 'A' && ch <= 'Z' || ch >= 'a' && ch <= 'z' || ch >= '0' && ch <= '9' ||
+			ch == '-' || ch == '_' || ch == '.' || ch == '~' {
+			b.WriteByte(ch)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", ch)
+	}
+	return b.String()
+}
+
+// hmacSHA256 returns HMAC-SHA256 of data under key
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
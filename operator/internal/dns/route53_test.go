@@ -0,0 +1,187 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/rieset/istio-dns01-bind9/internal/dnstest"
+)
+
+func TestSignV4(t *testing.T) {
+	// Example request from the AWS Signature Version 4 documentation
+	req, err := http.NewRequest(http.MethodGet, "https://iam.amazonaws.com/?Action=ListUsers&Version=2010-05-08", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	creds := AWSCredentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	signV4(req, nil, creds, "us-east-1", "iam", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/iam/aws4_request, " +
+		"SignedHeaders=content-type;host;x-amz-date, " +
+		"Signature=5d672d79c15b13162d9279b0855cfba6789a8edb4c82c400e06b5924a6f2b5d7"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Fatalf("Authorization = %s\nwant %s", got, want)
+	}
+}
+
+// fakeRoute53 serves the record set endpoints of one hosted zone
+type fakeRoute53 struct {
+	mu     sync.Mutex
+	rrsets map[string]route53RRset
+}
+
+func (f *fakeRoute53) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`<ErrorResponse><Error><Code>InvalidClientTokenId</Code><Message>bad key</Message></Error></ErrorResponse>`))
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/2013-04-01/hostedzone/Z1/rrset":
+		// Like Route53, list from name onwards in sorted order
+		var names []string
+		for key := range f.rrsets {
+			names = append(names, key)
+		}
+		sort.Strings(names)
+		var out struct {
+			XMLName xml.Name       `xml:"ListResourceRecordSetsResponse"`
+			RRsets  []route53RRset `xml:"ResourceRecordSets>ResourceRecordSet"`
+		}
+		for _, name := range names {
+			if name >= r.URL.Query().Get("name") {
+				out.RRsets = append(out.RRsets, f.rrsets[name])
+				break
+			}
+		}
+		_ = xml.NewEncoder(w).Encode(out)
+	case r.Method == http.MethodPost && r.URL.Path == "/2013-04-01/hostedzone/Z1/rrset/":
+		var in struct {
+			Changes []route53Change `xml:"ChangeBatch>Changes>Change"`
+		}
+		if err := xml.NewDecoder(r.Body).Decode(&in); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		for _, change := range in.Changes {
+			switch change.Action {
+			case "UPSERT":
+				f.rrsets[change.RRset.Name] = change.RRset
+			case "DELETE":
+				if !reflect.DeepEqual(f.rrsets[change.RRset.Name], change.RRset) {
+					w.WriteHeader(http.StatusBadRequest)
+					_, _ = w.Write([]byte(`<ErrorResponse><Error><Code>InvalidChangeBatch</Code></Error></ErrorResponse>`))
+					return
+				}
+				delete(f.rrsets, change.RRset.Name)
+			}
+		}
+		_, _ = w.Write([]byte(`<ChangeResourceRecordSetsResponse><ChangeInfo><Status>PENDING</Status></ChangeInfo></ChangeResourceRecordSetsResponse>`))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestRoute53ClientAddAndDelete(t *testing.T) {
+	fake := &fakeRoute53{rrsets: map[string]route53RRset{
+		"zzz.example.com.": {Name: "zzz.example.com.", Type: "A", TTL: 300, Records: []route53Record{{Value: "192.0.2.1"}}},
+	}}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+	c := NewRoute53Client(srv.URL, "/hostedzone/Z1", AWSCredentials{AccessKeyID: "AKID", SecretAccessKey: "secret"}, zap.NewNop())
+	ctx := context.Background()
+
+	for _, value := range []string{"token-1", "token-2"} {
+		if err := c.AddTXTRecord(ctx, testFQDN, value, 60); err != nil {
+			t.Fatalf("AddTXTRecord(%s): %v", value, err)
+		}
+	}
+	if got := len(fake.rrsets[testFQDN].Records); got != 2 {
+		t.Fatalf("Route53 holds %d TXT values, want 2", got)
+	}
+
+	if err := c.DeleteTXTRecordValue(ctx, testFQDN, "token-1"); err != nil {
+		t.Fatalf("DeleteTXTRecordValue: %v", err)
+	}
+	if records := fake.rrsets[testFQDN].Records; len(records) != 1 || records[0].Value != `"token-2"` {
+		t.Fatalf("Route53 TXT values after value delete = %v", records)
+	}
+
+	for range 2 {
+		if err := c.DeleteTXTRecord(ctx, testFQDN); err != nil {
+			t.Fatalf("DeleteTXTRecord: %v", err)
+		}
+	}
+	if _, ok := fake.rrsets[testFQDN]; ok || len(fake.rrsets) != 1 {
+		t.Fatalf("record sets after delete = %v", fake.rrsets)
+	}
+
+	bad := NewRoute53Client(srv.URL, "Z1", AWSCredentials{AccessKeyID: "OTHER"}, zap.NewNop())
+	if err := bad.AddTXTRecord(ctx, testFQDN, "token", 60); err == nil || !strings.Contains(err.Error(), "InvalidClientTokenId") {
+		t.Fatalf("AddTXTRecord with bad credentials error = %v", err)
+	}
+}
+
+// failingProvider fails every change
+type failingProvider struct{}
+
+func (failingProvider) AddTXTRecord(context.Context, string, string, int) error {
+	return errors.New("unavailable")
+}
+func (failingProvider) DeleteTXTRecord(context.Context, string) error {
+	return errors.New("unavailable")
+}
+func (failingProvider) DeleteTXTRecordValue(context.Context, string, string) error {
+	return errors.New("unavailable")
+}
+
+func TestBridgedProvider(t *testing.T) {
+	srv := startServer(t)
+	internal := newTestClient(srv, dnstest.TestSecret)
+	ctx := context.Background()
+
+	bridge := NewBridgedProvider(BridgeTarget{Name: "rfc2136", Provider: internal},
+		BridgeTarget{Name: "route53", Provider: failingProvider{}})
+	err := bridge.AddTXTRecord(ctx, testFQDN, "token", 60)
+	if err == nil || !strings.Contains(err.Error(), "route53: unavailable") {
+		t.Fatalf("AddTXTRecord error = %v, want the route53 failure", err)
+	}
+	if got := srv.TXT(testFQDN); len(got) != 1 {
+		t.Fatalf("internal view = %v, want the record applied", got)
+	}
+
+	if err := NewBridgedProvider(BridgeTarget{Name: "rfc2136", Provider: internal}).DeleteTXTRecord(ctx, testFQDN); err != nil {
+		t.Fatalf("DeleteTXTRecord: %v", err)
+	}
+}
@@ -0,0 +1,191 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/miekg/dns"
+	"go.uber.org/zap"
+)
+
+// FunctionRating: 80/100
+// - Complexity: LOW
+// - Integrations: 1 (dns library)
+// - External Risks: MEDIUM (network operations, replaces whole RRsets)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: ReplaceRRset, DeleteRRset, AddRecords, DeleteRecords
+// Purpose: Whole-RRset and batched record updates of any record type in single UPDATE messages
+
+// ReplaceRRset makes records the only records of rrtype at name. The old
+// RRset is removed and the new one added in a single UPDATE, so the server
+// applies both or neither. Every record must be owned by name and be of rrtype.
+func (c *RFC2136Client) ReplaceRRset(ctx context.Context, name string, rrtype uint16, records []dns.RR) error {
+	name = dns.Fqdn(name)
+	for _, rr := range records {
+		hdr := rr.Header()
+		if hdr.Rrtype != rrtype || !strings.EqualFold(dns.Fqdn(hdr.Name), name) {
+			return fmt.Errorf("record %q does not belong to the %s RRset at %s", rr.String(), dns.TypeToString[rrtype], name)
+		}
+	}
+	c.logger.Info("Replacing RRset",
+		zap.String("name", name),
+		zap.String("type", dns.TypeToString[rrtype]),
+		zap.Int("records", len(records)),
+		zap.String("server", c.server),
+		zap.String("zone", c.zone),
+	)
+
+	msg := new(dns.Msg)
+	msg.SetUpdate(c.zone)
+	msg.RemoveRRset([]dns.RR{&dns.ANY{Hdr: dns.RR_Header{Name: name, Rrtype: rrtype}}})
+	msg.Insert(copyRecords(records))
+	if c.ownership.Owner != "" {
+		live, err := c.liveRRset(ctx, name, rrtype)
+		if err != nil {
+			return err
+		}
+		if err := c.checkRegistry(ctx, live); err != nil {
+			return err
+		}
+		// Entries of the old records go before those of the new ones, so
+		// records kept across the replace keep their entry
+		msg.Ns = append(msg.Ns, c.ownership.removals(live)...)
+		msg.Ns = append(msg.Ns, c.ownership.entries(records)...)
+	}
+	c.finishMsg(msg)
+
+	return c.exchange(ctx, msg, name, "update")
+}
+
+// DeleteRRset removes every record of rrtype at name
+func (c *RFC2136Client) DeleteRRset(ctx context.Context, name string, rrtype uint16) error {
+	name = dns.Fqdn(name)
+	c.logger.Info("Deleting RRset",
+		zap.String("name", name),
+		zap.String("type", dns.TypeToString[rrtype]),
+		zap.String("server", c.server),
+		zap.String("zone", c.zone),
+	)
+
+	if c.ownership.Owner != "" {
+		return c.deleteOwnedRRset(ctx, name, rrtype)
+	}
+	msg := new(dns.Msg)
+	msg.SetUpdate(c.zone)
+	msg.RemoveRRset([]dns.RR{&dns.ANY{Hdr: dns.RR_Header{Name: name, Rrtype: rrtype}}})
+	c.finishMsg(msg)
+
+	return c.exchange(ctx, msg, name, "delete")
+}
+
+// TXTValue is one value of the TXT RRset at FQDN
+type TXTValue struct {
+	FQDN  string
+	Value string
+}
+
+// TXTRecords returns the TXT records holding values with ttl
+func TXTRecords(values []TXTValue, ttl int) []dns.RR {
+	records := make([]dns.RR, 0, len(values))
+	for _, v := range values {
+		records = append(records, &dns.TXT{
+			Hdr: dns.RR_Header{Name: dns.Fqdn(v.FQDN), Rrtype: dns.TypeTXT, Class: dns.ClassINET, Ttl: uint32(ttl)},
+			Txt: []string{v.Value},
+		})
+	}
+	return records
+}
+
+// copyRecords returns deep copies of records. Insert and Remove rewrite the
+// class and TTL of the RRs they are given, and callers share their records
+// across the clients of several servers.
+func copyRecords(records []dns.RR) []dns.RR {
+	copied := make([]dns.RR, len(records))
+	for i, rr := range records {
+		copied[i] = dns.Copy(rr)
+	}
+	return copied
+}
+
+// AddRecords adds records, which may have different names and types, in a
+// single UPDATE, so the server applies all or none of them
+func (c *RFC2136Client) AddRecords(ctx context.Context, records []dns.RR) error {
+	if len(records) == 0 {
+		return nil
+	}
+	c.logger.Info("Adding records",
+		zap.Int("records", len(records)),
+		zap.String("server", c.server),
+		zap.String("zone", c.zone),
+	)
+
+	msg := new(dns.Msg)
+	msg.SetUpdate(c.zone)
+	c.addInsertPrerequisites(msg, records...)
+	msg.Insert(copyRecords(records))
+	if c.ownership.Owner != "" {
+		msg.Ns = append(msg.Ns, c.ownership.entries(records)...)
+	}
+	c.finishMsg(msg)
+
+	if err := c.exchange(ctx, msg, c.zone, "update"); err != nil {
+		return c.resolveNameInUse(ctx, err, c.zone, records...)
+	}
+	return nil
+}
+
+// DeleteRecords removes records, matched by name, type and data, in a single
+// UPDATE and keeps the other records of their RRsets
+func (c *RFC2136Client) DeleteRecords(ctx context.Context, records []dns.RR) error {
+	if len(records) == 0 {
+		return nil
+	}
+	if err := c.checkOwned(ctx, records); err != nil {
+		return err
+	}
+	return c.deleteRecords(ctx, records)
+}
+
+// deleteRecords is DeleteRecords without the ownership check
+func (c *RFC2136Client) deleteRecords(ctx context.Context, records []dns.RR) error {
+	if len(records) == 0 {
+		return nil
+	}
+	c.logger.Info("Deleting records",
+		zap.Int("records", len(records)),
+		zap.String("server", c.server),
+		zap.String("zone", c.zone),
+	)
+
+	msg := new(dns.Msg)
+	msg.SetUpdate(c.zone)
+	c.addDeletePrerequisites(msg, records...)
+	msg.Remove(copyRecords(records))
+	if c.ownership.Owner != "" {
+		msg.Ns = append(msg.Ns, c.ownership.removals(records)...)
+	}
+	c.finishMsg(msg)
+
+	return c.exchange(ctx, msg, c.zone, "delete")
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/miekg/dns"
+
+	"github.com/rieset/istio-dns01-bind9/internal/dnstest"
+)
+
+func TestRFC2136ClientReplaceRRset(t *testing.T) {
+	srv := startServer(t)
+	c := newTestClient(srv, dnstest.TestSecret)
+	ctx := context.Background()
+	const name = "www.example.com."
+
+	first := mustRRs(t, "www.example.com. 300 IN A 192.0.2.1", "www.example.com. 300 IN A 192.0.2.2")
+	if err := c.ReplaceRRset(ctx, name, dns.TypeA, first); err != nil {
+		t.Fatalf("ReplaceRRset: %v", err)
+	}
+	if got, want := srv.Records(name, dns.TypeA), []string{"192.0.2.1", "192.0.2.2"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("A records = %v, want %v", got, want)
+	}
+
+	second := mustRRs(t, "www.example.com. 300 IN A 192.0.2.3")
+	if err := c.ReplaceRRset(ctx, name, dns.TypeA, second); err != nil {
+		t.Fatalf("ReplaceRRset: %v", err)
+	}
+	if got, want := srv.Records(name, dns.TypeA), []string{"192.0.2.3"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("A records after replace = %v, want %v", got, want)
+	}
+
+	srv.SetTXT(name, 60, "keep")
+	if err := c.DeleteRRset(ctx, name, dns.TypeA); err != nil {
+		t.Fatalf("DeleteRRset: %v", err)
+	}
+	if got := srv.Records(name, dns.TypeA); len(got) != 0 {
+		t.Fatalf("A records after delete = %v, want none", got)
+	}
+	if got, want := srv.TXT(name), []string{"keep"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("TXT = %v, want %v untouched", got, want)
+	}
+	if err := c.DeleteRRset(ctx, name, dns.TypeA); err != nil {
+		t.Fatalf("DeleteRRset of a missing RRset: %v", err)
+	}
+}
+
+func TestRFC2136ClientReplaceRRsetRejectsForeignRecords(t *testing.T) {
+	srv := startServer(t)
+	c := newTestClient(srv, dnstest.TestSecret)
+
+	for _, line := range []string{"other.example.com. 300 IN A 192.0.2.1", "www.example.com. 300 IN AAAA 2001:db8::1"} {
+		if err := c.ReplaceRRset(context.Background(), "www.example.com", dns.TypeA, mustRRs(t, line)); err == nil {
+			t.Fatalf("ReplaceRRset accepted %q", line)
+		}
+	}
+	if srv.Updates() != 0 {
+		t.Fatalf("server saw %d updates, want 0", srv.Updates())
+	}
+}
+
+func TestRFC2136ClientBatchedRecords(t *testing.T) {
+	srv := startServer(t)
+	c := newTestClient(srv, dnstest.TestSecret)
+	ctx := context.Background()
+	const other = "_acme-challenge.www.example.com."
+
+	records := TXTRecords([]TXTValue{
+		{FQDN: testFQDN, Value: "token-1"},
+		{FQDN: other, Value: "token-2"},
+		{FQDN: testFQDN, Value: "token-3"},
+	}, 60)
+	if err := c.AddRecords(ctx, records); err != nil {
+		t.Fatalf("AddRecords: %v", err)
+	}
+	if got := srv.Updates(); got != 1 {
+		t.Fatalf("server received %d UPDATEs, want 1", got)
+	}
+	if got, want := srv.TXT(testFQDN), []string{"token-1", "token-3"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("TXT at %s = %v, want %v", testFQDN, got, want)
+	}
+
+	if err := c.DeleteRecords(ctx, records[:2]); err != nil {
+		t.Fatalf("DeleteRecords: %v", err)
+	}
+	if got := srv.Updates(); got != 2 {
+		t.Fatalf("server received %d UPDATEs, want 2", got)
+	}
+	if got, want := srv.TXT(testFQDN), []string{"token-3"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("TXT at %s after delete = %v, want %v", testFQDN, got, want)
+	}
+	if got := srv.TXT(other); len(got) != 0 {
+		t.Fatalf("TXT at %s after delete = %v, want none", other, got)
+	}
+	// The caller's records are left as they were
+	if hdr := records[0].Header(); hdr.Class != dns.ClassINET || hdr.Ttl != 60 {
+		t.Fatalf("record after delete has class %d and TTL %d, want IN and 60", hdr.Class, hdr.Ttl)
+	}
+}
+
+func TestRFC2136ClientSharedRecords(t *testing.T) {
+	servers := dnstest.StartServers(t, 3)
+	ctx := context.Background()
+	records := TXTRecords([]TXTValue{{FQDN: testFQDN, Value: "token-1"}, {FQDN: testFQDN, Value: "token-2"}}, 60)
+	rrset := mustRRs(t, "www.example.com. 300 IN A 192.0.2.1")
+
+	// One slice of records goes to the clients of every server at once, as
+	// the multi-server manager and the controllers hand it out
+	var wg sync.WaitGroup
+	for _, srv := range servers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c := newTestClient(srv, dnstest.TestSecret)
+			if err := c.AddRecords(ctx, records); err != nil {
+				t.Errorf("AddRecords on %s: %v", srv.Addr(), err)
+			}
+			if err := c.DeleteRecords(ctx, records[:1]); err != nil {
+				t.Errorf("DeleteRecords on %s: %v", srv.Addr(), err)
+			}
+			if err := c.ReplaceRRset(ctx, "www.example.com.", dns.TypeA, rrset); err != nil {
+				t.Errorf("ReplaceRRset on %s: %v", srv.Addr(), err)
+			}
+		}()
+	}
+	wg.Wait()
+	for _, srv := range servers {
+		if got, want := srv.TXT(testFQDN), []string{"token-2"}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("TXT on %s = %v, want %v", srv.Addr(), got, want)
+		}
+		if got, want := srv.Records("www.example.com.", dns.TypeA), []string{"192.0.2.1"}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("A on %s = %v, want %v", srv.Addr(), got, want)
+		}
+	}
+}
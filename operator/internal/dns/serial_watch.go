@@ -0,0 +1,154 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// FunctionRating: 78/100
+// - Complexity: MEDIUM
+// - Integrations: 1 (dns library)
+// - External Risks: LOW (read-only SOA queries, bounded by the context)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: WaitForSerial
+// Purpose: Confirms secondaries caught up with a change by their SOA serial instead of the changed records
+
+// SerialCheck describes the version of a zone a set of secondaries must reach
+type SerialCheck struct {
+	// Primary is the server whose serial the secondaries must reach; it must
+	// already serve the change
+	Primary string
+	// Servers are the secondaries polled concurrently
+	Servers []string
+	// Zone is the zone whose SOA is compared
+	Zone string
+	// Interval is the delay between polls of a single server
+	Interval time.Duration
+	// QueryTimeout bounds each individual query
+	QueryTimeout time.Duration
+	// TLS and Proxies hold the DNS-over-TLS settings and proxies of the
+	// servers that need them, as in PropagationCheck
+	TLS     map[string]*tls.Config
+	Proxies map[string]*Proxy
+}
+
+// serialReached reports whether serial is target or later in serial number
+// arithmetic (RFC 1982), so a serial that wrapped around still counts
+func serialReached(serial, target uint32) bool {
+	return int32(serial-target) >= 0
+}
+
+// zoneSerial returns the serial of the SOA of zone as served by server
+func zoneSerial(ctx context.Context, server, zone string, timeout time.Duration, tlsConfig *tls.Config) (uint32, error) {
+	msg := new(dns.Msg)
+	msg.SetQuestion(zone, dns.TypeSOA)
+	msg.RecursionDesired = false
+
+	reply, err := queryMsg(ctx, msg, server, timeout, tlsConfig)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query SOA for %s on %s: %w", zone, server, err)
+	}
+	if reply.Rcode != dns.RcodeSuccess {
+		return 0, fmt.Errorf("SOA query for %s on %s failed: %s (rcode: %d)",
+			zone, server, dns.RcodeToString[reply.Rcode], reply.Rcode)
+	}
+	for _, rr := range reply.Answer {
+		if soa, ok := rr.(*dns.SOA); ok && strings.EqualFold(soa.Hdr.Name, zone) {
+			return soa.Serial, nil
+		}
+	}
+	return 0, fmt.Errorf("%s does not serve zone %s", server, zone)
+}
+
+// WaitForSerial reads the SOA serial of the zone on the primary and polls
+// every server until it serves that serial or a later one. Unlike polling
+// the changed records it needs one small answer per server and works when
+// query ACLs hide the changed names. The servers are added to result, which
+// holds the outcome of earlier checks; an error is returned when the serial
+// cannot be read or ctx expires first.
+func WaitForSerial(ctx context.Context, check SerialCheck, result PropagationResult) (PropagationResult, error) {
+	check.Servers = uniqueServers(check.Servers)
+	check.Zone = dns.Fqdn(check.Zone)
+	if check.Interval <= 0 {
+		check.Interval = DefaultPropagationInterval
+	}
+
+	target, err := zoneSerial(withProxy(ctx, check.Proxies[check.Primary]), check.Primary, check.Zone,
+		check.QueryTimeout, check.TLS[check.Primary])
+	if err != nil {
+		result.Pending = append(result.Pending, check.Servers...)
+		return result, fmt.Errorf("failed to read the serial secondaries must reach: %w", err)
+	}
+
+	pollCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	matches := make(chan string, len(check.Servers))
+	for _, server := range check.Servers {
+		go pollSerial(pollCtx, check, server, target, matches)
+	}
+
+	matched := map[string]bool{}
+	for len(matched) < len(check.Servers) && err == nil {
+		select {
+		case server := <-matches:
+			matched[server] = true
+		case <-ctx.Done():
+			err = fmt.Errorf("secondaries of %s did not reach serial %d: %d/%d servers caught up: %w",
+				check.Zone, target, len(matched), len(check.Servers), ctx.Err())
+		}
+	}
+
+	more := newPropagationResult(check.Servers, matched)
+	result.Matched = append(result.Matched, more.Matched...)
+	result.Pending = append(result.Pending, more.Pending...)
+	sort.Strings(result.Matched)
+	sort.Strings(result.Pending)
+	return result, err
+}
+
+// pollSerial queries the serial of server until it reaches target or ctx is cancelled
+func pollSerial(ctx context.Context, check SerialCheck, server string, target uint32, matches chan<- string) {
+	ctx = withProxy(ctx, check.Proxies[server])
+	ticker := time.NewTicker(check.Interval)
+	defer ticker.Stop()
+
+	for {
+		serial, err := zoneSerial(ctx, server, check.Zone, check.QueryTimeout, check.TLS[server])
+		if err == nil && serialReached(serial, target) {
+			matches <- server
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
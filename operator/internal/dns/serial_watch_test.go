@@ -0,0 +1,95 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/rieset/istio-dns01-bind9/internal/dnstest"
+)
+
+func TestSerialReached(t *testing.T) {
+	tests := []struct {
+		serial, target uint32
+		want           bool
+	}{
+		{5, 5, true},
+		{6, 5, true},
+		{4, 5, false},
+		// Serials wrap around: 2 is later than 4294967290
+		{2, 4294967290, true},
+		{4294967290, 2, false},
+	}
+	for _, tt := range tests {
+		if got := serialReached(tt.serial, tt.target); got != tt.want {
+			t.Errorf("serialReached(%d, %d) = %v, want %v", tt.serial, tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestWaitForSerial(t *testing.T) {
+	servers := dnstest.StartServers(t, 3)
+	primary, caughtUp, lagging := servers[0], servers[1], servers[2]
+	ctx := context.Background()
+	for _, srv := range []*dnstest.Server{primary, caughtUp} {
+		if err := newTestClient(srv, dnstest.TestSecret).AddTXTRecord(ctx, testFQDN, "token", 60); err != nil {
+			t.Fatal(err)
+		}
+	}
+	check := SerialCheck{
+		Primary:      primary.Addr(),
+		Servers:      []string{caughtUp.Addr(), lagging.Addr()},
+		Zone:         "example.com",
+		Interval:     20 * time.Millisecond,
+		QueryTimeout: time.Second,
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+	defer cancel()
+	result, err := WaitForSerial(timeoutCtx, check, PropagationResult{Matched: []string{primary.Addr()}})
+	if err == nil {
+		t.Fatal("WaitForSerial succeeded with a lagging secondary")
+	}
+	if !slices.Equal(result.Pending, []string{lagging.Addr()}) || len(result.Matched) != 2 {
+		t.Fatalf("result = %+v, want the lagging secondary pending", result)
+	}
+
+	// The secondary catches up with a later serial while being polled
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		client := newTestClient(lagging, dnstest.TestSecret)
+		_ = client.AddTXTRecord(ctx, testFQDN, "token", 60)
+		_ = client.AddTXTRecord(ctx, testFQDN, "other", 60)
+	}()
+	timeoutCtx, cancel = context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	result, err = WaitForSerial(timeoutCtx, check, PropagationResult{})
+	if err != nil {
+		t.Fatalf("WaitForSerial: %v", err)
+	}
+	if len(result.Matched) != 2 || len(result.Pending) != 0 {
+		t.Fatalf("result = %+v, want both secondaries matched", result)
+	}
+
+	check.Zone = "example.org"
+	if _, err := WaitForSerial(timeoutCtx, check, PropagationResult{}); err == nil {
+		t.Fatal("WaitForSerial succeeded for a zone the primary does not serve")
+	}
+}
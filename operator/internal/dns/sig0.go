@@ -0,0 +1,112 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"crypto"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// FunctionRating: 78/100
+// - Complexity: MEDIUM
+// - Integrations: 1 (dns library)
+// - External Risks: MEDIUM (private key handling, clock skew against the server)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: SIG0Signer
+// Purpose: SIG(0) public-key signatures (RFC 2931) for updates as an alternative to TSIG
+
+// sig0Validity is how far the inception and expiration of a signature lie
+// from the signing time, absorbing clock skew like the TSIG fudge
+const sig0Validity = 5 * time.Minute
+
+// SIG0Signer signs messages with a private key whose public KEY record is
+// published in the zone, so no shared secret has to be distributed
+type SIG0Signer struct {
+	key    *dns.KEY
+	signer crypto.Signer
+}
+
+// NewSIG0Signer parses a key pair as written by dnssec-keygen -T KEY: the
+// public KEY record from the .key file and the .private file contents
+func NewSIG0Signer(publicKey, privateKey string) (*SIG0Signer, error) {
+	rr, err := dns.NewRR(strings.TrimSpace(publicKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SIG(0) public key: %w", err)
+	}
+	key, ok := rr.(*dns.KEY)
+	if !ok {
+		return nil, fmt.Errorf("SIG(0) public key must be a KEY record, got %s", dns.TypeToString[rr.Header().Rrtype])
+	}
+	private, err := key.NewPrivateKey(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SIG(0) private key of %s: %w", key.Hdr.Name, err)
+	}
+	signer, ok := private.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("SIG(0) private key of %s cannot sign", key.Hdr.Name)
+	}
+	return &SIG0Signer{key: key, signer: signer}, nil
+}
+
+// KeyName returns the owner name of the signing key
+func (s *SIG0Signer) KeyName() string {
+	return s.key.Hdr.Name
+}
+
+// Sign returns a copy of msg carrying a SIG(0) record over its contents.
+// msg itself is left unchanged so it can be signed again for a retry.
+func (s *SIG0Signer) Sign(msg *dns.Msg) (*dns.Msg, error) {
+	now := time.Now()
+	sig := &dns.SIG{RRSIG: dns.RRSIG{
+		Algorithm:  s.key.Algorithm,
+		SignerName: s.key.Hdr.Name,
+		KeyTag:     s.key.KeyTag(),
+		Inception:  uint32(now.Add(-sig0Validity).Unix()),
+		Expiration: uint32(now.Add(sig0Validity).Unix()),
+	}}
+	// Sign fills in the signature over msg as packed; appending the record
+	// to a copy packs to the same wire form
+	if _, err := sig.Sign(s.signer, msg); err != nil {
+		return nil, fmt.Errorf("failed to sign message with SIG(0) key %s: %w", s.key.Hdr.Name, err)
+	}
+	signed := msg.Copy()
+	signed.Extra = append(signed.Extra, sig)
+	return signed, nil
+}
+
+// SetSIG0Signer makes the client sign updates and transfers with SIG(0)
+// instead of TSIG; nil restores TSIG
+func (c *RFC2136Client) SetSIG0Signer(signer *SIG0Signer) {
+	c.sig0 = signer
+}
+
+// keyName returns the name of the key messages are signed with
+func (c *RFC2136Client) keyName() string {
+	if c.sig0 != nil {
+		return c.sig0.KeyName()
+	}
+	keyName, _ := c.signingKey()
+	return keyName
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/rieset/istio-dns01-bind9/internal/dnstest"
+)
+
+func TestRFC2136ClientSIG0(t *testing.T) {
+	key, private, err := dnstest.GenerateSIG0Key("acme-sig0.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := NewSIG0Signer(key.String(), private)
+	if err != nil {
+		t.Fatalf("NewSIG0Signer: %v", err)
+	}
+
+	srv := dnstest.NewServer("example.com")
+	srv.AddSIG0Key(key)
+	if err := srv.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = srv.Close() })
+
+	c := NewRFC2136Client(srv.Addr(), "example.com", "", "", "", zap.NewNop())
+	ctx := context.Background()
+	if err := c.AddTXTRecord(ctx, testFQDN, "token", 60); err == nil {
+		t.Fatal("unsigned AddTXTRecord succeeded against a SIG(0) server")
+	}
+
+	c.SetSIG0Signer(signer)
+	if err := c.CheckUpdatePermission(ctx, testFQDN); err != nil {
+		t.Fatalf("CheckUpdatePermission: %v", err)
+	}
+	if err := c.AddTXTRecord(ctx, testFQDN, "token", 60); err != nil {
+		t.Fatalf("AddTXTRecord: %v", err)
+	}
+	if got, want := srv.TXT(testFQDN), []string{"token"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("TXT after add = %v, want %v", got, want)
+	}
+	records, err := c.TransferZone(ctx)
+	if err != nil {
+		t.Fatalf("TransferZone: %v", err)
+	}
+	if len(records) < 2 {
+		t.Fatalf("TransferZone returned %v, want the SOA and the TXT record", records)
+	}
+	if err := c.DeleteTXTRecordValue(ctx, testFQDN, "token"); err != nil {
+		t.Fatalf("DeleteTXTRecordValue: %v", err)
+	}
+	if got := srv.TXT(testFQDN); len(got) != 0 {
+		t.Fatalf("TXT after delete = %v, want none", got)
+	}
+}
+
+func TestNewSIG0Signer(t *testing.T) {
+	key, private, err := dnstest.GenerateSIG0Key("acme-sig0.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, tt := range map[string]struct{ public, private string }{
+		"not a record":  {"not a key", private},
+		"not a KEY":     {"example.com. 60 IN TXT \"x\"", private},
+		"bad private":   {key.String(), "Private-key-format: v1.3\n"},
+		"empty private": {key.String(), ""},
+	} {
+		if _, err := NewSIG0Signer(tt.public, tt.private); err == nil {
+			t.Errorf("%s: NewSIG0Signer succeeded", name)
+		}
+	}
+	// dnssec-keygen starts .key files with comment lines
+	signer, err := NewSIG0Signer("; This is a host key, keyid 1234, for acme-sig0.example.com.\n"+key.String()+"\n", private)
+	if err != nil {
+		t.Fatalf("NewSIG0Signer with a commented key file: %v", err)
+	}
+	if signer.KeyName() != key.Hdr.Name {
+		t.Errorf("KeyName() = %q, want %q", signer.KeyName(), key.Hdr.Name)
+	}
+}
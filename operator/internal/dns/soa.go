@@ -0,0 +1,93 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// FunctionRating: 78/100
+// - Complexity: MEDIUM
+// - Integrations: 1 (dns library)
+// - External Risks: MEDIUM (network operations, DNS server availability)
+// - Unit Tests: NO
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: QuerySOA, DiscoverZone
+// Purpose: SOA queries and closest-enclosing-zone discovery against an authoritative server
+
+// QuerySOA queries server for the SOA record at name. It returns the SOA
+// found in the answer section, or the SOA of the enclosing zone carried in
+// the authority section of a negative answer.
+func QuerySOA(ctx context.Context, server, name string, timeout time.Duration) (*dns.SOA, error) {
+	msg := new(dns.Msg)
+	msg.SetQuestion(dns.Fqdn(name), dns.TypeSOA)
+	msg.RecursionDesired = false
+
+	reply, err := queryMsg(ctx, msg, server, timeout, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query SOA for %s on %s: %w", name, server, err)
+	}
+
+	if reply.Rcode != dns.RcodeSuccess && reply.Rcode != dns.RcodeNameError {
+		return nil, fmt.Errorf("SOA query for %s on %s failed: %s (rcode: %d)",
+			name, server, dns.RcodeToString[reply.Rcode], reply.Rcode)
+	}
+
+	for _, rr := range reply.Answer {
+		if soa, ok := rr.(*dns.SOA); ok {
+			return soa, nil
+		}
+	}
+	for _, rr := range reply.Ns {
+		if soa, ok := rr.(*dns.SOA); ok {
+			return soa, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no SOA record for %s on %s", name, server)
+}
+
+// DiscoverZone finds the closest zone enclosing fqdn that is served by server.
+// It walks the FQDN labels from the most specific name towards the root and
+// stops at the first SOA whose owner is a suffix of fqdn.
+func DiscoverZone(ctx context.Context, server, fqdn string, timeout time.Duration) (*dns.SOA, error) {
+	name := dns.Fqdn(strings.ToLower(fqdn))
+
+	for _, offset := range dns.Split(name) {
+		candidate := name[offset:]
+		soa, err := QuerySOA(ctx, server, candidate, timeout)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			continue
+		}
+		if dns.IsSubDomain(strings.ToLower(soa.Hdr.Name), name) {
+			return soa, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no enclosing zone found for %s on %s", fqdn, server)
+}
@@ -0,0 +1,50 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+func TestPropagationTimingFromSOA(t *testing.T) {
+	soa := func(ttl, refresh, retry, minttl uint32) *dns.SOA {
+		return &dns.SOA{Hdr: dns.RR_Header{Ttl: ttl}, Refresh: refresh, Retry: retry, Minttl: minttl}
+	}
+
+	tests := []struct {
+		name string
+		soa  *dns.SOA
+		want PropagationTiming
+	}{
+		{"no soa", nil, DefaultPropagationTiming},
+		{"short timers", soa(60, 3600, 5, 5), PropagationTiming{Interval: 2 * time.Second, Timeout: MinPropagationTimeout}},
+		{"negative ttl", soa(3600, 86400, 60, 300), PropagationTiming{Interval: 30 * time.Second, Timeout: 360 * time.Second}},
+		{"soa ttl bounds minimum", soa(120, 86400, 60, 86400), PropagationTiming{Interval: 18 * time.Second, Timeout: 180 * time.Second}},
+		{"refresh bounds window", soa(3600, 90, 600, 600), PropagationTiming{Interval: 9 * time.Second, Timeout: 90 * time.Second}},
+		{"capped", soa(86400, 604800, 7200, 86400), PropagationTiming{Interval: MaxPropagationInterval, Timeout: MaxPropagationTimeout}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PropagationTimingFromSOA(tt.soa); got != tt.want {
+				t.Fatalf("PropagationTimingFromSOA = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rieset/istio-dns01-bind9/internal/dnstest"
+)
+
+func TestTimeoutsTotal(t *testing.T) {
+	tests := []struct {
+		name     string
+		timeouts Timeouts
+		want     time.Duration
+	}{
+		{"unset", Timeouts{}, DefaultTimeout},
+		{"all stages", Timeouts{Dial: time.Second, Read: 2 * time.Second, Write: time.Second}, 4 * time.Second},
+		{"read only", Timeouts{Read: time.Minute}, time.Minute + 2*DefaultTimeout},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.timeouts.total(); got != tt.want {
+				t.Fatalf("total() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetTimeouts(t *testing.T) {
+	srv := startServer(t)
+	srv.SetLatency(300 * time.Millisecond)
+	c := newTestClient(srv, dnstest.TestSecret)
+	c.SetTransport(TransportTCP)
+	ctx := context.Background()
+
+	c.SetTimeouts(Timeouts{Dial: time.Second, Read: 50 * time.Millisecond, Write: time.Second})
+	if c.tcpClient.Timeout != 0 || c.tcpClient.ReadTimeout != 50*time.Millisecond || c.tlsClient.DialTimeout != time.Second {
+		t.Fatalf("clients not split into stages: %+v", c.tcpClient)
+	}
+	start := time.Now()
+	if err := c.AddTXTRecord(ctx, testFQDN, "token", 60); err == nil {
+		t.Fatal("AddTXTRecord succeeded although the reply took longer than the read timeout")
+	}
+	if elapsed := time.Since(start); elapsed > 250*time.Millisecond {
+		t.Fatalf("AddTXTRecord gave up after %s, want the 50ms read timeout", elapsed)
+	}
+
+	// A slow server gets the time it needs once the read timeout allows it
+	c.SetTimeouts(Timeouts{Read: 2 * time.Second})
+	if err := c.AddTXTRecord(ctx, testFQDN, "token", 60); err != nil {
+		t.Fatalf("AddTXTRecord: %v", err)
+	}
+	c.SetTimeouts(Timeouts{})
+	if c.client.Timeout != DefaultTimeout || c.client.ReadTimeout != 0 || c.timeout != DefaultTimeout {
+		t.Fatalf("zero Timeouts did not restore the default: %+v", c.client)
+	}
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"testing"
+
+	"github.com/miekg/dns"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/rieset/istio-dns01-bind9/internal/dnstest"
+)
+
+func TestExchangeSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	srv := startServer(t)
+	c := newTestClient(srv, dnstest.TestSecret)
+	if err := c.AddTXTRecord(context.Background(), testFQDN, "token", 60); err != nil {
+		t.Fatalf("AddTXTRecord: %v", err)
+	}
+	srv.SetUpdateRcode(dns.RcodeRefused)
+	if err := c.DeleteTXTRecord(context.Background(), testFQDN); err == nil {
+		t.Fatal("DeleteTXTRecord succeeded against a refusing server")
+	}
+
+	spans := recorder.Ended()
+	tests := []struct {
+		name      string
+		rcode     string
+		wantError bool
+	}{
+		{"dns.update", "NOERROR", false},
+		{"dns.delete", "REFUSED", true},
+	}
+	if len(spans) != len(tests) {
+		t.Fatalf("recorded %d spans, want %d", len(spans), len(tests))
+	}
+	for i, tt := range tests {
+		span := spans[i]
+		if span.Name() != tt.name {
+			t.Errorf("span %d name = %q, want %q", i, span.Name(), tt.name)
+		}
+		attrs := map[attribute.Key]attribute.Value{}
+		for _, kv := range span.Attributes() {
+			attrs[kv.Key] = kv.Value
+		}
+		if got := attrs["dns.server"].AsString(); got != srv.Addr() {
+			t.Errorf("%s dns.server = %q, want %q", tt.name, got, srv.Addr())
+		}
+		if got := attrs["dns.zone"].AsString(); got != "example.com." {
+			t.Errorf("%s dns.zone = %q, want example.com.", tt.name, got)
+		}
+		if got := attrs["dns.rcode"].AsString(); got != tt.rcode {
+			t.Errorf("%s dns.rcode = %q, want %q", tt.name, got, tt.rcode)
+		}
+		if _, ok := attrs["dns.duration_ms"]; !ok {
+			t.Errorf("%s has no dns.duration_ms", tt.name)
+		}
+		if got := span.Status().Code == codes.Error; got != tt.wantError {
+			t.Errorf("%s error status = %v, want %v", tt.name, got, tt.wantError)
+		}
+	}
+}
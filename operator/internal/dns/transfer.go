@@ -0,0 +1,98 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// FunctionRating: 76/100
+// - Complexity: LOW
+// - Integrations: 1 (dns library)
+// - External Risks: MEDIUM (network operations, transfer ACLs on the server)
+// - Unit Tests: NO
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: TransferZone
+// Purpose: TSIG-signed AXFR of the client's zone from its server
+
+// TransferZone fetches every record of the zone with a TSIG-signed AXFR,
+// or a SIG(0)-signed one when the client has a SIG(0) signer.
+// The trailing SOA that closes the transfer is not included.
+func (c *RFC2136Client) TransferZone(ctx context.Context) ([]dns.RR, error) {
+	msg := new(dns.Msg)
+	msg.SetAxfr(c.zone)
+	if c.sig0 != nil {
+		signed, err := c.sig0.Sign(msg)
+		if err != nil {
+			return nil, err
+		}
+		msg = signed
+	} else {
+		keyName, algorithm := c.signingKey()
+		msg.SetTsig(keyName, algorithm, c.quirks.TSIGFudge, c.tsigTime())
+	}
+
+	timeout := c.timeout
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+	transfer := &dns.Transfer{
+		DialTimeout:  timeout,
+		ReadTimeout:  timeout,
+		WriteTimeout: timeout,
+		TsigSecret:   c.client.TsigSecret,
+	}
+	client := c.tcpClient
+	if c.transport == TransportTLS {
+		client = c.tlsClient
+		transfer.TLS = client.TLSConfig
+	}
+
+	addr := dialAddr(withProxy(ctx, c.proxy), client, c.server)
+	if c.proxy != nil {
+		// The library dials directly, so hand it a tunneled connection
+		conn, err := dialDNS(ctx, client, addr, c.proxy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to start zone transfer of %s from %s: %w", c.zone, c.server, err)
+		}
+		transfer.Conn = conn
+		defer func() { _ = conn.Close() }()
+	}
+	envelopes, err := transfer.In(msg, addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start zone transfer of %s from %s: %w", c.zone, c.server, err)
+	}
+
+	var records []dns.RR
+	for envelope := range envelopes {
+		if envelope.Error != nil {
+			return nil, fmt.Errorf("zone transfer of %s from %s failed: %w", c.zone, c.server, envelope.Error)
+		}
+		records = append(records, envelope.RR...)
+	}
+	if n := len(records); n > 1 && records[n-1].Header().Rrtype == dns.TypeSOA {
+		records = records[:n-1]
+	}
+	return records, nil
+}
@@ -0,0 +1,180 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// FunctionRating: 85/100
+// - Complexity: LOW
+// - Integrations: 0
+// - External Risks: LOW (static parsing, connection handling of single exchanges)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: ParseTransport, TLSClientConfig, exchangeContext
+// Purpose: Names the transports RFC2136 updates can be sent over, builds their TLS settings and sends cancellable exchanges
+
+// Transport selects how RFC2136 messages reach a server
+type Transport string
+
+const (
+	// TransportAuto sends over UDP and retries over TCP when the reply is
+	// truncated or UDP fails
+	TransportAuto Transport = "auto"
+	// TransportUDP only uses UDP
+	TransportUDP Transport = "udp"
+	// TransportTCP only uses TCP, for networks that drop UDP/53
+	TransportTCP Transport = "tcp"
+	// TransportTLS uses DNS-over-TLS (RFC 7858)
+	TransportTLS Transport = "tls"
+)
+
+// DefaultTLSPort is used for DNS-over-TLS servers configured without a port
+const DefaultTLSPort = "853"
+
+// ErrTruncated is returned when a UDP-only exchange gets a truncated reply
+var ErrTruncated = errors.New("reply truncated over UDP")
+
+// ParseTransport parses a transport name case-insensitively. An empty name is TransportAuto.
+func ParseTransport(name string) (Transport, error) {
+	switch transport := Transport(strings.ToLower(name)); transport {
+	case "":
+		return TransportAuto, nil
+	case TransportAuto, TransportUDP, TransportTCP, TransportTLS:
+		return transport, nil
+	}
+	return "", fmt.Errorf("unknown transport %q, expected one of udp, tcp, tls, auto", name)
+}
+
+// TLSClientConfig builds a client TLS config from the keys of a credentials
+// Secret: ca.crt to verify the server instead of the system roots and
+// tls.crt with tls.key for a client certificate. credentials may be nil.
+// serverName, when set, is verified and sent as SNI.
+func TLSClientConfig(credentials map[string][]byte, serverName string) (*tls.Config, error) {
+	config := &tls.Config{MinVersion: tls.VersionTLS12, ServerName: serverName}
+	if ca := credentials["ca.crt"]; len(ca) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("ca.crt holds no PEM certificates")
+		}
+		config.RootCAs = pool
+	}
+	if cert, key := credentials["tls.crt"], credentials["tls.key"]; len(cert) > 0 || len(key) > 0 {
+		pair, err := tls.X509KeyPair(cert, key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{pair}
+	}
+	return config, nil
+}
+
+// newTLSClient returns a DNS-over-TLS client for server. Without a
+// ServerName in config the host of server is verified.
+func newTLSClient(server string, config *tls.Config, timeout time.Duration) *dns.Client {
+	if config == nil {
+		config = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	if config.ServerName == "" {
+		config = config.Clone()
+		host, _ := splitServer(server)
+		config.ServerName = strings.TrimSuffix(host, ".")
+	}
+	return &dns.Client{Net: "tcp-tls", Timeout: timeout, TLSConfig: config}
+}
+
+// dialAddr returns the address client reaches server at. DNS-over-TLS
+// servers configured without a port are reached on DefaultTLSPort.
+func dialAddr(ctx context.Context, client *dns.Client, server string) string {
+	if client.Net == "tcp-tls" {
+		if host, port, err := ParseServerAddress(server); err == nil && port == "" {
+			server = net.JoinHostPort(host, DefaultTLSPort)
+		}
+	}
+	return serverAddr(ctx, server)
+}
+
+// exchangeContext sends msg to server with client like client.ExchangeContext,
+// but also gives up when ctx is cancelled, as the dns library only honours
+// the deadline of ctx. TCP and TLS connections are taken from and returned to
+// pool when it is set. A failed exchange on a reused connection is retried
+// once on a fresh one, since the server may have closed it while idle.
+func exchangeContext(ctx context.Context, client *dns.Client, msg *dns.Msg, server string, pool *ConnPool) (*dns.Msg, error) {
+	addr := dialAddr(ctx, client, server)
+	conn, reused, err := pool.get(ctx, client, addr)
+	if err != nil {
+		return nil, err
+	}
+	retry := msg
+	if reused {
+		// Signing strips the TSIG RR from msg, so keep a copy for the retry
+		retry = msg.Copy()
+	}
+
+	reply, err := exchangeConn(ctx, client, msg, conn, addr, pool)
+	if err == nil || ctx.Err() != nil {
+		return reply, err
+	}
+	// The server may have restarted or dropped its connections
+	pool.invalidate(ctx, client, addr)
+	if !reused {
+		return nil, err
+	}
+	if conn, _, err = pool.get(ctx, client, addr); err != nil {
+		return nil, err
+	}
+	return exchangeConn(ctx, client, retry, conn, addr, pool)
+}
+
+// exchangeConn sends msg over conn and hands conn back to pool when the
+// exchange succeeded. A cancelled ctx closes conn to unblock a pending write
+// or read. NOTAUTH replies and replies signed on a server clock learned from
+// BADTIME are returned although the dns library rejects their TSIG.
+func exchangeConn(ctx context.Context, client *dns.Client, msg *dns.Msg, conn *dns.Conn,
+	addr string, pool *ConnPool) (*dns.Msg, error) {
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	reply, _, err := client.ExchangeWithConnContext(ctx, msg, conn)
+	if !stop() {
+		// Closed under the exchange
+		if err != nil {
+			return nil, ctx.Err()
+		}
+		return reply, nil
+	}
+	if err != nil {
+		_ = conn.Close()
+		if notAuthReply(reply, err) || skewedReply(ctx, reply, err) {
+			return reply, nil
+		}
+		return nil, err
+	}
+	pool.put(ctx, client, addr, conn)
+	return reply, nil
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+// FunctionRating: 86/100
+// - Complexity: LOW
+// - Integrations: 1 (dns library)
+// - External Risks: LOW (pure lookup)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: ParseTSIGAlgorithm, TSIGAlgorithm
+// Purpose: Registry of the TSIG algorithms updates can be signed with, by the names configs use
+
+// ErrDeprecatedTSIGAlgorithm is returned for algorithms that must be allowed explicitly
+var ErrDeprecatedTSIGAlgorithm = errors.New("deprecated TSIG algorithm")
+
+// tsigAlgorithms maps the names configs use for TSIG algorithms to the names
+// the dns library signs with
+var tsigAlgorithms = map[string]string{
+	"hmac-sha1":   dns.HmacSHA1,
+	"hmac-sha224": dns.HmacSHA224,
+	"hmac-sha256": dns.HmacSHA256,
+	"hmac-sha384": dns.HmacSHA384,
+	"hmac-sha512": dns.HmacSHA512,
+}
+
+// deprecatedTSIGAlgorithms are supported but not recommended by RFC 8945
+var deprecatedTSIGAlgorithms = map[string]bool{"hmac-sha1": true}
+
+// tsigAlgorithmName reduces the spellings of an algorithm, such as
+// "HMACSHA256", "hmac-sha256." or "hmac-md5.sig-alg.reg.int", to its config name
+func tsigAlgorithmName(name string) string {
+	name = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(name), "."))
+	name = strings.TrimSuffix(name, ".sig-alg.reg.int")
+	if rest, ok := strings.CutPrefix(name, "hmac"); ok && !strings.HasPrefix(rest, "-") {
+		name = "hmac-" + rest
+	}
+	return name
+}
+
+// ParseTSIGAlgorithm returns the config name of algorithm: hmac-sha224,
+// hmac-sha256, hmac-sha384, hmac-sha512, or hmac-sha1 with allowDeprecated.
+// hmac-md5 is rejected, since the dns library can no longer sign with it.
+func ParseTSIGAlgorithm(algorithm string, allowDeprecated bool) (string, error) {
+	name := tsigAlgorithmName(algorithm)
+	if name == "hmac-md5" {
+		return "", fmt.Errorf("TSIG algorithm %q is no longer supported, use hmac-sha256 or stronger", algorithm)
+	}
+	if _, ok := tsigAlgorithms[name]; !ok {
+		return "", fmt.Errorf("unknown TSIG algorithm %q, expected hmac-sha224, hmac-sha256, hmac-sha384 or hmac-sha512",
+			algorithm)
+	}
+	if deprecatedTSIGAlgorithms[name] && !allowDeprecated {
+		return "", fmt.Errorf("%w %q, use hmac-sha256 or stronger or allow deprecated algorithms", ErrDeprecatedTSIGAlgorithm, algorithm)
+	}
+	return name, nil
+}
+
+// TSIGAlgorithm returns the name the dns library signs with for algorithm.
+// Names outside the registry are only made fully qualified, so the server
+// reports them as unknown.
+func TSIGAlgorithm(algorithm string) string {
+	if canonical, ok := tsigAlgorithms[tsigAlgorithmName(algorithm)]; ok {
+		return canonical
+	}
+	return dns.Fqdn(algorithm)
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+func TestParseTSIGAlgorithm(t *testing.T) {
+	tests := []struct {
+		algorithm       string
+		allowDeprecated bool
+		want            string
+		wantDeprecated  bool
+		wantErr         bool
+	}{
+		{"hmac-sha256", false, "hmac-sha256", false, false},
+		{"HMACSHA384", false, "hmac-sha384", false, false},
+		{"hmac-sha512.", false, "hmac-sha512", false, false},
+		{" hmac-sha224 ", false, "hmac-sha224", false, false},
+		{"hmac-sha1", false, "", true, true},
+		{"HMACSHA1", true, "hmac-sha1", false, false},
+		{"hmac-md5", true, "", false, true},
+		{"hmac-md5.sig-alg.reg.int.", true, "", false, true},
+		{"gss-tsig", true, "", false, true},
+		{"", false, "", false, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseTSIGAlgorithm(tt.algorithm, tt.allowDeprecated)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseTSIGAlgorithm(%q, %v) = %q, %v", tt.algorithm, tt.allowDeprecated, got, err)
+		}
+		if errors.Is(err, ErrDeprecatedTSIGAlgorithm) != tt.wantDeprecated {
+			t.Errorf("ParseTSIGAlgorithm(%q) error %v, deprecated = %v", tt.algorithm, err, tt.wantDeprecated)
+		}
+	}
+}
+
+func TestTSIGAlgorithm(t *testing.T) {
+	tests := map[string]string{
+		"hmac-sha256":  dns.HmacSHA256,
+		"HMACSHA512":   dns.HmacSHA512,
+		"hmac-sha384.": dns.HmacSHA384,
+		"hmac-sha1":    dns.HmacSHA1,
+		"gss-tsig":     "gss-tsig.",
+	}
+	for algorithm, want := range tests {
+		if got := TSIGAlgorithm(algorithm); got != want {
+			t.Errorf("TSIGAlgorithm(%q) = %q, want %q", algorithm, got, want)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/miekg/dns"
+	"go.uber.org/zap"
+)
+
+// FunctionRating: 76/100
+// - Complexity: MEDIUM
+// - Integrations: 1 (dns library)
+// - External Risks: MEDIUM (resends rejected updates signed with other keys)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: sendKeys, TSIGKeyCache
+// Purpose: Fails over between TSIG keys during a rotation and remembers per server which key it accepts
+
+// TSIGCredential is a TSIG key a client can sign with
+type TSIGCredential struct {
+	KeyName   string
+	Algorithm string
+	Secret    string
+}
+
+// TSIGKeyCache remembers per server the TSIG key it last accepted, so the
+// clients created for later operations start with that key. A nil cache
+// remembers nothing.
+type TSIGKeyCache struct {
+	mu   sync.Mutex
+	keys map[string]string
+}
+
+// NewTSIGKeyCache creates an empty cache
+func NewTSIGKeyCache() *TSIGKeyCache {
+	return &TSIGKeyCache{keys: map[string]string{}}
+}
+
+// Get returns the name of the key server last accepted, or ""
+func (c *TSIGKeyCache) Get(server string) string {
+	if c == nil {
+		return ""
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.keys[server]
+}
+
+// Set records that server accepted the key named keyName
+func (c *TSIGKeyCache) Set(server, keyName string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.keys[server] = keyName
+}
+
+// SetTSIGFallbacks adds keys, in priority order, that messages are signed
+// with when the server rejects the key of the client with BADKEY or BADSIG.
+// Keys named like an earlier key are ignored.
+func (c *RFC2136Client) SetTSIGFallbacks(keys []TSIGCredential) {
+	c.tsigKeys = []TSIGCredential{{KeyName: c.tsigKey, Algorithm: c.tsigAlg, Secret: c.tsigSec}}
+	for _, key := range keys {
+		key.KeyName = dns.Fqdn(key.KeyName)
+		key.Algorithm = TSIGAlgorithm(key.Algorithm)
+		if c.keyIndex(key.KeyName) >= 0 {
+			continue
+		}
+		c.tsigKeys = append(c.tsigKeys, key)
+		c.client.TsigSecret[key.KeyName] = key.Secret
+	}
+	c.activeKey.Store(0)
+	c.useCachedKey()
+}
+
+// SetTSIGKeyCache shares the keys servers accepted with the other clients
+// of cache, and starts with the key cache holds for the server
+func (c *RFC2136Client) SetTSIGKeyCache(cache *TSIGKeyCache) {
+	c.keyCache = cache
+	c.useCachedKey()
+}
+
+// useCachedKey signs with the key the cache holds for the server, if the
+// client has it
+func (c *RFC2136Client) useCachedKey() {
+	if i := c.keyIndex(c.keyCache.Get(c.server)); i >= 0 {
+		c.activeKey.Store(int32(i))
+	}
+}
+
+// keyIndex returns the position of the key named name, or -1
+func (c *RFC2136Client) keyIndex(name string) int {
+	for i, key := range c.tsigKeys {
+		if strings.EqualFold(key.KeyName, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// signingKey returns the name and algorithm of the TSIG key messages are
+// signed with: the last key the server accepted
+func (c *RFC2136Client) signingKey() (string, string) {
+	if len(c.tsigKeys) == 0 {
+		return c.tsigKey, c.tsigAlg
+	}
+	key := c.tsigKeys[c.activeKey.Load()]
+	return key.KeyName, key.Algorithm
+}
+
+// keyRejected reports whether reply rejects the TSIG key of a message, as
+// servers do for a key they do not know or whose secret differs
+func keyRejected(reply *dns.Msg) bool {
+	if reply == nil || reply.Rcode != dns.RcodeNotAuth {
+		return false
+	}
+	code := tsigError(reply)
+	return code == dns.RcodeBadKey || code == dns.RcodeBadSig
+}
+
+// sendKeys sends msg like sendTSIG. When the server rejects the key msg is
+// signed with, msg is signed with the next key of the client and sent again
+// until a key is accepted or every key was tried. An accepted fallback key
+// signs the later messages of the client and, through the key cache, of
+// every client of the server.
+func (c *RFC2136Client) sendKeys(ctx context.Context, msg *dns.Msg) (*dns.Msg, error) {
+	tsig := msg.IsTsig()
+	if tsig == nil || len(c.tsigKeys) < 2 {
+		return c.sendTSIG(ctx, msg)
+	}
+	index := max(c.keyIndex(tsig.Hdr.Name), 0)
+	for tried := 1; ; tried++ {
+		next := msg
+		if tried < len(c.tsigKeys) {
+			// Signing strips the TSIG RR, keep msg intact for the next key
+			next = msg.Copy()
+		}
+		reply, err := c.sendTSIG(ctx, next)
+		if err != nil || ctx.Err() != nil || !keyRejected(reply) {
+			if err == nil && tried > 1 {
+				c.useKey(index)
+			}
+			return reply, err
+		}
+		if tried == len(c.tsigKeys) {
+			return reply, err
+		}
+
+		rejected := c.tsigKeys[index].KeyName
+		index = (index + 1) % len(c.tsigKeys)
+		key := c.tsigKeys[index]
+		c.logger.Warn("TSIG key rejected by server, trying the next key",
+			zap.String("server", c.server),
+			zap.String("rejected_key", rejected),
+			zap.String("tsig_error", dns.RcodeToString[tsigError(reply)]),
+			zap.String("next_key", key.KeyName),
+		)
+		tsig = msg.IsTsig()
+		tsig.Hdr.Name = key.KeyName
+		tsig.Algorithm = key.Algorithm
+		tsig.TimeSigned = uint64(c.tsigTime())
+	}
+}
+
+// useKey makes the key at index sign the later messages to the server
+func (c *RFC2136Client) useKey(index int) {
+	if c.activeKey.Swap(int32(index)) == int32(index) {
+		return
+	}
+	key := c.tsigKeys[index].KeyName
+	c.keyCache.Set(c.server, key)
+	c.logger.Info("Server accepted fallback TSIG key, using it from now on",
+		zap.String("server", c.server),
+		zap.String("key", key),
+	)
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/miekg/dns"
+	"go.uber.org/zap"
+
+	"github.com/rieset/istio-dns01-bind9/internal/dnstest"
+)
+
+// otherSecret is a TSIG secret the test servers do not know
+const otherSecret = "b2xkLXNlY3JldC1iZWZvcmUtdGhlLXJvdGF0aW9uISE="
+
+func TestTSIGKeyFailover(t *testing.T) {
+	tests := []struct {
+		name string
+		// serverKeys are the keys the server knows besides TestKeyName
+		serverKeys map[string]string
+		// primary is the key of the client, tried before TestKeyName
+		primary, primarySecret string
+	}{
+		{"unknown key", nil, "old-key", otherSecret},
+		{"wrong secret", map[string]string{"old-key": dnstest.TestSecret}, "old-key", otherSecret},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := dnstest.NewServer("example.com")
+			srv.AddTSIGKey(dnstest.TestKeyName, dnstest.TestSecret)
+			for name, secret := range tt.serverKeys {
+				srv.AddTSIGKey(name, secret)
+			}
+			if err := srv.Start(); err != nil {
+				t.Fatalf("failed to start test server: %v", err)
+			}
+			t.Cleanup(func() { _ = srv.Close() })
+
+			cache := NewTSIGKeyCache()
+			newClient := func() *RFC2136Client {
+				c := NewRFC2136Client(srv.Addr(), "example.com", tt.primary, "hmac-sha256", tt.primarySecret, zap.NewNop())
+				c.SetTSIGFallbacks([]TSIGCredential{{KeyName: dnstest.TestKeyName, Algorithm: "hmac-sha256", Secret: dnstest.TestSecret}})
+				c.SetTSIGKeyCache(cache)
+				return c
+			}
+			c := newClient()
+			ctx := context.Background()
+
+			if err := c.AddTXTRecord(ctx, testFQDN, "token-1", 60); err != nil {
+				t.Fatalf("AddTXTRecord: %v", err)
+			}
+			if got := srv.Updates(); got != 2 {
+				t.Fatalf("updates after the first add = %d, want the rejected and the accepted one", got)
+			}
+			// The accepted key signs later messages of the client and of new clients
+			if err := c.AddTXTRecord(ctx, testFQDN, "token-2", 60); err != nil {
+				t.Fatalf("second AddTXTRecord: %v", err)
+			}
+			if err := newClient().DeleteTXTRecordValue(ctx, testFQDN, "token-1"); err != nil {
+				t.Fatalf("DeleteTXTRecordValue: %v", err)
+			}
+			if got := srv.Updates(); got != 4 {
+				t.Fatalf("updates = %d, want 4 with the remembered key", got)
+			}
+			if got := cache.Get(srv.Addr()); got != dnstest.TestKeyName {
+				t.Fatalf("cached key = %q, want %s", got, dnstest.TestKeyName)
+			}
+			if got := srv.TXT(testFQDN); len(got) != 1 || got[0] != "token-2" {
+				t.Fatalf("TXT = %v, want token-2", got)
+			}
+		})
+	}
+}
+
+func TestTSIGKeyFailoverExhausted(t *testing.T) {
+	srv := startServer(t)
+	c := NewRFC2136Client(srv.Addr(), "example.com", "old-key", "hmac-sha256", otherSecret, zap.NewNop())
+	c.SetTSIGFallbacks([]TSIGCredential{
+		{KeyName: "other-key", Algorithm: "hmac-sha256", Secret: otherSecret},
+		{KeyName: "OLD-KEY.", Algorithm: "hmac-sha256", Secret: dnstest.TestSecret},
+	})
+
+	err := c.AddTXTRecord(context.Background(), testFQDN, "token", 60)
+	var rcodeErr *RcodeError
+	if !errors.As(err, &rcodeErr) || rcodeErr.TSIGError != dns.RcodeBadKey {
+		t.Fatalf("AddTXTRecord error = %v, want a BADKEY rejection", err)
+	}
+	// The duplicate of the primary key is ignored
+	if got := srv.Updates(); got != 2 {
+		t.Fatalf("updates = %d, want one per distinct key", got)
+	}
+	if name, _ := c.signingKey(); name != "old-key." {
+		t.Fatalf("signing key = %s, want the primary key after every key failed", name)
+	}
+}
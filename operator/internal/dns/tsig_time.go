@@ -0,0 +1,142 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/miekg/dns"
+	"go.uber.org/zap"
+)
+
+// FunctionRating: 78/100
+// - Complexity: LOW
+// - Integrations: 1 (dns library)
+// - External Risks: MEDIUM (shifts the signing time of every later message of the client)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: sendTSIG
+// Purpose: Recognizes TSIG rejections and recovers from clock skew with one message signed on the server's time
+
+// tsigError returns the TSIG error of reply, such as dns.RcodeBadTime, or
+// zero when reply carries no TSIG or the server accepted it
+func tsigError(reply *dns.Msg) int {
+	if reply == nil {
+		return 0
+	}
+	if tsig := reply.IsTsig(); tsig != nil {
+		return int(tsig.Error)
+	}
+	return 0
+}
+
+// notAuthReply reports whether reply, whose exchange failed with err, is a
+// NOTAUTH answer. The dns library refuses to verify the TSIG of those and
+// returns dns.ErrAuth, which would hide the TSIG error and make a rejected
+// key look like a transport failure worth retrying; as a failure the reply
+// can be used unverified.
+func notAuthReply(reply *dns.Msg, err error) bool {
+	return errors.Is(err, dns.ErrAuth) && reply != nil && reply.Rcode == dns.RcodeNotAuth
+}
+
+// serverClock returns the current time of the server in seconds, which a
+// BADTIME reply carries in the other data of its TSIG (RFC 8945 section
+// 5.2.3). Like every NOTAUTH reply it is not verified, so a forged one can
+// at worst make the single retry of sendTSIG fail.
+func serverClock(reply *dns.Msg) (int64, bool) {
+	if reply == nil || tsigError(reply) != dns.RcodeBadTime {
+		return 0, false
+	}
+	raw, err := hex.DecodeString(reply.IsTsig().OtherData)
+	if err != nil || len(raw) != 6 {
+		return 0, false
+	}
+	var now int64
+	for _, b := range raw {
+		now = now<<8 | int64(b)
+	}
+	return now, true
+}
+
+// clockOffsetKey is the context key of the skew to the server's clock
+type clockOffsetKey struct{}
+
+// withClockOffset makes the exchanges under ctx accept replies signed on a
+// clock offset seconds ahead of the local one; zero leaves ctx unchanged
+func withClockOffset(ctx context.Context, offset int64) context.Context {
+	if offset == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, clockOffsetKey{}, offset)
+}
+
+// skewedReply reports whether reply, whose verification failed with err, is
+// signed within its fudge of the server clock set on ctx by withClockOffset.
+// The dns library checks the time only after the MAC, against the local
+// clock, so such a reply is authentic.
+func skewedReply(ctx context.Context, reply *dns.Msg, err error) bool {
+	offset, ok := ctx.Value(clockOffsetKey{}).(int64)
+	if !ok || !errors.Is(err, dns.ErrTime) || reply == nil || reply.IsTsig() == nil {
+		return false
+	}
+	tsig := reply.IsTsig()
+	skew := int64(tsig.TimeSigned) - time.Now().Unix() - offset
+	return skew <= int64(tsig.Fudge) && -skew <= int64(tsig.Fudge)
+}
+
+// tsigTime returns the time messages are signed at: the local clock
+// corrected by the skew learned from a BADTIME reply, if any
+func (c *RFC2136Client) tsigTime() int64 {
+	return time.Now().Unix() + c.clockOffset.Load()
+}
+
+// sendTSIG sends msg like send. When the server rejects its signing time
+// with BADTIME, the skew to the server's clock is taken from the reply and
+// logged, and msg is sent once more signed at the server's time. The skew is
+// kept for every later message of the client.
+func (c *RFC2136Client) sendTSIG(ctx context.Context, msg *dns.Msg) (*dns.Msg, error) {
+	tsig := msg.IsTsig()
+	reply, err := c.send(ctx, msg)
+	if tsig == nil || err != nil || ctx.Err() != nil {
+		return reply, err
+	}
+	now, ok := serverClock(reply)
+	if !ok {
+		return reply, err
+	}
+
+	skew := now - time.Now().Unix()
+	c.clockOffset.Store(skew)
+	c.logger.Warn("TSIG time rejected by server, retrying on its clock",
+		zap.String("server", c.server),
+		zap.Duration("skew", time.Duration(skew)*time.Second),
+		zap.Uint16("fudge_seconds", tsig.Fudge),
+		zap.String("hint", "synchronize the clocks of the webhook and the server with NTP, or raise tsigFudge"),
+	)
+	// Signing strips the TSIG RR from a message sent over TCP
+	if msg.IsTsig() == nil {
+		msg.Extra = append(msg.Extra, tsig)
+	}
+	tsig.TimeSigned = uint64(c.tsigTime())
+	return c.send(ctx, msg)
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"go.uber.org/zap"
+
+	"github.com/rieset/istio-dns01-bind9/internal/dnstest"
+)
+
+func TestClockSkewRetry(t *testing.T) {
+	tests := []struct {
+		name      string
+		skew      time.Duration
+		fudge     uint16
+		transport Transport
+		// wantUpdates counts the updates of the first and the second add
+		wantUpdates [2]int
+	}{
+		{"in sync", 0, 300, TransportAuto, [2]int{1, 2}},
+		{"server ahead over udp", time.Hour, 300, TransportAuto, [2]int{2, 3}},
+		{"server behind over tcp", -time.Hour, 300, TransportTCP, [2]int{2, 3}},
+		{"fudge covers the skew", time.Hour, 7200, TransportAuto, [2]int{1, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := startServer(t)
+			srv.SetClockSkew(tt.skew)
+			c := newTestClient(srv, dnstest.TestSecret)
+			c.SetTransport(tt.transport)
+			quirks := DefaultQuirks
+			quirks.TSIGFudge = tt.fudge
+			c.SetQuirks(quirks)
+			ctx := context.Background()
+
+			if err := c.AddTXTRecord(ctx, testFQDN, "token-1", 60); err != nil {
+				t.Fatalf("AddTXTRecord: %v", err)
+			}
+			if got := srv.Updates(); got != tt.wantUpdates[0] {
+				t.Fatalf("updates after the first add = %d, want %d", got, tt.wantUpdates[0])
+			}
+			// The learned skew signs later messages on the server's clock
+			if err := c.AddTXTRecord(ctx, testFQDN, "token-2", 60); err != nil {
+				t.Fatalf("second AddTXTRecord: %v", err)
+			}
+			if got := srv.Updates(); got != tt.wantUpdates[1] {
+				t.Fatalf("updates after the second add = %d, want %d", got, tt.wantUpdates[1])
+			}
+			if got := srv.Records(testFQDN, dns.TypeTXT); len(got) != 2 {
+				t.Fatalf("records = %v, want both tokens", got)
+			}
+		})
+	}
+}
+
+func TestTSIGRejection(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		secret  string
+		wantErr int
+		want    string
+	}{
+		{"wrong secret", dnstest.TestKeyName, "c2VjcmV0LXRoYXQtZG9lcy1ub3QtbWF0Y2g=", dns.RcodeBadSig, "secret or algorithm"},
+		{"unknown key", "other-key.", dnstest.TestSecret, dns.RcodeBadKey, "key name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := startServer(t)
+			c := NewRFC2136Client(srv.Addr(), "example.com", tt.key, "hmac-sha256", tt.secret, zap.NewNop())
+			c.SetRetryPolicy(RetryPolicy{Attempts: 3})
+
+			err := c.AddTXTRecord(context.Background(), testFQDN, "token", 60)
+			var rcodeErr *RcodeError
+			if !errors.As(err, &rcodeErr) || rcodeErr.TSIGError != tt.wantErr {
+				t.Fatalf("AddTXTRecord error = %v, want TSIG error %s", err, dns.RcodeToString[tt.wantErr])
+			}
+			if !IsPermanent(err) || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("error = %v, want a permanent error mentioning %q", err, tt.want)
+			}
+			if got := srv.Updates(); got != 1 {
+				t.Fatalf("updates = %d, want the rejected one only", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/rieset/istio-dns01-bind9/internal/metrics"
+)
+
+// FunctionRating: 80/100
+// - Complexity: LOW
+// - Integrations: 1 (metrics)
+// - External Risks: LOW (only delays updates, never drops them)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: UpdateLimiter
+// Purpose: Per-server token bucket and in-flight cap on the UPDATE messages sent to DNS servers
+
+// serverLimit is the token bucket and in-flight slots of one server
+type serverLimit struct {
+	// tokens paces the updates; nil when the rate is unlimited
+	tokens *rate.Limiter
+	// slots holds one entry per update in flight; nil when unbounded
+	slots chan struct{}
+}
+
+// UpdateLimiter paces the UPDATE messages sent to each server with a token
+// bucket and caps how many are in flight at once, so a burst of challenges
+// queues in the webhook instead of flooding the primaries. Every attempt,
+// retries included, waits for a token and a slot. A limiter is safe for
+// concurrent use and shared by every client it is set on; a nil limiter
+// admits every update immediately.
+type UpdateLimiter struct {
+	mu          sync.Mutex
+	servers     map[string]*serverLimit
+	rate        rate.Limit
+	burst       int
+	maxInFlight int
+}
+
+// NewUpdateLimiter creates a limiter allowing perSecond updates per server
+// with bursts of burst, and at most maxInFlight updates per server at once.
+// A zero perSecond leaves the rate unlimited and a zero maxInFlight the
+// concurrency; a zero burst allows one second worth of updates. It returns
+// nil when neither is limited.
+func NewUpdateLimiter(perSecond float64, burst, maxInFlight int) *UpdateLimiter {
+	if perSecond <= 0 && maxInFlight <= 0 {
+		return nil
+	}
+	l := &UpdateLimiter{servers: make(map[string]*serverLimit), rate: rate.Inf, maxInFlight: maxInFlight}
+	if perSecond > 0 {
+		l.rate = rate.Limit(perSecond)
+		l.burst = burst
+		if l.burst <= 0 {
+			l.burst = int(math.Ceil(perSecond))
+		}
+	}
+	return l
+}
+
+// acquire waits until server may receive another update and returns the
+// function ending it. The wait is observed in the update wait metric.
+func (l *UpdateLimiter) acquire(ctx context.Context, server string) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	limit := l.server(server)
+	start := time.Now()
+	if limit.slots != nil {
+		select {
+		case limit.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, fmt.Errorf("waiting for an update slot on %s: %w", server, ctx.Err())
+		}
+	}
+	if limit.tokens != nil {
+		if err := limit.tokens.Wait(ctx); err != nil {
+			if limit.slots != nil {
+				<-limit.slots
+			}
+			return nil, fmt.Errorf("waiting for the update rate of %s: %w", server, err)
+		}
+	}
+	metrics.DNSUpdateWaitSeconds.WithLabelValues(server).Observe(time.Since(start).Seconds())
+	inFlight := metrics.DNSUpdatesInFlight.WithLabelValues(server)
+	inFlight.Inc()
+	return func() {
+		inFlight.Dec()
+		if limit.slots != nil {
+			<-limit.slots
+		}
+	}, nil
+}
+
+// server returns the limits of server, creating them on first use
+func (l *UpdateLimiter) server(server string) *serverLimit {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	limit, ok := l.servers[server]
+	if !ok {
+		limit = &serverLimit{}
+		if l.rate != rate.Inf {
+			limit.tokens = rate.NewLimiter(l.rate, l.burst)
+		}
+		if l.maxInFlight > 0 {
+			limit.slots = make(chan struct{}, l.maxInFlight)
+		}
+		l.servers[server] = limit
+	}
+	return limit
+}
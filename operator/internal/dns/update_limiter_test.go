@@ -0,0 +1,129 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rieset/istio-dns01-bind9/internal/dnstest"
+)
+
+func TestUpdateLimiterDisabled(t *testing.T) {
+	if l := NewUpdateLimiter(0, 10, 0); l != nil {
+		t.Fatal("NewUpdateLimiter without a rate or cap returned a limiter")
+	}
+	var l *UpdateLimiter
+	release, err := l.acquire(context.Background(), "ns1:53")
+	if err != nil {
+		t.Fatalf("acquire on a nil limiter: %v", err)
+	}
+	release()
+}
+
+func TestUpdateLimiterInFlight(t *testing.T) {
+	l := NewUpdateLimiter(0, 0, 2)
+	ctx := context.Background()
+	first, err := l.acquire(ctx, "ns1:53")
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	second, err := l.acquire(ctx, "ns1:53")
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+
+	// Other servers have their own slots
+	other, err := l.acquire(ctx, "ns2:53")
+	if err != nil {
+		t.Fatalf("acquire on another server: %v", err)
+	}
+	other()
+
+	short, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := l.acquire(short, "ns1:53"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("acquire beyond the cap = %v, want a deadline error", err)
+	}
+
+	acquired := make(chan struct{})
+	go func() {
+		release, err := l.acquire(ctx, "ns1:53")
+		if err == nil {
+			release()
+		}
+		close(acquired)
+	}()
+	first()
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("waiting update did not get the released slot")
+	}
+	second()
+}
+
+func TestUpdateLimiterRate(t *testing.T) {
+	l := NewUpdateLimiter(20, 2, 0)
+	ctx := context.Background()
+	start := time.Now()
+	for range 4 {
+		release, err := l.acquire(ctx, "ns1:53")
+		if err != nil {
+			t.Fatalf("acquire: %v", err)
+		}
+		release()
+	}
+	// A burst of two passes at once, the other two wait 50ms each
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Fatalf("four updates at 20/s with a burst of 2 took %v, want at least 100ms", elapsed)
+	}
+
+	// A wait longer than the deadline fails at once
+	short, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	slow := NewUpdateLimiter(0.1, 1, 0)
+	release, err := slow.acquire(short, "ns1:53")
+	if err != nil {
+		t.Fatalf("acquire within the burst: %v", err)
+	}
+	release()
+	if _, err := slow.acquire(short, "ns1:53"); err == nil {
+		t.Fatal("acquire beyond the rate succeeded before the deadline")
+	}
+}
+
+func TestRFC2136ClientUpdateLimiter(t *testing.T) {
+	srv := startServer(t)
+	c := newTestClient(srv, dnstest.TestSecret)
+	c.SetUpdateLimiter(NewUpdateLimiter(0.001, 1, 0))
+	ctx := context.Background()
+
+	if err := c.AddTXTRecord(ctx, testFQDN, "token-1", 60); err != nil {
+		t.Fatalf("AddTXTRecord: %v", err)
+	}
+	short, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if err := c.AddTXTRecord(short, testFQDN, "token-2", 60); err == nil {
+		t.Fatal("second update was not held back by the rate limit")
+	}
+	if got := srv.TXT(testFQDN); len(got) != 1 {
+		t.Fatalf("TXT = %v, want only the first value", got)
+	}
+}
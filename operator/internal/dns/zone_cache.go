@@ -0,0 +1,190 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+	"go.uber.org/zap"
+
+	"github.com/rieset/istio-dns01-bind9/internal/metrics"
+)
+
+// FunctionRating: 80/100
+// - Complexity: MEDIUM
+// - Integrations: 2 (dns library, metrics)
+// - External Risks: LOW (falls back to live queries on miss)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: ZoneCache
+// Purpose: TTL-aware cache for SOA lookups and zone-apex discovery results
+
+const (
+	cacheKindSOA  = "soa"
+	cacheKindZone = "zone"
+
+	// DefaultZoneCacheMinTTL is the lower bound applied to SOA TTLs
+	DefaultZoneCacheMinTTL = 30 * time.Second
+	// DefaultZoneCacheMaxTTL is the upper bound applied to SOA TTLs
+	DefaultZoneCacheMaxTTL = 1 * time.Hour
+)
+
+type zoneCacheEntry struct {
+	soa     *dns.SOA
+	expires time.Time
+}
+
+// ZoneCache caches SOA records and zone-discovery results per server.
+// Entries expire after the SOA record TTL, clamped to [minTTL, maxTTL].
+type ZoneCache struct {
+	mu      sync.RWMutex
+	soa     map[string]zoneCacheEntry
+	zones   map[string]zoneCacheEntry
+	minTTL  time.Duration
+	maxTTL  time.Duration
+	timeout time.Duration
+	logger  *zap.Logger
+	// now returns the current time, replaced in tests
+	now func() time.Time
+}
+
+// NewZoneCache creates a new zone cache with default TTL bounds
+func NewZoneCache(logger *zap.Logger) *ZoneCache {
+	return &ZoneCache{
+		soa:     make(map[string]zoneCacheEntry),
+		zones:   make(map[string]zoneCacheEntry),
+		minTTL:  DefaultZoneCacheMinTTL,
+		maxTTL:  DefaultZoneCacheMaxTTL,
+		timeout: 10 * time.Second,
+		logger:  logger,
+		now:     time.Now,
+	}
+}
+
+// LookupSOA returns the SOA record of zone as served by server, querying the
+// server only when no unexpired entry is cached
+func (c *ZoneCache) LookupSOA(ctx context.Context, server, zone string) (*dns.SOA, error) {
+	key := cacheKey(server, zone)
+	if soa, ok := c.get(c.soa, key, cacheKindSOA); ok {
+		return soa, nil
+	}
+
+	soa, err := QuerySOA(ctx, server, zone, c.timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	c.put(c.soa, key, soa, cacheKindSOA)
+	return soa, nil
+}
+
+// FindZone returns the apex of the zone enclosing fqdn as served by server,
+// running zone discovery only when no unexpired entry is cached
+func (c *ZoneCache) FindZone(ctx context.Context, server, fqdn string) (string, error) {
+	key := cacheKey(server, fqdn)
+	if soa, ok := c.get(c.zones, key, cacheKindZone); ok {
+		return soa.Hdr.Name, nil
+	}
+
+	soa, err := DiscoverZone(ctx, server, fqdn, c.timeout)
+	if err != nil {
+		return "", err
+	}
+
+	c.logger.Debug("Discovered zone apex",
+		zap.String("fqdn", fqdn),
+		zap.String("zone", soa.Hdr.Name),
+		zap.String("server", server),
+	)
+	c.put(c.zones, key, soa, cacheKindZone)
+	c.put(c.soa, cacheKey(server, soa.Hdr.Name), soa, cacheKindSOA)
+	return soa.Hdr.Name, nil
+}
+
+// Invalidate drops all cached entries for the given zone on every server
+func (c *ZoneCache) Invalidate(zone string) {
+	zone = dns.Fqdn(strings.ToLower(zone))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, entry := range c.soa {
+		if strings.EqualFold(entry.soa.Hdr.Name, zone) {
+			delete(c.soa, key)
+		}
+	}
+	for key, entry := range c.zones {
+		if strings.EqualFold(entry.soa.Hdr.Name, zone) {
+			delete(c.zones, key)
+		}
+	}
+	metrics.ZoneCacheEntries.WithLabelValues(cacheKindSOA).Set(float64(len(c.soa)))
+	metrics.ZoneCacheEntries.WithLabelValues(cacheKindZone).Set(float64(len(c.zones)))
+}
+
+// get returns a cached SOA if present and not expired, recording hit/miss metrics
+func (c *ZoneCache) get(entries map[string]zoneCacheEntry, key, kind string) (*dns.SOA, bool) {
+	c.mu.RLock()
+	entry, ok := entries[key]
+	c.mu.RUnlock()
+
+	if !ok || c.now().After(entry.expires) {
+		metrics.ZoneCacheLookups.WithLabelValues(kind, "miss").Inc()
+		return nil, false
+	}
+
+	metrics.ZoneCacheLookups.WithLabelValues(kind, "hit").Inc()
+	return entry.soa, true
+}
+
+// put stores soa under key with an expiry derived from the record TTL
+func (c *ZoneCache) put(entries map[string]zoneCacheEntry, key string, soa *dns.SOA, kind string) {
+	ttl := time.Duration(soa.Hdr.Ttl) * time.Second
+	if ttl < c.minTTL {
+		ttl = c.minTTL
+	}
+	if ttl > c.maxTTL {
+		ttl = c.maxTTL
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entries[key] = zoneCacheEntry{soa: soa, expires: c.now().Add(ttl)}
+	c.evictExpired(entries)
+	metrics.ZoneCacheEntries.WithLabelValues(kind).Set(float64(len(entries)))
+}
+
+// evictExpired removes expired entries; caller must hold the write lock
+func (c *ZoneCache) evictExpired(entries map[string]zoneCacheEntry) {
+	now := c.now()
+	for key, entry := range entries {
+		if now.After(entry.expires) {
+			delete(entries, key)
+		}
+	}
+}
+
+// cacheKey builds a case-insensitive cache key for a server/name pair
+func cacheKey(server, name string) string {
+	return server + "|" + dns.Fqdn(strings.ToLower(name))
+}
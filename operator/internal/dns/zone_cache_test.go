@@ -0,0 +1,135 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"go.uber.org/zap"
+)
+
+// newTestZoneCache returns a zone cache whose clock only moves when the returned func advances it
+func newTestZoneCache() (*ZoneCache, func(time.Duration)) {
+	c := NewZoneCache(zap.NewNop())
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+	return c, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestZoneCacheLookupSOA(t *testing.T) {
+	srv := startServer(t)
+	c, advance := newTestZoneCache()
+	ctx := context.Background()
+
+	soa, err := c.LookupSOA(ctx, srv.Addr(), "example.com")
+	if err != nil {
+		t.Fatalf("LookupSOA: %v", err)
+	}
+	if soa.Hdr.Name != "example.com." {
+		t.Fatalf("LookupSOA returned the SOA of %s", soa.Hdr.Name)
+	}
+	queries := srv.Queries()
+
+	// The SOA of the test server has a TTL of 300s
+	advance(299 * time.Second)
+	if _, err := c.LookupSOA(ctx, srv.Addr(), "EXAMPLE.COM."); err != nil {
+		t.Fatalf("LookupSOA: %v", err)
+	}
+	if srv.Queries() != queries {
+		t.Fatal("LookupSOA queried the server within the TTL")
+	}
+
+	advance(2 * time.Second)
+	if _, err := c.LookupSOA(ctx, srv.Addr(), "example.com"); err != nil {
+		t.Fatalf("LookupSOA: %v", err)
+	}
+	if srv.Queries() == queries {
+		t.Fatal("LookupSOA served an expired entry")
+	}
+}
+
+func TestZoneCacheTTLClamps(t *testing.T) {
+	tests := []struct {
+		ttl  uint32
+		want time.Duration
+	}{
+		{0, DefaultZoneCacheMinTTL},
+		{5, DefaultZoneCacheMinTTL},
+		{300, 300 * time.Second},
+		{86400, DefaultZoneCacheMaxTTL},
+	}
+	for _, tt := range tests {
+		c, _ := newTestZoneCache()
+		soa := &dns.SOA{Hdr: dns.RR_Header{Name: "example.com.", Rrtype: dns.TypeSOA, Class: dns.ClassINET, Ttl: tt.ttl}}
+		c.put(c.soa, cacheKey("server", "example.com"), soa, cacheKindSOA)
+		if got := c.soa[cacheKey("server", "example.com")].expires.Sub(c.now()); got != tt.want {
+			t.Errorf("TTL %d cached for %s, want %s", tt.ttl, got, tt.want)
+		}
+	}
+}
+
+func TestZoneCacheFindZone(t *testing.T) {
+	srv := startServer(t)
+	c, _ := newTestZoneCache()
+	ctx := context.Background()
+
+	zone, err := c.FindZone(ctx, srv.Addr(), testFQDN)
+	if err != nil {
+		t.Fatalf("FindZone: %v", err)
+	}
+	if zone != "example.com." {
+		t.Fatalf("FindZone = %s, want example.com.", zone)
+	}
+	queries := srv.Queries()
+	if zone, err := c.FindZone(ctx, srv.Addr(), testFQDN); err != nil || zone != "example.com." {
+		t.Fatalf("FindZone = %s, %v", zone, err)
+	}
+	// Discovery fills the SOA cache of the apex it found
+	if _, err := c.LookupSOA(ctx, srv.Addr(), zone); err != nil {
+		t.Fatalf("LookupSOA: %v", err)
+	}
+	if srv.Queries() != queries {
+		t.Fatal("lookups after FindZone queried the server")
+	}
+}
+
+func TestZoneCacheInvalidate(t *testing.T) {
+	srv := startServer(t)
+	c, _ := newTestZoneCache()
+	ctx := context.Background()
+
+	if _, err := c.FindZone(ctx, srv.Addr(), testFQDN); err != nil {
+		t.Fatalf("FindZone: %v", err)
+	}
+	other := &dns.SOA{Hdr: dns.RR_Header{Name: "example.org.", Rrtype: dns.TypeSOA, Class: dns.ClassINET, Ttl: 300}}
+	c.put(c.soa, cacheKey(srv.Addr(), "example.org"), other, cacheKindSOA)
+
+	c.Invalidate("EXAMPLE.COM")
+	if len(c.zones) != 0 || len(c.soa) != 1 {
+		t.Fatalf("Invalidate left %d zone and %d SOA entries, want 0 and the one of example.org", len(c.zones), len(c.soa))
+	}
+	queries := srv.Queries()
+	if _, err := c.LookupSOA(ctx, srv.Addr(), "example.com"); err != nil {
+		t.Fatalf("LookupSOA: %v", err)
+	}
+	if srv.Queries() == queries {
+		t.Fatal("LookupSOA served an invalidated entry")
+	}
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dnstest
+
+import (
+	"crypto"
+	"fmt"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// zoneSigner signs the answers of one zone like an inline-signing server
+type zoneSigner struct {
+	key     *dns.DNSKEY
+	private crypto.Signer
+	// paused withholds signatures, as a server that has not re-signed yet
+	paused bool
+}
+
+// SignZone makes the server answer queries for zone that set the DNSSEC OK
+// bit with RRSIGs of a new ECDSA P-256 key, and serve that key as the DNSKEY
+// RRset of the zone. It returns the key.
+func (s *Server) SignZone(zone string) (*dns.DNSKEY, error) {
+	zone = canonical(zone)
+	key := &dns.DNSKEY{
+		Hdr:       dns.RR_Header{Name: zone, Rrtype: dns.TypeDNSKEY, Class: dns.ClassINET, Ttl: 3600},
+		Flags:     257,
+		Protocol:  3,
+		Algorithm: dns.ECDSAP256SHA256,
+	}
+	private, err := key.Generate(256)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate zone key: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.signers[zone] = &zoneSigner{key: key, private: private.(crypto.Signer)}
+	return key, nil
+}
+
+// SetSigningPaused makes the answers of a zone passed to SignZone carry no
+// RRSIGs while paused is true
+func (s *Server) SetSigningPaused(zone string, paused bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if signer, ok := s.signers[canonical(zone)]; ok {
+		signer.paused = paused
+	}
+}
+
+// signAnswerLocked adds the DNSKEY RRset to an apex DNSKEY query of a signed
+// zone and, unless signing is paused, an RRSIG per RRset of the answer when
+// req asks for DNSSEC records; caller must hold the lock
+func (s *Server) signAnswerLocked(zone string, req, reply *dns.Msg) {
+	signer, ok := s.signers[zone]
+	if !ok {
+		return
+	}
+	q := req.Question[0]
+	if q.Qtype == dns.TypeDNSKEY && canonical(q.Name) == zone {
+		reply.Answer = append(reply.Answer, dns.Copy(signer.key))
+		reply.Ns = nil
+	}
+	opt := req.IsEdns0()
+	if opt == nil || !opt.Do() || signer.paused || len(reply.Answer) == 0 {
+		return
+	}
+	reply.SetEdns0(opt.UDPSize(), true)
+
+	rrsets := map[uint16][]dns.RR{}
+	var order []uint16
+	for _, rr := range reply.Answer {
+		rrtype := rr.Header().Rrtype
+		if _, seen := rrsets[rrtype]; !seen {
+			order = append(order, rrtype)
+		}
+		rrsets[rrtype] = append(rrsets[rrtype], rr)
+	}
+	now := time.Now()
+	for _, rrtype := range order {
+		rrset := rrsets[rrtype]
+		sig := &dns.RRSIG{
+			Hdr:        dns.RR_Header{Name: rrset[0].Header().Name, Rrtype: dns.TypeRRSIG, Class: dns.ClassINET, Ttl: rrset[0].Header().Ttl},
+			Algorithm:  signer.key.Algorithm,
+			KeyTag:     signer.key.KeyTag(),
+			SignerName: zone,
+			Inception:  uint32(now.Add(-time.Hour).Unix()),
+			Expiration: uint32(now.Add(24 * time.Hour).Unix()),
+		}
+		if err := sig.Sign(signer.private, rrset); err == nil {
+			reply.Answer = append(reply.Answer, sig)
+		}
+	}
+}
@@ -0,0 +1,268 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dnstest
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+func exchange(t *testing.T, srv *Server, msg *dns.Msg) *dns.Msg {
+	t.Helper()
+	client := &dns.Client{Timeout: time.Second}
+	reply, _, err := client.Exchange(msg, srv.Addr())
+	if err != nil {
+		t.Fatalf("exchange failed: %v", err)
+	}
+	return reply
+}
+
+func TestServerQueries(t *testing.T) {
+	srv := NewServer("example.com")
+	if err := srv.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = srv.Close() }()
+	srv.SetTXT("a.example.com", 60, "v1")
+
+	tests := []struct {
+		name   string
+		qname  string
+		qtype  uint16
+		rcode  int
+		answer int
+	}{
+		{"existing TXT", "a.example.com.", dns.TypeTXT, dns.RcodeSuccess, 1},
+		{"zone apex SOA", "example.com.", dns.TypeSOA, dns.RcodeSuccess, 1},
+		{"missing name", "b.example.com.", dns.TypeTXT, dns.RcodeNameError, 0},
+		{"existing name other type", "a.example.com.", dns.TypeA, dns.RcodeSuccess, 0},
+		{"outside zone", "example.org.", dns.TypeTXT, dns.RcodeRefused, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := new(dns.Msg)
+			msg.SetQuestion(tt.qname, tt.qtype)
+			reply := exchange(t, srv, msg)
+			if reply.Rcode != tt.rcode || len(reply.Answer) != tt.answer {
+				t.Fatalf("got rcode %d with %d answers, want rcode %d with %d answers",
+					reply.Rcode, len(reply.Answer), tt.rcode, tt.answer)
+			}
+		})
+	}
+}
+
+func TestServerRequiresTSIG(t *testing.T) {
+	srv := NewServer("example.com")
+	srv.AddTSIGKey(TestKeyName, TestSecret)
+	if err := srv.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = srv.Close() }()
+
+	msg := new(dns.Msg)
+	msg.SetUpdate("example.com.")
+	rr, _ := dns.NewRR(`a.example.com. 60 IN TXT "v1"`)
+	msg.Insert([]dns.RR{rr})
+
+	if reply := exchange(t, srv, msg); reply.Rcode != dns.RcodeNotAuth {
+		t.Fatalf("unsigned update got rcode %d, want NOTAUTH", reply.Rcode)
+	}
+	if got := srv.TXT("a.example.com"); len(got) != 0 {
+		t.Fatalf("unsigned update was applied: %v", got)
+	}
+	if srv.Serial("example.com") != 1 {
+		t.Fatalf("serial changed on a rejected update")
+	}
+}
+
+func TestServerPrerequisites(t *testing.T) {
+	const fqdn = "_acme-challenge.example.com."
+	srv := NewServer("example.com")
+	if err := srv.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = srv.Close() }()
+	srv.SetTXT(fqdn, 60, "existing")
+
+	tests := []struct {
+		name  string
+		rr    dns.RR
+		rcode int
+	}{
+		{"zone SOA exists", &dns.ANY{Hdr: dns.RR_Header{Name: "example.com.", Rrtype: dns.TypeSOA, Class: dns.ClassANY}}, dns.RcodeSuccess},
+		{"TXT exists", &dns.ANY{Hdr: dns.RR_Header{Name: fqdn, Rrtype: dns.TypeTXT, Class: dns.ClassANY}}, dns.RcodeSuccess},
+		{"TXT must not exist", &dns.ANY{Hdr: dns.RR_Header{Name: fqdn, Rrtype: dns.TypeTXT, Class: dns.ClassNONE}}, dns.RcodeYXRrset},
+		{"A must exist", &dns.ANY{Hdr: dns.RR_Header{Name: fqdn, Rrtype: dns.TypeA, Class: dns.ClassANY}}, dns.RcodeNXRrset},
+		{"name not in use", &dns.ANY{Hdr: dns.RR_Header{Name: "new.example.com.", Rrtype: dns.TypeANY, Class: dns.ClassNONE}}, dns.RcodeSuccess},
+		{"name in use", &dns.ANY{Hdr: dns.RR_Header{Name: "new.example.com.", Rrtype: dns.TypeANY, Class: dns.ClassANY}}, dns.RcodeNameError},
+		{"outside zone", &dns.ANY{Hdr: dns.RR_Header{Name: "example.org.", Rrtype: dns.TypeANY, Class: dns.ClassANY}}, dns.RcodeNotZone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := new(dns.Msg)
+			msg.SetUpdate("example.com.")
+			msg.Answer = append(msg.Answer, tt.rr)
+
+			reply := exchange(t, srv, msg)
+			if reply.Rcode != tt.rcode {
+				t.Fatalf("rcode = %s, want %s", dns.RcodeToString[reply.Rcode], dns.RcodeToString[tt.rcode])
+			}
+		})
+	}
+}
+
+func TestServerSOAUpdate(t *testing.T) {
+	srv := NewServer("example.com")
+	if err := srv.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = srv.Close() }()
+
+	update := func(serial uint32) {
+		msg := new(dns.Msg)
+		msg.SetUpdate("example.com.")
+		rr, _ := dns.NewRR(fmt.Sprintf("example.com. 600 IN SOA ns.example.net. admin.example.com. %d 100 50 1000 30", serial))
+		msg.Insert([]dns.RR{rr})
+		if reply := exchange(t, srv, msg); reply.Rcode != dns.RcodeSuccess {
+			t.Fatalf("update got rcode %d", reply.Rcode)
+		}
+	}
+	query := func() *dns.SOA {
+		msg := new(dns.Msg)
+		msg.SetQuestion("example.com.", dns.TypeSOA)
+		reply := exchange(t, srv, msg)
+		if len(reply.Answer) != 1 {
+			t.Fatalf("got %d answers, want the SOA", len(reply.Answer))
+		}
+		return reply.Answer[0].(*dns.SOA)
+	}
+
+	// A serial that does not increase is ignored
+	update(1)
+	if soa := query(); soa.Ns != "ns1.example.com." || soa.Serial != 1 {
+		t.Fatalf("SOA with a stale serial was applied: %v", soa)
+	}
+	update(10)
+	soa := query()
+	if soa.Ns != "ns.example.net." || soa.Mbox != "admin.example.com." || soa.Minttl != 30 || soa.Serial != 10 {
+		t.Fatalf("SOA = %v, want the written one with serial 10", soa)
+	}
+	if srv.Serial("example.com") != 10 {
+		t.Fatalf("serial = %d, want 10", srv.Serial("example.com"))
+	}
+}
+
+func TestServerTransfer(t *testing.T) {
+	srv := NewServer("example.com")
+	srv.AddTSIGKey(TestKeyName, TestSecret)
+	if err := srv.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = srv.Close() }()
+	srv.SetTXT("a.example.com", 60, "v1", "v2")
+
+	msg := new(dns.Msg)
+	msg.SetAxfr("example.com.")
+	msg.SetTsig(TestKeyName, dns.HmacSHA256, 300, time.Now().Unix())
+	transfer := &dns.Transfer{TsigSecret: map[string]string{TestKeyName: TestSecret}}
+	envelopes, err := transfer.In(msg, srv.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var records []dns.RR
+	for envelope := range envelopes {
+		if envelope.Error != nil {
+			t.Fatal(envelope.Error)
+		}
+		records = append(records, envelope.RR...)
+	}
+	// SOA, two TXT records, closing SOA
+	if len(records) != 4 || records[0].Header().Rrtype != dns.TypeSOA || records[3].Header().Rrtype != dns.TypeSOA {
+		t.Fatalf("transfer returned %v", records)
+	}
+
+	unsigned := new(dns.Msg)
+	unsigned.SetAxfr("example.com.")
+	envelopes, err = new(dns.Transfer).In(unsigned, srv.Addr())
+	if err == nil {
+		for envelope := range envelopes {
+			err = envelope.Error
+		}
+	}
+	if err == nil {
+		t.Fatal("unsigned transfer succeeded")
+	}
+}
+
+func TestServerTLS(t *testing.T) {
+	srv := NewServer("example.com")
+	if err := srv.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = srv.Close() }()
+	if err := srv.StartTLS(); err != nil {
+		t.Fatal(err)
+	}
+
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(srv.TLSCertificatePEM()) {
+		t.Fatal("TLSCertificatePEM holds no certificate")
+	}
+	client := &dns.Client{
+		Net:       "tcp-tls",
+		Timeout:   time.Second,
+		TLSConfig: &tls.Config{RootCAs: roots, ServerName: TLSServerName, MinVersion: tls.VersionTLS12},
+	}
+	msg := new(dns.Msg)
+	msg.SetQuestion("example.com.", dns.TypeSOA)
+	reply, _, err := client.Exchange(msg, srv.TLSAddr())
+	if err != nil {
+		t.Fatalf("exchange over TLS failed: %v", err)
+	}
+	if len(reply.Answer) != 1 {
+		t.Fatalf("SOA over TLS answered %v", reply.Answer)
+	}
+}
+
+func TestStartServers(t *testing.T) {
+	servers := StartServers(t, 2, "example.com", "example.org")
+	addrs := Addrs(servers)
+	if len(addrs) != 2 || addrs[0] == addrs[1] {
+		t.Fatalf("Addrs = %v, want two distinct addresses", addrs)
+	}
+	for _, srv := range servers {
+		msg := new(dns.Msg)
+		msg.SetQuestion("example.org.", dns.TypeSOA)
+		if reply := exchange(t, srv, msg); reply.Rcode != dns.RcodeSuccess || len(reply.Answer) != 1 {
+			t.Fatalf("SOA of example.org on %s = %s with %d answers", srv.Addr(), dns.RcodeToString[reply.Rcode], len(reply.Answer))
+		}
+
+		// Unsigned updates are refused
+		update := new(dns.Msg)
+		update.SetUpdate("example.com.")
+		update.Insert([]dns.RR{&dns.TXT{Hdr: dns.RR_Header{Name: "a.example.com.", Rrtype: dns.TypeTXT,
+			Class: dns.ClassINET, Ttl: 60}, Txt: []string{"x"}}})
+		if reply := exchange(t, srv, update); reply.Rcode != dns.RcodeRefused && reply.Rcode != dns.RcodeNotAuth {
+			t.Fatalf("unsigned update on %s answered %s", srv.Addr(), dns.RcodeToString[reply.Rcode])
+		}
+	}
+}
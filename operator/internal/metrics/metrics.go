@@ -0,0 +1,245 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics holds the Prometheus collectors shared by the DNS client,
+// the webhook solver and the operator controllers. All collectors are
+// registered in the controller-runtime registry so they are served by the
+// manager metrics endpoint.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const namespace = "dns01_bind9"
+
+var (
+	// ZoneCacheLookups counts SOA and zone-discovery cache lookups by kind (soa, zone) and result (hit, miss)
+	ZoneCacheLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "zone_cache_lookups_total",
+		Help:      "Number of SOA and zone-discovery cache lookups partitioned by kind and result.",
+	}, []string{"kind", "result"})
+
+	// ZoneCacheEntries reports the number of live entries in the zone cache by kind
+	ZoneCacheEntries = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "zone_cache_entries",
+		Help:      "Number of entries currently held in the SOA and zone-discovery cache.",
+	}, []string{"kind"})
+
+	// DNSConnections counts TCP and TLS connections to DNS servers by how they
+	// were obtained or dropped (dialed, reused, invalidated)
+	DNSConnections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "dns_connections_total",
+		Help:      "Number of TCP and TLS connections to DNS servers dialed, reused from the pool or invalidated after a failure.",
+	}, []string{"result"})
+
+	// SecretCacheLookups counts TSIG secret lookups by result (hit, miss)
+	SecretCacheLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "secret_cache_lookups_total",
+		Help:      "Number of TSIG secret lookups served from the informer cache (hit) or the API server (miss).",
+	}, []string{"result"})
+
+	// ManagerCacheLookups counts lookups of the DNS managers the webhook keeps
+	// across challenges by result (hit, miss)
+	ManagerCacheLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "manager_cache_lookups_total",
+		Help:      "Number of DNS manager lookups served from the manager cache (hit) or built anew (miss).",
+	}, []string{"result"})
+
+	// SecretAccessDecisions counts the decisions of the secret access policy
+	// by decision (allow, deny) and reason
+	SecretAccessDecisions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "secret_access_decisions_total",
+		Help:      "Number of Secret reads the secret access policy allowed or denied, partitioned by reason.",
+	}, []string{"decision", "reason"})
+
+	// CleanupQueueDepth reports the number of challenge cleanups waiting in the background queue
+	CleanupQueueDepth = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "cleanup_queue_depth",
+		Help:      "Number of challenge record deletions waiting in the background cleanup queue.",
+	})
+
+	// CleanupOperations counts background cleanup attempts by result (success, retry, dropped, rejected)
+	CleanupOperations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cleanup_operations_total",
+		Help:      "Number of background challenge cleanup attempts partitioned by result.",
+	}, []string{"result"})
+
+	// ServerRepairs counts retries of servers that missed a challenge record by result
+	// (success, retry, cancelled, rejected)
+	ServerRepairs = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "server_repairs_total",
+		Help:      "Number of attempts to add a challenge record to a server that missed it, partitioned by result.",
+	}, []string{"result"})
+
+	// QuorumStragglers counts the servers still updating when an add reached its
+	// write quorum by outcome (applied, failed, cancelled)
+	QuorumStragglers = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "quorum_stragglers_total",
+		Help:      "Number of servers that finished a challenge record add after its write quorum was reached, partitioned by outcome.",
+	}, []string{"result"})
+
+	// StaleRecordsRemoved counts _acme-challenge TXT values removed by the garbage collector by zone
+	StaleRecordsRemoved = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "stale_challenge_records_removed_total",
+		Help:      "Number of stale _acme-challenge TXT values removed by the garbage collector, partitioned by zone.",
+	}, []string{"zone"})
+
+	// DryRunChanges counts the DNS changes recorded instead of applied in dry-run
+	// mode by zone and operation (add, delete, replace)
+	DryRunChanges = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "dry_run_changes_total",
+		Help:      "Number of DNS updates logged instead of sent in dry-run mode, partitioned by zone and operation.",
+	}, []string{"zone", "op"})
+
+	// WorkPoolQueued reports the number of tasks waiting for a worker in the shared pool
+	WorkPoolQueued = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "workpool_queued_tasks",
+		Help:      "Number of DNS tasks waiting for a worker in the shared pool.",
+	})
+
+	// WorkPoolWaitSeconds observes how long tasks wait for a worker in the shared pool
+	WorkPoolWaitSeconds = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "workpool_wait_seconds",
+		Help:      "Time DNS tasks spend waiting for a worker in the shared pool.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 4, 10),
+	})
+
+	// TenantOperationsWaiting reports the Presents and CleanUps waiting for a
+	// slot of the fair scheduler
+	TenantOperationsWaiting = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "tenant_operations_waiting",
+		Help:      "Number of challenge operations waiting for a slot of the fair scheduler.",
+	})
+
+	// TenantWaitSeconds observes how long challenge operations wait for a slot of the fair scheduler
+	TenantWaitSeconds = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "tenant_wait_seconds",
+		Help:      "Time challenge operations spend waiting for a slot of the fair scheduler.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 4, 10),
+	})
+
+	// DNSUpdateWaitSeconds observes how long UPDATE messages wait for the rate
+	// limit and in-flight cap of their server
+	DNSUpdateWaitSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "dns_update_wait_seconds",
+		Help:      "Time DNS UPDATE messages spend queued for the rate limit and in-flight cap of their server.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 4, 10),
+	}, []string{"server"})
+
+	// DNSUpdatesInFlight reports the number of UPDATE messages awaiting a reply by server
+	DNSUpdatesInFlight = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "dns_updates_in_flight",
+		Help:      "Number of rate-limited DNS UPDATE messages currently awaiting a reply, partitioned by server.",
+	}, []string{"server"})
+
+	// ZoneAuditDrift reports the number of owned RRsets a ZoneAudit found
+	// missing or different on a server
+	ZoneAuditDrift = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "zone_audit_drift_rrsets",
+		Help:      "Number of RRsets owned by DNSRecords found missing or different by the last zone audit, partitioned by zone and server.",
+	}, []string{"zone", "server"})
+
+	// ZoneAuditHeals counts rewrites of drifted RRsets by result (success, failure)
+	ZoneAuditHeals = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "zone_audit_heals_total",
+		Help:      "Number of drifted RRsets rewritten by zone audits, partitioned by result.",
+	}, []string{"result"})
+
+	// PropagationChecks counts the resolvers of finished propagation checks by
+	// resolver set (authoritative or the name of a configured set), resolver
+	// and result (converged, pending)
+	PropagationChecks = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "propagation_checks_total",
+		Help:      "Number of resolvers asked by finished propagation checks, partitioned by resolver set, resolver and whether it served the record.",
+	}, []string{"set", "resolver", "result"})
+
+	// AdmissionRequestSeconds observes the time the solver API took to answer
+	// challenge requests by action (present, cleanup, unknown) and status code
+	AdmissionRequestSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "admission_request_duration_seconds",
+		Help:      "Time taken to answer challenge requests of cert-manager, authentication included, partitioned by action and status code.",
+		Buckets:   prometheus.ExponentialBuckets(0.005, 2, 14),
+	}, []string{"action", "code"})
+
+	// AdmissionRequestBytes observes the size of the challenge request bodies by action
+	AdmissionRequestBytes = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "admission_request_size_bytes",
+		Help:      "Size of the challenge request bodies read from cert-manager, partitioned by action.",
+		Buckets:   prometheus.ExponentialBuckets(256, 4, 7),
+	}, []string{"action"})
+
+	// AdmissionResponseBytes observes the size of the challenge responses by action
+	AdmissionResponseBytes = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "admission_response_size_bytes",
+		Help:      "Size of the responses to challenge requests of cert-manager, partitioned by action.",
+		Buckets:   prometheus.ExponentialBuckets(256, 4, 7),
+	}, []string{"action"})
+)
+
+func init() {
+	ctrlmetrics.Registry.MustRegister(
+		ZoneCacheLookups,
+		ZoneCacheEntries,
+		DNSConnections,
+		SecretCacheLookups,
+		SecretAccessDecisions,
+		ManagerCacheLookups,
+		CleanupQueueDepth,
+		CleanupOperations,
+		ServerRepairs,
+		QuorumStragglers,
+		StaleRecordsRemoved,
+		DryRunChanges,
+		WorkPoolQueued,
+		WorkPoolWaitSeconds,
+		TenantOperationsWaiting,
+		TenantWaitSeconds,
+		DNSUpdateWaitSeconds,
+		DNSUpdatesInFlight,
+		ZoneAuditDrift,
+		ZoneAuditHeals,
+		PropagationChecks,
+		AdmissionRequestSeconds,
+		AdmissionRequestBytes,
+		AdmissionResponseBytes,
+	)
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package solverconfig
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FunctionRating: 82/100
+// - Complexity: LOW
+// - Integrations: 0
+// - External Risks: LOW (pure parsing, bounded input)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: Bind9ClusterRef, AddClusterEndpoints
+// Purpose: The endpoints ConfigMap of a Bind9Cluster and how its addresses join a config
+
+// Keys of the endpoints ConfigMap of a Bind9Cluster; each holds one address per line
+const (
+	EndpointsServersKey     = "servers"
+	EndpointsSecondariesKey = "secondaries"
+)
+
+// Bind9ClusterRef names a Bind9Cluster whose endpoints are used as servers
+type Bind9ClusterRef struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+// validate checks the reference; a nil reference is valid
+func (r *Bind9ClusterRef) validate(provider string) error {
+	switch {
+	case r == nil:
+		return nil
+	case provider != ProviderRFC2136:
+		return fmt.Errorf("bind9Cluster requires provider %q", ProviderRFC2136)
+	case r.Namespace == "" || r.Name == "":
+		return fmt.Errorf("bind9Cluster needs a namespace and a name")
+	}
+	return nil
+}
+
+// EndpointsConfigMapName returns the ConfigMap the Bind9Cluster publishes its endpoints in
+func (r *Bind9ClusterRef) EndpointsConfigMapName() string {
+	return Bind9ClusterEndpointsName(r.Name)
+}
+
+// Bind9ClusterEndpointsName returns the name of the endpoints ConfigMap of the Bind9Cluster cluster
+func Bind9ClusterEndpointsName(cluster string) string {
+	return cluster + "-endpoints"
+}
+
+// EndpointsData renders the data of an endpoints ConfigMap
+func EndpointsData(servers, secondaries []string) map[string]string {
+	return map[string]string{
+		EndpointsServersKey:     strings.Join(servers, "\n"),
+		EndpointsSecondariesKey: strings.Join(secondaries, "\n"),
+	}
+}
+
+// AddClusterEndpoints adds the addresses of the endpoints ConfigMap data of
+// Bind9Cluster to Servers and Secondaries, skipping those already listed,
+// and validates the result
+func (c *Config) AddClusterEndpoints(data map[string]string) error {
+	servers := endpointLines(data[EndpointsServersKey])
+	if len(servers) == 0 {
+		return fmt.Errorf("bind9Cluster %s/%s has no primary endpoint yet", c.Bind9Cluster.Namespace, c.Bind9Cluster.Name)
+	}
+	listed := make(map[string]bool, len(c.Servers)+len(c.Secondaries))
+	for _, server := range c.Servers {
+		listed[server] = true
+	}
+	for _, server := range c.Secondaries {
+		listed[server] = true
+	}
+	for _, server := range servers {
+		if !listed[server] {
+			c.Servers = append(c.Servers, server)
+			listed[server] = true
+		}
+	}
+	for _, server := range endpointLines(data[EndpointsSecondariesKey]) {
+		if !listed[server] {
+			c.Secondaries = append(c.Secondaries, server)
+			listed[server] = true
+		}
+	}
+	if err := c.validate(); err != nil {
+		return fmt.Errorf("bind9Cluster %s/%s: %w", c.Bind9Cluster.Namespace, c.Bind9Cluster.Name, err)
+	}
+	return nil
+}
+
+// endpointLines splits value into its non-empty lines
+func endpointLines(value string) []string {
+	var lines []string
+	for _, line := range strings.Split(value, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
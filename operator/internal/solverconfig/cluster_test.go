@@ -0,0 +1,75 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package solverconfig
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAddClusterEndpoints(t *testing.T) {
+	const raw = `{"servers":["192.0.2.1"],"bind9Cluster":{"namespace":"dns","name":"bind9"},` +
+		`"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s"}`
+	config, err := Parse([]byte(raw))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if got := config.Bind9Cluster.EndpointsConfigMapName(); got != "bind9-endpoints" {
+		t.Fatalf("EndpointsConfigMapName() = %q", got)
+	}
+	data := EndpointsData([]string{"10.96.0.10", "192.0.2.1"}, []string{"10.96.0.11", "10.96.0.12"})
+	if err := config.AddClusterEndpoints(data); err != nil {
+		t.Fatalf("AddClusterEndpoints: %v", err)
+	}
+	if want := []string{"192.0.2.1", "10.96.0.10"}; !reflect.DeepEqual(config.Servers, want) {
+		t.Fatalf("Servers = %v, want %v", config.Servers, want)
+	}
+	if want := []string{"10.96.0.11", "10.96.0.12"}; !reflect.DeepEqual(config.Secondaries, want) {
+		t.Fatalf("Secondaries = %v, want %v", config.Secondaries, want)
+	}
+
+	// A cluster without a primary address yet cannot take updates
+	config, _ = Parse([]byte(raw))
+	if err := config.AddClusterEndpoints(EndpointsData(nil, nil)); err == nil || !strings.Contains(err.Error(), "no primary endpoint") {
+		t.Fatalf("AddClusterEndpoints without endpoints = %v", err)
+	}
+}
+
+func TestParseBind9ClusterErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		wantErr string
+	}{
+		{"no servers without cluster", `{"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s"}`,
+			"servers list is required"},
+		{"missing name", `{"bind9Cluster":{"namespace":"dns"},"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s"}`,
+			"bind9Cluster needs a namespace and a name"},
+		{"other provider", `{"provider":"powerdns","powerdns":{"apiUrl":"http://pdns:8081","apiKeySecretName":"pdns"},` +
+			`"bind9Cluster":{"namespace":"dns","name":"bind9"},"zone":"example.com"}`,
+			`bind9Cluster requires provider "rfc2136"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.raw))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Parse() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
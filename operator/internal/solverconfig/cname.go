@@ -0,0 +1,103 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package solverconfig
+
+import (
+	"fmt"
+
+	rfc2136 "github.com/rieset/istio-dns01-bind9/internal/dns"
+)
+
+// FunctionRating: 86/100
+// - Complexity: LOW
+// - Integrations: 0
+// - External Risks: LOW (pure parsing, bounded input)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: CNAMEConfig
+// Purpose: Settings for following the CNAME that delegates a challenge name to a dedicated zone
+
+const (
+	// DefaultCNAMEHops is the longest CNAME chain followed when the config sets no limit
+	DefaultCNAMEHops = 5
+	// MaxCNAMEHops is the largest hop limit a config may ask for
+	MaxCNAMEHops = 10
+)
+
+// CNAMEConfig makes the solver follow the CNAME chain of each challenge name
+// and write the record at the name the chain ends at, which is then routed
+// like any other name, usually to an entry of Zones
+type CNAMEConfig struct {
+	// MaxHops bounds the chain, default 5
+	MaxHops int `json:"maxHops,omitempty"`
+	// Resolvers are asked for the CNAMEs, in turn; default every server of
+	// the config and of its zones
+	Resolvers []string `json:"resolvers,omitempty"`
+}
+
+// Hops returns the longest CNAME chain followed
+func (f *CNAMEConfig) Hops() int {
+	if f.MaxHops == 0 {
+		return DefaultCNAMEHops
+	}
+	return f.MaxHops
+}
+
+// CNAMEResolvers returns the servers asked for the CNAMEs at challenge names
+func (c *Config) CNAMEResolvers() []string {
+	if c.FollowCNAME != nil && len(c.FollowCNAME.Resolvers) > 0 {
+		return c.FollowCNAME.Resolvers
+	}
+	seen := map[string]bool{}
+	var servers []string
+	add := func(list []string) {
+		for _, server := range list {
+			if !seen[server] {
+				seen[server] = true
+				servers = append(servers, server)
+			}
+		}
+	}
+	add(c.Servers)
+	add(c.Secondaries)
+	for _, zone := range c.Zones {
+		add(zone.Servers)
+	}
+	return servers
+}
+
+// validate checks the CNAME settings; nil is valid
+func (f *CNAMEConfig) validate() error {
+	if f == nil {
+		return nil
+	}
+	if f.MaxHops < 0 || f.MaxHops > MaxCNAMEHops {
+		return fmt.Errorf("followCNAME.maxHops %d is out of range, must be between 1 and %d", f.MaxHops, MaxCNAMEHops)
+	}
+	if len(f.Resolvers) > MaxServers {
+		return fmt.Errorf("followCNAME.resolvers has %d entries, maximum is %d", len(f.Resolvers), MaxServers)
+	}
+	for i, resolver := range f.Resolvers {
+		if _, _, err := rfc2136.ParseServerAddress(resolver); err != nil {
+			return fmt.Errorf("followCNAME.resolvers[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,985 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package solverconfig defines the DNS01 solver configuration carried in
+// Issuer webhook stanzas. It has no cert-manager dependencies so that the
+// webhook, the operator and the command line tools can share it.
+package solverconfig
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+
+	rfc2136 "github.com/rieset/istio-dns01-bind9/internal/dns"
+)
+
+// FunctionRating: 88/100
+// - Complexity: LOW
+// - Integrations: 1 (dns library, name validation)
+// - External Risks: LOW (pure parsing, bounded input)
+// - Unit Tests: YES (including fuzzing)
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: Parse
+// Purpose: Parses and validates the solver configuration with defaults applied
+
+// Limits enforced on solver configuration so hostile or mistyped Issuer
+// specs cannot make the webhook allocate or fan out without bound
+const (
+	// MaxConfigSize is the largest raw config accepted, in bytes
+	MaxConfigSize = 64 * 1024
+	// MaxServers is the largest number of DNS servers in one config
+	MaxServers = 32
+	// MaxTTL is the largest TXT record TTL accepted, in seconds
+	MaxTTL = 86400
+	// MaxNameLength bounds secret names and secret keys
+	MaxNameLength = 253
+	// MaxTSIGFudge is the largest TSIG fudge accepted, in seconds
+	MaxTSIGFudge = 3600
+
+	// DefaultTTL is used when the config does not set a TTL
+	DefaultTTL = 60
+	// DefaultTSIGAlgorithm is used when the config does not set an algorithm
+	DefaultTSIGAlgorithm = "hmac-sha256"
+	// DefaultTSIGSecretKey is used when the config does not set a secret key
+	DefaultTSIGSecretKey = "secret"
+	// DefaultPowerDNSServerID is the PowerDNS server ID used when none is set
+	DefaultPowerDNSServerID = "localhost"
+	// DefaultPowerDNSAPIKeySecretKey is used when the config does not set an API key secret key
+	DefaultPowerDNSAPIKeySecretKey = "api-key"
+	// DefaultSIG0PublicKeySecretKey holds the KEY record when the config does not name a key
+	DefaultSIG0PublicKeySecretKey = "key"
+	// DefaultSIG0PrivateKeySecretKey holds the private key when the config does not name a key
+	DefaultSIG0PrivateKeySecretKey = "private"
+	// MaxPropagationTimeout is the longest propagation wait a config may ask for
+	MaxPropagationTimeout = time.Hour
+	// MaxRetryAttempts is the largest number of sends of one update
+	MaxRetryAttempts = 10
+	// MaxRetryDelay is the longest backoff a config may ask for between two sends
+	MaxRetryDelay = time.Minute
+)
+
+// Duration is a time.Duration written as a Go duration string such as "90s"
+type Duration struct {
+	time.Duration
+}
+
+// UnmarshalJSON parses a duration string
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"90s\": %w", err)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	d.Duration = v
+	return nil
+}
+
+// MarshalJSON writes the duration as a string
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// PropagationConfig controls how Present waits for the challenge record to
+// become visible before it returns
+type PropagationConfig struct {
+	// Disabled makes Present return as soon as the update is accepted
+	Disabled bool `json:"disabled,omitempty"`
+	// Timeout bounds the wait; zero derives it from the zone's SOA timers
+	Timeout Duration `json:"timeout,omitempty"`
+	// Interval is the delay between polls of one server; zero derives it from the zone's SOA timers
+	Interval Duration `json:"interval,omitempty"`
+	// MinMatches is the number of configured servers that must serve the
+	// record; zero means the write quorum for rfc2136 and all servers otherwise
+	MinMatches int `json:"minMatches,omitempty"`
+	// CheckPublicNS additionally waits until every nameserver in the zone's
+	// NS RRset serves the record
+	CheckPublicNS bool `json:"checkPublicNS,omitempty"`
+	// DNSSEC additionally waits until every polled server serves the TXT
+	// RRset with a valid RRSIG, for zones the server signs itself
+	DNSSEC bool `json:"dnssec,omitempty"`
+	// Resolvers are sets of resolvers that must serve the record too once
+	// the servers do, such as the ones cert-manager's self-check uses
+	Resolvers []ResolverSetConfig `json:"resolvers,omitempty"`
+	// SecondaryCheck selects how secondaries are confirmed: records
+	// (default) polls the changed TXT record, serial waits for the SOA
+	// serial of the primary
+	SecondaryCheck string `json:"secondaryCheck,omitempty"`
+}
+
+// Ways secondaries are confirmed to serve a change
+const (
+	SecondaryCheckRecords = "records"
+	SecondaryCheckSerial  = "serial"
+)
+
+// WatchesSerial reports whether secondaries are confirmed by their SOA serial
+func (p *PropagationConfig) WatchesSerial() bool {
+	return p != nil && p.SecondaryCheck == SecondaryCheckSerial
+}
+
+// RetryConfig controls how RFC2136 updates failing on a transport error or a
+// transient rcode are resent before the challenge fails
+type RetryConfig struct {
+	// Attempts is the total number of sends of an update, including the first
+	Attempts int `json:"attempts,omitempty"`
+	// BaseDelay is the backoff before the first retry; it doubles on every retry
+	BaseDelay Duration `json:"baseDelay,omitempty"`
+	// MaxDelay caps the backoff between two sends
+	MaxDelay Duration `json:"maxDelay,omitempty"`
+	// Rcodes are the reply codes worth retrying, such as SERVFAIL; empty means SERVFAIL only.
+	// NOTAUTH, REFUSED and NOTZONE are never retried.
+	Rcodes []string `json:"rcodes,omitempty"`
+}
+
+// PrerequisitesConfig adds RFC2136 prerequisites to the updates of the
+// rfc2136 provider so records of other systems are never touched
+type PrerequisitesConfig struct {
+	// NameNotInUse adds a challenge record only while its name holds no
+	// records; an add whose value is already the name's only record succeeds
+	NameNotInUse bool `json:"nameNotInUse,omitempty"`
+	// RRsetExists deletes a challenge record only while its TXT RRset exists;
+	// a missing RRset counts as already deleted
+	RRsetExists bool `json:"rrsetExists,omitempty"`
+}
+
+// SIG0Config locates the SIG(0) key pair of the sig0 auth method
+type SIG0Config struct {
+	// SecretName names the Secret holding the key pair written by dnssec-keygen -T KEY
+	SecretName string `json:"secretName"`
+	// PublicKeySecretKey is the key of the KEY record (.key file) in the Secret, default "key"
+	PublicKeySecretKey string `json:"publicKeySecretKey,omitempty"`
+	// PrivateKeySecretKey is the key of the .private file in the Secret, default "private"
+	PrivateKeySecretKey string `json:"privateKeySecretKey,omitempty"`
+}
+
+// TLSConfig configures DNS-over-TLS to servers of the rfc2136 provider
+type TLSConfig struct {
+	// SecretName optionally names a Secret with ca.crt to verify the server
+	// instead of the system roots and tls.crt with tls.key for a client certificate
+	SecretName string `json:"secretName,omitempty"`
+	// ServerName overrides the name verified in the server certificate and
+	// sent as SNI; by default the host of the server entry is used
+	ServerName string `json:"serverName,omitempty"`
+}
+
+// BridgeConfig adds views of a split zone that receive every change as well,
+// such as the public view of a zone whose internal view lives in BIND
+type BridgeConfig struct {
+	Route53 *Route53Config `json:"route53,omitempty"`
+}
+
+// Route53Config configures an AWS Route53 hosted zone target
+type Route53Config struct {
+	// HostedZoneID is the ID of the public hosted zone, e.g. Z0123456789ABC
+	HostedZoneID string `json:"hostedZoneID"`
+	// CredentialsSecretName names a Secret with the keys access-key-id,
+	// secret-access-key and optionally session-token
+	CredentialsSecretName string `json:"credentialsSecretName"`
+	// Endpoint overrides the Route53 API endpoint
+	Endpoint string `json:"endpoint,omitempty"`
+}
+
+// Write policies deciding how many servers must accept an update
+const (
+	// WritePolicyMajority requires more than half of the servers (default)
+	WritePolicyMajority = "majority"
+	// WritePolicyAll requires every server
+	WritePolicyAll = "all"
+	// WritePolicyAny requires a single server
+	WritePolicyAny = "any"
+)
+
+// Methods RFC2136 updates are authenticated with
+const (
+	// AuthMethodTSIG signs updates with a shared TSIG secret (default)
+	AuthMethodTSIG = "tsig"
+	// AuthMethodSIG0 signs updates with a SIG(0) private key
+	AuthMethodSIG0 = "sig0"
+)
+
+// Backends the TSIG secrets of the rfc2136 provider are read from
+const (
+	// SecretProviderKubernetes reads Kubernetes Secrets (default)
+	SecretProviderKubernetes = "kubernetes"
+	// SecretProviderFile reads Secrets mounted into the webhook, such as projected volumes
+	SecretProviderFile = "file"
+	// SecretProviderVault reads the KV v2 secrets engine of HashiCorp Vault
+	SecretProviderVault = "vault"
+)
+
+// Providers updating DNS for a zone
+const (
+	// ProviderRFC2136 sends RFC2136 updates to every entry of Servers
+	ProviderRFC2136 = "rfc2136"
+	// ProviderPowerDNS uses the PowerDNS Authoritative HTTP API
+	ProviderPowerDNS = "powerdns"
+	// ProviderCoreDNSEtcd writes records into the etcd keyspace of CoreDNS's etcd plugin
+	ProviderCoreDNSEtcd = "coredns-etcd"
+)
+
+// EtcdConfig configures the CoreDNS etcd provider
+type EtcdConfig struct {
+	// Endpoints are the etcd client URLs, e.g. https://etcd.dns.svc:2379
+	Endpoints []string `json:"endpoints"`
+	// Prefix is the path configured in CoreDNS's etcd plugin, default /skydns
+	Prefix string `json:"prefix,omitempty"`
+	// CredentialsSecretName optionally names a Secret with the keys username
+	// and password and/or ca.crt, tls.crt and tls.key for TLS
+	CredentialsSecretName string `json:"credentialsSecretName,omitempty"`
+}
+
+// PowerDNSConfig configures the PowerDNS HTTP API provider
+type PowerDNSConfig struct {
+	// APIURL is the base URL of the API, e.g. http://pdns.dns.svc:8081
+	APIURL string `json:"apiUrl"`
+	// ServerID is the PowerDNS server ID, default "localhost"
+	ServerID string `json:"serverId,omitempty"`
+	// APIKeySecretName is the Secret holding the X-API-Key value
+	APIKeySecretName string `json:"apiKeySecretName"`
+	// APIKeySecretKey is the key of the API key in the Secret, default "api-key"
+	APIKeySecretKey string `json:"apiKeySecretKey,omitempty"`
+}
+
+// Config represents the webhook configuration
+type Config struct {
+	// Servers are the addresses of the servers, or the IDs of structured
+	// entries; the JSON form accepts both, see ServerEntry
+	Servers []string `json:"servers"`
+	// Secondaries are servers that load the zone from Servers by transfer and
+	// refuse updates. They are never updated, only polled once the update was
+	// sent, and every one of them must serve the change before it succeeds.
+	Secondaries []string `json:"secondaries,omitempty"`
+	// Bind9Cluster takes Servers and Secondaries from the endpoints an
+	// operator-managed Bind9Cluster publishes, in addition to the listed ones
+	Bind9Cluster *Bind9ClusterRef `json:"bind9Cluster,omitempty"`
+	// Zone is the zone updates are sent for. It is optional for rfc2136, whose
+	// servers are then asked for the zone enclosing each challenge name.
+	Zone           string `json:"zone"`
+	TSIGKeyName    string `json:"tsigKeyName"`
+	TSIGAlgorithm  string `json:"tsigAlgorithm"`
+	TSIGSecretName string `json:"tsigSecretName"`
+	TSIGSecretKey  string `json:"tsigSecretKey"`
+	TTL            int    `json:"ttl,omitempty"`
+	// AuthMethod selects how RFC2136 updates are signed: tsig (default) with
+	// the TSIG fields, or sig0 with the key pair of SIG0
+	AuthMethod string      `json:"authMethod,omitempty"`
+	SIG0       *SIG0Config `json:"sig0,omitempty"`
+	// SecretProvider selects where TSIG secrets are read from: kubernetes
+	// (default), file or vault. The backends themselves are set up on the webhook.
+	SecretProvider string `json:"secretProvider,omitempty"`
+	// SecretNamespace is the namespace every Secret of the config is read
+	// from instead of the challenge's; the webhook's secret access policy
+	// decides whether the challenge may use it
+	SecretNamespace string `json:"secretNamespace,omitempty"`
+	// AllowDeprecatedTSIG permits TSIG algorithms RFC 8945 no longer
+	// recommends, currently hmac-sha1
+	AllowDeprecatedTSIG bool `json:"allowDeprecatedTSIGAlgorithm,omitempty"`
+	// TSIGFallbackKeys are tried in order when a server rejects its TSIG key
+	// with BADKEY or BADSIG, so key rotations need no issuance downtime
+	TSIGFallbackKeys []TSIGKeyConfig `json:"tsigFallbackKeys,omitempty"`
+	// TSIGFudge is the clock skew in seconds servers may accept for the time
+	// of a TSIG signature; zero keeps the 300 seconds of the server mode
+	TSIGFudge int `json:"tsigFudge,omitempty"`
+	// ServerModes maps entries of Servers to a compatibility mode (bind, knot,
+	// powerdns or windows). Servers without an entry use bind.
+	ServerModes map[string]string `json:"serverModes,omitempty"`
+	// ServerEntries holds the structured entries of Servers by ID
+	ServerEntries map[string]ServerEntry `json:"-"`
+	// entryProblems are the errors of the structured entries of Servers by index
+	entryProblems map[int]error
+	// Transport selects how RFC2136 updates are sent: udp, tcp or auto
+	// (default), which falls back to TCP on truncation or UDP failure
+	Transport string `json:"transport,omitempty"`
+	// TLS configures DNS-over-TLS for every server when Transport is tls
+	TLS *TLSConfig `json:"tls,omitempty"`
+	// ServerTLS replaces TLS for individual entries of Servers
+	ServerTLS map[string]TLSConfig `json:"serverTLS,omitempty"`
+	// Timeouts bounds the dial, read and write of every exchange with the servers
+	Timeouts *TimeoutConfig `json:"timeouts,omitempty"`
+	// ServerTimeouts replaces fields of Timeouts for individual entries of Servers
+	ServerTimeouts map[string]TimeoutConfig `json:"serverTimeouts,omitempty"`
+	// OperationTimeout bounds the DNS work of one Present or cleanup,
+	// replacing the webhook's DNS_OPERATION_TIMEOUT for this config
+	OperationTimeout Duration `json:"operationTimeout,omitempty"`
+	// WritePolicy sets how many servers must accept an update: majority
+	// (default), all or any
+	WritePolicy string `json:"writePolicy,omitempty"`
+	// MinSuccess requires an absolute number of servers instead of WritePolicy
+	MinSuccess int `json:"minSuccess,omitempty"`
+	// ServerGroups name sets of Servers that are reached through their own
+	// proxy and must each reach their own quorum, such as the primaries of
+	// each site of a geo-redundant zone
+	ServerGroups []ServerGroupConfig `json:"serverGroups,omitempty"`
+	// RollbackOnFailure removes the record from the servers that applied an
+	// add that missed the write quorum
+	RollbackOnFailure bool `json:"rollbackOnFailure,omitempty"`
+	// Retry resends RFC2136 updates that fail on transient errors
+	Retry *RetryConfig `json:"retry,omitempty"`
+	// Prerequisites guards RFC2136 updates against conflicting records
+	Prerequisites *PrerequisitesConfig `json:"prerequisites,omitempty"`
+	// Provider selects how the zone is updated: rfc2136 (default) or powerdns.
+	// With powerdns, Servers are optional and only used to verify propagation.
+	Provider string          `json:"provider,omitempty"`
+	PowerDNS *PowerDNSConfig `json:"powerdns,omitempty"`
+	Etcd     *EtcdConfig     `json:"etcd,omitempty"`
+	// Bridge fans every change out to additional views of the zone
+	Bridge *BridgeConfig `json:"bridge,omitempty"`
+	// Propagation tunes the wait for the record to become visible after Present
+	Propagation *PropagationConfig `json:"propagation,omitempty"`
+	// DryRun resolves credentials and logs every change instead of sending it
+	DryRun bool `json:"dryRun,omitempty"`
+	// Zones route challenges to per-zone servers and TSIG keys by the longest
+	// zone enclosing the challenge name, see ForName
+	Zones []ZoneConfig `json:"zones,omitempty"`
+	// FollowCNAME writes each challenge record at the end of the CNAME chain
+	// of its name, for challenge names delegated to a dedicated zone
+	FollowCNAME *CNAMEConfig `json:"followCNAME,omitempty"`
+}
+
+// SecretRef returns the Secret name and key holding the zone's credentials:
+// the TSIG secret for rfc2136, the API key for powerdns. For coredns-etcd and
+// SIG(0) the whole Secret is used, so key is empty, and name is empty without
+// credentials.
+func (c *Config) SecretRef() (name, key string) {
+	switch {
+	case c.UsesSIG0():
+		return c.SIG0.SecretName, ""
+	case c.Provider == ProviderPowerDNS && c.PowerDNS != nil:
+		return c.PowerDNS.APIKeySecretName, c.PowerDNS.APIKeySecretKey
+	case c.Provider == ProviderCoreDNSEtcd && c.Etcd != nil:
+		return c.Etcd.CredentialsSecretName, ""
+	}
+	return c.TSIGSecretName, c.TSIGSecretKey
+}
+
+// UsesSIG0 reports whether RFC2136 updates are signed with SIG(0) instead of TSIG
+func (c *Config) UsesSIG0() bool {
+	return c.Provider == ProviderRFC2136 && strings.EqualFold(c.AuthMethod, AuthMethodSIG0) && c.SIG0 != nil
+}
+
+// TSIGSecretProvider returns the backend TSIG secrets are read from
+func (c *Config) TSIGSecretProvider() string {
+	if c.SecretProvider == "" {
+		return SecretProviderKubernetes
+	}
+	return strings.ToLower(c.SecretProvider)
+}
+
+// SecretsNamespace returns the namespace the Secrets of c are read from for
+// a challenge in namespace: secretNamespace, or namespace when unset
+func (c *Config) SecretsNamespace(namespace string) string {
+	if c.SecretNamespace != "" {
+		return c.SecretNamespace
+	}
+	return namespace
+}
+
+// ServerMode returns the compatibility mode of server
+func (c *Config) ServerMode(server string) rfc2136.CompatMode {
+	mode, err := rfc2136.ParseCompatMode(c.ServerModes[server])
+	if err != nil {
+		return rfc2136.CompatBIND
+	}
+	return mode
+}
+
+// ServerQuirks returns the quirks of the mode of server with TSIGFudge applied
+func (c *Config) ServerQuirks(server string) rfc2136.Quirks {
+	quirks := rfc2136.QuirksFor(c.ServerMode(server))
+	if c.TSIGFudge > 0 {
+		quirks.TSIGFudge = uint16(c.TSIGFudge)
+	}
+	return quirks
+}
+
+// DNSTransport returns the transport RFC2136 updates are sent over
+func (c *Config) DNSTransport() rfc2136.Transport {
+	transport, err := rfc2136.ParseTransport(c.Transport)
+	if err != nil {
+		return rfc2136.TransportAuto
+	}
+	return transport
+}
+
+// RequiredWrites returns the number of servers that must accept an update
+// under the write policy, never more than the configured servers. With
+// ServerGroups and no write policy it is the sum of the group quorums, which
+// GroupQuorums enforces per group.
+func (c *Config) RequiredWrites() int {
+	n := len(c.Servers)
+	required := n/2 + 1
+	switch {
+	case c.MinSuccess > 0:
+		required = c.MinSuccess
+	case strings.EqualFold(c.WritePolicy, WritePolicyAll):
+		required = n
+	case strings.EqualFold(c.WritePolicy, WritePolicyAny):
+		required = 1
+	case len(c.ServerGroups) > 0 && c.WritePolicy == "":
+		required = 0
+		for _, quorum := range c.GroupQuorums() {
+			required += quorum.Required
+		}
+	}
+	return max(min(required, n), 1)
+}
+
+// RetryPolicy returns the policy RFC2136 updates are resent under
+func (c *Config) RetryPolicy() rfc2136.RetryPolicy {
+	if c.Retry == nil {
+		return rfc2136.RetryPolicy{}
+	}
+	policy := rfc2136.RetryPolicy{
+		Attempts:  c.Retry.Attempts,
+		BaseDelay: c.Retry.BaseDelay.Duration,
+		MaxDelay:  c.Retry.MaxDelay.Duration,
+	}
+	for _, name := range c.Retry.Rcodes {
+		if rcode, ok := dns.StringToRcode[strings.ToUpper(name)]; ok {
+			policy.Rcodes = append(policy.Rcodes, rcode)
+		}
+	}
+	return policy
+}
+
+// UpdatePrerequisites returns the prerequisites RFC2136 updates are sent with
+func (c *Config) UpdatePrerequisites() rfc2136.Prerequisites {
+	if c.Prerequisites == nil {
+		return rfc2136.Prerequisites{}
+	}
+	return rfc2136.Prerequisites{
+		NameNotInUse: c.Prerequisites.NameNotInUse,
+		RRsetExists:  c.Prerequisites.RRsetExists,
+	}
+}
+
+// ServerTLSConfig returns the DNS-over-TLS settings of server
+func (c *Config) ServerTLSConfig(server string) TLSConfig {
+	if settings, ok := c.ServerTLS[server]; ok {
+		return settings
+	}
+	if c.TLS != nil {
+		return *c.TLS
+	}
+	return TLSConfig{}
+}
+
+// Parse parses the JSON solver configuration, applies defaults and enforces
+// limits. Every problem found is reported at once in a ValidationError.
+func Parse(raw []byte) (*Config, error) {
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("config is empty")
+	}
+	if len(raw) > MaxConfigSize {
+		return nil, fmt.Errorf("config is %d bytes, maximum is %d", len(raw), MaxConfigSize)
+	}
+
+	var problems []error
+	for _, path := range unknownFields(raw) {
+		problems = append(problems, fmt.Errorf("unknown field %q", path))
+	}
+	config := &Config{}
+	if err := json.Unmarshal(raw, config); err != nil {
+		return nil, newValidationError(append(problems, fmt.Errorf("failed to unmarshal config: %w", err)))
+	}
+
+	// Empty values fall back to defaults
+	if config.TTL == 0 {
+		config.TTL = DefaultTTL
+	}
+	if config.TSIGAlgorithm == "" {
+		config.TSIGAlgorithm = DefaultTSIGAlgorithm
+	}
+	if config.TSIGSecretKey == "" {
+		config.TSIGSecretKey = DefaultTSIGSecretKey
+	}
+	if config.Provider == "" {
+		config.Provider = ProviderRFC2136
+	}
+	if config.SIG0 != nil {
+		if config.SIG0.PublicKeySecretKey == "" {
+			config.SIG0.PublicKeySecretKey = DefaultSIG0PublicKeySecretKey
+		}
+		if config.SIG0.PrivateKeySecretKey == "" {
+			config.SIG0.PrivateKeySecretKey = DefaultSIG0PrivateKeySecretKey
+		}
+	}
+	config.PowerDNS.setDefaults()
+	for _, entry := range config.ServerEntries {
+		entry.PowerDNS.setDefaults()
+	}
+
+	if err := config.validate(); err != nil {
+		var invalid *ValidationError
+		if errors.As(err, &invalid) {
+			problems = append(problems, invalid.Problems...)
+		} else {
+			problems = append(problems, err)
+		}
+	}
+	if err := newValidationError(problems); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// validate checks required fields and limits and returns a ValidationError
+// with every problem found
+func (c *Config) validate() error {
+	var problems []error
+	check := func(err error) {
+		if err != nil {
+			problems = append(problems, err)
+		}
+	}
+
+	switch c.Provider {
+	case ProviderRFC2136:
+		if len(c.Servers) == 0 && c.Bind9Cluster == nil && len(c.Zones) == 0 {
+			check(fmt.Errorf("servers list is required"))
+		}
+	case ProviderPowerDNS:
+		check(c.PowerDNS.validate())
+	case ProviderCoreDNSEtcd:
+		check(c.Etcd.validate())
+	default:
+		check(fmt.Errorf("unknown provider %q, expected one of %s, %s, %s",
+			c.Provider, ProviderRFC2136, ProviderPowerDNS, ProviderCoreDNSEtcd))
+	}
+	check(c.Bind9Cluster.validate(c.Provider))
+	if len(c.Servers) > MaxServers {
+		check(fmt.Errorf("servers list has %d entries, maximum is %d", len(c.Servers), MaxServers))
+	}
+	seen := make(map[string]bool, len(c.Servers))
+	for i, server := range c.Servers {
+		if err, ok := c.entryProblems[i]; ok {
+			check(err)
+		} else {
+			check(c.validateServer(i, server, seen))
+		}
+		seen[server] = true
+	}
+
+	check(c.validateSecondaries(seen))
+	c.validateServerGroups(seen, check)
+	c.validateTimeouts(seen, check)
+
+	for _, server := range slices.Sorted(maps.Keys(c.ServerModes)) {
+		mode := c.ServerModes[server]
+		if !seen[server] {
+			check(fmt.Errorf("serverModes entry %q is not listed in servers", server))
+		}
+		if _, err := rfc2136.ParseCompatMode(mode); err != nil {
+			check(fmt.Errorf("serverModes[%q]: %w", server, err))
+		}
+	}
+
+	transport, err := rfc2136.ParseTransport(c.Transport)
+	check(err)
+	if err == nil && (c.TLS != nil || len(c.ServerTLS) > 0) && transport != rfc2136.TransportTLS {
+		check(fmt.Errorf("tls and serverTLS require transport %q", rfc2136.TransportTLS))
+	}
+	check(c.TLS.validate("tls"))
+	for _, server := range slices.Sorted(maps.Keys(c.ServerTLS)) {
+		settings := c.ServerTLS[server]
+		if !seen[server] {
+			check(fmt.Errorf("serverTLS entry %q is not listed in servers", server))
+		}
+		check(settings.validate(fmt.Sprintf("serverTLS[%q]", server)))
+	}
+
+	// RFC2136 servers are asked for the zone enclosing each challenge when it is not set
+	if c.Zone == "" && c.Provider != ProviderRFC2136 {
+		check(fmt.Errorf("zone is required"))
+	}
+	if _, ok := dns.IsDomainName(c.Zone); !ok && c.Zone != "" {
+		check(fmt.Errorf("zone %q is not a valid domain name", c.Zone))
+	}
+	if c.TTL < 0 || c.TTL > MaxTTL {
+		check(fmt.Errorf("ttl %d is out of range, must be between 1 and %d", c.TTL, MaxTTL))
+	}
+	if c.TSIGFudge < 0 || c.TSIGFudge > MaxTSIGFudge {
+		check(fmt.Errorf("tsigFudge %d is out of range, must be between 1 and %d", c.TSIGFudge, MaxTSIGFudge))
+	}
+	check(c.Bridge.validate())
+	check(c.FollowCNAME.validate())
+	check(c.Propagation.validate(c.serverLimit()))
+	check(c.validateWritePolicy())
+	check(c.Retry.validate())
+	if c.Prerequisites != nil && c.Provider != ProviderRFC2136 {
+		check(fmt.Errorf("prerequisites require provider %q", ProviderRFC2136))
+	}
+	check(c.validateAuthMethod())
+	check(c.validateSecretProvider())
+	if c.Provider == ProviderRFC2136 && !c.UsesSIG0() {
+		c.validateTSIG(check)
+	}
+	c.validateFallbackKeys(check)
+	c.validateZones(check)
+	return newValidationError(problems)
+}
+
+// serverLimit returns the number of servers counts such as minSuccess are
+// checked against. A config whose servers all come from zones leaves the
+// check to each entry.
+func (c *Config) serverLimit() int {
+	if len(c.Servers) == 0 && len(c.Zones) > 0 {
+		return MaxServers
+	}
+	return len(c.Servers)
+}
+
+// validateServer checks servers[i], given the servers listed before it in seen
+func (c *Config) validateServer(i int, server string, seen map[string]bool) error {
+	if strings.TrimSpace(server) == "" {
+		return fmt.Errorf("servers[%d] is empty", i)
+	}
+	if len(server) > MaxNameLength || strings.ContainsAny(server, " \t\r\n/") {
+		return fmt.Errorf("servers[%d] is not a valid address: %q", i, server)
+	}
+	if _, _, err := rfc2136.ParseServerAddress(server); err != nil {
+		return fmt.Errorf("servers[%d]: %w", i, err)
+	}
+	if seen[server] {
+		return fmt.Errorf("servers[%d] duplicates %q", i, server)
+	}
+	if c.ServerProvider(server) != ProviderRFC2136 && c.Provider != ProviderRFC2136 {
+		return fmt.Errorf("servers[%d] sets provider %q, which requires provider %q",
+			i, c.ServerProvider(server), ProviderRFC2136)
+	}
+	return nil
+}
+
+// validateTSIG checks the TSIG settings of an rfc2136 config, passing each
+// problem to check
+func (c *Config) validateTSIG(check func(error)) {
+	if c.TSIGKeyName != "" {
+		if _, ok := dns.IsDomainName(c.TSIGKeyName); !ok {
+			check(fmt.Errorf("tsigKeyName %q is not a valid key name", c.TSIGKeyName))
+		}
+	}
+	if len(c.TSIGSecretName) > MaxNameLength || len(c.TSIGSecretKey) > MaxNameLength {
+		check(fmt.Errorf("tsigSecretName and tsigSecretKey must be at most %d characters", MaxNameLength))
+	}
+	check(c.canonicalizeTSIG())
+	// Servers may bring their own key; the top-level one is required for the others
+	var noKeyName, noSecretName []string
+	for _, server := range c.Servers {
+		if c.ServerProvider(server) != ProviderRFC2136 {
+			continue
+		}
+		settings := c.ServerSettings(server)
+		if settings.TSIGKeyName == "" {
+			noKeyName = append(noKeyName, strconv.Quote(server))
+		}
+		if settings.TSIGSecretName == "" {
+			noSecretName = append(noSecretName, strconv.Quote(server))
+		}
+	}
+	if len(noKeyName) > 0 {
+		check(fmt.Errorf("tsigKeyName is required for server %s", strings.Join(noKeyName, ", ")))
+	}
+	if len(noSecretName) > 0 {
+		check(fmt.Errorf("tsigSecretName is required for server %s", strings.Join(noSecretName, ", ")))
+	}
+}
+
+// canonicalizeTSIG checks the TSIG algorithms of the config and its server
+// entries against the registry of the dns package, replacing them with their
+// canonical names, and makes the key names fully qualified
+func (c *Config) canonicalizeTSIG() error {
+	algorithm, err := rfc2136.ParseTSIGAlgorithm(c.TSIGAlgorithm, c.AllowDeprecatedTSIG)
+	if err != nil {
+		return fmt.Errorf("tsigAlgorithm: %w", err)
+	}
+	c.TSIGAlgorithm = algorithm
+	if c.TSIGKeyName != "" {
+		c.TSIGKeyName = dns.CanonicalName(c.TSIGKeyName)
+	}
+	for id, entry := range c.ServerEntries {
+		if entry.Provider != "" && entry.Provider != ProviderRFC2136 {
+			continue
+		}
+		if entry.Algorithm != "" {
+			if entry.Algorithm, err = rfc2136.ParseTSIGAlgorithm(entry.Algorithm, c.AllowDeprecatedTSIG); err != nil {
+				return fmt.Errorf("servers[%q].algorithm: %w", id, err)
+			}
+		}
+		if entry.TSIGKeyName != "" {
+			entry.TSIGKeyName = dns.CanonicalName(entry.TSIGKeyName)
+		}
+		c.ServerEntries[id] = entry
+	}
+	return nil
+}
+
+// validateSecondaries checks the addresses of secondaries against each other
+// and against servers, the set of addresses in Servers
+func (c *Config) validateSecondaries(servers map[string]bool) error {
+	if len(c.Servers)+len(c.Secondaries) > MaxServers {
+		return fmt.Errorf("servers and secondaries have %d entries, maximum is %d",
+			len(c.Servers)+len(c.Secondaries), MaxServers)
+	}
+	seen := make(map[string]bool, len(c.Secondaries))
+	for i, server := range c.Secondaries {
+		if strings.TrimSpace(server) == "" {
+			return fmt.Errorf("secondaries[%d] is empty", i)
+		}
+		if len(server) > MaxNameLength || strings.ContainsAny(server, " \t\r\n/") {
+			return fmt.Errorf("secondaries[%d] is not a valid address: %q", i, server)
+		}
+		if _, _, err := rfc2136.ParseServerAddress(server); err != nil {
+			return fmt.Errorf("secondaries[%d]: %w", i, err)
+		}
+		if servers[server] {
+			return fmt.Errorf("secondaries[%d] %q is also listed in servers", i, server)
+		}
+		if seen[server] {
+			return fmt.Errorf("secondaries[%d] duplicates %q", i, server)
+		}
+		seen[server] = true
+	}
+	return nil
+}
+
+// validateWritePolicy checks writePolicy and minSuccess
+func (c *Config) validateWritePolicy() error {
+	switch strings.ToLower(c.WritePolicy) {
+	case "", WritePolicyMajority, WritePolicyAll, WritePolicyAny:
+	default:
+		return fmt.Errorf("unknown writePolicy %q, expected one of %s, %s, %s",
+			c.WritePolicy, WritePolicyMajority, WritePolicyAll, WritePolicyAny)
+	}
+	if c.MinSuccess == 0 {
+		return nil
+	}
+	if c.WritePolicy != "" {
+		return fmt.Errorf("writePolicy and minSuccess are mutually exclusive")
+	}
+	if c.MinSuccess < 0 || c.MinSuccess > c.serverLimit() {
+		return fmt.Errorf("minSuccess %d is out of range, must be between 1 and %d", c.MinSuccess, c.serverLimit())
+	}
+	return nil
+}
+
+// validateSecretProvider checks secretProvider, which only applies to TSIG secrets
+func (c *Config) validateSecretProvider() error {
+	switch provider := c.TSIGSecretProvider(); provider {
+	case SecretProviderKubernetes:
+		return nil
+	case SecretProviderFile, SecretProviderVault:
+		if c.Provider != ProviderRFC2136 || c.UsesSIG0() {
+			return fmt.Errorf("secretProvider %q requires provider %q with TSIG", provider, ProviderRFC2136)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown secretProvider %q, expected one of %s, %s, %s",
+		c.SecretProvider, SecretProviderKubernetes, SecretProviderFile, SecretProviderVault)
+}
+
+// validateAuthMethod checks authMethod and the SIG(0) key settings
+func (c *Config) validateAuthMethod() error {
+	switch strings.ToLower(c.AuthMethod) {
+	case "", AuthMethodTSIG:
+		if c.SIG0 != nil {
+			return fmt.Errorf("sig0 requires authMethod %q", AuthMethodSIG0)
+		}
+		return nil
+	case AuthMethodSIG0:
+	default:
+		return fmt.Errorf("unknown authMethod %q, expected one of %s, %s", c.AuthMethod, AuthMethodTSIG, AuthMethodSIG0)
+	}
+	if c.Provider != ProviderRFC2136 {
+		return fmt.Errorf("authMethod %q requires provider %s", c.AuthMethod, ProviderRFC2136)
+	}
+	if c.SIG0 == nil || c.SIG0.SecretName == "" {
+		return fmt.Errorf("sig0.secretName is required for authMethod %q", c.AuthMethod)
+	}
+	if len(c.SIG0.SecretName) > MaxNameLength || len(c.SIG0.PublicKeySecretKey) > MaxNameLength ||
+		len(c.SIG0.PrivateKeySecretKey) > MaxNameLength {
+		return fmt.Errorf("sig0 names must be at most %d characters", MaxNameLength)
+	}
+	return nil
+}
+
+// validate checks the TLS settings found at field; nil is valid
+func (t *TLSConfig) validate(field string) error {
+	if t == nil {
+		return nil
+	}
+	if len(t.SecretName) > MaxNameLength {
+		return fmt.Errorf("%s.secretName must be at most %d characters", field, MaxNameLength)
+	}
+	if t.ServerName != "" {
+		if _, ok := dns.IsDomainName(t.ServerName); !ok || strings.HasSuffix(t.ServerName, ".") ||
+			strings.ContainsAny(t.ServerName, " \t\r\n/") {
+			return fmt.Errorf("%s.serverName %q is not a valid host name", field, t.ServerName)
+		}
+	}
+	return nil
+}
+
+// validate checks the propagation settings against the number of configured servers; nil is valid
+func (p *PropagationConfig) validate(servers int) error {
+	if p == nil {
+		return nil
+	}
+	if p.Timeout.Duration < 0 || p.Timeout.Duration > MaxPropagationTimeout {
+		return fmt.Errorf("propagation.timeout %s is out of range, maximum is %s", p.Timeout, MaxPropagationTimeout)
+	}
+	if p.Interval.Duration < 0 || p.Interval.Duration > MaxPropagationTimeout {
+		return fmt.Errorf("propagation.interval %s is out of range, maximum is %s", p.Interval, MaxPropagationTimeout)
+	}
+	if p.MinMatches < 0 || p.MinMatches > servers {
+		return fmt.Errorf("propagation.minMatches %d is out of range, must be between 0 and %d", p.MinMatches, servers)
+	}
+	p.SecondaryCheck = strings.ToLower(p.SecondaryCheck)
+	switch p.SecondaryCheck {
+	case "", SecondaryCheckRecords, SecondaryCheckSerial:
+	default:
+		return fmt.Errorf("propagation.secondaryCheck %q is unknown, expected %s or %s",
+			p.SecondaryCheck, SecondaryCheckRecords, SecondaryCheckSerial)
+	}
+	return validateResolverSets(p.Resolvers)
+}
+
+// validate checks the retry settings; nil is valid
+func (r *RetryConfig) validate() error {
+	if r == nil {
+		return nil
+	}
+	if r.Attempts < 0 || r.Attempts > MaxRetryAttempts {
+		return fmt.Errorf("retry.attempts %d is out of range, must be between 0 and %d", r.Attempts, MaxRetryAttempts)
+	}
+	if r.BaseDelay.Duration < 0 || r.BaseDelay.Duration > MaxRetryDelay {
+		return fmt.Errorf("retry.baseDelay %s is out of range, maximum is %s", r.BaseDelay, MaxRetryDelay)
+	}
+	if r.MaxDelay.Duration < 0 || r.MaxDelay.Duration > MaxRetryDelay {
+		return fmt.Errorf("retry.maxDelay %s is out of range, maximum is %s", r.MaxDelay, MaxRetryDelay)
+	}
+	if r.MaxDelay.Duration > 0 && r.MaxDelay.Duration < r.BaseDelay.Duration {
+		return fmt.Errorf("retry.maxDelay %s is shorter than retry.baseDelay %s", r.MaxDelay, r.BaseDelay)
+	}
+	for i, name := range r.Rcodes {
+		if _, ok := dns.StringToRcode[strings.ToUpper(name)]; !ok {
+			return fmt.Errorf("retry.rcodes[%d] %q is not a known rcode", i, name)
+		}
+	}
+	return nil
+}
+
+// validate checks the PowerDNS provider settings
+func (p *PowerDNSConfig) validate() error {
+	if p == nil {
+		return fmt.Errorf("powerdns settings are required for the powerdns provider")
+	}
+	u, err := url.Parse(p.APIURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("powerdns.apiUrl %q is not an http(s) URL", p.APIURL)
+	}
+	if len(p.APIURL) > MaxNameLength || len(p.ServerID) > MaxNameLength {
+		return fmt.Errorf("powerdns.apiUrl and powerdns.serverId must be at most %d characters", MaxNameLength)
+	}
+	if strings.Contains(p.ServerID, "/") {
+		return fmt.Errorf("powerdns.serverId %q must not contain '/'", p.ServerID)
+	}
+	if p.APIKeySecretName == "" {
+		return fmt.Errorf("powerdns.apiKeySecretName is required")
+	}
+	if len(p.APIKeySecretName) > MaxNameLength || len(p.APIKeySecretKey) > MaxNameLength {
+		return fmt.Errorf("powerdns.apiKeySecretName and powerdns.apiKeySecretKey must be at most %d characters", MaxNameLength)
+	}
+	return nil
+}
+
+// setDefaults fills the server ID and API key Secret key left empty
+func (p *PowerDNSConfig) setDefaults() {
+	if p == nil {
+		return
+	}
+	if p.ServerID == "" {
+		p.ServerID = DefaultPowerDNSServerID
+	}
+	if p.APIKeySecretKey == "" {
+		p.APIKeySecretKey = DefaultPowerDNSAPIKeySecretKey
+	}
+}
+
+// validate checks the CoreDNS etcd provider settings
+func (e *EtcdConfig) validate() error {
+	if e == nil {
+		return fmt.Errorf("etcd settings are required for the coredns-etcd provider")
+	}
+	if len(e.Endpoints) == 0 {
+		return fmt.Errorf("etcd.endpoints is required")
+	}
+	if len(e.Endpoints) > MaxServers {
+		return fmt.Errorf("etcd.endpoints has %d entries, maximum is %d", len(e.Endpoints), MaxServers)
+	}
+	for i, endpoint := range e.Endpoints {
+		u, err := url.Parse(endpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || len(endpoint) > MaxNameLength {
+			return fmt.Errorf("etcd.endpoints[%d] %q is not an http(s) URL", i, endpoint)
+		}
+	}
+	if len(e.Prefix) > MaxNameLength || len(e.CredentialsSecretName) > MaxNameLength {
+		return fmt.Errorf("etcd.prefix and etcd.credentialsSecretName must be at most %d characters", MaxNameLength)
+	}
+	return nil
+}
+
+// validate checks the bridged views; a nil bridge is valid
+func (b *BridgeConfig) validate() error {
+	if b == nil {
+		return nil
+	}
+	if b.Route53 == nil {
+		return fmt.Errorf("bridge must configure route53")
+	}
+	r := b.Route53
+	zoneID := strings.TrimPrefix(r.HostedZoneID, "/hostedzone/")
+	if zoneID == "" || len(zoneID) > 32 || strings.Trim(zoneID, "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789") != "" {
+		return fmt.Errorf("bridge.route53.hostedZoneID %q is not a hosted zone ID", r.HostedZoneID)
+	}
+	if r.CredentialsSecretName == "" {
+		return fmt.Errorf("bridge.route53.credentialsSecretName is required")
+	}
+	if len(r.CredentialsSecretName) > MaxNameLength {
+		return fmt.Errorf("bridge.route53.credentialsSecretName must be at most %d characters", MaxNameLength)
+	}
+	if r.Endpoint != "" {
+		u, err := url.Parse(r.Endpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || len(r.Endpoint) > MaxNameLength {
+			return fmt.Errorf("bridge.route53.endpoint %q is not an http(s) URL", r.Endpoint)
+		}
+	}
+	return nil
+}
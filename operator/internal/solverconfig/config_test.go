@@ -0,0 +1,422 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package solverconfig
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+
+	rfc2136 "github.com/rieset/istio-dns01-bind9/internal/dns"
+)
+
+const validConfig = `{"servers":["192.0.2.1","192.0.2.2"],"zone":"example.com",` +
+	`"tsigKeyName":"acme-example-com","tsigSecretName":"tsig"}`
+
+func TestParse(t *testing.T) {
+	manyServers := make([]string, MaxServers+1)
+	for i := range manyServers {
+		manyServers[i] = fmt.Sprintf("%q", fmt.Sprintf("192.0.2.%d", i+1))
+	}
+
+	tests := []struct {
+		name    string
+		raw     string
+		wantErr string
+	}{
+		{"valid", validConfig, ""},
+		{"empty", "", "config is empty"},
+		{"malformed", `{"servers":`, "failed to unmarshal"},
+		{"no servers", `{"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s"}`, "servers list is required"},
+		{"too many servers", `{"servers":[` + strings.Join(manyServers, ",") + `],"zone":"example.com",` +
+			`"tsigKeyName":"k","tsigSecretName":"s"}`, "maximum is 32"},
+		{"empty server", `{"servers":[""],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s"}`, "servers[0] is empty"},
+		{"duplicate server", `{"servers":["a","a"],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s"}`,
+			"duplicates"},
+		{"secondaries", `{"servers":["a"],"secondaries":["b","[2001:db8::1]:5353"],"zone":"example.com",` +
+			`"tsigKeyName":"k","tsigSecretName":"s"}`, ""},
+		{"secondary also a server", `{"servers":["a"],"secondaries":["a"],"zone":"example.com",` +
+			`"tsigKeyName":"k","tsigSecretName":"s"}`, "also listed in servers"},
+		{"duplicate secondary", `{"servers":["a"],"secondaries":["b","b"],"zone":"example.com",` +
+			`"tsigKeyName":"k","tsigSecretName":"s"}`, "secondaries[1] duplicates"},
+		{"bad secondary", `{"servers":["a"],"secondaries":["b:port"],"zone":"example.com",` +
+			`"tsigKeyName":"k","tsigSecretName":"s"}`, "secondaries[0]"},
+		{"too many secondaries", `{"servers":["a"],"secondaries":[` + strings.Join(manyServers[1:], ",") + `],` +
+			`"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s"}`, "servers and secondaries have 33 entries"},
+		{"bad zone", `{"servers":["a"],"zone":"exa mple..com","tsigKeyName":"k","tsigSecretName":"s"}`,
+			"not a valid domain name"},
+		{"discovered zone", `{"servers":["a"],"tsigKeyName":"k","tsigSecretName":"s"}`, ""},
+		{"powerdns without zone", `{"provider":"powerdns","powerdns":{"apiUrl":"http://p","apiKeySecretName":"s"}}`,
+			"zone is required"},
+		{"negative ttl", `{"servers":["a"],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s","ttl":-1}`,
+			"out of range"},
+		{"absurd ttl", `{"servers":["a"],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s","ttl":2147483647}`,
+			"out of range"},
+		{"tsig fudge", `{"servers":["a"],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s","tsigFudge":900}`, ""},
+		{"absurd tsig fudge", `{"servers":["a"],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s",` +
+			`"tsigFudge":86400}`, "tsigFudge 86400 is out of range"},
+		{"server mode", `{"servers":["a","b"],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s",` +
+			`"serverModes":{"b":"PowerDNS"}}`, ""},
+		{"unknown server mode", `{"servers":["a"],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s",` +
+			`"serverModes":{"a":"djbdns"}}`, "unknown server mode"},
+		{"mode for unlisted server", `{"servers":["a"],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s",` +
+			`"serverModes":{"b":"knot"}}`, "not listed in servers"},
+		{"powerdns", `{"zone":"example.com","provider":"powerdns",` +
+			`"powerdns":{"apiUrl":"http://pdns:8081","apiKeySecretName":"pdns"}}`, ""},
+		{"powerdns without settings", `{"zone":"example.com","provider":"powerdns"}`, "powerdns settings are required"},
+		{"powerdns bad url", `{"zone":"example.com","provider":"powerdns",` +
+			`"powerdns":{"apiUrl":"pdns:8081","apiKeySecretName":"pdns"}}`, "not an http(s) URL"},
+		{"coredns-etcd", `{"zone":"example.com","provider":"coredns-etcd","etcd":{"endpoints":["https://etcd:2379"]}}`, ""},
+		{"coredns-etcd without endpoints", `{"zone":"example.com","provider":"coredns-etcd","etcd":{}}`,
+			"etcd.endpoints is required"},
+		{"coredns-etcd bad endpoint", `{"zone":"example.com","provider":"coredns-etcd","etcd":{"endpoints":["etcd:2379"]}}`,
+			"not an http(s) URL"},
+		{"route53 bridge", `{"servers":["a"],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s",` +
+			`"bridge":{"route53":{"hostedZoneID":"/hostedzone/Z0123456789ABC","credentialsSecretName":"aws"}}}`, ""},
+		{"route53 bridge bad zone id", `{"servers":["a"],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s",` +
+			`"bridge":{"route53":{"hostedZoneID":"z/../x","credentialsSecretName":"aws"}}}`, "not a hosted zone ID"},
+		{"empty bridge", `{"servers":["a"],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s","bridge":{}}`,
+			"bridge must configure route53"},
+		{"propagation", `{"servers":["a","b"],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s",` +
+			`"propagation":{"timeout":"2m","interval":"5s","minMatches":2,"checkPublicNS":true}}`, ""},
+		{"propagation bad duration", `{"servers":["a"],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s",` +
+			`"propagation":{"timeout":120}}`, "duration must be a string"},
+		{"propagation long timeout", `{"servers":["a"],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s",` +
+			`"propagation":{"timeout":"2h"}}`, "propagation.timeout 2h0m0s is out of range"},
+		{"propagation too many matches", `{"servers":["a"],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s",` +
+			`"propagation":{"minMatches":2}}`, "propagation.minMatches 2 is out of range"},
+		{"propagation serial watch", `{"servers":["a"],"secondaries":["b"],"zone":"example.com","tsigKeyName":"k",` +
+			`"tsigSecretName":"s","propagation":{"secondaryCheck":"Serial"}}`, ""},
+		{"propagation unknown secondary check", `{"servers":["a"],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s",` +
+			`"propagation":{"secondaryCheck":"ixfr"}}`, `propagation.secondaryCheck "ixfr" is unknown`},
+		{"tcp transport", `{"servers":["a"],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s","transport":"TCP"}`, ""},
+		{"unknown transport", `{"servers":["a"],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s","transport":"quic"}`,
+			`unknown transport "quic"`},
+		{"dns over tls", `{"servers":["a","b:8853"],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s","transport":"tls",` +
+			`"tls":{"secretName":"dot-ca"},"serverTLS":{"b:8853":{"serverName":"ns2.example.com"}}}`, ""},
+		{"tls without tls transport", `{"servers":["a"],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s",` +
+			`"tls":{"secretName":"dot-ca"}}`, `require transport "tls"`},
+		{"serverTLS unknown server", `{"servers":["a"],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s","transport":"tls",` +
+			`"serverTLS":{"b":{}}}`, `serverTLS entry "b" is not listed in servers`},
+		{"bad tls server name", `{"servers":["a"],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s","transport":"tls",` +
+			`"tls":{"serverName":"ns1 example"}}`, `tls.serverName "ns1 example" is not a valid host name`},
+		{"write policy all", `{"servers":["a","b"],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s","writePolicy":"All"}`, ""},
+		{"min success", `{"servers":["a","b"],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s","minSuccess":2}`, ""},
+		{"unknown write policy", `{"servers":["a"],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s","writePolicy":"most"}`,
+			`unknown writePolicy "most"`},
+		{"min success above servers", `{"servers":["a"],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s","minSuccess":2}`,
+			"minSuccess 2 is out of range"},
+		{"write policy and min success", `{"servers":["a","b"],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s",` +
+			`"writePolicy":"any","minSuccess":1}`, "mutually exclusive"},
+		{"retry", `{"servers":["a"],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s",` +
+			`"retry":{"attempts":3,"baseDelay":"200ms","maxDelay":"2s","rcodes":["servfail","NOTAUTH"]}}`, ""},
+		{"retry attempts out of range", `{"servers":["a"],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s",` +
+			`"retry":{"attempts":11}}`, "retry.attempts 11 is out of range"},
+		{"retry max delay below base delay", `{"servers":["a"],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s",` +
+			`"retry":{"attempts":2,"baseDelay":"2s","maxDelay":"1s"}}`, "shorter than retry.baseDelay"},
+		{"unknown retry rcode", `{"servers":["a"],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s",` +
+			`"retry":{"attempts":2,"rcodes":["FLAKY"]}}`, `retry.rcodes[0] "FLAKY" is not a known rcode`},
+		{"prerequisites", `{"servers":["a"],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s",` +
+			`"prerequisites":{"nameNotInUse":true,"rrsetExists":true}}`, ""},
+		{"prerequisites with powerdns", `{"zone":"example.com","provider":"powerdns",` +
+			`"powerdns":{"apiUrl":"http://pdns:8081","apiKeySecretName":"pdns"},"prerequisites":{"rrsetExists":true}}`,
+			`prerequisites require provider "rfc2136"`},
+		{"vault secret provider", `{"servers":["a"],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s",` +
+			`"secretProvider":"Vault"}`, ""},
+		{"unknown secret provider", `{"servers":["a"],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s",` +
+			`"secretProvider":"aws"}`, `unknown secretProvider "aws"`},
+		{"file secret provider with sig0", `{"servers":["a"],"zone":"example.com","authMethod":"sig0",` +
+			`"sig0":{"secretName":"sig0-key"},"secretProvider":"file"}`, `secretProvider "file" requires provider "rfc2136" with TSIG`},
+		{"sig0", `{"servers":["a"],"zone":"example.com","authMethod":"sig0","sig0":{"secretName":"sig0-key"}}`, ""},
+		{"sig0 without key", `{"servers":["a"],"zone":"example.com","authMethod":"SIG0"}`, "sig0.secretName is required"},
+		{"sig0 key without auth method", `{"servers":["a"],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s",` +
+			`"sig0":{"secretName":"sig0-key"}}`, `sig0 requires authMethod "sig0"`},
+		{"unknown auth method", `{"servers":["a"],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s","authMethod":"gss"}`,
+			`unknown authMethod "gss"`},
+		{"sig0 with powerdns", `{"zone":"example.com","provider":"powerdns","powerdns":{"apiUrl":"http://p","apiKeySecretName":"s"},` +
+			`"authMethod":"sig0","sig0":{"secretName":"k"}}`, "requires provider rfc2136"},
+		{"hmac-sha384", `{"servers":["a"],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s","tsigAlgorithm":"HMACSHA384"}`, ""},
+		{"hmac-md5", `{"servers":["a"],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s",` +
+			`"tsigAlgorithm":"hmac-md5.sig-alg.reg.int.","allowDeprecatedTSIGAlgorithm":true}`, "no longer supported"},
+		{"unknown algorithm", `{"servers":["a"],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s","tsigAlgorithm":"hmac-sha3"}`,
+			`tsigAlgorithm: unknown TSIG algorithm "hmac-sha3"`},
+		{"deprecated algorithm", `{"servers":["a"],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s","tsigAlgorithm":"hmac-sha1"}`,
+			`deprecated TSIG algorithm "hmac-sha1"`},
+		{"allowed deprecated algorithm", `{"servers":["a"],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s",` +
+			`"tsigAlgorithm":"hmac-sha1","allowDeprecatedTSIGAlgorithm":true}`, ""},
+		{"unknown server algorithm", `{"servers":[{"address":"a","algorithm":"sha256"}],"zone":"example.com","tsigKeyName":"k",` +
+			`"tsigSecretName":"s"}`, `servers["a"].algorithm: unknown TSIG algorithm "sha256"`},
+		{"zones only", `{"tsigKeyName":"k","tsigSecretName":"s","zones":[{"zone":"example.com","servers":["a"]},` +
+			`{"zone":"example.org","servers":["b"],"tsigKeyName":"k2","tsigSecretName":"s2"}]}`, ""},
+		{"zone without servers", `{"tsigKeyName":"k","tsigSecretName":"s","zones":[{"zone":"example.com"}]}`,
+			"zones[0].servers list is required"},
+		{"duplicate zone", `{"tsigKeyName":"k","tsigSecretName":"s","zones":[{"zone":"example.com","servers":["a"]},` +
+			`{"zone":"Example.com.","servers":["b"]}]}`, `zones[1].zone duplicates "Example.com."`},
+		{"bad zone server", `{"tsigKeyName":"k","tsigSecretName":"s","zones":[{"zone":"example.com","servers":["a","a"]}]}`,
+			`zones[0].servers[1] duplicates "a"`},
+		{"zone without key", `{"zones":[{"zone":"example.com","servers":["a"],"tsigSecretName":"s"}]}`,
+			"zones[0].tsigKeyName is required"},
+		{"zone algorithm", `{"tsigKeyName":"k","tsigSecretName":"s","zones":[{"zone":"example.com","servers":["a"],` +
+			`"tsigAlgorithm":"hmac-sha3"}]}`, `zones[0].tsigAlgorithm: unknown TSIG algorithm "hmac-sha3"`},
+		{"zone min success", `{"tsigKeyName":"k","tsigSecretName":"s","minSuccess":2,"zones":[{"zone":"example.com","servers":["a"]}]}`,
+			"minSuccess 2 exceeds the 1 servers of zones[0]"},
+		{"zones with powerdns", `{"zone":"example.com","provider":"powerdns","powerdns":{"apiUrl":"http://p","apiKeySecretName":"s"},` +
+			`"zones":[{"zone":"example.org","servers":["a"]}]}`, `zones require provider "rfc2136"`},
+		{"follow cname", `{"servers":["a"],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s",` +
+			`"followCNAME":{"maxHops":3,"resolvers":["10.0.0.53"]}}`, ""},
+		{"cname hops", `{"servers":["a"],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s","followCNAME":{"maxHops":11}}`,
+			"followCNAME.maxHops 11 is out of range"},
+		{"cname resolver", `{"servers":["a"],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s",` +
+			`"followCNAME":{"resolvers":["[::1"]}}`, "followCNAME.resolvers[0]"},
+		{"unknown provider", `{"servers":["a"],"zone":"example.com","provider":"route53"}`, "unknown provider"},
+		{"oversized", `{"zone":"` + strings.Repeat("a", MaxConfigSize) + `"}`, "maximum is 65536"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.raw))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Parse: unexpected error %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Parse error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseDefaults(t *testing.T) {
+	config, err := Parse([]byte(`{"servers":["a"],"zone":"example.com","tsigKeyName":"k",` +
+		`"tsigSecretName":"s","tsigAlgorithm":"","tsigSecretKey":""}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.TTL != DefaultTTL || config.TSIGAlgorithm != DefaultTSIGAlgorithm || config.TSIGSecretKey != DefaultTSIGSecretKey ||
+		config.Provider != ProviderRFC2136 {
+		t.Fatalf("defaults not applied: %+v", config)
+	}
+
+	// Algorithms and key names are stored in their canonical form
+	config, err = Parse([]byte(`{"servers":["a"],"zone":"example.com","tsigKeyName":"ACME-Key",` +
+		`"tsigSecretName":"s","tsigAlgorithm":"HMAC-SHA512."}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.TSIGKeyName != "acme-key." || config.TSIGAlgorithm != "hmac-sha512" {
+		t.Fatalf("TSIG key = %q, %q, want acme-key., hmac-sha512", config.TSIGKeyName, config.TSIGAlgorithm)
+	}
+
+	config, err = Parse([]byte(`{"servers":["a"],"zone":"example.com","authMethod":"sig0","sig0":{"secretName":"sig0-key"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name, key := config.SecretRef(); name != "sig0-key" || key != "" || !config.UsesSIG0() ||
+		config.SIG0.PublicKeySecretKey != DefaultSIG0PublicKeySecretKey ||
+		config.SIG0.PrivateKeySecretKey != DefaultSIG0PrivateKeySecretKey {
+		t.Fatalf("sig0 defaults not applied: %+v", config.SIG0)
+	}
+
+	config, err = Parse([]byte(`{"zone":"example.com","provider":"powerdns",` +
+		`"powerdns":{"apiUrl":"http://pdns:8081","apiKeySecretName":"pdns"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name, key := config.SecretRef(); name != "pdns" || key != DefaultPowerDNSAPIKeySecretKey ||
+		config.PowerDNS.ServerID != DefaultPowerDNSServerID {
+		t.Fatalf("powerdns defaults not applied: %+v", config.PowerDNS)
+	}
+}
+
+func TestRequiredWrites(t *testing.T) {
+	tests := []struct {
+		policy     string
+		minSuccess int
+		servers    int
+		want       int
+	}{
+		{"", 0, 1, 1},
+		{"", 0, 4, 3},
+		{"majority", 0, 5, 3},
+		{"all", 0, 5, 5},
+		{"ANY", 0, 5, 1},
+		{"", 2, 5, 2},
+		// Servers already done when a Present is resumed shrink the list below minSuccess
+		{"", 4, 2, 2},
+	}
+	for _, tt := range tests {
+		config := Config{WritePolicy: tt.policy, MinSuccess: tt.minSuccess, Servers: make([]string, tt.servers)}
+		if got := config.RequiredWrites(); got != tt.want {
+			t.Errorf("RequiredWrites(%q, %d, %d servers) = %d, want %d", tt.policy, tt.minSuccess, tt.servers, got, tt.want)
+		}
+	}
+}
+
+func TestRetryPolicy(t *testing.T) {
+	config, err := Parse([]byte(`{"servers":["a"],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s",` +
+		`"retry":{"attempts":3,"baseDelay":"200ms","maxDelay":"2s","rcodes":["servfail","NOTAUTH"]}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := rfc2136.RetryPolicy{
+		Attempts:  3,
+		BaseDelay: 200 * time.Millisecond,
+		MaxDelay:  2 * time.Second,
+		Rcodes:    []int{dns.RcodeServerFailure, dns.RcodeNotAuth},
+	}
+	if got := config.RetryPolicy(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("RetryPolicy() = %+v, want %+v", got, want)
+	}
+	if got := (&Config{}).RetryPolicy(); !reflect.DeepEqual(got, rfc2136.RetryPolicy{}) {
+		t.Fatalf("RetryPolicy() without retry = %+v, want the zero policy", got)
+	}
+}
+
+func TestServerQuirks(t *testing.T) {
+	config, err := Parse([]byte(`{"servers":["a","b"],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s",` +
+		`"serverModes":{"b":"knot"},"tsigFudge":900}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := rfc2136.QuirksFor(rfc2136.CompatKnot)
+	want.TSIGFudge = 900
+	if got := config.ServerQuirks("b"); got != want {
+		t.Fatalf("ServerQuirks(b) = %+v, want %+v", got, want)
+	}
+	if got := (&Config{}).ServerQuirks("a"); got != rfc2136.DefaultQuirks {
+		t.Fatalf("ServerQuirks without tsigFudge = %+v, want the bind quirks", got)
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	f.Add([]byte(validConfig))
+	f.Add([]byte(`{"servers":["a"],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s","ttl":1e309}`))
+	f.Add([]byte(`{"servers":null,"zone":null}`))
+	f.Add([]byte(`{"servers":["a"],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s","serverModes":{"a":"windows"}}`))
+	f.Add([]byte(`{"zone":"example.com","provider":"powerdns","powerdns":{"apiUrl":"http://p","apiKeySecretName":"s"}}`))
+	f.Add([]byte(`{"zone":"example.com","provider":"coredns-etcd","etcd":{"endpoints":["http://e:2379"]}}`))
+	f.Add([]byte(`{"servers":["a"],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s",` +
+		`"bridge":{"route53":{"hostedZoneID":"Z1","credentialsSecretName":"aws"}}}`))
+	f.Add([]byte(`{"servers":["a"],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s",` +
+		`"propagation":{"timeout":"1m","minMatches":1}}`))
+	f.Add([]byte(`{"servers":["a","b"],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s","minSuccess":2}`))
+	f.Add([]byte(`{"servers":[{"address":"a","port":53,"zone":"sub.example.com","tsigKeyName":"k2"}],` +
+		`"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s"}`))
+	f.Add([]byte(`{"servers":["a"],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s",` +
+		`"retry":{"attempts":3,"baseDelay":"1s","maxDelay":"5s","rcodes":["SERVFAIL"]}}`))
+	f.Add([]byte(`{"servers":["a"],"zone":"example.com","authMethod":"sig0","sig0":{"secretName":"k","privateKeySecretKey":"p"}}`))
+	f.Add([]byte(`[]`))
+	f.Add([]byte(`{"servers":["\u0000"],"zone":"."}`))
+
+	f.Fuzz(func(t *testing.T, raw []byte) {
+		config, err := Parse(raw)
+		if err != nil {
+			return
+		}
+		if len(config.Servers) > MaxServers {
+			t.Fatalf("accepted %d servers", len(config.Servers))
+		}
+		if config.TTL < 1 || config.TTL > MaxTTL {
+			t.Fatalf("accepted ttl %d", config.TTL)
+		}
+		if config.Zone == "" && config.Provider != ProviderRFC2136 {
+			t.Fatalf("accepted config without a zone: %+v", config)
+		}
+		if p := config.Propagation; p != nil && (p.Timeout.Duration < 0 || p.Timeout.Duration > MaxPropagationTimeout ||
+			p.MinMatches < 0 || p.MinMatches > len(config.Servers)) {
+			t.Fatalf("accepted propagation settings %+v", p)
+		}
+		if r := config.Retry; r != nil && (r.Attempts < 0 || r.Attempts > MaxRetryAttempts ||
+			r.BaseDelay.Duration < 0 || r.MaxDelay.Duration > MaxRetryDelay) {
+			t.Fatalf("accepted retry settings %+v", r)
+		}
+		switch config.Provider {
+		case ProviderRFC2136:
+			if len(config.Servers) == 0 {
+				t.Fatalf("accepted rfc2136 config without servers: %+v", config)
+			}
+			if n := config.RequiredWrites(); n < 1 || n > len(config.Servers) {
+				t.Fatalf("write quorum %d of %d servers", n, len(config.Servers))
+			}
+			if config.UsesSIG0() {
+				if config.SIG0.SecretName == "" {
+					t.Fatalf("accepted sig0 config without a key Secret: %+v", config)
+				}
+				break
+			}
+			for _, server := range config.Servers {
+				if settings := config.ServerSettings(server); settings.TSIGKeyName == "" || settings.TSIGSecretName == "" {
+					t.Fatalf("accepted rfc2136 server %q without credentials: %+v", server, config)
+				}
+			}
+		case ProviderPowerDNS:
+			if config.PowerDNS == nil || config.PowerDNS.APIURL == "" || config.PowerDNS.APIKeySecretName == "" {
+				t.Fatalf("accepted powerdns config without required fields: %+v", config)
+			}
+		case ProviderCoreDNSEtcd:
+			if config.Etcd == nil || len(config.Etcd.Endpoints) == 0 {
+				t.Fatalf("accepted coredns-etcd config without endpoints: %+v", config)
+			}
+		default:
+			t.Fatalf("accepted provider %q", config.Provider)
+		}
+	})
+}
+
+func TestParseReportsAllProblems(t *testing.T) {
+	raw := `{"servers":["a",{"address":"b:53"},"a"],"zone":"example.com","ttl":-1,"tsigKey":"k",` +
+		`"tsigAlgorithm":"hmac-md4","tsigSecretName":"s","retry":{"attempts":99,"jitter":true}}`
+	_, err := Parse([]byte(raw))
+	var invalid *ValidationError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("Parse error = %v, want a ValidationError", err)
+	}
+	want := []string{
+		`unknown field "retry.jitter"`,
+		`unknown field "tsigKey"`,
+		`servers[1].address "b:53" must not include a port`,
+		`servers[2] duplicates "a"`,
+		"ttl -1 is out of range",
+		"retry.attempts 99 is out of range",
+		"tsigAlgorithm:",
+		`tsigKeyName is required for server "a"`,
+	}
+	if len(invalid.Problems) != len(want) {
+		t.Fatalf("problems = %v, want %d", invalid.Problems, len(want))
+	}
+	for i, problem := range invalid.Problems {
+		if !strings.Contains(problem.Error(), want[i]) {
+			t.Errorf("problem %d = %q, want %q", i, problem, want[i])
+		}
+	}
+	if !strings.HasPrefix(err.Error(), "8 problems: (1) ") {
+		t.Errorf("Error() = %q, want the numbered problems", err)
+	}
+}
+
+func TestParseCaseInsensitiveFields(t *testing.T) {
+	raw := `{"Servers":[{"address":"a","TSIGKeyName":"k"}],"zone":"example.com","tsigsecretname":"s"}`
+	if _, err := Parse([]byte(raw)); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package solverconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// FunctionRating: 84/100
+// - Complexity: LOW
+// - Integrations: 0
+// - External Risks: LOW (pure transformation of bounded input)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: PARTIAL (configs are merged as generic JSON objects)
+// - Critical Issues: NONE
+//
+// Function: MergeDefaults
+// Purpose: Layers the solver config of an Issuer over shared defaults
+
+// DefaultsKey is the key of a defaults ConfigMap holding the default config
+const DefaultsKey = "config.json"
+
+// ParseDefaults checks that raw is a JSON object usable as defaults. The
+// defaults need not be a complete config on their own.
+func ParseDefaults(raw []byte) (map[string]any, error) {
+	if len(raw) > MaxConfigSize {
+		return nil, fmt.Errorf("defaults are %d bytes, maximum is %d", len(raw), MaxConfigSize)
+	}
+	var defaults map[string]any
+	if err := json.Unmarshal(raw, &defaults); err != nil || defaults == nil {
+		return nil, fmt.Errorf("defaults must be a JSON object")
+	}
+	if paths := unknownFields(raw); len(paths) > 0 {
+		return nil, fmt.Errorf("defaults have unknown fields %s", strings.Join(paths, ", "))
+	}
+	return defaults, nil
+}
+
+// MergeDefaults returns raw with the fields it does not set taken from
+// defaults. Objects are merged field by field, other values of raw replace
+// those of defaults, and a null in raw drops the default, as in a JSON merge
+// patch (RFC 7386). An empty raw stands for an empty object.
+func MergeDefaults(defaults map[string]any, raw []byte) ([]byte, error) {
+	if len(defaults) == 0 {
+		return raw, nil
+	}
+	if len(bytes.TrimSpace(raw)) == 0 {
+		raw = []byte("{}")
+	}
+	var overrides map[string]any
+	if err := json.Unmarshal(raw, &overrides); err != nil || overrides == nil {
+		return nil, fmt.Errorf("config must be a JSON object to merge defaults into")
+	}
+	return json.Marshal(mergeObjects(defaults, overrides))
+}
+
+// mergeObjects returns base with patch applied, leaving both unchanged
+func mergeObjects(base, patch map[string]any) map[string]any {
+	merged := make(map[string]any, len(base)+len(patch))
+	for key, value := range base {
+		merged[key] = value
+	}
+	for key, value := range patch {
+		switch v := value.(type) {
+		case nil:
+			delete(merged, key)
+		case map[string]any:
+			nested, _ := merged[key].(map[string]any)
+			merged[key] = mergeObjects(nested, v)
+		default:
+			merged[key] = v
+		}
+	}
+	return merged
+}
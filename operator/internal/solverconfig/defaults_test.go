@@ -0,0 +1,92 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package solverconfig
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMergeDefaults(t *testing.T) {
+	defaults, err := ParseDefaults([]byte(`{"servers":["192.0.2.1","192.0.2.2"],"tsigKeyName":"acme",` +
+		`"tsigSecretName":"tsig","ttl":120,"propagation":{"timeout":"2m","minMatches":2}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{"empty", "", `{"servers":["192.0.2.1","192.0.2.2"],"tsigKeyName":"acme","tsigSecretName":"tsig",` +
+			`"ttl":120,"propagation":{"timeout":"2m","minMatches":2}}`},
+		{"override scalars and lists", `{"zone":"example.com","ttl":60,"servers":["192.0.2.9"]}`,
+			`{"servers":["192.0.2.9"],"tsigKeyName":"acme","tsigSecretName":"tsig","ttl":60,"zone":"example.com",` +
+				`"propagation":{"timeout":"2m","minMatches":2}}`},
+		{"merge objects", `{"propagation":{"minMatches":1,"timeout":null}}`,
+			`{"servers":["192.0.2.1","192.0.2.2"],"tsigKeyName":"acme","tsigSecretName":"tsig","ttl":120,` +
+				`"propagation":{"minMatches":1}}`},
+		{"drop default", `{"servers":null,"bind9Cluster":{"namespace":"dns","name":"bind9"}}`,
+			`{"tsigKeyName":"acme","tsigSecretName":"tsig","ttl":120,"propagation":{"timeout":"2m","minMatches":2},` +
+				`"bind9Cluster":{"namespace":"dns","name":"bind9"}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, err := MergeDefaults(defaults, []byte(tt.raw))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got, want map[string]any
+			if err := json.Unmarshal(merged, &got); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal([]byte(tt.want), &want); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("MergeDefaults = %s, want %s", merged, tt.want)
+			}
+		})
+	}
+
+	if _, err := MergeDefaults(defaults, []byte(`["a"]`)); err == nil {
+		t.Error("MergeDefaults accepted a config that is not an object")
+	}
+}
+
+func TestParseDefaultsErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		wantErr string
+	}{
+		{"not an object", `["a"]`, "must be a JSON object"},
+		{"null", `null`, "must be a JSON object"},
+		{"unknown field", `{"severs":["a"],"zone":"example.com"}`, `unknown fields severs`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseDefaults([]byte(tt.raw))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("ParseDefaults error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
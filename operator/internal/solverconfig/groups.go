@@ -0,0 +1,185 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package solverconfig
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	rfc2136 "github.com/rieset/istio-dns01-bind9/internal/dns"
+)
+
+// FunctionRating: 82/100
+// - Complexity: LOW
+// - Integrations: 0
+// - External Risks: LOW (pure parsing, bounded input)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: ServerGroupConfig, ServerProxy, GroupQuorums
+// Purpose: Named groups of servers with their own proxy and write quorum, for zones replicated across clusters and sites
+
+// MaxServerGroups is the largest number of entries in serverGroups
+const MaxServerGroups = 16
+
+// ServerGroupConfig names a set of entries of Servers that are reached the
+// same way, such as the primaries of one site, and must each accept an
+// update on their own
+type ServerGroupConfig struct {
+	// Name identifies the group in logs, events and errors
+	Name string `json:"name"`
+	// Servers are entries of Servers, by address or entry ID; a server
+	// belongs to at most one group
+	Servers []string `json:"servers"`
+	// MinSuccess is the number of the group's servers that must accept an
+	// update, default 1
+	MinSuccess int `json:"minSuccess,omitempty"`
+	// Proxy is a socks5://, http:// or https:// URL the servers of the group
+	// are reached through, for servers in another cluster or on-prem. Proxied
+	// servers are updated over TCP, or TLS with the tls transport.
+	Proxy string `json:"proxy,omitempty"`
+	// Timeouts replaces fields of the config's timeouts for the servers of
+	// the group, such as longer reads for servers on another continent
+	Timeouts *TimeoutConfig `json:"timeouts,omitempty"`
+}
+
+// GroupQuorum is the number of servers of one group that must accept an update
+type GroupQuorum struct {
+	Name     string
+	Servers  []string
+	Required int
+}
+
+// GroupQuorums returns the quorum of every entry of ServerGroups
+func (c *Config) GroupQuorums() []GroupQuorum {
+	quorums := make([]GroupQuorum, 0, len(c.ServerGroups))
+	for _, group := range c.ServerGroups {
+		required := group.MinSuccess
+		if required <= 0 {
+			required = 1
+		}
+		quorums = append(quorums, GroupQuorum{
+			Name:     group.Name,
+			Servers:  group.Servers,
+			Required: min(required, len(group.Servers)),
+		})
+	}
+	return quorums
+}
+
+// ServerProxy returns the proxy server is reached through, or nil when it is
+// reached directly
+func (c *Config) ServerProxy(server string) *rfc2136.Proxy {
+	for _, group := range c.ServerGroups {
+		if group.Proxy == "" {
+			continue
+		}
+		for _, member := range group.Servers {
+			if member != server {
+				continue
+			}
+			// Parse rejects configs with an invalid proxy
+			proxy, err := rfc2136.ParseProxy(group.Proxy)
+			if err != nil {
+				return nil
+			}
+			return proxy
+		}
+	}
+	return nil
+}
+
+// ServerProxies returns the proxy of every server reached through one
+func (c *Config) ServerProxies() map[string]*rfc2136.Proxy {
+	proxies := map[string]*rfc2136.Proxy{}
+	for _, server := range c.Servers {
+		if proxy := c.ServerProxy(server); proxy != nil {
+			proxies[server] = proxy
+		}
+	}
+	return proxies
+}
+
+// validateServerGroups checks serverGroups against servers, the set of
+// addresses in Servers, passing each problem to check
+func (c *Config) validateServerGroups(servers map[string]bool, check func(error)) {
+	if len(c.ServerGroups) == 0 {
+		return
+	}
+	if c.Provider != ProviderRFC2136 {
+		check(fmt.Errorf("serverGroups require provider %q", ProviderRFC2136))
+	}
+	if len(c.ServerGroups) > MaxServerGroups {
+		check(fmt.Errorf("serverGroups has %d entries, maximum is %d", len(c.ServerGroups), MaxServerGroups))
+	}
+	transport := c.DNSTransport()
+	names := map[string]bool{}
+	grouped := map[string]string{}
+	for i, group := range c.ServerGroups {
+		field := fmt.Sprintf("serverGroups[%d]", i)
+		switch {
+		case strings.TrimSpace(group.Name) == "":
+			check(fmt.Errorf("%s.name is required", field))
+		case len(group.Name) > MaxNameLength:
+			check(fmt.Errorf("%s.name must be at most %d characters", field, MaxNameLength))
+		case names[group.Name]:
+			check(fmt.Errorf("%s.name %q is used by another group", field, group.Name))
+		}
+		names[group.Name] = true
+
+		if len(group.Servers) == 0 {
+			check(fmt.Errorf("%s.servers is required", field))
+		}
+		for _, server := range group.Servers {
+			switch {
+			case !servers[server]:
+				check(fmt.Errorf("%s server %q is not listed in servers", field, server))
+			case grouped[server] != "":
+				check(fmt.Errorf("%s server %q already belongs to group %q", field, server, grouped[server]))
+			default:
+				grouped[server] = group.Name
+			}
+		}
+		if group.MinSuccess < 0 || group.MinSuccess > len(group.Servers) {
+			check(fmt.Errorf("%s.minSuccess %d is out of range, must be between 1 and %d",
+				field, group.MinSuccess, len(group.Servers)))
+		}
+
+		if group.Proxy == "" {
+			continue
+		}
+		proxy, err := rfc2136.ParseProxy(group.Proxy)
+		switch {
+		case err != nil:
+			check(fmt.Errorf("%s.proxy: %w", field, err))
+		case hasUserInfo(group.Proxy):
+			// Issuer specs are readable by many; proxies authorize by source address instead
+			check(fmt.Errorf("%s.proxy %q must not carry credentials", field, proxy))
+		case transport == rfc2136.TransportUDP:
+			check(fmt.Errorf("%s.proxy cannot carry transport %q, use tcp, tls or auto", field, transport))
+		}
+	}
+}
+
+// hasUserInfo reports whether the URL raw carries a user name or password
+func hasUserInfo(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && u.User != nil
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package solverconfig
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestServerGroups(t *testing.T) {
+	config, err := Parse([]byte(`{"servers":["10.0.0.1","10.0.0.2",{"address":"bind.onprem.example","port":5353},"10.0.0.4"],` +
+		`"tsigKeyName":"k","tsigSecretName":"s","serverGroups":[` +
+		`{"name":"mgmt","servers":["10.0.0.1","10.0.0.2"],"minSuccess":2},` +
+		`{"name":"onprem","servers":["bind.onprem.example:5353"],"proxy":"socks5://jump.example:1080"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	quorums := config.GroupQuorums()
+	if len(quorums) != 2 || quorums[0].Required != 2 || quorums[1].Name != "onprem" || quorums[1].Required != 1 {
+		t.Fatalf("GroupQuorums = %+v, want mgmt 2 and onprem 1", quorums)
+	}
+	// Without a write policy the groups alone set the quorum
+	if got := config.RequiredWrites(); got != 3 {
+		t.Fatalf("RequiredWrites = %d, want the sum of the group quorums 3", got)
+	}
+	if proxy := config.ServerProxy("bind.onprem.example:5353"); proxy == nil || proxy.String() != "socks5://jump.example:1080" {
+		t.Fatalf("ServerProxy of the on-prem server = %v, want the group proxy", proxy)
+	}
+	if proxy := config.ServerProxy("10.0.0.1"); proxy != nil {
+		t.Fatalf("ServerProxy of a direct server = %v, want nil", proxy)
+	}
+	if proxies := config.ServerProxies(); len(proxies) != 1 {
+		t.Fatalf("ServerProxies = %v, want the on-prem server only", proxies)
+	}
+
+	config.WritePolicy = WritePolicyAll
+	if got := config.RequiredWrites(); got != 4 {
+		t.Fatalf("RequiredWrites with writePolicy all = %d, want 4", got)
+	}
+}
+
+func TestServerGroupsValidation(t *testing.T) {
+	base := `"servers":["a","b","c"],"tsigKeyName":"k","tsigSecretName":"s"`
+	tests := []struct {
+		name    string
+		extra   string
+		wantErr string
+	}{
+		{name: "valid", extra: `"serverGroups":[{"name":"x","servers":["a"]},{"name":"y","servers":["b","c"],"minSuccess":2}]`},
+		{name: "missing name", extra: `"serverGroups":[{"servers":["a"]}]`, wantErr: "serverGroups[0].name is required"},
+		{name: "duplicate name", extra: `"serverGroups":[{"name":"x","servers":["a"]},{"name":"x","servers":["b"]}]`,
+			wantErr: "used by another group"},
+		{name: "no servers", extra: `"serverGroups":[{"name":"x"}]`, wantErr: "serverGroups[0].servers is required"},
+		{name: "unknown server", extra: `"serverGroups":[{"name":"x","servers":["d"]}]`, wantErr: "not listed in servers"},
+		{name: "server in two groups", extra: `"serverGroups":[{"name":"x","servers":["a"]},{"name":"y","servers":["a"]}]`,
+			wantErr: `already belongs to group "x"`},
+		{name: "minSuccess above group size", extra: `"serverGroups":[{"name":"x","servers":["a"],"minSuccess":2}]`,
+			wantErr: "minSuccess 2 is out of range"},
+		{name: "invalid proxy", extra: `"serverGroups":[{"name":"x","servers":["a"],"proxy":"ssh://jump:22"}]`,
+			wantErr: "must use scheme"},
+		{name: "proxy credentials", extra: `"serverGroups":[{"name":"x","servers":["a"],"proxy":"http://u:p@proxy:3128"}]`,
+			wantErr: "must not carry credentials"},
+		{name: "proxy over udp", extra: `"transport":"udp","serverGroups":[{"name":"x","servers":["a"],"proxy":"http://proxy:3128"}]`,
+			wantErr: `cannot carry transport "udp"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(`{` + base + `,` + tt.extra + `}`))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Parse: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Parse error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
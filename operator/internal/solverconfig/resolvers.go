@@ -0,0 +1,119 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package solverconfig
+
+import (
+	"fmt"
+	"strings"
+
+	rfc2136 "github.com/rieset/istio-dns01-bind9/internal/dns"
+)
+
+// FunctionRating: 84/100
+// - Complexity: LOW
+// - Integrations: 0
+// - External Risks: LOW (pure parsing, bounded input)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: ResolverSetConfig
+// Purpose: Resolver sets propagation checks wait for besides the authoritative servers
+
+// Types of resolver sets
+const (
+	// ResolverSetRecursive asks the resolvers listed in the set
+	ResolverSetRecursive = "recursive"
+	// ResolverSetSystem asks the resolvers of the webhook pod's resolv.conf,
+	// which cert-manager's self-check uses unless it is given nameservers
+	ResolverSetSystem = "system"
+)
+
+// MaxResolverSets is the number of resolver sets a config may list
+const MaxResolverSets = 8
+
+// ResolverSetConfig is a set of resolvers asked with recursion for the
+// challenge record once the authoritative servers serve it. Without any set
+// propagation checks ask the authoritative servers only.
+type ResolverSetConfig struct {
+	// Name identifies the set in logs, metrics and errors; defaults to Type
+	Name string `json:"name,omitempty"`
+	// Type is recursive or system
+	Type string `json:"type"`
+	// Servers are the resolvers of a recursive set
+	Servers []string `json:"servers,omitempty"`
+	// MinMatches is the number of resolvers that must serve the record;
+	// zero means all of them
+	MinMatches int `json:"minMatches,omitempty"`
+}
+
+// SetName returns the name of the set, its type when it has none
+func (r ResolverSetConfig) SetName() string {
+	if r.Name != "" {
+		return r.Name
+	}
+	return r.Type
+}
+
+// validateResolverSets checks the resolver sets of propagation and
+// lowercases their types
+func validateResolverSets(sets []ResolverSetConfig) error {
+	if len(sets) > MaxResolverSets {
+		return fmt.Errorf("propagation.resolvers has %d entries, maximum is %d", len(sets), MaxResolverSets)
+	}
+	names := make(map[string]bool, len(sets))
+	for i := range sets {
+		set := &sets[i]
+		set.Type = strings.ToLower(set.Type)
+		field := fmt.Sprintf("propagation.resolvers[%d]", i)
+		switch set.Type {
+		case ResolverSetRecursive:
+			if len(set.Servers) == 0 {
+				return fmt.Errorf("%s.servers is required for type %q", field, ResolverSetRecursive)
+			}
+		case ResolverSetSystem:
+			if len(set.Servers) > 0 {
+				return fmt.Errorf("%s.servers must be empty for type %q, which reads them from resolv.conf",
+					field, ResolverSetSystem)
+			}
+		default:
+			return fmt.Errorf("%s.type %q is unknown, expected %q or %q", field, set.Type, ResolverSetRecursive, ResolverSetSystem)
+		}
+		if len(set.Servers) > MaxServers {
+			return fmt.Errorf("%s.servers has %d entries, maximum is %d", field, len(set.Servers), MaxServers)
+		}
+		for j, server := range set.Servers {
+			if _, _, err := rfc2136.ParseServerAddress(server); err != nil {
+				return fmt.Errorf("%s.servers[%d]: %w", field, j, err)
+			}
+		}
+		limit := len(set.Servers)
+		if set.Type == ResolverSetSystem {
+			limit = MaxServers
+		}
+		if set.MinMatches < 0 || set.MinMatches > limit {
+			return fmt.Errorf("%s.minMatches %d is out of range, must be between 0 and %d", field, set.MinMatches, limit)
+		}
+		name := set.SetName()
+		if names[name] {
+			return fmt.Errorf("%s.name %q is used by another set", field, name)
+		}
+		names[name] = true
+	}
+	return nil
+}
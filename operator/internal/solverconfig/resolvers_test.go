@@ -0,0 +1,65 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package solverconfig
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResolverSets(t *testing.T) {
+	base := `"servers":["a","b"],"tsigKeyName":"k","tsigSecretName":"s"`
+	tests := []struct {
+		name    string
+		extra   string
+		wantErr string
+	}{
+		{name: "recursive and system", extra: `"propagation":{"resolvers":[` +
+			`{"name":"public","type":"Recursive","servers":["1.1.1.1","8.8.8.8:53"],"minMatches":1},{"type":"system"}]}`},
+		{name: "unknown type", extra: `"propagation":{"resolvers":[{"type":"stub"}]}`,
+			wantErr: `propagation.resolvers[0].type "stub" is unknown`},
+		{name: "recursive without servers", extra: `"propagation":{"resolvers":[{"type":"recursive"}]}`,
+			wantErr: "propagation.resolvers[0].servers is required"},
+		{name: "system with servers", extra: `"propagation":{"resolvers":[{"type":"system","servers":["1.1.1.1"]}]}`,
+			wantErr: "propagation.resolvers[0].servers must be empty"},
+		{name: "invalid resolver", extra: `"propagation":{"resolvers":[{"type":"recursive","servers":["1.1.1.1:dns"]}]}`,
+			wantErr: "propagation.resolvers[0].servers[0]"},
+		{name: "minMatches above set size", extra: `"propagation":{"resolvers":[` +
+			`{"type":"recursive","servers":["1.1.1.1"],"minMatches":2}]}`,
+			wantErr: "propagation.resolvers[0].minMatches 2 is out of range"},
+		{name: "duplicate name", extra: `"propagation":{"resolvers":[{"type":"system"},{"type":"SYSTEM"}]}`,
+			wantErr: `propagation.resolvers[1].name "system" is used by another set`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := Parse([]byte(`{` + base + `,` + tt.extra + `}`))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Parse: %v", err)
+				}
+				sets := config.Propagation.Resolvers
+				if sets[0].SetName() != "public" || sets[0].Type != ResolverSetRecursive || sets[1].SetName() != "system" {
+					t.Fatalf("resolvers = %+v, want public and system", sets)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Parse error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,277 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package solverconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/miekg/dns"
+
+	rfc2136 "github.com/rieset/istio-dns01-bind9/internal/dns"
+)
+
+// FunctionRating: 82/100
+// - Complexity: MEDIUM
+// - Integrations: 0
+// - External Risks: LOW (pure parsing, bounded input)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: ServerEntry, ServerSettings
+// Purpose: Structured entries of the servers list with per-server zone and TSIG credentials
+
+// ServerEntry is an entry of servers written as an object instead of a plain
+// address. Empty fields fall back to the top-level settings of the config.
+type ServerEntry struct {
+	// Address is the host name or IP address of the server, without a port;
+	// IPv6 addresses may be written in brackets
+	Address string `json:"address"`
+	// Port is the server port, default 53 (853 with the tls transport)
+	Port int `json:"port,omitempty"`
+	// Zone overrides the zone name updates to this server are sent for
+	Zone           string `json:"zone,omitempty"`
+	TSIGKeyName    string `json:"tsigKeyName,omitempty"`
+	TSIGSecretName string `json:"tsigSecretName,omitempty"`
+	TSIGSecretKey  string `json:"tsigSecretKey,omitempty"`
+	// Algorithm is the TSIG algorithm of TSIGKeyName
+	Algorithm string `json:"algorithm,omitempty"`
+	// Provider selects how this server is updated: rfc2136 (default) or
+	// powerdns, whose API then takes the updates while Address is still
+	// queried to verify them
+	Provider string `json:"provider,omitempty"`
+	// PowerDNS holds the API settings of a powerdns entry
+	PowerDNS *PowerDNSConfig `json:"powerdns,omitempty"`
+}
+
+// ID returns the name the entry is known by in Servers and in the per-server
+// maps of the config: the address, joined with the port when one is set
+func (e ServerEntry) ID() string {
+	if e.Port == 0 {
+		return e.Address
+	}
+	host := strings.TrimSuffix(strings.TrimPrefix(e.Address, "["), "]")
+	return net.JoinHostPort(host, strconv.Itoa(e.Port))
+}
+
+// validate checks the entry found at servers[i]
+func (e ServerEntry) validate(i int) error {
+	if strings.TrimSpace(e.Address) == "" {
+		return fmt.Errorf("servers[%d].address is required", i)
+	}
+	if e.Port < 0 || e.Port > 65535 {
+		return fmt.Errorf("servers[%d].port %d is out of range", i, e.Port)
+	}
+	_, port, err := rfc2136.ParseServerAddress(e.Address)
+	if err != nil {
+		return fmt.Errorf("servers[%d].address: %w", i, err)
+	}
+	if port != "" {
+		return fmt.Errorf("servers[%d].address %q must not include a port, set port instead", i, e.Address)
+	}
+	if e.Zone != "" {
+		if _, ok := dns.IsDomainName(e.Zone); !ok {
+			return fmt.Errorf("servers[%d].zone %q is not a valid domain name", i, e.Zone)
+		}
+	}
+	if e.TSIGKeyName != "" {
+		if _, ok := dns.IsDomainName(e.TSIGKeyName); !ok {
+			return fmt.Errorf("servers[%d].tsigKeyName %q is not a valid key name", i, e.TSIGKeyName)
+		}
+	}
+	if len(e.TSIGSecretName) > MaxNameLength || len(e.TSIGSecretKey) > MaxNameLength || len(e.Algorithm) > MaxNameLength {
+		return fmt.Errorf("servers[%d] names must be at most %d characters", i, MaxNameLength)
+	}
+	switch e.Provider {
+	case "", ProviderRFC2136:
+		if e.PowerDNS != nil {
+			return fmt.Errorf("servers[%d].powerdns requires provider %q", i, ProviderPowerDNS)
+		}
+	case ProviderPowerDNS:
+		if err := e.PowerDNS.validate(); err != nil {
+			return fmt.Errorf("servers[%d]: %w", i, err)
+		}
+	default:
+		return fmt.Errorf("servers[%d].provider %q is unknown, expected %s or %s",
+			i, e.Provider, ProviderRFC2136, ProviderPowerDNS)
+	}
+	return nil
+}
+
+// Optional keys of a TSIG Secret that replace the key name and algorithm of
+// the config, so keys rotated by a TSIGKey are used under their current name
+const (
+	SecretKeyNameKey   = "key-name"
+	SecretAlgorithmKey = "algorithm"
+)
+
+// TSIGKeyFromSecret returns keyName and algorithm, replaced by the values the
+// data of a TSIG Secret holds under SecretKeyNameKey and SecretAlgorithmKey
+func TSIGKeyFromSecret(data map[string][]byte, keyName, algorithm string) (string, string) {
+	if name := strings.TrimSpace(string(data[SecretKeyNameKey])); name != "" {
+		keyName = name
+	}
+	if alg := strings.TrimSpace(string(data[SecretAlgorithmKey])); alg != "" {
+		algorithm = alg
+	}
+	return keyName, algorithm
+}
+
+// ServerSettings are the zone and TSIG credentials used to update one server
+type ServerSettings struct {
+	Zone           string
+	TSIGKeyName    string
+	TSIGAlgorithm  string
+	TSIGSecretName string
+	TSIGSecretKey  string
+}
+
+// ServerSettings returns the settings of server, taken from its entry with
+// the top-level settings filling the fields the entry leaves empty
+func (c *Config) ServerSettings(server string) ServerSettings {
+	settings := ServerSettings{
+		Zone:           c.Zone,
+		TSIGKeyName:    c.TSIGKeyName,
+		TSIGAlgorithm:  c.TSIGAlgorithm,
+		TSIGSecretName: c.TSIGSecretName,
+		TSIGSecretKey:  c.TSIGSecretKey,
+	}
+	entry, ok := c.ServerEntries[server]
+	if !ok {
+		return settings
+	}
+	if entry.Zone != "" {
+		settings.Zone = entry.Zone
+	}
+	if entry.TSIGKeyName != "" {
+		settings.TSIGKeyName = entry.TSIGKeyName
+	}
+	if entry.Algorithm != "" {
+		settings.TSIGAlgorithm = entry.Algorithm
+	}
+	if entry.TSIGSecretName != "" {
+		settings.TSIGSecretName = entry.TSIGSecretName
+		settings.TSIGSecretKey = DefaultTSIGSecretKey
+	}
+	if entry.TSIGSecretKey != "" {
+		settings.TSIGSecretKey = entry.TSIGSecretKey
+	}
+	return settings
+}
+
+// ServerProvider returns the provider server is updated through: the one of
+// its entry, or rfc2136
+func (c *Config) ServerProvider(server string) string {
+	if entry, ok := c.ServerEntries[server]; ok && entry.Provider != "" {
+		return entry.Provider
+	}
+	return ProviderRFC2136
+}
+
+// OnlyRFC2136 reports whether every server is updated through RFC2136
+func (c *Config) OnlyRFC2136() bool {
+	if c.Provider != ProviderRFC2136 {
+		return false
+	}
+	for _, server := range c.Servers {
+		if c.ServerProvider(server) != ProviderRFC2136 {
+			return false
+		}
+	}
+	return true
+}
+
+// serverJSON is one entry of servers in either of its JSON forms
+type serverJSON struct {
+	entry ServerEntry
+	// structured is set when the entry was written as an object
+	structured bool
+}
+
+// UnmarshalJSON accepts a plain address string or a ServerEntry object
+func (s *serverJSON) UnmarshalJSON(b []byte) error {
+	b = bytes.TrimSpace(b)
+	if len(b) > 0 && b[0] == '{' {
+		s.structured = true
+		return json.Unmarshal(b, &s.entry)
+	}
+	return json.Unmarshal(b, &s.entry.Address)
+}
+
+// UnmarshalJSON decodes the config, accepting servers as plain addresses or
+// ServerEntry objects. Objects are kept in ServerEntries under their ID.
+func (c *Config) UnmarshalJSON(b []byte) error {
+	type plain Config
+	aux := struct {
+		*plain
+		Servers []serverJSON `json:"servers"`
+	}{plain: (*plain)(c)}
+	if err := json.Unmarshal(b, &aux); err != nil {
+		return err
+	}
+
+	c.Servers = nil
+	c.ServerEntries = nil
+	c.entryProblems = nil
+	if aux.Servers == nil {
+		return nil
+	}
+	c.Servers = make([]string, 0, len(aux.Servers))
+	for i, server := range aux.Servers {
+		if !server.structured {
+			c.Servers = append(c.Servers, server.entry.Address)
+			continue
+		}
+		// Invalid entries are reported by validate with the other problems
+		if err := server.entry.validate(i); err != nil {
+			if c.entryProblems == nil {
+				c.entryProblems = map[int]error{}
+			}
+			c.entryProblems[i] = err
+		}
+		if c.ServerEntries == nil {
+			c.ServerEntries = map[string]ServerEntry{}
+		}
+		c.ServerEntries[server.entry.ID()] = server.entry
+		c.Servers = append(c.Servers, server.entry.ID())
+	}
+	return nil
+}
+
+// MarshalJSON encodes the config, writing servers with an entry in
+// ServerEntries as objects and the others as plain addresses
+func (c Config) MarshalJSON() ([]byte, error) {
+	type plain Config
+	var servers []any
+	for _, server := range c.Servers {
+		if entry, ok := c.ServerEntries[server]; ok {
+			servers = append(servers, entry)
+		} else {
+			servers = append(servers, server)
+		}
+	}
+	return json.Marshal(struct {
+		plain
+		Servers []any `json:"servers"`
+	}{plain: plain(c), Servers: servers})
+}
@@ -0,0 +1,180 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package solverconfig
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const structuredConfig = `{"servers":["192.0.2.1",` +
+	`{"address":"192.0.2.2","port":5353,"tsigKeyName":"internal-key","tsigSecretName":"tsig-internal","algorithm":"hmac-sha512"},` +
+	`{"address":"2001:db8::1","zone":"acme.example.com"}],` +
+	`"zone":"example.com","tsigKeyName":"acme-example-com","tsigSecretName":"tsig","tsigSecretKey":"key",` +
+	`"serverModes":{"192.0.2.2:5353":"knot"}}`
+
+func TestParseServerEntries(t *testing.T) {
+	config, err := Parse([]byte(structuredConfig))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if want := []string{"192.0.2.1", "192.0.2.2:5353", "2001:db8::1"}; !reflect.DeepEqual(config.Servers, want) {
+		t.Fatalf("Servers = %v, want %v", config.Servers, want)
+	}
+
+	tests := []struct {
+		server string
+		want   ServerSettings
+	}{
+		{"192.0.2.1", ServerSettings{"example.com", "acme-example-com.", DefaultTSIGAlgorithm, "tsig", "key"}},
+		{"192.0.2.2:5353", ServerSettings{"example.com", "internal-key.", "hmac-sha512", "tsig-internal", DefaultTSIGSecretKey}},
+		{"2001:db8::1", ServerSettings{"acme.example.com", "acme-example-com.", DefaultTSIGAlgorithm, "tsig", "key"}},
+	}
+	for _, tt := range tests {
+		if got := config.ServerSettings(tt.server); got != tt.want {
+			t.Errorf("ServerSettings(%q) = %+v, want %+v", tt.server, got, tt.want)
+		}
+	}
+
+	// Marshalling keeps the structured entries, so persisted configs parse back the same
+	raw, err := json.Marshal(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := Parse(raw)
+	if err != nil {
+		t.Fatalf("Parse after marshalling: %v", err)
+	}
+	if !reflect.DeepEqual(again, config) {
+		t.Fatalf("round trip changed the config:\n%+v\n%+v", again, config)
+	}
+}
+
+func TestParseServerEntriesErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		wantErr string
+	}{
+		{"missing address", `{"servers":[{"port":53}],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s"}`,
+			"servers[0].address is required"},
+		{"bad port", `{"servers":[{"address":"a","port":70000}],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s"}`,
+			"servers[0].port 70000 is out of range"},
+		{"bad zone", `{"servers":["a",{"address":"b","zone":"bad..zone"}],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s"}`,
+			`servers[1].zone "bad..zone" is not a valid domain name`},
+		{"duplicate of plain entry", `{"servers":["a:53",{"address":"a","port":53}],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s"}`,
+			`servers[1] duplicates "a:53"`},
+		{"server without key", `{"servers":[{"address":"a","tsigKeyName":"k","tsigSecretName":"s"},"b"],"zone":"example.com"}`,
+			`tsigKeyName is required for server "b"`},
+		{"port in address", `{"servers":[{"address":"a:5353"}],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s"}`,
+			`servers[0].address "a:5353" must not include a port`},
+		{"bad plain address", `{"servers":["2001:db8::1:53:x"],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s"}`,
+			"servers[0]: server address"},
+		{"bad plain port", `{"servers":["192.0.2.1:0"],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s"}`,
+			`invalid port "0"`},
+		{"unknown provider", `{"servers":[{"address":"a","provider":"route53"}],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s"}`,
+			`servers[0].provider "route53" is unknown`},
+		{"powerdns without api", `{"servers":[{"address":"a","provider":"powerdns"}],"zone":"example.com"}`,
+			"servers[0]: powerdns settings are required"},
+		{"api without powerdns", `{"servers":[{"address":"a","powerdns":{"apiUrl":"http://pdns","apiKeySecretName":"k"}}],` +
+			`"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s"}`,
+			`servers[0].powerdns requires provider "powerdns"`},
+		{"mixed outside rfc2136", `{"provider":"powerdns","powerdns":{"apiUrl":"http://pdns","apiKeySecretName":"k"},` +
+			`"servers":[{"address":"a","provider":"powerdns","powerdns":{"apiUrl":"http://pdns","apiKeySecretName":"k"}}],"zone":"example.com"}`,
+			`servers[0] sets provider "powerdns", which requires provider "rfc2136"`},
+		{"wrong type", `{"servers":[53],"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s"}`,
+			"failed to unmarshal config"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.raw))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Parse error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	// Every server bringing its own key makes the top-level key optional
+	if _, err := Parse([]byte(`{"servers":[{"address":"a","tsigKeyName":"k","tsigSecretName":"s"}],"zone":"example.com"}`)); err != nil {
+		t.Fatalf("Parse with per-server keys only: %v", err)
+	}
+}
+
+func TestParseServerAddresses(t *testing.T) {
+	config, err := Parse([]byte(`{"servers":["192.0.2.1:5353","2001:db8::1","[2001:db8::2]:5353","ns1.example.com",` +
+		`{"address":"[2001:db8::3]","port":5353},{"address":"2001:db8::4","port":53}],` +
+		`"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s"}`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	want := []string{"192.0.2.1:5353", "2001:db8::1", "[2001:db8::2]:5353", "ns1.example.com", "[2001:db8::3]:5353", "[2001:db8::4]:53"}
+	if !reflect.DeepEqual(config.Servers, want) {
+		t.Fatalf("Servers = %v, want %v", config.Servers, want)
+	}
+}
+
+func TestServerProvider(t *testing.T) {
+	config, err := Parse([]byte(`{"servers":["192.0.2.1",` +
+		`{"address":"192.0.2.2","provider":"powerdns","powerdns":{"apiUrl":"http://pdns:8081","apiKeySecretName":"pdns"}}],` +
+		`"zone":"example.com","tsigKeyName":"k","tsigSecretName":"s"}`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if got := config.ServerProvider("192.0.2.1"); got != ProviderRFC2136 {
+		t.Errorf("ServerProvider(192.0.2.1) = %q, want %q", got, ProviderRFC2136)
+	}
+	if got := config.ServerProvider("192.0.2.2"); got != ProviderPowerDNS {
+		t.Errorf("ServerProvider(192.0.2.2) = %q, want %q", got, ProviderPowerDNS)
+	}
+	if config.OnlyRFC2136() {
+		t.Error("OnlyRFC2136() = true with a powerdns server")
+	}
+	api := config.ServerEntries["192.0.2.2"].PowerDNS
+	if api.ServerID != DefaultPowerDNSServerID || api.APIKeySecretKey != DefaultPowerDNSAPIKeySecretKey {
+		t.Errorf("powerdns defaults not applied: %+v", api)
+	}
+
+	// Only RFC2136 servers need a TSIG key
+	if _, err := Parse([]byte(`{"servers":[{"address":"a","tsigKeyName":"k","tsigSecretName":"s"},` +
+		`{"address":"b","provider":"powerdns","powerdns":{"apiUrl":"http://pdns","apiKeySecretName":"k"}}],"zone":"example.com"}`)); err != nil {
+		t.Fatalf("Parse with a keyless powerdns server: %v", err)
+	}
+}
+
+func TestTSIGKeyFromSecret(t *testing.T) {
+	tests := []struct {
+		name          string
+		data          map[string][]byte
+		wantKey       string
+		wantAlgorithm string
+	}{
+		{"secret only", map[string][]byte{"secret": []byte("c2VjcmV0")}, "acme-update", "hmac-sha256"},
+		{"rotated key", map[string][]byte{SecretKeyNameKey: []byte("v2.acme-update\n"), SecretAlgorithmKey: []byte("hmac-sha512")},
+			"v2.acme-update", "hmac-sha512"},
+		{"blank entries", map[string][]byte{SecretKeyNameKey: []byte(" ")}, "acme-update", "hmac-sha256"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, algorithm := TSIGKeyFromSecret(tt.data, "acme-update", "hmac-sha256")
+			if key != tt.wantKey || algorithm != tt.wantAlgorithm {
+				t.Errorf("TSIGKeyFromSecret = %q, %q, want %q, %q", key, algorithm, tt.wantKey, tt.wantAlgorithm)
+			}
+		})
+	}
+}
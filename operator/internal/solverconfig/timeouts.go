@@ -0,0 +1,136 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package solverconfig
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	rfc2136 "github.com/rieset/istio-dns01-bind9/internal/dns"
+)
+
+// FunctionRating: 84/100
+// - Complexity: LOW
+// - Integrations: 0
+// - External Risks: LOW (pure parsing, bounded input)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: TimeoutConfig, ClientTimeouts, ServerClientTimeouts
+// Purpose: Dial, read and write timeouts per config, server group and server, and the bound of a whole operation
+
+const (
+	// MaxExchangeTimeout is the longest dial, read or write timeout a config may ask for
+	MaxExchangeTimeout = 5 * time.Minute
+	// MaxOperationTimeout is the longest operationTimeout a config may ask for
+	MaxOperationTimeout = 30 * time.Minute
+)
+
+// TimeoutConfig bounds the stages of every exchange with a server. Unset
+// fields fall back to the enclosing level: serverTimeouts, the server's
+// group, timeouts and finally the client default of 10s for the whole exchange.
+type TimeoutConfig struct {
+	// Dial bounds opening a connection, the TLS handshake included
+	Dial Duration `json:"dial,omitempty"`
+	// Read bounds waiting for a reply
+	Read Duration `json:"read,omitempty"`
+	// Write bounds sending a message
+	Write Duration `json:"write,omitempty"`
+}
+
+// merge returns base with the fields set in t replaced; nil keeps base
+func (t *TimeoutConfig) merge(base rfc2136.Timeouts) rfc2136.Timeouts {
+	if t == nil {
+		return base
+	}
+	if t.Dial.Duration > 0 {
+		base.Dial = t.Dial.Duration
+	}
+	if t.Read.Duration > 0 {
+		base.Read = t.Read.Duration
+	}
+	if t.Write.Duration > 0 {
+		base.Write = t.Write.Duration
+	}
+	return base
+}
+
+// validate checks the timeouts found at field; nil is valid
+func (t *TimeoutConfig) validate(field string) error {
+	if t == nil {
+		return nil
+	}
+	for _, stage := range []struct {
+		name string
+		d    Duration
+	}{{"dial", t.Dial}, {"read", t.Read}, {"write", t.Write}} {
+		if stage.d.Duration < 0 || stage.d.Duration > MaxExchangeTimeout {
+			return fmt.Errorf("%s.%s %s is out of range, maximum is %s", field, stage.name, stage.d, MaxExchangeTimeout)
+		}
+	}
+	return nil
+}
+
+// ClientTimeouts returns the timeouts of the exchanges with server, merged
+// from timeouts, the server's group and serverTimeouts
+func (c *Config) ClientTimeouts(server string) rfc2136.Timeouts {
+	timeouts := c.Timeouts.merge(rfc2136.Timeouts{})
+	for _, group := range c.ServerGroups {
+		if slices.Contains(group.Servers, server) {
+			timeouts = group.Timeouts.merge(timeouts)
+		}
+	}
+	if settings, ok := c.ServerTimeouts[server]; ok {
+		timeouts = settings.merge(timeouts)
+	}
+	return timeouts
+}
+
+// ServerClientTimeouts returns the timeouts of every server of Servers that
+// does not keep the client default
+func (c *Config) ServerClientTimeouts() map[string]rfc2136.Timeouts {
+	timeouts := map[string]rfc2136.Timeouts{}
+	for _, server := range c.Servers {
+		if t := c.ClientTimeouts(server); !t.IsZero() {
+			timeouts[server] = t
+		}
+	}
+	return timeouts
+}
+
+// validateTimeouts checks timeouts, serverTimeouts against servers, the set
+// of addresses in Servers, and operationTimeout, passing each problem to check
+func (c *Config) validateTimeouts(servers map[string]bool, check func(error)) {
+	check(c.Timeouts.validate("timeouts"))
+	for _, server := range slices.Sorted(maps.Keys(c.ServerTimeouts)) {
+		settings := c.ServerTimeouts[server]
+		if !servers[server] {
+			check(fmt.Errorf("serverTimeouts entry %q is not listed in servers", server))
+		}
+		check(settings.validate(fmt.Sprintf("serverTimeouts[%q]", server)))
+	}
+	for i, group := range c.ServerGroups {
+		check(group.Timeouts.validate(fmt.Sprintf("serverGroups[%d].timeouts", i)))
+	}
+	if c.OperationTimeout.Duration < 0 || c.OperationTimeout.Duration > MaxOperationTimeout {
+		check(fmt.Errorf("operationTimeout %s is out of range, maximum is %s", c.OperationTimeout, MaxOperationTimeout))
+	}
+}
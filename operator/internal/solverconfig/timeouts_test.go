@@ -0,0 +1,93 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package solverconfig
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	rfc2136 "github.com/rieset/istio-dns01-bind9/internal/dns"
+)
+
+func TestClientTimeouts(t *testing.T) {
+	config, err := Parse([]byte(`{"servers":["a","b","c"],"tsigKeyName":"k","tsigSecretName":"s",` +
+		`"timeouts":{"dial":"3s","read":"5s"},` +
+		`"serverGroups":[{"name":"wan","servers":["b","c"],"timeouts":{"dial":"10s","write":"2s"}}],` +
+		`"serverTimeouts":{"c":{"read":"30s"}},"operationTimeout":"5m"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		server string
+		want   rfc2136.Timeouts
+	}{
+		{"a", rfc2136.Timeouts{Dial: 3 * time.Second, Read: 5 * time.Second}},
+		{"b", rfc2136.Timeouts{Dial: 10 * time.Second, Read: 5 * time.Second, Write: 2 * time.Second}},
+		{"c", rfc2136.Timeouts{Dial: 10 * time.Second, Read: 30 * time.Second, Write: 2 * time.Second}},
+	}
+	for _, tt := range tests {
+		if got := config.ClientTimeouts(tt.server); got != tt.want {
+			t.Errorf("ClientTimeouts(%s) = %+v, want %+v", tt.server, got, tt.want)
+		}
+	}
+	if all := config.ServerClientTimeouts(); len(all) != 3 || all["b"] != tests[1].want {
+		t.Errorf("ServerClientTimeouts = %+v, want every server", all)
+	}
+	if config.OperationTimeout.Duration != 5*time.Minute {
+		t.Errorf("OperationTimeout = %s, want 5m", config.OperationTimeout)
+	}
+	if got := (&Config{}).ClientTimeouts("a"); !got.IsZero() {
+		t.Errorf("ClientTimeouts without timeouts = %+v, want the client default", got)
+	}
+}
+
+func TestTimeoutsValidation(t *testing.T) {
+	base := `"servers":["a","b"],"tsigKeyName":"k","tsigSecretName":"s"`
+	tests := []struct {
+		name    string
+		extra   string
+		wantErr string
+	}{
+		{name: "valid", extra: `"timeouts":{"dial":"1s"},"serverTimeouts":{"b":{"read":"20s"}},"operationTimeout":"2m"`},
+		{name: "negative dial", extra: `"timeouts":{"dial":"-1s"}`, wantErr: "timeouts.dial -1s is out of range"},
+		{name: "absurd read", extra: `"timeouts":{"read":"1h"}`, wantErr: "timeouts.read 1h0m0s is out of range"},
+		{name: "unlisted server", extra: `"serverTimeouts":{"c":{"read":"1s"}}`,
+			wantErr: `serverTimeouts entry "c" is not listed in servers`},
+		{name: "absurd server write", extra: `"serverTimeouts":{"a":{"write":"10m"}}`,
+			wantErr: `serverTimeouts["a"].write 10m0s is out of range`},
+		{name: "absurd group timeout", extra: `"serverGroups":[{"name":"x","servers":["a"],"timeouts":{"dial":"6m"}}]`,
+			wantErr: "serverGroups[0].timeouts.dial 6m0s is out of range"},
+		{name: "absurd operation timeout", extra: `"operationTimeout":"2h"`,
+			wantErr: "operationTimeout 2h0m0s is out of range"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(`{` + base + `,` + tt.extra + `}`))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Parse: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Parse error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
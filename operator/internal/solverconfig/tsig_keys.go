@@ -0,0 +1,113 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package solverconfig
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/miekg/dns"
+
+	rfc2136 "github.com/rieset/istio-dns01-bind9/internal/dns"
+)
+
+// FunctionRating: 82/100
+// - Complexity: LOW
+// - Integrations: 0
+// - External Risks: LOW (pure parsing, bounded input)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: TSIGKeyConfig, FallbackTSIGKeys
+// Purpose: TSIG keys tried in order when a server rejects its own key, for rotations rolling through a fleet
+
+// MaxFallbackTSIGKeys is the largest number of fallback keys a config may list
+const MaxFallbackTSIGKeys = 4
+
+// TSIGKeyConfig is a TSIG key signed with when a server rejects the one it
+// is configured with, such as the next key while a rotation rolls through
+// the servers
+type TSIGKeyConfig struct {
+	KeyName string `json:"keyName"`
+	// Algorithm defaults to tsigAlgorithm
+	Algorithm string `json:"algorithm,omitempty"`
+	// SecretName defaults to tsigSecretName
+	SecretName string `json:"secretName,omitempty"`
+	// SecretKey defaults to "secret"
+	SecretKey string `json:"secretKey,omitempty"`
+}
+
+// FallbackTSIGKeys returns the fallback keys in priority order with the
+// defaults applied; the secrets are left to the caller
+func (c *Config) FallbackTSIGKeys() []TSIGKeyConfig {
+	keys := make([]TSIGKeyConfig, 0, len(c.TSIGFallbackKeys))
+	for _, key := range c.TSIGFallbackKeys {
+		if key.Algorithm == "" {
+			key.Algorithm = c.TSIGAlgorithm
+		}
+		if key.SecretName == "" {
+			key.SecretName = c.TSIGSecretName
+		}
+		if key.SecretKey == "" {
+			key.SecretKey = DefaultTSIGSecretKey
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// validateFallbackKeys checks tsigFallbackKeys and canonicalizes their key
+// names and algorithms, passing each problem to check
+func (c *Config) validateFallbackKeys(check func(error)) {
+	if len(c.TSIGFallbackKeys) == 0 {
+		return
+	}
+	if c.Provider != ProviderRFC2136 || c.UsesSIG0() {
+		check(fmt.Errorf("tsigFallbackKeys require provider %q with TSIG", ProviderRFC2136))
+		return
+	}
+	if len(c.TSIGFallbackKeys) > MaxFallbackTSIGKeys {
+		check(fmt.Errorf("tsigFallbackKeys has %d entries, maximum is %d", len(c.TSIGFallbackKeys), MaxFallbackTSIGKeys))
+		return
+	}
+	seen := map[string]bool{strings.ToLower(c.TSIGKeyName): true}
+	for i := range c.TSIGFallbackKeys {
+		key := &c.TSIGFallbackKeys[i]
+		if _, ok := dns.IsDomainName(key.KeyName); !ok || strings.TrimSpace(key.KeyName) == "" {
+			check(fmt.Errorf("tsigFallbackKeys[%d].keyName %q is not a valid key name", i, key.KeyName))
+			continue
+		}
+		key.KeyName = dns.CanonicalName(key.KeyName)
+		if seen[key.KeyName] {
+			check(fmt.Errorf("tsigFallbackKeys[%d].keyName %q repeats an earlier key", i, key.KeyName))
+		}
+		seen[key.KeyName] = true
+		if len(key.SecretName) > MaxNameLength || len(key.SecretKey) > MaxNameLength {
+			check(fmt.Errorf("tsigFallbackKeys[%d] names must be at most %d characters", i, MaxNameLength))
+		}
+		if key.Algorithm != "" {
+			algorithm, err := rfc2136.ParseTSIGAlgorithm(key.Algorithm, c.AllowDeprecatedTSIG)
+			if err != nil {
+				check(fmt.Errorf("tsigFallbackKeys[%d].algorithm: %w", i, err))
+				continue
+			}
+			key.Algorithm = algorithm
+		}
+	}
+}
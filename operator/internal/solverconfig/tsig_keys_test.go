@@ -0,0 +1,76 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package solverconfig
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFallbackTSIGKeys(t *testing.T) {
+	config, err := Parse([]byte(`{"servers":["a"],"tsigKeyName":"old","tsigSecretName":"tsig",` +
+		`"tsigFallbackKeys":[{"keyName":"New.","secretKey":"next"},` +
+		`{"keyName":"other","algorithm":"HMAC-SHA512","secretName":"other-tsig"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []TSIGKeyConfig{
+		{KeyName: "new.", Algorithm: "hmac-sha256", SecretName: "tsig", SecretKey: "next"},
+		{KeyName: "other.", Algorithm: "hmac-sha512", SecretName: "other-tsig", SecretKey: DefaultTSIGSecretKey},
+	}
+	if got := config.FallbackTSIGKeys(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("FallbackTSIGKeys = %+v, want %+v", got, want)
+	}
+}
+
+func TestFallbackTSIGKeysValidation(t *testing.T) {
+	base := `"servers":["a"],"zone":"example.com","tsigKeyName":"old","tsigSecretName":"tsig"`
+	tests := []struct {
+		name    string
+		extra   string
+		wantErr string
+	}{
+		{name: "valid", extra: `"tsigFallbackKeys":[{"keyName":"new"}]`},
+		{name: "missing key name", extra: `"tsigFallbackKeys":[{"secretName":"next"}]`,
+			wantErr: `tsigFallbackKeys[0].keyName "" is not a valid key name`},
+		{name: "primary key repeated", extra: `"tsigFallbackKeys":[{"keyName":"OLD."}]`,
+			wantErr: `tsigFallbackKeys[0].keyName "old." repeats an earlier key`},
+		{name: "fallback repeated", extra: `"tsigFallbackKeys":[{"keyName":"new"},{"keyName":"new."}]`,
+			wantErr: `tsigFallbackKeys[1].keyName "new." repeats an earlier key`},
+		{name: "unknown algorithm", extra: `"tsigFallbackKeys":[{"keyName":"new","algorithm":"md4"}]`,
+			wantErr: "tsigFallbackKeys[0].algorithm"},
+		{name: "too many", extra: `"tsigFallbackKeys":[{"keyName":"a"},{"keyName":"b"},{"keyName":"c"},{"keyName":"d"},{"keyName":"e"}]`,
+			wantErr: "tsigFallbackKeys has 5 entries, maximum is 4"},
+		{name: "sig0", extra: `"authMethod":"sig0","sig0":{"secretName":"sig0-key"},"tsigFallbackKeys":[{"keyName":"new"}]`,
+			wantErr: `tsigFallbackKeys require provider "rfc2136" with TSIG`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(`{` + base + `,` + tt.extra + `}`))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Parse: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Parse error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package solverconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// FunctionRating: 84/100
+// - Complexity: MEDIUM
+// - Integrations: 0
+// - External Risks: LOW (pure inspection of bounded input)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: PARTIAL (raw JSON is walked as generic values)
+// - Critical Issues: NONE
+//
+// Function: ValidationError, unknownFields
+// Purpose: Reports every problem of a config at once, including fields the config does not define
+
+// ValidationError lists every problem found in a config, so all of them can
+// be fixed before the next issuance attempt
+type ValidationError struct {
+	Problems []error
+}
+
+// Error renders the problems on one line, numbered when there are several
+func (e *ValidationError) Error() string {
+	if len(e.Problems) == 1 {
+		return e.Problems[0].Error()
+	}
+	parts := make([]string, len(e.Problems))
+	for i, problem := range e.Problems {
+		parts[i] = fmt.Sprintf("(%d) %v", i+1, problem)
+	}
+	return fmt.Sprintf("%d problems: %s", len(e.Problems), strings.Join(parts, "; "))
+}
+
+// Unwrap returns the problems for errors.Is and errors.As
+func (e *ValidationError) Unwrap() []error {
+	return e.Problems
+}
+
+// newValidationError returns a ValidationError of problems, or nil without any
+func newValidationError(problems []error) error {
+	if len(problems) == 0 {
+		return nil
+	}
+	return &ValidationError{Problems: problems}
+}
+
+// unknownFields returns the paths of the object keys in raw that no field of
+// Config or its nested settings is named after. A value whose JSON type does
+// not match is left to the decoder, which reports it.
+func unknownFields(raw []byte) []string {
+	var doc map[string]any
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil
+	}
+	var paths []string
+	walkFields(doc, reflect.TypeOf(Config{}), "", &paths)
+	slices.Sort(paths)
+	return paths
+}
+
+// walkFields appends the keys of obj that typ has no field for to paths
+func walkFields(obj map[string]any, typ reflect.Type, path string, paths *[]string) {
+	fields := jsonFields(typ)
+	for key, value := range obj {
+		field, ok := lookupField(fields, key)
+		if !ok {
+			*paths = append(*paths, joinPath(path, key))
+			continue
+		}
+		// Servers are plain addresses or structured entries
+		if typ == reflect.TypeOf(Config{}) && strings.EqualFold(key, "servers") {
+			field = reflect.TypeOf([]ServerEntry{})
+		}
+		walkValue(value, field, joinPath(path, key), paths)
+	}
+}
+
+// walkValue descends into value when it is an object or array typ decodes
+func walkValue(value any, typ reflect.Type, path string, paths *[]string) {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	switch v := value.(type) {
+	case map[string]any:
+		switch typ.Kind() {
+		case reflect.Struct:
+			walkFields(v, typ, path, paths)
+		case reflect.Map:
+			for key, item := range v {
+				walkValue(item, typ.Elem(), fmt.Sprintf("%s[%q]", path, key), paths)
+			}
+		}
+	case []any:
+		if typ.Kind() == reflect.Slice {
+			for i, item := range v {
+				walkValue(item, typ.Elem(), path+"["+strconv.Itoa(i)+"]", paths)
+			}
+		}
+	}
+}
+
+// jsonFields maps the JSON names of the exported fields of the struct typ,
+// including those of embedded structs, to their types
+func jsonFields(typ reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			for embedded, t := range jsonFields(field.Type) {
+				fields[embedded] = t
+			}
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
+
+// lookupField finds the field of key, ignoring case as the decoder does
+func lookupField(fields map[string]reflect.Type, key string) (reflect.Type, bool) {
+	if field, ok := fields[key]; ok {
+		return field, true
+	}
+	for name, field := range fields {
+		if strings.EqualFold(name, key) {
+			return field, true
+		}
+	}
+	return nil, false
+}
+
+// joinPath appends key to the dotted path of its parent object
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
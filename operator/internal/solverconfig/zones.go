@@ -0,0 +1,203 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package solverconfig
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/miekg/dns"
+
+	rfc2136 "github.com/rieset/istio-dns01-bind9/internal/dns"
+)
+
+// FunctionRating: 84/100
+// - Complexity: LOW
+// - Integrations: 1 (dns library, name matching)
+// - External Risks: LOW (pure parsing, bounded input)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: ZoneConfig, ForName
+// Purpose: Per-zone servers and TSIG keys in one config, picked by longest-suffix match on the challenge name
+
+// MaxZones is the largest number of entries in zones
+const MaxZones = 64
+
+// ZoneConfig sends the challenges of one zone to servers of its own. Empty
+// TSIG fields fall back to the top-level settings of the config.
+type ZoneConfig struct {
+	// Zone is the zone the entry serves, and the zone updates are sent for
+	Zone string `json:"zone"`
+	// Servers are the addresses of the servers of the zone
+	Servers        []string `json:"servers"`
+	TSIGKeyName    string   `json:"tsigKeyName,omitempty"`
+	TSIGAlgorithm  string   `json:"tsigAlgorithm,omitempty"`
+	TSIGSecretName string   `json:"tsigSecretName,omitempty"`
+	TSIGSecretKey  string   `json:"tsigSecretKey,omitempty"`
+}
+
+// ForName returns the config the challenge at fqdn is sent with: the entry of
+// Zones with the longest zone enclosing fqdn applied over c, or c itself when
+// no entry matches, which then discovers the zone from its own servers
+func (c *Config) ForName(fqdn string) (*Config, error) {
+	if len(c.Zones) == 0 {
+		return c, nil
+	}
+	name := dns.CanonicalName(fqdn)
+	best := -1
+	for i, zone := range c.Zones {
+		if !dns.IsSubDomain(dns.CanonicalName(zone.Zone), name) {
+			continue
+		}
+		if best < 0 || dns.CountLabel(zone.Zone) > dns.CountLabel(c.Zones[best].Zone) {
+			best = i
+		}
+	}
+	if best >= 0 {
+		return c.forZone(c.Zones[best]), nil
+	}
+	if len(c.Servers) == 0 {
+		return nil, fmt.Errorf("no entry of zones encloses %s and servers is empty", fqdn)
+	}
+	return c, nil
+}
+
+// ZoneConfigs returns the config of every entry of Zones, preceded by c when
+// it has servers of its own
+func (c *Config) ZoneConfigs() []*Config {
+	if len(c.Zones) == 0 {
+		return []*Config{c}
+	}
+	var configs []*Config
+	if len(c.Servers) > 0 {
+		configs = append(configs, c)
+	}
+	for _, zone := range c.Zones {
+		configs = append(configs, c.forZone(zone))
+	}
+	return configs
+}
+
+// forZone returns a copy of c that sends updates for zone to its servers
+func (c *Config) forZone(zone ZoneConfig) *Config {
+	config := *c
+	config.Zones = nil
+	config.Zone = strings.TrimSuffix(zone.Zone, ".")
+	config.Servers = slices.Clone(zone.Servers)
+	config.Secondaries = nil
+	config.Bind9Cluster = nil
+	config.ServerGroups = nil
+	config.ServerEntries = nil
+	config.entryProblems = nil
+	if zone.TSIGKeyName != "" {
+		config.TSIGKeyName = zone.TSIGKeyName
+	}
+	if zone.TSIGAlgorithm != "" {
+		config.TSIGAlgorithm = zone.TSIGAlgorithm
+	}
+	if zone.TSIGSecretName != "" {
+		config.TSIGSecretName = zone.TSIGSecretName
+		config.TSIGSecretKey = DefaultTSIGSecretKey
+	}
+	if zone.TSIGSecretKey != "" {
+		config.TSIGSecretKey = zone.TSIGSecretKey
+	}
+	return &config
+}
+
+// validateZones checks the entries of zones, passing each problem to check.
+// TSIG algorithms are replaced with their canonical names.
+func (c *Config) validateZones(check func(error)) {
+	if len(c.Zones) == 0 {
+		return
+	}
+	if c.Provider != ProviderRFC2136 {
+		check(fmt.Errorf("zones require provider %q", ProviderRFC2136))
+		return
+	}
+	if len(c.Zones) > MaxZones {
+		check(fmt.Errorf("zones has %d entries, maximum is %d", len(c.Zones), MaxZones))
+	}
+	seen := make(map[string]bool, len(c.Zones))
+	for i := range c.Zones {
+		zone := &c.Zones[i]
+		field := fmt.Sprintf("zones[%d]", i)
+		name := dns.CanonicalName(zone.Zone)
+		switch _, ok := dns.IsDomainName(zone.Zone); {
+		case zone.Zone == "":
+			check(fmt.Errorf("%s.zone is required", field))
+		case !ok:
+			check(fmt.Errorf("%s.zone %q is not a valid domain name", field, zone.Zone))
+		case seen[name]:
+			check(fmt.Errorf("%s.zone duplicates %q", field, zone.Zone))
+		}
+		seen[name] = true
+
+		switch {
+		case len(zone.Servers) == 0:
+			check(fmt.Errorf("%s.servers list is required", field))
+		case len(zone.Servers) > MaxServers:
+			check(fmt.Errorf("%s.servers list has %d entries, maximum is %d", field, len(zone.Servers), MaxServers))
+		}
+		servers := make(map[string]bool, len(zone.Servers))
+		for j, server := range zone.Servers {
+			if err := c.forZone(*zone).validateServer(j, server, servers); err != nil {
+				check(fmt.Errorf("%s.%w", field, err))
+			}
+			servers[server] = true
+		}
+		if c.MinSuccess > len(zone.Servers) {
+			check(fmt.Errorf("minSuccess %d exceeds the %d servers of %s", c.MinSuccess, len(zone.Servers), field))
+		}
+		if c.Propagation != nil && c.Propagation.MinMatches > len(zone.Servers) {
+			check(fmt.Errorf("propagation.minMatches %d exceeds the %d servers of %s",
+				c.Propagation.MinMatches, len(zone.Servers), field))
+		}
+
+		if zone.TSIGAlgorithm != "" {
+			algorithm, err := rfc2136.ParseTSIGAlgorithm(zone.TSIGAlgorithm, c.AllowDeprecatedTSIG)
+			if err != nil {
+				check(fmt.Errorf("%s.tsigAlgorithm: %w", field, err))
+			} else {
+				zone.TSIGAlgorithm = algorithm
+			}
+		}
+		if zone.TSIGKeyName != "" {
+			if _, ok := dns.IsDomainName(zone.TSIGKeyName); !ok {
+				check(fmt.Errorf("%s.tsigKeyName %q is not a valid key name", field, zone.TSIGKeyName))
+			} else {
+				zone.TSIGKeyName = dns.CanonicalName(zone.TSIGKeyName)
+			}
+		}
+		if len(zone.TSIGSecretName) > MaxNameLength || len(zone.TSIGSecretKey) > MaxNameLength {
+			check(fmt.Errorf("%s.tsigSecretName and tsigSecretKey must be at most %d characters", field, MaxNameLength))
+		}
+		if c.UsesSIG0() {
+			continue
+		}
+		if zone.TSIGKeyName == "" && c.TSIGKeyName == "" {
+			check(fmt.Errorf("%s.tsigKeyName is required without a top-level tsigKeyName", field))
+		}
+		if zone.TSIGSecretName == "" && c.TSIGSecretName == "" {
+			check(fmt.Errorf("%s.tsigSecretName is required without a top-level tsigSecretName", field))
+		}
+	}
+}
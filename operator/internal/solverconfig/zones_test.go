@@ -0,0 +1,81 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package solverconfig
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestForName(t *testing.T) {
+	config, err := Parse([]byte(`{"servers":["fallback"],"tsigKeyName":"k","tsigSecretName":"s","zones":[` +
+		`{"zone":"example.com","servers":["a"]},` +
+		`{"zone":"sub.example.com.","servers":["b","c"],"tsigKeyName":"sub-key","tsigSecretName":"sub","tsigAlgorithm":"hmac-sha512"},` +
+		`{"zone":"example.org","servers":["d"],"tsigSecretKey":"org"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		fqdn      string
+		zone      string
+		servers   []string
+		keyName   string
+		algorithm string
+		secret    string
+		secretKey string
+	}{
+		{"_acme-challenge.www.example.com.", "example.com", []string{"a"}, "k.", "hmac-sha256", "s", "secret"},
+		{"_acme-challenge.EXAMPLE.com", "example.com", []string{"a"}, "k.", "hmac-sha256", "s", "secret"},
+		{"_acme-challenge.a.sub.example.com.", "sub.example.com", []string{"b", "c"}, "sub-key.", "hmac-sha512", "sub", "secret"},
+		{"_acme-challenge.example.org.", "example.org", []string{"d"}, "k.", "hmac-sha256", "s", "org"},
+		{"_acme-challenge.notexample.com.", "", []string{"fallback"}, "k.", "hmac-sha256", "s", "secret"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fqdn, func(t *testing.T) {
+			got, err := config.ForName(tt.fqdn)
+			if err != nil {
+				t.Fatalf("ForName: %v", err)
+			}
+			if got.Zone != tt.zone || !reflect.DeepEqual(got.Servers, tt.servers) || got.TSIGKeyName != tt.keyName ||
+				got.TSIGAlgorithm != tt.algorithm || got.TSIGSecretName != tt.secret || got.TSIGSecretKey != tt.secretKey {
+				t.Fatalf("ForName = zone %q servers %v key %s/%s secret %s/%s, want zone %q servers %v key %s/%s secret %s/%s",
+					got.Zone, got.Servers, got.TSIGKeyName, got.TSIGAlgorithm, got.TSIGSecretName, got.TSIGSecretKey,
+					tt.zone, tt.servers, tt.keyName, tt.algorithm, tt.secret, tt.secretKey)
+			}
+			if len(got.Zones) != 0 && got != config {
+				t.Fatalf("ForName kept zones %v on a zone config", got.Zones)
+			}
+		})
+	}
+
+	if configs := config.ZoneConfigs(); len(configs) != 4 || configs[0] != config || configs[2].Zone != "sub.example.com" {
+		t.Fatalf("ZoneConfigs = %d configs, want the top-level one and one per zone", len(configs))
+	}
+
+	zonesOnly, err := Parse([]byte(`{"tsigKeyName":"k","tsigSecretName":"s","zones":[{"zone":"example.com","servers":["a"]}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := zonesOnly.ForName("_acme-challenge.example.net."); err == nil || !strings.Contains(err.Error(), "no entry of zones") {
+		t.Fatalf("ForName outside the zones = %v, want an error", err)
+	}
+	if configs := zonesOnly.ZoneConfigs(); len(configs) != 1 || configs[0].Zone != "example.com" {
+		t.Fatalf("ZoneConfigs without top-level servers = %+v, want the zone only", configs)
+	}
+}
@@ -0,0 +1,363 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	mdns "github.com/miekg/dns"
+	"go.uber.org/zap"
+
+	"github.com/rieset/istio-dns01-bind9/internal/dns"
+)
+
+// FunctionRating: 74/100
+// - Complexity: MEDIUM
+// - Integrations: 3 (net/http, repair queue, dns package)
+// - External Risks: MEDIUM (operators can force DNS updates and queries on demand)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: adminAPI
+// Purpose: Authenticated HTTP API listing challenges and server repairs, forcing retries and running propagation checks
+
+const (
+	// defaultAdminCheckTimeout bounds a propagation check that sets no timeout
+	defaultAdminCheckTimeout = 10 * time.Second
+	// maxAdminCheckTimeout is the longest propagation check a request may ask for
+	maxAdminCheckTimeout = 2 * time.Minute
+	// maxAdminRequestBody bounds the JSON body of a request
+	maxAdminRequestBody = 64 << 10
+)
+
+// adminChallenge is a TXT value of the challenges listing. Values are
+// redacted like in the logs.
+type adminChallenge struct {
+	FQDN  string `json:"fqdn"`
+	Value string `json:"value"`
+	// Challenges counts the presented challenges of this instance needing the value
+	Challenges int `json:"challenges,omitempty"`
+	// Op, Namespace, Servers and Started describe a persisted operation
+	// that has not completed yet
+	Op        challengeOp `json:"op,omitempty"`
+	Namespace string      `json:"namespace,omitempty"`
+	Servers   []string    `json:"servers,omitempty"`
+	Started   *time.Time  `json:"started,omitempty"`
+}
+
+// adminServer is the sync status of one server: the challenge records it
+// missed and the repair queue keeps retrying
+type adminServer struct {
+	Server  string        `json:"server"`
+	Pending []adminRepair `json:"pending"`
+}
+
+// adminRepair is a challenge record a server missed
+type adminRepair struct {
+	Namespace string `json:"namespace"`
+	FQDN      string `json:"fqdn"`
+	Value     string `json:"value"`
+	Attempts  int    `json:"attempts"`
+}
+
+// adminPropagationRequest asks for a propagation check of a TXT value
+type adminPropagationRequest struct {
+	FQDN    string   `json:"fqdn"`
+	Value   string   `json:"value"`
+	Servers []string `json:"servers"`
+	// Absent waits for the value to disappear instead
+	Absent bool `json:"absent,omitempty"`
+	// MinMatches is the number of servers that must match; 0 means all
+	MinMatches int `json:"minMatches,omitempty"`
+	// Recursive queries the servers as resolvers
+	Recursive bool `json:"recursive,omitempty"`
+	// Timeout bounds the check, defaultAdminCheckTimeout when empty
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// adminPropagationResult is the outcome of a propagation check
+type adminPropagationResult struct {
+	Confirmed bool     `json:"confirmed"`
+	Matched   []string `json:"matched"`
+	Pending   []string `json:"pending"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// adminAPI serves the admin endpoints of a solver
+type adminAPI struct {
+	solver *DNS01Solver
+	// token is the bearer token requests must carry; empty relies on client certificates
+	token  string
+	logger *zap.Logger
+}
+
+// newAdminMux serves the admin endpoints, every one behind authentication
+func newAdminMux(api *adminAPI) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/challenges", api.authenticated(api.listChallenges))
+	mux.HandleFunc("GET /v1/servers", api.authenticated(api.listServers))
+	mux.HandleFunc("POST /v1/servers/{server}/retry", api.authenticated(api.retryServer))
+	mux.HandleFunc("POST /v1/propagation", api.authenticated(api.checkPropagation))
+	return mux
+}
+
+// authenticated rejects requests without the bearer token, when one is set
+func (a *adminAPI) authenticated(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if a.token != "" {
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(a.token)) != 1 {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				writeAdminError(w, http.StatusUnauthorized, errors.New("missing or invalid bearer token"))
+				return
+			}
+		}
+		a.logger.Info("Admin API request", zap.String("method", r.Method), zap.String("path", r.URL.Path),
+			zap.String("remote", r.RemoteAddr))
+		next(w, r)
+	}
+}
+
+// listChallenges lists the values presented challenges need and the
+// persisted operations that have not completed
+func (a *adminAPI) listChallenges(w http.ResponseWriter, r *http.Request) {
+	var challenges []adminChallenge
+	for _, value := range a.solver.active.tracked() {
+		challenges = append(challenges, adminChallenge{
+			FQDN:       value.FQDN,
+			Value:      dns.RedactValue(value.Value),
+			Challenges: value.Challenges,
+		})
+	}
+	if a.solver.state != nil {
+		records, err := a.solver.state.List(r.Context())
+		if err != nil {
+			writeAdminError(w, http.StatusInternalServerError, fmt.Errorf("failed to read challenge state: %w", err))
+			return
+		}
+		for _, rec := range records {
+			started := rec.Started
+			challenges = append(challenges, adminChallenge{
+				FQDN:      rec.FQDN,
+				Value:     dns.RedactValue(rec.Value),
+				Op:        rec.Op,
+				Namespace: rec.Namespace,
+				Servers:   rec.Servers,
+				Started:   &started,
+			})
+		}
+	}
+	writeAdminJSON(w, http.StatusOK, map[string]any{"challenges": emptyIfNil(challenges)})
+}
+
+// listServers reports the servers with pending repairs
+func (a *adminAPI) listServers(w http.ResponseWriter, _ *http.Request) {
+	servers := map[string]*adminServer{}
+	if a.solver.repairs != nil {
+		for _, item := range a.solver.repairs.Pending() {
+			server := servers[item.Server]
+			if server == nil {
+				server = &adminServer{Server: item.Server}
+				servers[item.Server] = server
+			}
+			server.Pending = append(server.Pending, adminRepair{
+				Namespace: item.Namespace,
+				FQDN:      item.FQDN,
+				Value:     dns.RedactValue(item.Value),
+				Attempts:  a.solver.repairs.Attempts(item),
+			})
+		}
+	}
+	list := make([]adminServer, 0, len(servers))
+	for _, server := range servers {
+		sort.Slice(server.Pending, func(i, j int) bool { return server.Pending[i].FQDN < server.Pending[j].FQDN })
+		list = append(list, *server)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Server < list[j].Server })
+	writeAdminJSON(w, http.StatusOK, map[string]any{"servers": list})
+}
+
+// retryServer retries the pending repairs of a server right away
+func (a *adminAPI) retryServer(w http.ResponseWriter, r *http.Request) {
+	server := r.PathValue("server")
+	retried := 0
+	if a.solver.repairs != nil {
+		retried = a.solver.repairs.Retry(server)
+	}
+	if retried == 0 {
+		writeAdminError(w, http.StatusNotFound, fmt.Errorf("no pending repairs for server %s", server))
+		return
+	}
+	a.logger.Info("Retrying server repairs on request", zap.String("server", server), zap.Int("repairs", retried))
+	writeAdminJSON(w, http.StatusAccepted, map[string]any{"server": server, "retried": retried})
+}
+
+// checkPropagation runs a propagation check and reports which servers
+// converged; a check that times out is not an error of the request
+func (a *adminAPI) checkPropagation(w http.ResponseWriter, r *http.Request) {
+	var req adminPropagationRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAdminRequestBody))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		writeAdminError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %w", err))
+		return
+	}
+	timeout, err := req.validate()
+	if err != nil {
+		writeAdminError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+	result, err := dns.WaitForPropagation(ctx, dns.PropagationCheck{
+		Servers:      req.Servers,
+		FQDN:         mdns.Fqdn(req.FQDN),
+		Value:        req.Value,
+		Present:      !req.Absent,
+		MinMatches:   req.MinMatches,
+		QueryTimeout: a.solver.opts.HealthTimeout,
+		Recursive:    req.Recursive,
+	})
+	response := adminPropagationResult{
+		Confirmed: err == nil,
+		Matched:   emptyIfNil(result.Matched),
+		Pending:   emptyIfNil(result.Pending),
+	}
+	if err != nil {
+		response.Error = err.Error()
+	}
+	writeAdminJSON(w, http.StatusOK, response)
+}
+
+// validate checks the request and returns the timeout of the check
+func (req adminPropagationRequest) validate() (time.Duration, error) {
+	if _, ok := mdns.IsDomainName(req.FQDN); !ok || req.FQDN == "" {
+		return 0, fmt.Errorf("invalid fqdn %q", req.FQDN)
+	}
+	if len(req.Servers) == 0 {
+		return 0, errors.New("servers is required")
+	}
+	for _, server := range req.Servers {
+		if _, _, err := dns.ParseServerAddress(server); err != nil {
+			return 0, err
+		}
+	}
+	if req.MinMatches < 0 || req.MinMatches > len(req.Servers) {
+		return 0, fmt.Errorf("minMatches must be between 0 and %d", len(req.Servers))
+	}
+	if req.Timeout == "" {
+		return defaultAdminCheckTimeout, nil
+	}
+	timeout, err := time.ParseDuration(req.Timeout)
+	if err != nil || timeout <= 0 || timeout > maxAdminCheckTimeout {
+		return 0, fmt.Errorf("timeout %q must be a duration up to %s", req.Timeout, maxAdminCheckTimeout)
+	}
+	return timeout, nil
+}
+
+// emptyIfNil renders a nil list as [] instead of null
+func emptyIfNil[T any](list []T) []T {
+	if list == nil {
+		return []T{}
+	}
+	return list
+}
+
+// writeAdminJSON writes body as the JSON response
+func writeAdminJSON(w http.ResponseWriter, code int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// writeAdminError writes err as a JSON error response
+func writeAdminError(w http.ResponseWriter, code int, err error) {
+	writeAdminJSON(w, code, map[string]string{"error": err.Error()})
+}
+
+// adminTLSConfig loads the serving certificate of the admin API and, with
+// a client CA, requires clients to present a certificate it signed
+func adminTLSConfig(opts Options) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(opts.AdminTLSCertFile, opts.AdminTLSKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load admin API certificate: %w", err)
+	}
+	config := &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+	if opts.AdminClientCAFile != "" {
+		pem, err := os.ReadFile(opts.AdminClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read admin API client CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in %s", opts.AdminClientCAFile)
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return config, nil
+}
+
+// serveAdmin serves the admin API of solver on opts.AdminAddr until the
+// process exits. Options.Validate made sure a token or client CA is set.
+func serveAdmin(opts Options, solver *DNS01Solver, logger *zap.Logger) error {
+	api := &adminAPI{solver: solver, logger: logger}
+	if opts.AdminTokenFile != "" {
+		raw, err := os.ReadFile(opts.AdminTokenFile)
+		if err != nil {
+			return fmt.Errorf("failed to read admin API token: %w", err)
+		}
+		api.token = strings.TrimSpace(string(raw))
+		if api.token == "" {
+			return fmt.Errorf("admin API token file %s is empty", opts.AdminTokenFile)
+		}
+	}
+	server := &http.Server{
+		Addr:              opts.AdminAddr,
+		Handler:           newAdminMux(api),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	logger.Info("Serving admin API",
+		zap.String("address", opts.AdminAddr),
+		zap.Bool("token", api.token != ""),
+		zap.Bool("clientCertificates", opts.AdminClientCAFile != ""),
+	)
+	if opts.AdminTLSCertFile == "" {
+		return server.ListenAndServe()
+	}
+	config, err := adminTLSConfig(opts)
+	if err != nil {
+		return err
+	}
+	server.TLSConfig = config
+	return server.ListenAndServeTLS("", "")
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"go.uber.org/zap"
+
+	rfc2136 "github.com/rieset/istio-dns01-bind9/internal/dns"
+)
+
+// adminRequest sends a request with token to mux and decodes the JSON response into out
+func adminRequest(t *testing.T, mux http.Handler, method, path, token, body string, out any) int {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if out != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+			t.Fatalf("%s %s: undecodable response %q: %v", method, path, rec.Body.String(), err)
+		}
+	}
+	return rec.Code
+}
+
+func TestAdminAPIAuthentication(t *testing.T) {
+	mux := newAdminMux(&adminAPI{solver: newTestSolver(t), token: "secret-token", logger: zap.NewNop()})
+	for _, token := range []string{"", "wrong-token", "secret-token-x"} {
+		if code := adminRequest(t, mux, http.MethodGet, "/v1/challenges", token, "", nil); code != http.StatusUnauthorized {
+			t.Errorf("token %q: status %d, want 401", token, code)
+		}
+	}
+	if code := adminRequest(t, mux, http.MethodGet, "/v1/challenges", "secret-token", "", nil); code != http.StatusOK {
+		t.Fatalf("valid token: status %d, want 200", code)
+	}
+}
+
+func TestAdminAPIChallengesAndRetry(t *testing.T) {
+	servers := startServers(t, 3)
+	servers[0].SetUpdateRcode(dns.RcodeServerFailure)
+	s := newRepairingSolver(t)
+	// Backoff long enough that only a forced retry reaches the server in time
+	s.opts.CleanupRetryBaseDelay, s.opts.CleanupRetryMaxDelay = time.Hour, time.Hour
+	s.repairs = newRepairQueue(s.repairServer, s.opts, zap.NewNop())
+	stopCh := make(chan struct{})
+	t.Cleanup(func() { close(stopCh) })
+	s.repairs.Start(stopCh)
+	mux := newAdminMux(&adminAPI{solver: s, token: "token", logger: zap.NewNop()})
+
+	ch := newChallenge(t, serverAddrs(servers), testFQDN, "value")
+	ch.UID = "challenge-1"
+	if err := s.Present(ch); err != nil {
+		t.Fatalf("Present: %v", err)
+	}
+
+	var challenges struct{ Challenges []adminChallenge }
+	if code := adminRequest(t, mux, http.MethodGet, "/v1/challenges", "token", "", &challenges); code != http.StatusOK ||
+		len(challenges.Challenges) != 1 || challenges.Challenges[0].FQDN != testFQDN ||
+		challenges.Challenges[0].Value != rfc2136.RedactValue("value") || challenges.Challenges[0].Challenges != 1 {
+		t.Fatalf("/v1/challenges = %d %+v, want the presented value", code, challenges)
+	}
+
+	var status struct{ Servers []adminServer }
+	if code := adminRequest(t, mux, http.MethodGet, "/v1/servers", "token", "", &status); code != http.StatusOK ||
+		len(status.Servers) != 1 || status.Servers[0].Server != servers[0].Addr() || len(status.Servers[0].Pending) != 1 {
+		t.Fatalf("/v1/servers = %d %+v, want the failed server with one repair", code, status)
+	}
+
+	if code := adminRequest(t, mux, http.MethodPost, "/v1/servers/"+servers[1].Addr()+"/retry", "token", "", nil); code != http.StatusNotFound {
+		t.Fatalf("retry of a synced server: status %d, want 404", code)
+	}
+	servers[0].SetUpdateRcode(dns.RcodeSuccess)
+	var retry struct{ Retried int }
+	if code := adminRequest(t, mux, http.MethodPost, "/v1/servers/"+servers[0].Addr()+"/retry", "token", "", &retry); code != http.StatusAccepted ||
+		retry.Retried != 1 {
+		t.Fatalf("retry: status %d %+v, want 202 retrying one repair", code, retry)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for len(servers[0].TXT(testFQDN)) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("forced retry never added the record")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestAdminAPIPropagation(t *testing.T) {
+	servers := startServers(t, 2)
+	servers[0].SetTXT(testFQDN, 60, "value")
+	servers[1].SetTXT(testFQDN, 60, "value")
+	mux := newAdminMux(&adminAPI{solver: newTestSolver(t), token: "token", logger: zap.NewNop()})
+	check := func(body string) (int, adminPropagationResult) {
+		var result adminPropagationResult
+		code := adminRequest(t, mux, http.MethodPost, "/v1/propagation", "token", body, &result)
+		return code, result
+	}
+	addrs := `["` + servers[0].Addr() + `","` + servers[1].Addr() + `"]`
+
+	code, result := check(`{"fqdn":"` + testFQDN + `","value":"value","servers":` + addrs + `}`)
+	if code != http.StatusOK || !result.Confirmed || len(result.Matched) != 2 {
+		t.Fatalf("check of a published value = %d %+v, want confirmed on both servers", code, result)
+	}
+
+	servers[1].SetTXT(testFQDN, 60)
+	code, result = check(`{"fqdn":"` + testFQDN + `","value":"value","servers":` + addrs + `,"timeout":"200ms"}`)
+	if code != http.StatusOK || result.Confirmed || len(result.Pending) != 1 || result.Pending[0] != servers[1].Addr() {
+		t.Fatalf("check of a missing value = %d %+v, want the second server pending", code, result)
+	}
+	code, result = check(`{"fqdn":"` + testFQDN + `","value":"value","servers":` + addrs + `,"absent":true,"minMatches":1}`)
+	if code != http.StatusOK || !result.Confirmed {
+		t.Fatalf("absence check = %d %+v, want confirmed", code, result)
+	}
+
+	for _, body := range []string{
+		`{"fqdn":"` + testFQDN + `","value":"value"}`,
+		`{"fqdn":"` + testFQDN + `","value":"value","servers":` + addrs + `,"timeout":"1h"}`,
+		`{"fqdn":"` + testFQDN + `","value":"value","servers":` + addrs + `,"minMatches":3}`,
+		`{"fqdn":"` + testFQDN + `","servers":` + addrs + `,"unknown":true}`,
+	} {
+		if code := adminRequest(t, mux, http.MethodPost, "/v1/propagation", "token", body, nil); code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", body, code)
+		}
+	}
+}
+
+func TestOptionsValidateAdmin(t *testing.T) {
+	tests := []struct {
+		name                 string
+		token, cert, key, ca string
+		wantErr              string
+	}{
+		{"token", "/token", "", "", "", ""},
+		{"mutual TLS", "", "/tls.crt", "/tls.key", "/ca.crt", ""},
+		{"unauthenticated", "", "/tls.crt", "/tls.key", "", EnvAdminTokenFile},
+		{"certificate without key", "/token", "/tls.crt", "", "", EnvAdminTLSKey},
+		{"client CA without TLS", "", "", "", "/ca.crt", EnvAdminTLSCert},
+	}
+	for _, tt := range tests {
+		opts := DefaultOptions()
+		opts.AdminAddr = ":8443"
+		opts.AdminTokenFile, opts.AdminTLSCertFile, opts.AdminTLSKeyFile, opts.AdminClientCAFile = tt.token, tt.cert, tt.key, tt.ca
+		err := opts.Validate()
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: %v", tt.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: error = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/rieset/istio-dns01-bind9/internal/metrics"
+)
+
+// FunctionRating: 80/100
+// - Complexity: LOW
+// - Integrations: 2 (net/http, metrics)
+// - External Risks: LOW (observes requests without changing them)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: instrumentAdmission
+// Purpose: Latency, status code and size metrics of the challenge requests of cert-manager, with a log of the slow ones
+
+// maxCapturedBody is how much of a request body is kept to find its action
+// and FQDN; solver configs are limited to 64 KiB
+const maxCapturedBody = 256 * 1024
+
+// admissionPayload is the part of a ChallengePayload the metrics and the
+// slow-request log need
+type admissionPayload struct {
+	Request struct {
+		Action            string `json:"action"`
+		ResolvedFQDN      string `json:"resolvedFQDN"`
+		ResourceNamespace string `json:"resourceNamespace"`
+	} `json:"request"`
+}
+
+// action returns the metric label of the request action
+func (p admissionPayload) action() string {
+	switch strings.ToLower(p.Request.Action) {
+	case "present":
+		return "present"
+	case "cleanup":
+		return "cleanup"
+	}
+	return "unknown"
+}
+
+// capturedBody counts the bytes the handler reads from a request body and
+// keeps the first maxCapturedBody of them
+type capturedBody struct {
+	io.ReadCloser
+	data bytes.Buffer
+	size int64
+}
+
+// Read implements io.Reader
+func (b *capturedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.size += int64(n)
+	if room := maxCapturedBody - b.data.Len(); room > 0 {
+		b.data.Write(p[:min(n, room)])
+	}
+	return n, err
+}
+
+// statusRecorder records the status code and size of a response
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	size   int64
+}
+
+// WriteHeader implements http.ResponseWriter
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Write implements http.ResponseWriter
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(b)
+	r.size += int64(n)
+	return n, err
+}
+
+// Unwrap returns the underlying writer for http.ResponseController
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// instrumentAdmission wraps the handler of the solver API server. The
+// challenge requests cert-manager posts to groupName are timed from arrival,
+// authentication and authorization included, and counted in the admission
+// metrics; those taking at least slow are logged with their FQDN so
+// cert-manager timeouts can be matched with the webhook's own processing
+// time. Other requests, such as discovery, pass through untouched.
+func instrumentAdmission(next http.Handler, groupName string, slow time.Duration, logger *zap.Logger) http.Handler {
+	prefix := "/apis/" + groupName + "/"
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || !strings.HasPrefix(r.URL.Path, prefix) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		start := time.Now()
+		body := &capturedBody{ReadCloser: r.Body}
+		r.Body = body
+		recorder := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r)
+		elapsed := time.Since(start)
+
+		status := recorder.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		// Requests rejected before their body was read carry no action
+		var payload admissionPayload
+		_ = json.Unmarshal(body.data.Bytes(), &payload)
+		action := payload.action()
+		metrics.AdmissionRequestSeconds.WithLabelValues(action, strconv.Itoa(status)).Observe(elapsed.Seconds())
+		metrics.AdmissionRequestBytes.WithLabelValues(action).Observe(float64(body.size))
+		metrics.AdmissionResponseBytes.WithLabelValues(action).Observe(float64(recorder.size))
+
+		if slow > 0 && elapsed >= slow {
+			logger.Warn("Slow challenge request",
+				zap.String("action", action),
+				zap.String("fqdn", payload.Request.ResolvedFQDN),
+				zap.String("namespace", payload.Request.ResourceNamespace),
+				zap.String("path", r.URL.Path),
+				zap.Int("status", status),
+				zap.Duration("duration", elapsed),
+				zap.Int64("request_bytes", body.size),
+				zap.Int64("response_bytes", recorder.size),
+			)
+		}
+	})
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/rieset/istio-dns01-bind9/internal/metrics"
+)
+
+// sampleCount returns how many observations histogram holds
+func sampleCount(t *testing.T, histogram prometheus.Observer) uint64 {
+	t.Helper()
+	var m dto.Metric
+	if err := histogram.(prometheus.Metric).Write(&m); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	return m.GetHistogram().GetSampleCount()
+}
+
+func TestInstrumentAdmission(t *testing.T) {
+	const group = "acme.example.com"
+	path := "/apis/" + group + "/v1alpha1/bind9"
+	present := `{"request":{"action":"Present","resolvedFQDN":"_acme-challenge.example.com.","resourceNamespace":"team-a"}}`
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		status   int
+		delay    time.Duration
+		action   string
+		code     string
+		observed bool
+		slowLog  bool
+	}{
+		{"fast present", http.MethodPost, path, present, http.StatusCreated, 0, "present", "201", true, false},
+		{"slow present", http.MethodPost, path, present, http.StatusOK, 30 * time.Millisecond, "present", "200", true, true},
+		{"cleanup rejected", http.MethodPost, path, `{"request":{"action":"CleanUp"}}`, http.StatusForbidden, 0, "cleanup", "403", true, false},
+		{"unreadable body", http.MethodPost, path, "not json", http.StatusBadRequest, 0, "unknown", "400", true, false},
+		{"discovery", http.MethodGet, "/apis/" + group + "/v1alpha1", "", http.StatusOK, 0, "unknown", "200", false, false},
+		{"other group", http.MethodPost, "/apis/other.example.com/v1alpha1/bind9", present, http.StatusOK, 0, "present", "200", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics.AdmissionRequestSeconds.Reset()
+			metrics.AdmissionRequestBytes.Reset()
+			metrics.AdmissionResponseBytes.Reset()
+			core, logs := observer.New(zap.WarnLevel)
+			var read string
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				read = string(body)
+				time.Sleep(tt.delay)
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(`{"response":{"success":true}}`))
+			})
+			handler := instrumentAdmission(next, group, 20*time.Millisecond, zap.New(core))
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+			if rec.Code != tt.status || read != tt.body {
+				t.Fatalf("handler saw status %d body %q, want %d %q", rec.Code, read, tt.status, tt.body)
+			}
+
+			var want uint64
+			if tt.observed {
+				want = 1
+			}
+			if got := sampleCount(t, metrics.AdmissionRequestSeconds.WithLabelValues(tt.action, tt.code)); got != want {
+				t.Fatalf("latency observations = %d, want %d", got, want)
+			}
+			if got := sampleCount(t, metrics.AdmissionRequestBytes.WithLabelValues(tt.action)); got != want {
+				t.Fatalf("request size observations = %d, want %d", got, want)
+			}
+
+			entries := logs.FilterMessage("Slow challenge request").All()
+			if (len(entries) == 1) != tt.slowLog {
+				t.Fatalf("slow request logs = %d, want logged %v", len(entries), tt.slowLog)
+			}
+			if tt.slowLog {
+				fields := entries[0].ContextMap()
+				if fields["fqdn"] != "_acme-challenge.example.com." || fields["namespace"] != "team-a" || fields["action"] != "present" {
+					t.Fatalf("slow request log fields = %v", fields)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cert-manager/cert-manager/pkg/acme/webhook/apis/acme/v1alpha1"
+	cmacme "github.com/cert-manager/cert-manager/pkg/apis/acme/v1"
+	cmversioned "github.com/cert-manager/cert-manager/pkg/client/clientset/versioned"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/rieset/istio-dns01-bind9/internal/dns"
+)
+
+// FunctionRating: 80/100
+// - Complexity: LOW
+// - Integrations: 1 (Kubernetes API)
+// - External Risks: LOW (events are best effort and never fail a challenge)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: challengeEvents
+// Purpose: Kubernetes Events telling the outcome of Present on the Challenge it solves
+
+// Reasons of the Events recorded for challenges
+const (
+	eventRecordAccepted     = "RecordAccepted"
+	eventPresented          = "Presented"
+	eventSecretUnavailable  = "SecretUnavailable"
+	eventUpdateRejected     = "UpdateRejected"
+	eventQuorumNotReached   = "QuorumNotReached"
+	eventPropagationTimeout = "PropagationTimeout"
+	eventPresentFailed      = "PresentFailed"
+)
+
+// challengeLookupTimeout bounds the search for the Challenge of a request
+const challengeLookupTimeout = 5 * time.Second
+
+// challengeEvents records Events about the challenges this instance presents.
+// Challenge requests carry no reference to their Challenge, so it is found
+// by its DNS name and key; with a configured object every Event is recorded
+// against that object instead.
+type challengeEvents struct {
+	recorder record.EventRecorder
+	// find returns the object the Events of a challenge are recorded against,
+	// or nil when there is none
+	find   func(ctx context.Context, ch *v1alpha1.ChallengeRequest) (*corev1.ObjectReference, error)
+	logger *zap.Logger
+}
+
+// newChallengeEvents creates the Event recorder of the solver, which stops
+// when stopCh is closed. object, when set, is the "namespace/name" of the
+// Deployment every Event is recorded against.
+func newChallengeEvents(cl kubernetes.Interface, cm cmversioned.Interface, component, object string,
+	stopCh <-chan struct{}, logger *zap.Logger) (*challengeEvents, error) {
+	e := &challengeEvents{logger: logger, find: challengeFinder(cm)}
+	if object != "" {
+		namespace, name, ok := strings.Cut(object, "/")
+		if !ok || namespace == "" || name == "" {
+			return nil, fmt.Errorf("%s must be namespace/name, got %q", EnvEventsObject, object)
+		}
+		ref := &corev1.ObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Namespace: namespace, Name: name}
+		e.find = func(context.Context, *v1alpha1.ChallengeRequest) (*corev1.ObjectReference, error) {
+			return ref, nil
+		}
+	}
+
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: cl.CoreV1().Events("")})
+	e.recorder = broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: component})
+	go func() {
+		<-stopCh
+		broadcaster.Shutdown()
+	}()
+	return e, nil
+}
+
+// challengeFinder returns a lookup of the DNS01 Challenge with the DNS name
+// and key of a request
+func challengeFinder(cm cmversioned.Interface) func(context.Context, *v1alpha1.ChallengeRequest) (*corev1.ObjectReference, error) {
+	return func(ctx context.Context, ch *v1alpha1.ChallengeRequest) (*corev1.ObjectReference, error) {
+		challenges, err := cm.AcmeV1().Challenges(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list challenges: %w", err)
+		}
+		for _, challenge := range challenges.Items {
+			if challenge.Spec.Type == cmacme.ACMEChallengeTypeDNS01 && challenge.Spec.Key == ch.Key &&
+				challenge.Spec.DNSName == ch.DNSName {
+				return &corev1.ObjectReference{
+					APIVersion:      cmacme.SchemeGroupVersion.String(),
+					Kind:            cmacme.ChallengeKind,
+					Namespace:       challenge.Namespace,
+					Name:            challenge.Name,
+					UID:             challenge.UID,
+					ResourceVersion: challenge.ResourceVersion,
+				}, nil
+			}
+		}
+		return nil, nil
+	}
+}
+
+// forChallenge returns the Event sink of ch. A nil challengeEvents returns a
+// nil sink, which records nothing.
+func (e *challengeEvents) forChallenge(ch *v1alpha1.ChallengeRequest) *challengeEventSink {
+	if e == nil {
+		return nil
+	}
+	return &challengeEventSink{events: e, ch: ch}
+}
+
+// challengeEventSink records the Events of one challenge, looking up the
+// object they belong to on the first one. It is safe for concurrent use.
+type challengeEventSink struct {
+	events *challengeEvents
+	ch     *v1alpha1.ChallengeRequest
+	once   sync.Once
+	ref    *corev1.ObjectReference
+}
+
+// eventf records an Event of type eventtype on the object of the challenge
+func (k *challengeEventSink) eventf(eventtype, reason, format string, args ...any) {
+	if k == nil {
+		return
+	}
+	k.once.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), challengeLookupTimeout)
+		defer cancel()
+		ref, err := k.events.find(ctx, k.ch)
+		if err != nil || ref == nil {
+			k.events.logger.Debug("No object to record challenge events on",
+				zap.String("fqdn", k.ch.ResolvedFQDN), zap.Error(err))
+			return
+		}
+		k.ref = ref
+	})
+	if k.ref != nil {
+		k.events.recorder.Eventf(k.ref, eventtype, reason, format, args...)
+	}
+}
+
+// serverAccepted wraps onServer to also record that server applied the record
+func (k *challengeEventSink) serverAccepted(onServer func(server string)) func(server string) {
+	if k == nil {
+		return onServer
+	}
+	return func(server string) {
+		onServer(server)
+		k.eventf(corev1.EventTypeNormal, eventRecordAccepted, "Server %s accepted TXT record %s", server, k.ch.ResolvedFQDN)
+	}
+}
+
+// presented records the outcome of Present: success, or the reason it failed
+func (k *challengeEventSink) presented(err error) {
+	if k == nil {
+		return
+	}
+	if err == nil {
+		k.eventf(corev1.EventTypeNormal, eventPresented, "TXT record %s presented", k.ch.ResolvedFQDN)
+		return
+	}
+	k.eventf(corev1.EventTypeWarning, presentFailureReason(err), "Presenting TXT record %s failed: %v", k.ch.ResolvedFQDN, err)
+}
+
+// presentFailureReason classifies the error of a failed Present
+func presentFailureReason(err error) string {
+	var quorum *QuorumError
+	var missingKey *missingSecretKeyError
+	switch {
+	case apierrors.IsNotFound(err), errors.As(err, &missingKey), errors.Is(err, fs.ErrNotExist):
+		return eventSecretUnavailable
+	case dns.IsPermanent(err):
+		return eventUpdateRejected
+	case errors.As(err, &quorum):
+		return eventQuorumNotReached
+	case errors.Is(err, errPropagationNotConfirmed):
+		return eventPropagationTimeout
+	}
+	return eventPresentFailed
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/cert-manager/cert-manager/pkg/acme/webhook/apis/acme/v1alpha1"
+	"github.com/miekg/dns"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
+
+	rfc2136 "github.com/rieset/istio-dns01-bind9/internal/dns"
+)
+
+// recordEvents makes s record Events on a fixed Challenge in recorder
+func recordEvents(s *DNS01Solver) *record.FakeRecorder {
+	recorder := record.NewFakeRecorder(32)
+	ref := &corev1.ObjectReference{Kind: "Challenge", Namespace: "default", Name: "example-challenge"}
+	s.events = &challengeEvents{
+		recorder: recorder,
+		find: func(context.Context, *v1alpha1.ChallengeRequest) (*corev1.ObjectReference, error) {
+			return ref, nil
+		},
+		logger: zap.NewNop(),
+	}
+	return recorder
+}
+
+// drainEvents returns the reasons of the Events recorder holds
+func drainEvents(recorder *record.FakeRecorder) []string {
+	var reasons []string
+	for {
+		select {
+		case event := <-recorder.Events:
+			// FakeRecorder formats events as "<type> <reason> <message>"
+			reasons = append(reasons, strings.Fields(event)[1])
+		default:
+			return reasons
+		}
+	}
+}
+
+// countReason returns how often reason occurs in reasons
+func countReason(reasons []string, reason string) int {
+	n := 0
+	for _, r := range reasons {
+		if r == reason {
+			n++
+		}
+	}
+	return n
+}
+
+func TestSolverPresentEvents(t *testing.T) {
+	servers := startServers(t, 3)
+	s := newTestSolver(t)
+	recorder := recordEvents(s)
+	ch := newChallenge(t, serverAddrs(servers), testFQDN, "token")
+
+	if err := s.Present(ch); err != nil {
+		t.Fatalf("Present: %v", err)
+	}
+	reasons := drainEvents(recorder)
+	if countReason(reasons, eventRecordAccepted) != 3 || countReason(reasons, eventPresented) != 1 {
+		t.Fatalf("events after Present = %v, want 3 %s and 1 %s", reasons, eventRecordAccepted, eventPresented)
+	}
+
+	ch.ResourceNamespace = "other"
+	if err := s.Present(ch); err == nil {
+		t.Fatal("Present succeeded without a TSIG secret")
+	}
+	if reasons := drainEvents(recorder); len(reasons) != 1 || reasons[0] != eventSecretUnavailable {
+		t.Fatalf("events without a secret = %v, want %s", reasons, eventSecretUnavailable)
+	}
+
+	ch.ResourceNamespace = "cert-manager"
+	s.opts.PreflightEnabled = false
+	servers[1].SetUpdateRcode(dns.RcodeServerFailure)
+	servers[2].SetUpdateRcode(dns.RcodeServerFailure)
+	if err := s.Present(ch); err == nil {
+		t.Fatal("Present succeeded on one of three servers")
+	}
+	reasons = drainEvents(recorder)
+	if countReason(reasons, eventQuorumNotReached) != 1 || countReason(reasons, eventPresented) != 0 {
+		t.Fatalf("events without a quorum = %v, want %s", reasons, eventQuorumNotReached)
+	}
+
+	servers[1].SetUpdateRcode(dns.RcodeRefused)
+	servers[2].SetUpdateRcode(dns.RcodeRefused)
+	if err := s.Present(ch); err == nil {
+		t.Fatal("Present succeeded on one of three servers")
+	}
+	reasons = drainEvents(recorder)
+	if countReason(reasons, eventUpdateRejected) != 1 || countReason(reasons, eventPresented) != 0 {
+		t.Fatalf("events of refused updates = %v, want %s", reasons, eventUpdateRejected)
+	}
+}
+
+func TestSolverPresentWithoutEvents(t *testing.T) {
+	servers := startServers(t, 1)
+	s := newTestSolver(t)
+	ch := newChallenge(t, serverAddrs(servers), testFQDN, "token")
+	if err := s.Present(ch); err != nil {
+		t.Fatalf("Present without an event recorder: %v", err)
+	}
+}
+
+func TestPresentFailureReason(t *testing.T) {
+	notFound := apierrors.NewNotFound(schema.GroupResource{Resource: "secrets"}, "tsig")
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{fmt.Errorf("failed to get TSIG secret: %w", notFound), eventSecretUnavailable},
+		{fmt.Errorf("preflight check failed: %w", &missingSecretKeyError{"ns", "tsig", "secret"}), eventSecretUnavailable},
+		{fmt.Errorf("failed to add TXT record: %w", &QuorumError{Succeeded: 1, Total: 3, Required: 2}), eventQuorumNotReached},
+		{fmt.Errorf("failed to add TXT record: %w", &QuorumError{Succeeded: 1, Total: 3, Required: 2,
+			Errors: []error{&rfc2136.RcodeError{Op: "update", Rcode: dns.RcodeRefused}}}), eventUpdateRejected},
+		{fmt.Errorf("%w: %w", errPropagationNotConfirmed, context.DeadlineExceeded), eventPropagationTimeout},
+		{errors.New("zone not found"), eventPresentFailed},
+	}
+	for _, tt := range tests {
+		if got := presentFailureReason(tt.err); got != tt.want {
+			t.Errorf("presentFailureReason(%v) = %s, want %s", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/cert-manager/cert-manager/pkg/acme/webhook/apis/acme/v1alpha1"
+)
+
+// FunctionRating: 80/100
+// - Complexity: MEDIUM
+// - Integrations: 0
+// - External Risks: LOW (in-memory only, lost on restart)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: challengeRegistry
+// Purpose: Serializes challenge operations per FQDN and tracks the TXT values still needed by an order
+
+// challengeName is the registry state of one challenge FQDN
+type challengeName struct {
+	// lock is held by the operation currently changing the records of the name
+	lock chan struct{}
+	// users counts the operations holding or waiting for lock
+	users int
+	// values holds per TXT value the IDs of the presented challenges that
+	// have not been cleaned up
+	values map[string]map[string]bool
+}
+
+// challengeRegistry coordinates the challenges of this webhook instance that
+// share an FQDN, as the apex and wildcard challenges of one certificate do.
+// Operations on a name run one at a time, and a value is only deleted once
+// every challenge that presented it was cleaned up. Challenges are told apart
+// by ID, so a Present that cert-manager retries counts once. Instances
+// coordinate through zone leases; the registry does not span processes.
+type challengeRegistry struct {
+	mu    sync.Mutex
+	names map[string]*challengeName
+}
+
+// newChallengeRegistry creates an empty registry
+func newChallengeRegistry() *challengeRegistry {
+	return &challengeRegistry{names: map[string]*challengeName{}}
+}
+
+// registryKey normalizes fqdn so spellings of the same name share an entry
+func registryKey(fqdn string) string {
+	return strings.ToLower(strings.TrimSuffix(fqdn, "."))
+}
+
+// lock waits until no other operation changes the records of fqdn and
+// returns the function releasing it
+func (r *challengeRegistry) lock(ctx context.Context, fqdn string) (func(), error) {
+	key := registryKey(fqdn)
+	r.mu.Lock()
+	name := r.entry(key)
+	name.users++
+	r.mu.Unlock()
+
+	select {
+	case name.lock <- struct{}{}:
+	case <-ctx.Done():
+		r.leave(key, name)
+		return nil, ctx.Err()
+	}
+	return func() {
+		<-name.lock
+		r.leave(key, name)
+	}, nil
+}
+
+// entry returns the state of key, creating it when missing; r.mu must be held
+func (r *challengeRegistry) entry(key string) *challengeName {
+	name := r.names[key]
+	if name == nil {
+		name = &challengeName{lock: make(chan struct{}, 1), values: map[string]map[string]bool{}}
+		r.names[key] = name
+	}
+	return name
+}
+
+// leave drops a user of name and forgets the name once nothing refers to it
+func (r *challengeRegistry) leave(key string, name *challengeName) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	name.users--
+	r.prune(key, name)
+}
+
+// prune removes name when no operation uses it and no value is active; r.mu must be held
+func (r *challengeRegistry) prune(key string, name *challengeName) {
+	if name.users == 0 && len(name.values) == 0 {
+		delete(r.names, key)
+	}
+}
+
+// present records the challenge with ID challenge presenting value at fqdn
+// and returns the number of distinct values now active at fqdn. Presenting
+// the same challenge again changes nothing.
+func (r *challengeRegistry) present(fqdn, value, challenge string) int {
+	key := registryKey(fqdn)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	name := r.entry(key)
+	if name.values[value] == nil {
+		name.values[value] = map[string]bool{}
+	}
+	name.values[value][challenge] = true
+	return len(name.values)
+}
+
+// release records the cleanup of the challenge with ID challenge presenting
+// value at fqdn and returns how many other presented challenges still need
+// the value. Values this instance never saw, such as those presented before a
+// restart, report zero.
+func (r *challengeRegistry) release(fqdn, value, challenge string) int {
+	key := registryKey(fqdn)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	name := r.names[key]
+	if name == nil || len(name.values[value]) == 0 {
+		return 0
+	}
+	delete(name.values[value], challenge)
+	remaining := len(name.values[value])
+	if remaining == 0 {
+		delete(name.values, value)
+		r.prune(key, name)
+	}
+	return remaining
+}
+
+// needed reports whether a presented challenge still needs value at fqdn
+func (r *challengeRegistry) needed(fqdn, value string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	name := r.names[registryKey(fqdn)]
+	return name != nil && len(name.values[value]) > 0
+}
+
+// trackedValue is a TXT value the registry keeps for presented challenges
+type trackedValue struct {
+	FQDN  string
+	Value string
+	// Challenges counts the presented challenges that need the value
+	Challenges int
+}
+
+// tracked returns every value still needed by a presented challenge, by name
+func (r *challengeRegistry) tracked() []trackedValue {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var values []trackedValue
+	for key, name := range r.names {
+		for value, challenges := range name.values {
+			values = append(values, trackedValue{FQDN: key + ".", Value: value, Challenges: len(challenges)})
+		}
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if values[i].FQDN != values[j].FQDN {
+			return values[i].FQDN < values[j].FQDN
+		}
+		return values[i].Value < values[j].Value
+	})
+	return values
+}
+
+// challengeID identifies the Challenge resource behind ch. cert-manager sets
+// its UID; requests without one share the empty ID.
+func challengeID(ch *v1alpha1.ChallengeRequest) string {
+	return string(ch.UID)
+}
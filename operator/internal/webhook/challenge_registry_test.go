@@ -0,0 +1,105 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestChallengeRegistryValues(t *testing.T) {
+	r := newChallengeRegistry()
+	if got := r.present("_acme-challenge.example.com.", "a", "order-1"); got != 1 {
+		t.Fatalf("present(a) = %d active values, want 1", got)
+	}
+	if got := r.present("_ACME-challenge.example.com", "b", "order-2"); got != 2 {
+		t.Fatalf("present(b) = %d active values, want 2", got)
+	}
+	// A retried Present of the same challenge counts once
+	r.present("_acme-challenge.example.com.", "a", "order-1")
+	r.present("_acme-challenge.example.com.", "a", "order-3")
+
+	steps := []struct {
+		value, challenge string
+		remaining        int
+		needed           bool
+	}{
+		{value: "a", challenge: "order-1", remaining: 1, needed: true},
+		{value: "a", challenge: "order-1", remaining: 1, needed: true},
+		{value: "a", challenge: "order-3", remaining: 0, needed: false},
+		{value: "a", challenge: "order-3", remaining: 0, needed: false},
+		{value: "b", challenge: "order-2", remaining: 0, needed: false},
+		{value: "unknown", challenge: "order-4", remaining: 0, needed: false},
+	}
+	for i, step := range steps {
+		if got := r.release("_acme-challenge.example.com.", step.value, step.challenge); got != step.remaining {
+			t.Fatalf("step %d: release(%s, %s) = %d, want %d", i, step.value, step.challenge, got, step.remaining)
+		}
+		if got := r.needed("_acme-challenge.example.com.", step.value); got != step.needed {
+			t.Fatalf("step %d: needed(%s) = %v, want %v", i, step.value, got, step.needed)
+		}
+	}
+	if len(r.names) != 0 {
+		t.Fatalf("registry keeps %d names after every value was released", len(r.names))
+	}
+}
+
+func TestChallengeRegistryLock(t *testing.T) {
+	r := newChallengeRegistry()
+	unlock, err := r.lock(context.Background(), "_acme-challenge.example.com.")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Other names are not blocked
+	other, err := r.lock(context.Background(), "_acme-challenge.www.example.com.")
+	if err != nil {
+		t.Fatalf("lock of another name: %v", err)
+	}
+	other()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := r.lock(ctx, "_acme-challenge.example.com"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("second lock = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	acquired := make(chan struct{})
+	go func() {
+		second, err := r.lock(context.Background(), "_acme-challenge.example.com.")
+		if err == nil {
+			second()
+		}
+		close(acquired)
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("lock acquired while held")
+	case <-time.After(50 * time.Millisecond):
+	}
+	unlock()
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("lock not acquired after release")
+	}
+	if len(r.names) != 0 {
+		t.Fatalf("registry keeps %d names after every lock was released", len(r.names))
+	}
+}
@@ -0,0 +1,212 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/rieset/istio-dns01-bind9/internal/dns"
+	"github.com/rieset/istio-dns01-bind9/internal/solverconfig"
+)
+
+// FunctionRating: 78/100
+// - Complexity: MEDIUM
+// - Integrations: 1 (Kubernetes API)
+// - External Risks: LOW (best-effort writes, bounded ConfigMap size)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: resultPublisher
+// Purpose: Publishes a machine-readable summary of each challenge's DNS work for external automation
+
+const (
+	// outcomeSucceeded marks an operation that completed
+	outcomeSucceeded = "succeeded"
+	// outcomeFailed marks an operation that returned an error
+	outcomeFailed = "failed"
+)
+
+// operationResult summarizes the last attempt of one Present or CleanUp
+type operationResult struct {
+	Outcome string `json:"outcome"`
+	Error   string `json:"error,omitempty"`
+	// ServersUpdated applied the update; ServersNotUpdated did not
+	ServersUpdated    []string `json:"serversUpdated,omitempty"`
+	ServersNotUpdated []string `json:"serversNotUpdated,omitempty"`
+	// ServersVerified confirmed the expected state; ServersUnverified did not in time
+	ServersVerified   []string  `json:"serversVerified,omitempty"`
+	ServersUnverified []string  `json:"serversUnverified,omitempty"`
+	StartedAt         time.Time `json:"startedAt"`
+	DurationSeconds   float64   `json:"durationSeconds"`
+}
+
+// challengeResult is the published summary of one challenge, keyed like its state record
+type challengeResult struct {
+	Namespace string           `json:"namespace"`
+	FQDN      string           `json:"fqdn"`
+	Zone      string           `json:"zone,omitempty"`
+	Present   *operationResult `json:"present,omitempty"`
+	Cleanup   *operationResult `json:"cleanup,omitempty"`
+	UpdatedAt time.Time        `json:"updatedAt"`
+}
+
+// operationReport collects what one operation did while it runs
+type operationReport struct {
+	started      time.Time
+	mu           sync.Mutex
+	updated      []string
+	verification *dns.PropagationResult
+}
+
+// newOperationReport starts a report at the current time
+func newOperationReport() *operationReport {
+	return &operationReport{started: time.Now()}
+}
+
+// serverDone records that server applied the update
+func (r *operationReport) serverDone(server string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.updated = append(r.updated, server)
+}
+
+// verified records the outcome of the propagation check that followed the update
+func (r *operationReport) verified(result dns.PropagationResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.verification = &result
+}
+
+// result finishes the report against the configured servers and the operation error
+func (r *operationReport) result(servers []string, err error) *operationResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := &operationResult{
+		Outcome:         outcomeSucceeded,
+		ServersUpdated:  slices.Clone(r.updated),
+		StartedAt:       r.started.UTC(),
+		DurationSeconds: time.Since(r.started).Seconds(),
+	}
+	sort.Strings(result.ServersUpdated)
+	for _, server := range servers {
+		if !slices.Contains(r.updated, server) {
+			result.ServersNotUpdated = append(result.ServersNotUpdated, server)
+		}
+	}
+	if r.verification != nil {
+		result.ServersVerified = r.verification.Matched
+		result.ServersUnverified = r.verification.Pending
+	}
+	if err != nil {
+		result.Outcome = outcomeFailed
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// updateTargets returns the servers config sends updates to. The servers of
+// API-backed providers are only used for verification.
+func updateTargets(config *Config) []string {
+	if config.Provider != solverconfig.ProviderRFC2136 {
+		return nil
+	}
+	return config.Servers
+}
+
+// resultPublisher keeps the most recent challenge summaries in a ConfigMap
+type resultPublisher struct {
+	data       *configMapData
+	maxEntries int
+}
+
+// newResultPublisher creates a publisher writing to the ConfigMap namespace/name
+func newResultPublisher(client kubernetes.Interface, namespace, name string, maxEntries int) *resultPublisher {
+	return &resultPublisher{data: newConfigMapData(client, namespace, name), maxEntries: maxEntries}
+}
+
+// Publish merges the outcome of op into the summary of the challenge fqdn/value
+// and drops the least recently updated summaries beyond maxEntries
+func (p *resultPublisher) Publish(ctx context.Context, namespace, fqdn, value, zone string, op challengeOp,
+	result *operationResult) error {
+	key := challengeKey(fqdn, value)
+	return p.data.Update(ctx, func(data map[string]string) bool {
+		summary := challengeResult{Namespace: namespace, FQDN: fqdn}
+		_ = json.Unmarshal([]byte(data[key]), &summary)
+		if zone != "" {
+			summary.Zone = zone
+		}
+		switch op {
+		case opPresent:
+			summary.Present = result
+		case opCleanup:
+			summary.Cleanup = result
+		}
+		summary.UpdatedAt = time.Now().UTC()
+
+		raw, err := json.Marshal(summary)
+		if err != nil {
+			return false
+		}
+		data[key] = string(raw)
+		p.pruneLocked(data)
+		return true
+	})
+}
+
+// pruneLocked removes the least recently updated entries beyond maxEntries
+func (p *resultPublisher) pruneLocked(data map[string]string) {
+	if p.maxEntries <= 0 || len(data) <= p.maxEntries {
+		return
+	}
+
+	type entry struct {
+		key     string
+		updated time.Time
+	}
+	entries := make([]entry, 0, len(data))
+	for key, raw := range data {
+		var summary challengeResult
+		_ = json.Unmarshal([]byte(raw), &summary)
+		entries = append(entries, entry{key: key, updated: summary.UpdatedAt})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].updated.Before(entries[j].updated) })
+	for _, e := range entries[:len(entries)-p.maxEntries] {
+		delete(data, e.key)
+	}
+}
+
+// publishResult publishes the outcome of op when result publishing is enabled;
+// failures are logged since the summary is informational
+func (s *DNS01Solver) publishResult(namespace, fqdn, value, zone string, op challengeOp, result *operationResult) {
+	if s.results == nil {
+		return
+	}
+	if err := s.results.Publish(context.Background(), namespace, fqdn, value, zone, op, result); err != nil {
+		s.logger.Warn("Failed to publish challenge result", zap.String("fqdn", fqdn), zap.Error(err))
+	}
+}
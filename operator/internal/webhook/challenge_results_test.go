@@ -0,0 +1,112 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// publishedResult returns the summary published for fqdn/value
+func publishedResult(t *testing.T, s *DNS01Solver, fqdn, value string) challengeResult {
+	t.Helper()
+	data, err := s.results.data.Get(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var summary challengeResult
+	if err := json.Unmarshal([]byte(data[challengeKey(fqdn, value)]), &summary); err != nil {
+		t.Fatalf("no summary for %s: %v", fqdn, err)
+	}
+	return summary
+}
+
+func TestSolverPublishesResults(t *testing.T) {
+	servers := startServers(t, 3)
+	servers[2].SetUpdateRcode(dns.RcodeServerFailure)
+	s := newTestSolver(t)
+	s.results = newResultPublisher(s.client, "cert-manager", "results", 10)
+	addrs := serverAddrs(servers)
+	ch := newChallenge(t, addrs, testFQDN, "token")
+
+	if err := s.Present(ch); err != nil {
+		t.Fatalf("Present: %v", err)
+	}
+	summary := publishedResult(t, s, testFQDN, "token")
+	want := addrs[:2]
+	sort.Strings(want)
+	if summary.Zone != "example.com" || summary.Present == nil || summary.Present.Outcome != outcomeSucceeded ||
+		!reflect.DeepEqual(summary.Present.ServersUpdated, want) ||
+		!reflect.DeepEqual(summary.Present.ServersNotUpdated, addrs[2:]) {
+		t.Fatalf("present summary = %+v", summary.Present)
+	}
+
+	item := cleanupItem{Namespace: ch.ResourceNamespace, FQDN: ch.ResolvedFQDN, Value: ch.Key, Config: string(ch.Config.Raw)}
+	if err := cleanUp(context.Background(), s, item); err != nil {
+		t.Fatalf("cleanUp: %v", err)
+	}
+	summary = publishedResult(t, s, testFQDN, "token")
+	if summary.Present == nil || summary.Cleanup == nil || summary.Cleanup.Outcome != outcomeSucceeded ||
+		len(summary.Cleanup.ServersVerified) != 3 || len(summary.Cleanup.ServersUnverified) != 0 {
+		t.Fatalf("cleanup summary = %+v", summary.Cleanup)
+	}
+
+	// A failed Present is published with its error
+	servers[1].SetUpdateRcode(dns.RcodeServerFailure)
+	if err := s.Present(newChallenge(t, addrs, testFQDN, "other")); err == nil {
+		t.Fatal("Present succeeded without a quorum")
+	}
+	if summary = publishedResult(t, s, testFQDN, "other"); summary.Present.Outcome != outcomeFailed ||
+		summary.Present.Error == "" {
+		t.Fatalf("failed present summary = %+v", summary.Present)
+	}
+}
+
+func TestResultPublisherPrunes(t *testing.T) {
+	s := newTestSolver(t)
+	publisher := newResultPublisher(s.client, "cert-manager", "results", 3)
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		result := &operationResult{Outcome: outcomeSucceeded, StartedAt: time.Now()}
+		if err := publisher.Publish(ctx, "ns", fmt.Sprintf("_acme-challenge.%d.example.com.", i), "v", "example.com",
+			opPresent, result); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	data, err := publisher.data.Get(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 3 {
+		t.Fatalf("kept %d summaries, want 3", len(data))
+	}
+	for i := 0; i < 2; i++ {
+		if _, ok := data[challengeKey(fmt.Sprintf("_acme-challenge.%d.example.com.", i), "v")]; ok {
+			t.Fatalf("oldest summary %d was kept", i)
+		}
+	}
+}
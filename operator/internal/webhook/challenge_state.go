@@ -0,0 +1,256 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/rieset/istio-dns01-bind9/internal/solverconfig"
+)
+
+// FunctionRating: 76/100
+// - Complexity: MEDIUM
+// - Integrations: 1 (Kubernetes API)
+// - External Risks: MEDIUM (ConfigMap writes on every challenge operation)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: challengeStore
+// Purpose: Persists in-flight challenge operations in a ConfigMap so they survive webhook restarts
+
+// challengeOp is the operation a persisted challenge record is waiting on
+type challengeOp string
+
+const (
+	// opPresent is an interrupted Present: the record may be missing on some servers
+	opPresent challengeOp = "present"
+	// opCleanup is a CleanUp whose deletion has not completed yet
+	opCleanup challengeOp = "cleanup"
+)
+
+// challengeRecord is one in-flight challenge operation
+type challengeRecord struct {
+	Op        challengeOp `json:"op"`
+	Namespace string      `json:"namespace"`
+	FQDN      string      `json:"fqdn"`
+	Value     string      `json:"value"`
+	// Challenge is the ID of the challenge, see challengeID
+	Challenge string `json:"challenge,omitempty"`
+	// Config is the raw solver config of the challenge
+	Config string `json:"config"`
+	// Servers lists the servers that already applied Op
+	Servers []string  `json:"servers,omitempty"`
+	Started time.Time `json:"started"`
+}
+
+// challengeStore keeps challenge records in the data of a single ConfigMap,
+// one key per fqdn and value. A CleanUp replaces the Present record of the
+// same challenge.
+type challengeStore struct {
+	data   *configMapData
+	logger *zap.Logger
+}
+
+// newChallengeStore creates a store backed by the ConfigMap namespace/name
+func newChallengeStore(client kubernetes.Interface, namespace, name string, logger *zap.Logger) *challengeStore {
+	return &challengeStore{data: newConfigMapData(client, namespace, name), logger: logger}
+}
+
+// challengeKey returns the ConfigMap key of the challenge fqdn/value
+func challengeKey(fqdn, value string) string {
+	sum := sha256.Sum256([]byte(fqdn + "\x00" + value))
+	return hex.EncodeToString(sum[:12])
+}
+
+// Put stores rec, replacing any record of the same challenge
+func (c *challengeStore) Put(ctx context.Context, rec challengeRecord) error {
+	raw, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to encode challenge record: %w", err)
+	}
+	return c.data.Update(ctx, func(data map[string]string) bool {
+		data[challengeKey(rec.FQDN, rec.Value)] = string(raw)
+		return true
+	})
+}
+
+// MarkServer records that server applied the pending op of the challenge fqdn/value.
+// Records that are missing or waiting on a different op are left alone.
+func (c *challengeStore) MarkServer(ctx context.Context, op challengeOp, fqdn, value, server string) error {
+	key := challengeKey(fqdn, value)
+	return c.data.Update(ctx, func(data map[string]string) bool {
+		var rec challengeRecord
+		if err := json.Unmarshal([]byte(data[key]), &rec); err != nil || rec.Op != op ||
+			slices.Contains(rec.Servers, server) {
+			return false
+		}
+		rec.Servers = append(rec.Servers, server)
+		raw, err := json.Marshal(rec)
+		if err != nil {
+			return false
+		}
+		data[key] = string(raw)
+		return true
+	})
+}
+
+// Delete removes the record of the challenge fqdn/value if it is waiting on op
+func (c *challengeStore) Delete(ctx context.Context, op challengeOp, fqdn, value string) error {
+	key := challengeKey(fqdn, value)
+	return c.data.Update(ctx, func(data map[string]string) bool {
+		var rec challengeRecord
+		if err := json.Unmarshal([]byte(data[key]), &rec); err != nil || rec.Op != op {
+			return false
+		}
+		delete(data, key)
+		return true
+	})
+}
+
+// List returns every stored record, oldest first. Undecodable entries are skipped.
+func (c *challengeStore) List(ctx context.Context) ([]challengeRecord, error) {
+	data, err := c.data.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]challengeRecord, 0, len(data))
+	for key, raw := range data {
+		var rec challengeRecord
+		if err := json.Unmarshal([]byte(raw), &rec); err != nil {
+			c.logger.Warn("Ignoring undecodable challenge record", zap.String("key", key), zap.Error(err))
+			continue
+		}
+		records = append(records, rec)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].Started.Before(records[j].Started) })
+	return records, nil
+}
+
+// recordChallenge persists rec; failures are logged since the state only aids recovery
+func (s *DNS01Solver) recordChallenge(rec challengeRecord) {
+	if s.state == nil {
+		return
+	}
+	if err := s.state.Put(context.Background(), rec); err != nil {
+		s.logger.Warn("Failed to persist challenge state", zap.String("fqdn", rec.FQDN), zap.Error(err))
+	}
+}
+
+// forgetChallenge drops the persisted op of the challenge fqdn/value
+func (s *DNS01Solver) forgetChallenge(op challengeOp, fqdn, value string) {
+	if s.state == nil {
+		return
+	}
+	if err := s.state.Delete(context.Background(), op, fqdn, value); err != nil {
+		s.logger.Warn("Failed to clear challenge state", zap.String("fqdn", fqdn), zap.Error(err))
+	}
+}
+
+// challengeProgress returns a callback recording the servers a Present
+// reached in report and, when state is persisted, in the challenge record
+func (s *DNS01Solver) challengeProgress(fqdn, value string, report *operationReport) func(server string) {
+	return func(server string) {
+		report.serverDone(server)
+		if s.state == nil {
+			return
+		}
+		if err := s.state.MarkServer(context.Background(), opPresent, fqdn, value, server); err != nil {
+			s.logger.Warn("Failed to persist challenge progress",
+				zap.String("fqdn", fqdn), zap.String("server", server), zap.Error(err))
+		}
+	}
+}
+
+// resumeChallenges finishes the operations a previous process left behind:
+// pending cleanups are queued again and interrupted Presents are completed
+// on the servers they had not reached
+func (s *DNS01Solver) resumeChallenges(ctx context.Context) {
+	records, err := s.state.List(ctx)
+	if err != nil {
+		s.logger.Warn("Unable to load challenge state, in-flight challenges are not resumed", zap.Error(err))
+		return
+	}
+
+	for _, rec := range records {
+		s.logger.Info("Resuming in-flight challenge",
+			zap.String("op", string(rec.Op)),
+			zap.String("fqdn", rec.FQDN),
+			zap.Strings("done_servers", rec.Servers),
+			zap.Time("started", rec.Started),
+		)
+		switch rec.Op {
+		case opCleanup:
+			s.cleanups.Enqueue(cleanupItem{Namespace: rec.Namespace, FQDN: rec.FQDN, Value: rec.Value, Config: rec.Config})
+		case opPresent:
+			if err := s.resumePresent(ctx, rec); err != nil {
+				s.logger.Warn("Failed to resume interrupted Present", zap.String("fqdn", rec.FQDN), zap.Error(err))
+			} else {
+				// The value stays until the CleanUp of its challenge, as if Present had returned
+				s.active.present(rec.FQDN, rec.Value, rec.Challenge)
+			}
+			s.forgetChallenge(opPresent, rec.FQDN, rec.Value)
+		}
+	}
+}
+
+// resumePresent adds the challenge record of rec to the servers it is still missing on
+func (s *DNS01Solver) resumePresent(ctx context.Context, rec challengeRecord) error {
+	config, err := s.parseConfig(&apiextensionsv1.JSON{Raw: []byte(rec.Config)})
+	if err != nil {
+		return fmt.Errorf("failed to parse config: %w", err)
+	}
+	target, err := s.resolveZone(ctx, config, rec.FQDN)
+	if err != nil {
+		return err
+	}
+
+	remaining := *config
+	if config.Provider == solverconfig.ProviderRFC2136 {
+		remaining.Servers = nil
+		for _, server := range config.Servers {
+			if !slices.Contains(rec.Servers, server) {
+				remaining.Servers = append(remaining.Servers, server)
+			}
+		}
+		if len(remaining.Servers) == 0 {
+			return nil
+		}
+	}
+
+	dnsManager, err := s.newDNSManager(ctx, rec.Namespace, &remaining, nil,
+		s.laggingServers(rec.Namespace, rec.FQDN, rec.Value, rec.Config))
+	if err != nil {
+		return err
+	}
+	return s.updateZone(ctx, config.Zone, func(ctx context.Context) error {
+		return dnsManager.AddTXTRecord(ctx, target, rec.Value, config.TTL)
+	})
+}
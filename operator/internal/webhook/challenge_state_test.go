@@ -0,0 +1,179 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestChallengeStore(t *testing.T) {
+	ctx := context.Background()
+	store := newChallengeStore(fake.NewSimpleClientset(), "cert-manager", "state", zap.NewNop())
+
+	if records, err := store.List(ctx); err != nil || len(records) != 0 {
+		t.Fatalf("List on missing ConfigMap = %v, %v", records, err)
+	}
+
+	started := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	rec := challengeRecord{Op: opPresent, Namespace: "cert-manager", FQDN: testFQDN, Value: "token", Started: started}
+	if err := store.Put(ctx, rec); err != nil {
+		t.Fatal(err)
+	}
+	for _, server := range []string{"a", "b", "a"} {
+		if err := store.MarkServer(ctx, opPresent, testFQDN, "token", server); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Progress of another op must not touch the record
+	if err := store.MarkServer(ctx, opCleanup, testFQDN, "token", "c"); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := store.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || !reflect.DeepEqual(records[0].Servers, []string{"a", "b"}) {
+		t.Fatalf("records = %+v", records)
+	}
+
+	// A CleanUp replaces the Present record and survives deletes of the Present op
+	rec.Op = opCleanup
+	if err := store.Put(ctx, rec); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Delete(ctx, opPresent, testFQDN, "token"); err != nil {
+		t.Fatal(err)
+	}
+	if records, _ = store.List(ctx); len(records) != 1 || records[0].Op != opCleanup || len(records[0].Servers) != 0 {
+		t.Fatalf("records after replace = %+v", records)
+	}
+	if err := store.Delete(ctx, opCleanup, testFQDN, "token"); err != nil {
+		t.Fatal(err)
+	}
+	if records, _ = store.List(ctx); len(records) != 0 {
+		t.Fatalf("records after delete = %+v", records)
+	}
+}
+
+func TestResumeChallenges(t *testing.T) {
+	servers := startServers(t, 3)
+	s := newTestSolver(t)
+	s.state = newChallengeStore(s.client, "cert-manager", "state", zap.NewNop())
+	stopCh := make(chan struct{})
+	t.Cleanup(func() { close(stopCh) })
+	s.cleanups = newCleanupQueue(s.cleanupRecord, s.opts, zap.NewNop())
+	s.cleanups.Start(stopCh)
+	ctx := context.Background()
+
+	// An interrupted Present that only reached the first server
+	present := newChallenge(t, serverAddrs(servers), testFQDN, "present-token")
+	servers[0].SetTXT(testFQDN, 60, "present-token")
+	err := s.state.Put(ctx, challengeRecord{Op: opPresent, Namespace: present.ResourceNamespace, FQDN: testFQDN,
+		Value: "present-token", Challenge: "order-1", Config: string(present.Config.Raw),
+		Servers: []string{servers[0].Addr()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A scheduled CleanUp that never ran. Its deletion runs in the background
+	// while the Updates check below asserts the resumed Present left the first
+	// server alone, so it only targets the other servers.
+	const staleFQDN = "_acme-challenge.stale.example.com."
+	cleanup := newChallenge(t, serverAddrs(servers[1:]), staleFQDN, "stale-token")
+	for _, srv := range servers[1:] {
+		srv.SetTXT(staleFQDN, 60, "stale-token")
+	}
+	err = s.state.Put(ctx, challengeRecord{Op: opCleanup, Namespace: cleanup.ResourceNamespace, FQDN: staleFQDN,
+		Value: "stale-token", Config: string(cleanup.Config.Raw)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.resumeChallenges(ctx)
+
+	for _, srv := range servers {
+		if got := srv.TXT(testFQDN); len(got) != 1 || got[0] != "present-token" {
+			t.Fatalf("server %s has TXT %v after resuming Present", srv.Addr(), got)
+		}
+	}
+	if servers[0].Updates() != 0 {
+		t.Fatal("resumed Present updated a server it had already reached")
+	}
+	// The resumed value is kept until the CleanUp of its challenge
+	if !s.active.needed(testFQDN, "present-token") {
+		t.Fatal("resumed Present not registered as active")
+	}
+	if remaining := s.active.release(testFQDN, "present-token", "order-1"); remaining != 0 {
+		t.Fatalf("release after resume = %d, want 0", remaining)
+	}
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		records, err := s.state.List(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(records) == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("challenge state not drained: %+v", records)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	for _, srv := range servers {
+		if got := srv.TXT(staleFQDN); len(got) != 0 {
+			t.Fatalf("server %s has TXT %v after resuming CleanUp", srv.Addr(), got)
+		}
+	}
+}
+
+func TestSolverPresentPersistsProgress(t *testing.T) {
+	servers := startServers(t, 2)
+	s := newTestSolver(t)
+	s.state = newChallengeStore(s.client, "cert-manager", "state", zap.NewNop())
+
+	var reached []string
+	progress := s.challengeProgress(testFQDN, "token", newOperationReport())
+	s.recordChallenge(challengeRecord{Op: opPresent, FQDN: testFQDN, Value: "token"})
+	for _, srv := range servers {
+		progress(srv.Addr())
+		reached = append(reached, srv.Addr())
+	}
+	records, err := s.state.List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || !reflect.DeepEqual(records[0].Servers, reached) {
+		t.Fatalf("records = %+v", records)
+	}
+
+	// A completed Present leaves no state behind
+	if err := s.Present(newChallenge(t, serverAddrs(servers), testFQDN, "token")); err != nil {
+		t.Fatal(err)
+	}
+	if records, _ = s.state.List(context.Background()); len(records) != 0 {
+		t.Fatalf("records after Present = %+v", records)
+	}
+}
@@ -0,0 +1,54 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rieset/istio-dns01-bind9/internal/dns"
+)
+
+func TestMultiServerQuorumUnderFaults(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		faulty  int
+		wantErr bool
+	}{
+		{"one server drops responses", "drop=100", 1, false},
+		{"one server has a bad TSIG secret", "corrupt-tsig=100", 1, false},
+		{"two servers answer SERVFAIL", "rcode=SERVFAIL", 2, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			servers := startServers(t, 3)
+			config, err := dns.ParseFaultSpec(tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			config.Servers = serverAddrs(servers[:tt.faulty])
+			dns.SetFaultInjector(dns.NewRandomFaults(config))
+			defer dns.SetFaultInjector(nil)
+
+			err = newTestManager(servers).AddTXTRecord(context.Background(), testFQDN, "token", 60)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AddTXTRecord error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"crypto/tls"
+	"slices"
+	"time"
+
+	"go.uber.org/zap"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/rieset/istio-dns01-bind9/internal/dns"
+	"github.com/rieset/istio-dns01-bind9/internal/metrics"
+)
+
+// FunctionRating: 78/100
+// - Complexity: MEDIUM
+// - Integrations: 3 (workqueue, dns package, metrics)
+// - External Risks: MEDIUM (DNS server availability, retries)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: cleanupQueue
+// Purpose: Background queue that deletes challenge records with retries and verification
+
+// verifyQueryTimeout bounds each TXT query issued while verifying an update
+const verifyQueryTimeout = 5 * time.Second
+
+// cleanupItem identifies one pending challenge record deletion.
+// The raw solver config is carried as a string so the item stays comparable.
+type cleanupItem struct {
+	Namespace string
+	FQDN      string
+	Value     string
+	Config    string
+}
+
+// cleanupFunc deletes the record described by item and verifies the deletion
+type cleanupFunc func(ctx context.Context, item cleanupItem) error
+
+// cleanupQueue runs challenge record deletions in the background so CleanUp
+// returns to cert-manager without waiting for slow or partially down servers.
+// The queue only lives in memory: the challenge state ConfigMap is what keeps
+// a deletion across restarts, including one dropped after maxRetries, and
+// without it a dropped or interrupted deletion is left to the stale sweeper.
+type cleanupQueue struct {
+	queue      workqueue.TypedRateLimitingInterface[cleanupItem]
+	process    cleanupFunc
+	workers    int
+	maxRetries int
+	timeout    time.Duration
+	logger     *zap.Logger
+}
+
+// newCleanupQueue creates a cleanup queue that runs process for every item
+func newCleanupQueue(process cleanupFunc, opts Options, logger *zap.Logger) *cleanupQueue {
+	rateLimiter := workqueue.NewTypedItemExponentialFailureRateLimiter[cleanupItem](
+		opts.CleanupRetryBaseDelay, opts.CleanupRetryMaxDelay)
+
+	return &cleanupQueue{
+		queue: workqueue.NewTypedRateLimitingQueueWithConfig(rateLimiter,
+			workqueue.TypedRateLimitingQueueConfig[cleanupItem]{Name: "dns01_cleanup"}),
+		process:    process,
+		workers:    opts.CleanupWorkers,
+		maxRetries: opts.CleanupMaxRetries,
+		timeout:    opts.CleanupTimeout,
+		logger:     logger,
+	}
+}
+
+// Enqueue schedules a record deletion
+func (q *cleanupQueue) Enqueue(item cleanupItem) {
+	q.queue.Add(item)
+	metrics.CleanupQueueDepth.Set(float64(q.queue.Len()))
+}
+
+// Start launches the workers and shuts the queue down when stopCh closes
+func (q *cleanupQueue) Start(stopCh <-chan struct{}) {
+	for i := 0; i < q.workers; i++ {
+		go q.runWorker()
+	}
+	go func() {
+		<-stopCh
+		q.queue.ShutDown()
+	}()
+}
+
+// runWorker processes items until the queue is shut down
+func (q *cleanupQueue) runWorker() {
+	for q.processNextItem() {
+	}
+}
+
+// processNextItem handles one item, requeueing it with backoff on failure
+func (q *cleanupQueue) processNextItem() bool {
+	item, shutdown := q.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer q.queue.Done(item)
+	defer func() { metrics.CleanupQueueDepth.Set(float64(q.queue.Len())) }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), q.timeout)
+	defer cancel()
+
+	err := q.process(ctx, item)
+	if err == nil {
+		q.queue.Forget(item)
+		metrics.CleanupOperations.WithLabelValues("success").Inc()
+		return true
+	}
+
+	retries := q.queue.NumRequeues(item)
+	if dns.IsPermanent(err) {
+		q.logger.Error("Challenge record cleanup rejected by the servers, not retrying",
+			zap.String("fqdn", item.FQDN),
+			zap.Int("attempts", retries+1),
+			zap.Error(err),
+		)
+		metrics.CleanupOperations.WithLabelValues("rejected").Inc()
+		q.queue.Forget(item)
+		return true
+	}
+	if retries < q.maxRetries {
+		q.logger.Warn("Challenge record cleanup failed, retrying",
+			zap.String("fqdn", item.FQDN),
+			zap.Int("attempt", retries+1),
+			zap.Error(err),
+		)
+		metrics.CleanupOperations.WithLabelValues("retry").Inc()
+		q.queue.AddRateLimited(item)
+		return true
+	}
+
+	// Its challenge state record stays, so with the state enabled the next start retries it
+	q.logger.Error("Challenge record cleanup abandoned after retries",
+		zap.String("fqdn", item.FQDN),
+		zap.Int("attempts", retries+1),
+		zap.Error(err),
+	)
+	metrics.CleanupOperations.WithLabelValues("dropped").Inc()
+	q.queue.Forget(item)
+	return true
+}
+
+// verifyDeleted waits until no server or secondary of config still
+// publishes value at fqdn, polling and giving up as timing prescribes. With
+// the serial secondary check the secondaries only need to reach the serial
+// of the servers once those dropped the value. Servers with an entry in
+// tlsConfigs are queried over DNS-over-TLS, those with a proxy through it.
+func verifyDeleted(ctx context.Context, config *Config, tlsConfigs map[string]*tls.Config,
+	fqdn, value string, timing dns.PropagationTiming) (dns.PropagationResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, timing.Timeout)
+	defer cancel()
+
+	check := dns.PropagationCheck{
+		Servers:      append(slices.Clone(config.Servers), config.Secondaries...),
+		FQDN:         fqdn,
+		Value:        value,
+		Present:      false,
+		Interval:     timing.Interval,
+		QueryTimeout: verifyQueryTimeout,
+		TLS:          tlsConfigs,
+		Proxies:      config.ServerProxies(),
+	}
+	if !config.Propagation.WatchesSerial() || len(config.Secondaries) == 0 {
+		return dns.WaitForPropagation(ctx, check)
+	}
+	check.Servers = config.Servers
+	result, err := dns.WaitForPropagation(ctx, check)
+	if err != nil {
+		return result, err
+	}
+	return waitForSecondaries(ctx, config, check, result)
+}
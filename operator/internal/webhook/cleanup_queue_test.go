@@ -0,0 +1,125 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+
+	rfc2136 "github.com/rieset/istio-dns01-bind9/internal/dns"
+	"github.com/rieset/istio-dns01-bind9/internal/metrics"
+)
+
+func TestCleanupQueueRetries(t *testing.T) {
+	tests := []struct {
+		name  string
+		rcode int
+		// foreign publishes the value without a registry entry while the manager tracks ownership
+		foreign   bool
+		wantCalls int
+		result    string
+	}{
+		{"success", dns.RcodeSuccess, false, 1, "success"},
+		{"dropped after retries", dns.RcodeServerFailure, false, 3, "dropped"},
+		{"refused", dns.RcodeRefused, false, 1, "rejected"},
+		{"not authoritative", dns.RcodeNotAuth, false, 1, "rejected"},
+		{"not owned", dns.RcodeSuccess, true, 1, "rejected"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			servers := startServers(t, 2)
+			m := newTestManager(servers)
+			for _, srv := range servers {
+				srv.SetUpdateRcode(tt.rcode)
+				if tt.foreign {
+					srv.SetTXT(testFQDN, 60, "token")
+				}
+			}
+			if tt.foreign {
+				m.SetOwnership(rfc2136.Ownership{Owner: "cluster-a"})
+			}
+			opts := DefaultOptions()
+			opts.CleanupMaxRetries = 2
+			opts.CleanupRetryBaseDelay = time.Millisecond
+			opts.CleanupRetryMaxDelay = time.Millisecond
+			var calls int
+			q := newCleanupQueue(func(ctx context.Context, item cleanupItem) error {
+				calls++
+				if err := m.DeleteTXTRecordValue(ctx, item.FQDN, item.Value); err != nil {
+					return fmt.Errorf("failed to delete TXT record: %w", err)
+				}
+				return nil
+			}, opts, zap.NewNop())
+			before := testutil.ToFloat64(metrics.CleanupOperations.WithLabelValues(tt.result))
+
+			q.Enqueue(cleanupItem{FQDN: testFQDN, Value: "token"})
+			for range tt.wantCalls {
+				q.processNextItem()
+			}
+			if calls != tt.wantCalls || q.queue.Len() != 0 {
+				t.Fatalf("%d attempts left %d items queued, want %d and none", calls, q.queue.Len(), tt.wantCalls)
+			}
+			if got := testutil.ToFloat64(metrics.CleanupOperations.WithLabelValues(tt.result)); got != before+1 {
+				t.Fatalf("cleanup_operations_total{result=%q} grew by %v, want 1", tt.result, got-before)
+			}
+		})
+	}
+}
+
+func TestSolverDroppedCleanupResumes(t *testing.T) {
+	servers := startServers(t, 2)
+	for _, srv := range servers {
+		srv.SetUpdateRcode(dns.RcodeServerFailure)
+	}
+	s := newTestSolver(t)
+	s.opts.CleanupMaxRetries = 1
+	s.opts.CleanupRetryBaseDelay = time.Millisecond
+	s.opts.CleanupRetryMaxDelay = time.Millisecond
+	s.state = newChallengeStore(s.client, "cert-manager", "state", zap.NewNop())
+	s.cleanups = newCleanupQueue(s.cleanupRecord, s.opts, zap.NewNop())
+	ctx := context.Background()
+	ch := newChallenge(t, serverAddrs(servers), testFQDN, "token")
+
+	if err := s.CleanUp(ch); err != nil {
+		t.Fatalf("CleanUp: %v", err)
+	}
+	for range 2 {
+		s.cleanups.processNextItem()
+	}
+	if s.cleanups.queue.Len() != 0 {
+		t.Fatal("cleanup still queued after its retries")
+	}
+
+	// The dropped deletion stays in the challenge state for the next start
+	records, err := s.state.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].Op != opCleanup || records[0].FQDN != testFQDN {
+		t.Fatalf("challenge state after the drop = %+v, want the cleanup", records)
+	}
+	s.resumeChallenges(ctx)
+	if s.cleanups.queue.Len() != 1 {
+		t.Fatal("the dropped cleanup was not queued again on resume")
+	}
+}
@@ -0,0 +1,72 @@
+//go:build conformance
+
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	acmetest "github.com/cert-manager/cert-manager/test/acme"
+	"go.uber.org/zap"
+
+	"github.com/rieset/istio-dns01-bind9/internal/dnstest"
+)
+
+// TestConformance runs cert-manager's DNS01 webhook conformance suite
+// against the solver. The suite starts a local API server from envtest
+// binaries, so it is built only with the conformance tag; run it with
+// `make test-conformance`.
+//
+// By default records are written to an in-memory dnstest server. Set
+// CONFORMANCE_DNS_SERVER to a BIND address instead, e.g. 127.0.0.1:15353 from
+// test/integration/pebble/docker-compose.yaml.
+func TestConformance(t *testing.T) {
+	server := os.Getenv("CONFORMANCE_DNS_SERVER")
+	if server == "" {
+		srv := dnstest.NewServer("example.com")
+		srv.AddTSIGKey(dnstest.TestKeyName, dnstest.TestSecret)
+		if err := srv.Start(); err != nil {
+			t.Fatalf("failed to start test server: %v", err)
+		}
+		defer func() { _ = srv.Close() }()
+		server = srv.Addr()
+	}
+
+	solver := NewDNS01Solver(DefaultOptions(), zap.NewNop())
+	fixture := acmetest.NewFixture(solver,
+		acmetest.SetResolvedZone("example.com."),
+		acmetest.SetResolvedFQDN("_acme-challenge.conformance.example.com."),
+		acmetest.SetAllowAmbientCredentials(false),
+		acmetest.SetManifestPath("testdata/conformance"),
+		acmetest.SetDNSServer(server),
+		acmetest.SetUseAuthoritative(false),
+		acmetest.SetPollInterval(500*time.Millisecond),
+		acmetest.SetPropagationLimit(time.Minute),
+		acmetest.SetConfig(map[string]any{
+			"servers":        []string{server},
+			"zone":           "example.com",
+			"tsigKeyName":    dnstest.TestKeyName,
+			"tsigAlgorithm":  "hmac-sha256",
+			"tsigSecretName": "tsig",
+			"tsigSecretKey":  "secret",
+		}),
+	)
+	fixture.RunConformance(t)
+}
@@ -18,24 +18,38 @@ package webhook
 
 import (
 	"context"
-	"encoding/json"
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
-	acmev1 "github.com/cert-manager/cert-manager/pkg/apis/acme/v1"
 	"github.com/cert-manager/cert-manager/pkg/acme/webhook/apis/acme/v1alpha1"
-	"github.com/cert-manager/cert-manager/pkg/acme/webhook/cmd"
+	cmversioned "github.com/cert-manager/cert-manager/pkg/client/clientset/versioned"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+
+	"github.com/rieset/istio-dns01-bind9/internal/dns"
+	"github.com/rieset/istio-dns01-bind9/internal/solverconfig"
+	"github.com/rieset/istio-dns01-bind9/internal/workpool"
 )
 
 // FunctionRating: 85/100
 // - Complexity: MEDIUM
 // - Integrations: 3 (cert-manager, kubernetes client, webhook)
 // - External Risks: MEDIUM (Kubernetes API, DNS operations)
-// - Unit Tests: NO
+// - Unit Tests: YES
 // - E2E Tests: NO
 // - Typing: FULL
 // - Critical Issues: NONE
@@ -45,29 +59,101 @@ import (
 
 // DNS01Solver implements the cert-manager webhook solver interface
 type DNS01Solver struct {
-	client kubernetes.Interface
-	logger *zap.Logger
+	client   kubernetes.Interface
+	secrets  *secretCache
+	cleanups *cleanupQueue
+	repairs  *repairQueue
+	state    *challengeStore
+	active   *challengeRegistry
+	leases   *zoneLeases
+	results  *resultPublisher
+	pool     *workpool.Pool
+	tenants  *tenantScheduler
+	zones    *dns.ZoneCache
+	conns    *dns.ConnPool
+	limiter  *dns.UpdateLimiter
+	batcher  *addBatcher
+	vault    *vaultSecretProvider
+	// access decides which namespaces' Secrets a challenge may read
+	access *secretPolicy
+	// managers keeps RFC2136 managers across challenges; nil builds one per operation
+	managers *managerCache
+	// tsigKeys remembers the fallback TSIG key each server accepted
+	tsigKeys *dns.TSIGKeyCache
+	policy   *domainPolicy
+	defaults *solverDefaults
+	recorder *dns.DryRunRecorder
+	events   *challengeEvents
+	// resolvConf lists the resolvers of system resolver sets
+	resolvConf string
+	// ops tracks the DNS operations in flight for draining at shutdown
+	ops       *operationTracker
+	drainOnce sync.Once
+	// drained is closed once draining finished
+	drained chan struct{}
+	opts    Options
+	logger  *zap.Logger
 }
 
 // NewDNS01Solver creates a new DNS01 solver
-func NewDNS01Solver(logger *zap.Logger) *DNS01Solver {
-	return &DNS01Solver{
-		logger: logger,
+func NewDNS01Solver(opts Options, logger *zap.Logger) *DNS01Solver {
+	s := &DNS01Solver{
+		pool:     workpool.New(opts.DNSWorkers, opts.DNSWorkersPerZone),
+		tenants:  newTenantScheduler(opts),
+		zones:    dns.NewZoneCache(logger),
+		conns:    dns.NewConnPool(opts.ConnIdleTimeout, opts.ConnMaxIdle),
+		limiter:  dns.NewUpdateLimiter(opts.UpdateRate, opts.UpdateBurst, opts.MaxUpdatesInFlight),
+		policy:   newDomainPolicy(opts.DomainAllowlist, opts.DomainDenylist),
+		active:   newChallengeRegistry(),
+		defaults: &solverDefaults{},
+		ops:      newOperationTracker(),
+		drained:  make(chan struct{}),
+		opts:     opts,
+		logger:   logger,
+	}
+	s.resolvConf = dns.ResolvConfPath
+	s.recorder = dns.NewDryRunRecorder(logger)
+	s.tsigKeys = dns.NewTSIGKeyCache()
+	s.access = newSecretPolicy(opts, logger)
+	s.managers = newManagerCache(opts.ManagerCacheSize)
+	if opts.Vault.Address != "" {
+		s.vault = newVaultSecretProvider(opts.Vault)
 	}
+	if opts.BatchWindow > 0 {
+		s.batcher = newAddBatcher(opts.BatchWindow, opts.OperationTimeout, s.sendAddBatch)
+	}
+	return s
 }
 
 // Name returns the name of the solver
 func (s *DNS01Solver) Name() string {
-	return "multi-dns"
+	return s.opts.SolverName
 }
 
+// errPropagationNotConfirmed is wrapped by Present when the record did not
+// become visible on enough servers in time
+var errPropagationNotConfirmed = errors.New("failed to verify TXT record propagation")
+
 // Present creates a TXT record for the DNS01 challenge
-func (s *DNS01Solver) Present(ch *v1alpha1.ChallengeRequest) error {
+func (s *DNS01Solver) Present(ch *v1alpha1.ChallengeRequest) (err error) {
 	s.logger.Info("Presenting DNS01 challenge",
 		zap.String("fqdn", ch.ResolvedFQDN),
-		zap.String("key", ch.Key),
+		zap.String("key", dns.RedactValue(ch.Key)),
 		zap.String("namespace", ch.ResourceNamespace),
 	)
+	ctx, span := startChallengeSpan(context.Background(), "dns01.Present", ch.ResourceNamespace, ch.ResolvedFQDN)
+	defer func() { dns.EndSpan(span, err) }()
+	done, ok := s.ops.begin()
+	if !ok {
+		return errShuttingDown
+	}
+	defer done()
+	events := s.events.forChallenge(ch)
+	defer func() { events.presented(err) }()
+
+	if err := s.policy.check(ch.ResolvedFQDN); err != nil {
+		return err
+	}
 
 	// Parse configuration
 	config, err := s.parseConfig(ch.Config)
@@ -75,27 +161,108 @@ func (s *DNS01Solver) Present(ch *v1alpha1.ChallengeRequest) error {
 		return fmt.Errorf("failed to parse config: %w", err)
 	}
 
-	// Get TSIG secret from Kubernetes Secret
-	tsigSecret, err := s.getTSIGSecret(ch.ResourceNamespace, config.TSIGSecretName, config.TSIGSecretKey)
+	report := newOperationReport()
+	defer func() {
+		s.publishResult(ch.ResourceNamespace, ch.ResolvedFQDN, ch.Key, config.Zone, opPresent,
+			report.result(updateTargets(config), err))
+	}()
+
+	// Zone discovery, preflight and the update share one deadline so a hung
+	// server cannot hold Present; verification has a timeout of its own
+	opCtx, cancel := context.WithTimeout(ctx, s.operationTimeout(config))
+	defer cancel()
+
+	// The DNS work queues behind the other operations of the Issuer's
+	// namespace; verification does not hold a slot
+	release, err := s.tenants.acquire(opCtx, ch.ResourceNamespace)
 	if err != nil {
-		return fmt.Errorf("failed to get TSIG secret: %w", err)
+		return fmt.Errorf("failed waiting for a scheduler slot of namespace %s: %w", ch.ResourceNamespace, err)
 	}
+	defer release()
 
-	// Create multi-server DNS manager
-	dnsManager := NewMultiServerDNS(
-		config.Servers,
-		config.Zone,
-		config.TSIGKeyName,
-		config.TSIGAlgorithm,
-		tsigSecret,
-		s.logger,
-	)
+	// The record is written at target, which differs from the challenge name
+	// when a CNAME delegates it; locks, state and results stay keyed by the
+	// challenge name
+	target, err := s.resolveZone(opCtx, config, ch.ResolvedFQDN)
+	if err != nil {
+		return err
+	}
+	span.SetAttributes(attribute.String("dns.zone", config.Zone))
 
-	// Add TXT record
-	ctx := context.Background()
-	if err := dnsManager.AddTXTRecord(ctx, ch.ResolvedFQDN, ch.Key, config.TTL); err != nil {
+	// Fail before touching any server when the update-policy does not cover the name
+	if err := s.preflightUpdate(opCtx, ch.ResourceNamespace, config, target); err != nil {
+		return fmt.Errorf("preflight check failed: %w", err)
+	}
+
+	// Persist the operation so a restart can finish it; the record is
+	// dropped once Present returns and only survives a crash
+	s.recordChallenge(challengeRecord{
+		Op:        opPresent,
+		Namespace: ch.ResourceNamespace,
+		FQDN:      ch.ResolvedFQDN,
+		Value:     ch.Key,
+		Challenge: challengeID(ch),
+		Config:    string(ch.Config.Raw),
+		Started:   time.Now(),
+	})
+	defer s.forgetChallenge(opPresent, ch.ResolvedFQDN, ch.Key)
+
+	// Create the zone's DNS provider
+	onServer := events.serverAccepted(s.challengeProgress(ch.ResolvedFQDN, ch.Key, report))
+	onLagging := s.laggingServers(ch.ResourceNamespace, ch.ResolvedFQDN, ch.Key, string(ch.Config.Raw))
+	dnsManager, err := s.newDNSManager(ctx, ch.ResourceNamespace, config, onServer, onLagging)
+	if err != nil {
+		return err
+	}
+
+	// Operations on the same name run one at a time, so the apex and wildcard
+	// challenges of a certificate cannot race each other's deletes
+	unlock, err := s.active.lock(opCtx, ch.ResolvedFQDN)
+	if err != nil {
+		return fmt.Errorf("failed waiting for other challenges of %s: %w", ch.ResolvedFQDN, err)
+	}
+
+	// Add TXT record through the shared worker pool, keyed by zone for fairness,
+	// while this instance owns the zone. With a batch window, records of the
+	// zone arriving meanwhile share the UPDATE.
+	if manager, ok := dnsManager.(*MultiServerDNS); ok && s.batcher != nil {
+		err = s.batcher.add(opCtx, batchKey(ch.ResourceNamespace, config.Zone, string(ch.Config.Raw)), config.Zone,
+			manager, config.TTL, batchedAdd{
+				value:     dns.TXTValue{FQDN: target, Value: ch.Key},
+				onServer:  onServer,
+				onLagging: onLagging,
+			})
+	} else {
+		err = s.updateZone(opCtx, config.Zone, func(ctx context.Context) error {
+			return dnsManager.AddTXTRecord(ctx, target, ch.Key, config.TTL)
+		})
+	}
+	if err == nil {
+		active := s.active.present(ch.ResolvedFQDN, ch.Key, challengeID(ch))
+		s.logger.Debug("Challenge value registered",
+			zap.String("fqdn", ch.ResolvedFQDN),
+			zap.Int("active_values", active),
+		)
+	}
+	unlock()
+	release()
+	if err != nil {
 		return fmt.Errorf("failed to add TXT record: %w", err)
 	}
+	if s.dryRun(config) {
+		s.logger.Info("Dry run: DNS01 challenge not published, skipping verification",
+			zap.String("fqdn", ch.ResolvedFQDN),
+		)
+		return nil
+	}
+
+	// Return only once the record is visible, so cert-manager's self-check
+	// and the ACME server do not query servers that have not caught up
+	verification, err := s.verifyPresent(ctx, ch.ResourceNamespace, config, target, ch.Key)
+	report.verified(verification)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errPropagationNotConfirmed, err)
+	}
 
 	s.logger.Info("DNS01 challenge presented successfully",
 		zap.String("fqdn", ch.ResolvedFQDN),
@@ -104,135 +271,900 @@ func (s *DNS01Solver) Present(ch *v1alpha1.ChallengeRequest) error {
 	return nil
 }
 
-// CleanUp removes the TXT record after challenge completion
-func (s *DNS01Solver) CleanUp(ch *v1alpha1.ChallengeRequest) error {
+// CleanUp schedules removal of the TXT record after challenge completion.
+// The deletion runs in the background cleanup queue so slow DNS servers do
+// not block cert-manager's order finalization.
+func (s *DNS01Solver) CleanUp(ch *v1alpha1.ChallengeRequest) (err error) {
 	s.logger.Info("Cleaning up DNS01 challenge",
 		zap.String("fqdn", ch.ResolvedFQDN),
-		zap.String("key", ch.Key),
+		zap.String("key", dns.RedactValue(ch.Key)),
 		zap.String("namespace", ch.ResourceNamespace),
 	)
+	_, span := startChallengeSpan(context.Background(), "dns01.CleanUp", ch.ResourceNamespace, ch.ResolvedFQDN)
+	defer func() { dns.EndSpan(span, err) }()
+	done, ok := s.ops.begin()
+	if !ok {
+		return errShuttingDown
+	}
+	defer done()
+
+	if err := s.policy.check(ch.ResolvedFQDN); err != nil {
+		return err
+	}
 
 	// Parse configuration
-	config, err := s.parseConfig(ch.Config)
+	if _, err := s.parseConfig(ch.Config); err != nil {
+		return fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	if s.cleanups == nil {
+		return fmt.Errorf("cleanup queue not initialized")
+	}
+	// Another order presenting the same value keeps the record
+	if remaining := s.active.release(ch.ResolvedFQDN, ch.Key, challengeID(ch)); remaining > 0 {
+		s.logger.Info("TXT record still needed by other challenges, keeping it",
+			zap.String("fqdn", ch.ResolvedFQDN),
+			zap.Int("challenges", remaining),
+		)
+		return nil
+	}
+	if s.repairs != nil {
+		s.repairs.Cancel(ch.ResolvedFQDN, ch.Key)
+	}
+	s.recordChallenge(challengeRecord{
+		Op:        opCleanup,
+		Namespace: ch.ResourceNamespace,
+		FQDN:      ch.ResolvedFQDN,
+		Value:     ch.Key,
+		Challenge: challengeID(ch),
+		Config:    string(ch.Config.Raw),
+		Started:   time.Now(),
+	})
+	s.cleanups.Enqueue(cleanupItem{
+		Namespace: ch.ResourceNamespace,
+		FQDN:      ch.ResolvedFQDN,
+		Value:     ch.Key,
+		Config:    string(ch.Config.Raw),
+	})
+
+	s.logger.Info("DNS01 challenge cleanup scheduled",
+		zap.String("fqdn", ch.ResolvedFQDN),
+	)
+	return nil
+}
+
+// cleanupRecord deletes a challenge record from all servers and verifies that
+// it is gone; it is called by the background cleanup queue
+func (s *DNS01Solver) cleanupRecord(ctx context.Context, item cleanupItem) (err error) {
+	ctx, span := startChallengeSpan(ctx, "dns01.cleanupRecord", item.Namespace, item.FQDN)
+	defer func() { dns.EndSpan(span, err) }()
+	// A deletion turned away while draining stays in the challenge state
+	done, ok := s.ops.begin()
+	if !ok {
+		return errShuttingDown
+	}
+	defer done()
+
+	config, err := s.parseConfig(&apiextensionsv1.JSON{Raw: []byte(item.Config)})
 	if err != nil {
 		return fmt.Errorf("failed to parse config: %w", err)
 	}
 
-	// Get TSIG secret from Kubernetes Secret
-	tsigSecret, err := s.getTSIGSecret(ch.ResourceNamespace, config.TSIGSecretName, config.TSIGSecretKey)
+	report := newOperationReport()
+	defer func() {
+		s.publishResult(item.Namespace, item.FQDN, item.Value, config.Zone, opCleanup,
+			report.result(updateTargets(config), err))
+	}()
+
+	opCtx, cancel := context.WithTimeout(ctx, s.operationTimeout(config))
+	defer cancel()
+	release, err := s.tenants.acquire(opCtx, item.Namespace)
+	if err != nil {
+		return fmt.Errorf("failed waiting for a scheduler slot of namespace %s: %w", item.Namespace, err)
+	}
+	defer release()
+	target, err := s.resolveZone(opCtx, config, item.FQDN)
 	if err != nil {
-		return fmt.Errorf("failed to get TSIG secret: %w", err)
+		return err
 	}
+	span.SetAttributes(attribute.String("dns.zone", config.Zone))
 
-	// Create multi-server DNS manager
-	dnsManager := NewMultiServerDNS(
-		config.Servers,
-		config.Zone,
-		config.TSIGKeyName,
-		config.TSIGAlgorithm,
-		tsigSecret,
-		s.logger,
-	)
+	// Create the zone's DNS provider
+	dnsManager, err := s.newDNSManager(ctx, item.Namespace, config, report.serverDone, nil)
+	if err != nil {
+		return err
+	}
 
-	// Delete TXT record
-	ctx := context.Background()
-	if err := dnsManager.DeleteTXTRecord(ctx, ch.ResolvedFQDN); err != nil {
+	// A challenge presented again since CleanUp was scheduled keeps the record
+	unlock, err := s.active.lock(opCtx, item.FQDN)
+	if err != nil {
+		return fmt.Errorf("failed waiting for other challenges of %s: %w", item.FQDN, err)
+	}
+	if s.active.needed(item.FQDN, item.Value) {
+		unlock()
+		s.logger.Info("TXT record presented again, skipping cleanup", zap.String("fqdn", item.FQDN))
+		s.forgetChallenge(opCleanup, item.FQDN, item.Value)
+		return nil
+	}
+
+	// Delete TXT record through the shared worker pool, keyed by zone for fairness,
+	// while this instance owns the zone
+	err = s.updateZone(opCtx, config.Zone, func(ctx context.Context) error {
+		return dnsManager.DeleteTXTRecordValue(ctx, target, item.Value)
+	})
+	unlock()
+	release()
+	if err != nil {
 		return fmt.Errorf("failed to delete TXT record: %w", err)
 	}
+	if s.dryRun(config) {
+		s.forgetChallenge(opCleanup, item.FQDN, item.Value)
+		return nil
+	}
+
+	// Verify the record is gone everywhere, waiting as long as the zone's SOA timers call for
+	timing := s.propagationTiming(ctx, config)
+	tlsConfigs, err := s.serverTLSConfigs(ctx, item.Namespace, config)
+	if err != nil {
+		return err
+	}
+	verification, err := verifyDeleted(ctx, config, tlsConfigs, target, item.Value, timing)
+	report.verified(verification)
+	if err != nil {
+		return fmt.Errorf("failed to verify TXT record deletion: %w", err)
+	}
+	s.forgetChallenge(opCleanup, item.FQDN, item.Value)
 
 	s.logger.Info("DNS01 challenge cleaned up successfully",
-		zap.String("fqdn", ch.ResolvedFQDN),
+		zap.String("fqdn", item.FQDN),
 		zap.Int("servers", len(config.Servers)),
 	)
 	return nil
 }
 
-// Initialize initializes the solver with Kubernetes client
-func (s *DNS01Solver) Initialize(kubeClientConfig *rest.Config, stopCh <-chan struct{}) error {
-	cl, err := kubernetes.NewForConfig(kubeClientConfig)
+// preflightUpdate confirms with a no-op UPDATE that every RFC2136 server lets
+// the TSIG key change TXT records at fqdn
+func (s *DNS01Solver) preflightUpdate(ctx context.Context, namespace string, config *Config, fqdn string) error {
+	if !s.opts.PreflightEnabled || config.Provider != solverconfig.ProviderRFC2136 {
+		return nil
+	}
+	provider, err := s.newZoneProvider(ctx, namespace, config, nil)
 	if err != nil {
-		return fmt.Errorf("failed to create kubernetes client: %w", err)
+		return err
 	}
-	s.client = cl
-	return nil
+	manager, ok := provider.(*MultiServerDNS)
+	if !ok {
+		return nil
+	}
+	return s.pool.Do(ctx, config.Zone, func(ctx context.Context) error {
+		return manager.CheckUpdatePermission(ctx, fqdn)
+	})
 }
 
-// Config represents the webhook configuration
-type Config struct {
-	Servers        []string `json:"servers"`
-	Zone           string   `json:"zone"`
-	TSIGKeyName    string   `json:"tsigKeyName"`
-	TSIGAlgorithm  string   `json:"tsigAlgorithm"`
-	TSIGSecretName string   `json:"tsigSecretName"`
-	TSIGSecretKey  string   `json:"tsigSecretKey"`
-	TTL            int      `json:"ttl,omitempty"`
+// resolveZone returns the name the challenge record of fqdn is written at:
+// fqdn itself, or with FollowCNAME the end of its CNAME chain. It narrows a
+// config with zones to the entry enclosing that name, then sets the zone of a
+// config that leaves it empty to the closest zone enclosing the name, as
+// served by the first server that answers. Servers with a zone of their own
+// are not asked. Results are cached by s.zones.
+func (s *DNS01Solver) resolveZone(ctx context.Context, config *Config, fqdn string) (string, error) {
+	target, err := s.followCNAME(ctx, config, fqdn)
+	if err != nil {
+		return "", err
+	}
+	selected, err := config.ForName(target)
+	if err != nil {
+		return "", err
+	}
+	*config = *selected
+	if config.Zone != "" {
+		return target, nil
+	}
+	var errs []error
+	for _, server := range config.Servers {
+		if config.ServerEntries[server].Zone != "" {
+			continue
+		}
+		zone, err := s.zones.FindZone(ctx, server, target)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if zone != "." {
+			zone = strings.TrimSuffix(zone, ".")
+		}
+		config.Zone = zone
+		return target, nil
+	}
+	if len(errs) > 0 {
+		return "", fmt.Errorf("failed to discover the zone of %s: %w", target, errors.Join(errs...))
+	}
+	// Every server names its own zone; the first one keys the zone's locks and results
+	config.Zone = config.ServerSettings(config.Servers[0]).Zone
+	return target, nil
 }
 
-// parseConfig parses the webhook configuration
-func (s *DNS01Solver) parseConfig(cfgJSON *acmev1.ACMESolverConfig) (*Config, error) {
-	config := &Config{
-		TTL:           60, // Default TTL
-		TSIGAlgorithm: "hmac-sha256",
-		TSIGSecretKey: "secret",
+// followCNAME returns the end of the CNAME chain of fqdn when config follows
+// CNAMEs, and fqdn otherwise
+func (s *DNS01Solver) followCNAME(ctx context.Context, config *Config, fqdn string) (string, error) {
+	if config.FollowCNAME == nil {
+		return fqdn, nil
+	}
+	target, err := dns.FollowCNAME(ctx, config.CNAMEResolvers(), fqdn, config.FollowCNAME.Hops(), verifyQueryTimeout)
+	if err != nil {
+		return "", err
+	}
+	if strings.EqualFold(strings.TrimSuffix(target, "."), strings.TrimSuffix(fqdn, ".")) {
+		return fqdn, nil
 	}
+	s.logger.Info("Challenge name delegated by CNAME",
+		zap.String("fqdn", fqdn),
+		zap.String("target", target),
+	)
+	return target, nil
+}
 
-	if cfgJSON == nil || len(cfgJSON.Raw) == 0 {
-		return nil, fmt.Errorf("config is empty")
+// propagationTiming derives verification timing from the zone's SOA on its
+// first server, falling back to dns.DefaultPropagationTiming
+func (s *DNS01Solver) propagationTiming(ctx context.Context, config *Config) dns.PropagationTiming {
+	if len(config.Servers) == 0 {
+		return dns.DefaultPropagationTiming
+	}
+	soa, err := s.zones.LookupSOA(ctx, config.Servers[0], config.ServerSettings(config.Servers[0]).Zone)
+	if err != nil {
+		s.logger.Debug("Using default propagation timing, zone SOA unavailable",
+			zap.String("zone", config.Zone),
+			zap.Error(err),
+		)
+		return dns.DefaultPropagationTiming
 	}
+	return dns.PropagationTimingFromSOA(soa)
+}
 
-	if err := json.Unmarshal(cfgJSON.Raw, config); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
+// verifyPresent waits until value is visible at fqdn on the configured
+// servers, and on the zone's nameservers when config asks for it, then on
+// the resolver sets of config. By default RFC2136 zones need the same
+// number of servers the update needed and API-backed zones need every
+// listed server.
+func (s *DNS01Solver) verifyPresent(ctx context.Context, namespace string, config *Config,
+	fqdn, value string) (dns.PropagationResult, error) {
+	settings := solverconfig.PropagationConfig{}
+	if config.Propagation != nil {
+		settings = *config.Propagation
+	}
+	if settings.Disabled || len(config.Servers)+len(config.Secondaries) == 0 {
+		return dns.PropagationResult{}, nil
 	}
 
-	// Validate required fields
-	if len(config.Servers) == 0 {
-		return nil, fmt.Errorf("servers list is required")
+	tlsConfigs, err := s.serverTLSConfigs(ctx, namespace, config)
+	if err != nil {
+		return dns.PropagationResult{}, err
 	}
-	if config.Zone == "" {
-		return nil, fmt.Errorf("zone is required")
+	timing := s.propagationTiming(ctx, config)
+	if settings.Timeout.Duration > 0 {
+		timing.Timeout = settings.Timeout.Duration
 	}
-	if config.TSIGKeyName == "" {
-		return nil, fmt.Errorf("tsigKeyName is required")
+	if settings.Interval.Duration > 0 {
+		timing.Interval = settings.Interval.Duration
 	}
-	if config.TSIGSecretName == "" {
-		return nil, fmt.Errorf("tsigSecretName is required")
+	minMatches := settings.MinMatches
+	if minMatches == 0 && config.Provider == solverconfig.ProviderRFC2136 {
+		minMatches = config.RequiredWrites()
 	}
 
-	return config, nil
+	ctx, cancel := context.WithTimeout(ctx, timing.Timeout)
+	defer cancel()
+	check := dns.PropagationCheck{
+		Servers:      config.Servers,
+		FQDN:         fqdn,
+		Value:        value,
+		Present:      true,
+		MinMatches:   minMatches,
+		Interval:     timing.Interval,
+		QueryTimeout: verifyQueryTimeout,
+		TLS:          tlsConfigs,
+		Proxies:      config.ServerProxies(),
+		DNSSEC:       settings.DNSSEC,
+	}
+	var result dns.PropagationResult
+	if settings.CheckPublicNS {
+		result, err = dns.WaitForZonePropagation(ctx, check, config.Zone)
+	} else {
+		result, err = dns.WaitForPropagation(ctx, check)
+	}
+	if err == nil && len(config.Secondaries) > 0 {
+		result, err = waitForSecondaries(ctx, config, check, result)
+	}
+	observeConvergence(authoritativeSet, result)
+	if err != nil {
+		return result, err
+	}
+	return s.waitForResolvers(ctx, check, settings.Resolvers, result)
 }
 
-// getTSIGSecret retrieves TSIG secret from Kubernetes Secret
-func (s *DNS01Solver) getTSIGSecret(namespace, secretName, key string) (string, error) {
+// waitForSecondaries waits until the secondaries of config serve the change
+// check confirmed on the servers of result. Secondaries were not updated, so
+// only their answers tell whether the change arrived: the changed record by
+// default, or with the serial secondary check the SOA serial of the first
+// server, in config order, that serves the change.
+func waitForSecondaries(ctx context.Context, config *Config, check dns.PropagationCheck,
+	result dns.PropagationResult) (dns.PropagationResult, error) {
+	if !config.Propagation.WatchesSerial() {
+		return dns.WaitForAllServers(ctx, check, config.Secondaries, result)
+	}
+	var primary string
+	for _, server := range config.Servers {
+		if slices.Contains(result.Matched, server) {
+			primary = server
+			break
+		}
+	}
+	return dns.WaitForSerial(ctx, dns.SerialCheck{
+		Primary:      primary,
+		Servers:      config.Secondaries,
+		Zone:         config.Zone,
+		Interval:     check.Interval,
+		QueryTimeout: check.QueryTimeout,
+		TLS:          check.TLS,
+		Proxies:      check.Proxies,
+	}, result)
+}
+
+// newDNSManager builds the DNS provider for config, bridged to the zone's
+// other views when the config has a bridge. onServer, when set, is called
+// with every RFC2136 server that applied an update and onLagging with every
+// one that missed an add which still met the write quorum. RFC2136 managers
+// are shared across challenges through the manager cache.
+func (s *DNS01Solver) newDNSManager(ctx context.Context, namespace string, config *Config,
+	onServer, onLagging func(server string)) (dns.Provider, error) {
+	provider, err := s.sharedZoneProvider(ctx, namespace, config, onServer, onLagging)
+	if err != nil {
+		return nil, err
+	}
+	if config.Bridge == nil {
+		return provider, nil
+	}
+
+	var route53 dns.Provider
+	route53, err = s.newRoute53Client(ctx, namespace, config)
+	if err != nil {
+		return nil, err
+	}
+	if s.dryRun(config) {
+		route53 = dns.NewDryRunProvider("route53", config.Zone, s.logger)
+	}
+	return dns.NewBridgedProvider(
+		dns.BridgeTarget{Name: config.Provider, Provider: provider},
+		dns.BridgeTarget{Name: "route53", Provider: route53},
+	), nil
+}
+
+// dryRun reports whether the changes of config are logged instead of sent
+func (s *DNS01Solver) dryRun(config *Config) bool {
+	if s.opts.DryRun != nil {
+		return *s.opts.DryRun
+	}
+	return config.DryRun
+}
+
+// operationTimeout returns the deadline of a Present or cleanup of config:
+// its operationTimeout, or Options.OperationTimeout when unset
+func (s *DNS01Solver) operationTimeout(config *Config) time.Duration {
+	if config.OperationTimeout.Duration > 0 {
+		return config.OperationTimeout.Duration
+	}
+	return s.opts.OperationTimeout
+}
+
+// newRoute53Client builds the Route53 client of the bridge of config with
+// credentials from its Secret
+func (s *DNS01Solver) newRoute53Client(ctx context.Context, namespace string, config *Config) (*dns.Route53Client, error) {
+	route53 := config.Bridge.Route53
+	data, err := s.getSecretData(ctx, namespace, config, route53.CredentialsSecretName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Route53 credentials: %w", err)
+	}
+	credentials := dns.AWSCredentials{
+		AccessKeyID:     string(data["access-key-id"]),
+		SecretAccessKey: string(data["secret-access-key"]),
+		SessionToken:    string(data["session-token"]),
+	}
+	if credentials.AccessKeyID == "" || credentials.SecretAccessKey == "" {
+		return nil, fmt.Errorf("secret %s/%s must hold access-key-id and secret-access-key",
+			config.SecretsNamespace(namespace), route53.CredentialsSecretName)
+	}
+	return dns.NewRoute53Client(route53.Endpoint, route53.HostedZoneID, credentials, s.logger), nil
+}
+
+// newZoneProvider resolves the zone's credentials and builds its primary provider:
+// a multi-server RFC2136 manager, a PowerDNS API client or a CoreDNS etcd client.
+// In dry-run mode the credentials are still resolved but nothing is sent.
+func (s *DNS01Solver) newZoneProvider(ctx context.Context, namespace string, config *Config, onServer func(server string)) (dns.Provider, error) {
+	if config.Provider == solverconfig.ProviderCoreDNSEtcd {
+		provider, err := s.newCoreDNSEtcdClient(ctx, namespace, config)
+		if err != nil || !s.dryRun(config) {
+			return provider, err
+		}
+		return dns.NewDryRunProvider(config.Provider, config.Zone, s.logger), nil
+	}
+
+	if config.Provider == solverconfig.ProviderPowerDNS {
+		secretName, secretKey := config.SecretRef()
+		apiKey, err := s.getTSIGSecret(ctx, namespace, config, secretName, secretKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get PowerDNS API key: %w", err)
+		}
+		if s.dryRun(config) {
+			return dns.NewDryRunProvider(config.Provider, config.Zone, s.logger), nil
+		}
+		return dns.NewPowerDNSClient(config.PowerDNS.APIURL, config.PowerDNS.ServerID,
+			config.Zone, apiKey, s.logger), nil
+	}
+
+	// The top-level secret is only needed by servers without their own entry
+	var secret string
+	keyName, algorithm := config.TSIGKeyName, config.TSIGAlgorithm
+	if len(config.ServerEntries) < len(config.Servers) && !config.UsesSIG0() {
+		data, err := s.tsigSecretData(ctx, namespace, config, config.TSIGSecretName)
+		if err == nil {
+			secret, err = secretValue(data, config.SecretsNamespace(namespace), config.TSIGSecretName, config.TSIGSecretKey)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get TSIG secret: %w", err)
+		}
+		keyName, algorithm = solverconfig.TSIGKeyFromSecret(data, keyName, algorithm)
+	}
+	credentials, err := s.serverCredentials(ctx, namespace, config)
+	if err != nil {
+		return nil, err
+	}
+
+	manager := NewMultiServerDNS(
+		config.Servers,
+		config.RequiredWrites(),
+		config.Zone,
+		keyName,
+		algorithm,
+		secret,
+		s.logger,
+	)
+	if len(config.ServerModes) > 0 || config.TSIGFudge > 0 {
+		quirks := make(map[string]dns.Quirks, len(config.Servers))
+		for _, server := range config.Servers {
+			quirks[server] = config.ServerQuirks(server)
+		}
+		manager.SetServerQuirks(quirks)
+	}
+	manager.SetServerCredentials(credentials)
+	if !config.UsesSIG0() && len(config.TSIGFallbackKeys) > 0 {
+		fallbacks, err := s.fallbackTSIGKeys(ctx, namespace, config)
+		if err != nil {
+			return nil, err
+		}
+		manager.SetTSIGFallbacks(fallbacks, s.tsigKeys)
+	}
+	clients, err := s.serverClients(ctx, namespace, config)
+	if err != nil {
+		return nil, err
+	}
+	manager.SetServerClients(clients)
+	if config.UsesSIG0() {
+		signer, err := s.sig0Signer(ctx, namespace, config)
+		if err != nil {
+			return nil, err
+		}
+		manager.SetSIG0Signer(signer)
+	}
+	manager.SetRollback(config.RollbackOnFailure)
+	manager.SetServerTimeout(s.opts.ServerTimeout)
+	manager.SetCancelOnQuorum(s.opts.CancelOnQuorum)
+	manager.SetReturnOnQuorum(s.opts.ReturnOnQuorum)
+	manager.SetConnPool(s.conns)
+	manager.SetUpdateLimiter(s.limiter)
+	manager.SetTransport(config.DNSTransport())
+	manager.SetRetryPolicy(config.RetryPolicy())
+	manager.SetPrerequisites(config.UpdatePrerequisites())
+	if s.opts.RegistryOwnerID != "" {
+		manager.SetOwnership(dns.Ownership{Owner: s.opts.RegistryOwnerID, Resource: "challenge/" + namespace})
+	}
+	if s.dryRun(config) {
+		manager.SetDryRun(s.recorder)
+	}
+	tlsConfigs, err := s.serverTLSConfigs(ctx, namespace, config)
+	if err != nil {
+		return nil, err
+	}
+	manager.SetServerTLS(tlsConfigs)
+	manager.SetServerProxies(config.ServerProxies())
+	manager.SetServerTimeouts(config.ServerClientTimeouts())
+	if quorums := config.GroupQuorums(); len(quorums) > 0 {
+		groups := make([]ServerGroup, 0, len(quorums))
+		for _, quorum := range quorums {
+			groups = append(groups, ServerGroup{Name: quorum.Name, Servers: quorum.Servers, MinSuccess: quorum.Required})
+		}
+		manager.SetServerGroups(groups)
+	}
+	if onServer != nil {
+		manager.SetServerCallback(onServer)
+	}
+	return manager, nil
+}
+
+// serverCredentials resolves the zone and TSIG key of every server with its
+// own entry in config, reading each referenced Secret once
+func (s *DNS01Solver) serverCredentials(ctx context.Context, namespace string, config *Config) (map[string]ServerCredentials, error) {
+	if len(config.ServerEntries) == 0 {
+		return nil, nil
+	}
+	secrets := map[string]map[string][]byte{}
+	credentials := make(map[string]ServerCredentials, len(config.ServerEntries))
+	for server := range config.ServerEntries {
+		if config.ServerProvider(server) != solverconfig.ProviderRFC2136 {
+			continue
+		}
+		settings := config.ServerSettings(server)
+		if config.UsesSIG0() {
+			credentials[server] = ServerCredentials{Zone: settings.Zone}
+			continue
+		}
+		data, ok := secrets[settings.TSIGSecretName]
+		if !ok {
+			var err error
+			if data, err = s.tsigSecretData(ctx, namespace, config, settings.TSIGSecretName); err != nil {
+				return nil, fmt.Errorf("failed to get TSIG secret of server %s: %w", server, err)
+			}
+			secrets[settings.TSIGSecretName] = data
+		}
+		secret, err := secretValue(data, config.SecretsNamespace(namespace), settings.TSIGSecretName, settings.TSIGSecretKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get TSIG secret of server %s: %w", server, err)
+		}
+		keyName, algorithm := solverconfig.TSIGKeyFromSecret(data, settings.TSIGKeyName, settings.TSIGAlgorithm)
+		credentials[server] = ServerCredentials{
+			Zone:          settings.Zone,
+			TSIGKey:       keyName,
+			TSIGAlgorithm: algorithm,
+			TSIGSecret:    secret,
+		}
+	}
+	return credentials, nil
+}
+
+// fallbackTSIGKeys reads the secrets of the fallback TSIG keys of config.
+// Like the top-level key, a key name and algorithm the Secret holds win.
+func (s *DNS01Solver) fallbackTSIGKeys(ctx context.Context, namespace string, config *Config) ([]dns.TSIGCredential, error) {
+	keys := config.FallbackTSIGKeys()
+	credentials := make([]dns.TSIGCredential, 0, len(keys))
+	for _, key := range keys {
+		data, err := s.tsigSecretData(ctx, namespace, config, key.SecretName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get secret of fallback TSIG key %s: %w", key.KeyName, err)
+		}
+		secret, err := secretValue(data, config.SecretsNamespace(namespace), key.SecretName, key.SecretKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get secret of fallback TSIG key %s: %w", key.KeyName, err)
+		}
+		keyName, algorithm := solverconfig.TSIGKeyFromSecret(data, key.KeyName, key.Algorithm)
+		credentials = append(credentials, dns.TSIGCredential{KeyName: keyName, Algorithm: algorithm, Secret: secret})
+	}
+	return credentials, nil
+}
+
+// serverClients builds the API clients of the servers of config that are not
+// updated through RFC2136, with their API keys read from their Secrets
+func (s *DNS01Solver) serverClients(ctx context.Context, namespace string, config *Config) (map[string]serverClient, error) {
+	var clients map[string]serverClient
+	for server, entry := range config.ServerEntries {
+		if entry.Provider != solverconfig.ProviderPowerDNS {
+			continue
+		}
+		if clients == nil {
+			clients = map[string]serverClient{}
+		}
+		api := entry.PowerDNS
+		apiKey, err := s.getTSIGSecret(ctx, namespace, config, api.APIKeySecretName, api.APIKeySecretKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get PowerDNS API key of server %s: %w", server, err)
+		}
+		zone := config.ServerSettings(server).Zone
+		if s.dryRun(config) {
+			clients[server] = dns.NewDryRunProvider(entry.Provider, zone, s.logger)
+			continue
+		}
+		clients[server] = dns.NewPowerDNSClient(api.APIURL, api.ServerID, zone, apiKey, s.logger)
+	}
+	return clients, nil
+}
+
+// sig0Signer loads the SIG(0) key pair of config from its Secret
+func (s *DNS01Solver) sig0Signer(ctx context.Context, namespace string, config *Config) (*dns.SIG0Signer, error) {
+	if s.secrets == nil {
+		return nil, fmt.Errorf("kubernetes client not initialized")
+	}
+	namespace, err := s.secretNamespace(ctx, namespace, config, config.SIG0.SecretName, s.secrets)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get SIG(0) key: %w", err)
+	}
+	publicKey, privateKey := config.SIG0.PublicKeySecretKey, config.SIG0.PrivateKeySecretKey
+	signer, err := decodeSecret(ctx, s.secrets, namespace, config.SIG0.SecretName,
+		"sig0/"+publicKey+"/"+privateKey, func(data map[string][]byte) (*dns.SIG0Signer, error) {
+			public, private := data[publicKey], data[privateKey]
+			if len(public) == 0 || len(private) == 0 {
+				return nil, fmt.Errorf("secret %s/%s must hold %s and %s", namespace, config.SIG0.SecretName,
+					publicKey, privateKey)
+			}
+			return dns.NewSIG0Signer(string(public), string(private))
+		})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get SIG(0) key: %w", err)
+	}
+	return signer, nil
+}
+
+// serverTLSConfigs returns the DNS-over-TLS settings of every server of
+// config, with CA bundles and client certificates loaded from their Secrets.
+// It returns nil unless config uses the tls transport.
+func (s *DNS01Solver) serverTLSConfigs(ctx context.Context, namespace string, config *Config) (map[string]*tls.Config, error) {
+	if config.DNSTransport() != dns.TransportTLS {
+		return nil, nil
+	}
+	configs := make(map[string]*tls.Config, len(config.Servers))
+	for _, server := range config.Servers {
+		settings := config.ServerTLSConfig(server)
+		if settings.SecretName == "" {
+			tlsConfig, err := dns.TLSClientConfig(nil, settings.ServerName)
+			if err != nil {
+				return nil, fmt.Errorf("server %s: %w", server, err)
+			}
+			configs[server] = tlsConfig
+			continue
+		}
+		if s.secrets == nil {
+			return nil, fmt.Errorf("kubernetes client not initialized")
+		}
+		secretNamespace, err := s.secretNamespace(ctx, namespace, config, settings.SecretName, s.secrets)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get TLS secret of server %s: %w", server, err)
+		}
+		// Parsed certificates are kept until the Secret changes
+		tlsConfig, err := decodeSecret(ctx, s.secrets, secretNamespace, settings.SecretName,
+			"tls/"+settings.ServerName, func(data map[string][]byte) (*tls.Config, error) {
+				return dns.TLSClientConfig(data, settings.ServerName)
+			})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get TLS secret of server %s: %w", server, err)
+		}
+		configs[server] = tlsConfig
+	}
+	return configs, nil
+}
+
+// newCoreDNSEtcdClient builds a CoreDNS etcd client using the optional credentials Secret
+func (s *DNS01Solver) newCoreDNSEtcdClient(ctx context.Context, namespace string, config *Config) (dns.Provider, error) {
+	var credentials map[string][]byte
+	if config.Etcd.CredentialsSecretName != "" {
+		data, err := s.getSecretData(ctx, namespace, config, config.Etcd.CredentialsSecretName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get etcd credentials: %w", err)
+		}
+		credentials = data
+	}
+
+	clientConfig, err := dns.EtcdClientConfig(config.Etcd.Endpoints, credentials)
+	if err != nil {
+		return nil, err
+	}
+	return dns.NewCoreDNSEtcdClient(clientConfig, config.Etcd.Prefix, config.Zone, s.logger), nil
+}
+
+// Initialize initializes the solver with Kubernetes client
+func (s *DNS01Solver) Initialize(kubeClientConfig *rest.Config, stopCh <-chan struct{}) error {
+	cl, err := kubernetes.NewForConfig(kubeClientConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create kubernetes client: %w", err)
+	}
+	s.client = cl
+	// Background work stops only once the operations in flight drained
+	stopCh = s.stopAfterDrain(stopCh)
+	if s.policy.err != nil {
+		return fmt.Errorf("invalid domain policy: %w", s.policy.err)
+	}
+	if s.opts.DNSFaults != "" {
+		if err := dns.EnableFaultsFromSpec(s.opts.DNSFaults); err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvDNSFaults, err)
+		}
+		s.logger.Warn("DNS fault injection enabled, do not use in production",
+			zap.String("faults", s.opts.DNSFaults),
+		)
+	}
+	go func() {
+		_ = s.pool.Start(wait.ContextForChannel(stopCh))
+	}()
+	go func() {
+		<-stopCh
+		s.conns.Close()
+	}()
+	s.secrets = newSecretCache(cl, s.opts, s.logger)
+	s.secrets.Start(stopCh)
+	if s.opts.EventsEnabled {
+		cm, err := cmversioned.NewForConfig(kubeClientConfig)
+		if err != nil {
+			return fmt.Errorf("failed to create cert-manager client: %w", err)
+		}
+		s.events, err = newChallengeEvents(cl, cm, "dns01-webhook", s.opts.EventsObject, stopCh, s.logger)
+		if err != nil {
+			return err
+		}
+	}
+	if s.opts.DomainPolicyConfigMap != "" {
+		s.policy.watch(cl, s.opts.stateNamespace(), s.opts.DomainPolicyConfigMap, stopCh, s.logger)
+	}
+	if s.opts.DefaultsConfigMap != "" {
+		s.defaults.watch(cl, s.opts.stateNamespace(), s.opts.DefaultsConfigMap, stopCh, s.logger)
+	}
+	s.cleanups = newCleanupQueue(s.cleanupRecord, s.opts, s.logger)
+	s.cleanups.Start(stopCh)
+	s.repairs = newRepairQueue(s.repairServer, s.opts, s.logger)
+	if s.opts.StateEnabled && s.opts.RepairJournalConfigMap != "" {
+		s.repairs.journal = newRepairJournal(cl, s.opts.stateNamespace(), s.opts.RepairJournalConfigMap, s.logger)
+	}
+	s.repairs.Start(stopCh)
+	go s.repairs.Resume(wait.ContextForChannel(stopCh))
+	if s.opts.StateEnabled {
+		s.state = newChallengeStore(cl, s.opts.stateNamespace(), s.opts.StateConfigMap, s.logger)
+		go s.resumeChallenges(wait.ContextForChannel(stopCh))
+	}
+	if s.opts.LeasesEnabled {
+		s.leases = newZoneLeases(cl, s.opts.stateNamespace(), s.opts.LeaseIdentity, s.opts.LeaseDuration,
+			s.opts.LeaseWaitTimeout, s.logger)
+	}
+	if s.opts.ResultsConfigMap != "" {
+		s.results = newResultPublisher(cl, s.opts.stateNamespace(), s.opts.ResultsConfigMap, s.opts.ResultsMaxEntries)
+	}
+	if s.opts.WarmupEnabled {
+		go s.warmup(wait.ContextForChannel(stopCh), kubeClientConfig, stopCh)
+	}
+	if s.opts.GCEnabled {
+		go newStaleSweeper(s, s.opts.GCMaxAge).run(kubeClientConfig, s.opts.GCInterval, stopCh)
+	}
+	return nil
+}
+
+// Config represents the webhook configuration
+type Config = solverconfig.Config
+
+// parseConfig parses the webhook configuration merged over the solver
+// defaults and adds the endpoints of the Bind9Cluster it references, if any.
+// With defaults an Issuer may leave its config out entirely.
+func (s *DNS01Solver) parseConfig(cfgJSON *apiextensionsv1.JSON) (*Config, error) {
+	var raw []byte
+	if cfgJSON != nil {
+		raw = cfgJSON.Raw
+	}
+	raw, err := s.defaults.apply(raw)
+	if err != nil {
+		return nil, err
+	}
+	config, err := solverconfig.Parse(raw)
+	if err != nil || config.Bind9Cluster == nil {
+		return config, err
+	}
 	if s.client == nil {
-		return "", fmt.Errorf("kubernetes client not initialized")
+		return nil, fmt.Errorf("kubernetes client not initialized")
 	}
+	ref := config.Bind9Cluster
+	cm, err := s.client.CoreV1().ConfigMaps(ref.Namespace).Get(context.Background(), ref.EndpointsConfigMapName(), metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get endpoints of bind9Cluster %s/%s: %w", ref.Namespace, ref.Name, err)
+	}
+	if err := config.AddClusterEndpoints(cm.Data); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
 
-	secret, err := s.client.CoreV1().Secrets(namespace).Get(context.Background(), secretName, metav1.GetOptions{})
+// getTSIGSecret retrieves TSIG secret from Kubernetes Secret
+func (s *DNS01Solver) getTSIGSecret(ctx context.Context, namespace string, config *Config, secretName, key string) (string, error) {
+	data, err := s.getSecretData(ctx, namespace, config, secretName)
 	if err != nil {
-		return "", fmt.Errorf("failed to get secret %s/%s: %w", namespace, secretName, err)
+		return "", err
 	}
+	return secretValue(data, config.SecretsNamespace(namespace), secretName, key)
+}
 
-	secretData, ok := secret.Data[key]
+// secretValue returns the value of key in the data of a Secret
+func secretValue(data map[string][]byte, namespace, secretName, key string) (string, error) {
+	secretData, ok := data[key]
 	if !ok {
-		return "", fmt.Errorf("key %s not found in secret %s/%s", key, namespace, secretName)
+		return "", &missingSecretKeyError{namespace: namespace, name: secretName, key: key}
 	}
-
 	return string(secretData), nil
 }
 
-// StartWebhookServer starts the webhook server
-func StartWebhookServer(logger *zap.Logger) {
-	groupName := "acme.example.com"
-	solver := NewDNS01Solver(logger)
+// missingSecretKeyError is returned when a Secret lacks the key a config names
+type missingSecretKeyError struct {
+	namespace, name, key string
+}
 
-	cmd.RunWebhookServer(groupName,
-		func(kubeClientConfig *rest.Config, stopCh <-chan struct{}) (v1alpha1.Solver, error) {
-			if err := solver.Initialize(kubeClientConfig, stopCh); err != nil {
-				return nil, err
-			}
-			return solver, nil
-		},
+// Error implements error
+func (e *missingSecretKeyError) Error() string {
+	return fmt.Sprintf("key %s not found in secret %s/%s", e.key, e.namespace, e.name)
+}
+
+// getSecretData returns the data of the Secret secretName of config for a
+// challenge in namespace from the cache
+func (s *DNS01Solver) getSecretData(ctx context.Context, namespace string, config *Config, secretName string) (map[string][]byte, error) {
+	if s.secrets == nil {
+		return nil, fmt.Errorf("kubernetes client not initialized")
+	}
+	namespace, err := s.secretNamespace(ctx, namespace, config, secretName, s.secrets)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := s.secrets.Get(ctx, namespace, secretName)
+	if err != nil {
+		return nil, err
+	}
+	return secret.Data, nil
+}
+
+// StartWebhookServer starts the webhook server with opts, as returned by
+// LoadOptions. On SIGTERM or SIGINT the solver drains: new challenges are
+// turned away and readiness fails while the DNS operations in flight
+// finish, bounded by DRAIN_TIMEOUT.
+func StartWebhookServer(opts Options, logger *zap.Logger) {
+	logger.Info("Registering webhook solver",
+		zap.String("group", opts.GroupName),
+		zap.String("solver", opts.SolverName),
 	)
+	flushTracing := func(context.Context) error { return nil }
+	if opts.TracingEnabled {
+		shutdown, err := setupTracing(context.Background())
+		if err != nil {
+			logger.Error("Tracing disabled", zap.Error(err))
+		} else {
+			flushTracing = shutdown
+		}
+	}
+	solver := NewDNS01Solver(opts, logger)
+	if opts.HealthAddr != "" {
+		go func() {
+			if err := serveHealth(opts, solver.Draining, logger); err != nil {
+				logger.Fatal("Health endpoints failed", zap.Error(err))
+			}
+		}()
+	}
+	if opts.AdminAddr != "" {
+		go func() {
+			if err := serveAdmin(opts, solver, logger); err != nil {
+				logger.Fatal("Admin API failed", zap.Error(err))
+			}
+		}()
+	}
+
+	// The API server handles the same signals and stops once its requests
+	// are done; draining starts right away so the Presents behind those
+	// requests and the background queues finish first
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, os.Interrupt)
+	drained := make(chan struct{})
+	go func() {
+		defer close(drained)
+		sig := <-signals
+		logger.Info("Shutdown signal received", zap.String("signal", sig.String()))
+		solver.drain()
+		_ = flushTracing(context.Background())
+	}()
+
+	instrument := func(next http.Handler) http.Handler {
+		return instrumentAdmission(next, opts.GroupName, opts.SlowRequestThreshold, logger)
+	}
+	if err := runWebhookServer(opts.GroupName, solver, instrument); err != nil {
+		_ = flushTracing(context.Background())
+		logger.Fatal("Webhook server failed", zap.Error(err))
+	}
+	<-drained
 }
 
 // HealthCheckHandler provides health check endpoint
@@ -240,4 +1172,3 @@ func HealthCheckHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("OK"))
 }
-
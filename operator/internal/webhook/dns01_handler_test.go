@@ -0,0 +1,1292 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cert-manager/cert-manager/pkg/acme/webhook/apis/acme/v1alpha1"
+	"github.com/miekg/dns"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/rieset/istio-dns01-bind9/internal/dnstest"
+	"github.com/rieset/istio-dns01-bind9/internal/metrics"
+	"github.com/rieset/istio-dns01-bind9/internal/solverconfig"
+)
+
+// newTestSolver returns a solver backed by a fake clientset holding the TSIG and PowerDNS secrets
+func newTestSolver(t testing.TB) *DNS01Solver {
+	t.Helper()
+	client := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "tsig", Namespace: "cert-manager"},
+		Data:       map[string][]byte{"secret": []byte(dnstest.TestSecret)},
+	}, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "pdns", Namespace: "cert-manager"},
+		Data:       map[string][]byte{"api-key": []byte("pdns-api-key")},
+	}, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "aws", Namespace: "cert-manager"},
+		Data:       map[string][]byte{"access-key-id": []byte("AKID"), "secret-access-key": []byte("secret")},
+	})
+
+	opts := DefaultOptions()
+	s := NewDNS01Solver(opts, zap.NewNop())
+	s.client = client
+	s.secrets = newSecretCache(client, opts, zap.NewNop())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go func() { _ = s.pool.Start(ctx) }()
+	return s
+}
+
+// serverAddrs returns the listen addresses of servers
+func serverAddrs(servers []*dnstest.Server) []string {
+	return dnstest.Addrs(servers)
+}
+
+// newChallenge builds a challenge request for fqdn in example.com against addrs
+func newChallenge(t *testing.T, addrs []string, fqdn, key string) *v1alpha1.ChallengeRequest {
+	t.Helper()
+	raw, err := json.Marshal(Config{
+		Servers:        addrs,
+		Zone:           "example.com",
+		TSIGKeyName:    dnstest.TestKeyName,
+		TSIGAlgorithm:  "hmac-sha256",
+		TSIGSecretName: "tsig",
+		TSIGSecretKey:  "secret",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &v1alpha1.ChallengeRequest{
+		ResolvedFQDN:      fqdn,
+		Key:               key,
+		ResourceNamespace: "cert-manager",
+		Config:            &apiextensionsv1.JSON{Raw: raw},
+	}
+}
+
+// cleanUp releases the challenge of item as CleanUp does and runs its
+// deletion as the cleanup queue would
+func cleanUp(ctx context.Context, s *DNS01Solver, item cleanupItem) error {
+	s.active.release(item.FQDN, item.Value, "")
+	return s.cleanupRecord(ctx, item)
+}
+
+func TestSolverPresentAndCleanUp(t *testing.T) {
+	servers := startServers(t, 3)
+	s := newTestSolver(t)
+	ch := newChallenge(t, serverAddrs(servers), testFQDN, "token")
+
+	if err := s.Present(ch); err != nil {
+		t.Fatalf("Present: %v", err)
+	}
+	for _, srv := range servers {
+		if got := srv.TXT(testFQDN); len(got) != 1 || got[0] != "token" {
+			t.Fatalf("server %s has TXT %v after Present", srv.Addr(), got)
+		}
+	}
+
+	item := cleanupItem{Namespace: ch.ResourceNamespace, FQDN: ch.ResolvedFQDN, Value: ch.Key, Config: string(ch.Config.Raw)}
+	if err := cleanUp(context.Background(), s, item); err != nil {
+		t.Fatalf("cleanUp: %v", err)
+	}
+	for _, srv := range servers {
+		if got := srv.TXT(testFQDN); len(got) != 0 {
+			t.Fatalf("server %s has TXT %v after cleanup", srv.Addr(), got)
+		}
+	}
+}
+
+func TestSolverPresentOperationTimeout(t *testing.T) {
+	servers := startServers(t, 2)
+	for _, srv := range servers {
+		srv.SetLatency(time.Second)
+	}
+	s := newTestSolver(t)
+	s.opts.OperationTimeout = 300 * time.Millisecond
+
+	start := time.Now()
+	if err := s.Present(newChallenge(t, serverAddrs(servers), testFQDN, "token")); err == nil {
+		t.Fatal("Present succeeded against hung servers")
+	}
+	if elapsed := time.Since(start); elapsed > 800*time.Millisecond {
+		t.Fatalf("Present returned after %v, want the operation timeout", elapsed)
+	}
+}
+
+func TestSolverPresentBatchesZone(t *testing.T) {
+	servers := startServers(t, 2)
+	s := newTestSolver(t)
+	s.opts.PreflightEnabled = false
+	s.batcher = newAddBatcher(200*time.Millisecond, s.opts.OperationTimeout, s.sendAddBatch)
+
+	// The challenges of a SAN certificate arrive together
+	fqdns := []string{testFQDN, "_acme-challenge.www.example.com."}
+	var wg sync.WaitGroup
+	errs := make([]error, len(fqdns))
+	for i, fqdn := range fqdns {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = s.Present(newChallenge(t, serverAddrs(servers), fqdn, "token"))
+		}()
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("Present(%s): %v", fqdns[i], err)
+		}
+	}
+
+	for _, srv := range servers {
+		if got := srv.Updates(); got != 1 {
+			t.Fatalf("server %s received %d updates, want 1", srv.Addr(), got)
+		}
+		for _, fqdn := range fqdns {
+			if got := srv.TXT(fqdn); !reflect.DeepEqual(got, []string{"token"}) {
+				t.Fatalf("server %s has TXT %v at %s, want [token]", srv.Addr(), got, fqdn)
+			}
+		}
+	}
+}
+
+func TestSolverCleanUpKeepsOtherValues(t *testing.T) {
+	servers := startServers(t, 2)
+	s := newTestSolver(t)
+	for _, srv := range servers {
+		srv.SetTXT(testFQDN, 60, "unrelated")
+	}
+	// Two orders for the same name, such as example.com and *.example.com
+	first := newChallenge(t, serverAddrs(servers), testFQDN, "token-1")
+	second := newChallenge(t, serverAddrs(servers), testFQDN, "token-2")
+	for _, ch := range []*v1alpha1.ChallengeRequest{first, second} {
+		if err := s.Present(ch); err != nil {
+			t.Fatalf("Present(%s): %v", ch.Key, err)
+		}
+	}
+
+	item := cleanupItem{Namespace: first.ResourceNamespace, FQDN: testFQDN, Value: first.Key, Config: string(first.Config.Raw)}
+	if err := cleanUp(context.Background(), s, item); err != nil {
+		t.Fatalf("cleanUp: %v", err)
+	}
+	for _, srv := range servers {
+		if got, want := srv.TXT(testFQDN), []string{"token-2", "unrelated"}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("server %s has TXT %v after cleanup, want %v", srv.Addr(), got, want)
+		}
+	}
+}
+
+func TestSolverSharedChallengeValue(t *testing.T) {
+	servers := startServers(t, 2)
+	s := newTestSolver(t)
+	// Two orders reusing one pending authorization present the same value
+	// through Challenges of their own
+	orders := []*v1alpha1.ChallengeRequest{
+		newChallenge(t, serverAddrs(servers), testFQDN, "token"),
+		newChallenge(t, serverAddrs(servers), testFQDN, "token"),
+	}
+	orders[0].UID, orders[1].UID = "order-1", "order-2"
+	var wg sync.WaitGroup
+	errs := make([]error, len(orders))
+	for i, ch := range orders {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = s.Present(ch)
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			t.Fatalf("Present: %v", err)
+		}
+	}
+
+	for i, want := range [][]string{{"token"}, nil} {
+		ch := orders[i]
+		s.active.release(testFQDN, ch.Key, challengeID(ch))
+		item := cleanupItem{Namespace: ch.ResourceNamespace, FQDN: testFQDN, Value: ch.Key, Config: string(ch.Config.Raw)}
+		if err := s.cleanupRecord(context.Background(), item); err != nil {
+			t.Fatalf("cleanupRecord %d: %v", i+1, err)
+		}
+		for _, srv := range servers {
+			if got := srv.TXT(testFQDN); !slices.Equal(got, want) {
+				t.Fatalf("server %s has TXT %v after cleanup %d, want %v", srv.Addr(), got, i+1, want)
+			}
+		}
+	}
+}
+
+func TestSolverRetriedPresentCountsOnce(t *testing.T) {
+	servers := startServers(t, 1)
+	s := newTestSolver(t)
+	ch := newChallenge(t, serverAddrs(servers), testFQDN, "token")
+	ch.UID = "order-1"
+	// cert-manager calls Present again until one call succeeds
+	for range 2 {
+		if err := s.Present(ch); err != nil {
+			t.Fatalf("Present: %v", err)
+		}
+	}
+
+	item := cleanupItem{Namespace: ch.ResourceNamespace, FQDN: testFQDN, Value: ch.Key, Config: string(ch.Config.Raw)}
+	if remaining := s.active.release(testFQDN, ch.Key, challengeID(ch)); remaining != 0 {
+		t.Fatalf("release = %d challenges still needing the value, want 0", remaining)
+	}
+	if err := s.cleanupRecord(context.Background(), item); err != nil {
+		t.Fatalf("cleanupRecord: %v", err)
+	}
+	if got := servers[0].TXT(testFQDN); len(got) != 0 {
+		t.Fatalf("TXT after cleanup = %v, want none", got)
+	}
+}
+
+func TestSolverCleanUpSkipsPresentedAgain(t *testing.T) {
+	servers := startServers(t, 1)
+	s := newTestSolver(t)
+	ch := newChallenge(t, serverAddrs(servers), testFQDN, "token")
+	if err := s.Present(ch); err != nil {
+		t.Fatalf("Present: %v", err)
+	}
+	// The value is presented again while its cleanup waits in the queue
+	s.active.release(testFQDN, ch.Key, challengeID(ch))
+	if err := s.Present(ch); err != nil {
+		t.Fatalf("Present: %v", err)
+	}
+
+	item := cleanupItem{Namespace: ch.ResourceNamespace, FQDN: testFQDN, Value: ch.Key, Config: string(ch.Config.Raw)}
+	if err := s.cleanupRecord(context.Background(), item); err != nil {
+		t.Fatalf("cleanupRecord: %v", err)
+	}
+	if got := servers[0].TXT(testFQDN); !reflect.DeepEqual(got, []string{"token"}) {
+		t.Fatalf("TXT after cleanup = %v, want [token]", got)
+	}
+}
+
+func TestSolverPresentMissingSecret(t *testing.T) {
+	servers := startServers(t, 1)
+	s := newTestSolver(t)
+	ch := newChallenge(t, serverAddrs(servers), testFQDN, "token")
+	ch.ResourceNamespace = "other"
+
+	if err := s.Present(ch); err == nil {
+		t.Fatal("Present succeeded without a TSIG secret")
+	}
+	if servers[0].Updates() != 0 {
+		t.Fatal("an update was sent without a TSIG secret")
+	}
+}
+
+func TestSolverDryRun(t *testing.T) {
+	servers := startServers(t, 2)
+	s := newTestSolver(t)
+	ch := newChallenge(t, serverAddrs(servers), testFQDN, "token")
+	var config Config
+	if err := json.Unmarshal(ch.Config.Raw, &config); err != nil {
+		t.Fatal(err)
+	}
+	config.DryRun = true
+	raw, err := json.Marshal(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ch.Config.Raw = raw
+
+	if err := s.Present(ch); err != nil {
+		t.Fatalf("Present: %v", err)
+	}
+	item := cleanupItem{Namespace: ch.ResourceNamespace, FQDN: testFQDN, Value: ch.Key, Config: string(raw)}
+	if err := cleanUp(context.Background(), s, item); err != nil {
+		t.Fatalf("cleanUp: %v", err)
+	}
+	for _, srv := range servers {
+		if got := srv.Updates(); got != 0 {
+			t.Fatalf("server %s received %d updates in dry-run mode", srv.Addr(), got)
+		}
+	}
+
+	// Credentials are still resolved
+	ch.ResourceNamespace = "other"
+	if err := s.Present(ch); err == nil {
+		t.Fatal("Present succeeded in dry-run mode without a TSIG secret")
+	}
+
+	// DRY_RUN=false wins over the config
+	off := false
+	s.opts.DryRun = &off
+	ch.ResourceNamespace = "cert-manager"
+	if err := s.Present(ch); err != nil {
+		t.Fatalf("Present: %v", err)
+	}
+	for _, srv := range servers {
+		if got := srv.TXT(testFQDN); !reflect.DeepEqual(got, []string{"token"}) {
+			t.Fatalf("server %s has TXT %v, want [token]", srv.Addr(), got)
+		}
+	}
+}
+
+func TestSolverPresentPreflightRefused(t *testing.T) {
+	servers := startServers(t, 3)
+	// The last server's update-policy does not cover the challenge name
+	servers[2].Grant(dnstest.TestKeyName, "_acme-challenge.other.example.com.")
+	s := newTestSolver(t)
+
+	err := s.Present(newChallenge(t, serverAddrs(servers), testFQDN, "token"))
+	if err == nil || !strings.Contains(err.Error(), "update-policy") {
+		t.Fatalf("Present error = %v, want an update-policy refusal", err)
+	}
+	for _, srv := range servers {
+		if got := srv.TXT(testFQDN); len(got) != 0 {
+			t.Fatalf("server %s has TXT %v although the preflight failed", srv.Addr(), got)
+		}
+	}
+
+	s.opts.PreflightEnabled = false
+	if err := s.Present(newChallenge(t, serverAddrs(servers), testFQDN, "token")); err != nil {
+		t.Fatalf("Present without preflight: %v", err)
+	}
+	if got := servers[0].TXT(testFQDN); len(got) != 1 {
+		t.Fatalf("quorum servers have TXT %v without preflight", got)
+	}
+}
+
+func TestSolverPresentWaitsForPropagation(t *testing.T) {
+	servers := startServers(t, 3)
+	s := newTestSolver(t)
+	ch := newChallenge(t, serverAddrs(servers), testFQDN, "token")
+	config, err := solverconfig.Parse(ch.Config.Raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.Propagation = &solverconfig.PropagationConfig{
+		Timeout:  solverconfig.Duration{Duration: 500 * time.Millisecond},
+		Interval: solverconfig.Duration{Duration: 50 * time.Millisecond},
+	}
+	if ch.Config.Raw, err = json.Marshal(config); err != nil {
+		t.Fatal(err)
+	}
+
+	// One lagging server does not block the majority
+	servers[2].SetQueryRcode(dns.RcodeServerFailure)
+	if err := s.Present(ch); err != nil {
+		t.Fatalf("Present with one lagging server: %v", err)
+	}
+
+	// Without a visible majority Present fails once the timeout expires
+	servers[1].SetQueryRcode(dns.RcodeServerFailure)
+	err = s.Present(ch)
+	if err == nil || !strings.Contains(err.Error(), "failed to verify TXT record propagation") {
+		t.Fatalf("Present error = %v, want a propagation timeout", err)
+	}
+
+	config.Propagation.Disabled = true
+	if ch.Config.Raw, err = json.Marshal(config); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Present(ch); err != nil {
+		t.Fatalf("Present with propagation checks disabled: %v", err)
+	}
+}
+
+func TestSolverConfigTimeouts(t *testing.T) {
+	servers := startServers(t, 3)
+	s := newTestSolver(t)
+	ch := newChallenge(t, serverAddrs(servers), testFQDN, "token")
+	config, err := solverconfig.Parse(ch.Config.Raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.operationTimeout(config); got != s.opts.OperationTimeout {
+		t.Fatalf("operationTimeout without a config value = %s, want DNS_OPERATION_TIMEOUT %s", got, s.opts.OperationTimeout)
+	}
+
+	// A slow server times out on its own read timeout, the others still form a majority
+	servers[2].SetLatency(time.Second)
+	config.Timeouts = &solverconfig.TimeoutConfig{Read: solverconfig.Duration{Duration: 2 * time.Second}}
+	config.ServerTimeouts = map[string]solverconfig.TimeoutConfig{
+		servers[2].Addr(): {Read: solverconfig.Duration{Duration: 100 * time.Millisecond}},
+	}
+	config.OperationTimeout = solverconfig.Duration{Duration: time.Minute}
+	config.Propagation = &solverconfig.PropagationConfig{Disabled: true}
+	if ch.Config.Raw, err = json.Marshal(config); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.operationTimeout(config); got != time.Minute {
+		t.Fatalf("operationTimeout = %s, want the config value 1m", got)
+	}
+	start := time.Now()
+	if err := s.Present(ch); err != nil {
+		t.Fatalf("Present with a slow server: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 900*time.Millisecond {
+		t.Fatalf("Present took %s, want the slow server cut off by its read timeout", elapsed)
+	}
+}
+
+func TestSolverTSIGFallbackKeys(t *testing.T) {
+	servers := startServers(t, 2)
+	// The third server already switched to the next key
+	rotated := dnstest.NewServer("example.com")
+	rotated.AddTSIGKey("next-key", dnstest.TestSecret)
+	if err := rotated.Start(); err != nil {
+		t.Fatalf("failed to start test server: %v", err)
+	}
+	t.Cleanup(func() { _ = rotated.Close() })
+	servers = append(servers, rotated)
+
+	s := newTestSolver(t)
+	ch := newChallenge(t, serverAddrs(servers), testFQDN, "token")
+	config, err := solverconfig.Parse(ch.Config.Raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.TSIGFallbackKeys = []solverconfig.TSIGKeyConfig{{KeyName: "next-key", SecretName: "tsig"}}
+	if ch.Config.Raw, err = json.Marshal(config); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Present(ch); err != nil {
+		t.Fatalf("Present: %v", err)
+	}
+	for i, srv := range servers {
+		if got := srv.TXT(testFQDN); len(got) != 1 {
+			t.Fatalf("server %d has TXT %v, want the challenge record", i, got)
+		}
+	}
+	if got := s.tsigKeys.Get(rotated.Addr()); got != "next-key." {
+		t.Fatalf("remembered key of the rotated server = %q, want next-key.", got)
+	}
+	if got := s.tsigKeys.Get(servers[0].Addr()); got != "" {
+		t.Fatalf("remembered key of a server accepting the primary key = %q, want none", got)
+	}
+	if err := cleanUp(context.Background(), s, cleanupItem{Namespace: ch.ResourceNamespace, FQDN: testFQDN,
+		Value: "token", Config: string(ch.Config.Raw)}); err != nil {
+		t.Fatalf("cleanUp: %v", err)
+	}
+	if got := rotated.TXT(testFQDN); len(got) != 0 {
+		t.Fatalf("rotated server still has TXT %v", got)
+	}
+}
+
+func TestSolverPresentWaitsForResolvers(t *testing.T) {
+	servers := startServers(t, 2)
+	resolver := startServers(t, 1)[0]
+	s := newTestSolver(t)
+	ch := newChallenge(t, serverAddrs(servers), testFQDN, "token")
+	config, err := solverconfig.Parse(ch.Config.Raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.Propagation = &solverconfig.PropagationConfig{
+		Timeout:  solverconfig.Duration{Duration: 500 * time.Millisecond},
+		Interval: solverconfig.Duration{Duration: 50 * time.Millisecond},
+		Resolvers: []solverconfig.ResolverSetConfig{
+			{Name: "public", Type: solverconfig.ResolverSetRecursive, Servers: []string{resolver.Addr()}},
+		},
+	}
+	if ch.Config.Raw, err = json.Marshal(config); err != nil {
+		t.Fatal(err)
+	}
+	pending := testutil.ToFloat64(metrics.PropagationChecks.WithLabelValues("public", resolver.Addr(), "pending"))
+
+	// The authoritative servers serve the record but the resolver does not
+	err = s.Present(ch)
+	if err == nil || !strings.Contains(err.Error(), "resolver set public") {
+		t.Fatalf("Present error = %v, want the resolver set pending", err)
+	}
+	if got := testutil.ToFloat64(metrics.PropagationChecks.WithLabelValues("public", resolver.Addr(), "pending")); got != pending+1 {
+		t.Fatalf("pending count of the resolver = %v, want %v", got, pending+1)
+	}
+
+	resolver.SetTXT(testFQDN, 60, ch.Key)
+	if err := s.Present(ch); err != nil {
+		t.Fatalf("Present once the resolver serves the record: %v", err)
+	}
+
+	// A system set without a readable resolv.conf fails the check
+	s.resolvConf = filepath.Join(t.TempDir(), "resolv.conf")
+	config.Propagation.Resolvers = []solverconfig.ResolverSetConfig{{Type: solverconfig.ResolverSetSystem}}
+	if ch.Config.Raw, err = json.Marshal(config); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Present(ch); err == nil || !strings.Contains(err.Error(), "resolver set system") {
+		t.Fatalf("Present error = %v, want the system resolvers unavailable", err)
+	}
+}
+
+func TestSolverPresentWaitsForSignatures(t *testing.T) {
+	servers := startServers(t, 1)
+	if _, err := servers[0].SignZone("example.com"); err != nil {
+		t.Fatal(err)
+	}
+	s := newTestSolver(t)
+	ch := newChallenge(t, serverAddrs(servers), testFQDN, "token")
+	config, err := solverconfig.Parse(ch.Config.Raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.Propagation = &solverconfig.PropagationConfig{
+		Timeout:  solverconfig.Duration{Duration: 300 * time.Millisecond},
+		Interval: solverconfig.Duration{Duration: 20 * time.Millisecond},
+		DNSSEC:   true,
+	}
+	if ch.Config.Raw, err = json.Marshal(config); err != nil {
+		t.Fatal(err)
+	}
+
+	servers[0].SetSigningPaused("example.com", true)
+	if err := s.Present(ch); err == nil {
+		t.Fatal("Present succeeded while the record was unsigned")
+	}
+	servers[0].SetSigningPaused("example.com", false)
+	if err := s.Present(ch); err != nil {
+		t.Fatalf("Present once signed: %v", err)
+	}
+}
+
+func TestSolverSecondaries(t *testing.T) {
+	primary := startServers(t, 1)[0]
+	secondaries := startServers(t, 2)
+	for _, srv := range secondaries {
+		srv.SetUpdateRcode(dns.RcodeRefused)
+	}
+	s := newTestSolver(t)
+	ch := newChallenge(t, []string{primary.Addr()}, testFQDN, "token")
+	config, err := solverconfig.Parse(ch.Config.Raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.Secondaries = serverAddrs(secondaries)
+	config.Propagation = &solverconfig.PropagationConfig{
+		Timeout:  solverconfig.Duration{Duration: 2 * time.Second},
+		Interval: solverconfig.Duration{Duration: 50 * time.Millisecond},
+	}
+	if ch.Config.Raw, err = json.Marshal(config); err != nil {
+		t.Fatal(err)
+	}
+
+	// The secondaries pick the record up a while after the primary took it
+	transferDelay := 300 * time.Millisecond
+	time.AfterFunc(transferDelay, func() {
+		for _, srv := range secondaries {
+			srv.SetTXT(testFQDN, 60, "token")
+		}
+	})
+	start := time.Now()
+	if err := s.Present(ch); err != nil {
+		t.Fatalf("Present: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < transferDelay {
+		t.Fatalf("Present returned after %v, before the secondaries served the record", elapsed)
+	}
+	for _, srv := range secondaries {
+		if got := srv.Updates(); got != 0 {
+			t.Fatalf("secondary %s received %d updates, want none", srv.Addr(), got)
+		}
+	}
+
+	// The deletion is only confirmed once the secondaries dropped the record too
+	item := cleanupItem{Namespace: ch.ResourceNamespace, FQDN: testFQDN, Value: ch.Key, Config: string(ch.Config.Raw)}
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	err = cleanUp(ctx, s, item)
+	if err == nil || !strings.Contains(err.Error(), "failed to verify TXT record deletion") {
+		t.Fatalf("cleanUp error = %v, want a verification timeout", err)
+	}
+	if got := primary.TXT(testFQDN); len(got) != 0 {
+		t.Fatalf("primary has TXT %v after cleanup", got)
+	}
+	for _, srv := range secondaries {
+		srv.SetTXT(testFQDN, 60)
+	}
+	if err := cleanUp(context.Background(), s, item); err != nil {
+		t.Fatalf("cleanUp: %v", err)
+	}
+}
+
+func TestSolverSecondarySerialCheck(t *testing.T) {
+	primary := startServers(t, 1)[0]
+	secondary := startServers(t, 1)[0]
+	secondary.SetUpdateRcode(dns.RcodeRefused)
+	s := newTestSolver(t)
+	ch := newChallenge(t, []string{primary.Addr()}, testFQDN, "token")
+	config, err := solverconfig.Parse(ch.Config.Raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.Secondaries = []string{secondary.Addr()}
+	config.Propagation = &solverconfig.PropagationConfig{
+		Timeout:        solverconfig.Duration{Duration: 2 * time.Second},
+		Interval:       solverconfig.Duration{Duration: 50 * time.Millisecond},
+		SecondaryCheck: solverconfig.SecondaryCheckSerial,
+	}
+	if ch.Config.Raw, err = json.Marshal(config); err != nil {
+		t.Fatal(err)
+	}
+
+	// The secondary never serves the record itself, only the serial of the
+	// primary once the transfer ran
+	transferDelay := 300 * time.Millisecond
+	time.AfterFunc(transferDelay, func() {
+		secondary.SetSerial("example.com", primary.Serial("example.com"))
+	})
+	start := time.Now()
+	if err := s.Present(ch); err != nil {
+		t.Fatalf("Present: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < transferDelay {
+		t.Fatalf("Present returned after %v, before the secondary reached the serial", elapsed)
+	}
+
+	item := cleanupItem{Namespace: ch.ResourceNamespace, FQDN: testFQDN, Value: ch.Key, Config: string(ch.Config.Raw)}
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	err = cleanUp(ctx, s, item)
+	if err == nil || !strings.Contains(err.Error(), "failed to verify TXT record deletion") {
+		t.Fatalf("cleanUp error = %v, want a verification timeout", err)
+	}
+	secondary.SetSerial("example.com", primary.Serial("example.com"))
+	if err := cleanUp(context.Background(), s, item); err != nil {
+		t.Fatalf("cleanUp: %v", err)
+	}
+}
+
+func TestSolverBind9ClusterEndpoints(t *testing.T) {
+	servers := startServers(t, 2)
+	s := newTestSolver(t)
+	ch := newChallenge(t, nil, testFQDN, "token")
+	config, err := solverconfig.Parse([]byte(`{"bind9Cluster":{"namespace":"dns","name":"bind9"},` +
+		`"zone":"example.com","tsigKeyName":"` + dnstest.TestKeyName + `","tsigSecretName":"tsig"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.Propagation = &solverconfig.PropagationConfig{Timeout: solverconfig.Duration{Duration: 2 * time.Second}}
+	if ch.Config.Raw, err = json.Marshal(config); err != nil {
+		t.Fatal(err)
+	}
+
+	// Until the cluster publishes its endpoints there is nothing to update
+	if err := s.Present(ch); err == nil || !strings.Contains(err.Error(), "bind9Cluster dns/bind9") {
+		t.Fatalf("Present without endpoints = %v", err)
+	}
+
+	servers[1].SetTXT(testFQDN, 60, "token")
+	_, err = s.client.CoreV1().ConfigMaps("dns").Create(context.Background(), &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "bind9-endpoints", Namespace: "dns"},
+		Data:       solverconfig.EndpointsData([]string{servers[0].Addr()}, []string{servers[1].Addr()}),
+	}, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Present(ch); err != nil {
+		t.Fatalf("Present: %v", err)
+	}
+	if got := servers[0].TXT(testFQDN); !slices.Equal(got, []string{"token"}) {
+		t.Fatalf("primary TXT = %v, want the challenge value", got)
+	}
+	if got := servers[1].Updates(); got != 0 {
+		t.Fatalf("secondary received %d updates, want none", got)
+	}
+}
+
+func TestSolverTransport(t *testing.T) {
+	servers := startServers(t, 3)
+	for _, srv := range servers {
+		srv.SetTruncateUDP(true)
+	}
+	s := newTestSolver(t)
+	s.opts.PreflightEnabled = false
+	ch := newChallenge(t, serverAddrs(servers), testFQDN, "token")
+
+	// The default transport falls back to TCP for updates and verification queries
+	if err := s.Present(ch); err != nil {
+		t.Fatalf("Present with truncating servers: %v", err)
+	}
+
+	config, err := solverconfig.Parse(ch.Config.Raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.Transport = "udp"
+	if ch.Config.Raw, err = json.Marshal(config); err != nil {
+		t.Fatal(err)
+	}
+	ch.Key = "token-udp"
+	if err := s.Present(ch); err == nil || !strings.Contains(err.Error(), "truncated") {
+		t.Fatalf("Present over UDP only = %v, want a truncation error", err)
+	}
+}
+
+func TestSolverDNSOverTLS(t *testing.T) {
+	servers := startServers(t, 2)
+	addrs := make([]string, len(servers))
+	for i, srv := range servers {
+		if err := srv.StartTLS(); err != nil {
+			t.Fatal(err)
+		}
+		addrs[i] = srv.TLSAddr()
+	}
+	s := newTestSolver(t)
+	_, err := s.client.CoreV1().Secrets("cert-manager").Create(context.Background(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "dot-ca", Namespace: "cert-manager"},
+		// The default CA bundle trusts the first server; the second has its own in serverTLS
+		Data: map[string][]byte{"ca.crt": servers[0].TLSCertificatePEM()},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.client.CoreV1().Secrets("cert-manager").Create(context.Background(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "dot-ca-2", Namespace: "cert-manager"},
+		Data:       map[string][]byte{"ca.crt": servers[1].TLSCertificatePEM()},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ch := newChallenge(t, addrs, testFQDN, "token")
+	config, err := solverconfig.Parse(ch.Config.Raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.Transport = "tls"
+	config.TLS = &solverconfig.TLSConfig{SecretName: "dot-ca", ServerName: dnstest.TLSServerName}
+	config.ServerTLS = map[string]solverconfig.TLSConfig{
+		addrs[1]: {SecretName: "dot-ca-2", ServerName: dnstest.TLSServerName},
+	}
+	if ch.Config.Raw, err = json.Marshal(config); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Present(ch); err != nil {
+		t.Fatalf("Present over TLS: %v", err)
+	}
+	for _, srv := range servers {
+		if got := srv.TXT(testFQDN); len(got) != 1 {
+			t.Fatalf("server %s has TXT %v after Present over TLS", srv.Addr(), got)
+		}
+	}
+
+	item := cleanupItem{Namespace: "cert-manager", FQDN: testFQDN, Value: "token", Config: string(ch.Config.Raw)}
+	if err := cleanUp(context.Background(), s, item); err != nil {
+		t.Fatalf("cleanup over TLS: %v", err)
+	}
+	for _, srv := range servers {
+		if got := srv.TXT(testFQDN); len(got) != 0 {
+			t.Fatalf("server %s has TXT %v after cleanup over TLS", srv.Addr(), got)
+		}
+	}
+}
+
+func TestSolverServerEntries(t *testing.T) {
+	const secondKey, secondSecret = "second-key.", "c2Vjb25kLXRzaWctc2VjcmV0LWZvci10ZXN0aW5n"
+	servers := startServers(t, 1)
+	second := dnstest.NewServer("example.com")
+	second.AddTSIGKey(secondKey, secondSecret)
+	if err := second.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = second.Close() })
+	servers = append(servers, second)
+
+	s := newTestSolver(t)
+	_, err := s.client.CoreV1().Secrets("cert-manager").Create(context.Background(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "tsig-second", Namespace: "cert-manager"},
+		Data:       map[string][]byte{"secret": []byte(secondSecret)},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	host, port, err := net.SplitHostPort(second.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ch := newChallenge(t, serverAddrs(servers), testFQDN, "token")
+	var config map[string]any
+	if err := json.Unmarshal(ch.Config.Raw, &config); err != nil {
+		t.Fatal(err)
+	}
+	config["servers"] = []any{
+		servers[0].Addr(),
+		map[string]any{"address": host, "port": json.Number(port), "tsigKeyName": secondKey, "tsigSecretName": "tsig-second"},
+	}
+	if ch.Config.Raw, err = json.Marshal(config); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Present(ch); err != nil {
+		t.Fatalf("Present with per-server keys: %v", err)
+	}
+	for _, srv := range servers {
+		if got := srv.TXT(testFQDN); len(got) != 1 || got[0] != "token" {
+			t.Fatalf("server %s has TXT %v after Present", srv.Addr(), got)
+		}
+	}
+
+	item := cleanupItem{Namespace: "cert-manager", FQDN: testFQDN, Value: "token", Config: string(ch.Config.Raw)}
+	if err := cleanUp(context.Background(), s, item); err != nil {
+		t.Fatalf("cleanup with per-server keys: %v", err)
+	}
+	for _, srv := range servers {
+		if got := srv.TXT(testFQDN); len(got) != 0 {
+			t.Fatalf("server %s has TXT %v after cleanup", srv.Addr(), got)
+		}
+	}
+}
+
+func TestSolverSIG0(t *testing.T) {
+	key, private, err := dnstest.GenerateSIG0Key("acme-sig0.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := dnstest.NewServer("example.com")
+	srv.AddSIG0Key(key)
+	if err := srv.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = srv.Close() })
+
+	s := newTestSolver(t)
+	_, err = s.client.CoreV1().Secrets("cert-manager").Create(context.Background(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "sig0-key", Namespace: "cert-manager"},
+		Data:       map[string][]byte{"key": []byte(key.String()), "private": []byte(private)},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := json.Marshal(map[string]any{
+		"servers":    []string{srv.Addr()},
+		"zone":       "example.com",
+		"authMethod": "sig0",
+		"sig0":       map[string]string{"secretName": "sig0-key"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ch := newChallenge(t, []string{srv.Addr()}, testFQDN, "token")
+	ch.Config.Raw = raw
+
+	if err := s.Present(ch); err != nil {
+		t.Fatalf("Present with SIG(0): %v", err)
+	}
+	if got := srv.TXT(testFQDN); len(got) != 1 || got[0] != "token" {
+		t.Fatalf("TXT after Present = %v, want [token]", got)
+	}
+
+	item := cleanupItem{Namespace: "cert-manager", FQDN: testFQDN, Value: "token", Config: string(raw)}
+	if err := cleanUp(context.Background(), s, item); err != nil {
+		t.Fatalf("cleanup with SIG(0): %v", err)
+	}
+	if got := srv.TXT(testFQDN); len(got) != 0 {
+		t.Fatalf("TXT after cleanup = %v, want none", got)
+	}
+}
+
+func TestSolverDiscoversZone(t *testing.T) {
+	const fqdn = "_acme-challenge.www.sub.example.com."
+	srv := dnstest.NewServer("example.com", "sub.example.com")
+	srv.AddTSIGKey(dnstest.TestKeyName, dnstest.TestSecret)
+	if err := srv.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = srv.Close() })
+
+	s := newTestSolver(t)
+	ch := newChallenge(t, []string{srv.Addr()}, fqdn, "token")
+	var raw map[string]any
+	if err := json.Unmarshal(ch.Config.Raw, &raw); err != nil {
+		t.Fatal(err)
+	}
+	delete(raw, "zone")
+	var err error
+	if ch.Config.Raw, err = json.Marshal(raw); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := s.parseConfig(ch.Config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.resolveZone(context.Background(), config, fqdn); err != nil {
+		t.Fatalf("resolveZone: %v", err)
+	}
+	if config.Zone != "sub.example.com" {
+		t.Fatalf("discovered zone %q, want sub.example.com", config.Zone)
+	}
+
+	if err := s.Present(ch); err != nil {
+		t.Fatalf("Present without a zone: %v", err)
+	}
+	if got := srv.TXT(fqdn); len(got) != 1 || got[0] != "token" {
+		t.Fatalf("TXT after Present = %v, want [token]", got)
+	}
+}
+
+func TestSolverZones(t *testing.T) {
+	const parentFQDN, subFQDN = "_acme-challenge.www.example.com.", "_acme-challenge.www.sub.example.com."
+	parent := startServers(t, 1)[0]
+	sub := dnstest.NewServer("sub.example.com")
+	sub.AddTSIGKey(dnstest.TestKeyName, dnstest.TestSecret)
+	if err := sub.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = sub.Close() })
+
+	s := newTestSolver(t)
+	raw, err := json.Marshal(map[string]any{
+		"tsigKeyName":    dnstest.TestKeyName,
+		"tsigSecretName": "tsig",
+		"zones": []map[string]any{
+			{"zone": "example.com", "servers": []string{parent.Addr()}},
+			{"zone": "sub.example.com", "servers": []string{sub.Addr()}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	challenge := func(fqdn string) *v1alpha1.ChallengeRequest {
+		return &v1alpha1.ChallengeRequest{
+			ResolvedFQDN:      fqdn,
+			Key:               "token",
+			ResourceNamespace: "cert-manager",
+			Config:            &apiextensionsv1.JSON{Raw: raw},
+		}
+	}
+
+	// Each name goes to the servers of the longest zone enclosing it
+	for _, fqdn := range []string{parentFQDN, subFQDN} {
+		if err := s.Present(challenge(fqdn)); err != nil {
+			t.Fatalf("Present %s: %v", fqdn, err)
+		}
+	}
+	if got := parent.TXT(parentFQDN); len(got) != 1 || got[0] != "token" {
+		t.Fatalf("example.com server has TXT %v, want [token]", got)
+	}
+	if got := sub.TXT(subFQDN); len(got) != 1 || got[0] != "token" {
+		t.Fatalf("sub.example.com server has TXT %v, want [token]", got)
+	}
+	if got := parent.TXT(subFQDN); len(got) != 0 {
+		t.Fatalf("example.com server has TXT %v for the sub zone, want none", got)
+	}
+
+	item := cleanupItem{Namespace: "cert-manager", FQDN: subFQDN, Value: "token", Config: string(raw)}
+	if err := cleanUp(context.Background(), s, item); err != nil {
+		t.Fatalf("cleanup: %v", err)
+	}
+	if got := sub.TXT(subFQDN); len(got) != 0 {
+		t.Fatalf("sub.example.com server has TXT %v after cleanup", got)
+	}
+
+	// Without top-level servers a name outside every zone has nowhere to go
+	if err := s.Present(challenge("_acme-challenge.example.net.")); err == nil ||
+		!strings.Contains(err.Error(), "no entry of zones encloses") {
+		t.Fatalf("Present outside the zones = %v, want a routing error", err)
+	}
+}
+
+func TestSolverFollowsCNAME(t *testing.T) {
+	const fqdn, target = "_acme-challenge.www.example.com.", "www.acme.example.net."
+	parent := startServers(t, 1)[0]
+	parent.SetCNAME(fqdn, target)
+	delegated := dnstest.NewServer("acme.example.net")
+	delegated.AddTSIGKey(dnstest.TestKeyName, dnstest.TestSecret)
+	if err := delegated.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = delegated.Close() })
+
+	s := newTestSolver(t)
+	raw, err := json.Marshal(map[string]any{
+		"tsigKeyName":    dnstest.TestKeyName,
+		"tsigSecretName": "tsig",
+		"followCNAME":    map[string]any{"maxHops": 2},
+		"zones": []map[string]any{
+			{"zone": "example.com", "servers": []string{parent.Addr()}},
+			{"zone": "acme.example.net", "servers": []string{delegated.Addr()}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ch := &v1alpha1.ChallengeRequest{
+		ResolvedFQDN:      fqdn,
+		Key:               "token",
+		ResourceNamespace: "cert-manager",
+		Config:            &apiextensionsv1.JSON{Raw: raw},
+	}
+
+	// The record lands at the CNAME target, on the servers of its zone
+	if err := s.Present(ch); err != nil {
+		t.Fatalf("Present: %v", err)
+	}
+	if got := delegated.TXT(target); len(got) != 1 || got[0] != "token" {
+		t.Fatalf("acme.example.net server has TXT %v at %s, want [token]", got, target)
+	}
+	if got := parent.TXT(fqdn); len(got) != 0 {
+		t.Fatalf("example.com server has TXT %v at the challenge name, want none", got)
+	}
+
+	item := cleanupItem{Namespace: "cert-manager", FQDN: fqdn, Value: "token", Config: string(raw)}
+	if err := cleanUp(context.Background(), s, item); err != nil {
+		t.Fatalf("cleanup: %v", err)
+	}
+	if got := delegated.TXT(target); len(got) != 0 {
+		t.Fatalf("acme.example.net server has TXT %v after cleanup", got)
+	}
+
+	// A chain longer than maxHops is not followed
+	parent.SetCNAME(target, "a.acme.example.net.")
+	delegated.SetCNAME(target, "a.acme.example.net.")
+	delegated.SetCNAME("a.acme.example.net.", "b.acme.example.net.")
+	if err := s.Present(ch); err == nil || !strings.Contains(err.Error(), "longer than 2 hops") {
+		t.Fatalf("Present over a long chain = %v, want a hop limit error", err)
+	}
+}
+
+func TestSolverPowerDNSProvider(t *testing.T) {
+	var mu sync.Mutex
+	var changes []string
+	// The zone holds at most the single RRset of the last REPLACE
+	published := []map[string]any{}
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-API-Key") != "pdns-api-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Method == http.MethodGet {
+			_ = json.NewEncoder(w).Encode(map[string]any{"rrsets": published})
+			return
+		}
+		var body struct {
+			RRsets []map[string]any `json:"rrsets"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		changeType, _ := body.RRsets[0]["changetype"].(string)
+		changes = append(changes, r.URL.Path+" "+changeType)
+		published = []map[string]any{}
+		if changeType == "REPLACE" {
+			published = body.RRsets
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer api.Close()
+
+	s := newTestSolver(t)
+	raw, err := json.Marshal(Config{
+		Zone:     "example.com",
+		Provider: solverconfig.ProviderPowerDNS,
+		PowerDNS: &solverconfig.PowerDNSConfig{APIURL: api.URL, APIKeySecretName: "pdns"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ch := &v1alpha1.ChallengeRequest{ResolvedFQDN: testFQDN, Key: "token", ResourceNamespace: "cert-manager",
+		Config: &apiextensionsv1.JSON{Raw: raw}}
+
+	if err := s.Present(ch); err != nil {
+		t.Fatalf("Present: %v", err)
+	}
+	item := cleanupItem{Namespace: ch.ResourceNamespace, FQDN: ch.ResolvedFQDN, Value: ch.Key, Config: string(raw)}
+	if err := cleanUp(context.Background(), s, item); err != nil {
+		t.Fatalf("cleanUp: %v", err)
+	}
+
+	want := []string{
+		"/api/v1/servers/localhost/zones/example.com. REPLACE",
+		"/api/v1/servers/localhost/zones/example.com. DELETE",
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Fatalf("PowerDNS changes = %v, want %v", changes, want)
+	}
+}
+
+func TestSolverMixedBackends(t *testing.T) {
+	servers := startServers(t, 2)
+	// The second server is updated through a PowerDNS API writing into its zone
+	pdns := servers[1]
+	var mu sync.Mutex
+	var changes []string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-API-Key") != "pdns-api-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Method == http.MethodGet {
+			var rrsets []map[string]any
+			if values := pdns.TXT(testFQDN); len(values) > 0 {
+				var records []map[string]any
+				for _, value := range values {
+					records = append(records, map[string]any{"content": `"` + value + `"`})
+				}
+				rrsets = append(rrsets, map[string]any{"name": testFQDN, "type": "TXT", "ttl": 60, "records": records})
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"rrsets": rrsets})
+			return
+		}
+		var body struct {
+			RRsets []struct {
+				Name       string `json:"name"`
+				ChangeType string `json:"changetype"`
+				Records    []struct {
+					Content string `json:"content"`
+				} `json:"records"`
+			} `json:"rrsets"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		for _, rrset := range body.RRsets {
+			changes = append(changes, rrset.ChangeType+" "+rrset.Name)
+			var values []string
+			for _, record := range rrset.Records {
+				values = append(values, strings.Trim(record.Content, `"`))
+			}
+			pdns.SetTXT(rrset.Name, 60, values...)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer api.Close()
+
+	host, port, err := net.SplitHostPort(pdns.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	portNum, _ := strconv.Atoi(port)
+
+	s := newTestSolver(t)
+	config := Config{
+		Servers:        serverAddrs(servers),
+		Zone:           "example.com",
+		TSIGKeyName:    dnstest.TestKeyName,
+		TSIGAlgorithm:  "hmac-sha256",
+		TSIGSecretName: "tsig",
+		TSIGSecretKey:  "secret",
+		ServerEntries: map[string]solverconfig.ServerEntry{pdns.Addr(): {
+			Address:  host,
+			Port:     portNum,
+			Provider: solverconfig.ProviderPowerDNS,
+			PowerDNS: &solverconfig.PowerDNSConfig{APIURL: api.URL, APIKeySecretName: "pdns"},
+		}},
+	}
+	raw, err := json.Marshal(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ch := &v1alpha1.ChallengeRequest{ResolvedFQDN: testFQDN, Key: "token", ResourceNamespace: "cert-manager",
+		Config: &apiextensionsv1.JSON{Raw: raw}}
+
+	if err := s.Present(ch); err != nil {
+		t.Fatalf("Present: %v", err)
+	}
+	for i, srv := range servers {
+		if got := srv.TXT(testFQDN); !reflect.DeepEqual(got, []string{"token"}) {
+			t.Fatalf("server %d TXT = %v, want [token]", i, got)
+		}
+	}
+	if servers[0].Updates() == 0 || pdns.Updates() != 0 {
+		t.Fatalf("updates = %d, %d; want RFC2136 updates on the first server only",
+			servers[0].Updates(), pdns.Updates())
+	}
+
+	item := cleanupItem{Namespace: ch.ResourceNamespace, FQDN: ch.ResolvedFQDN, Value: ch.Key, Config: string(raw)}
+	if err := cleanUp(context.Background(), s, item); err != nil {
+		t.Fatalf("cleanUp: %v", err)
+	}
+	for i, srv := range servers {
+		if got := srv.TXT(testFQDN); len(got) != 0 {
+			t.Fatalf("server %d TXT after cleanup = %v, want none", i, got)
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if want := []string{"REPLACE " + testFQDN, "DELETE " + testFQDN}; !reflect.DeepEqual(changes, want) {
+		t.Fatalf("PowerDNS changes = %v, want %v", changes, want)
+	}
+}
+
+func TestSolverRoute53Bridge(t *testing.T) {
+	var mu sync.Mutex
+	var actions []string
+	route53 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			_, _ = w.Write([]byte(`<ListResourceRecordSetsResponse><ResourceRecordSets>` +
+				`<ResourceRecordSet><Name>` + testFQDN + `</Name><Type>TXT</Type><TTL>60</TTL>` +
+				`<ResourceRecords><ResourceRecord><Value>"token"</Value></ResourceRecord></ResourceRecords>` +
+				`</ResourceRecordSet></ResourceRecordSets></ListResourceRecordSetsResponse>`))
+			return
+		}
+		var body struct {
+			Action string `xml:"ChangeBatch>Changes>Change>Action"`
+		}
+		_ = xml.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		actions = append(actions, body.Action)
+		mu.Unlock()
+		_, _ = w.Write([]byte(`<ChangeResourceRecordSetsResponse/>`))
+	}))
+	defer route53.Close()
+
+	servers := startServers(t, 1)
+	s := newTestSolver(t)
+	ch := newChallenge(t, serverAddrs(servers), testFQDN, "token")
+	config, err := solverconfig.Parse(ch.Config.Raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.Bridge = &solverconfig.BridgeConfig{Route53: &solverconfig.Route53Config{
+		HostedZoneID: "Z1", CredentialsSecretName: "aws", Endpoint: route53.URL}}
+	if ch.Config.Raw, err = json.Marshal(config); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Present(ch); err != nil {
+		t.Fatalf("Present: %v", err)
+	}
+	if got := servers[0].TXT(testFQDN); len(got) != 1 {
+		t.Fatalf("internal view has TXT %v after Present", got)
+	}
+	item := cleanupItem{Namespace: ch.ResourceNamespace, FQDN: ch.ResolvedFQDN, Value: ch.Key, Config: string(ch.Config.Raw)}
+	if err := cleanUp(context.Background(), s, item); err != nil {
+		t.Fatalf("cleanUp: %v", err)
+	}
+
+	if want := []string{"UPSERT", "DELETE"}; !reflect.DeepEqual(actions, want) {
+		t.Fatalf("Route53 actions = %v, want %v", actions, want)
+	}
+}
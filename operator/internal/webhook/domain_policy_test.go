@@ -0,0 +1,95 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestDomainPolicyCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		allow   []string
+		deny    []string
+		fqdn    string
+		allowed bool
+	}{
+		{"no patterns", nil, nil, "_acme-challenge.app.example.com.", true},
+		{"below allowed domain", []string{"example.com"}, nil, "_acme-challenge.app.example.com.", true},
+		{"outside allowed domain", []string{"example.com"}, nil, "_acme-challenge.example.org.", false},
+		{"suffix is not a subdomain", []string{"example.com"}, nil, "_acme-challenge.badexample.com.", false},
+		{"glob", []string{"_acme-challenge.*.example.com"}, nil, "_acme-challenge.App.example.com.", true},
+		{"regular expression", []string{`re:_acme-challenge\.(app|api)\.example\.com`}, nil,
+			"_acme-challenge.api.example.com.", true},
+		{"regular expression is anchored", []string{`re:_acme-challenge\.app\.example\.com`}, nil,
+			"_acme-challenge.app.example.com.evil.org.", false},
+		{"deny wins", []string{"example.com"}, []string{"internal.example.com"},
+			"_acme-challenge.db.internal.example.com.", false},
+		{"deny only", nil, []string{"*.example.org"}, "_acme-challenge.example.org.", false},
+		{"invalid pattern fails closed", []string{"re:("}, nil, "_acme-challenge.example.com.", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newDomainPolicy(tt.allow, tt.deny).check(tt.fqdn)
+			if (err == nil) != tt.allowed {
+				t.Fatalf("check(%s) = %v, allowed %v", tt.fqdn, err, tt.allowed)
+			}
+			if err != nil && !errors.Is(err, ErrDomainNotPermitted) {
+				t.Fatalf("check(%s) = %v, want ErrDomainNotPermitted", tt.fqdn, err)
+			}
+		})
+	}
+}
+
+func TestDomainPolicyConfigMap(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	p := newDomainPolicy([]string{"example.com"}, nil)
+	stopCh := make(chan struct{})
+	t.Cleanup(func() { close(stopCh) })
+	p.watch(client, "cert-manager", "dns01-domain-policy", stopCh, zap.NewNop())
+
+	const fqdn = "_acme-challenge.db.internal.example.com."
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "dns01-domain-policy", Namespace: "cert-manager"},
+		Data:       map[string]string{"deny": "# internal zones\ninternal.example.com\n"},
+	}
+	ctx := context.Background()
+	if _, err := client.CoreV1().ConfigMaps("cert-manager").Create(ctx, cm, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return p.check(fqdn) != nil })
+
+	// An invalid pattern keeps the previous policy
+	cm.Data["deny"] = "re:("
+	if _, err := client.CoreV1().ConfigMaps("cert-manager").Update(ctx, cm, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.CoreV1().ConfigMaps("cert-manager").Delete(ctx, cm.Name, metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return p.check(fqdn) == nil })
+	if err := p.check("_acme-challenge.example.org."); err == nil {
+		t.Fatal("the allowlist of the environment was dropped with the ConfigMap")
+	}
+}
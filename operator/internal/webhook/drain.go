@@ -0,0 +1,150 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// FunctionRating: 78/100
+// - Complexity: MEDIUM
+// - Integrations: 0
+// - External Risks: MEDIUM (bounded wait on DNS operations at shutdown)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: drain
+// Purpose: Graceful shutdown that finishes in-flight DNS operations before the webhook exits
+
+// errShuttingDown is returned for challenges arriving while the webhook
+// drains; cert-manager retries them, usually on another replica
+var errShuttingDown = errors.New("webhook is shutting down")
+
+// operationTracker counts the DNS operations in flight so shutdown can wait
+// for them, and turns new ones away once draining started
+type operationTracker struct {
+	mu       sync.Mutex
+	draining bool
+	inFlight int
+	// idle is closed when draining and the last operation ends
+	idle chan struct{}
+}
+
+// newOperationTracker creates a tracker accepting operations
+func newOperationTracker() *operationTracker {
+	return &operationTracker{idle: make(chan struct{})}
+}
+
+// begin registers an operation and returns the function ending it, or false
+// once draining started
+func (t *operationTracker) begin() (func(), bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.draining {
+		return nil, false
+	}
+	t.inFlight++
+	var once sync.Once
+	return func() { once.Do(t.end) }, true
+}
+
+// end unregisters an operation
+func (t *operationTracker) end() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.inFlight--
+	if t.draining && t.inFlight == 0 {
+		close(t.idle)
+	}
+}
+
+// isDraining reports whether draining started
+func (t *operationTracker) isDraining() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.draining
+}
+
+// drain turns new operations away and waits for those in flight until ctx
+// ends. It returns how many were still running.
+func (t *operationTracker) drain(ctx context.Context) int {
+	t.mu.Lock()
+	if !t.draining {
+		t.draining = true
+		if t.inFlight == 0 {
+			close(t.idle)
+		}
+	}
+	t.mu.Unlock()
+
+	select {
+	case <-t.idle:
+		return 0
+	case <-ctx.Done():
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		return t.inFlight
+	}
+}
+
+// Draining reports whether the solver stopped accepting challenges
+func (s *DNS01Solver) Draining() bool {
+	return s.ops.isDraining()
+}
+
+// drain stops accepting challenges and waits up to opts.DrainTimeout for the
+// DNS operations in flight. Pending server repairs survive in the repair
+// journal; without one they are lost, which is logged. It runs once;
+// concurrent callers wait for it, and s.drained is closed when it returns.
+func (s *DNS01Solver) drain() {
+	s.drainOnce.Do(func() {
+		defer close(s.drained)
+		s.logger.Info("Draining webhook", zap.Duration("timeout", s.opts.DrainTimeout))
+		start := time.Now()
+		ctx, cancel := context.WithTimeout(context.Background(), s.opts.DrainTimeout)
+		defer cancel()
+		if running := s.ops.drain(ctx); running > 0 {
+			s.logger.Warn("Drain timeout reached, abandoning DNS operations in flight",
+				zap.Int("operations", running),
+			)
+		}
+		if s.repairs != nil && s.repairs.journal == nil {
+			if pending := len(s.repairs.Pending()); pending > 0 {
+				s.logger.Warn("Repair journal disabled, dropping pending server repairs", zap.Int("repairs", pending))
+			}
+		}
+		s.logger.Info("Webhook drained", zap.Duration("duration", time.Since(start)))
+	})
+}
+
+// stopAfterDrain returns a channel closed once stopCh is closed and the solver
+// drained, so the queues, worker pool and connections outlive the operations
+// still using them
+func (s *DNS01Solver) stopAfterDrain(stopCh <-chan struct{}) <-chan struct{} {
+	go func() {
+		<-stopCh
+		s.drain()
+	}()
+	return s.drained
+}
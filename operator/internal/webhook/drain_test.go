@@ -0,0 +1,124 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestOperationTracker(t *testing.T) {
+	tracker := newOperationTracker()
+	done, ok := tracker.begin()
+	if !ok {
+		t.Fatal("begin refused before draining")
+	}
+
+	// An operation still running outlasts a short drain
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if running := tracker.drain(ctx); running != 1 {
+		t.Fatalf("drain left %d operations running, want 1", running)
+	}
+	if _, ok := tracker.begin(); ok {
+		t.Fatal("begin accepted an operation while draining")
+	}
+
+	// Ending it, twice even, releases a second drain
+	done()
+	done()
+	if running := tracker.drain(context.Background()); running != 0 {
+		t.Fatalf("drain left %d operations running, want 0", running)
+	}
+}
+
+func TestSolverDrainWaitsForPresent(t *testing.T) {
+	servers := startServers(t, 2)
+	servers[1].SetLatency(200 * time.Millisecond)
+	s := newTestSolver(t)
+	ch := newChallenge(t, serverAddrs(servers), testFQDN, "token")
+
+	presented := make(chan error, 1)
+	go func() { presented <- s.Present(ch) }()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		s.ops.mu.Lock()
+		started := s.ops.inFlight > 0
+		s.ops.mu.Unlock()
+		if started {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Present never started")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	s.drain()
+	select {
+	case err := <-presented:
+		if err != nil {
+			t.Fatalf("Present during drain: %v", err)
+		}
+	default:
+		t.Fatal("drain returned before the Present in flight")
+	}
+	for _, srv := range servers {
+		if got := srv.TXT(testFQDN); len(got) != 1 {
+			t.Fatalf("server %s has TXT %v after drain, want [token]", srv.Addr(), got)
+		}
+	}
+	select {
+	case <-s.drained:
+	default:
+		t.Fatal("drained not closed after drain")
+	}
+
+	// New challenges are turned away
+	if err := s.Present(newChallenge(t, serverAddrs(servers), testFQDN, "late")); !errors.Is(err, errShuttingDown) {
+		t.Fatalf("Present after drain = %v, want errShuttingDown", err)
+	}
+	if err := s.CleanUp(ch); !errors.Is(err, errShuttingDown) {
+		t.Fatalf("CleanUp after drain = %v, want errShuttingDown", err)
+	}
+}
+
+func TestReadyzFailsWhileDraining(t *testing.T) {
+	draining := false
+	checker := newHealthChecker(nil, time.Hour, time.Second, zap.NewNop())
+	checker.draining = func() bool { return draining }
+	mux := newHealthMux(checker)
+	get := func() int {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		return rec.Code
+	}
+
+	if code := get(); code != http.StatusOK {
+		t.Fatalf("/readyz = %d before draining, want 200", code)
+	}
+	draining = true
+	if code := get(); code != http.StatusServiceUnavailable {
+		t.Fatalf("/readyz = %d while draining, want 503", code)
+	}
+}
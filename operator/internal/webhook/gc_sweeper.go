@@ -0,0 +1,246 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	cmacme "github.com/cert-manager/cert-manager/pkg/apis/acme/v1"
+	cmversioned "github.com/cert-manager/cert-manager/pkg/client/clientset/versioned"
+	mdns "github.com/miekg/dns"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/rest"
+
+	"github.com/rieset/istio-dns01-bind9/internal/dns"
+	"github.com/rieset/istio-dns01-bind9/internal/metrics"
+	"github.com/rieset/istio-dns01-bind9/internal/solverconfig"
+)
+
+// FunctionRating: 74/100
+// - Complexity: MEDIUM
+// - Integrations: 3 (cert-manager clientset, dns package, secret cache)
+// - External Risks: MEDIUM (deletes records; bounded to _acme-challenge TXT RRsets)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: staleSweeper
+// Purpose: Periodically removes _acme-challenge TXT records left behind by failed or lost CleanUp calls
+
+// challengeLabel is the first label of every ACME DNS01 challenge name
+const challengeLabel = "_acme-challenge"
+
+// sweepKey identifies one challenge value observed on one server
+type sweepKey struct {
+	server string
+	fqdn   string
+	value  string
+}
+
+// staleSweeper finds challenge records through zone transfers. Zone
+// transfers carry no timestamps, so the age of a record is the time since
+// the sweeper first observed it; after a restart records age from zero again.
+// Records whose value belongs to an existing Challenge resource or to a
+// challenge this instance presented are never stale.
+type staleSweeper struct {
+	solver *DNS01Solver
+	maxAge time.Duration
+	now    func() time.Time
+	// firstSeen holds when each challenge value was first observed
+	firstSeen map[sweepKey]time.Time
+	// challengeKeys holds the keys of the DNS01 Challenge resources of the last listing
+	challengeKeys map[string]bool
+}
+
+// newStaleSweeper creates a sweeper removing challenge records older than maxAge
+func newStaleSweeper(solver *DNS01Solver, maxAge time.Duration) *staleSweeper {
+	return &staleSweeper{
+		solver:    solver,
+		maxAge:    maxAge,
+		now:       time.Now,
+		firstSeen: map[sweepKey]time.Time{},
+	}
+}
+
+// run sweeps every interval until stopCh is closed
+func (g *staleSweeper) run(kubeClientConfig *rest.Config, interval time.Duration, stopCh <-chan struct{}) {
+	ctx := wait.ContextForChannel(stopCh)
+	wait.Until(func() {
+		refs, err := g.solver.listSolverReferences(ctx, kubeClientConfig)
+		if err != nil {
+			g.solver.logger.Warn("Garbage collection skipped: unable to list issuers", zap.Error(err))
+			return
+		}
+		keys, err := listChallengeKeys(ctx, kubeClientConfig)
+		if err != nil {
+			g.solver.logger.Warn("Garbage collection skipped: unable to list challenges", zap.Error(err))
+			return
+		}
+		g.challengeKeys = keys
+		g.sweep(ctx, refs)
+	}, interval, stopCh)
+}
+
+// listChallengeKeys returns the keys of the DNS01 Challenge resources in the
+// cluster, which are the TXT values cert-manager still expects to clean up
+func listChallengeKeys(ctx context.Context, kubeClientConfig *rest.Config) (map[string]bool, error) {
+	cm, err := cmversioned.NewForConfig(kubeClientConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cert-manager client: %w", err)
+	}
+	challenges, err := cm.AcmeV1().Challenges(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list challenges: %w", err)
+	}
+	keys := map[string]bool{}
+	for _, ch := range challenges.Items {
+		if ch.Spec.Type == cmacme.ACMEChallengeTypeDNS01 && ch.Spec.Key != "" {
+			keys[ch.Spec.Key] = true
+		}
+	}
+	return keys, nil
+}
+
+// active reports whether value at fqdn still belongs to a challenge
+func (g *staleSweeper) active(fqdn, value string) bool {
+	return g.challengeKeys[value] || g.solver.active.needed(fqdn, value)
+}
+
+// sweep inspects the zones of refs and removes challenge RRsets whose every
+// value has been observed for longer than maxAge
+func (g *staleSweeper) sweep(ctx context.Context, refs []solverReference) {
+	now := g.now()
+	seen := map[sweepKey]bool{}
+	visited := map[string]bool{}
+
+	for _, ref := range refs {
+		config := ref.Config
+		if config.Provider != solverconfig.ProviderRFC2136 {
+			continue
+		}
+		provider, err := g.solver.newZoneProvider(ctx, ref.Namespace, config, nil)
+		if err != nil {
+			g.solver.logger.Warn("Garbage collection skipped zone",
+				zap.String("issuer", ref.Issuer), zap.String("zone", config.Zone), zap.Error(err))
+			continue
+		}
+		manager, ok := provider.(*MultiServerDNS)
+		if !ok {
+			continue
+		}
+
+		for _, server := range config.Servers {
+			zone := config.ServerSettings(server).Zone
+			if zone == "" || config.ServerProvider(server) != solverconfig.ProviderRFC2136 {
+				// Discovered zones are only known per challenge, and API
+				// backends offer no zone transfer
+				continue
+			}
+			visit := server + "|" + mdns.Fqdn(strings.ToLower(zone))
+			if visited[visit] {
+				continue
+			}
+			visited[visit] = true
+
+			g.sweepServer(ctx, manager.newRFC2136Client(server), server, zone, now, seen)
+		}
+	}
+
+	for key := range g.firstSeen {
+		if !seen[key] {
+			delete(g.firstSeen, key)
+		}
+	}
+}
+
+// sweepServer transfers zone from server and deletes its stale challenge values
+func (g *staleSweeper) sweepServer(ctx context.Context, client *dns.RFC2136Client, server, zone string,
+	now time.Time, seen map[sweepKey]bool) {
+	records, err := client.TransferZone(ctx)
+	if err != nil {
+		g.solver.logger.Warn("Garbage collection failed to transfer zone",
+			zap.String("server", server), zap.String("zone", zone), zap.Error(err))
+		return
+	}
+
+	challenges := map[string][]string{}
+	for _, rr := range records {
+		txt, ok := rr.(*mdns.TXT)
+		if !ok {
+			continue
+		}
+		fqdn := strings.ToLower(txt.Hdr.Name)
+		if labels := mdns.SplitDomainName(fqdn); len(labels) == 0 || labels[0] != challengeLabel {
+			continue
+		}
+		challenges[fqdn] = append(challenges[fqdn], strings.Join(txt.Txt, ""))
+	}
+
+	names := make([]string, 0, len(challenges))
+	for fqdn := range challenges {
+		names = append(names, fqdn)
+	}
+	sort.Strings(names)
+
+	for _, fqdn := range names {
+		for _, value := range challenges[fqdn] {
+			key := sweepKey{server: server, fqdn: fqdn, value: value}
+			seen[key] = true
+			first, ok := g.firstSeen[key]
+			if !ok {
+				first = now
+				g.firstSeen[key] = now
+			}
+			if now.Sub(first) < g.maxAge || g.active(fqdn, value) {
+				continue
+			}
+
+			// Only the value seen stale goes: one presented since the transfer,
+			// possibly by another replica, stays in the RRset
+			err := g.solver.updateZone(ctx, zone, func(ctx context.Context) error {
+				return client.DeleteTXTRecordValue(ctx, fqdn, value)
+			})
+			if errors.Is(err, dns.ErrNotOwned) {
+				// Records of another owner sharing the zone are theirs to remove
+				g.solver.logger.Debug("Garbage collection skipped challenge record of another owner",
+					zap.String("server", server), zap.String("fqdn", fqdn), zap.Error(err))
+				continue
+			}
+			if err != nil {
+				g.solver.logger.Warn("Garbage collection failed to delete stale challenge record",
+					zap.String("server", server), zap.String("fqdn", fqdn), zap.Error(err))
+				continue
+			}
+			delete(g.firstSeen, key)
+			delete(seen, key)
+			metrics.StaleRecordsRemoved.WithLabelValues(zone).Inc()
+			g.solver.logger.Info("Removed stale challenge record",
+				zap.String("server", server),
+				zap.String("fqdn", fqdn),
+				dns.ChallengeValue(value),
+			)
+		}
+	}
+}
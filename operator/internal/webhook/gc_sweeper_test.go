@@ -0,0 +1,217 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	rfc2136 "github.com/rieset/istio-dns01-bind9/internal/dns"
+	"github.com/rieset/istio-dns01-bind9/internal/dnstest"
+	"github.com/rieset/istio-dns01-bind9/internal/solverconfig"
+)
+
+func TestStaleSweeperRemovesOldChallenges(t *testing.T) {
+	servers := startServers(t, 2)
+	s := newTestSolver(t)
+	for _, srv := range servers {
+		srv.SetTXT("_acme-challenge.old.example.com.", 60, "stale")
+		srv.SetTXT("keep.example.com.", 60, "not-a-challenge")
+	}
+
+	refs := []solverReference{{
+		Issuer:    "cert-manager/letsencrypt",
+		Namespace: "cert-manager",
+		Config: &Config{
+			Provider:       solverconfig.ProviderRFC2136,
+			Servers:        serverAddrs(servers),
+			Zone:           "example.com",
+			TSIGKeyName:    dnstest.TestKeyName,
+			TSIGAlgorithm:  "hmac-sha256",
+			TSIGSecretName: "tsig",
+			TSIGSecretKey:  "secret",
+		},
+	}}
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	g := newStaleSweeper(s, time.Hour)
+	g.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	g.sweep(ctx, refs)
+	for _, srv := range servers {
+		if got := srv.TXT("_acme-challenge.old.example.com."); len(got) != 1 {
+			t.Fatalf("server %s lost a challenge record on first sight: %v", srv.Addr(), got)
+		}
+	}
+
+	// A value added later stays until it is old as well, while the old one goes
+	now = now.Add(30 * time.Minute)
+	servers[0].SetTXT("_acme-challenge.old.example.com.", 60, "stale", "fresh")
+	g.sweep(ctx, refs)
+
+	now = now.Add(45 * time.Minute)
+	g.sweep(ctx, refs)
+	if got := servers[0].TXT("_acme-challenge.old.example.com."); !reflect.DeepEqual(got, []string{"fresh"}) {
+		t.Fatalf("RRset with a fresh value has %v, want only the fresh value", got)
+	}
+	if got := servers[1].TXT("_acme-challenge.old.example.com."); len(got) != 0 {
+		t.Fatalf("stale RRset survived: %v", got)
+	}
+
+	now = now.Add(time.Hour)
+	g.sweep(ctx, refs)
+	for _, srv := range servers {
+		if got := srv.TXT("_acme-challenge.old.example.com."); len(got) != 0 {
+			t.Fatalf("server %s kept stale challenge %v", srv.Addr(), got)
+		}
+		if got := srv.TXT("keep.example.com."); len(got) != 1 {
+			t.Fatalf("server %s lost a non-challenge record: %v", srv.Addr(), got)
+		}
+	}
+	if len(g.firstSeen) != 0 {
+		t.Fatalf("first-seen entries of removed records were kept: %v", g.firstSeen)
+	}
+}
+
+func TestStaleSweeperKeepsValuesPresentedAfterTransfer(t *testing.T) {
+	servers := startServers(t, 1)
+	s := newTestSolver(t)
+	srv := servers[0]
+	const fqdn = "_acme-challenge.app.example.com."
+	srv.SetTXT(fqdn, 60, "stale")
+
+	refs := []solverReference{{
+		Issuer:    "cert-manager/letsencrypt",
+		Namespace: "cert-manager",
+		Config: &Config{
+			Provider:       solverconfig.ProviderRFC2136,
+			Servers:        serverAddrs(servers),
+			Zone:           "example.com",
+			TSIGKeyName:    dnstest.TestKeyName,
+			TSIGAlgorithm:  "hmac-sha256",
+			TSIGSecretName: "tsig",
+			TSIGSecretKey:  "secret",
+		},
+	}}
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	g := newStaleSweeper(s, time.Hour)
+	g.now = func() time.Time { return now }
+	ctx := context.Background()
+	g.sweep(ctx, refs)
+
+	// Another replica presents a value once the transfer of the next sweep was taken
+	srv.SetTransferHook(func() {
+		srv.SetTransferHook(nil)
+		srv.SetTXT(fqdn, 60, "stale", "presented")
+	})
+	now = now.Add(2 * time.Hour)
+	g.sweep(ctx, refs)
+	if got := srv.TXT(fqdn); !reflect.DeepEqual(got, []string{"presented"}) {
+		t.Fatalf("%s has TXT %v after the sweep, want only the value presented after the transfer", fqdn, got)
+	}
+}
+
+func TestStaleSweeperKeepsActiveChallenges(t *testing.T) {
+	servers := startServers(t, 1)
+	s := newTestSolver(t)
+	srv := servers[0]
+	srv.SetTXT("_acme-challenge.pending.example.com.", 60, "pending-key")
+	srv.SetTXT("_acme-challenge.presented.example.com.", 60, "presented-key")
+	srv.SetTXT("_acme-challenge.orphan.example.com.", 60, "orphan-key")
+
+	refs := []solverReference{{
+		Issuer:    "cert-manager/letsencrypt",
+		Namespace: "cert-manager",
+		Config: &Config{
+			Provider:       solverconfig.ProviderRFC2136,
+			Servers:        serverAddrs(servers),
+			Zone:           "example.com",
+			TSIGKeyName:    dnstest.TestKeyName,
+			TSIGAlgorithm:  "hmac-sha256",
+			TSIGSecretName: "tsig",
+			TSIGSecretKey:  "secret",
+		},
+	}}
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	g := newStaleSweeper(s, time.Hour)
+	g.now = func() time.Time { return now }
+	// A Challenge resource still exists for one value, this instance presented another
+	g.challengeKeys = map[string]bool{"pending-key": true}
+	s.active.present("_acme-challenge.presented.example.com.", "presented-key", "")
+
+	g.sweep(context.Background(), refs)
+	now = now.Add(2 * time.Hour)
+	g.sweep(context.Background(), refs)
+
+	for fqdn, want := range map[string]int{
+		"_acme-challenge.pending.example.com.":   1,
+		"_acme-challenge.presented.example.com.": 1,
+		"_acme-challenge.orphan.example.com.":    0,
+	} {
+		if got := srv.TXT(fqdn); len(got) != want {
+			t.Fatalf("%s has TXT %v, want %d values", fqdn, got, want)
+		}
+	}
+}
+
+func TestStaleSweeperKeepsRecordsOfOtherOwners(t *testing.T) {
+	servers := startServers(t, 1)
+	s := newTestSolver(t)
+	s.opts.RegistryOwnerID = "cluster-a"
+	srv := servers[0]
+	config := &Config{
+		Provider:       solverconfig.ProviderRFC2136,
+		Servers:        serverAddrs(servers),
+		Zone:           "example.com",
+		TSIGKeyName:    dnstest.TestKeyName,
+		TSIGAlgorithm:  "hmac-sha256",
+		TSIGSecretName: "tsig",
+		TSIGSecretKey:  "secret",
+	}
+	refs := []solverReference{{Issuer: "cert-manager/letsencrypt", Namespace: "cert-manager", Config: config}}
+
+	provider, err := s.newZoneProvider(context.Background(), "cert-manager", config, nil)
+	if err != nil {
+		t.Fatalf("newZoneProvider: %v", err)
+	}
+	if err := provider.AddTXTRecord(context.Background(), "_acme-challenge.owned.example.com.", "owned-key", 60); err != nil {
+		t.Fatalf("AddTXTRecord: %v", err)
+	}
+	srv.SetTXT("_acme-challenge.foreign.example.com.", 60, "foreign-key")
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	g := newStaleSweeper(s, time.Hour)
+	g.now = func() time.Time { return now }
+	g.sweep(context.Background(), refs)
+	now = now.Add(2 * time.Hour)
+	g.sweep(context.Background(), refs)
+
+	for fqdn, want := range map[string]int{
+		"_acme-challenge.owned.example.com.":                       0,
+		rfc2136.RegistryName("_acme-challenge.owned.example.com."): 0,
+		"_acme-challenge.foreign.example.com.":                     1,
+	} {
+		if got := srv.TXT(fqdn); len(got) != want {
+			t.Fatalf("%s has TXT %v, want %d values", fqdn, got, want)
+		}
+	}
+}
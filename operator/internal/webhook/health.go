@@ -0,0 +1,253 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	mdns "github.com/miekg/dns"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.uber.org/zap"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/rieset/istio-dns01-bind9/internal/dns"
+)
+
+// FunctionRating: 78/100
+// - Complexity: MEDIUM
+// - Integrations: 3 (dns package, net/http, metrics)
+// - External Risks: LOW (read-only SOA queries, bounded by a timeout and cached)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: healthChecker
+// Purpose: Liveness and readiness endpoints, readiness probing the zones on their DNS servers
+
+// healthTarget is a zone probed on one of its servers
+type healthTarget struct {
+	server string
+	zone   string
+}
+
+// String renders the target as it is configured
+func (t healthTarget) String() string {
+	return t.server + "/" + strings.TrimSuffix(t.zone, ".")
+}
+
+// parseHealthTargets parses "server/zone" entries; servers take the same
+// forms as in solver configs
+func parseHealthTargets(entries []string) ([]healthTarget, error) {
+	var targets []healthTarget
+	for _, entry := range entries {
+		server, zone, ok := strings.Cut(entry, "/")
+		if !ok || server == "" || zone == "" {
+			return nil, fmt.Errorf("invalid health check target %q: want server/zone", entry)
+		}
+		if _, _, err := dns.ParseServerAddress(server); err != nil {
+			return nil, fmt.Errorf("invalid health check target %q: %w", entry, err)
+		}
+		zone = mdns.Fqdn(strings.ToLower(zone))
+		if _, ok := mdns.IsDomainName(zone); !ok {
+			return nil, fmt.Errorf("invalid health check target %q: invalid zone", entry)
+		}
+		targets = append(targets, healthTarget{server: server, zone: zone})
+	}
+	return targets, nil
+}
+
+// healthResult is the outcome of the last probe of a target
+type healthResult struct {
+	target healthTarget
+	err    error
+}
+
+// healthChecker reports the instance ready while every configured zone
+// answers SOA queries on at least one of its servers. Probe results are
+// reused for ttl so frequent kubelet probes do not load the servers.
+type healthChecker struct {
+	targets []healthTarget
+	ttl     time.Duration
+	timeout time.Duration
+	query   func(ctx context.Context, server, zone string, timeout time.Duration) (*mdns.SOA, error)
+	logger  *zap.Logger
+	// draining, when set and true, fails readiness so no new challenges are routed here
+	draining func() bool
+
+	// probeMu serializes probes so concurrent requests share one round
+	probeMu sync.Mutex
+	mu      sync.Mutex
+	results []healthResult
+	checked time.Time
+}
+
+// newHealthChecker creates a checker of targets probing with dns.QuerySOA
+func newHealthChecker(targets []healthTarget, ttl, timeout time.Duration, logger *zap.Logger) *healthChecker {
+	return &healthChecker{
+		targets: targets,
+		ttl:     ttl,
+		timeout: timeout,
+		query:   dns.QuerySOA,
+		logger:  logger,
+	}
+}
+
+// check returns the results of the last probe, probing again when they are
+// older than ttl
+func (h *healthChecker) check(ctx context.Context) []healthResult {
+	if results, ok := h.cached(); ok {
+		return results
+	}
+	h.probeMu.Lock()
+	defer h.probeMu.Unlock()
+	// Another request may have probed while this one waited
+	if results, ok := h.cached(); ok {
+		return results
+	}
+
+	results := make([]healthResult, len(h.targets))
+	var wg sync.WaitGroup
+	for i, target := range h.targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = healthResult{target: target, err: h.probe(ctx, target)}
+		}()
+	}
+	wg.Wait()
+
+	h.mu.Lock()
+	h.results, h.checked = results, time.Now()
+	h.mu.Unlock()
+	return results
+}
+
+// cached returns the results of the last probe while they are fresh
+func (h *healthChecker) cached() ([]healthResult, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.results, !h.checked.IsZero() && time.Since(h.checked) < h.ttl
+}
+
+// probe queries the SOA of the target's zone and requires an answer from the zone itself
+func (h *healthChecker) probe(ctx context.Context, target healthTarget) error {
+	soa, err := h.query(ctx, target.server, target.zone, h.timeout)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(soa.Hdr.Name, target.zone) {
+		return fmt.Errorf("server %s does not serve zone %s", target.server, target.zone)
+	}
+	return nil
+}
+
+// ready reports whether every zone of results is reachable on at least one
+// server, and the zones that are not
+func ready(results []healthResult) (bool, []string) {
+	reachable := map[string]bool{}
+	for _, result := range results {
+		reachable[result.target.zone] = reachable[result.target.zone] || result.err == nil
+	}
+	var down []string
+	for zone, ok := range reachable {
+		if !ok {
+			down = append(down, strings.TrimSuffix(zone, "."))
+		}
+	}
+	slices.Sort(down)
+	return len(down) == 0, down
+}
+
+// ServeReadyz answers 200 while the instance can reach its zones and 503
+// otherwise or while draining, listing each target with ?verbose like the
+// Kubernetes endpoints
+func (h *healthChecker) ServeReadyz(w http.ResponseWriter, r *http.Request) {
+	if h.draining != nil && h.draining() {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte("readyz check failed: shutting down\n"))
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), h.timeout+time.Second)
+	defer cancel()
+	results := h.check(ctx)
+	ok, down := ready(results)
+
+	var b strings.Builder
+	_, verbose := r.URL.Query()["verbose"]
+	if verbose || !ok {
+		for _, result := range results {
+			if result.err != nil {
+				fmt.Fprintf(&b, "[-]%s failed: %v\n", result.target, result.err)
+			} else {
+				fmt.Fprintf(&b, "[+]%s ok\n", result.target)
+			}
+		}
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if !ok {
+		h.logger.Warn("Readiness check failed", zap.Strings("zones", down))
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintf(&b, "readyz check failed: unreachable zones %s\n", strings.Join(down, ", "))
+	} else {
+		b.WriteString("ok\n")
+	}
+	_, _ = w.Write([]byte(b.String()))
+}
+
+// newHealthMux serves /livez and /healthz, which only report that the
+// process answers, /readyz backed by checker and the webhook metrics on
+// /metrics. Liveness never depends on DNS: restarting the webhook does not
+// bring an unreachable server back.
+func newHealthMux(checker *healthChecker) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/livez", HealthCheckHandler)
+	mux.HandleFunc("/healthz", HealthCheckHandler)
+	mux.HandleFunc("/readyz", checker.ServeReadyz)
+	mux.Handle("/metrics", promhttp.HandlerFor(ctrlmetrics.Registry, promhttp.HandlerOpts{}))
+	return mux
+}
+
+// serveHealth serves the health endpoints on opts.HealthAddr until the
+// process exits; readiness fails once draining reports true
+func serveHealth(opts Options, draining func() bool, logger *zap.Logger) error {
+	entries, err := parseHealthTargets(opts.HealthTargets)
+	if err != nil {
+		return err
+	}
+	checker := newHealthChecker(entries, opts.HealthCacheTTL, opts.HealthTimeout, logger)
+	checker.draining = draining
+	server := &http.Server{
+		Addr:              opts.HealthAddr,
+		Handler:           newHealthMux(checker),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	logger.Info("Serving health endpoints",
+		zap.String("address", opts.HealthAddr),
+		zap.Int("targets", len(entries)),
+	)
+	return server.ListenAndServe()
+}
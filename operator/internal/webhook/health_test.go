@@ -0,0 +1,108 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"go.uber.org/zap"
+
+	"github.com/rieset/istio-dns01-bind9/internal/dnstest"
+)
+
+func TestParseHealthTargets(t *testing.T) {
+	targets, err := parseHealthTargets([]string{"10.0.0.1:5353/Example.com", "ns1.example.net/example.org.", "[2001:db8::1]:5353/example.net"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []healthTarget{{"10.0.0.1:5353", "example.com."}, {"ns1.example.net", "example.org."}, {"[2001:db8::1]:5353", "example.net."}}
+	if !slices.Equal(targets, want) {
+		t.Fatalf("parseHealthTargets = %v, want %v", targets, want)
+	}
+	for _, entry := range []string{"10.0.0.1", "/example.com", "10.0.0.1/", "10.0.0.1:0/example.com"} {
+		if _, err := parseHealthTargets([]string{entry}); err == nil {
+			t.Errorf("parseHealthTargets(%q) succeeded", entry)
+		}
+	}
+}
+
+func TestReadyz(t *testing.T) {
+	start := func(zone string) *dnstest.Server {
+		srv := dnstest.NewServer(zone)
+		if err := srv.Start(); err != nil {
+			t.Fatalf("failed to start test server: %v", err)
+		}
+		t.Cleanup(func() { _ = srv.Close() })
+		return srv
+	}
+	primary, secondary, other := start("example.com"), start("example.com"), start("example.org")
+
+	checker := newHealthChecker([]healthTarget{
+		{primary.Addr(), "example.com."},
+		{secondary.Addr(), "example.com."},
+		{other.Addr(), "example.org."},
+	}, time.Hour, time.Second, zap.NewNop())
+	mux := newHealthMux(checker)
+	get := func(path string) (int, string) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.Code, rec.Body.String()
+	}
+
+	// One failing server of a zone keeps the instance ready
+	secondary.SetQueryRcode(dns.RcodeServerFailure)
+	if code, body := get("/readyz?verbose"); code != http.StatusOK || !strings.Contains(body, "[-]"+secondary.Addr()) {
+		t.Fatalf("/readyz = %d %q, want 200 listing the failed server", code, body)
+	}
+
+	// Results are reused until they expire
+	other.SetQueryRcode(dns.RcodeServerFailure)
+	queries := other.Queries()
+	if code, _ := get("/readyz"); code != http.StatusOK || other.Queries() != queries {
+		t.Fatalf("/readyz = %d after %d queries, want the cached result", code, other.Queries()-queries)
+	}
+
+	checker.ttl = 0
+	code, body := get("/readyz")
+	if code != http.StatusServiceUnavailable || !strings.Contains(body, "unreachable zones example.org") {
+		t.Fatalf("/readyz = %d %q, want 503 naming example.org", code, body)
+	}
+	if code, _ := get("/livez"); code != http.StatusOK {
+		t.Fatalf("/livez = %d, want 200 regardless of DNS", code)
+	}
+}
+
+func TestReadyzRequiresZoneApex(t *testing.T) {
+	srv := dnstest.NewServer("example.com")
+	if err := srv.Start(); err != nil {
+		t.Fatalf("failed to start test server: %v", err)
+	}
+	t.Cleanup(func() { _ = srv.Close() })
+
+	// A server answering with the SOA of a parent zone does not serve the child
+	checker := newHealthChecker([]healthTarget{{srv.Addr(), "sub.example.com."}}, 0, time.Second, zap.NewNop())
+	results := checker.check(t.Context())
+	if ok, down := ready(results); ok || len(down) != 1 || down[0] != "sub.example.com" {
+		t.Fatalf("ready = %v %v, want sub.example.com down", ok, down)
+	}
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/rieset/istio-dns01-bind9/internal/dns"
+)
+
+// FunctionRating: 84/100
+// - Complexity: LOW
+// - Integrations: 1 (zap)
+// - External Risks: LOW (logging only)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: NewLogger
+// Purpose: Builds the process logger from the logging options and sets how challenge keys are logged
+
+// NewLogger builds the logger of the webhook from the logging options of
+// opts. Challenge keys and TXT values are logged as digests unless
+// LogChallengeKeys is set; secret material is never logged.
+func NewLogger(opts Options) (*zap.Logger, error) {
+	level, err := zapcore.ParseLevel(opts.LogLevel)
+	if err != nil {
+		return nil, fmt.Errorf("invalid log level %q: %w", opts.LogLevel, err)
+	}
+	config := zap.NewProductionConfig()
+	if opts.LogEncoding == "console" {
+		config.EncoderConfig = zap.NewDevelopmentEncoderConfig()
+	}
+	config.Encoding = opts.LogEncoding
+	config.Level = zap.NewAtomicLevelAt(level)
+	if !opts.LogSampling {
+		config.Sampling = nil
+	}
+	logger, err := config.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	dns.SetLogChallengeValues(opts.LogChallengeKeys)
+	if opts.LogChallengeKeys {
+		logger.Warn("Challenge keys are logged in full, do not use this outside of debugging",
+			zap.String("env", EnvLogChallengeKeys))
+	}
+	return logger, nil
+}
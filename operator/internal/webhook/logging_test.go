@@ -0,0 +1,68 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/rieset/istio-dns01-bind9/internal/dns"
+)
+
+func TestNewLogger(t *testing.T) {
+	defer dns.SetLogChallengeValues(false)
+	tests := []struct {
+		name      string
+		modify    func(*Options)
+		wantDebug bool
+		wantInfo  bool
+		wantFull  bool
+		wantErr   string
+	}{
+		{"defaults", func(*Options) {}, false, true, false, ""},
+		{"debug console", func(o *Options) { o.LogLevel, o.LogEncoding = "debug", "console" }, true, true, false, ""},
+		{"warn without sampling", func(o *Options) { o.LogLevel, o.LogSampling = "warn", false }, false, false, false, ""},
+		{"full challenge keys", func(o *Options) { o.LogChallengeKeys = true }, false, true, true, ""},
+		{"unknown level", func(o *Options) { o.LogLevel = "verbose" }, false, false, false, `invalid log level "verbose"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			tt.modify(&opts)
+			logger, err := NewLogger(opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("NewLogger error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewLogger: %v", err)
+			}
+			core := logger.Core()
+			if core.Enabled(zap.DebugLevel) != tt.wantDebug || core.Enabled(zap.InfoLevel) != tt.wantInfo {
+				t.Fatalf("debug, info enabled = %v, %v, want %v, %v",
+					core.Enabled(zap.DebugLevel), core.Enabled(zap.InfoLevel), tt.wantDebug, tt.wantInfo)
+			}
+			if full := dns.RedactValue("token") == "token"; full != tt.wantFull {
+				t.Fatalf("challenge keys logged in full = %v, want %v", full, tt.wantFull)
+			}
+		})
+	}
+}
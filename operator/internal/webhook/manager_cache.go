@@ -0,0 +1,155 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/rieset/istio-dns01-bind9/internal/dns"
+	"github.com/rieset/istio-dns01-bind9/internal/metrics"
+	"github.com/rieset/istio-dns01-bind9/internal/solverconfig"
+)
+
+// FunctionRating: 80/100
+// - Complexity: MEDIUM
+// - Integrations: 2 (Secret cache, metrics)
+// - External Risks: LOW (falls back to building a manager per operation)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: managerCache
+// Purpose: Keeps the RFC2136 managers of namespaces and solver configs across challenges until one of their Secrets changes
+
+// defaultManagerCacheSize is the number of managers kept by default
+const defaultManagerCacheSize = 256
+
+// managerCache keeps the RFC2136 managers built for a namespace and solver
+// config, so the challenges of an Issuer reuse one manager and its clients
+// instead of resolving credentials and building them on every operation.
+// A manager is dropped once one of the Secrets it was built from changed;
+// beyond size managers the least recently used one is dropped.
+type managerCache struct {
+	size int
+
+	mu      sync.Mutex
+	entries map[string]*cachedManager
+}
+
+// cachedManager is a manager with the versions of the Secrets it was built from
+type cachedManager struct {
+	manager *MultiServerDNS
+	// secrets maps the namespace/name key of each Secret to its resource version
+	secrets map[string]string
+	used    time.Time
+}
+
+// newManagerCache returns a cache of up to size managers, or nil for a size of zero
+func newManagerCache(size int) *managerCache {
+	if size <= 0 {
+		return nil
+	}
+	return &managerCache{size: size, entries: map[string]*cachedManager{}}
+}
+
+// get returns the manager of key if current reports its Secrets unchanged
+func (c *managerCache) get(key string, current func(secrets map[string]string) bool) *MultiServerDNS {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	if !current(entry.secrets) {
+		delete(c.entries, key)
+		return nil
+	}
+	entry.used = time.Now()
+	return entry.manager
+}
+
+// put keeps manager as the one of key, built from the Secrets at the versions of secrets
+func (c *managerCache) put(key string, secrets map[string]string, manager *MultiServerDNS) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= c.size {
+		var oldest string
+		for k, entry := range c.entries {
+			if oldest == "" || entry.used.Before(c.entries[oldest].used) {
+				oldest = k
+			}
+		}
+		delete(c.entries, oldest)
+	}
+	c.entries[key] = &cachedManager{manager: manager, secrets: secrets, used: time.Now()}
+}
+
+// managerKey returns the key the manager of config for a challenge in
+// namespace is cached under. Only RFC2136 zones whose Secrets the informers
+// watch can be cached, since changes elsewhere would go unnoticed.
+func (s *DNS01Solver) managerKey(namespace string, config *Config) (string, bool) {
+	if s.managers == nil || s.secrets == nil || config.Provider != solverconfig.ProviderRFC2136 ||
+		config.TSIGSecretProvider() != solverconfig.SecretProviderKubernetes ||
+		!s.secrets.tracks(config.SecretsNamespace(namespace)) {
+		return "", false
+	}
+	raw, err := json.Marshal(config)
+	if err != nil {
+		return "", false
+	}
+	return namespace + "\n" + string(raw), true
+}
+
+// sharedZoneProvider returns the zone provider of config like
+// newZoneProvider, reporting to onServer and onLagging. RFC2136 managers
+// come from the manager cache where possible, as a view of their own on the
+// shared manager.
+func (s *DNS01Solver) sharedZoneProvider(ctx context.Context, namespace string, config *Config,
+	onServer, onLagging func(server string)) (dns.Provider, error) {
+	key, ok := s.managerKey(namespace, config)
+	if !ok {
+		provider, err := s.newZoneProvider(ctx, namespace, config, onServer)
+		if manager, ok := provider.(*MultiServerDNS); ok && onLagging != nil {
+			manager.SetLaggingCallback(onLagging)
+		}
+		return provider, err
+	}
+
+	if manager := s.managers.get(key, s.secrets.current); manager != nil {
+		metrics.ManagerCacheLookups.WithLabelValues("hit").Inc()
+		return manager.withCallbacks(onServer, onLagging), nil
+	}
+	metrics.ManagerCacheLookups.WithLabelValues("miss").Inc()
+	// The versions read are the ones built from, so a Secret changing
+	// meanwhile invalidates the result
+	reads := &secretReads{}
+	provider, err := s.newZoneProvider(withSecretReads(ctx, reads), namespace, config, nil)
+	if err != nil {
+		return nil, err
+	}
+	manager, ok := provider.(*MultiServerDNS)
+	if !ok {
+		return provider, nil
+	}
+	manager.KeepClients()
+	s.managers.put(key, reads.versions, manager)
+	return manager.withCallbacks(onServer, onLagging), nil
+}
@@ -0,0 +1,225 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/rieset/istio-dns01-bind9/internal/dnstest"
+)
+
+// newCachingSolver returns a test solver whose Secret informers have synced,
+// so it keeps up to size managers. Its Secrets carry resource versions, as
+// the API server sets them.
+func newCachingSolver(tb testing.TB, size int) *DNS01Solver {
+	tb.Helper()
+	s := newTestSolver(tb)
+	s.managers = newManagerCache(size)
+	for _, name := range []string{"tsig", "pdns", "aws"} {
+		setSecretVersion(tb, s, name, "1")
+	}
+	stopCh := make(chan struct{})
+	tb.Cleanup(func() { close(stopCh) })
+	s.secrets.Start(stopCh)
+	waitFor(tb, s.secrets.synced)
+	return s
+}
+
+// setSecretVersion updates the Secret name of cert-manager to version
+func setSecretVersion(tb testing.TB, s *DNS01Solver, name, version string) {
+	tb.Helper()
+	secrets := s.client.CoreV1().Secrets("cert-manager")
+	secret, err := secrets.Get(context.Background(), name, metav1.GetOptions{})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	secret.ResourceVersion = version
+	if _, err := secrets.Update(context.Background(), secret, metav1.UpdateOptions{}); err != nil {
+		tb.Fatal(err)
+	}
+}
+
+// waitForSecretVersion waits until the informers see the Secret name of cert-manager at version
+func waitForSecretVersion(tb testing.TB, s *DNS01Solver, name, version string) {
+	tb.Helper()
+	waitFor(tb, func() bool { return s.secrets.current(map[string]string{"cert-manager/" + name: version}) })
+}
+
+// testZoneConfig returns the config of example.com on servers signed with the test TSIG key
+func testZoneConfig(servers []string) *Config {
+	return &Config{
+		Provider:       "rfc2136",
+		Servers:        servers,
+		Zone:           "example.com",
+		TSIGKeyName:    dnstest.TestKeyName,
+		TSIGAlgorithm:  "hmac-sha256",
+		TSIGSecretName: "tsig",
+		TSIGSecretKey:  "secret",
+	}
+}
+
+func TestManagerCache(t *testing.T) {
+	if newManagerCache(0) != nil {
+		t.Fatal("a cache of size zero is not disabled")
+	}
+	c := newManagerCache(2)
+	a, b, d := &MultiServerDNS{}, &MultiServerDNS{}, &MultiServerDNS{}
+	secrets := map[string]string{"cert-manager/tsig": "1"}
+	current := func(map[string]string) bool { return true }
+	c.put("a", secrets, a)
+	c.put("b", secrets, b)
+	if got := c.get("a", current); got != a {
+		t.Fatalf("get(a) = %p, want %p", got, a)
+	}
+	// b is the least recently used entry
+	time.Sleep(time.Millisecond)
+	c.get("a", current)
+	c.put("d", secrets, d)
+	if c.get("b", current) != nil || c.get("d", current) != d {
+		t.Fatal("the least recently used manager was not dropped")
+	}
+	var checked map[string]string
+	if c.get("a", func(s map[string]string) bool { checked = s; return false }) != nil || c.get("a", current) != nil {
+		t.Fatal("a manager built from an outdated Secret was served")
+	}
+	if !reflect.DeepEqual(checked, secrets) {
+		t.Fatalf("checked Secrets %v, want %v", checked, secrets)
+	}
+}
+
+func TestSolverManagerCache(t *testing.T) {
+	servers := startServers(t, 2)
+	s := newCachingSolver(t, 4)
+	config := testZoneConfig(serverAddrs(servers))
+
+	var reported []string
+	first, err := s.newDNSManager(context.Background(), "cert-manager", config, func(server string) { reported = append(reported, server) }, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := s.newDNSManager(context.Background(), "cert-manager", config, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m1, m2 := first.(*MultiServerDNS), second.(*MultiServerDNS)
+	if m1 == m2 || m1.kept == nil || m1.kept != m2.kept {
+		t.Fatal("operations on one config do not share the kept clients")
+	}
+	if err := m1.AddTXTRecord(context.Background(), testFQDN, "token", 60); err != nil {
+		t.Fatal(err)
+	}
+	if err := m2.AddTXTRecord(context.Background(), testFQDN, "token2", 60); err != nil {
+		t.Fatal(err)
+	}
+	want := slices.Sorted(slices.Values(serverAddrs(servers)))
+	slices.Sort(reported)
+	if !slices.Equal(reported, want) {
+		t.Fatalf("callbacks of the first operation saw %v, want only its own servers %v", reported, want)
+	}
+	if len(m1.kept.clients) != 2 {
+		t.Fatalf("kept %d clients, want one per server", len(m1.kept.clients))
+	}
+
+	// Concurrent challenges share the kept clients
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			provider, err := s.newDNSManager(context.Background(), "cert-manager", config, nil, nil)
+			if err == nil {
+				err = provider.AddTXTRecord(context.Background(), testFQDN, fmt.Sprintf("token-%d", i), 60)
+			}
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	other, err := s.newDNSManager(context.Background(), "cert-manager", testZoneConfig(serverAddrs(servers[:1])), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.(*MultiServerDNS).kept == m1.kept {
+		t.Fatal("a manager was shared across configs")
+	}
+
+	// Secrets written by issuances leave the managers of other Secrets alone
+	setSecretVersion(t, s, "pdns", "2")
+	waitForSecretVersion(t, s, "pdns", "2")
+	kept, err := s.newDNSManager(context.Background(), "cert-manager", config, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kept.(*MultiServerDNS).kept != m1.kept {
+		t.Fatal("a change of an unrelated Secret dropped a manager")
+	}
+
+	// A changed Secret invalidates the managers built from it
+	setSecretVersion(t, s, "tsig", "2")
+	waitForSecretVersion(t, s, "tsig", "2")
+	rebuilt, err := s.newDNSManager(context.Background(), "cert-manager", config, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rebuilt.(*MultiServerDNS).kept == m1.kept {
+		t.Fatal("a manager outlived a change of its Secret")
+	}
+}
+
+// BenchmarkPresentPath measures the provider build and the TXT add of a
+// Present against three servers, reporting the p99 latency alongside
+func BenchmarkPresentPath(b *testing.B) {
+	servers := dnstest.StartServers(b, 3)
+	for _, size := range []int{0, defaultManagerCacheSize} {
+		name := "fresh"
+		if size > 0 {
+			name = "cached"
+		}
+		b.Run(name, func(b *testing.B) {
+			s := newCachingSolver(b, size)
+			config := testZoneConfig(serverAddrs(servers))
+			ctx := context.Background()
+			latencies := make([]time.Duration, 0, b.N)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for range b.N {
+				start := time.Now()
+				provider, err := s.newDNSManager(context.Background(), "cert-manager", config, nil, nil)
+				if err == nil {
+					err = provider.AddTXTRecord(ctx, testFQDN, "token", 60)
+				}
+				if err != nil {
+					b.Fatal(err)
+				}
+				latencies = append(latencies, time.Since(start))
+			}
+			b.StopTimer()
+			slices.Sort(latencies)
+			b.ReportMetric(float64(latencies[len(latencies)*99/100].Nanoseconds()), "p99-ns/op")
+		})
+	}
+}
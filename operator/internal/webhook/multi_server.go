@@ -18,10 +18,18 @@ package webhook
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/rieset/istio-dns01-bind9/internal/dns"
+	"github.com/rieset/istio-dns01-bind9/internal/metrics"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
@@ -29,7 +37,7 @@ import (
 // - Complexity: MEDIUM
 // - Integrations: 1 (dns package)
 // - External Risks: MEDIUM (multiple network operations)
-// - Unit Tests: NO
+// - Unit Tests: YES
 // - E2E Tests: NO
 // - Typing: FULL
 // - Critical Issues: NONE
@@ -39,20 +47,131 @@ import (
 
 // MultiServerDNS handles DNS updates on multiple servers
 type MultiServerDNS struct {
-	servers  []string
-	zone     string
-	tsigKey  string
-	tsigAlg  string
-	tsigSec  string
-	logger   *zap.Logger
+	servers    []string
+	zone       string
+	tsigKey    string
+	tsigAlg    string
+	tsigSec    string
+	logger     *zap.Logger
 	minSuccess int // Minimum number of successful updates required
+	quirks     map[string]dns.Quirks
+	transport  dns.Transport
+	retry      dns.RetryPolicy
+	prereqs    dns.Prerequisites
+	// sig0 signs the updates of every server instead of its TSIG key
+	sig0       *dns.SIG0Signer
+	tlsConfigs map[string]*tls.Config
+	// credentials replaces the zone and TSIG key for individual servers
+	credentials map[string]ServerCredentials
+	onServer    func(server string)
+	// rollback removes the record from the servers that applied an add
+	// when the add as a whole fails
+	rollback bool
+	// onLagging is called with the servers that failed an add that met the quorum
+	onLagging func(server string)
+	// serverTimeout bounds the work on one server, retries included; zero leaves it to ctx
+	serverTimeout time.Duration
+	// cancelOnQuorum stops the adds still running once minSuccess servers applied one
+	cancelOnQuorum bool
+	// returnOnQuorum returns from an add once minSuccess servers applied it and
+	// lets the others finish in the background
+	returnOnQuorum bool
+	// conns is the connection pool shared by the clients of every server
+	conns *dns.ConnPool
+	// limiter paces the updates of every server, shared across challenges
+	limiter *dns.UpdateLimiter
+	// dryRun receives the updates of every server instead of the server when set
+	dryRun *dns.DryRunRecorder
+	// apiClients replaces the RFC2136 client of servers updated through another backend
+	apiClients map[string]serverClient
+	// groups must each reach their own quorum besides minSuccess
+	groups []ServerGroup
+	// proxies tunnels the exchanges with individual servers
+	proxies map[string]*dns.Proxy
+	// ownership is handed to the RFC2136 client of every server
+	ownership dns.Ownership
+	// timeouts replaces the exchange timeouts of individual servers
+	timeouts map[string]dns.Timeouts
+	// fallbacks are the TSIG keys tried when a server rejects its own, with
+	// keyCache remembering the key each server accepted
+	fallbacks []dns.TSIGCredential
+	keyCache  *dns.TSIGKeyCache
+	// kept holds the RFC2136 clients reused across operations, see KeepClients
+	kept *keptClients
 }
 
-// NewMultiServerDNS creates a new multi-server DNS manager
-func NewMultiServerDNS(servers []string, zone, tsigKey, tsigAlg, tsigSec string, logger *zap.Logger) *MultiServerDNS {
-	minSuccess := len(servers)/2 + 1 // At least half + 1 must succeed
-	if minSuccess < 1 {
-		minSuccess = 1
+// keptClients holds the RFC2136 client of every server of a long-lived
+// manager, built on first use
+type keptClients struct {
+	mu      sync.Mutex
+	clients map[string]*dns.RFC2136Client
+}
+
+// ServerGroup is a set of servers, such as the primaries of one site, of
+// which MinSuccess must apply an add for it to succeed
+type ServerGroup struct {
+	Name       string
+	Servers    []string
+	MinSuccess int
+}
+
+// serverClient updates the zone of one server, through RFC2136 or an API
+type serverClient interface {
+	dns.Provider
+	dns.RecordProvider
+}
+
+// ServerCredentials is the zone and TSIG key one server is updated with
+type ServerCredentials struct {
+	Zone          string
+	TSIGKey       string
+	TSIGAlgorithm string
+	TSIGSecret    string
+}
+
+// QuorumError is returned when fewer than the required servers applied an
+// add, or when no server applied a delete
+type QuorumError struct {
+	Succeeded int
+	Total     int
+	Required  int
+	// Groups are the server groups that missed their own quorum
+	Groups []string
+	// Errors holds the failure of every server that did not apply the update
+	Errors []error
+}
+
+// Error implements error
+func (e *QuorumError) Error() string {
+	msg := fmt.Sprintf("only %d/%d servers updated successfully (minimum %d required): %v",
+		e.Succeeded, e.Total, e.Required, e.Errors)
+	if len(e.Groups) > 0 {
+		msg = fmt.Sprintf("server groups %s missed their quorum (%d/%d servers updated): %v",
+			strings.Join(e.Groups, ", "), e.Succeeded, e.Total, e.Errors)
+	}
+	if e.Permanent() {
+		msg += "; every failed server rejected the update, fix the zone or key configuration before retrying"
+	}
+	return msg
+}
+
+// Unwrap returns the failures of the servers
+func (e *QuorumError) Unwrap() []error {
+	return e.Errors
+}
+
+// Permanent reports whether every failed server rejected the update for a
+// reason no retry can fix
+func (e *QuorumError) Permanent() bool {
+	return dns.IsPermanent(e)
+}
+
+// NewMultiServerDNS creates a new multi-server DNS manager. An update succeeds
+// once minSuccess servers accepted it; zero or less requires a majority.
+func NewMultiServerDNS(servers []string, minSuccess int, zone, tsigKey, tsigAlg, tsigSec string,
+	logger *zap.Logger) *MultiServerDNS {
+	if minSuccess <= 0 {
+		minSuccess = len(servers)/2 + 1 // At least half + 1 must succeed
 	}
 	return &MultiServerDNS{
 		servers:    servers,
@@ -62,65 +181,430 @@ func NewMultiServerDNS(servers []string, zone, tsigKey, tsigAlg, tsigSec string,
 		tsigSec:    tsigSec,
 		logger:     logger,
 		minSuccess: minSuccess,
+		transport:  dns.TransportAuto,
 	}
 }
 
+// SetServerQuirks sets the compatibility quirks of individual servers.
+// Servers without an entry use dns.DefaultQuirks.
+func (m *MultiServerDNS) SetServerQuirks(quirks map[string]dns.Quirks) {
+	m.quirks = quirks
+}
+
+// SetTransport sets how updates reach every server
+func (m *MultiServerDNS) SetTransport(transport dns.Transport) {
+	m.transport = transport
+}
+
+// SetRetryPolicy sets how every server's client resends failed updates
+func (m *MultiServerDNS) SetRetryPolicy(policy dns.RetryPolicy) {
+	m.retry = policy
+}
+
+// SetPrerequisites sets the prerequisites every server's client guards updates with
+func (m *MultiServerDNS) SetPrerequisites(prereqs dns.Prerequisites) {
+	m.prereqs = prereqs
+}
+
+// SetDryRun makes every server's client log its updates to recorder instead of sending them
+func (m *MultiServerDNS) SetDryRun(recorder *dns.DryRunRecorder) {
+	m.dryRun = recorder
+}
+
+// SetSIG0Signer makes every server's client sign updates with SIG(0) instead of TSIG
+func (m *MultiServerDNS) SetSIG0Signer(signer *dns.SIG0Signer) {
+	m.sig0 = signer
+}
+
+// SetServerTLS sets the DNS-over-TLS settings of individual servers, used
+// with dns.TransportTLS. Servers without an entry are verified against the
+// system roots.
+func (m *MultiServerDNS) SetServerTLS(configs map[string]*tls.Config) {
+	m.tlsConfigs = configs
+}
+
+// SetServerCredentials sets the zone and TSIG key of individual servers.
+// Servers without an entry use the ones the manager was created with.
+func (m *MultiServerDNS) SetServerCredentials(credentials map[string]ServerCredentials) {
+	m.credentials = credentials
+}
+
+// SetServerClients sets the clients of servers updated through another
+// backend than RFC2136, such as the PowerDNS API
+func (m *MultiServerDNS) SetServerClients(clients map[string]serverClient) {
+	m.apiClients = clients
+}
+
+// SetServerGroups makes an add require the quorum of every group on top of
+// minSuccess, so each site of a replicated zone gets the record
+func (m *MultiServerDNS) SetServerGroups(groups []ServerGroup) {
+	m.groups = groups
+}
+
+// SetServerProxies makes the clients of individual servers reach them
+// through a proxy, for servers in another cluster or network
+func (m *MultiServerDNS) SetServerProxies(proxies map[string]*dns.Proxy) {
+	m.proxies = proxies
+}
+
+// SetServerTimeouts sets the dial, read and write timeouts of individual
+// servers. Servers without an entry keep the client default.
+func (m *MultiServerDNS) SetServerTimeouts(timeouts map[string]dns.Timeouts) {
+	m.timeouts = timeouts
+}
+
+// SetTSIGFallbacks sets the TSIG keys every server's RFC2136 client tries,
+// in order, when the server rejects its own key, see
+// dns.RFC2136Client.SetTSIGFallbacks
+func (m *MultiServerDNS) SetTSIGFallbacks(keys []dns.TSIGCredential, cache *dns.TSIGKeyCache) {
+	m.fallbacks = keys
+	m.keyCache = cache
+}
+
+// SetOwnership makes every server's RFC2136 client keep the ownership
+// registry of ownership, see dns.RFC2136Client.SetOwnership
+func (m *MultiServerDNS) SetOwnership(ownership dns.Ownership) {
+	m.ownership = ownership
+}
+
+// SetRollback makes a failed AddTXTRecord remove the record again from the
+// servers that did apply it, so a retry starts from a consistent state
+func (m *MultiServerDNS) SetRollback(enabled bool) {
+	m.rollback = enabled
+}
+
+// SetLaggingCallback sets a function called with each server that failed an
+// AddTXTRecord which still met the quorum, so the caller can retry it later.
+// Servers that rejected the update with a permanent error are not reported:
+// retrying them cannot succeed until their configuration changes.
+func (m *MultiServerDNS) SetLaggingCallback(fn func(server string)) {
+	m.onLagging = fn
+}
+
+// SetServerTimeout bounds the work on each server, retries included
+func (m *MultiServerDNS) SetServerTimeout(timeout time.Duration) {
+	m.serverTimeout = timeout
+}
+
+// SetCancelOnQuorum makes an add cancel the servers still updating once
+// minSuccess servers applied it. The cancelled servers count as lagging.
+func (m *MultiServerDNS) SetCancelOnQuorum(enabled bool) {
+	m.cancelOnQuorum = enabled
+}
+
+// SetReturnOnQuorum makes an add return as soon as minSuccess servers applied
+// it. The remaining servers finish in the background, bounded by the server
+// timeout and the deadline of the add; SetCancelOnQuorum takes precedence.
+func (m *MultiServerDNS) SetReturnOnQuorum(enabled bool) {
+	m.returnOnQuorum = enabled
+}
+
+// SetConnPool makes the clients of every server reuse the connections of pool
+func (m *MultiServerDNS) SetConnPool(pool *dns.ConnPool) {
+	m.conns = pool
+}
+
+// SetUpdateLimiter makes the clients of every server wait for limiter before each update
+func (m *MultiServerDNS) SetUpdateLimiter(limiter *dns.UpdateLimiter) {
+	m.limiter = limiter
+}
+
+// SetServerCallback sets a function called with each server that applied an update
+func (m *MultiServerDNS) SetServerCallback(fn func(server string)) {
+	m.onServer = fn
+}
+
+// KeepClients makes the manager build the RFC2136 client of each server
+// once and reuse it for every later operation, for managers that serve many
+// challenges. Settings changed afterwards do not reach the clients already built.
+func (m *MultiServerDNS) KeepClients() {
+	m.kept = &keptClients{clients: map[string]*dns.RFC2136Client{}}
+}
+
+// withCallbacks returns a copy of m that shares its clients and reports to
+// onServer and onLagging instead, so one operation's callbacks do not leak
+// into the others of a shared manager
+func (m *MultiServerDNS) withCallbacks(onServer, onLagging func(server string)) *MultiServerDNS {
+	view := *m
+	view.onServer, view.onLagging = onServer, onLagging
+	return &view
+}
+
+// serverZone returns the zone server is updated in
+func (m *MultiServerDNS) serverZone(server string) string {
+	if creds, ok := m.credentials[server]; ok {
+		return creds.Zone
+	}
+	return m.zone
+}
+
+// startServer bounds ctx by the server timeout and starts the child span of
+// op on server. The returned function ends both with the outcome of op.
+func (m *MultiServerDNS) startServer(ctx context.Context, op, server string) (context.Context, func(err error)) {
+	cancel := context.CancelFunc(func() {})
+	if m.serverTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, m.serverTimeout)
+	}
+	ctx, span := tracer.Start(ctx, "multi_server."+op, trace.WithAttributes(
+		attribute.String("dns.server", server),
+		attribute.String("dns.zone", m.serverZone(server)),
+	))
+	return ctx, func(err error) {
+		dns.EndSpan(span, err)
+		cancel()
+	}
+}
+
+// newClient returns the client server is updated through: its API client, or
+// its RFC2136 client
+func (m *MultiServerDNS) newClient(server string) serverClient {
+	if client, ok := m.apiClients[server]; ok {
+		return client
+	}
+	return m.rfc2136Client(server)
+}
+
+// rfc2136Client returns the kept RFC2136 client of server, or a new one
+// unless the manager keeps its clients
+func (m *MultiServerDNS) rfc2136Client(server string) *dns.RFC2136Client {
+	if m.kept == nil {
+		return m.newRFC2136Client(server)
+	}
+	m.kept.mu.Lock()
+	defer m.kept.mu.Unlock()
+	client, ok := m.kept.clients[server]
+	if !ok {
+		client = m.newRFC2136Client(server)
+		m.kept.clients[server] = client
+	}
+	return client
+}
+
+// newRFC2136Client creates the RFC2136 client for server with its credentials, signer, quirks, transport, retry policy,
+// prerequisites, connection pool, update limiter and ownership applied
+func (m *MultiServerDNS) newRFC2136Client(server string) *dns.RFC2136Client {
+	creds, ok := m.credentials[server]
+	if !ok {
+		creds = ServerCredentials{Zone: m.zone, TSIGKey: m.tsigKey, TSIGAlgorithm: m.tsigAlg, TSIGSecret: m.tsigSec}
+	}
+	client := dns.NewRFC2136Client(server, creds.Zone, creds.TSIGKey, creds.TSIGAlgorithm, creds.TSIGSecret, m.logger)
+	client.SetTransport(m.transport)
+	client.SetRetryPolicy(m.retry)
+	client.SetPrerequisites(m.prereqs)
+	client.SetConnPool(m.conns)
+	client.SetUpdateLimiter(m.limiter)
+	if m.dryRun != nil {
+		client.SetDryRun(m.dryRun)
+	}
+	if m.sig0 != nil {
+		client.SetSIG0Signer(m.sig0)
+	}
+	if config, ok := m.tlsConfigs[server]; ok {
+		client.SetTLSConfig(config)
+	}
+	if quirks, ok := m.quirks[server]; ok {
+		client.SetQuirks(quirks)
+	}
+	if proxy, ok := m.proxies[server]; ok {
+		client.SetProxy(proxy)
+	}
+	if timeouts, ok := m.timeouts[server]; ok {
+		client.SetTimeouts(timeouts)
+	}
+	if len(m.fallbacks) > 0 && m.sig0 == nil {
+		client.SetTSIGFallbacks(m.fallbacks)
+		client.SetTSIGKeyCache(m.keyCache)
+	}
+	client.SetOwnership(m.ownership)
+	return client
+}
+
+// missingGroups returns the groups fewer than MinSuccess of whose servers are
+// in succeeded
+func (m *MultiServerDNS) missingGroups(succeeded []string) []string {
+	var missing []string
+	for _, group := range m.groups {
+		applied := 0
+		for _, server := range group.Servers {
+			if slices.Contains(succeeded, server) {
+				applied++
+			}
+		}
+		if applied < group.MinSuccess {
+			missing = append(missing, group.Name)
+		}
+	}
+	return missing
+}
+
+// quorumMet reports whether the servers in succeeded meet minSuccess and the
+// quorum of every group
+func (m *MultiServerDNS) quorumMet(succeeded []string) bool {
+	return len(succeeded) >= m.minSuccess && len(m.missingGroups(succeeded)) == 0
+}
+
+// addResult is the outcome of an add on one server
+type addResult struct {
+	server string
+	err    error
+}
+
+// addOp is an add applied to every server and undone on the servers that
+// applied it when the add as a whole fails
+type addOp struct {
+	// name identifies the added records in logs
+	name     string
+	apply    func(ctx context.Context, client serverClient) error
+	rollback func(ctx context.Context, client serverClient) error
+}
+
 // AddTXTRecord adds a TXT record to all configured DNS servers synchronously
 func (m *MultiServerDNS) AddTXTRecord(ctx context.Context, fqdn, value string, ttl int) error {
 	m.logger.Info("Adding TXT record to multiple servers",
 		zap.String("fqdn", fqdn),
-		zap.String("value", value),
+		dns.ChallengeValue(value),
 		zap.Strings("servers", m.servers),
 	)
+	return m.addEverywhere(ctx, addOp{
+		name: fqdn,
+		apply: func(ctx context.Context, client serverClient) error {
+			return client.AddTXTRecord(ctx, fqdn, value, ttl)
+		},
+		rollback: func(ctx context.Context, client serverClient) error {
+			return client.DeleteTXTRecordValue(ctx, fqdn, value)
+		},
+	})
+}
 
-	var wg sync.WaitGroup
-	errChan := make(chan error, len(m.servers))
-	successCount := 0
-	var mu sync.Mutex
+// AddTXTRecords adds several TXT values to all configured DNS servers with a
+// single UPDATE per server, so each server applies all of them or none
+func (m *MultiServerDNS) AddTXTRecords(ctx context.Context, values []dns.TXTValue, ttl int) error {
+	names := make([]string, 0, len(values))
+	for _, v := range values {
+		if !slices.Contains(names, v.FQDN) {
+			names = append(names, v.FQDN)
+		}
+	}
+	m.logger.Info("Adding TXT records to multiple servers",
+		zap.Strings("fqdns", names),
+		zap.Int("records", len(values)),
+		zap.Strings("servers", m.servers),
+	)
+	records := dns.TXTRecords(values, ttl)
+	return m.addEverywhere(ctx, addOp{
+		name: strings.Join(names, ","),
+		apply: func(ctx context.Context, client serverClient) error {
+			return client.AddRecords(ctx, records)
+		},
+		rollback: func(ctx context.Context, client serverClient) error {
+			return client.DeleteRecords(ctx, records)
+		},
+	})
+}
 
+// addEverywhere applies op on every server concurrently and succeeds once
+// minSuccess servers and the quorum of every group applied it. With
+// returnOnQuorum it returns at that point and leaves the remaining servers
+// to finishStragglers.
+func (m *MultiServerDNS) addEverywhere(ctx context.Context, op addOp) error {
+	// updateCtx is cancelled early with cancelOnQuorum and outlives the call
+	// when returnOnQuorum leaves stragglers running; rollbacks use ctx
+	background := m.returnOnQuorum && !m.cancelOnQuorum
+	var updateCtx context.Context
+	var cancelRemaining context.CancelFunc
+	if background {
+		updateCtx, cancelRemaining = detach(ctx)
+	} else {
+		updateCtx, cancelRemaining = context.WithCancel(ctx)
+	}
+	// finishStragglers takes over cancelRemaining when the add returns early
+	handedOff := false
+	defer func() {
+		if !handedOff {
+			cancelRemaining()
+		}
+	}()
+
+	results := make(chan addResult, len(m.servers))
 	for _, server := range m.servers {
-		wg.Add(1)
 		go func(srv string) {
-			defer wg.Done()
-			client := dns.NewRFC2136Client(srv, m.zone, m.tsigKey, m.tsigAlg, m.tsigSec, m.logger)
-			if err := client.AddTXTRecord(ctx, fqdn, value, ttl); err != nil {
-				m.logger.Error("Failed to add TXT record on server",
-					zap.String("server", srv),
-					zap.String("fqdn", fqdn),
-					zap.Error(err),
+			serverCtx, done := m.startServer(updateCtx, "add", srv)
+			err := op.apply(serverCtx, m.newClient(srv))
+			done(err)
+			results <- addResult{server: srv, err: err}
+		}(server)
+	}
+
+	var succeeded, failed []string
+	var errors []error
+	pending := len(m.servers)
+	for pending > 0 {
+		result := <-results
+		pending--
+		if result.err != nil {
+			permanent := dns.IsPermanent(result.err)
+			if updateCtx.Err() != nil && ctx.Err() == nil {
+				metrics.QuorumStragglers.WithLabelValues("cancelled").Inc()
+				m.logger.Info("Cancelled TXT record add on server after quorum was reached",
+					zap.String("server", result.server),
+					zap.String("fqdn", op.name),
+				)
+			} else if permanent {
+				m.logger.Error("Server rejected TXT record, not retrying until its configuration is fixed",
+					zap.String("server", result.server),
+					zap.String("fqdn", op.name),
+					zap.Error(result.err),
 				)
-				errChan <- fmt.Errorf("server %s: %w", srv, err)
 			} else {
-				mu.Lock()
-				successCount++
-				mu.Unlock()
-				m.logger.Info("Successfully added TXT record on server",
-					zap.String("server", srv),
-					zap.String("fqdn", fqdn),
+				m.logger.Error("Failed to add TXT record on server",
+					zap.String("server", result.server),
+					zap.String("fqdn", op.name),
+					zap.Error(result.err),
 				)
 			}
-		}(server)
-	}
-
-	wg.Wait()
-	close(errChan)
+			if !permanent {
+				failed = append(failed, result.server)
+			}
+			errors = append(errors, fmt.Errorf("server %s: %w", result.server, result.err))
+		} else {
+			succeeded = append(succeeded, result.server)
+			if m.onServer != nil {
+				m.onServer(result.server)
+			}
+			m.logger.Info("Successfully added TXT record on server",
+				zap.String("server", result.server),
+				zap.String("fqdn", op.name),
+			)
+		}
 
-	// Collect errors
-	var errors []error
-	for err := range errChan {
-		errors = append(errors, err)
+		if !m.quorumMet(succeeded) || pending == 0 {
+			continue
+		}
+		if m.cancelOnQuorum {
+			cancelRemaining()
+		} else if background {
+			handedOff = true
+			go m.finishStragglers(results, pending, cancelRemaining, op.name)
+			break
+		}
 	}
 
 	// Check if we have enough successful updates
-	if successCount < m.minSuccess {
+	successCount := len(succeeded)
+	if !m.quorumMet(succeeded) {
+		missing := m.missingGroups(succeeded)
 		m.logger.Error("Insufficient successful updates",
 			zap.Int("success_count", successCount),
 			zap.Int("min_required", m.minSuccess),
 			zap.Int("total_servers", len(m.servers)),
+			zap.Strings("groups_below_quorum", missing),
 			zap.Int("errors", len(errors)),
 		)
-		return fmt.Errorf("only %d/%d servers updated successfully (minimum %d required): %v",
-			successCount, len(m.servers), m.minSuccess, errors)
+		if m.rollback {
+			m.rollbackAdd(ctx, succeeded, op)
+		}
+		return &QuorumError{Succeeded: successCount, Total: len(m.servers), Required: m.minSuccess,
+			Groups: missing, Errors: errors}
 	}
 
 	if len(errors) > 0 {
@@ -128,23 +612,127 @@ func (m *MultiServerDNS) AddTXTRecord(ctx context.Context, fqdn, value string, t
 			zap.Int("success_count", successCount),
 			zap.Int("error_count", len(errors)),
 		)
+		if m.onLagging != nil {
+			for _, srv := range failed {
+				m.onLagging(srv)
+			}
+		}
 	}
 
 	m.logger.Info("TXT record added successfully to multiple servers",
-		zap.String("fqdn", fqdn),
+		zap.String("fqdn", op.name),
 		zap.Int("success_count", successCount),
+		zap.Int("pending_count", pending),
 		zap.Int("total_servers", len(m.servers)),
 	)
 	return nil
 }
 
-// DeleteTXTRecord deletes a TXT record from all configured DNS servers synchronously
+// finishStragglers collects the pending adds an addEverywhere returning on
+// quorum left behind and records their outcome. Servers that miss the
+// record are reported to onLagging like those that failed before the quorum.
+func (m *MultiServerDNS) finishStragglers(results <-chan addResult, pending int, cancel context.CancelFunc, fqdn string) {
+	defer cancel()
+	for ; pending > 0; pending-- {
+		result := <-results
+		if result.err != nil {
+			metrics.QuorumStragglers.WithLabelValues("failed").Inc()
+			if dns.IsPermanent(result.err) {
+				m.logger.Error("Server rejected TXT record after quorum was reached, not retrying",
+					zap.String("server", result.server),
+					zap.String("fqdn", fqdn),
+					zap.Error(result.err),
+				)
+				continue
+			}
+			m.logger.Warn("Server missed TXT record after quorum was reached",
+				zap.String("server", result.server),
+				zap.String("fqdn", fqdn),
+				zap.Error(result.err),
+			)
+			if m.onLagging != nil {
+				m.onLagging(result.server)
+			}
+			continue
+		}
+		metrics.QuorumStragglers.WithLabelValues("applied").Inc()
+		if m.onServer != nil {
+			m.onServer(result.server)
+		}
+		m.logger.Info("Server applied TXT record after quorum was reached",
+			zap.String("server", result.server),
+			zap.String("fqdn", fqdn),
+		)
+	}
+}
+
+// detach returns a context that is not cancelled with ctx but keeps its
+// deadline and values, for work that continues after the caller returned
+func detach(ctx context.Context) (context.Context, context.CancelFunc) {
+	detached := context.WithoutCancel(ctx)
+	if deadline, ok := ctx.Deadline(); ok {
+		return context.WithDeadline(detached, deadline)
+	}
+	return context.WithCancel(detached)
+}
+
+// rollbackAdd undoes op on servers after a failed add.
+// Failures are only logged: the record is still removed by CleanUp or the
+// stale record sweeper.
+func (m *MultiServerDNS) rollbackAdd(ctx context.Context, servers []string, op addOp) {
+	var wg sync.WaitGroup
+	for _, server := range servers {
+		wg.Add(1)
+		go func(srv string) {
+			defer wg.Done()
+			ctx, done := m.startServer(ctx, "rollback", srv)
+			err := op.rollback(ctx, m.newClient(srv))
+			done(err)
+			if err != nil {
+				m.logger.Warn("Failed to roll back TXT record on server",
+					zap.String("server", srv),
+					zap.String("fqdn", op.name),
+					zap.Error(err),
+				)
+				return
+			}
+			m.logger.Info("Rolled back TXT record on server",
+				zap.String("server", srv),
+				zap.String("fqdn", op.name),
+			)
+		}(server)
+	}
+	wg.Wait()
+}
+
+// DeleteTXTRecord deletes the TXT RRset at fqdn from all configured DNS servers synchronously
 func (m *MultiServerDNS) DeleteTXTRecord(ctx context.Context, fqdn string) error {
 	m.logger.Info("Deleting TXT record from multiple servers",
 		zap.String("fqdn", fqdn),
 		zap.Strings("servers", m.servers),
 	)
+	return m.deleteEverywhere(ctx, fqdn, func(ctx context.Context, client serverClient) error {
+		return client.DeleteTXTRecord(ctx, fqdn)
+	})
+}
+
+// DeleteTXTRecordValue deletes value from the TXT RRset at fqdn on all
+// configured DNS servers synchronously, keeping the RRset's other values
+func (m *MultiServerDNS) DeleteTXTRecordValue(ctx context.Context, fqdn, value string) error {
+	m.logger.Info("Deleting TXT record value from multiple servers",
+		zap.String("fqdn", fqdn),
+		dns.ChallengeValue(value),
+		zap.Strings("servers", m.servers),
+	)
+	return m.deleteEverywhere(ctx, fqdn, func(ctx context.Context, client serverClient) error {
+		return client.DeleteTXTRecordValue(ctx, fqdn, value)
+	})
+}
 
+// deleteEverywhere runs del against the client of every server and succeeds
+// when at least one server applied it
+func (m *MultiServerDNS) deleteEverywhere(ctx context.Context, fqdn string,
+	del func(ctx context.Context, client serverClient) error) error {
 	var wg sync.WaitGroup
 	errChan := make(chan error, len(m.servers))
 	successCount := 0
@@ -154,8 +742,10 @@ func (m *MultiServerDNS) DeleteTXTRecord(ctx context.Context, fqdn string) error
 		wg.Add(1)
 		go func(srv string) {
 			defer wg.Done()
-			client := dns.NewRFC2136Client(srv, m.zone, m.tsigKey, m.tsigAlg, m.tsigSec, m.logger)
-			if err := client.DeleteTXTRecord(ctx, fqdn); err != nil {
+			ctx, done := m.startServer(ctx, "delete", srv)
+			err := del(ctx, m.newClient(srv))
+			done(err)
+			if err != nil {
 				m.logger.Error("Failed to delete TXT record on server",
 					zap.String("server", srv),
 					zap.String("fqdn", fqdn),
@@ -166,6 +756,9 @@ func (m *MultiServerDNS) DeleteTXTRecord(ctx context.Context, fqdn string) error
 				mu.Lock()
 				successCount++
 				mu.Unlock()
+				if m.onServer != nil {
+					m.onServer(srv)
+				}
 				m.logger.Info("Successfully deleted TXT record on server",
 					zap.String("server", srv),
 					zap.String("fqdn", fqdn),
@@ -189,7 +782,7 @@ func (m *MultiServerDNS) DeleteTXTRecord(ctx context.Context, fqdn string) error
 			zap.Int("total_servers", len(m.servers)),
 			zap.Int("errors", len(errors)),
 		)
-		return fmt.Errorf("failed to delete on all servers: %v", errors)
+		return &QuorumError{Succeeded: 0, Total: len(m.servers), Required: 1, Errors: errors}
 	}
 
 	if len(errors) > 0 {
@@ -207,3 +800,44 @@ func (m *MultiServerDNS) DeleteTXTRecord(ctx context.Context, fqdn string) error
 	return nil
 }
 
+// CheckUpdatePermission confirms on every server that the TSIG key may update
+// TXT records at fqdn. Only explicit refusals fail the check; servers that
+// cannot be reached are left to the quorum of the update itself.
+func (m *MultiServerDNS) CheckUpdatePermission(ctx context.Context, fqdn string) error {
+	var wg sync.WaitGroup
+	errChan := make(chan error, len(m.servers))
+
+	for _, server := range m.servers {
+		// API backends authorize each request; there is no UPDATE to probe
+		if _, ok := m.apiClients[server]; ok {
+			continue
+		}
+		wg.Add(1)
+		go func(srv string) {
+			defer wg.Done()
+			ctx, done := m.startServer(ctx, "preflight", srv)
+			err := m.rfc2136Client(srv).CheckUpdatePermission(ctx, fqdn)
+			done(err)
+			switch {
+			case err == nil:
+			case errors.Is(err, dns.ErrUpdateNotAuthorized):
+				errChan <- err
+			default:
+				m.logger.Warn("Preflight check inconclusive",
+					zap.String("server", srv),
+					zap.String("fqdn", fqdn),
+					zap.Error(err),
+				)
+			}
+		}(server)
+	}
+
+	wg.Wait()
+	close(errChan)
+
+	var refusals []error
+	for err := range errChan {
+		refusals = append(refusals, err)
+	}
+	return errors.Join(refusals...)
+}
@@ -0,0 +1,292 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"go.uber.org/zap"
+
+	"github.com/rieset/istio-dns01-bind9/internal/dnstest"
+)
+
+const testFQDN = "_acme-challenge.app.example.com."
+
+func startServers(t *testing.T, n int) []*dnstest.Server {
+	t.Helper()
+	return dnstest.StartServers(t, n)
+}
+
+func newTestManager(servers []*dnstest.Server) *MultiServerDNS {
+	return NewMultiServerDNS(serverAddrs(servers), 0, "example.com", dnstest.TestKeyName, "hmac-sha256", dnstest.TestSecret, zap.NewNop())
+}
+
+func TestMultiServerAddQuorum(t *testing.T) {
+	tests := []struct {
+		name    string
+		failing int
+		wantErr bool
+	}{
+		{"all servers healthy", 0, false},
+		{"minority failing", 1, false},
+		{"majority failing", 2, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			servers := startServers(t, 3)
+			for _, srv := range servers[:tt.failing] {
+				srv.SetUpdateRcode(dns.RcodeServerFailure)
+			}
+
+			err := newTestManager(servers).AddTXTRecord(context.Background(), testFQDN, "token", 60)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AddTXTRecord error = %v, wantErr %v", err, tt.wantErr)
+			}
+			for _, srv := range servers[tt.failing:] {
+				if got := srv.TXT(testFQDN); len(got) != 1 || got[0] != "token" {
+					t.Fatalf("healthy server %s has TXT %v", srv.Addr(), got)
+				}
+			}
+		})
+	}
+}
+
+func TestMultiServerMinSuccess(t *testing.T) {
+	tests := []struct {
+		name       string
+		minSuccess int
+		failing    int
+		wantErr    bool
+	}{
+		{"all required, one failing", 3, 1, true},
+		{"all required, none failing", 3, 0, false},
+		{"any, two failing", 1, 2, false},
+		{"any, all failing", 1, 3, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			servers := startServers(t, 3)
+			for _, srv := range servers[:tt.failing] {
+				srv.SetUpdateRcode(dns.RcodeServerFailure)
+			}
+
+			m := NewMultiServerDNS(serverAddrs(servers), tt.minSuccess, "example.com", dnstest.TestKeyName, "hmac-sha256",
+				dnstest.TestSecret, zap.NewNop())
+			err := m.AddTXTRecord(context.Background(), testFQDN, "token", 60)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AddTXTRecord error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMultiServerRollback(t *testing.T) {
+	servers := startServers(t, 3)
+	servers[0].SetUpdateRcode(dns.RcodeServerFailure)
+	servers[1].SetUpdateRcode(dns.RcodeServerFailure)
+
+	m := newTestManager(servers)
+	m.SetRollback(true)
+	if err := m.AddTXTRecord(context.Background(), testFQDN, "token", 60); err == nil {
+		t.Fatal("AddTXTRecord succeeded without a quorum")
+	}
+	if got := servers[2].TXT(testFQDN); len(got) != 0 {
+		t.Fatalf("record not rolled back on server that applied it: %v", got)
+	}
+}
+
+func TestMultiServerLaggingCallback(t *testing.T) {
+	servers := startServers(t, 3)
+	servers[0].SetUpdateRcode(dns.RcodeServerFailure)
+
+	var lagging []string
+	m := newTestManager(servers)
+	m.SetLaggingCallback(func(server string) { lagging = append(lagging, server) })
+	if err := m.AddTXTRecord(context.Background(), testFQDN, "token", 60); err != nil {
+		t.Fatalf("AddTXTRecord: %v", err)
+	}
+	if len(lagging) != 1 || lagging[0] != servers[0].Addr() {
+		t.Fatalf("lagging servers = %v, want [%s]", lagging, servers[0].Addr())
+	}
+}
+
+func TestMultiServerDeleteNeedsOneServer(t *testing.T) {
+	servers := startServers(t, 3)
+	for _, srv := range servers {
+		srv.SetTXT(testFQDN, 60, "token")
+	}
+	servers[0].SetUpdateRcode(dns.RcodeServerFailure)
+	servers[1].SetUpdateRcode(dns.RcodeServerFailure)
+
+	m := newTestManager(servers)
+	if err := m.DeleteTXTRecord(context.Background(), testFQDN); err != nil {
+		t.Fatalf("DeleteTXTRecord with one healthy server: %v", err)
+	}
+	if got := servers[2].TXT(testFQDN); len(got) != 0 {
+		t.Fatalf("healthy server still has TXT %v", got)
+	}
+
+	servers[2].SetUpdateRcode(dns.RcodeServerFailure)
+	if err := m.DeleteTXTRecord(context.Background(), testFQDN); err == nil {
+		t.Fatal("DeleteTXTRecord succeeded with every server failing")
+	}
+}
+
+func TestMultiServerTimeouts(t *testing.T) {
+	tests := []struct {
+		name           string
+		serverTimeout  time.Duration
+		cancelOnQuorum bool
+	}{
+		{"server timeout cuts off slow server", 200 * time.Millisecond, false},
+		{"quorum cancels slow server", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			servers := startServers(t, 3)
+			servers[0].SetLatency(time.Second)
+
+			var lagging []string
+			var mu sync.Mutex
+			m := newTestManager(servers)
+			m.SetServerTimeout(tt.serverTimeout)
+			m.SetCancelOnQuorum(tt.cancelOnQuorum)
+			m.SetLaggingCallback(func(server string) {
+				mu.Lock()
+				defer mu.Unlock()
+				lagging = append(lagging, server)
+			})
+
+			start := time.Now()
+			if err := m.AddTXTRecord(context.Background(), testFQDN, "token", 60); err != nil {
+				t.Fatalf("AddTXTRecord: %v", err)
+			}
+			if elapsed := time.Since(start); elapsed > 800*time.Millisecond {
+				t.Fatalf("AddTXTRecord waited %v for the slow server", elapsed)
+			}
+			if len(lagging) != 1 || lagging[0] != servers[0].Addr() {
+				t.Fatalf("lagging servers = %v, want [%s]", lagging, servers[0].Addr())
+			}
+		})
+	}
+}
+
+func TestMultiServerReturnOnQuorum(t *testing.T) {
+	servers := startServers(t, 3)
+	servers[0].SetLatency(500 * time.Millisecond)
+
+	applied := make(chan string, len(servers))
+	m := newTestManager(servers)
+	m.SetReturnOnQuorum(true)
+	m.SetServerCallback(func(server string) { applied <- server })
+
+	start := time.Now()
+	if err := m.AddTXTRecord(context.Background(), testFQDN, "token", 60); err != nil {
+		t.Fatalf("AddTXTRecord: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 400*time.Millisecond {
+		t.Fatalf("AddTXTRecord waited %v for the slow server", elapsed)
+	}
+	if got := servers[0].TXT(testFQDN); len(got) != 0 {
+		t.Fatalf("slow server has TXT %v before its update finished", got)
+	}
+
+	// The slow server still receives the record in the background
+	timeout := time.After(2 * time.Second)
+	for seen := 0; seen < len(servers); seen++ {
+		select {
+		case <-applied:
+		case <-timeout:
+			t.Fatalf("only %d of %d servers reported the record", seen, len(servers))
+		}
+	}
+	if got := servers[0].TXT(testFQDN); len(got) != 1 || got[0] != "token" {
+		t.Fatalf("slow server has TXT %v after finishing in the background", got)
+	}
+}
+
+func TestMultiServerRejectedUpdates(t *testing.T) {
+	servers := startServers(t, 3)
+	servers[0].SetUpdateRcode(dns.RcodeRefused)
+
+	var lagging []string
+	m := newTestManager(servers)
+	m.SetLaggingCallback(func(server string) { lagging = append(lagging, server) })
+	if err := m.AddTXTRecord(context.Background(), testFQDN, "token", 60); err != nil {
+		t.Fatalf("AddTXTRecord: %v", err)
+	}
+	if len(lagging) != 0 {
+		t.Fatalf("lagging servers = %v, want none for a server refusing the update", lagging)
+	}
+
+	servers[1].SetUpdateRcode(dns.RcodeNotZone)
+	err := m.AddTXTRecord(context.Background(), testFQDN, "other", 60)
+	var quorum *QuorumError
+	if !errors.As(err, &quorum) || !quorum.Permanent() {
+		t.Fatalf("AddTXTRecord error = %v, want a permanent QuorumError", err)
+	}
+
+	servers[1].SetUpdateRcode(dns.RcodeServerFailure)
+	err = m.AddTXTRecord(context.Background(), testFQDN, "third", 60)
+	if !errors.As(err, &quorum) || quorum.Permanent() {
+		t.Fatalf("AddTXTRecord error = %v, want a QuorumError worth retrying", err)
+	}
+}
+
+func TestMultiServerGroupQuorum(t *testing.T) {
+	tests := []struct {
+		name       string
+		failing    []int
+		wantGroups []string
+	}{
+		{"one server per group", []int{0, 2}, nil},
+		{"whole group failing", []int{0, 1}, []string{"mgmt"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			servers := startServers(t, 4)
+			for _, i := range tt.failing {
+				servers[i].SetUpdateRcode(dns.RcodeServerFailure)
+			}
+			addrs := serverAddrs(servers)
+			m := NewMultiServerDNS(addrs, 2, "example.com", dnstest.TestKeyName, "hmac-sha256", dnstest.TestSecret, zap.NewNop())
+			m.SetServerGroups([]ServerGroup{
+				{Name: "mgmt", Servers: addrs[:2], MinSuccess: 1},
+				{Name: "onprem", Servers: addrs[2:], MinSuccess: 1},
+			})
+
+			err := m.AddTXTRecord(context.Background(), testFQDN, "token", 60)
+			if tt.wantGroups == nil {
+				if err != nil {
+					t.Fatalf("AddTXTRecord: %v", err)
+				}
+				return
+			}
+			// Two servers applied the add, which meets minSuccess but not the group quorum
+			var quorum *QuorumError
+			if !errors.As(err, &quorum) || !slices.Equal(quorum.Groups, tt.wantGroups) {
+				t.Fatalf("AddTXTRecord error = %v, want a QuorumError for groups %v", err, tt.wantGroups)
+			}
+		})
+	}
+}
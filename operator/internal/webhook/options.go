@@ -0,0 +1,688 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/rieset/istio-dns01-bind9/internal/dns"
+)
+
+// FunctionRating: 88/100
+// - Complexity: LOW
+// - Integrations: 0
+// - External Risks: LOW (environment and flag parsing only)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: Options
+// Purpose: Process-level settings of the webhook solver loaded from the environment and flags
+
+// Environment variables read by OptionsFromEnv
+const (
+	EnvSecretNamespace     = "TSIG_SECRET_NAMESPACE"
+	EnvSecretLabelSelector = "TSIG_SECRET_LABEL_SELECTOR"
+	EnvSecretFieldSelector = "TSIG_SECRET_FIELD_SELECTOR"
+	EnvSecretResync        = "TSIG_SECRET_RESYNC"
+	EnvSecretFileDir       = "SECRET_FILE_DIR"
+	EnvSecretAllowedNS     = "SECRET_ALLOWED_NAMESPACES"
+	EnvSecretGrantNS       = "SECRET_GRANT_NAMESPACES"
+	EnvVaultAddr           = "VAULT_ADDR"
+	EnvVaultRole           = "VAULT_ROLE"
+	EnvVaultAuthPath       = "VAULT_AUTH_PATH"
+	EnvVaultKVMount        = "VAULT_KV_MOUNT"
+	EnvVaultPathPrefix     = "VAULT_PATH_PREFIX"
+	EnvVaultNamespace      = "VAULT_NAMESPACE"
+	EnvVaultCACert         = "VAULT_CACERT"
+	EnvVaultTokenPath      = "VAULT_TOKEN_PATH"
+	EnvCleanupWorkers      = "CLEANUP_WORKERS"
+	EnvCleanupMaxRetries   = "CLEANUP_MAX_RETRIES"
+	EnvCleanupTimeout      = "CLEANUP_TIMEOUT"
+	EnvDNSWorkers          = "DNS_WORKERS"
+	EnvDNSWorkersPerZone   = "DNS_WORKERS_PER_ZONE"
+	EnvOperationTimeout    = "DNS_OPERATION_TIMEOUT"
+	EnvServerTimeout       = "DNS_SERVER_TIMEOUT"
+	EnvCancelOnQuorum      = "DNS_CANCEL_ON_QUORUM"
+	EnvReturnOnQuorum      = "DNS_RETURN_ON_QUORUM"
+	EnvConnIdleTimeout     = "DNS_CONN_IDLE_TIMEOUT"
+	EnvConnMaxIdle         = "DNS_CONN_MAX_IDLE"
+	EnvManagerCacheSize    = "DNS_MANAGER_CACHE_SIZE"
+	EnvBatchWindow         = "DNS_BATCH_WINDOW"
+	EnvUpdateRate          = "DNS_UPDATE_RATE"
+	EnvUpdateBurst         = "DNS_UPDATE_BURST"
+	EnvMaxUpdatesInFlight  = "DNS_MAX_UPDATES_IN_FLIGHT"
+	EnvSchedulerSlots      = "SCHEDULER_SLOTS"
+	EnvTenantConcurrency   = "TENANT_CONCURRENCY"
+	EnvTenantLimits        = "TENANT_LIMITS"
+	EnvTenantWeights       = "TENANT_WEIGHTS"
+	EnvClusterResourceNS   = "CLUSTER_RESOURCE_NAMESPACE"
+	EnvDomainAllowlist     = "DOMAIN_ALLOWLIST"
+	EnvDomainDenylist      = "DOMAIN_DENYLIST"
+	EnvDomainPolicyCM      = "DOMAIN_POLICY_CONFIGMAP"
+	EnvDefaultsConfigMap   = "DEFAULTS_CONFIGMAP"
+	EnvWarmupEnabled       = "WARMUP_ENABLED"
+	EnvWarmupTimeout       = "WARMUP_TIMEOUT"
+	EnvDNSFaults           = "DEBUG_DNS_FAULTS"
+	EnvLogLevel            = "LOG_LEVEL"
+	EnvLogEncoding         = "LOG_ENCODING"
+	EnvLogSampling         = "LOG_SAMPLING"
+	EnvLogChallengeKeys    = "DEBUG_LOG_CHALLENGE_KEYS"
+	EnvDryRun              = "DRY_RUN"
+	EnvPreflightEnabled    = "PREFLIGHT_ENABLED"
+	EnvStateEnabled        = "STATE_ENABLED"
+	EnvStateNamespace      = "STATE_NAMESPACE"
+	EnvStateConfigMap      = "STATE_CONFIGMAP"
+	EnvRepairJournalCM     = "REPAIR_JOURNAL_CONFIGMAP"
+	EnvResultsConfigMap    = "RESULTS_CONFIGMAP"
+	EnvResultsMaxEntries   = "RESULTS_MAX_ENTRIES"
+	EnvLeasesEnabled       = "LEASES_ENABLED"
+	EnvLeaseIdentity       = "LEASE_IDENTITY"
+	EnvLeaseDuration       = "LEASE_DURATION"
+	EnvLeaseWaitTimeout    = "LEASE_WAIT_TIMEOUT"
+	EnvGCEnabled           = "GC_ENABLED"
+	EnvGCInterval          = "GC_INTERVAL"
+	EnvGCMaxAge            = "GC_MAX_AGE"
+	EnvTracingEnabled      = "TRACING_ENABLED"
+	EnvEventsEnabled       = "EVENTS_ENABLED"
+	EnvEventsObject        = "EVENTS_OBJECT"
+	EnvDrainTimeout        = "DRAIN_TIMEOUT"
+	EnvHealthAddr          = "HEALTH_ADDR"
+	EnvSlowRequest         = "SLOW_REQUEST_THRESHOLD"
+	EnvRegistryOwnerID     = "REGISTRY_OWNER_ID"
+	EnvHealthTargets       = "HEALTH_CHECK_TARGETS"
+	EnvHealthCacheTTL      = "HEALTH_CHECK_CACHE"
+	EnvHealthTimeout       = "HEALTH_CHECK_TIMEOUT"
+	EnvAdminAddr           = "ADMIN_ADDR"
+	EnvAdminTokenFile      = "ADMIN_TOKEN_FILE"
+	EnvAdminTLSCert        = "ADMIN_TLS_CERT_FILE"
+	EnvAdminTLSKey         = "ADMIN_TLS_KEY_FILE"
+	EnvAdminClientCA       = "ADMIN_CLIENT_CA_FILE"
+	EnvGroupName           = "GROUP_NAME"
+	EnvSolverName          = "SOLVER_NAME"
+)
+
+// Flags read by ApplyFlags, which take precedence over the environment
+const (
+	FlagGroupName   = "group-name"
+	FlagSolverName  = "solver-name"
+	FlagLogLevel    = "log-level"
+	FlagLogEncoding = "log-encoding"
+)
+
+// Options holds process-level settings of the webhook solver.
+// Per-challenge settings live in Config instead.
+type Options struct {
+	// GroupName is the API group the webhook is registered under
+	GroupName string
+	// SolverName is the solverName Issuers refer to the solver by
+	SolverName string
+	// ClusterResourceNamespace is where challenges of ClusterIssuers look up secrets
+	ClusterResourceNamespace string
+
+	// SecretNamespaces restricts the Secret informers to these namespaces (empty means all)
+	SecretNamespaces []string
+	// SecretLabelSelector restricts the Secret informer to matching Secrets
+	SecretLabelSelector string
+	// SecretFieldSelector restricts the Secret informer to matching Secrets
+	SecretFieldSelector string
+	// SecretResync is the informer resync period
+	SecretResync time.Duration
+	// SecretFileDir holds the TSIG Secrets of the file secret provider as
+	// <namespace>/<name>/<key> files
+	SecretFileDir string
+	// Vault configures the vault secret provider; it is disabled without an address
+	Vault VaultOptions
+	// SecretAllowedNamespaces restricts the namespaces whose Secrets solver
+	// configs may read to those matching one of these globs; empty allows all
+	SecretAllowedNamespaces []string
+	// SecretGrantNamespaces are the central namespaces whose Secrets
+	// challenges of other namespaces may read, once the Secret grants it
+	SecretGrantNamespaces []string
+
+	// DomainAllowlist restricts the challenge names the webhook writes to those
+	// matching one of these patterns; empty allows every name not denied
+	DomainAllowlist []string
+	// DomainDenylist rejects the challenge names matching one of these patterns
+	DomainDenylist []string
+	// DomainPolicyConfigMap names a ConfigMap in the state namespace whose
+	// allow and deny keys add patterns, one per line, picked up as they change
+	DomainPolicyConfigMap string
+	// DefaultsConfigMap names a ConfigMap in the state namespace whose
+	// config.json key holds the solver config Issuer configs are merged over,
+	// picked up as it changes
+	DefaultsConfigMap string
+
+	// CleanupWorkers is the number of background cleanup workers
+	CleanupWorkers int
+	// CleanupMaxRetries is how often a failed cleanup is retried before it is dropped
+	CleanupMaxRetries int
+	// CleanupRetryBaseDelay is the initial backoff between cleanup retries
+	CleanupRetryBaseDelay time.Duration
+	// CleanupRetryMaxDelay caps the backoff between cleanup retries
+	CleanupRetryMaxDelay time.Duration
+	// CleanupTimeout bounds a single cleanup attempt including verification
+	CleanupTimeout time.Duration
+
+	// DNSWorkers is the size of the shared worker pool running DNS updates
+	DNSWorkers int
+	// DNSWorkersPerZone caps how many pool workers a single zone may occupy
+	DNSWorkersPerZone int
+
+	// SchedulerSlots caps the Presents and cleanups doing DNS work at once
+	// across all Issuer namespaces; zero disables the fair scheduler
+	SchedulerSlots int
+	// TenantConcurrency caps the operations of one namespace holding a slot;
+	// zero lets a namespace use every slot
+	TenantConcurrency int
+	// TenantLimits are "namespace=n" entries overriding TenantConcurrency
+	TenantLimits []string
+	// TenantWeights are "namespace=n" entries giving a namespace n shares of
+	// the freed slots while several wait; the default weight is 1
+	TenantWeights []string
+
+	// OperationTimeout bounds the DNS work of a Present or cleanup before
+	// verification: zone discovery, preflight and the update on all servers,
+	// including the wait for a scheduler slot, a worker and a zone Lease
+	OperationTimeout time.Duration
+	// ServerTimeout bounds the update of a single server, retries included
+	ServerTimeout time.Duration
+	// CancelOnQuorum stops the updates still running once enough servers applied
+	// an add; the servers left behind are handed to the repair queue
+	CancelOnQuorum bool
+	// ReturnOnQuorum returns from an add once enough servers applied it and lets
+	// the others finish in the background; CancelOnQuorum takes precedence
+	ReturnOnQuorum bool
+
+	// ConnIdleTimeout is how long an unused TCP or TLS connection to a server is kept
+	ConnIdleTimeout time.Duration
+	// ConnMaxIdle is the number of idle connections kept per server and transport
+	ConnMaxIdle int
+	// ManagerCacheSize is the number of RFC2136 managers kept across
+	// challenges, one per namespace and solver config; zero builds one per operation
+	ManagerCacheSize int
+
+	// BatchWindow holds back each add for this long so the challenges of a zone
+	// presented meanwhile share one UPDATE per server; zero disables batching
+	BatchWindow time.Duration
+
+	// UpdateRate is the number of UPDATE messages per second sent to each
+	// server, retries included; zero leaves the rate unlimited
+	UpdateRate float64
+	// UpdateBurst is how many updates a server may receive at once above
+	// UpdateRate; zero allows one second worth
+	UpdateBurst int
+	// MaxUpdatesInFlight caps the updates awaiting a reply per server; zero
+	// leaves it unlimited
+	MaxUpdatesInFlight int
+
+	// WarmupEnabled pre-resolves servers and pre-fetches secrets at startup
+	WarmupEnabled bool
+	// WarmupTimeout bounds the startup warm-up
+	WarmupTimeout time.Duration
+
+	// PreflightEnabled confirms the update-policy allows the challenge name before Present changes anything
+	PreflightEnabled bool
+
+	// StateEnabled persists in-flight challenges so they are resumed after a restart
+	StateEnabled bool
+	// StateNamespace holds the state and results ConfigMaps (empty means ClusterResourceNamespace)
+	StateNamespace string
+	// StateConfigMap is the name of the ConfigMap holding in-flight challenges
+	StateConfigMap string
+	// RepairJournalConfigMap, with StateEnabled, journals the servers still
+	// missing a challenge record so a restart keeps repairing them; empty disables it
+	RepairJournalConfigMap string
+
+	// ResultsConfigMap, when set, receives a JSON summary of every challenge's DNS work
+	ResultsConfigMap string
+	// ResultsMaxEntries bounds the number of challenge summaries kept
+	ResultsMaxEntries int
+
+	// LeasesEnabled makes every zone update hold a per-zone Lease so that only one
+	// of several instances sharing the namespace mutates a zone at a time
+	LeasesEnabled bool
+	// LeaseIdentity names this instance in the Leases it holds
+	LeaseIdentity string
+	// LeaseDuration is how long a Lease outlives an instance that stopped renewing it
+	LeaseDuration time.Duration
+	// LeaseWaitTimeout bounds how long an update waits for another instance's Lease
+	LeaseWaitTimeout time.Duration
+
+	// GCEnabled periodically removes stale _acme-challenge records from managed zones
+	GCEnabled bool
+	// GCInterval is the time between garbage collection sweeps
+	GCInterval time.Duration
+	// GCMaxAge is how long a challenge record must have been observed before it is removed
+	GCMaxAge time.Duration
+
+	// RegistryOwnerID, when set, writes a TXT ownership registry entry naming
+	// it next to every challenge record and refuses to delete records without
+	// one, so cleanups and sweeps never touch records of others
+	RegistryOwnerID string
+
+	// TracingEnabled exports OpenTelemetry spans of Present, CleanUp and the DNS
+	// updates over OTLP/gRPC, configured by the standard OTEL_EXPORTER_OTLP_*
+	// variables. It defaults to on when an OTLP endpoint is set.
+	TracingEnabled bool
+
+	// EventsEnabled records Kubernetes Events about the outcome of Present on
+	// the Challenge it solves
+	EventsEnabled bool
+	// EventsObject is the "namespace/name" of a Deployment, such as the
+	// webhook's own, that Events are recorded against instead of the Challenge
+	EventsObject string
+
+	// DrainTimeout bounds how long shutdown waits for the DNS operations in
+	// flight; keep it below the pod's terminationGracePeriodSeconds
+	DrainTimeout time.Duration
+
+	// HealthAddr is the address of the /livez, /healthz, /readyz and /metrics
+	// endpoints; empty disables them
+	HealthAddr string
+	// HealthTargets are "server/zone" pairs /readyz probes with SOA queries;
+	// the instance is ready while every zone answers on one of its servers
+	HealthTargets []string
+	// HealthCacheTTL is how long probe results are reused
+	HealthCacheTTL time.Duration
+	// HealthTimeout bounds a single SOA probe
+	HealthTimeout time.Duration
+
+	// AdminAddr is the address of the admin API; empty disables it
+	AdminAddr string
+	// AdminTokenFile holds the bearer token admin API requests must carry
+	AdminTokenFile string
+	// AdminTLSCertFile and AdminTLSKeyFile serve the admin API over TLS
+	AdminTLSCertFile string
+	AdminTLSKeyFile  string
+	// AdminClientCAFile, when set, requires admin API clients to present a
+	// certificate signed by one of its CAs
+	AdminClientCAFile string
+
+	// SlowRequestThreshold is the processing time above which a challenge
+	// request to the solver API is logged with its FQDN; zero disables the log
+	SlowRequestThreshold time.Duration
+
+	// DryRun, when set, overrides the dryRun field of every solver config: true
+	// logs all changes instead of sending them, false sends them regardless
+	DryRun *bool
+
+	// LogLevel is the minimum level logged: debug, info, warn or error
+	LogLevel string
+	// LogEncoding is json or console
+	LogEncoding string
+	// LogSampling drops repeated messages under load like zap's production logger
+	LogSampling bool
+	// LogChallengeKeys logs challenge keys and TXT values in full instead of
+	// their digests. Never set it in production.
+	LogChallengeKeys bool
+
+	// DNSFaults is a fault injection spec for chaos testing (see dns.ParseFaultSpec).
+	// Never set it in production.
+	DNSFaults string
+}
+
+// VaultOptions locates the HashiCorp Vault the vault secret provider reads
+// TSIG Secrets from, as <KVMount>/<PathPrefix><namespace>/<name> in a KV v2
+// engine, logging in with the webhook's service account token
+type VaultOptions struct {
+	// Address is the URL of the Vault server
+	Address string
+	// Role is the role of the Kubernetes auth method to log in with
+	Role string
+	// AuthPath is the mount path of the Kubernetes auth method
+	AuthPath string
+	// KVMount is the mount path of the KV v2 secrets engine
+	KVMount string
+	// PathPrefix is prepended to <namespace>/<name> in the engine
+	PathPrefix string
+	// Namespace is the Vault Enterprise namespace, if any
+	Namespace string
+	// CACert is a PEM file verifying the server instead of the system roots
+	CACert string
+	// TokenPath is the service account token presented at login
+	TokenPath string
+}
+
+// DefaultOptions returns the default solver options
+func DefaultOptions() Options {
+	return Options{
+		GroupName:                "acme.example.com",
+		ClusterResourceNamespace: "cert-manager",
+		SecretResync:             10 * time.Minute,
+		SecretFileDir:            "/etc/dns01-webhook/secrets",
+		CleanupWorkers:           2,
+		CleanupMaxRetries:        10,
+		CleanupRetryBaseDelay:    2 * time.Second,
+		CleanupRetryMaxDelay:     5 * time.Minute,
+		CleanupTimeout:           60 * time.Second,
+		DNSWorkers:               16,
+		DNSWorkersPerZone:        4,
+		SchedulerSlots:           32,
+		TenantConcurrency:        8,
+		OperationTimeout:         30 * time.Second,
+		ServerTimeout:            10 * time.Second,
+		ConnIdleTimeout:          dns.DefaultConnIdleTimeout,
+		ConnMaxIdle:              dns.DefaultConnMaxIdle,
+		ManagerCacheSize:         defaultManagerCacheSize,
+		WarmupEnabled:            true,
+		WarmupTimeout:            30 * time.Second,
+		PreflightEnabled:         true,
+		StateEnabled:             true,
+		StateConfigMap:           "dns01-webhook-state",
+		RepairJournalConfigMap:   "dns01-webhook-repairs",
+		ResultsMaxEntries:        500,
+		LeaseDuration:            30 * time.Second,
+		LeaseWaitTimeout:         time.Minute,
+		GCInterval:               time.Hour,
+		GCMaxAge:                 24 * time.Hour,
+		EventsEnabled:            true,
+		DrainTimeout:             25 * time.Second,
+		LogLevel:                 "info",
+		LogEncoding:              "json",
+		LogSampling:              true,
+		Vault: VaultOptions{
+			AuthPath:   "kubernetes",
+			KVMount:    "secret",
+			PathPrefix: "dns01/",
+			TokenPath:  "/var/run/secrets/kubernetes.io/serviceaccount/token",
+		},
+		HealthAddr:           ":8081",
+		HealthCacheTTL:       10 * time.Second,
+		HealthTimeout:        2 * time.Second,
+		SlowRequestThreshold: 5 * time.Second,
+		SolverName:           "multi-dns",
+	}
+}
+
+// OptionsFromEnv returns the default options overridden by environment variables
+func OptionsFromEnv() Options {
+	opts := DefaultOptions()
+	opts.SecretNamespaces = envList(EnvSecretNamespace)
+	opts.SecretLabelSelector = os.Getenv(EnvSecretLabelSelector)
+	opts.SecretFieldSelector = os.Getenv(EnvSecretFieldSelector)
+	opts.SecretResync = envDuration(EnvSecretResync, opts.SecretResync)
+	opts.SecretFileDir = envString(EnvSecretFileDir, opts.SecretFileDir)
+	opts.SecretAllowedNamespaces = envList(EnvSecretAllowedNS)
+	opts.SecretGrantNamespaces = envList(EnvSecretGrantNS)
+	opts.Vault.Address = os.Getenv(EnvVaultAddr)
+	opts.Vault.Role = os.Getenv(EnvVaultRole)
+	opts.Vault.AuthPath = envString(EnvVaultAuthPath, opts.Vault.AuthPath)
+	opts.Vault.KVMount = envString(EnvVaultKVMount, opts.Vault.KVMount)
+	opts.Vault.PathPrefix = envString(EnvVaultPathPrefix, opts.Vault.PathPrefix)
+	opts.Vault.Namespace = os.Getenv(EnvVaultNamespace)
+	opts.Vault.CACert = os.Getenv(EnvVaultCACert)
+	opts.Vault.TokenPath = envString(EnvVaultTokenPath, opts.Vault.TokenPath)
+	opts.CleanupWorkers = envInt(EnvCleanupWorkers, opts.CleanupWorkers)
+	opts.CleanupMaxRetries = envInt(EnvCleanupMaxRetries, opts.CleanupMaxRetries)
+	opts.CleanupTimeout = envDuration(EnvCleanupTimeout, opts.CleanupTimeout)
+	opts.DNSWorkers = envInt(EnvDNSWorkers, opts.DNSWorkers)
+	opts.DNSWorkersPerZone = envInt(EnvDNSWorkersPerZone, opts.DNSWorkersPerZone)
+	opts.OperationTimeout = envDuration(EnvOperationTimeout, opts.OperationTimeout)
+	opts.ServerTimeout = envDuration(EnvServerTimeout, opts.ServerTimeout)
+	opts.CancelOnQuorum = envBool(EnvCancelOnQuorum, opts.CancelOnQuorum)
+	opts.ReturnOnQuorum = envBool(EnvReturnOnQuorum, opts.ReturnOnQuorum)
+	opts.ConnIdleTimeout = envDuration(EnvConnIdleTimeout, opts.ConnIdleTimeout)
+	opts.ConnMaxIdle = envInt(EnvConnMaxIdle, opts.ConnMaxIdle)
+	// Zero is a valid size, it disables the cache
+	if v, err := strconv.Atoi(os.Getenv(EnvManagerCacheSize)); err == nil && v >= 0 {
+		opts.ManagerCacheSize = v
+	}
+	opts.BatchWindow = envDuration(EnvBatchWindow, opts.BatchWindow)
+	opts.UpdateRate = envFloat(EnvUpdateRate, opts.UpdateRate)
+	opts.UpdateBurst = envInt(EnvUpdateBurst, opts.UpdateBurst)
+	opts.MaxUpdatesInFlight = envInt(EnvMaxUpdatesInFlight, opts.MaxUpdatesInFlight)
+	if v, err := strconv.Atoi(os.Getenv(EnvSchedulerSlots)); err == nil && v >= 0 {
+		opts.SchedulerSlots = v
+	}
+	if v, err := strconv.Atoi(os.Getenv(EnvTenantConcurrency)); err == nil && v >= 0 {
+		opts.TenantConcurrency = v
+	}
+	opts.TenantLimits = envList(EnvTenantLimits)
+	opts.TenantWeights = envList(EnvTenantWeights)
+	if v := os.Getenv(EnvClusterResourceNS); v != "" {
+		opts.ClusterResourceNamespace = v
+	}
+	opts.DomainAllowlist = envList(EnvDomainAllowlist)
+	opts.DomainDenylist = envList(EnvDomainDenylist)
+	opts.DomainPolicyConfigMap = os.Getenv(EnvDomainPolicyCM)
+	opts.DefaultsConfigMap = os.Getenv(EnvDefaultsConfigMap)
+	opts.WarmupEnabled = envBool(EnvWarmupEnabled, opts.WarmupEnabled)
+	opts.WarmupTimeout = envDuration(EnvWarmupTimeout, opts.WarmupTimeout)
+	opts.PreflightEnabled = envBool(EnvPreflightEnabled, opts.PreflightEnabled)
+	opts.StateEnabled = envBool(EnvStateEnabled, opts.StateEnabled)
+	opts.StateNamespace = os.Getenv(EnvStateNamespace)
+	if v := os.Getenv(EnvStateConfigMap); v != "" {
+		opts.StateConfigMap = v
+	}
+	if v, ok := os.LookupEnv(EnvRepairJournalCM); ok {
+		opts.RepairJournalConfigMap = v
+	}
+	opts.ResultsConfigMap = os.Getenv(EnvResultsConfigMap)
+	opts.ResultsMaxEntries = envInt(EnvResultsMaxEntries, opts.ResultsMaxEntries)
+	opts.LeasesEnabled = envBool(EnvLeasesEnabled, opts.LeasesEnabled)
+	opts.LeaseIdentity = os.Getenv(EnvLeaseIdentity)
+	if opts.LeaseIdentity == "" {
+		opts.LeaseIdentity, _ = os.Hostname()
+	}
+	opts.LeaseDuration = envDuration(EnvLeaseDuration, opts.LeaseDuration)
+	opts.LeaseWaitTimeout = envDuration(EnvLeaseWaitTimeout, opts.LeaseWaitTimeout)
+	opts.GCEnabled = envBool(EnvGCEnabled, opts.GCEnabled)
+	opts.GCInterval = envDuration(EnvGCInterval, opts.GCInterval)
+	opts.GCMaxAge = envDuration(EnvGCMaxAge, opts.GCMaxAge)
+	opts.TracingEnabled = envBool(EnvTracingEnabled,
+		os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" || os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != "")
+	opts.RegistryOwnerID = os.Getenv(EnvRegistryOwnerID)
+	opts.EventsEnabled = envBool(EnvEventsEnabled, opts.EventsEnabled)
+	opts.EventsObject = os.Getenv(EnvEventsObject)
+	opts.DrainTimeout = envDuration(EnvDrainTimeout, opts.DrainTimeout)
+	if v, ok := os.LookupEnv(EnvHealthAddr); ok {
+		opts.HealthAddr = v
+	}
+	opts.HealthTargets = envList(EnvHealthTargets)
+	opts.HealthCacheTTL = envDuration(EnvHealthCacheTTL, opts.HealthCacheTTL)
+	opts.HealthTimeout = envDuration(EnvHealthTimeout, opts.HealthTimeout)
+	opts.AdminAddr = os.Getenv(EnvAdminAddr)
+	opts.AdminTokenFile = os.Getenv(EnvAdminTokenFile)
+	opts.AdminTLSCertFile = os.Getenv(EnvAdminTLSCert)
+	opts.AdminTLSKeyFile = os.Getenv(EnvAdminTLSKey)
+	opts.AdminClientCAFile = os.Getenv(EnvAdminClientCA)
+	if v, err := time.ParseDuration(os.Getenv(EnvSlowRequest)); err == nil && v >= 0 {
+		opts.SlowRequestThreshold = v
+	}
+	if v, err := strconv.ParseBool(os.Getenv(EnvDryRun)); err == nil {
+		opts.DryRun = &v
+	}
+	opts.LogLevel = envString(EnvLogLevel, opts.LogLevel)
+	opts.LogEncoding = envString(EnvLogEncoding, opts.LogEncoding)
+	opts.LogSampling = envBool(EnvLogSampling, opts.LogSampling)
+	opts.LogChallengeKeys = envBool(EnvLogChallengeKeys, opts.LogChallengeKeys)
+	opts.DNSFaults = os.Getenv(EnvDNSFaults)
+	opts.GroupName = envString(EnvGroupName, opts.GroupName)
+	opts.SolverName = envString(EnvSolverName, opts.SolverName)
+	return opts
+}
+
+// LoadOptions reads the options from the environment and the flags of the
+// process and leaves the flags of the cert-manager webhook server in os.Args
+func LoadOptions() (Options, error) {
+	opts := OptionsFromEnv()
+	args, err := opts.ApplyFlags(os.Args[1:])
+	if err != nil {
+		return opts, err
+	}
+	if err := opts.Validate(); err != nil {
+		return opts, err
+	}
+	os.Args = append(os.Args[:1], args...)
+	return opts, nil
+}
+
+// ApplyFlags sets the options given as --group-name, --solver-name,
+// --log-level and --log-encoding in args and returns the other arguments,
+// which belong to the cert-manager webhook server
+func (o *Options) ApplyFlags(args []string) ([]string, error) {
+	targets := map[string]*string{
+		FlagGroupName:   &o.GroupName,
+		FlagSolverName:  &o.SolverName,
+		FlagLogLevel:    &o.LogLevel,
+		FlagLogEncoding: &o.LogEncoding,
+	}
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			rest = append(rest, args[i:]...)
+			break
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		target, ok := targets[name]
+		if !ok || !strings.HasPrefix(arg, "-") {
+			rest = append(rest, arg)
+			continue
+		}
+		if !hasValue {
+			if i+1 == len(args) {
+				return nil, fmt.Errorf("flag --%s needs a value", name)
+			}
+			i++
+			value = args[i]
+		}
+		*target = value
+	}
+	return rest, nil
+}
+
+// Validate checks the settings the webhook cannot be registered or started without
+func (o Options) Validate() error {
+	if len(validation.IsDNS1123Subdomain(o.GroupName)) > 0 || !strings.Contains(o.GroupName, ".") {
+		return fmt.Errorf("group name %q must be a lowercase DNS subdomain such as acme.example.com", o.GroupName)
+	}
+	if len(validation.IsDNS1123Label(o.SolverName)) > 0 {
+		return fmt.Errorf("solver name %q must be a lowercase DNS label such as multi-dns", o.SolverName)
+	}
+	if _, err := parseTenantValues(o.TenantLimits); err != nil {
+		return fmt.Errorf("%s: %w", EnvTenantLimits, err)
+	}
+	if _, err := parseTenantValues(o.TenantWeights); err != nil {
+		return fmt.Errorf("%s: %w", EnvTenantWeights, err)
+	}
+	if err := dns.ValidateOwner(o.RegistryOwnerID); err != nil {
+		return fmt.Errorf("%s: %w", EnvRegistryOwnerID, err)
+	}
+	if err := validateNamespacePatterns(o.SecretAllowedNamespaces); err != nil {
+		return fmt.Errorf("%s: %w", EnvSecretAllowedNS, err)
+	}
+	if err := validateNamespacePatterns(o.SecretGrantNamespaces); err != nil {
+		return fmt.Errorf("%s: %w", EnvSecretGrantNS, err)
+	}
+	if err := o.validateAdmin(); err != nil {
+		return err
+	}
+	if _, err := zapcore.ParseLevel(o.LogLevel); err != nil {
+		return fmt.Errorf("log level %q must be debug, info, warn or error", o.LogLevel)
+	}
+	if o.LogEncoding != "json" && o.LogEncoding != "console" {
+		return fmt.Errorf("log encoding %q must be json or console", o.LogEncoding)
+	}
+	return nil
+}
+
+// validateAdmin refuses an admin API anyone could call: it needs a token,
+// client certificates, or both
+func (o Options) validateAdmin() error {
+	if o.AdminAddr == "" {
+		return nil
+	}
+	if o.AdminTokenFile == "" && o.AdminClientCAFile == "" {
+		return fmt.Errorf("%s needs %s or %s", EnvAdminAddr, EnvAdminTokenFile, EnvAdminClientCA)
+	}
+	if (o.AdminTLSCertFile == "") != (o.AdminTLSKeyFile == "") {
+		return fmt.Errorf("%s and %s must be set together", EnvAdminTLSCert, EnvAdminTLSKey)
+	}
+	if o.AdminClientCAFile != "" && o.AdminTLSCertFile == "" {
+		return fmt.Errorf("%s needs %s and %s", EnvAdminClientCA, EnvAdminTLSCert, EnvAdminTLSKey)
+	}
+	return nil
+}
+
+// stateNamespace returns the namespace of the webhook's own ConfigMaps
+func (o Options) stateNamespace() string {
+	if o.StateNamespace != "" {
+		return o.StateNamespace
+	}
+	return o.ClusterResourceNamespace
+}
+
+// envDuration returns the positive duration stored in the environment variable, or def
+func envDuration(name string, def time.Duration) time.Duration {
+	if v, err := time.ParseDuration(os.Getenv(name)); err == nil && v > 0 {
+		return v
+	}
+	return def
+}
+
+// envInt returns the positive integer stored in the environment variable, or def
+func envInt(name string, def int) int {
+	if v, err := strconv.Atoi(os.Getenv(name)); err == nil && v > 0 {
+		return v
+	}
+	return def
+}
+
+// envFloat returns the positive number stored in the environment variable, or def
+func envFloat(name string, def float64) float64 {
+	if v, err := strconv.ParseFloat(os.Getenv(name), 64); err == nil && v > 0 {
+		return v
+	}
+	return def
+}
+
+// envBool returns the boolean stored in the environment variable, or def
+func envBool(name string, def bool) bool {
+	if v, err := strconv.ParseBool(os.Getenv(name)); err == nil {
+		return v
+	}
+	return def
+}
+
+// envString returns the environment variable, or def when it is unset or empty
+func envString(name, def string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return def
+}
+
+// envList returns the non-empty comma-separated entries of the environment variable
+func envList(name string) []string {
+	var list []string
+	for _, v := range strings.Split(os.Getenv(name), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
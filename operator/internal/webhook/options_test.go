@@ -0,0 +1,146 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestOptionsApplyFlags(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantGroup  string
+		wantSolver string
+		wantRest   []string
+		wantErr    string
+	}{
+		{"none", []string{"--secure-port=8443"}, "acme.example.com", "multi-dns", []string{"--secure-port=8443"}, ""},
+		{"equals", []string{"--group-name=acme.corp.io", "--tls-cert-file", "/tls/tls.crt", "-solver-name=bind"},
+			"acme.corp.io", "bind", []string{"--tls-cert-file", "/tls/tls.crt"}, ""},
+		{"separate value", []string{"--solver-name", "bind", "--v=2"}, "acme.example.com", "bind", []string{"--v=2"}, ""},
+		{"after terminator", []string{"--", "--group-name=x"}, "acme.example.com", "multi-dns", []string{"--", "--group-name=x"}, ""},
+		{"log flags", []string{"--log-level=debug", "--log-encoding", "console", "--v=2"}, "acme.example.com", "multi-dns",
+			[]string{"--v=2"}, ""},
+		{"missing value", []string{"--group-name"}, "", "", nil, "flag --group-name needs a value"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			rest, err := opts.ApplyFlags(tt.args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ApplyFlags error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ApplyFlags: %v", err)
+			}
+			if opts.GroupName != tt.wantGroup || opts.SolverName != tt.wantSolver {
+				t.Fatalf("group, solver = %q, %q, want %q, %q", opts.GroupName, opts.SolverName, tt.wantGroup, tt.wantSolver)
+			}
+			if !reflect.DeepEqual(rest, tt.wantRest) {
+				t.Fatalf("rest = %q, want %q", rest, tt.wantRest)
+			}
+		})
+	}
+}
+
+func TestOptionsFromEnvNames(t *testing.T) {
+	t.Setenv(EnvGroupName, "acme.team-a.example.org")
+	t.Setenv(EnvSolverName, "team-a")
+	opts := OptionsFromEnv()
+	if opts.GroupName != "acme.team-a.example.org" || opts.SolverName != "team-a" {
+		t.Fatalf("group, solver = %q, %q", opts.GroupName, opts.SolverName)
+	}
+	if s := NewDNS01Solver(opts, zap.NewNop()); s.Name() != "team-a" {
+		t.Fatalf("Name() = %q, want team-a", s.Name())
+	}
+}
+
+func TestOptionsValidate(t *testing.T) {
+	tests := []struct {
+		group, solver string
+		wantErr       string
+	}{
+		{"acme.example.com", "multi-dns", ""},
+		{"acme", "multi-dns", `group name "acme"`},
+		{"Acme.Example.com", "multi-dns", `group name "Acme.Example.com"`},
+		{"acme.example.com", "", `solver name ""`},
+		{"acme.example.com", "multi.dns", `solver name "multi.dns"`},
+	}
+	for _, tt := range tests {
+		opts := DefaultOptions()
+		opts.GroupName, opts.SolverName = tt.group, tt.solver
+		err := opts.Validate()
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("Validate(%q, %q): %v", tt.group, tt.solver, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("Validate(%q, %q) error = %v, want %q", tt.group, tt.solver, err, tt.wantErr)
+		}
+	}
+}
+
+func TestOptionsValidateLogging(t *testing.T) {
+	tests := []struct {
+		level, encoding string
+		wantErr         string
+	}{
+		{"debug", "console", ""},
+		{"warn", "json", ""},
+		{"verbose", "json", `log level "verbose"`},
+		{"info", "text", `log encoding "text"`},
+	}
+	for _, tt := range tests {
+		opts := DefaultOptions()
+		opts.LogLevel, opts.LogEncoding = tt.level, tt.encoding
+		err := opts.Validate()
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("Validate(%q, %q): %v", tt.level, tt.encoding, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("Validate(%q, %q) error = %v, want %q", tt.level, tt.encoding, err, tt.wantErr)
+		}
+	}
+}
+
+func TestOptionsRegistryOwnerID(t *testing.T) {
+	t.Setenv(EnvRegistryOwnerID, "cluster-a")
+	opts := OptionsFromEnv()
+	if opts.RegistryOwnerID != "cluster-a" {
+		t.Fatalf("RegistryOwnerID = %q, want cluster-a", opts.RegistryOwnerID)
+	}
+	if err := opts.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	opts.RegistryOwnerID = "cluster=a"
+	if err := opts.Validate(); err == nil || !strings.Contains(err.Error(), EnvRegistryOwnerID) {
+		t.Fatalf("Validate with owner %q = %v, want an %s error", opts.RegistryOwnerID, err, EnvRegistryOwnerID)
+	}
+}
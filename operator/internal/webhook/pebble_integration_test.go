@@ -0,0 +1,177 @@
+//go:build integration
+
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"os"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/cert-manager/cert-manager/pkg/acme/webhook/apis/acme/v1alpha1"
+	"golang.org/x/crypto/acme"
+
+	"github.com/rieset/istio-dns01-bind9/internal/dns"
+)
+
+// The Pebble suite drives a full ACME order the way cert-manager does:
+// Present for every dns-01 challenge, accept, finalize, then CleanUp.
+// Start the environment with `make test-integration` or
+// `docker compose -f test/integration/pebble/docker-compose.yaml up -d`.
+//
+// Environment variables:
+// - PEBBLE_DIRECTORY: ACME directory URL (default https://localhost:14000/dir)
+// - PEBBLE_BIND_SERVER: BIND address reachable from the test (default 127.0.0.1:15353)
+
+const pebbleDomain = "app.example.com"
+
+func envOr(name, fallback string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func TestPebbleWildcardIssuance(t *testing.T) {
+	directory := envOr("PEBBLE_DIRECTORY", "https://localhost:14000/dir")
+	bind := envOr("PEBBLE_BIND_SERVER", "127.0.0.1:15353")
+	fqdn := "_acme-challenge." + pebbleDomain + "."
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	accountKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &acme.Client{
+		Key:          accountKey,
+		DirectoryURL: directory,
+		HTTPClient: &http.Client{Transport: &http.Transport{
+			// Pebble serves its directory with a throwaway certificate
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true}, //nolint:gosec
+		}},
+	}
+	if _, err := client.Register(ctx, &acme.Account{}, acme.AcceptTOS); err != nil {
+		t.Fatalf("failed to register ACME account (is Pebble running?): %v", err)
+	}
+
+	// The apex and the wildcard share one challenge name, so both TXT values
+	// must be published side by side.
+	order, err := client.AuthorizeOrder(ctx, acme.DomainIDs(pebbleDomain, "*."+pebbleDomain))
+	if err != nil {
+		t.Fatalf("failed to create order: %v", err)
+	}
+
+	s := newTestSolver(t)
+	var presented []*v1alpha1.ChallengeRequest
+	var accepted []*acme.Challenge
+	for _, authzURL := range order.AuthzURLs {
+		authz, err := client.GetAuthorization(ctx, authzURL)
+		if err != nil {
+			t.Fatalf("failed to get authorization: %v", err)
+		}
+		chal := dns01Challenge(authz)
+		if chal == nil {
+			t.Fatalf("authorization %s offers no dns-01 challenge", authzURL)
+		}
+		value, err := client.DNS01ChallengeRecord(chal.Token)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ch := newChallenge(t, []string{bind}, fqdn, value)
+		if err := s.Present(ch); err != nil {
+			t.Fatalf("Present: %v", err)
+		}
+		presented = append(presented, ch)
+		accepted = append(accepted, chal)
+	}
+
+	values, err := dns.QueryTXT(ctx, bind, fqdn, 5*time.Second)
+	if err != nil {
+		t.Fatalf("QueryTXT: %v", err)
+	}
+	if len(values) != len(presented) {
+		sort.Strings(values)
+		t.Fatalf("BIND publishes %v, want %d challenge values", values, len(presented))
+	}
+
+	for _, chal := range accepted {
+		if _, err := client.Accept(ctx, chal); err != nil {
+			t.Fatalf("failed to accept challenge: %v", err)
+		}
+	}
+	for _, authzURL := range order.AuthzURLs {
+		if _, err := client.WaitAuthorization(ctx, authzURL); err != nil {
+			t.Fatalf("authorization failed: %v", err)
+		}
+	}
+
+	order, err = client.WaitOrder(ctx, order.URI)
+	if err != nil {
+		t.Fatalf("order did not become ready: %v", err)
+	}
+	certKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: pebbleDomain},
+		DNSNames: []string{pebbleDomain, "*." + pebbleDomain},
+	}, certKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := client.CreateOrderCert(ctx, order.FinalizeURL, csr, true); err != nil {
+		t.Fatalf("failed to finalize order: %v", err)
+	}
+
+	for _, ch := range presented {
+		item := cleanupItem{Namespace: ch.ResourceNamespace, FQDN: ch.ResolvedFQDN, Value: ch.Key, Config: string(ch.Config.Raw)}
+		if err := cleanUp(ctx, s, item); err != nil {
+			t.Fatalf("cleanUp: %v", err)
+		}
+	}
+	values, err = dns.QueryTXT(ctx, bind, fqdn, 5*time.Second)
+	if err != nil {
+		t.Fatalf("QueryTXT: %v", err)
+	}
+	if len(values) != 0 {
+		t.Fatalf("BIND still publishes %v after cleanup", values)
+	}
+}
+
+// dns01Challenge returns the dns-01 challenge of authz, if offered
+func dns01Challenge(authz *acme.Authorization) *acme.Challenge {
+	for _, chal := range authz.Challenges {
+		if chal.Type == "dns-01" {
+			return chal
+		}
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"go.uber.org/zap"
+
+	"github.com/rieset/istio-dns01-bind9/internal/dns"
+	"github.com/rieset/istio-dns01-bind9/internal/metrics"
+	"github.com/rieset/istio-dns01-bind9/internal/solverconfig"
+)
+
+// FunctionRating: 78/100
+// - Complexity: LOW
+// - Integrations: 2 (dns package, metrics)
+// - External Risks: MEDIUM (queries resolvers outside the zone's servers)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: waitForResolvers
+// Purpose: Waits for the resolver sets of a config after the authoritative servers and reports where propagation is stuck
+
+// authoritativeSet labels the servers of the config, their nameservers and
+// secondaries in the propagation metrics
+const authoritativeSet = "authoritative"
+
+// waitForResolvers waits until each of sets serves the value of check, one
+// set after the other, and adds their resolvers to result. Each set is
+// logged and counted with the resolvers that did and did not converge.
+func (s *DNS01Solver) waitForResolvers(ctx context.Context, check dns.PropagationCheck,
+	sets []solverconfig.ResolverSetConfig, result dns.PropagationResult) (dns.PropagationResult, error) {
+	for _, set := range sets {
+		name := set.SetName()
+		servers := set.Servers
+		if set.Type == solverconfig.ResolverSetSystem {
+			var err error
+			if servers, err = dns.SystemResolvers(s.resolvConf); err != nil {
+				return result, fmt.Errorf("resolver set %s: %w", name, err)
+			}
+		}
+
+		// Resolvers are asked directly, without the TLS or proxy settings of
+		// the authoritative servers
+		resolverCheck := check
+		resolverCheck.Servers = servers
+		resolverCheck.MinMatches = set.MinMatches
+		resolverCheck.Recursive = true
+		resolverCheck.TLS = nil
+		resolverCheck.Proxies = nil
+		more, err := dns.WaitForPropagation(ctx, resolverCheck)
+		observeConvergence(name, more)
+		result.Matched = append(result.Matched, more.Matched...)
+		result.Pending = append(result.Pending, more.Pending...)
+		sort.Strings(result.Matched)
+		sort.Strings(result.Pending)
+		if err != nil {
+			s.logger.Warn("Resolver set did not converge",
+				zap.String("set", name),
+				zap.String("fqdn", check.FQDN),
+				zap.Strings("converged", more.Matched),
+				zap.Strings("pending", more.Pending),
+			)
+			return result, fmt.Errorf("resolver set %s: %w", name, err)
+		}
+		s.logger.Info("Resolver set converged",
+			zap.String("set", name),
+			zap.String("fqdn", check.FQDN),
+			zap.Strings("converged", more.Matched),
+			zap.Strings("pending", more.Pending),
+		)
+	}
+	return result, nil
+}
+
+// observeConvergence counts the resolvers of a finished check of set
+func observeConvergence(set string, result dns.PropagationResult) {
+	for _, server := range result.Matched {
+		metrics.PropagationChecks.WithLabelValues(set, server, "converged").Inc()
+	}
+	for _, server := range result.Pending {
+		metrics.PropagationChecks.WithLabelValues(set, server, "pending").Inc()
+	}
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRepairJournal(t *testing.T) {
+	s := newTestSolver(t)
+	journal := newRepairJournal(s.client, "cert-manager", "repairs", zap.NewNop())
+	ctx := context.Background()
+	a := repairItem{Namespace: "cert-manager", FQDN: testFQDN, Value: "token", Config: "{}", Server: "10.0.0.1"}
+	b := a
+	b.Server = "10.0.0.2"
+	other := a
+	other.Value = "other"
+
+	for _, item := range []repairItem{a, b, other, a} {
+		if err := journal.Put(ctx, item); err != nil {
+			t.Fatalf("Put: %v", err)
+		}
+	}
+	if items, err := journal.List(ctx); err != nil || len(items) != 3 {
+		t.Fatalf("List = %v, %v, want 3 items", items, err)
+	}
+
+	if err := journal.Delete(ctx, b); err != nil {
+		t.Fatal(err)
+	}
+	if err := journal.DeleteChallenge(ctx, testFQDN, "token"); err != nil {
+		t.Fatal(err)
+	}
+	if items, err := journal.List(ctx); err != nil || len(items) != 1 || items[0] != other {
+		t.Fatalf("List after deletes = %v, %v, want [%v]", items, err, other)
+	}
+
+	// Entries past repairJournalMaxAge are dropped
+	raw, err := json.Marshal(repairEntry{FQDN: testFQDN, Value: "old", Server: "10.0.0.1",
+		Queued: time.Now().Add(-repairJournalMaxAge - time.Minute)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = journal.data.Update(ctx, func(data map[string]string) bool {
+		data["stale"] = string(raw)
+		data["garbage"] = "{"
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if items, err := journal.List(ctx); err != nil || len(items) != 1 {
+		t.Fatalf("List with expired entries = %v, %v, want 1 item", items, err)
+	}
+	cm, err := s.client.CoreV1().ConfigMaps("cert-manager").Get(ctx, "repairs", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cm.Data) != 1 {
+		t.Fatalf("journal holds %d entries after pruning, want 1", len(cm.Data))
+	}
+}
+
+func TestRepairJournalSurvivesRestart(t *testing.T) {
+	servers := startServers(t, 3)
+	servers[0].SetUpdateRcode(dns.RcodeServerFailure)
+	// The queue of the first process never runs, as if it stopped right after Present
+	s := newTestSolver(t)
+	s.repairs = newRepairQueue(s.repairServer, s.opts, zap.NewNop())
+	s.repairs.journal = newRepairJournal(s.client, "cert-manager", "repairs", zap.NewNop())
+	ch := newChallenge(t, serverAddrs(servers), testFQDN, "token")
+
+	if err := s.Present(ch); err != nil {
+		t.Fatalf("Present: %v", err)
+	}
+	ctx := context.Background()
+	if items, err := s.repairs.journal.List(ctx); err != nil || len(items) != 1 || items[0].Server != servers[0].Addr() {
+		t.Fatalf("journal after Present = %v, %v, want the failing server", items, err)
+	}
+
+	// A new process with a fresh queue picks the repair up from the journal
+	servers[0].SetUpdateRcode(dns.RcodeSuccess)
+	next := newTestSolver(t)
+	next.client = s.client
+	next.repairs = newRepairQueue(next.repairServer, next.opts, zap.NewNop())
+	next.repairs.journal = newRepairJournal(next.client, "cert-manager", "repairs", zap.NewNop())
+	stopCh := make(chan struct{})
+	t.Cleanup(func() { close(stopCh) })
+	next.repairs.Start(stopCh)
+	next.repairs.Resume(ctx)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(servers[0].TXT(testFQDN)) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("lagging server never received the record after the restart")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	for {
+		items, err := next.repairs.journal.List(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(items) == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("journal still holds %v after the repair", items)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
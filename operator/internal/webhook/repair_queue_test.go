@@ -0,0 +1,116 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"go.uber.org/zap"
+
+	rfc2136 "github.com/rieset/istio-dns01-bind9/internal/dns"
+)
+
+// newRepairingSolver returns a test solver whose repair queue retries quickly
+func newRepairingSolver(t *testing.T) *DNS01Solver {
+	t.Helper()
+	s := newTestSolver(t)
+	s.opts.CleanupRetryBaseDelay = 10 * time.Millisecond
+	s.opts.CleanupRetryMaxDelay = 50 * time.Millisecond
+	s.cleanups = newCleanupQueue(s.cleanupRecord, s.opts, zap.NewNop())
+	s.repairs = newRepairQueue(s.repairServer, s.opts, zap.NewNop())
+	stopCh := make(chan struct{})
+	t.Cleanup(func() { close(stopCh) })
+	s.repairs.Start(stopCh)
+	return s
+}
+
+func TestRepairQueueCatchesUpLaggingServer(t *testing.T) {
+	servers := startServers(t, 3)
+	servers[0].SetUpdateRcode(dns.RcodeServerFailure)
+	s := newRepairingSolver(t)
+	ch := newChallenge(t, serverAddrs(servers), testFQDN, "token")
+
+	if err := s.Present(ch); err != nil {
+		t.Fatalf("Present: %v", err)
+	}
+	if got := servers[0].TXT(testFQDN); len(got) != 0 {
+		t.Fatalf("failing server has TXT %v", got)
+	}
+
+	servers[0].SetUpdateRcode(dns.RcodeSuccess)
+	deadline := time.Now().Add(5 * time.Second)
+	for len(servers[0].TXT(testFQDN)) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("lagging server never received the record")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if s.repairs.isPending(repairItem{Namespace: ch.ResourceNamespace, FQDN: testFQDN, Value: "token",
+		Config: string(ch.Config.Raw), Server: servers[0].Addr()}) {
+		t.Fatal("repair still pending after the server caught up")
+	}
+}
+
+func TestRepairQueueStopsAtCleanUp(t *testing.T) {
+	servers := startServers(t, 3)
+	servers[0].SetUpdateRcode(dns.RcodeServerFailure)
+	s := newRepairingSolver(t)
+	ch := newChallenge(t, serverAddrs(servers), testFQDN, "token")
+
+	if err := s.Present(ch); err != nil {
+		t.Fatalf("Present: %v", err)
+	}
+	if err := s.CleanUp(ch); err != nil {
+		t.Fatalf("CleanUp: %v", err)
+	}
+	item := repairItem{Namespace: ch.ResourceNamespace, FQDN: testFQDN, Value: "token",
+		Config: string(ch.Config.Raw), Server: servers[0].Addr()}
+	if s.repairs.isPending(item) {
+		t.Fatal("repair still pending after CleanUp")
+	}
+
+	servers[0].SetUpdateRcode(dns.RcodeSuccess)
+	time.Sleep(200 * time.Millisecond)
+	if got := servers[0].TXT(testFQDN); len(got) != 0 {
+		t.Fatalf("cancelled repair added TXT %v", got)
+	}
+}
+
+func TestRepairQueueDropsRejectedRepairs(t *testing.T) {
+	opts := DefaultOptions()
+	opts.CleanupRetryBaseDelay = time.Millisecond
+	opts.CleanupRetryMaxDelay = time.Millisecond
+	var calls int
+	q := newRepairQueue(func(context.Context, repairItem) error {
+		calls++
+		return fmt.Errorf("failed to add TXT record: %w", &rfc2136.RcodeError{Op: "update", Rcode: dns.RcodeRefused})
+	}, opts, zap.NewNop())
+	item := repairItem{FQDN: testFQDN, Value: "token", Server: "192.0.2.1:53"}
+
+	q.Enqueue(item)
+	q.processNextItem()
+	if q.isPending(item) {
+		t.Fatal("repair still pending after the server refused the update")
+	}
+	if q.queue.Len() != 0 || calls != 1 {
+		t.Fatalf("queue length %d after %d attempts, want the repair dropped after one", q.queue.Len(), calls)
+	}
+}
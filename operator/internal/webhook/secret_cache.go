@@ -0,0 +1,283 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/rieset/istio-dns01-bind9/internal/metrics"
+)
+
+// FunctionRating: 82/100
+// - Complexity: MEDIUM
+// - Integrations: 2 (kubernetes informers, metrics)
+// - External Risks: LOW (serves from memory, falls back to direct GET)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: secretCache
+// Purpose: Serves TSIG Secrets and the keys decoded from them from shared informer listers with a direct GET fallback
+
+// secretCache serves Secrets from shared informers so repeated challenges
+// do not hit the API server, and keeps working from memory while the API
+// server is briefly unavailable. Values decoded from a Secret, such as
+// SIG(0) signers and TLS configs, are kept until the informer sees the
+// Secret change, so rotated keys are picked up without a restart.
+type secretCache struct {
+	client    kubernetes.Interface
+	factories []informers.SharedInformerFactory
+	// listers holds the lister of every watched namespace, or of all
+	// namespaces under the key ""
+	listers map[string]corelisters.SecretLister
+	syncs   []cache.InformerSynced
+	logger  *zap.Logger
+
+	mu sync.Mutex
+	// decoded maps the namespace/name key of a Secret to its decoded values by kind
+	decoded map[string]map[string]decodedValue
+}
+
+// decodedValue is a value decoded from one version of a Secret
+type decodedValue struct {
+	resourceVersion string
+	value           any
+}
+
+// secretReads records the resource versions of the Secrets read through a
+// context, so values built from several Secrets can tell they are outdated
+type secretReads struct {
+	mu sync.Mutex
+	// versions maps the namespace/name key of a Secret to its resource version
+	versions map[string]string
+}
+
+// secretReadsKey is the context key of the secretReads of a context
+type secretReadsKey struct{}
+
+// withSecretReads returns a context recording the Secrets read through it in reads
+func withSecretReads(ctx context.Context, reads *secretReads) context.Context {
+	return context.WithValue(ctx, secretReadsKey{}, reads)
+}
+
+// recordSecretRead notes the version of the Secret namespace/name read
+// through ctx, if ctx records its reads
+func recordSecretRead(ctx context.Context, namespace, name, resourceVersion string) {
+	reads, ok := ctx.Value(secretReadsKey{}).(*secretReads)
+	if !ok {
+		return
+	}
+	reads.mu.Lock()
+	defer reads.mu.Unlock()
+	if reads.versions == nil {
+		reads.versions = map[string]string{}
+	}
+	reads.versions[namespace+"/"+name] = resourceVersion
+}
+
+// current reports whether every Secret of versions is still at its version
+// in the listers. Secrets without a version, as served by fakes, cannot
+// tell a change and never count as current.
+func (c *secretCache) current(versions map[string]string) bool {
+	for key, version := range versions {
+		namespace, name, err := cache.SplitMetaNamespaceKey(key)
+		if err != nil || version == "" {
+			return false
+		}
+		lister, ok := c.lister(namespace)
+		if !ok {
+			return false
+		}
+		secret, err := lister.Secrets(namespace).Get(name)
+		if err != nil || secret.ResourceVersion != version {
+			return false
+		}
+	}
+	return true
+}
+
+// newSecretCache creates a Secret cache bounded by the namespaces and selectors in opts
+func newSecretCache(client kubernetes.Interface, opts Options, logger *zap.Logger) *secretCache {
+	c := &secretCache{
+		client:  client,
+		listers: map[string]corelisters.SecretLister{},
+		logger:  logger,
+		decoded: map[string]map[string]decodedValue{},
+	}
+	namespaces := opts.SecretNamespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{metav1.NamespaceAll}
+	}
+	for _, namespace := range namespaces {
+		factoryOpts := []informers.SharedInformerOption{
+			informers.WithTweakListOptions(func(lo *metav1.ListOptions) {
+				lo.LabelSelector = opts.SecretLabelSelector
+				lo.FieldSelector = opts.SecretFieldSelector
+			}),
+		}
+		if namespace != metav1.NamespaceAll {
+			factoryOpts = append(factoryOpts, informers.WithNamespace(namespace))
+		}
+
+		factory := informers.NewSharedInformerFactoryWithOptions(client, opts.SecretResync, factoryOpts...)
+		informer := factory.Core().V1().Secrets()
+		_, _ = informer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+			UpdateFunc: func(old, obj any) {
+				// Resyncs replay unchanged Secrets
+				if o, ok := old.(*corev1.Secret); ok && o.ResourceVersion == obj.(*corev1.Secret).ResourceVersion {
+					return
+				}
+				c.forget(obj)
+			},
+			DeleteFunc: c.forget,
+		})
+		c.factories = append(c.factories, factory)
+		c.listers[namespace] = informer.Lister()
+		c.syncs = append(c.syncs, informer.Informer().HasSynced)
+	}
+	return c
+}
+
+// Start starts the informers; lookups fall back to direct GETs until they have synced
+func (c *secretCache) Start(stopCh <-chan struct{}) {
+	for _, factory := range c.factories {
+		factory.Start(stopCh)
+	}
+	go func() {
+		if cache.WaitForCacheSync(stopCh, c.syncs...) {
+			c.logger.Info("TSIG secret cache synced")
+		}
+	}()
+}
+
+// synced reports whether every informer has synced
+func (c *secretCache) synced() bool {
+	for _, synced := range c.syncs {
+		if !synced() {
+			return false
+		}
+	}
+	return true
+}
+
+// tracks reports whether the synced informers see every change of the
+// Secrets of namespace
+func (c *secretCache) tracks(namespace string) bool {
+	_, ok := c.lister(namespace)
+	return ok && c.synced()
+}
+
+// lister returns the lister watching namespace, if any
+func (c *secretCache) lister(namespace string) (corelisters.SecretLister, bool) {
+	if lister, ok := c.listers[metav1.NamespaceAll]; ok {
+		return lister, true
+	}
+	lister, ok := c.listers[namespace]
+	return lister, ok
+}
+
+// Get returns the Secret from the lister, or from the API server on a cache
+// miss or for a namespace outside the watched ones
+func (c *secretCache) Get(ctx context.Context, namespace, name string) (*corev1.Secret, error) {
+	if lister, ok := c.lister(namespace); ok && c.synced() {
+		secret, err := lister.Secrets(namespace).Get(name)
+		if err == nil {
+			metrics.SecretCacheLookups.WithLabelValues("hit").Inc()
+			recordSecretRead(ctx, namespace, name, secret.ResourceVersion)
+			return secret, nil
+		}
+		if !apierrors.IsNotFound(err) {
+			c.logger.Warn("Secret lister lookup failed, falling back to API server",
+				zap.String("namespace", namespace),
+				zap.String("secret", name),
+				zap.Error(err),
+			)
+		}
+	}
+
+	metrics.SecretCacheLookups.WithLabelValues("miss").Inc()
+	secret, err := c.client.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret %s/%s: %w", namespace, name, err)
+	}
+	recordSecretRead(ctx, namespace, name, secret.ResourceVersion)
+	return secret, nil
+}
+
+// decodeSecret returns the value decode derives from the data of a Secret,
+// decoding it again only once the Secret has changed. kind tells apart the
+// values decoded from one Secret and must name everything decode depends on
+// besides the data.
+func decodeSecret[T any](ctx context.Context, c *secretCache, namespace, name, kind string,
+	decode func(data map[string][]byte) (T, error)) (T, error) {
+	var zero T
+	secret, err := c.Get(ctx, namespace, name)
+	if err != nil {
+		return zero, err
+	}
+
+	key := namespace + "/" + name
+	c.mu.Lock()
+	cached, ok := c.decoded[key][kind]
+	c.mu.Unlock()
+	if value, typed := cached.value.(T); ok && typed && secret.ResourceVersion != "" &&
+		cached.resourceVersion == secret.ResourceVersion {
+		return value, nil
+	}
+
+	value, err := decode(secret.Data)
+	if err != nil {
+		return zero, err
+	}
+	// Secrets without a version, as served by fakes, cannot tell a change
+	if secret.ResourceVersion != "" {
+		c.mu.Lock()
+		if c.decoded[key] == nil {
+			c.decoded[key] = map[string]decodedValue{}
+		}
+		c.decoded[key][kind] = decodedValue{resourceVersion: secret.ResourceVersion, value: value}
+		c.mu.Unlock()
+	}
+	return value, nil
+}
+
+// forget drops the values decoded from a Secret the informer saw change or go
+func (c *secretCache) forget(obj any) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		return
+	}
+	c.mu.Lock()
+	_, ok := c.decoded[key]
+	delete(c.decoded, key)
+	c.mu.Unlock()
+	if ok {
+		c.logger.Info("Secret changed, dropping decoded keys", zap.String("secret", key))
+	}
+}
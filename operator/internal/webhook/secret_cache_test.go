@@ -0,0 +1,110 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestSecretCacheDecodesOncePerVersion(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "sig0", Namespace: "cert-manager", ResourceVersion: "1"},
+		Data:       map[string][]byte{"key": []byte("v1")},
+	}
+	client := fake.NewSimpleClientset(secret)
+	opts := DefaultOptions()
+	opts.SecretNamespaces = []string{"cert-manager"}
+	c := newSecretCache(client, opts, zap.NewNop())
+	stopCh := make(chan struct{})
+	t.Cleanup(func() { close(stopCh) })
+	c.Start(stopCh)
+	waitFor(t, c.synced)
+
+	ctx := context.Background()
+	decodes := 0
+	decode := func(data map[string][]byte) (string, error) {
+		decodes++
+		return string(data["key"]), nil
+	}
+	for range 2 {
+		value, err := decodeSecret(ctx, c, "cert-manager", "sig0", "test", decode)
+		if err != nil || value != "v1" {
+			t.Fatalf("decodeSecret = %q, %v, want v1", value, err)
+		}
+	}
+	if decodes != 1 {
+		t.Fatalf("decoded %d times, want 1", decodes)
+	}
+
+	// A rotated key is decoded again once the informer sees it
+	rotated := secret.DeepCopy()
+	rotated.ResourceVersion = "2"
+	rotated.Data["key"] = []byte("v2")
+	if _, err := client.CoreV1().Secrets("cert-manager").Update(ctx, rotated, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("update secret: %v", err)
+	}
+	waitFor(t, func() bool {
+		value, err := decodeSecret(ctx, c, "cert-manager", "sig0", "test", decode)
+		return err == nil && value == "v2"
+	})
+	if decodes != 2 {
+		t.Fatalf("decoded %d times after rotation, want 2", decodes)
+	}
+}
+
+func TestSecretCacheNamespaces(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "tsig", Namespace: "a"}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "tsig", Namespace: "c"}},
+	)
+	opts := DefaultOptions()
+	opts.SecretNamespaces = []string{"a", "b"}
+	c := newSecretCache(client, opts, zap.NewNop())
+	stopCh := make(chan struct{})
+	t.Cleanup(func() { close(stopCh) })
+	c.Start(stopCh)
+	waitFor(t, c.synced)
+
+	if _, ok := c.lister("c"); ok {
+		t.Fatal("namespace c has a lister, want only a and b watched")
+	}
+	// Namespaces outside the watched ones are read from the API server
+	for _, namespace := range []string{"a", "c"} {
+		if _, err := c.Get(context.Background(), namespace, "tsig"); err != nil {
+			t.Fatalf("Get(%s/tsig): %v", namespace, err)
+		}
+	}
+}
+
+// waitFor polls cond until it holds or a second has passed
+func waitFor(t testing.TB, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met within a second")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
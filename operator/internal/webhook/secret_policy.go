@@ -0,0 +1,174 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/rieset/istio-dns01-bind9/internal/metrics"
+)
+
+// FunctionRating: 80/100
+// - Complexity: MEDIUM
+// - Integrations: 2 (Kubernetes Secrets, metrics)
+// - External Risks: MEDIUM (guards the credentials of every tenant)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: secretPolicy
+// Purpose: Decides which namespaces' Secrets a challenge may read and audits every decision
+
+// SecretGrantAnnotation lists, comma separated, the namespaces whose
+// challenges may read a Secret of a grant namespace. Entries may be globs
+// such as team-*.
+const SecretGrantAnnotation = "dns.istio-dns01-bind9.rieset.io/secret-grant"
+
+// ErrSecretAccessDenied is returned for Secrets the secret access policy keeps from a challenge
+var ErrSecretAccessDenied = errors.New("secret access denied by the webhook's secret policy")
+
+// Reasons of the secret access decisions, as logged and counted
+const (
+	secretReasonSameNamespace = "same-namespace"
+	secretReasonGranted       = "granted"
+	secretReasonNotAllowed    = "namespace-not-allowed"
+	secretReasonNoGrantNS     = "not-a-grant-namespace"
+	secretReasonNoGrant       = "no-grant"
+	secretReasonLookupFailed  = "lookup-failed"
+)
+
+// secretGetter reads Secrets with their metadata, as secretCache does
+type secretGetter interface {
+	Get(ctx context.Context, namespace, name string) (*corev1.Secret, error)
+}
+
+// secretPolicy decides whether a challenge of one namespace may read a
+// Secret. Secrets are only read from allowed namespaces, all of them when
+// none are configured. A challenge reads the Secrets of its own namespace;
+// those of another namespace only when that is a grant namespace and the
+// Secret names the challenge's namespace in SecretGrantAnnotation.
+type secretPolicy struct {
+	allowed []string
+	grants  []string
+	logger  *zap.Logger
+}
+
+// newSecretPolicy returns the policy of opts, logging its decisions to logger
+func newSecretPolicy(opts Options, logger *zap.Logger) *secretPolicy {
+	return &secretPolicy{
+		allowed: opts.SecretAllowedNamespaces,
+		grants:  opts.SecretGrantNamespaces,
+		logger:  logger.Named("secret-audit"),
+	}
+}
+
+// validateNamespacePatterns checks every entry of patterns is a valid glob
+func validateNamespacePatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil || pattern == "" {
+			return fmt.Errorf("invalid namespace pattern %q", pattern)
+		}
+	}
+	return nil
+}
+
+// matchesNamespace reports whether namespace matches one of patterns
+func matchesNamespace(patterns []string, namespace string) bool {
+	return slices.ContainsFunc(patterns, func(pattern string) bool {
+		ok, _ := path.Match(strings.TrimSpace(pattern), namespace)
+		return ok
+	})
+}
+
+// authorize returns nil when a challenge in requester may read the Secret
+// namespace/name, looking up its grant through secrets for another
+// namespace, and an error wrapping ErrSecretAccessDenied otherwise. Every
+// decision is logged and counted.
+func (p *secretPolicy) authorize(ctx context.Context, secrets secretGetter, requester, namespace, name string) error {
+	reason, err := p.decide(ctx, secrets, requester, namespace, name)
+	decision := "allow"
+	if reason != secretReasonSameNamespace && reason != secretReasonGranted {
+		decision = "deny"
+	}
+	metrics.SecretAccessDecisions.WithLabelValues(decision, reason).Inc()
+	fields := []zap.Field{
+		zap.String("requester", requester),
+		zap.String("namespace", namespace),
+		zap.String("secret", name),
+		zap.String("decision", decision),
+		zap.String("reason", reason),
+	}
+	if decision == "allow" {
+		p.logger.Info("Secret access allowed", fields...)
+		return nil
+	}
+	if err != nil {
+		p.logger.Warn("Secret access denied", append(fields, zap.Error(err))...)
+		return err
+	}
+	p.logger.Warn("Secret access denied", fields...)
+	return fmt.Errorf("%w: secret %s/%s for namespace %s: %s", ErrSecretAccessDenied, namespace, name, requester, reason)
+}
+
+// decide returns the reason of the decision on the Secret namespace/name
+// for requester, and the error of a failed grant lookup
+func (p *secretPolicy) decide(ctx context.Context, secrets secretGetter, requester, namespace, name string) (string, error) {
+	if len(validation.IsDNS1123Label(namespace)) > 0 ||
+		(len(p.allowed) > 0 && !matchesNamespace(p.allowed, namespace)) {
+		return secretReasonNotAllowed, nil
+	}
+	if namespace == requester {
+		return secretReasonSameNamespace, nil
+	}
+	if !matchesNamespace(p.grants, namespace) {
+		return secretReasonNoGrantNS, nil
+	}
+	// Grants are annotations, which only Kubernetes Secrets carry
+	if secrets == nil {
+		return secretReasonNoGrant, nil
+	}
+	secret, err := secrets.Get(ctx, namespace, name)
+	if err != nil {
+		return secretReasonLookupFailed, err
+	}
+	if !matchesNamespace(strings.Split(secret.Annotations[SecretGrantAnnotation], ","), requester) {
+		return secretReasonNoGrant, nil
+	}
+	return secretReasonGranted, nil
+}
+
+// secretNamespace returns the namespace the Secret name of config is read
+// from through provider for a challenge in namespace, once the secret access
+// policy allowed it. Grants are only looked up through the informer cache.
+func (s *DNS01Solver) secretNamespace(ctx context.Context, namespace string, config *Config, name string, provider secretProvider) (string, error) {
+	target := config.SecretsNamespace(namespace)
+	grants, _ := provider.(secretGetter)
+	if err := s.access.authorize(ctx, grants, namespace, target, name); err != nil {
+		return "", err
+	}
+	return target, nil
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/rieset/istio-dns01-bind9/internal/solverconfig"
+)
+
+func TestSecretPolicy(t *testing.T) {
+	secret := func(namespace, name, grant string) *corev1.Secret {
+		s := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+		if grant != "" {
+			s.Annotations = map[string]string{SecretGrantAnnotation: grant}
+		}
+		return s
+	}
+	client := fake.NewSimpleClientset(
+		secret("central", "tsig", "team-a, team-b*"),
+		secret("central", "plain", ""),
+		secret("other", "tsig", "team-a"),
+		secret("team-a", "tsig", ""),
+	)
+	opts := DefaultOptions()
+	cache := newSecretCache(client, opts, zap.NewNop())
+
+	tests := []struct {
+		name      string
+		allowed   []string
+		requester string
+		namespace string
+		secret    string
+		noGetter  bool
+		wantErr   bool
+		notDenied bool
+	}{
+		{name: "own namespace", requester: "team-a", namespace: "team-a", secret: "tsig"},
+		{name: "granted", requester: "team-a", namespace: "central", secret: "tsig"},
+		{name: "granted by glob", requester: "team-b2", namespace: "central", secret: "tsig"},
+		{name: "not granted", requester: "team-c", namespace: "central", secret: "tsig", wantErr: true},
+		{name: "no grant annotation", requester: "team-a", namespace: "central", secret: "plain", wantErr: true},
+		{name: "not a grant namespace", requester: "team-a", namespace: "other", secret: "tsig", wantErr: true},
+		{name: "grant without a Kubernetes Secret", requester: "team-a", namespace: "central", secret: "tsig",
+			noGetter: true, wantErr: true},
+		{name: "namespace not allowed", allowed: []string{"central"}, requester: "team-a", namespace: "team-a",
+			secret: "tsig", wantErr: true},
+		{name: "allowed by glob", allowed: []string{"team-*"}, requester: "team-a", namespace: "team-a", secret: "tsig"},
+		{name: "invalid namespace", requester: "team-a", namespace: "../central", secret: "tsig", wantErr: true},
+		{name: "missing secret", requester: "team-a", namespace: "central", secret: "missing",
+			wantErr: true, notDenied: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.SecretAllowedNamespaces = tt.allowed
+			opts.SecretGrantNamespaces = []string{"central"}
+			policy := newSecretPolicy(opts, zap.NewNop())
+			var getter secretGetter = cache
+			if tt.noGetter {
+				getter = nil
+			}
+			err := policy.authorize(context.Background(), getter, tt.requester, tt.namespace, tt.secret)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("authorize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && errors.Is(err, ErrSecretAccessDenied) == tt.notDenied {
+				t.Fatalf("authorize() error = %v, want ErrSecretAccessDenied %v", err, !tt.notDenied)
+			}
+		})
+	}
+}
+
+func TestSolverSecretNamespace(t *testing.T) {
+	s := newTestSolver(t)
+	config := &Config{TSIGSecretName: "tsig", TSIGSecretKey: "secret", SecretNamespace: "cert-manager"}
+
+	// cert-manager is no grant namespace, so only its own challenges may read it
+	if _, err := s.tsigSecretData(context.Background(), "cert-manager", config, "tsig"); err != nil {
+		t.Fatalf("tsigSecretData from its own namespace: %v", err)
+	}
+	if _, err := s.tsigSecretData(context.Background(), "team-a", config, "tsig"); !errors.Is(err, ErrSecretAccessDenied) {
+		t.Fatalf("tsigSecretData without a grant = %v, want ErrSecretAccessDenied", err)
+	}
+
+	opts := DefaultOptions()
+	opts.SecretGrantNamespaces = []string{"cert-manager"}
+	s.access = newSecretPolicy(opts, zap.NewNop())
+	granted, err := s.secrets.Get(context.Background(), "cert-manager", "tsig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	granted = granted.DeepCopy()
+	granted.Annotations = map[string]string{SecretGrantAnnotation: "team-a"}
+	if _, err := s.client.CoreV1().Secrets("cert-manager").Update(context.Background(), granted,
+		metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := s.tsigSecretData(context.Background(), "team-a", config, "tsig")
+	if err != nil {
+		t.Fatalf("tsigSecretData with a grant: %v", err)
+	}
+	if _, err := secretValue(data, config.SecretsNamespace("team-a"), "tsig", "secret"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The file provider cannot carry grants
+	config.SecretProvider = solverconfig.SecretProviderFile
+	if _, err := s.tsigSecretData(context.Background(), "team-a", config, "tsig"); !errors.Is(err, ErrSecretAccessDenied) {
+		t.Fatalf("tsigSecretData through the file provider = %v, want ErrSecretAccessDenied", err)
+	}
+}
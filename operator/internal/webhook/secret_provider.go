@@ -0,0 +1,314 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/rieset/istio-dns01-bind9/internal/solverconfig"
+)
+
+// FunctionRating: 76/100
+// - Complexity: MEDIUM
+// - Integrations: 3 (Kubernetes Secrets, mounted files, HashiCorp Vault)
+// - External Risks: MEDIUM (reads credentials from external systems)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: secretProvider
+// Purpose: Pluggable backends the TSIG secrets of a solver config are read from
+
+// secretProvider reads the data of a named Secret of a namespace
+type secretProvider interface {
+	SecretData(ctx context.Context, namespace, name string) (map[string][]byte, error)
+}
+
+// SecretData implements secretProvider with the informer cache
+func (c *secretCache) SecretData(ctx context.Context, namespace, name string) (map[string][]byte, error) {
+	secret, err := c.Get(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	return secret.Data, nil
+}
+
+// secretPath checks namespace and name are Kubernetes names, which keeps them
+// from escaping the directory or path prefix they are appended to
+func secretPath(namespace, name string) (string, error) {
+	if errs := validation.IsDNS1123Label(namespace); len(errs) > 0 {
+		return "", fmt.Errorf("invalid namespace %q: %s", namespace, strings.Join(errs, ", "))
+	}
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		return "", fmt.Errorf("invalid secret name %q: %s", name, strings.Join(errs, ", "))
+	}
+	return namespace + "/" + name, nil
+}
+
+// fileSecretProvider reads Secrets mounted as <dir>/<namespace>/<name>/<key>
+// files, the layout of Secret and projected volumes mounted per Secret. Files
+// are read on every lookup, so updates of the volume are picked up at once.
+type fileSecretProvider struct {
+	dir string
+}
+
+// SecretData implements secretProvider; trailing newlines of the files are dropped
+func (p *fileSecretProvider) SecretData(_ context.Context, namespace, name string) (map[string][]byte, error) {
+	path, err := secretPath(namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Join(p.dir, filepath.FromSlash(path))
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secret %s: %w", path, err)
+	}
+
+	data := map[string][]byte{}
+	for _, entry := range entries {
+		// Volumes keep their atomic-update directories and ..data link next to the keys
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		file := filepath.Join(dir, entry.Name())
+		if info, err := os.Stat(file); err != nil || info.IsDir() {
+			continue
+		}
+		value, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read secret %s: %w", path, err)
+		}
+		data[entry.Name()] = bytes.TrimRight(value, "\r\n")
+	}
+	return data, nil
+}
+
+// vaultRenewMargin is the share of a token's lease after which a new one is requested
+const vaultRenewMargin = 0.75
+
+// errVaultForbidden is returned when Vault refuses a token, which then is replaced
+var errVaultForbidden = errors.New("vault denied access")
+
+// vaultSecretProvider reads Secrets from the KV v2 engine of HashiCorp Vault.
+// It logs in with the Kubernetes auth method and keeps the short-lived token
+// it gets until three quarters of its lease have passed.
+type vaultSecretProvider struct {
+	opts   VaultOptions
+	client *http.Client
+	// err is returned by every lookup when the provider could not be set up
+	err error
+
+	mu      sync.Mutex
+	token   string
+	renewAt time.Time
+}
+
+// newVaultSecretProvider returns the provider of opts; a bad CA file makes
+// every lookup fail instead of the webhook
+func newVaultSecretProvider(opts VaultOptions) *vaultSecretProvider {
+	p := &vaultSecretProvider{opts: opts, client: &http.Client{Timeout: 10 * time.Second}}
+	if opts.CACert == "" {
+		return p
+	}
+	pem, err := os.ReadFile(opts.CACert)
+	if err != nil {
+		p.err = fmt.Errorf("failed to read Vault CA: %w", err)
+		return p
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		p.err = fmt.Errorf("no certificates in Vault CA %s", opts.CACert)
+		return p
+	}
+	p.client.Transport = &http.Transport{
+		Proxy:           http.ProxyFromEnvironment,
+		TLSClientConfig: &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12},
+	}
+	return p
+}
+
+// SecretData implements secretProvider. String values of the Vault secret
+// become the keys of the Secret; a token Vault refuses is replaced once.
+func (p *vaultSecretProvider) SecretData(ctx context.Context, namespace, name string) (map[string][]byte, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	path, err := secretPath(namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	secretURL := fmt.Sprintf("%s/v1/%s/data/%s%s", strings.TrimSuffix(p.opts.Address, "/"),
+		strings.Trim(p.opts.KVMount, "/"), p.opts.PathPrefix, path)
+
+	var reply struct {
+		Data struct {
+			Data map[string]any `json:"data"`
+		} `json:"data"`
+	}
+	for attempt := 0; ; attempt++ {
+		token, err := p.clientToken(ctx)
+		if err != nil {
+			return nil, err
+		}
+		err = p.do(ctx, http.MethodGet, secretURL, token, nil, &reply)
+		if errors.Is(err, errVaultForbidden) && attempt == 0 {
+			p.dropToken(token)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read secret %s from Vault: %w", path, err)
+		}
+		break
+	}
+
+	data := make(map[string][]byte, len(reply.Data.Data))
+	for key, value := range reply.Data.Data {
+		if s, ok := value.(string); ok {
+			data[key] = []byte(s)
+		}
+	}
+	return data, nil
+}
+
+// clientToken returns the current token, logging in when there is none or it is due for renewal
+func (p *vaultSecretProvider) clientToken(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.token != "" && time.Now().Before(p.renewAt) {
+		return p.token, nil
+	}
+
+	jwt, err := os.ReadFile(p.opts.TokenPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read service account token for Vault: %w", err)
+	}
+	var reply struct {
+		Auth struct {
+			ClientToken   string `json:"client_token"`
+			LeaseDuration int    `json:"lease_duration"`
+		} `json:"auth"`
+	}
+	loginURL := fmt.Sprintf("%s/v1/auth/%s/login", strings.TrimSuffix(p.opts.Address, "/"), strings.Trim(p.opts.AuthPath, "/"))
+	body := map[string]string{"role": p.opts.Role, "jwt": strings.TrimSpace(string(jwt))}
+	if err := p.do(ctx, http.MethodPost, loginURL, "", body, &reply); err != nil {
+		return "", fmt.Errorf("vault login failed: %w", err)
+	}
+	if reply.Auth.ClientToken == "" {
+		return "", fmt.Errorf("vault login returned no token")
+	}
+
+	p.token = reply.Auth.ClientToken
+	lease := time.Duration(reply.Auth.LeaseDuration) * time.Second
+	if lease <= 0 {
+		// Tokens without a lease are kept until Vault refuses them
+		lease = 24 * time.Hour
+	}
+	p.renewAt = time.Now().Add(time.Duration(float64(lease) * vaultRenewMargin))
+	return p.token, nil
+}
+
+// dropToken forgets token so the next lookup logs in again
+func (p *vaultSecretProvider) dropToken(token string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.token == token {
+		p.token = ""
+	}
+}
+
+// do sends a Vault API request and decodes the JSON reply into out
+func (p *vaultSecretProvider) do(ctx context.Context, method, target, token string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if p.opts.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", p.opts.Namespace)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch {
+	case resp.StatusCode == http.StatusForbidden:
+		return errVaultForbidden
+	case resp.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("not found")
+	case resp.StatusCode/100 != 2:
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(out)
+}
+
+// tsigSecretProvider returns the backend the TSIG secrets of config are read from
+func (s *DNS01Solver) tsigSecretProvider(config *Config) (secretProvider, error) {
+	switch config.TSIGSecretProvider() {
+	case solverconfig.SecretProviderFile:
+		return &fileSecretProvider{dir: s.opts.SecretFileDir}, nil
+	case solverconfig.SecretProviderVault:
+		if s.vault == nil {
+			return nil, fmt.Errorf("vault secret provider is not configured, set %s", EnvVaultAddr)
+		}
+		return s.vault, nil
+	}
+	if s.secrets == nil {
+		return nil, fmt.Errorf("kubernetes client not initialized")
+	}
+	return s.secrets, nil
+}
+
+// tsigSecretData returns the data of the TSIG Secret name of config from its secret provider
+func (s *DNS01Solver) tsigSecretData(ctx context.Context, namespace string, config *Config, name string) (map[string][]byte, error) {
+	provider, err := s.tsigSecretProvider(config)
+	if err != nil {
+		return nil, err
+	}
+	namespace, err = s.secretNamespace(ctx, namespace, config, name, provider)
+	if err != nil {
+		return nil, err
+	}
+	return provider.SecretData(ctx, namespace, name)
+}
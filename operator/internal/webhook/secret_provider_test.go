@@ -0,0 +1,179 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/rieset/istio-dns01-bind9/internal/dnstest"
+)
+
+func TestFileSecretProvider(t *testing.T) {
+	dir := t.TempDir()
+	secretDir := filepath.Join(dir, "cert-manager", "tsig")
+	// Volume layout: keys link into a timestamped directory through ..data
+	if err := os.MkdirAll(filepath.Join(secretDir, "..2026_01_01"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(secretDir, "..2026_01_01", "secret"), []byte("c2VjcmV0\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("..2026_01_01", filepath.Join(secretDir, "..data")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join("..data", "secret"), filepath.Join(secretDir, "secret")); err != nil {
+		t.Fatal(err)
+	}
+
+	p := &fileSecretProvider{dir: dir}
+	data, err := p.SecretData(context.Background(), "cert-manager", "tsig")
+	if err != nil {
+		t.Fatalf("SecretData: %v", err)
+	}
+	if want := map[string][]byte{"secret": []byte("c2VjcmV0")}; !reflect.DeepEqual(data, want) {
+		t.Fatalf("SecretData = %q, want %q", data, want)
+	}
+	for _, name := range []string{"../tsig", "missing"} {
+		if _, err := p.SecretData(context.Background(), "cert-manager", name); err == nil {
+			t.Fatalf("SecretData(%q) succeeded", name)
+		}
+	}
+}
+
+// fakeVault serves the Kubernetes auth login and the KV v2 reads of a Vault
+// holding secrets, issuing a new token on every login
+type fakeVault struct {
+	secrets map[string]map[string]string
+	logins  atomic.Int32
+	token   atomic.Value
+}
+
+func (v *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/v1/auth/kubernetes/login":
+		var body map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body["role"] != "dns01" || body["jwt"] != "sa-token" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		token := "token-" + strconv.Itoa(int(v.logins.Add(1)))
+		v.token.Store(token)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"auth": map[string]any{"client_token": token, "lease_duration": 3600},
+		})
+	case r.Method == http.MethodGet:
+		if current, _ := v.token.Load().(string); r.Header.Get("X-Vault-Token") != current {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		data, ok := v.secrets[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"data": data}})
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// startFakeVault serves vault and returns the options of a provider reading from it
+func startFakeVault(t *testing.T, vault *fakeVault) VaultOptions {
+	t.Helper()
+	srv := httptest.NewServer(vault)
+	t.Cleanup(srv.Close)
+	tokenPath := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenPath, []byte("sa-token\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	opts := DefaultOptions().Vault
+	opts.Address = srv.URL
+	opts.Role = "dns01"
+	opts.TokenPath = tokenPath
+	return opts
+}
+
+func TestVaultSecretProvider(t *testing.T) {
+	vault := &fakeVault{secrets: map[string]map[string]string{
+		"/v1/secret/data/dns01/cert-manager/tsig": {"secret": "c2VjcmV0"},
+	}}
+	p := newVaultSecretProvider(startFakeVault(t, vault))
+	ctx := context.Background()
+
+	for range 2 {
+		data, err := p.SecretData(ctx, "cert-manager", "tsig")
+		if err != nil {
+			t.Fatalf("SecretData: %v", err)
+		}
+		if got := string(data["secret"]); got != "c2VjcmV0" {
+			t.Fatalf("secret = %q, want c2VjcmV0", got)
+		}
+	}
+	if got := vault.logins.Load(); got != 1 {
+		t.Fatalf("logged in %d times, want the token reused", got)
+	}
+
+	// A token Vault revoked is replaced by a new login
+	vault.token.Store("revoked")
+	if _, err := p.SecretData(ctx, "cert-manager", "tsig"); err != nil {
+		t.Fatalf("SecretData after revocation: %v", err)
+	}
+	if got := vault.logins.Load(); got != 2 {
+		t.Fatalf("logged in %d times, want a new login after revocation", got)
+	}
+	if _, err := p.SecretData(ctx, "other", "tsig"); err == nil {
+		t.Fatal("SecretData of a missing secret succeeded")
+	}
+}
+
+func TestSolverPresentWithVaultSecret(t *testing.T) {
+	servers := startServers(t, 1)
+	vault := &fakeVault{secrets: map[string]map[string]string{
+		"/v1/secret/data/dns01/cert-manager/bind-tsig": {"secret": dnstest.TestSecret},
+	}}
+	s := newTestSolver(t)
+	s.vault = newVaultSecretProvider(startFakeVault(t, vault))
+
+	ch := newChallenge(t, serverAddrs(servers), testFQDN, "token")
+	var config map[string]any
+	if err := json.Unmarshal(ch.Config.Raw, &config); err != nil {
+		t.Fatal(err)
+	}
+	config["secretProvider"] = "vault"
+	config["tsigSecretName"] = "bind-tsig"
+	raw, err := json.Marshal(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ch.Config.Raw = raw
+
+	if err := s.Present(ch); err != nil {
+		t.Fatalf("Present: %v", err)
+	}
+	if got := servers[0].TXT(testFQDN); !reflect.DeepEqual(got, []string{"token"}) {
+		t.Fatalf("TXT = %v, want [token]", got)
+	}
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"fmt"
+	"sync"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/rieset/istio-dns01-bind9/internal/solverconfig"
+)
+
+// FunctionRating: 82/100
+// - Complexity: LOW
+// - Integrations: 1 (Kubernetes ConfigMap informer)
+// - External Risks: MEDIUM (changes the servers and credentials of every Issuer relying on them)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: solverDefaults
+// Purpose: Default solver config from a ConfigMap, reloaded as it changes, that Issuer configs override
+
+// solverDefaults holds the default solver config the configs of Issuers are
+// merged over. Without a ConfigMap there are no defaults.
+type solverDefaults struct {
+	mu       sync.RWMutex
+	defaults map[string]any
+}
+
+// apply returns raw with the current defaults merged in; nil has no defaults
+func (d *solverDefaults) apply(raw []byte) ([]byte, error) {
+	if d == nil {
+		return raw, nil
+	}
+	d.mu.RLock()
+	defaults := d.defaults
+	d.mu.RUnlock()
+	if defaults == nil {
+		return raw, nil
+	}
+	merged, err := solverconfig.MergeDefaults(defaults, raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply solver defaults: %w", err)
+	}
+	return merged, nil
+}
+
+// update replaces the defaults with those of cm, or drops them when cm is
+// nil. A ConfigMap with invalid defaults is ignored and the previous kept.
+func (d *solverDefaults) update(cm *corev1.ConfigMap) error {
+	var defaults map[string]any
+	if cm != nil {
+		raw, ok := cm.Data[solverconfig.DefaultsKey]
+		if !ok {
+			return fmt.Errorf("key %s is missing", solverconfig.DefaultsKey)
+		}
+		parsed, err := solverconfig.ParseDefaults([]byte(raw))
+		if err != nil {
+			return err
+		}
+		defaults = parsed
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.defaults = defaults
+	return nil
+}
+
+// watch keeps the defaults in sync with the ConfigMap name in namespace
+func (d *solverDefaults) watch(client kubernetes.Interface, namespace, name string, stopCh <-chan struct{},
+	logger *zap.Logger) {
+	factory := informers.NewSharedInformerFactoryWithOptions(client, 0,
+		informers.WithNamespace(namespace),
+		informers.WithTweakListOptions(func(lo *metav1.ListOptions) {
+			lo.FieldSelector = fields.OneTermEqualSelector("metadata.name", name).String()
+		}),
+	)
+	apply := func(obj any) {
+		cm, _ := obj.(*corev1.ConfigMap)
+		if err := d.update(cm); err != nil {
+			logger.Error("Ignoring invalid solver defaults ConfigMap",
+				zap.String("namespace", namespace),
+				zap.String("configmap", name),
+				zap.Error(err),
+			)
+			return
+		}
+		logger.Info("Solver defaults updated",
+			zap.String("configmap", name),
+			zap.Bool("present", cm != nil),
+		)
+	}
+	_, _ = factory.Core().V1().ConfigMaps().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    apply,
+		UpdateFunc: func(_, obj any) { apply(obj) },
+		DeleteFunc: func(any) { apply(nil) },
+	})
+	factory.Start(stopCh)
+}
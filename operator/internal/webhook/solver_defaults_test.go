@@ -0,0 +1,86 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSolverDefaultsConfigMap(t *testing.T) {
+	s := newTestSolver(t)
+	stopCh := make(chan struct{})
+	t.Cleanup(func() { close(stopCh) })
+	s.defaults.watch(s.client, "cert-manager", "dns01-defaults", stopCh, zap.NewNop())
+
+	if _, err := s.parseConfig(nil); err == nil || !strings.Contains(err.Error(), "config is empty") {
+		t.Fatalf("parseConfig without config or defaults: err = %v", err)
+	}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "dns01-defaults", Namespace: "cert-manager"},
+		Data: map[string]string{"config.json": `{"servers":["192.0.2.1","192.0.2.2"],"zone":"example.com",` +
+			`"tsigKeyName":"acme","tsigSecretName":"tsig","ttl":120}`},
+	}
+	ctx := context.Background()
+	configMaps := s.client.CoreV1().ConfigMaps("cert-manager")
+	if _, err := configMaps.Create(ctx, cm, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool {
+		_, err := s.parseConfig(nil)
+		return err == nil
+	})
+
+	config, err := s.parseConfig(&apiextensionsv1.JSON{Raw: []byte(`{"zone":"example.org","ttl":30}`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Zone != "example.org" || config.TTL != 30 || config.TSIGKeyName != "acme." ||
+		!reflect.DeepEqual(config.Servers, []string{"192.0.2.1", "192.0.2.2"}) {
+		t.Errorf("merged config = %+v", config)
+	}
+
+	// Invalid defaults keep the previous ones
+	cm.Data["config.json"] = `{"severs":["192.0.2.3"]}`
+	if _, err := configMaps.Update(ctx, cm, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	cm.Data["config.json"] = `{"servers":["192.0.2.3"],"tsigKeyName":"acme","tsigSecretName":"tsig"}`
+	if _, err := configMaps.Update(ctx, cm, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool {
+		config, err := s.parseConfig(nil)
+		return err == nil && reflect.DeepEqual(config.Servers, []string{"192.0.2.3"})
+	})
+
+	if err := configMaps.Delete(ctx, cm.Name, metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool {
+		_, err := s.parseConfig(nil)
+		return err != nil
+	})
+}
@@ -0,0 +1,234 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rieset/istio-dns01-bind9/internal/metrics"
+)
+
+// FunctionRating: 78/100
+// - Complexity: MEDIUM
+// - Integrations: 1 (metrics)
+// - External Risks: LOW (in-process scheduling only)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: tenantScheduler
+// Purpose: Weighted round-robin admission of Present and CleanUp work across Issuer namespaces
+
+// tenant is the scheduler state of one Issuer namespace
+type tenant struct {
+	name string
+	// waiting holds the operations waiting for a slot, oldest first
+	waiting []chan struct{}
+	// running counts the operations holding a slot
+	running int
+	limit   int
+	weight  int
+	// credit is the smooth weighted round-robin counter of the tenant
+	credit int
+}
+
+// tenantScheduler bounds the challenge operations doing DNS work at once.
+// Operations are grouped by the namespace of their Issuer, ClusterIssuers
+// sharing the cluster resource namespace. Each tenant runs at most its
+// limit of operations, and when a slot frees up the waiting tenants get it
+// in weighted round-robin order, so a bulk renewal of one tenant queues
+// behind its own work instead of in front of everyone else's.
+type tenantScheduler struct {
+	mu      sync.Mutex
+	slots   int
+	running int
+	// tenants holds the tenants with waiting or running operations in
+	// order of arrival, which breaks ties between equal credits
+	tenants      []*tenant
+	defaultLimit int
+	limits       map[string]int
+	weights      map[string]int
+}
+
+// newTenantScheduler creates the scheduler of opts, or nil when
+// SchedulerSlots disables it. The tenant settings were checked by Validate.
+func newTenantScheduler(opts Options) *tenantScheduler {
+	if opts.SchedulerSlots <= 0 {
+		return nil
+	}
+	limits, _ := parseTenantValues(opts.TenantLimits)
+	weights, _ := parseTenantValues(opts.TenantWeights)
+	return &tenantScheduler{
+		slots:        opts.SchedulerSlots,
+		defaultLimit: opts.TenantConcurrency,
+		limits:       limits,
+		weights:      weights,
+	}
+}
+
+// parseTenantValues parses "namespace=n" entries with positive n
+func parseTenantValues(entries []string) (map[string]int, error) {
+	values := map[string]int{}
+	for _, entry := range entries {
+		name, raw, ok := strings.Cut(entry, "=")
+		n, err := strconv.Atoi(strings.TrimSpace(raw))
+		if name = strings.TrimSpace(name); !ok || name == "" || err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid entry %q, expected namespace=n with n > 0", entry)
+		}
+		values[name] = n
+	}
+	return values, nil
+}
+
+// acquire waits until name may start an operation and returns the function
+// ending it. A nil scheduler admits everything right away.
+func (s *tenantScheduler) acquire(ctx context.Context, name string) (func(), error) {
+	if s == nil {
+		return func() {}, nil
+	}
+	s.mu.Lock()
+	t := s.tenant(name)
+	if len(t.waiting) == 0 && s.running < s.slots && t.running < t.limit {
+		s.start(t)
+		s.mu.Unlock()
+		return s.releaser(t), nil
+	}
+	ready := make(chan struct{})
+	t.waiting = append(t.waiting, ready)
+	metrics.TenantOperationsWaiting.Inc()
+	s.mu.Unlock()
+
+	queued := time.Now()
+	select {
+	case <-ready:
+		metrics.TenantWaitSeconds.Observe(time.Since(queued).Seconds())
+		return s.releaser(t), nil
+	case <-ctx.Done():
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, waiter := range t.waiting {
+		if waiter == ready {
+			t.waiting = append(t.waiting[:i], t.waiting[i+1:]...)
+			metrics.TenantOperationsWaiting.Dec()
+			s.forgetIfIdle(t)
+			return nil, ctx.Err()
+		}
+	}
+	// The slot was handed over while ctx ended; pass it on
+	s.finish(t)
+	return nil, ctx.Err()
+}
+
+// releaser returns the function giving the slot of t back exactly once
+func (s *tenantScheduler) releaser(t *tenant) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.finish(t)
+		})
+	}
+}
+
+// tenant returns the state of name, adding it; caller must hold the lock
+func (s *tenantScheduler) tenant(name string) *tenant {
+	for _, t := range s.tenants {
+		if t.name == name {
+			return t
+		}
+	}
+	t := &tenant{name: name, limit: s.defaultLimit, weight: 1}
+	if limit, ok := s.limits[name]; ok {
+		t.limit = limit
+	}
+	if t.limit <= 0 || t.limit > s.slots {
+		t.limit = s.slots
+	}
+	if weight, ok := s.weights[name]; ok {
+		t.weight = weight
+	}
+	s.tenants = append(s.tenants, t)
+	return t
+}
+
+// start gives t a slot; caller must hold the lock
+func (s *tenantScheduler) start(t *tenant) {
+	t.running++
+	s.running++
+}
+
+// finish takes the slot of t back and hands free slots to the waiting
+// tenants; caller must hold the lock
+func (s *tenantScheduler) finish(t *tenant) {
+	t.running--
+	s.running--
+	s.dispatch()
+	s.forgetIfIdle(t)
+}
+
+// dispatch hands free slots to waiting operations with smooth weighted
+// round-robin: every eligible tenant earns its weight in credit, the one
+// with the most credit runs next and pays the total weight back. Over time
+// each tenant gets slots in proportion to its weight. Caller must hold the lock.
+func (s *tenantScheduler) dispatch() {
+	for s.running < s.slots {
+		var next *tenant
+		total := 0
+		for _, t := range s.tenants {
+			if len(t.waiting) == 0 || t.running >= t.limit {
+				continue
+			}
+			t.credit += t.weight
+			total += t.weight
+			if next == nil || t.credit > next.credit {
+				next = t
+			}
+		}
+		if next == nil {
+			return
+		}
+		next.credit -= total
+		ready := next.waiting[0]
+		next.waiting = next.waiting[1:]
+		metrics.TenantOperationsWaiting.Dec()
+		s.start(next)
+		close(ready)
+	}
+}
+
+// forgetIfIdle drops t once it has no waiting or running operations;
+// caller must hold the lock
+func (s *tenantScheduler) forgetIfIdle(t *tenant) {
+	if len(t.waiting) > 0 || t.running > 0 {
+		return
+	}
+	for i, other := range s.tenants {
+		if other == t {
+			s.tenants = append(s.tenants[:i], s.tenants[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// grant is an operation admitted by a tenantScheduler
+type grant struct {
+	tenant  string
+	release func()
+}
+
+// queueOperations starts n operations of name that report their grant,
+// and waits until all of them are queued
+func queueOperations(t *testing.T, s *tenantScheduler, name string, n int, granted chan<- grant) {
+	t.Helper()
+	for range n {
+		go func() {
+			release, err := s.acquire(context.Background(), name)
+			if err == nil {
+				granted <- grant{name, release}
+			}
+		}()
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		s.mu.Lock()
+		waiting := len(s.tenant(name).waiting)
+		s.mu.Unlock()
+		if waiting == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d operations of %s queued, want %d", waiting, name, n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestTenantSchedulerLimits(t *testing.T) {
+	opts := DefaultOptions()
+	opts.SchedulerSlots, opts.TenantConcurrency = 3, 2
+	opts.TenantLimits = []string{"big=3"}
+	s := newTenantScheduler(opts)
+
+	releaseA1, _ := s.acquire(context.Background(), "a")
+	if _, err := s.acquire(context.Background(), "a"); err != nil {
+		t.Fatalf("second operation of a: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := s.acquire(ctx, "a"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("third operation of a = %v, want to wait beyond the tenant limit", err)
+	}
+	if _, err := s.acquire(context.Background(), "b"); err != nil {
+		t.Fatalf("operation of b: %v", err)
+	}
+
+	// Every slot is taken: c waits until a gives one back
+	granted := make(chan grant, 1)
+	queueOperations(t, s, "c", 1, granted)
+	releaseA1()
+	releaseA1()
+	if g := <-granted; g.tenant != "c" {
+		t.Fatalf("granted %s, want c", g.tenant)
+	}
+	if s.running != 3 {
+		t.Fatalf("running = %d, want 3 after releasing twice", s.running)
+	}
+	if got := s.tenant("big").limit; got != 3 {
+		t.Fatalf("limit of big = %d, want 3", got)
+	}
+
+	if newTenantScheduler(Options{}) != nil {
+		t.Fatal("scheduler created without slots")
+	}
+	if release, err := (*tenantScheduler)(nil).acquire(context.Background(), "a"); err != nil || release == nil {
+		t.Fatalf("disabled scheduler acquire = %v", err)
+	}
+}
+
+func TestTenantSchedulerFairness(t *testing.T) {
+	tests := []struct {
+		name    string
+		weights []string
+		bulk    int
+		other   int
+		grants  int
+		want    map[string]int
+	}{
+		// A bulk renewal queued first does not delay the other tenant
+		{"equal weights", nil, 10, 2, 4, map[string]int{"bulk": 2, "other": 2}},
+		{"weighted", []string{"other=2"}, 10, 10, 6, map[string]int{"bulk": 2, "other": 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.SchedulerSlots, opts.TenantConcurrency = 1, 0
+			opts.TenantWeights = tt.weights
+			s := newTenantScheduler(opts)
+			hold, err := s.acquire(context.Background(), "holder")
+			if err != nil {
+				t.Fatal(err)
+			}
+			granted := make(chan grant, tt.bulk+tt.other)
+			queueOperations(t, s, "bulk", tt.bulk, granted)
+			queueOperations(t, s, "other", tt.other, granted)
+
+			hold()
+			got := map[string]int{}
+			for range tt.grants {
+				g := <-granted
+				got[g.tenant]++
+				g.release()
+			}
+			if got["bulk"] != tt.want["bulk"] || got["other"] != tt.want["other"] {
+				t.Fatalf("grants = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTenantValues(t *testing.T) {
+	values, err := parseTenantValues([]string{"team-a=3", " team-b = 1 "})
+	if err != nil || values["team-a"] != 3 || values["team-b"] != 1 {
+		t.Fatalf("parseTenantValues = %v, %v", values, err)
+	}
+	for _, entry := range []string{"team-a", "=2", "team-a=0", "team-a=x"} {
+		if _, err := parseTenantValues([]string{entry}); err == nil {
+			t.Errorf("parseTenantValues(%q) succeeded", entry)
+		}
+	}
+	opts := DefaultOptions()
+	opts.TenantWeights = []string{"team-a"}
+	if err := opts.Validate(); err == nil || !strings.Contains(err.Error(), EnvTenantWeights) {
+		t.Fatalf("Validate error = %v, want %s", err, EnvTenantWeights)
+	}
+}
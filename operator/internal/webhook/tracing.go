@@ -0,0 +1,88 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/rieset/istio-dns01-bind9/internal/dns"
+)
+
+// FunctionRating: 82/100
+// - Complexity: LOW
+// - Integrations: 1 (OpenTelemetry OTLP exporter)
+// - External Risks: LOW (export failures only drop spans)
+// - Unit Tests: YES (spans through an in-memory exporter)
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: setupTracing
+// Purpose: OTLP export of the spans of Present, CleanUp and the DNS updates
+
+// defaultServiceName names the webhook in traces unless OTEL_SERVICE_NAME is set
+const defaultServiceName = "istio-dns01-bind9-webhook"
+
+// tracer creates the spans of the solver and of MultiServerDNS
+var tracer = otel.Tracer(dns.TracerName + "/internal/webhook")
+
+// setupTracing installs a global tracer provider exporting spans over
+// OTLP/gRPC. The exporter reads the standard OTEL_EXPORTER_OTLP_* variables
+// and the resource OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES. The
+// returned function flushes pending spans and must be called on shutdown.
+func setupTracing(ctx context.Context) (func(context.Context) error, error) {
+	exporter, err := otlptracegrpc.New(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP trace exporter: %w", err)
+	}
+	res, err := resource.Merge(
+		resource.NewSchemaless(semconv.ServiceName(defaultServiceName)),
+		resource.Environment(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{},
+	))
+	return provider.Shutdown, nil
+}
+
+// startChallengeSpan starts the span of a solver operation on the challenge
+// record fqdn of a resource in namespace
+func startChallengeSpan(ctx context.Context, name, namespace, fqdn string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, trace.WithAttributes(
+		attribute.String("dns.name", fqdn),
+		attribute.String("k8s.namespace.name", namespace),
+	))
+}
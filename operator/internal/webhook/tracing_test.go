@@ -0,0 +1,62 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSolverPresentSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	servers := startServers(t, 2)
+	s := newTestSolver(t)
+	if err := s.Present(newChallenge(t, serverAddrs(servers), testFQDN, "token")); err != nil {
+		t.Fatalf("Present: %v", err)
+	}
+
+	byName := map[string][]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		byName[span.Name()] = append(byName[span.Name()], span)
+	}
+	if len(byName["dns01.Present"]) != 1 {
+		t.Fatalf("recorded %d dns01.Present spans, want 1", len(byName["dns01.Present"]))
+	}
+	root := byName["dns01.Present"][0]
+	if len(byName["multi_server.add"]) != len(servers) || len(byName["dns.update"]) != len(servers) {
+		t.Fatalf("recorded %d multi_server.add and %d dns.update spans, want %d each",
+			len(byName["multi_server.add"]), len(byName["dns.update"]), len(servers))
+	}
+
+	perServer := map[string]bool{}
+	for _, span := range byName["multi_server.add"] {
+		if span.Parent().SpanID() != root.SpanContext().SpanID() {
+			t.Errorf("multi_server.add is not a child of dns01.Present")
+		}
+		perServer[span.SpanContext().SpanID().String()] = true
+	}
+	for _, span := range byName["dns.update"] {
+		if !perServer[span.Parent().SpanID().String()] {
+			t.Errorf("dns.update is not a child of a multi_server.add span")
+		}
+	}
+}
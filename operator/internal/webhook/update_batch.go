@@ -0,0 +1,162 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/rieset/istio-dns01-bind9/internal/dns"
+)
+
+// FunctionRating: 80/100
+// - Complexity: MEDIUM
+// - Integrations: 1 (MultiServerDNS)
+// - External Risks: LOW (delays adds by the batch window only)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: addBatcher
+// Purpose: Aggregates the challenge records of one zone arriving within a short window into one UPDATE per server
+
+// batchedAdd is one challenge record of a batch with the progress callbacks of its challenge
+type batchedAdd struct {
+	value     dns.TXTValue
+	onServer  func(server string)
+	onLagging func(server string)
+}
+
+// addBatch collects the records added to one zone with one config within a window
+type addBatch struct {
+	zone string
+	// manager and ttl of the first challenge are used for the whole batch
+	manager *MultiServerDNS
+	ttl     int
+	// span of the first challenge is the parent of the batch's spans
+	span  trace.Span
+	items []batchedAdd
+	done  chan struct{}
+	err   error
+}
+
+// addBatcher holds back adds for window so that adds with the same key
+// arriving meanwhile, such as the challenges of a SAN certificate, are sent
+// in a single UPDATE per server
+type addBatcher struct {
+	window  time.Duration
+	timeout time.Duration
+	send    func(ctx context.Context, batch *addBatch) error
+
+	mu      sync.Mutex
+	pending map[string]*addBatch
+}
+
+// batchKey groups the adds of one zone made with the same config in the same
+// namespace, which therefore go to the same servers with the same key
+func batchKey(namespace, zone, config string) string {
+	return namespace + "\x00" + zone + "\x00" + config
+}
+
+// newAddBatcher returns a batcher sending each batch with send, bounded by timeout
+func newAddBatcher(window, timeout time.Duration, send func(ctx context.Context, batch *addBatch) error) *addBatcher {
+	return &addBatcher{
+		window:  window,
+		timeout: timeout,
+		send:    send,
+		pending: map[string]*addBatch{},
+	}
+}
+
+// add joins item to the open batch of key, opening one when there is none,
+// and waits until the batch has been sent
+func (b *addBatcher) add(ctx context.Context, key, zone string, manager *MultiServerDNS, ttl int, item batchedAdd) error {
+	b.mu.Lock()
+	batch := b.pending[key]
+	if batch == nil {
+		batch = &addBatch{
+			zone:    zone,
+			manager: manager,
+			ttl:     ttl,
+			span:    trace.SpanFromContext(ctx),
+			done:    make(chan struct{}),
+		}
+		b.pending[key] = batch
+		time.AfterFunc(b.window, func() { b.flush(key, batch) })
+	}
+	batch.items = append(batch.items, item)
+	b.mu.Unlock()
+
+	select {
+	case <-batch.done:
+		return batch.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// flush closes the batch of key to new records and sends it
+func (b *addBatcher) flush(key string, batch *addBatch) {
+	b.mu.Lock()
+	delete(b.pending, key)
+	b.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(trace.ContextWithSpan(context.Background(), batch.span), b.timeout)
+	defer cancel()
+	batch.err = b.send(ctx, batch)
+	close(batch.done)
+}
+
+// values returns the records of the batch
+func (batch *addBatch) values() []dns.TXTValue {
+	values := make([]dns.TXTValue, len(batch.items))
+	for i, item := range batch.items {
+		values[i] = item.value
+	}
+	return values
+}
+
+// sendAddBatch adds the records of batch through the shared worker pool while
+// this instance owns the zone, reporting every server to each challenge
+func (s *DNS01Solver) sendAddBatch(ctx context.Context, batch *addBatch) error {
+	batch.manager.SetServerCallback(func(server string) {
+		for _, item := range batch.items {
+			if item.onServer != nil {
+				item.onServer(server)
+			}
+		}
+	})
+	batch.manager.SetLaggingCallback(func(server string) {
+		for _, item := range batch.items {
+			if item.onLagging != nil {
+				item.onLagging(server)
+			}
+		}
+	})
+
+	values := batch.values()
+	return s.updateZone(ctx, batch.zone, func(ctx context.Context) error {
+		if len(values) == 1 {
+			return batch.manager.AddTXTRecord(ctx, values[0].FQDN, values[0].Value, batch.ttl)
+		}
+		return batch.manager.AddTXTRecords(ctx, values, batch.ttl)
+	})
+}
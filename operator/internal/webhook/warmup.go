@@ -0,0 +1,214 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	cmacme "github.com/cert-manager/cert-manager/pkg/apis/acme/v1"
+	cmversioned "github.com/cert-manager/cert-manager/pkg/client/clientset/versioned"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/rieset/istio-dns01-bind9/internal/dns"
+	"github.com/rieset/istio-dns01-bind9/internal/solverconfig"
+)
+
+// FunctionRating: 74/100
+// - Complexity: MEDIUM
+// - Integrations: 3 (cert-manager clientset, dns package, secret cache)
+// - External Risks: LOW (best effort, failures are only logged)
+// - Unit Tests: NO
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: warmup
+// Purpose: Pre-resolves servers, probes zones and pre-fetches secrets referenced by Issuers at startup
+
+// solverReference is one Issuer solver stanza that targets this webhook
+type solverReference struct {
+	// Issuer is namespace/name (namespace empty for ClusterIssuers)
+	Issuer string
+	// Namespace is where challenges of this issuer look up their secrets
+	Namespace string
+	Config    *Config
+}
+
+// warmup runs best-effort startup work so the first challenge after a restart
+// is not slower than steady state. Every failure is logged and ignored.
+func (s *DNS01Solver) warmup(ctx context.Context, kubeClientConfig *rest.Config, stopCh <-chan struct{}) {
+	ctx, cancel := context.WithTimeout(ctx, s.opts.WarmupTimeout)
+	defer cancel()
+
+	refs, err := s.listSolverReferences(ctx, kubeClientConfig)
+	if err != nil {
+		s.logger.Warn("Warm-up skipped: unable to list issuers", zap.Error(err))
+		return
+	}
+
+	var servers []string
+	for _, ref := range refs {
+		servers = append(servers, ref.Config.Servers...)
+	}
+	for host, err := range dns.PrewarmHosts(ctx, servers) {
+		s.logger.Warn("Warm-up failed to resolve DNS server", zap.String("server", host), zap.Error(err))
+	}
+
+	for _, ref := range refs {
+		if ref.Config.Zone == "" {
+			continue
+		}
+		for _, server := range ref.Config.Servers {
+			if _, err := s.zones.LookupSOA(ctx, server, ref.Config.Zone); err != nil {
+				s.logger.Warn("Warm-up SOA probe failed",
+					zap.String("issuer", ref.Issuer),
+					zap.String("server", server),
+					zap.String("zone", ref.Config.Zone),
+					zap.Error(err),
+				)
+			}
+		}
+	}
+
+	if !cache.WaitForCacheSync(mergeDone(ctx, stopCh), s.secrets.synced) {
+		s.logger.Warn("Warm-up timed out waiting for the TSIG secret cache")
+	}
+	for _, ref := range refs {
+		var secretNames []string
+		// TSIG secrets of other backends are fetched through them, which
+		// also logs in to Vault ahead of the first challenge
+		external := ref.Config.TSIGSecretProvider() != solverconfig.SecretProviderKubernetes
+		if name, _ := ref.Config.SecretRef(); name != "" && !external {
+			secretNames = append(secretNames, name)
+		}
+		if ref.Config.Bridge != nil && ref.Config.Bridge.Route53 != nil {
+			secretNames = append(secretNames, ref.Config.Bridge.Route53.CredentialsSecretName)
+		}
+		for _, server := range ref.Config.Servers {
+			if name := ref.Config.ServerTLSConfig(server).SecretName; name != "" && !slices.Contains(secretNames, name) {
+				secretNames = append(secretNames, name)
+			}
+			if _, ok := ref.Config.ServerEntries[server]; !ok {
+				continue
+			}
+			if name := ref.Config.ServerSettings(server).TSIGSecretName; name != "" && !external &&
+				!slices.Contains(secretNames, name) {
+				secretNames = append(secretNames, name)
+			}
+		}
+		if external && ref.Config.TSIGSecretName != "" {
+			if _, err := s.tsigSecretData(ctx, ref.Namespace, ref.Config, ref.Config.TSIGSecretName); err != nil {
+				s.logger.Warn("Warm-up failed to fetch TSIG secret",
+					zap.String("issuer", ref.Issuer),
+					zap.String("provider", ref.Config.TSIGSecretProvider()),
+					zap.String("secret", ref.Config.TSIGSecretName),
+					zap.Error(err),
+				)
+			}
+		}
+		for _, secretName := range secretNames {
+			if _, err := s.getSecretData(ctx, ref.Namespace, ref.Config, secretName); err != nil {
+				s.logger.Warn("Warm-up failed to fetch solver secret",
+					zap.String("issuer", ref.Issuer),
+					zap.String("secret", secretName),
+					zap.Error(err),
+				)
+			}
+		}
+	}
+
+	s.logger.Info("Webhook warm-up completed",
+		zap.Int("issuers", len(refs)),
+		zap.Int("servers", len(servers)),
+	)
+}
+
+// listSolverReferences returns the solver configs of all Issuers and ClusterIssuers targeting this webhook
+func (s *DNS01Solver) listSolverReferences(ctx context.Context, kubeClientConfig *rest.Config) ([]solverReference, error) {
+	cm, err := cmversioned.NewForConfig(kubeClientConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cert-manager client: %w", err)
+	}
+
+	issuers, err := cm.CertmanagerV1().Issuers(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list issuers: %w", err)
+	}
+	clusterIssuers, err := cm.CertmanagerV1().ClusterIssuers().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cluster issuers: %w", err)
+	}
+
+	var refs []solverReference
+	for _, issuer := range issuers.Items {
+		if issuer.Spec.ACME == nil {
+			continue
+		}
+		refs = append(refs, s.solverReferences(issuer.Namespace+"/"+issuer.Name, issuer.Namespace,
+			issuer.Spec.ACME.Solvers)...)
+	}
+	for _, issuer := range clusterIssuers.Items {
+		if issuer.Spec.ACME == nil {
+			continue
+		}
+		refs = append(refs, s.solverReferences(issuer.Name, s.opts.ClusterResourceNamespace,
+			issuer.Spec.ACME.Solvers)...)
+	}
+	return refs, nil
+}
+
+// solverReferences extracts the parseable webhook solver stanzas that target
+// this solver, one reference per zone of a config with zones
+func (s *DNS01Solver) solverReferences(issuer, namespace string, solvers []cmacme.ACMEChallengeSolver) []solverReference {
+	var refs []solverReference
+	for _, solver := range solvers {
+		if solver.DNS01 == nil || solver.DNS01.Webhook == nil {
+			continue
+		}
+		wh := solver.DNS01.Webhook
+		if wh.GroupName != s.opts.GroupName || wh.SolverName != s.Name() {
+			continue
+		}
+		config, err := s.parseConfig(wh.Config)
+		if err != nil {
+			s.logger.Warn("Warm-up skipped invalid solver config", zap.String("issuer", issuer), zap.Error(err))
+			continue
+		}
+		for _, zoneConfig := range config.ZoneConfigs() {
+			refs = append(refs, solverReference{Issuer: issuer, Namespace: namespace, Config: zoneConfig})
+		}
+	}
+	return refs
+}
+
+// mergeDone returns a channel closed when either ctx is done or stopCh is closed
+func mergeDone(ctx context.Context, stopCh <-chan struct{}) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		select {
+		case <-ctx.Done():
+		case <-stopCh:
+		}
+	}()
+	return done
+}
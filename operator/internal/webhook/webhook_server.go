@@ -0,0 +1,77 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"net/http"
+	"os"
+
+	"github.com/cert-manager/cert-manager/pkg/acme/webhook"
+	"github.com/cert-manager/cert-manager/pkg/acme/webhook/cmd/server"
+	cmlogs "github.com/cert-manager/cert-manager/pkg/logs"
+	"github.com/spf13/pflag"
+	genericapiserver "k8s.io/apiserver/pkg/server"
+	"k8s.io/component-base/logs"
+)
+
+// FunctionRating: 70/100
+// - Complexity: LOW
+// - Integrations: 2 (cert-manager solver API server, Kubernetes apiserver library)
+// - External Risks: MEDIUM (serves every challenge request of cert-manager)
+// - Unit Tests: NO
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: runWebhookServer
+// Purpose: Runs the cert-manager solver API server with a middleware around its handler chain
+
+// runWebhookServer runs the solver API server like cert-manager's
+// cmd.RunWebhookServer, reading its flags from the command line, but with
+// wrap applied around the whole handler chain. It returns once the server
+// stopped after SIGTERM or SIGINT, or failed to start.
+func runWebhookServer(groupName string, solver webhook.Solver, wrap func(http.Handler) http.Handler) error {
+	logs.InitLogs()
+	defer logs.FlushLogs()
+
+	options := server.NewWebhookServerOptions(os.Stdout, os.Stderr, groupName, solver)
+	// An extension apiserver has no use for priority and fairness, which
+	// would need its own RBAC and resources
+	options.RecommendedOptions.Features.EnablePriorityAndFairness = false
+	flags := pflag.NewFlagSet(os.Args[0], pflag.ContinueOnError)
+	cmlogs.AddFlags(options.Logging, flags)
+	options.RecommendedOptions.AddFlags(flags)
+	if err := flags.Parse(os.Args[1:]); err != nil {
+		return err
+	}
+	if err := options.Validate(flags.Args()); err != nil {
+		return err
+	}
+
+	config, err := options.Config()
+	if err != nil {
+		return err
+	}
+	config.GenericConfig.BuildHandlerChainFunc = func(handler http.Handler, c *genericapiserver.Config) http.Handler {
+		return wrap(genericapiserver.DefaultBuildHandlerChain(handler, c))
+	}
+	apiServer, err := config.Complete().New()
+	if err != nil {
+		return err
+	}
+	return apiServer.GenericAPIServer.PrepareRun().Run(genericapiserver.SetupSignalHandler())
+}
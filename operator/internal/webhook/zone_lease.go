@@ -0,0 +1,307 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/ptr"
+)
+
+// FunctionRating: 72/100
+// - Complexity: HIGH
+// - Integrations: 1 (Kubernetes coordination API)
+// - External Risks: MEDIUM (API server availability delays zone updates)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: zoneLeases
+// Purpose: Per-zone ownership leases so only one webhook instance mutates a zone at a time
+
+// zoneLeasePrefix prefixes the names of zone ownership Leases
+const zoneLeasePrefix = "dns01-zone-"
+
+// heldLease is a zone lease this instance holds on behalf of refs operations
+type heldLease struct {
+	refs int
+	stop chan struct{}
+}
+
+// zoneLeases hands out coordination.k8s.io Leases, one per zone. Operations
+// of the same instance share a held lease; other instances wait until it is
+// released or expires because its holder stopped renewing it.
+type zoneLeases struct {
+	client    kubernetes.Interface
+	namespace string
+	identity  string
+	duration  time.Duration
+	// waitTimeout bounds how long Acquire waits for another instance
+	waitTimeout   time.Duration
+	retryInterval time.Duration
+	logger        *zap.Logger
+
+	mu   sync.Mutex
+	held map[string]*heldLease
+	// acquiring serializes acquisition attempts per zone within this process
+	acquiring map[string]chan struct{}
+}
+
+// newZoneLeases creates leases in namespace held under identity
+func newZoneLeases(client kubernetes.Interface, namespace, identity string, duration, waitTimeout time.Duration,
+	logger *zap.Logger) *zoneLeases {
+	return &zoneLeases{
+		client:        client,
+		namespace:     namespace,
+		identity:      identity,
+		duration:      duration,
+		waitTimeout:   waitTimeout,
+		retryInterval: time.Second,
+		logger:        logger,
+		held:          map[string]*heldLease{},
+		acquiring:     map[string]chan struct{}{},
+	}
+}
+
+// zoneLeaseName returns the Lease name of zone
+func zoneLeaseName(zone string) string {
+	name := zoneLeasePrefix + strings.TrimSuffix(strings.ToLower(zone), ".")
+	if zone == "" || zone == "." || len(validation.IsDNS1123Subdomain(name)) > 0 {
+		sum := sha256.Sum256([]byte(strings.ToLower(zone)))
+		name = zoneLeasePrefix + hex.EncodeToString(sum[:8])
+	}
+	return name
+}
+
+// Acquire takes ownership of zone, waiting for another instance to release it
+// for at most waitTimeout. The returned function gives up this operation's share.
+func (l *zoneLeases) Acquire(ctx context.Context, zone string) (func(), error) {
+	key := zoneLeaseName(zone)
+	if l.share(key) {
+		return func() { l.release(key) }, nil
+	}
+
+	l.mu.Lock()
+	gate, ok := l.acquiring[key]
+	if !ok {
+		gate = make(chan struct{}, 1)
+		l.acquiring[key] = gate
+	}
+	l.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, l.waitTimeout)
+	defer cancel()
+
+	select {
+	case gate <- struct{}{}:
+	case <-ctx.Done():
+		return nil, fmt.Errorf("timed out waiting for ownership of zone %s: %w", zone, ctx.Err())
+	}
+	defer func() { <-gate }()
+
+	// Another operation of this process may have acquired it while we waited
+	if l.share(key) {
+		return func() { l.release(key) }, nil
+	}
+	if err := l.acquire(ctx, key); err != nil {
+		return nil, fmt.Errorf("zone %s: %w", zone, err)
+	}
+
+	h := &heldLease{refs: 1, stop: make(chan struct{})}
+	l.mu.Lock()
+	l.held[key] = h
+	l.mu.Unlock()
+	go l.renew(key, h.stop)
+	return func() { l.release(key) }, nil
+}
+
+// share joins a lease this instance already holds
+func (l *zoneLeases) share(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if h, ok := l.held[key]; ok {
+		h.refs++
+		return true
+	}
+	return false
+}
+
+// acquire polls the Lease key until this instance holds it or ctx expires
+func (l *zoneLeases) acquire(ctx context.Context, key string) error {
+	leases := l.client.CoordinationV1().Leases(l.namespace)
+	for {
+		now := metav1.NewMicroTime(time.Now())
+		lease, err := leases.Get(ctx, key, metav1.GetOptions{})
+		switch {
+		case apierrors.IsNotFound(err):
+			lease = &coordinationv1.Lease{ObjectMeta: metav1.ObjectMeta{Name: key, Namespace: l.namespace}}
+			l.claim(lease, now)
+			_, err = leases.Create(ctx, lease, metav1.CreateOptions{})
+			if err == nil {
+				return nil
+			}
+			if !apierrors.IsAlreadyExists(err) {
+				return fmt.Errorf("failed to create lease %s: %w", key, err)
+			}
+			continue
+		case err != nil:
+			return fmt.Errorf("failed to get lease %s: %w", key, err)
+		}
+
+		holder := ptr.Deref(lease.Spec.HolderIdentity, "")
+		if holder == "" || holder == l.identity || leaseExpired(lease, now.Time) {
+			if holder != "" && holder != l.identity {
+				l.logger.Warn("Taking over expired zone lease",
+					zap.String("lease", key),
+					zap.String("previous_holder", holder),
+				)
+			}
+			l.claim(lease, now)
+			_, err = leases.Update(ctx, lease, metav1.UpdateOptions{})
+			if err == nil {
+				return nil
+			}
+			if !apierrors.IsConflict(err) {
+				return fmt.Errorf("failed to update lease %s: %w", key, err)
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("owned by %s: %w", holder, ctx.Err())
+		case <-time.After(l.retryInterval):
+		}
+	}
+}
+
+// claim makes this instance the holder of lease
+func (l *zoneLeases) claim(lease *coordinationv1.Lease, now metav1.MicroTime) {
+	if ptr.Deref(lease.Spec.HolderIdentity, "") != l.identity {
+		lease.Spec.AcquireTime = &now
+		lease.Spec.LeaseTransitions = ptr.To(ptr.Deref(lease.Spec.LeaseTransitions, 0) + 1)
+	}
+	lease.Spec.HolderIdentity = ptr.To(l.identity)
+	lease.Spec.LeaseDurationSeconds = ptr.To(int32(l.duration / time.Second))
+	lease.Spec.RenewTime = &now
+}
+
+// leaseExpired reports whether the holder of lease stopped renewing it
+func leaseExpired(lease *coordinationv1.Lease, now time.Time) bool {
+	if lease.Spec.RenewTime == nil || lease.Spec.LeaseDurationSeconds == nil {
+		return true
+	}
+	expiry := lease.Spec.RenewTime.Add(time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second)
+	return now.After(expiry)
+}
+
+// renew keeps the Lease key alive until stop is closed
+func (l *zoneLeases) renew(key string, stop <-chan struct{}) {
+	ticker := time.NewTicker(l.duration / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), l.duration/3)
+		err := l.update(ctx, key, func(lease *coordinationv1.Lease) {
+			now := metav1.NewMicroTime(time.Now())
+			lease.Spec.RenewTime = &now
+		})
+		cancel()
+		if err != nil {
+			l.logger.Warn("Failed to renew zone lease", zap.String("lease", key), zap.Error(err))
+		}
+	}
+}
+
+// release gives up one operation's share and frees the Lease after the last one
+func (l *zoneLeases) release(key string) {
+	l.mu.Lock()
+	h, ok := l.held[key]
+	if !ok {
+		l.mu.Unlock()
+		return
+	}
+	h.refs--
+	if h.refs > 0 {
+		l.mu.Unlock()
+		return
+	}
+	delete(l.held, key)
+	close(h.stop)
+	l.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := l.update(ctx, key, func(lease *coordinationv1.Lease) {
+		lease.Spec.HolderIdentity = nil
+	})
+	if err != nil {
+		l.logger.Warn("Failed to release zone lease, it expires on its own",
+			zap.String("lease", key),
+			zap.Error(err),
+		)
+	}
+}
+
+// update applies mutate to the Lease key if this instance still holds it
+func (l *zoneLeases) update(ctx context.Context, key string, mutate func(lease *coordinationv1.Lease)) error {
+	leases := l.client.CoordinationV1().Leases(l.namespace)
+	lease, err := leases.Get(ctx, key, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if holder := ptr.Deref(lease.Spec.HolderIdentity, ""); holder != l.identity {
+		return fmt.Errorf("lease is held by %q", holder)
+	}
+	mutate(lease)
+	_, err = leases.Update(ctx, lease, metav1.UpdateOptions{})
+	return err
+}
+
+// updateZone runs fn through the worker pool, holding the zone's ownership
+// lease while it runs when leases are enabled
+func (s *DNS01Solver) updateZone(ctx context.Context, zone string, fn func(ctx context.Context) error) error {
+	return s.pool.Do(ctx, zone, func(ctx context.Context) error {
+		if s.leases == nil {
+			return fn(ctx)
+		}
+		release, err := s.leases.Acquire(ctx, zone)
+		if err != nil {
+			return fmt.Errorf("failed to acquire ownership: %w", err)
+		}
+		defer release()
+		return fn(ctx)
+	})
+}
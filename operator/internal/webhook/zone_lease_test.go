@@ -0,0 +1,140 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// newTestLeases returns leases of identity with a short retry interval
+func newTestLeases(client kubernetes.Interface, identity string, duration time.Duration) *zoneLeases {
+	l := newZoneLeases(client, "cert-manager", identity, duration, 300*time.Millisecond, zap.NewNop())
+	l.retryInterval = 20 * time.Millisecond
+	return l
+}
+
+func TestZoneLeaseName(t *testing.T) {
+	if got := zoneLeaseName("Example.COM."); got != "dns01-zone-example.com" {
+		t.Fatalf("zoneLeaseName = %q", got)
+	}
+	for _, zone := range []string{".", "", "under_score.example.com"} {
+		if got := zoneLeaseName(zone); !strings.HasPrefix(got, zoneLeasePrefix) || len(got) != len(zoneLeasePrefix)+16 {
+			t.Fatalf("zoneLeaseName(%q) = %q, want a hashed name", zone, got)
+		}
+	}
+}
+
+func TestZoneLeasesExclusive(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	a := newTestLeases(client, "region-a", 30*time.Second)
+	b := newTestLeases(client, "region-b", 30*time.Second)
+	ctx := context.Background()
+
+	releaseA1, err := a.Acquire(ctx, "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Operations of the same instance share the lease
+	releaseA2, err := a.Acquire(ctx, "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Acquire(ctx, "example.com"); err == nil || !strings.Contains(err.Error(), "region-a") {
+		t.Fatalf("second instance acquired a held zone: %v", err)
+	}
+	// Other zones are independent
+	releaseB, err := b.Acquire(ctx, "example.org")
+	if err != nil {
+		t.Fatal(err)
+	}
+	releaseB()
+
+	releaseA1()
+	if _, err := b.Acquire(ctx, "example.com"); err == nil {
+		t.Fatal("zone released while an operation still held it")
+	}
+	releaseA2()
+
+	releaseB, err = b.Acquire(ctx, "example.com")
+	if err != nil {
+		t.Fatalf("zone not handed over after release: %v", err)
+	}
+	defer releaseB()
+
+	lease, err := client.CoordinationV1().Leases("cert-manager").Get(ctx, "dns01-zone-example.com", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *lease.Spec.HolderIdentity != "region-b" || *lease.Spec.LeaseTransitions != 2 {
+		t.Fatalf("lease spec = %+v", lease.Spec)
+	}
+}
+
+func TestZoneLeasesTakeOverExpired(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	crashed := newTestLeases(client, "crashed", time.Second)
+	ctx := context.Background()
+
+	// Acquire without the renewal loop, like an instance that died holding the lease
+	if err := crashed.acquire(ctx, zoneLeaseName("example.com")); err != nil {
+		t.Fatal(err)
+	}
+
+	b := newTestLeases(client, "survivor", time.Second)
+	b.waitTimeout = 3 * time.Second
+	start := time.Now()
+	release, err := b.Acquire(ctx, "example.com")
+	if err != nil {
+		t.Fatalf("expired lease not taken over: %v", err)
+	}
+	defer release()
+	if time.Since(start) < 500*time.Millisecond {
+		t.Fatal("lease taken over before it expired")
+	}
+}
+
+func TestSolverPresentWaitsForZoneOwner(t *testing.T) {
+	servers := startServers(t, 1)
+	s := newTestSolver(t)
+	s.leases = newTestLeases(s.client, "self", 30*time.Second)
+	other := newTestLeases(s.client, "other", 30*time.Second)
+
+	release, err := other.Acquire(context.Background(), "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.Present(newChallenge(t, serverAddrs(servers), testFQDN, "token"))
+	if err == nil || !strings.Contains(err.Error(), "ownership") {
+		t.Fatalf("Present while another instance owns the zone = %v", err)
+	}
+	if got := servers[0].TXT(testFQDN); len(got) != 0 {
+		t.Fatalf("zone changed while owned by another instance: %v", got)
+	}
+
+	release()
+	if err := s.Present(newChallenge(t, serverAddrs(servers), testFQDN, "token")); err != nil {
+		t.Fatalf("Present after the owner released the zone: %v", err)
+	}
+}
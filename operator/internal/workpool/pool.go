@@ -0,0 +1,223 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package workpool provides a bounded worker pool with per-key fairness that
+// is shared by the webhook solver and the operator controllers, so one busy
+// zone cannot starve DNS work for the others.
+package workpool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/rieset/istio-dns01-bind9/internal/metrics"
+)
+
+// FunctionRating: 76/100
+// - Complexity: MEDIUM
+// - Integrations: 1 (metrics)
+// - External Risks: LOW (in-process scheduling only)
+// - Unit Tests: YES
+// - E2E Tests: NO
+// - Typing: FULL
+// - Critical Issues: NONE
+//
+// Function: Pool
+// Purpose: Bounded worker pool dispatching tasks round-robin across keys (zones)
+
+// errStopped is returned for tasks that did not run before the pool stopped
+var errStopped = errors.New("worker pool is stopped")
+
+// task is one unit of work waiting in a key queue
+type task struct {
+	ctx      context.Context
+	fn       func(ctx context.Context) error
+	result   chan error
+	enqueued time.Time
+}
+
+// Pool runs tasks on a fixed number of workers. Tasks are queued per key and
+// dispatched round-robin across keys, and at most maxPerKey tasks of the same
+// key run at once.
+type Pool struct {
+	mu        sync.Mutex
+	cond      *sync.Cond
+	queues    map[string][]*task
+	inflight  map[string]int
+	keys      []string
+	cursor    int
+	workers   int
+	maxPerKey int
+	stopped   bool
+}
+
+// New creates a pool with the given number of workers and per-key concurrency limit.
+// A maxPerKey of zero or less means a single key may use every worker.
+func New(workers, maxPerKey int) *Pool {
+	if workers < 1 {
+		workers = 1
+	}
+	if maxPerKey < 1 || maxPerKey > workers {
+		maxPerKey = workers
+	}
+	p := &Pool{
+		queues:    make(map[string][]*task),
+		inflight:  make(map[string]int),
+		workers:   workers,
+		maxPerKey: maxPerKey,
+	}
+	p.cond = sync.NewCond(&p.mu)
+	return p
+}
+
+// Start runs the workers until ctx is cancelled. It satisfies the
+// controller-runtime manager.Runnable interface. Once ctx is cancelled the
+// running tasks finish before Start returns, and the queued ones fail.
+func (p *Pool) Start(ctx context.Context) error {
+	var wg sync.WaitGroup
+	for i := 0; i < p.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.runWorker()
+		}()
+	}
+
+	<-ctx.Done()
+	p.mu.Lock()
+	p.stopped = true
+	p.cond.Broadcast()
+	p.mu.Unlock()
+	wg.Wait()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for key, queue := range p.queues {
+		for _, t := range queue {
+			t.result <- errStopped
+			metrics.WorkPoolQueued.Dec()
+		}
+		delete(p.queues, key)
+	}
+	p.keys = nil
+	return nil
+}
+
+// Do queues fn under key and blocks until it has run or ctx is done
+func (p *Pool) Do(ctx context.Context, key string, fn func(ctx context.Context) error) error {
+	t := &task{ctx: ctx, fn: fn, result: make(chan error, 1), enqueued: time.Now()}
+
+	p.mu.Lock()
+	if p.stopped {
+		p.mu.Unlock()
+		return errStopped
+	}
+	if _, ok := p.queues[key]; !ok {
+		p.keys = append(p.keys, key)
+	}
+	p.queues[key] = append(p.queues[key], t)
+	metrics.WorkPoolQueued.Inc()
+	p.cond.Signal()
+	p.mu.Unlock()
+
+	select {
+	case err := <-t.result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// runWorker executes tasks until the pool is stopped
+func (p *Pool) runWorker() {
+	for {
+		key, t, ok := p.next()
+		if !ok {
+			return
+		}
+
+		metrics.WorkPoolWaitSeconds.Observe(time.Since(t.enqueued).Seconds())
+		if err := t.ctx.Err(); err != nil {
+			t.result <- err
+		} else {
+			t.result <- t.fn(t.ctx)
+		}
+
+		p.mu.Lock()
+		p.inflight[key]--
+		p.forgetIfIdle(key)
+		p.cond.Broadcast()
+		p.mu.Unlock()
+	}
+}
+
+// next blocks until a task is eligible to run and returns it, or reports false once stopped
+func (p *Pool) next() (string, *task, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for {
+		if p.stopped {
+			return "", nil, false
+		}
+		if key, t, ok := p.pick(); ok {
+			return key, t, true
+		}
+		p.cond.Wait()
+	}
+}
+
+// pick takes the next task round-robin across keys below their concurrency
+// limit; caller must hold the lock
+func (p *Pool) pick() (string, *task, bool) {
+	for i := 0; i < len(p.keys); i++ {
+		idx := (p.cursor + i) % len(p.keys)
+		key := p.keys[idx]
+		queue := p.queues[key]
+		if len(queue) == 0 || p.inflight[key] >= p.maxPerKey {
+			continue
+		}
+
+		t := queue[0]
+		p.queues[key] = queue[1:]
+		p.inflight[key]++
+		p.cursor = idx + 1
+		metrics.WorkPoolQueued.Dec()
+		return key, t, true
+	}
+	return "", nil, false
+}
+
+// forgetIfIdle drops a key with no queued or running work; caller must hold the lock
+func (p *Pool) forgetIfIdle(key string) {
+	if len(p.queues[key]) > 0 || p.inflight[key] > 0 {
+		return
+	}
+	for i, k := range p.keys {
+		if k != key {
+			continue
+		}
+		p.keys = append(p.keys[:i], p.keys[i+1:]...)
+		if i < p.cursor {
+			p.cursor--
+		}
+		break
+	}
+	delete(p.queues, key)
+	delete(p.inflight, key)
+}
@@ -0,0 +1,211 @@
+/*
+Copyright 2026 Albert.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workpool
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// startPool runs p until the test ends
+func startPool(t *testing.T, p *Pool) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = p.Start(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+// waitQueued waits until n tasks of key are queued in p
+func waitQueued(t *testing.T, p *Pool, key string, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		p.mu.Lock()
+		queued := len(p.queues[key])
+		p.mu.Unlock()
+		if queued == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d tasks of %s queued, want %d", queued, key, n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// block runs a task under key on p that holds its worker until the returned func is called
+func block(t *testing.T, p *Pool, key string) func() {
+	t.Helper()
+	started, release := make(chan struct{}), make(chan struct{})
+	go func() {
+		_ = p.Do(context.Background(), key, func(context.Context) error {
+			close(started)
+			<-release
+			return nil
+		})
+	}()
+	<-started
+	return func() { close(release) }
+}
+
+func TestPoolRoundRobin(t *testing.T) {
+	p := New(1, 1)
+	startPool(t, p)
+	release := block(t, p, "gate")
+
+	var mu sync.Mutex
+	var order []string
+	var wg sync.WaitGroup
+	// A busy key queues all its work before a quiet one
+	for _, key := range []string{"busy", "busy", "busy", "quiet", "quiet"} {
+		p.mu.Lock()
+		n := len(p.queues[key])
+		p.mu.Unlock()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = p.Do(context.Background(), key, func(context.Context) error {
+				mu.Lock()
+				order = append(order, key)
+				mu.Unlock()
+				return nil
+			})
+		}()
+		waitQueued(t, p, key, n+1)
+	}
+	release()
+	wg.Wait()
+
+	if want := []string{"busy", "quiet", "busy", "quiet", "busy"}; !reflect.DeepEqual(order, want) {
+		t.Fatalf("tasks ran in order %v, want %v", order, want)
+	}
+}
+
+func TestPoolMaxPerKey(t *testing.T) {
+	p := New(4, 2)
+	startPool(t, p)
+
+	var mu sync.Mutex
+	running, peak := 0, 0
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = p.Do(context.Background(), "busy", func(context.Context) error {
+				mu.Lock()
+				running++
+				peak = max(peak, running)
+				mu.Unlock()
+				<-release
+				mu.Lock()
+				running--
+				mu.Unlock()
+				return nil
+			})
+		}()
+	}
+	waitQueued(t, p, "busy", 2)
+
+	// The workers the busy key may not take still serve other keys
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := p.Do(ctx, "quiet", func(context.Context) error { return nil }); err != nil {
+		t.Fatalf("task of another key did not run while the busy key was at its limit: %v", err)
+	}
+	close(release)
+	wg.Wait()
+	if peak != 2 {
+		t.Fatalf("%d tasks of one key ran at once, want the limit of 2", peak)
+	}
+}
+
+func TestPoolCancelWhileQueued(t *testing.T) {
+	p := New(1, 1)
+	startPool(t, p)
+	release := block(t, p, "gate")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ran := make(chan struct{}, 1)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- p.Do(ctx, "zone", func(context.Context) error {
+			ran <- struct{}{}
+			return nil
+		})
+	}()
+	waitQueued(t, p, "zone", 1)
+	cancel()
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Fatalf("Do of a cancelled task = %v, want context.Canceled", err)
+	}
+
+	release()
+	// The worker skips the cancelled task once it reaches it
+	if err := p.Do(context.Background(), "zone", func(context.Context) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-ran:
+		t.Fatal("a task cancelled while queued ran")
+	default:
+	}
+}
+
+func TestPoolShutdownDrains(t *testing.T) {
+	p := New(1, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		_ = p.Start(ctx)
+	}()
+	release := block(t, p, "zone")
+
+	queued := make(chan error, 1)
+	go func() {
+		queued <- p.Do(context.Background(), "zone", func(context.Context) error { return nil })
+	}()
+	waitQueued(t, p, "zone", 1)
+
+	cancel()
+	select {
+	case <-stopped:
+		t.Fatal("Start returned while a task was still running")
+	case <-time.After(50 * time.Millisecond):
+	}
+	release()
+	<-stopped
+	if err := <-queued; !errors.Is(err, errStopped) {
+		t.Fatalf("Do of a task queued at shutdown = %v, want errStopped", err)
+	}
+	if err := p.Do(context.Background(), "zone", func(context.Context) error { return nil }); !errors.Is(err, errStopped) {
+		t.Fatalf("Do after shutdown = %v, want errStopped", err)
+	}
+}